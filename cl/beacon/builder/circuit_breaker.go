@@ -0,0 +1,149 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package builder
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+var (
+	ErrCircuitOpen     = errors.New("builder is skipped after too many relay faults")
+	ErrChainUnhealthy  = errors.New("builder is skipped after too many missed slots in the last epoch")
+	ErrBidBelowMinimum = errors.New("builder bid is below the minimum")
+)
+
+const DefaultHeaderTimeout = time.Second
+
+type CircuitBreakerConfig struct {
+	// MaxMissedSlotsPerEpoch - the builder is skipped if more slots were missed within the last epoch, 0 disables the check
+	MaxMissedSlotsPerEpoch uint64
+	// MaxRelayFaults - the builder is skipped for FaultCooldownEpochs, the faulting one included, after this many
+	// consecutive relay faults (late or invalid headers, failed reveals of the payload), 0 disables the check
+	MaxRelayFaults      uint64
+	FaultCooldownEpochs uint64
+	// MinBid - bids lower than this (in wei) are ignored
+	MinBid *big.Int
+	// LocalValueBoost - percentage added to the value of the local payload when it is compared to the builder bid
+	LocalValueBoost uint64
+	// HeaderTimeout - headers which are not received within this time are considered late
+	HeaderTimeout time.Duration
+}
+
+// CircuitBreaker decides whether the builder can be used for a block, falling back to the local execution node
+// when the chain is unhealthy or the relay misbehaves. A nil CircuitBreaker never trips.
+type CircuitBreaker struct {
+	cfg CircuitBreakerConfig
+
+	mu         sync.Mutex
+	faults     uint64
+	openUntil  uint64 // first epoch at which the builder is used again
+	open       bool
+	lastReason error
+}
+
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	if cfg.HeaderTimeout == 0 {
+		cfg.HeaderTimeout = DefaultHeaderTimeout
+	}
+	if cfg.FaultCooldownEpochs == 0 {
+		cfg.FaultCooldownEpochs = 1
+	}
+	return &CircuitBreaker{cfg: cfg}
+}
+
+// Allow returns an error if the builder must be skipped for a block in the given epoch.
+func (c *CircuitBreaker) Allow(epoch, missedSlots uint64) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.open {
+		if epoch < c.openUntil {
+			return ErrCircuitOpen
+		}
+		log.Info("[mev builder] Circuit breaker is closed", "epoch", epoch)
+		c.open, c.faults = false, 0
+	}
+	if c.cfg.MaxMissedSlotsPerEpoch > 0 && missedSlots > c.cfg.MaxMissedSlotsPerEpoch {
+		return ErrChainUnhealthy
+	}
+	return nil
+}
+
+// OnFault records a relay fault and trips the breaker if there were too many of them in a row.
+func (c *CircuitBreaker) OnFault(epoch uint64, reason error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.faults++
+	c.lastReason = reason
+	if c.cfg.MaxRelayFaults == 0 || c.faults < c.cfg.MaxRelayFaults || c.open {
+		return
+	}
+	c.open = true
+	c.openUntil = epoch + c.cfg.FaultCooldownEpochs
+	log.Warn("[mev builder] Circuit breaker is open, falling back to the local execution node", "faults", c.faults, "untilEpoch", c.openUntil, "lastFault", reason)
+}
+
+// OnSuccess resets the count of consecutive faults.
+func (c *CircuitBreaker) OnSuccess() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.faults = 0
+}
+
+// CheckBid returns an error if the bid is too low to be considered.
+func (c *CircuitBreaker) CheckBid(bid *big.Int) error {
+	if bid == nil || bid.Sign() <= 0 {
+		return ErrBidBelowMinimum
+	}
+	if c != nil && c.cfg.MinBid != nil && bid.Cmp(c.cfg.MinBid) < 0 {
+		return ErrBidBelowMinimum
+	}
+	return nil
+}
+
+// UseBuilder compares the values of the payloads: the local one is used if
+// local_value * (100 + local_value_boost) >= builder_value * builder_boost_factor.
+func (c *CircuitBreaker) UseBuilder(localValue, builderValue *big.Int, boostFactor uint64) bool {
+	localBoost := uint64(100)
+	if c != nil {
+		localBoost += c.cfg.LocalValueBoost
+	}
+	local := new(big.Int).Mul(localValue, new(big.Int).SetUint64(localBoost))
+	builder := new(big.Int).Mul(builderValue, new(big.Int).SetUint64(boostFactor))
+	return local.Cmp(builder) < 0
+}
+
+// HeaderTimeout returns how long to wait for the builder header.
+func (c *CircuitBreaker) HeaderTimeout() time.Duration {
+	if c == nil {
+		return DefaultHeaderTimeout
+	}
+	return c.cfg.HeaderTimeout
+}
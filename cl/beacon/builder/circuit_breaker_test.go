@@ -0,0 +1,76 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package builder
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerMissedSlots(t *testing.T) {
+	c := NewCircuitBreaker(CircuitBreakerConfig{MaxMissedSlotsPerEpoch: 2})
+	require.NoError(t, c.Allow(10, 0))
+	require.NoError(t, c.Allow(10, 2))
+	require.ErrorIs(t, c.Allow(10, 3), ErrChainUnhealthy)
+	// the check is disabled by default
+	require.NoError(t, NewCircuitBreaker(CircuitBreakerConfig{}).Allow(10, 32))
+}
+
+func TestCircuitBreakerRelayFaults(t *testing.T) {
+	c := NewCircuitBreaker(CircuitBreakerConfig{MaxRelayFaults: 2, FaultCooldownEpochs: 2})
+	c.OnFault(10, ErrNoContent)
+	require.NoError(t, c.Allow(10, 0))
+	// a valid header resets the count of consecutive faults
+	c.OnSuccess()
+	c.OnFault(10, ErrNoContent)
+	require.NoError(t, c.Allow(10, 0))
+	c.OnFault(10, ErrNoContent)
+	// skipped for 2 epochs: the faulting one and the next one
+	require.ErrorIs(t, c.Allow(10, 0), ErrCircuitOpen)
+	require.ErrorIs(t, c.Allow(11, 0), ErrCircuitOpen)
+	require.NoError(t, c.Allow(12, 0))
+	// the breaker is closed again and needs new faults to trip
+	c.OnFault(12, ErrNoContent)
+	require.NoError(t, c.Allow(12, 0))
+}
+
+func TestCircuitBreakerBids(t *testing.T) {
+	c := NewCircuitBreaker(CircuitBreakerConfig{MinBid: big.NewInt(100), LocalValueBoost: 10})
+	require.ErrorIs(t, c.CheckBid(nil), ErrBidBelowMinimum)
+	require.ErrorIs(t, c.CheckBid(big.NewInt(99)), ErrBidBelowMinimum)
+	require.NoError(t, c.CheckBid(big.NewInt(100)))
+
+	// local value is boosted by 10%
+	require.False(t, c.UseBuilder(big.NewInt(100), big.NewInt(110), 100))
+	require.True(t, c.UseBuilder(big.NewInt(100), big.NewInt(111), 100))
+	// builder_boost_factor=0 always prefers the local payload
+	require.False(t, c.UseBuilder(big.NewInt(0), big.NewInt(1000), 0))
+}
+
+func TestCircuitBreakerNil(t *testing.T) {
+	var c *CircuitBreaker
+	c.OnFault(1, ErrNoContent)
+	c.OnSuccess()
+	require.NoError(t, c.Allow(1, 32))
+	require.NoError(t, c.CheckBid(big.NewInt(1)))
+	require.True(t, c.UseBuilder(big.NewInt(100), big.NewInt(101), 100))
+	require.False(t, c.UseBuilder(big.NewInt(100), big.NewInt(100), 100))
+	require.Equal(t, time.Second, c.HeaderTimeout())
+}
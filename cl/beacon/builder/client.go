@@ -119,8 +119,12 @@ func (b *builderClient) SubmitBlindedBlocks(ctx context.Context, block *cltypes.
 	var blobsBundle *engine_types.BlobsBundleV1
 	switch resp.Version {
 	case "bellatrix", "capella":
-		eth1Block = &cltypes.Eth1Block{}
-		if err := json.Unmarshal(resp.Data, block); err != nil {
+		version, err := clparams.StringToClVersion(resp.Version)
+		if err != nil {
+			return nil, nil, err
+		}
+		eth1Block = cltypes.NewEth1Block(version, b.beaconConfig)
+		if err := json.Unmarshal(resp.Data, eth1Block); err != nil {
 			return nil, nil, err
 		}
 	case "deneb":
@@ -136,6 +140,8 @@ func (b *builderClient) SubmitBlindedBlocks(ctx context.Context, block *cltypes.
 		}
 		eth1Block = denebResp.ExecutionPayload
 		blobsBundle = denebResp.BlobsBundle
+	default:
+		return nil, nil, fmt.Errorf("unsupported version %s", resp.Version)
 	}
 	return eth1Block, blobsBundle, nil
 }
@@ -0,0 +1,290 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package builder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon/cl/clparams"
+	"github.com/erigontech/erigon/cl/cltypes"
+	"github.com/erigontech/erigon/cl/cltypes/solid"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_types"
+)
+
+// MockRelayFault - misbehaviour of a relay which can be injected into MockRelay
+type MockRelayFault int
+
+const (
+	MockRelayNoFault         MockRelayFault = iota
+	MockRelayUnavailable                    // every endpoint fails
+	MockRelayNoBid                          // no header is served
+	MockRelayWrongVersion                   // headers are served for another fork
+	MockRelayInvalidPayload                 // revealed payload doesn't match the header
+	MockRelayWithheldPayload                // payload is never revealed
+)
+
+// MockRelay - in-process stand-in of a MEV relay implementing the builder API, to be served with httptest.NewServer.
+// It holds a single payload: the bid is derived from it and the payload is revealed on submission of a matching blinded block.
+type MockRelay struct {
+	beaconConfig *clparams.BeaconChainConfig
+	router       chi.Router
+
+	mu            sync.Mutex
+	payload       *cltypes.Eth1Block
+	blobsBundle   *engine_types.BlobsBundleV1
+	value         *big.Int
+	headerDelay   time.Duration
+	fault         MockRelayFault
+	registrations []*cltypes.ValidatorRegistration
+	blindedBlocks []*cltypes.SignedBlindedBeaconBlock
+}
+
+func NewMockRelay(beaconConfig *clparams.BeaconChainConfig) *MockRelay {
+	m := &MockRelay{beaconConfig: beaconConfig}
+	r := chi.NewRouter()
+	r.Route("/eth/v1/builder", func(r chi.Router) {
+		r.Get("/status", m.getStatus)
+		r.Post("/validators", m.postValidators)
+		r.Get("/header/{slot}/{parent_hash}/{pubkey}", m.getHeader)
+		r.Post("/blinded_blocks", m.postBlindedBlocks)
+	})
+	m.router = r
+	return m
+}
+
+func (m *MockRelay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.router.ServeHTTP(w, r)
+}
+
+// SetPayload sets the payload the relay bids with. blobsBundle is only used since deneb.
+func (m *MockRelay) SetPayload(payload *cltypes.Eth1Block, blobsBundle *engine_types.BlobsBundleV1, value *big.Int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payload, m.blobsBundle, m.value = payload, blobsBundle, value
+}
+
+// SetHeaderDelay delays every header response, to emulate late headers.
+func (m *MockRelay) SetHeaderDelay(delay time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.headerDelay = delay
+}
+
+func (m *MockRelay) SetFault(fault MockRelayFault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fault = fault
+}
+
+// Registrations returns the validator registrations received so far.
+func (m *MockRelay) Registrations() []*cltypes.ValidatorRegistration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*cltypes.ValidatorRegistration{}, m.registrations...)
+}
+
+// BlindedBlocks returns the blinded blocks submitted so far.
+func (m *MockRelay) BlindedBlocks() []*cltypes.SignedBlindedBeaconBlock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*cltypes.SignedBlindedBeaconBlock{}, m.blindedBlocks...)
+}
+
+func (m *MockRelay) getStatus(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fault == MockRelayUnavailable {
+		writeMockRelayError(w, http.StatusServiceUnavailable, errors.New("relay is unavailable"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *MockRelay) postValidators(w http.ResponseWriter, r *http.Request) {
+	var registrations []*cltypes.ValidatorRegistration
+	if err := json.NewDecoder(r.Body).Decode(&registrations); err != nil {
+		writeMockRelayError(w, http.StatusBadRequest, err)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fault == MockRelayUnavailable {
+		writeMockRelayError(w, http.StatusServiceUnavailable, errors.New("relay is unavailable"))
+		return
+	}
+	m.registrations = append(m.registrations, registrations...)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *MockRelay) getHeader(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	delay := m.headerDelay
+	m.mu.Unlock()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case m.fault == MockRelayUnavailable:
+		writeMockRelayError(w, http.StatusServiceUnavailable, errors.New("relay is unavailable"))
+		return
+	case m.fault == MockRelayNoBid, m.payload == nil:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	parentHash := common.HexToHash(chi.URLParam(r, "parent_hash"))
+	if parentHash != m.payload.ParentHash {
+		// relays have no bid for unknown parents
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	header, err := m.payload.PayloadHeader()
+	if err != nil {
+		writeMockRelayError(w, http.StatusInternalServerError, err)
+		return
+	}
+	commitments := solid.NewStaticListSSZ[*cltypes.KZGCommitment](cltypes.MaxBlobsCommittmentsPerBlock, 48)
+	if m.blobsBundle != nil {
+		for _, c := range m.blobsBundle.Commitments {
+			commitment := cltypes.KZGCommitment{}
+			copy(commitment[:], c)
+			commitments.Append(&commitment)
+		}
+	}
+	version := m.payload.Version()
+	if m.fault == MockRelayWrongVersion {
+		version--
+	}
+	value := m.value
+	if value == nil {
+		value = new(big.Int)
+	}
+	writeMockRelayJSON(w, &ExecutionHeader{
+		Version: version.String(),
+		Data: ExecutionHeaderData{
+			Message: ExecutionHeaderMessage{
+				Header:             header,
+				BlobKzgCommitments: commitments,
+				Value:              value.String(),
+			},
+		},
+	})
+}
+
+func (m *MockRelay) postBlindedBlocks(w http.ResponseWriter, r *http.Request) {
+	version, err := clparams.StringToClVersion(r.Header.Get("Eth-Consensus-Version"))
+	if err != nil {
+		writeMockRelayError(w, http.StatusBadRequest, err)
+		return
+	}
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeMockRelayError(w, http.StatusBadRequest, err)
+		return
+	}
+	block := cltypes.NewSignedBlindedBeaconBlock(m.beaconConfig)
+	block.Block.SetVersion(version)
+	if err := json.Unmarshal(b, block); err != nil {
+		writeMockRelayError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case m.fault == MockRelayUnavailable:
+		writeMockRelayError(w, http.StatusServiceUnavailable, errors.New("relay is unavailable"))
+		return
+	case m.payload == nil || block.Block.Body.ExecutionPayload.BlockHash != m.payload.BlockHash:
+		writeMockRelayError(w, http.StatusBadRequest, errors.New("unknown payload"))
+		return
+	}
+	m.blindedBlocks = append(m.blindedBlocks, block)
+	if m.fault == MockRelayWithheldPayload {
+		writeMockRelayError(w, http.StatusInternalServerError, errors.New("payload is withheld"))
+		return
+	}
+
+	payload := m.payload
+	if m.fault == MockRelayInvalidPayload {
+		if payload, err = m.invalidPayload(); err != nil {
+			writeMockRelayError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	var data any = payload
+	if payload.Version() >= clparams.DenebVersion {
+		blobsBundle := m.blobsBundle
+		if blobsBundle == nil {
+			blobsBundle = &engine_types.BlobsBundleV1{}
+		}
+		data = struct {
+			ExecutionPayload *cltypes.Eth1Block          `json:"execution_payload"`
+			BlobsBundle      *engine_types.BlobsBundleV1 `json:"blobs_bundle"`
+		}{payload, blobsBundle}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		writeMockRelayError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeMockRelayJSON(w, &BlindedBlockResponse{Version: payload.Version().String(), Data: encoded})
+}
+
+// invalidPayload returns a copy of the payload with a different block hash
+func (m *MockRelay) invalidPayload() (*cltypes.Eth1Block, error) {
+	encoded, err := json.Marshal(m.payload)
+	if err != nil {
+		return nil, err
+	}
+	payload := cltypes.NewEth1Block(m.payload.Version(), m.beaconConfig)
+	if err := json.Unmarshal(encoded, payload); err != nil {
+		return nil, err
+	}
+	payload.BlockHash[0] ^= 0xff
+	return payload, nil
+}
+
+func writeMockRelayJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeMockRelayError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `{"code":%d,"message":%q}`, code, err.Error())
+}
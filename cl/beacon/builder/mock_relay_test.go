@@ -0,0 +1,123 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package builder
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon/cl/clparams"
+	"github.com/erigontech/erigon/cl/cltypes"
+	"github.com/erigontech/erigon/cl/cltypes/solid"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_types"
+)
+
+func newMockRelayPayload(cfg *clparams.BeaconChainConfig) (*cltypes.Eth1Block, *engine_types.BlobsBundleV1) {
+	payload := cltypes.NewEth1Block(clparams.DenebVersion, cfg)
+	payload.ParentHash = common.HexToHash("0x01")
+	payload.StateRoot = common.HexToHash("0x02")
+	payload.BlockHash = common.HexToHash("0x03")
+	payload.BlockNumber = 1
+	payload.Extra = solid.NewExtraData()
+	payload.Transactions = solid.NewTransactionsSSZFromTransactions([][]byte{{0x02, 0x01}})
+	payload.Withdrawals = solid.NewStaticListSSZ[*cltypes.Withdrawal](int(cfg.MaxWithdrawalsPerPayload), 44)
+	blobsBundle := &engine_types.BlobsBundleV1{
+		Commitments: []hexutility.Bytes{make([]byte, 48)},
+		Proofs:      []hexutility.Bytes{make([]byte, 48)},
+		Blobs:       []hexutility.Bytes{make([]byte, 4096*32)},
+	}
+	return payload, blobsBundle
+}
+
+func TestMockRelay(t *testing.T) {
+	ctx := context.Background()
+	cfg := &clparams.MainnetBeaconConfig
+	relay := NewMockRelay(cfg)
+	server := httptest.NewServer(relay)
+	defer server.Close()
+	client := NewBlockBuilderClient(server.URL, cfg)
+
+	payload, blobsBundle := newMockRelayPayload(cfg)
+	relay.SetPayload(payload, blobsBundle, big.NewInt(1000))
+
+	// registrations
+	registration := &cltypes.ValidatorRegistration{
+		Message: cltypes.ValidatorRegistrationMessage{GasLimit: "30000000", Timestamp: "1"},
+	}
+	require.NoError(t, client.RegisterValidator(ctx, []*cltypes.ValidatorRegistration{registration}))
+	require.Len(t, relay.Registrations(), 1)
+
+	// no bid for unknown parents
+	_, err := client.GetHeader(ctx, 1, common.HexToHash("0xff"), common.Bytes48{})
+	require.ErrorIs(t, err, ErrNoContent)
+
+	header, err := client.GetHeader(ctx, 1, payload.ParentHash, common.Bytes48{})
+	require.NoError(t, err)
+	require.Equal(t, "deneb", header.Version)
+	require.Equal(t, big.NewInt(1000), header.BlockValue())
+	require.Equal(t, payload.BlockHash, header.Data.Message.Header.BlockHash)
+	require.Equal(t, 1, header.Data.Message.BlobKzgCommitments.Len())
+
+	// reveal the payload
+	block := cltypes.NewSignedBlindedBeaconBlock(cfg)
+	block.Block.SetVersion(clparams.DenebVersion)
+	header.Data.Message.Header.SetVersion(clparams.DenebVersion)
+	block.Block.Body.SetHeader(header.Data.Message.Header).SetBlobKzgCommitments(header.Data.Message.BlobKzgCommitments)
+	revealed, revealedBlobs, err := client.SubmitBlindedBlocks(ctx, block)
+	require.NoError(t, err)
+	require.Equal(t, payload.BlockHash, revealed.BlockHash)
+	require.Equal(t, payload.StateRoot, revealed.StateRoot)
+	require.Len(t, revealedBlobs.Blobs, 1)
+	require.Len(t, relay.BlindedBlocks(), 1)
+
+	// faults
+	relay.SetFault(MockRelayInvalidPayload)
+	revealed, _, err = client.SubmitBlindedBlocks(ctx, block)
+	require.NoError(t, err)
+	require.NotEqual(t, payload.BlockHash, revealed.BlockHash)
+
+	relay.SetFault(MockRelayWithheldPayload)
+	_, _, err = client.SubmitBlindedBlocks(ctx, block)
+	require.Error(t, err)
+
+	relay.SetFault(MockRelayWrongVersion)
+	header, err = client.GetHeader(ctx, 1, payload.ParentHash, common.Bytes48{})
+	require.NoError(t, err)
+	require.Equal(t, "capella", header.Version)
+
+	relay.SetFault(MockRelayNoBid)
+	_, err = client.GetHeader(ctx, 1, payload.ParentHash, common.Bytes48{})
+	require.ErrorIs(t, err, ErrNoContent)
+
+	relay.SetFault(MockRelayUnavailable)
+	require.Error(t, client.GetStatus(ctx))
+
+	// late headers
+	relay.SetFault(MockRelayNoFault)
+	relay.SetHeaderDelay(time.Second)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = client.GetHeader(timeoutCtx, 1, payload.ParentHash, common.Bytes48{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
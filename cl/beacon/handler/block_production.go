@@ -148,7 +148,7 @@ func (a *ApiHandler) GetEthV3ValidatorBlock(
 	}
 
 	// builder boost factor controls block choice between local execution node or builder
-	var builderBoostFactor uint64
+	builderBoostFactor := uint64(100)
 	builderBoostFactorStr := r.URL.Query().Get("builder_boost_factor")
 	if builderBoostFactorStr != "" {
 		builderBoostFactor, err = strconv.ParseUint(builderBoostFactorStr, 10, 64)
@@ -299,7 +299,12 @@ func (a *ApiHandler) produceBlock(
 		defer wg.Done()
 		if a.routerCfg.Builder && a.builderClient != nil {
 			builderHeader, builderErr = a.getBuilderPayload(ctx, baseBlock, baseState, targetSlot)
-			if builderErr != nil && builderErr != errBuilderNotEnabled {
+			switch {
+			case builderErr == nil, builderErr == errBuilderNotEnabled:
+			case errors.Is(builderErr, builder.ErrCircuitOpen), errors.Is(builderErr, builder.ErrChainUnhealthy),
+				errors.Is(builderErr, builder.ErrBidBelowMinimum), errors.Is(builderErr, builder.ErrNoContent):
+				log.Info("Skipping builder payload", "reason", builderErr, "slot", targetSlot)
+			default:
 				log.Warn("Failed to get builder payload", "err", builderErr)
 			}
 		}
@@ -341,10 +346,10 @@ func (a *ApiHandler) produceBlock(
 	// determine whether to use local execution node or builder
 	// if exec_node_payload_value >= builder_boost_factor * (builder_payload_value // 100), then return a full (unblinded) block containing the execution node payload.
 	// otherwise, return a blinded block containing the builder payload header.
+	// the value of the local payload may be boosted further by the circuit breaker configuration.
 	execValue := new(big.Int).SetUint64(localExecValue)
 	builderValue := builderHeader.BlockValue()
-	boostFactorBig := new(big.Int).SetUint64(boostFactor)
-	useLocalExec := new(big.Int).Mul(execValue, big.NewInt(100)).Cmp(new(big.Int).Mul(builderValue, boostFactorBig)) >= 0
+	useLocalExec := !a.builderCircuitBreaker.UseBuilder(execValue, builderValue, boostFactor)
 	log.Info("Check mev bid", "useLocalExec", useLocalExec, "execValue", execValue, "builderValue", builderValue, "boostFactor", boostFactor, "targetSlot", targetSlot)
 
 	if useLocalExec {
@@ -394,40 +399,103 @@ func (a *ApiHandler) getBuilderPayload(
 	if err != nil {
 		return nil, err
 	}
+	// skip the builder if the chain is unhealthy or the relay misbehaved recently
+	epoch := targetSlot / a.beaconChainCfg.SlotsPerEpoch
+	missedSlots, err := missedSlotsInLastEpoch(baseState, targetSlot, a.beaconChainCfg.SlotsPerEpoch)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.builderCircuitBreaker.Allow(epoch, missedSlots); err != nil {
+		return nil, err
+	}
+
 	// get the parent hash of base execution block
 	parentHash := baseBlock.Body.ExecutionPayload.BlockHash
-	header, err := a.builderClient.GetHeader(ctx, int64(targetSlot), parentHash, pubKey)
-	if err != nil {
+	headerCtx, cancel := context.WithTimeout(ctx, a.builderCircuitBreaker.HeaderTimeout())
+	defer cancel()
+	header, err := a.builderClient.GetHeader(headerCtx, int64(targetSlot), parentHash, pubKey)
+	if errors.Is(err, builder.ErrNoContent) {
+		// the relay has no bid for this slot
+		return nil, err
+	} else if err != nil {
+		if headerCtx.Err() != nil {
+			err = fmt.Errorf("late builder header: %w", err)
+		}
+		a.builderCircuitBreaker.OnFault(epoch, err)
 		return nil, err
 	} else if header == nil {
 		return nil, errors.New("no error but nil header")
 	}
+	if err := checkBuilderHeader(header, baseState.Version(), parentHash); err != nil {
+		a.builderCircuitBreaker.OnFault(epoch, err)
+		return nil, err
+	}
+	a.builderCircuitBreaker.OnSuccess()
+
+	if err := a.builderCircuitBreaker.CheckBid(header.BlockValue()); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
 
+func checkBuilderHeader(header *builder.ExecutionHeader, version clparams.StateVersion, parentHash common.Hash) error {
 	// check the version
-	curVersion := baseState.Version().String()
+	curVersion := version.String()
 	if !strings.EqualFold(header.Version, curVersion) {
-		return nil, fmt.Errorf("invalid version %s, expected %s", header.Version, curVersion)
+		return fmt.Errorf("invalid version %s, expected %s", header.Version, curVersion)
 	}
-	if ethHeader := header.Data.Message.Header; ethHeader != nil {
-		ethHeader.SetVersion(baseState.Version())
+	ethHeader := header.Data.Message.Header
+	if ethHeader == nil {
+		return errors.New("nil execution payload header")
+	}
+	ethHeader.SetVersion(version)
+	if ethHeader.ParentHash != parentHash {
+		return fmt.Errorf("invalid parent hash %x, expected %x", ethHeader.ParentHash, parentHash)
 	}
 	// check kzg commitments
-	if header != nil && baseState.Version() >= clparams.DenebVersion {
+	if version >= clparams.DenebVersion {
+		if header.Data.Message.BlobKzgCommitments == nil {
+			return errors.New("nil blob kzg commitments")
+		}
 		if header.Data.Message.BlobKzgCommitments.Len() >= cltypes.MaxBlobsCommittmentsPerBlock {
-			return nil, fmt.Errorf("too many blob kzg commitments: %d", header.Data.Message.BlobKzgCommitments.Len())
+			return fmt.Errorf("too many blob kzg commitments: %d", header.Data.Message.BlobKzgCommitments.Len())
 		}
 		for i := 0; i < header.Data.Message.BlobKzgCommitments.Len(); i++ {
 			c := header.Data.Message.BlobKzgCommitments.Get(i)
 			if c == nil {
-				return nil, errors.New("nil blob kzg commitment")
+				return errors.New("nil blob kzg commitment")
 			}
 			if len(c) != length.Bytes48 {
-				return nil, errors.New("invalid blob kzg commitment length")
+				return errors.New("invalid blob kzg commitment length")
 			}
 		}
 	}
+	return nil
+}
 
-	return header, nil
+// missedSlotsInLastEpoch counts the slots without a block within the epoch preceding targetSlot.
+// The state must be already processed up to targetSlot.
+func missedSlotsInLastEpoch(s *state.CachingBeaconState, targetSlot, slotsPerEpoch uint64) (uint64, error) {
+	from := uint64(1)
+	if targetSlot > slotsPerEpoch {
+		from = targetSlot - slotsPerEpoch
+	}
+	var missed uint64
+	for slot := from; slot < targetSlot; slot++ {
+		root, err := s.GetBlockRootAtSlot(slot)
+		if err != nil {
+			return 0, err
+		}
+		prevRoot, err := s.GetBlockRootAtSlot(slot - 1)
+		if err != nil {
+			return 0, err
+		}
+		// the root of an empty slot is the root of the previous block
+		if root == prevRoot {
+			missed++
+		}
+	}
+	return missed, nil
 }
 
 func (a *ApiHandler) produceBeaconBody(
@@ -823,12 +891,20 @@ func (a *ApiHandler) publishBlindedBlocks(w http.ResponseWriter, r *http.Request
 		}
 	}
 	// submit and unblind the signedBlindedBlock
+	epoch := signedBlindedBlock.Block.Slot / a.beaconChainCfg.SlotsPerEpoch
 	blockPayload, blobsBundle, err := a.builderClient.SubmitBlindedBlocks(r.Context(), signedBlindedBlock)
 	if err != nil {
+		a.builderCircuitBreaker.OnFault(epoch, fmt.Errorf("failed to reveal payload: %w", err))
+		return nil, beaconhttp.NewEndpointError(http.StatusInternalServerError, err)
+	}
+	if blindedHash := signedBlindedBlock.Block.Body.ExecutionPayload.BlockHash; blockPayload.BlockHash != blindedHash {
+		err := fmt.Errorf("block hash mismatch: %s != %s", blindedHash, blockPayload.BlockHash)
+		a.builderCircuitBreaker.OnFault(epoch, err)
 		return nil, beaconhttp.NewEndpointError(http.StatusInternalServerError, err)
 	}
 	signedBlock, err := signedBlindedBlock.Unblind(blockPayload)
 	if err != nil {
+		a.builderCircuitBreaker.OnFault(epoch, err)
 		return nil, beaconhttp.NewEndpointError(http.StatusInternalServerError, err)
 	}
 
@@ -0,0 +1,74 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cl/clparams"
+	"github.com/erigontech/erigon/cl/persistence/deposit_tree"
+)
+
+func TestGetDepositSnapshot(t *testing.T) {
+	db, _, _, _, _, handler, _, _, _, _ := setupTestingHandler(t, clparams.Phase0Version, log.Root())
+
+	server := httptest.NewServer(handler.mux)
+	defer server.Close()
+
+	getSnapshot := func() (*http.Response, error) {
+		return http.Get(server.URL + "/eth/v1/beacon/deposit_snapshot")
+	}
+	resp, err := getSnapshot()
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	tree := &deposit_tree.Tree{}
+	for i := byte(0); i < 3; i++ {
+		require.NoError(t, tree.Push(libcommon.Hash{i + 1}))
+	}
+	expected := &deposit_tree.Snapshot{
+		Finalized:            tree.Finalized(),
+		DepositRoot:          tree.DepositRoot(),
+		DepositCount:         tree.Count(),
+		ExecutionBlockHash:   libcommon.Hash{0xaa},
+		ExecutionBlockHeight: 100,
+	}
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	handler.depositTree = deposit_tree.NewStore()
+	require.NoError(t, handler.depositTree.Initialize(tx, expected, 0))
+
+	resp, err = getSnapshot()
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body struct {
+		Data *deposit_tree.Snapshot `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, expected, body.Data)
+}
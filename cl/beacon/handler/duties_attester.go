@@ -24,7 +24,9 @@ import (
 	"strconv"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon/cl/beacon/beaconhttp"
+	"github.com/erigontech/erigon/cl/persistence/beacon_indicies"
 	state_accessors "github.com/erigontech/erigon/cl/persistence/state"
 	"github.com/erigontech/erigon/cl/phase1/core/state"
 )
@@ -39,22 +41,22 @@ type attesterDutyResponse struct {
 	Slot                    uint64            `json:"slot,string"`
 }
 
-func (a *ApiHandler) getDependentRoot(s *state.CachingBeaconState, epoch uint64) libcommon.Hash {
-	dependentRootSlot := ((epoch - 1) * a.beaconChainCfg.SlotsPerEpoch) - 3
-	maxIterations := 2048
-	for i := 0; i < maxIterations; i++ {
-		if dependentRootSlot > epoch*a.beaconChainCfg.SlotsPerEpoch {
-			return libcommon.Hash{}
-		}
-
-		dependentRoot, err := s.GetBlockRootAtSlot(dependentRootSlot)
+// getDependentRoot returns the root of the block at dependentRootSlot (or the latest one before it):
+// the head state is used for recent slots and the canonical chain in the db for older ones.
+func (a *ApiHandler) getDependentRoot(tx kv.Tx, s *state.CachingBeaconState, dependentRootSlot uint64) (libcommon.Hash, error) {
+	if dependentRootSlot+a.beaconChainCfg.SlotsPerHistoricalRoot >= s.Slot() {
+		return state.GetBlockRootAtSlotOrLatest(s, dependentRootSlot)
+	}
+	// skip the empty slots
+	for slot := dependentRootSlot; ; slot-- {
+		root, err := beacon_indicies.ReadCanonicalBlockRoot(tx, slot)
 		if err != nil {
-			dependentRootSlot--
-			continue
+			return libcommon.Hash{}, err
+		}
+		if root != (libcommon.Hash{}) || slot == 0 {
+			return root, nil
 		}
-		return dependentRoot
 	}
-	return libcommon.Hash{}
 }
 
 func (a *ApiHandler) getAttesterDuties(w http.ResponseWriter, r *http.Request) (*beaconhttp.BeaconResponse, error) {
@@ -66,12 +68,22 @@ func (a *ApiHandler) getAttesterDuties(w http.ResponseWriter, r *http.Request) (
 	if s == nil {
 		return nil, beaconhttp.NewEndpointError(http.StatusServiceUnavailable, errors.New("node is syncing"))
 	}
-	dependentRoot := a.getDependentRoot(s, epoch)
 
 	var idxsStr []string
 	if err := json.NewDecoder(r.Body).Decode(&idxsStr); err != nil {
 		return nil, beaconhttp.NewEndpointError(http.StatusBadRequest, fmt.Errorf("could not decode request body: %w. request body is required", err))
 	}
+
+	tx, err := a.indiciesDB.BeginRo(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	dependentRoot, err := a.getDependentRoot(tx, s, state.AttesterDutiesDependentRootSlot(a.beaconChainCfg, epoch))
+	if err != nil {
+		return nil, err
+	}
 	if len(idxsStr) == 0 {
 		return newBeaconResponse([]string{}).WithOptimistic(a.forkchoiceStore.IsHeadOptimistic()).With("dependent_root", dependentRoot), nil
 	}
@@ -89,12 +101,6 @@ func (a *ApiHandler) getAttesterDuties(w http.ResponseWriter, r *http.Request) (
 		idxSet[int(idx)] = struct{}{}
 	}
 
-	tx, err := a.indiciesDB.BeginRo(r.Context())
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
-
 	resp := []attesterDutyResponse{}
 
 	// get the duties
@@ -26,6 +26,7 @@ import (
 	"github.com/erigontech/erigon/cl/beacon/beaconhttp"
 	"github.com/erigontech/erigon/cl/persistence/base_encoding"
 	state_accessors "github.com/erigontech/erigon/cl/persistence/state"
+	state2 "github.com/erigontech/erigon/cl/phase1/core/state"
 	shuffling2 "github.com/erigontech/erigon/cl/phase1/core/state/shuffling"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
@@ -47,13 +48,16 @@ func (a *ApiHandler) getDutiesProposer(w http.ResponseWriter, r *http.Request) (
 	if s == nil {
 		return nil, beaconhttp.NewEndpointError(http.StatusServiceUnavailable, errors.New("node is syncing"))
 	}
-	dependentRoot := a.getDependentRoot(s, epoch)
+	tx, err := a.indiciesDB.BeginRo(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	dependentRoot, err := a.getDependentRoot(tx, s, state2.ProposerDutiesDependentRootSlot(a.beaconChainCfg, epoch))
+	if err != nil {
+		return nil, err
+	}
 	if epoch < a.forkchoiceStore.FinalizedCheckpoint().Epoch() {
-		tx, err := a.indiciesDB.BeginRo(r.Context())
-		if err != nil {
-			return nil, err
-		}
-		defer tx.Rollback()
 		key := base_encoding.Encode64ToBytes4(epoch)
 		indiciesBytes, err := tx.GetOne(kv.Proposers, key)
 		if err != nil {
@@ -169,7 +169,9 @@ func setupTestingHandler(t *testing.T, v clparams.StateVersion, logger log.Logge
 		blsToExecutionChangeService,
 		proposerSlashingService,
 		nil,
+		nil,
 		mockValidatorMonitor,
+		nil,
 	) // TODO: add tests
 	h.Init()
 	return
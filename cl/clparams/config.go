@@ -44,8 +44,26 @@ type CaplinConfig struct {
 	// CaplinMeVRelayUrl is optional and is used to connect to the external builder service.
 	// If it's set, the node will start in builder mode
 	MevRelayUrl string
+	// Circuit breaker of the builder: block production falls back to the local execution node
+	// when the chain is unhealthy or the relay misbehaves
+	MevMaxMissedSlots      uint64        // skip the builder if more slots were missed within the last epoch, 0 disables the check
+	MevMaxRelayFaults      uint64        // skip the builder after this many consecutive relay faults, 0 disables the check
+	MevFaultCooldownEpochs uint64        // how many epochs the builder is skipped after too many relay faults, the faulting one included
+	MevMinBidGwei          uint64        // bids lower than this are ignored
+	MevLocalValueBoost     uint64        // percentage added to the value of the local payload when it is compared to the bid
+	MevHeaderTimeout       time.Duration // headers which are not received within this time are ignored
 	// EnableValidatorMonitor is used to enable the validator monitor metrics and corresponding logs
 	EnableValidatorMonitor bool
+	// ValidatorSignerUrl is optional and is used to connect to a Web3Signer-compatible remote signer.
+	// If it's set, the built-in validator client performs the duties of the validators of the signer
+	ValidatorSignerUrl     string
+	ValidatorSignerTimeout time.Duration
+	ValidatorPubKeys       []libcommon.Bytes48 // validators to run, all the keys of the signer if empty
+	ValidatorGraffiti      libcommon.Hash
+}
+
+func (c CaplinConfig) ValidatorClientEnabled() bool {
+	return c.ValidatorSignerUrl != ""
 }
 
 func (c CaplinConfig) RelayUrlExist() bool {
@@ -0,0 +1,220 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package deposit_tree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/cl/cltypes"
+)
+
+var (
+	snapshotKey = []byte("snapshot")
+	pendingKey  = []byte("pending")
+	progressKey = []byte("progress")
+)
+
+// ErrInconsistent is returned when the deposits of the blocks do not match the beacon state, the store needs to be reset.
+var ErrInconsistent = errors.New("deposit tree is inconsistent with the beacon state")
+
+// Store keeps the finalized deposit tree (EIP-4881) up to date with the finalized beacon blocks.
+// Deposits which are included in finalized blocks, but are not yet covered by the finalized Eth1Data, are kept as pending.
+type Store struct {
+	mu sync.RWMutex
+
+	tree     *Tree // nil if the tree is unknown
+	pending  []libcommon.Hash
+	progress uint64 // last slot whose deposits are accounted for
+
+	executionBlockHash   libcommon.Hash
+	executionBlockHeight uint64
+}
+
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Load reads the persisted tree, if any.
+func (s *Store) Load(tx kv.Tx) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, err := tx.GetOne(kv.DepositTree, snapshotKey)
+	if err != nil {
+		return err
+	}
+	if len(v) == 0 {
+		s.tree = nil
+		return nil
+	}
+	if len(v) < 48 || (len(v)-48)%32 != 0 {
+		return fmt.Errorf("unexpected deposit snapshot length: %d", len(v))
+	}
+	finalized := make([]libcommon.Hash, (len(v)-48)/32)
+	for i := range finalized {
+		copy(finalized[i][:], v[48+i*32:])
+	}
+	tree, err := NewTreeFromFinalized(finalized, binary.BigEndian.Uint64(v))
+	if err != nil {
+		return err
+	}
+	pending, err := tx.GetOne(kv.DepositTree, pendingKey)
+	if err != nil {
+		return err
+	}
+	progress, err := tx.GetOne(kv.DepositTree, progressKey)
+	if err != nil {
+		return err
+	}
+	if len(pending)%32 != 0 || len(progress) != 8 {
+		return errors.New("deposit tree is corrupted")
+	}
+	s.tree = tree
+	s.executionBlockHash = libcommon.BytesToHash(v[8:40])
+	s.executionBlockHeight = binary.BigEndian.Uint64(v[40:48])
+	s.pending = make([]libcommon.Hash, len(pending)/32)
+	for i := range s.pending {
+		copy(s.pending[i][:], pending[i*32:])
+	}
+	s.progress = binary.BigEndian.Uint64(progress)
+	return nil
+}
+
+// Initialize sets the tree from a snapshot which accounts for all the deposits included in blocks up to the given slot.
+func (s *Store) Initialize(tx kv.RwTx, snapshot *Snapshot, slot uint64) error {
+	tree, err := snapshot.Tree()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tree, s.pending, s.progress = tree, nil, slot
+	s.executionBlockHash, s.executionBlockHeight = snapshot.ExecutionBlockHash, snapshot.ExecutionBlockHeight
+	return s.write(tx)
+}
+
+// Reset forgets the tree, it can be only initialized again.
+func (s *Store) Reset(tx kv.RwTx) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tree, s.pending, s.progress = nil, nil, 0
+	s.executionBlockHash, s.executionBlockHeight = libcommon.Hash{}, 0
+	for _, k := range [][]byte{snapshotKey, pendingKey, progressKey} {
+		if err := tx.Delete(kv.DepositTree, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Initialized returns whether the tree is known.
+func (s *Store) Initialized() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree != nil
+}
+
+// Progress returns the last slot whose deposits are accounted for.
+func (s *Store) Progress() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.progress
+}
+
+// OnFinalized accounts for the deposits of the blocks up to the finalized slot: leaves are the hash tree roots of their
+// DepositData in order, depositIndex and eth1Data are taken from the finalized state. The tree is finalized up to
+// eth1Data.DepositCount, blockHeight resolves the number of the execution block of eth1Data.
+func (s *Store) OnFinalized(tx kv.RwTx, slot uint64, leaves []libcommon.Hash, depositIndex uint64, eth1Data *cltypes.Eth1Data, blockHeight func(libcommon.Hash) (uint64, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tree == nil || slot <= s.progress {
+		return nil
+	}
+	pending := append(append([]libcommon.Hash{}, s.pending...), leaves...)
+	if s.tree.Count()+uint64(len(pending)) != depositIndex {
+		return fmt.Errorf("%w: %d deposits are known, eth1_deposit_index is %d", ErrInconsistent, s.tree.Count()+uint64(len(pending)), depositIndex)
+	}
+	tree := s.tree
+	executionBlockHash, executionBlockHeight := s.executionBlockHash, s.executionBlockHeight
+	if eth1Data.DepositCount >= tree.Count() && eth1Data.DepositCount <= depositIndex && eth1Data.BlockHash != executionBlockHash {
+		n := eth1Data.DepositCount - tree.Count()
+		tree = tree.copy()
+		for _, leaf := range pending[:n] {
+			if err := tree.Push(leaf); err != nil {
+				return err
+			}
+		}
+		if root := tree.DepositRoot(); root != eth1Data.Root {
+			return fmt.Errorf("%w: deposit root is %x, expected %x", ErrInconsistent, root, eth1Data.Root)
+		}
+		height, err := blockHeight(eth1Data.BlockHash)
+		if err != nil {
+			return fmt.Errorf("failed to get execution block height: %w", err)
+		}
+		pending = pending[n:]
+		executionBlockHash, executionBlockHeight = eth1Data.BlockHash, height
+	}
+	s.tree, s.pending, s.progress = tree, pending, slot
+	s.executionBlockHash, s.executionBlockHeight = executionBlockHash, executionBlockHeight
+	return s.write(tx)
+}
+
+// Snapshot returns the snapshot of the finalized tree, false if it is unknown.
+func (s *Store) Snapshot() (*Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// the snapshot must point to an execution block
+	if s.tree == nil || s.executionBlockHash == (libcommon.Hash{}) {
+		return nil, false
+	}
+	return &Snapshot{
+		Finalized:            s.tree.Finalized(),
+		DepositRoot:          s.tree.DepositRoot(),
+		DepositCount:         s.tree.Count(),
+		ExecutionBlockHash:   s.executionBlockHash,
+		ExecutionBlockHeight: s.executionBlockHeight,
+	}, true
+}
+
+func (s *Store) write(tx kv.RwTx) error {
+	finalized := s.tree.Finalized()
+	v := make([]byte, 48, 48+len(finalized)*32)
+	binary.BigEndian.PutUint64(v, s.tree.Count())
+	copy(v[8:], s.executionBlockHash[:])
+	binary.BigEndian.PutUint64(v[40:], s.executionBlockHeight)
+	for _, node := range finalized {
+		v = append(v, node[:]...)
+	}
+	if err := tx.Put(kv.DepositTree, snapshotKey, v); err != nil {
+		return err
+	}
+	pending := make([]byte, 0, len(s.pending)*32)
+	for _, leaf := range s.pending {
+		pending = append(pending, leaf[:]...)
+	}
+	if err := tx.Put(kv.DepositTree, pendingKey, pending); err != nil {
+		return err
+	}
+	progress := make([]byte, 8)
+	binary.BigEndian.PutUint64(progress, s.progress)
+	return tx.Put(kv.DepositTree, progressKey, progress)
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package deposit_tree
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon/cl/cltypes"
+	"github.com/erigontech/erigon/cl/merkle_tree"
+	"github.com/erigontech/erigon/cl/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func testLeaves(n int) []libcommon.Hash {
+	leaves := make([]libcommon.Hash, n)
+	for i := range leaves {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(i))
+		leaves[i] = utils.Sha256(b[:])
+	}
+	return leaves
+}
+
+// naiveRoot merkleizes all the leaves padded with zeroes up to the contract depth
+func naiveRoot(leaves []libcommon.Hash) libcommon.Hash {
+	layer := append([]libcommon.Hash{}, leaves...)
+	for h := 0; h < depositContractDepth; h++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, merkle_tree.ZeroHashes[h])
+		}
+		if len(layer) == 0 {
+			layer = append(layer, merkle_tree.ZeroHashes[h+1])
+			continue
+		}
+		next := make([]libcommon.Hash, len(layer)/2)
+		for i := range next {
+			next[i] = utils.Sha256(layer[2*i][:], layer[2*i+1][:])
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+func TestTreeRoot(t *testing.T) {
+	// root of the empty deposit contract
+	require.Equal(t, libcommon.HexToHash("0xd70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e"), (&Tree{}).DepositRoot())
+
+	leaves := testLeaves(70)
+	tree := &Tree{}
+	for i, leaf := range leaves {
+		require.NoError(t, tree.Push(leaf))
+		require.Equal(t, naiveRoot(leaves[:i+1]), tree.Root(), "deposits: %d", i+1)
+	}
+}
+
+func TestTreeFromSnapshot(t *testing.T) {
+	leaves := testLeaves(45)
+	tree := &Tree{}
+	for _, leaf := range leaves[:21] {
+		require.NoError(t, tree.Push(leaf))
+	}
+	snapshot := &Snapshot{Finalized: tree.Finalized(), DepositRoot: tree.DepositRoot(), DepositCount: tree.Count()}
+	require.Len(t, snapshot.Finalized, 3)
+	restored, err := snapshot.Tree()
+	require.NoError(t, err)
+	for _, leaf := range leaves[21:] {
+		require.NoError(t, tree.Push(leaf))
+		require.NoError(t, restored.Push(leaf))
+	}
+	require.Equal(t, tree.DepositRoot(), restored.DepositRoot())
+
+	snapshot.DepositRoot = libcommon.Hash{1}
+	_, err = snapshot.Tree()
+	require.Error(t, err)
+	snapshot.Finalized = snapshot.Finalized[1:]
+	_, err = snapshot.Tree()
+	require.Error(t, err)
+}
+
+// TestSnapshotRoot checks the deposit root of snapshots against the calculate_root of EIP-4881,
+// the vectors are shared with Prysm.
+func TestSnapshotRoot(t *testing.T) {
+	tests := []struct {
+		finalized    int
+		depositCount uint64
+		root         libcommon.Hash
+	}{
+		{finalized: 0, depositCount: 0, root: libcommon.HexToHash("0xd70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e")},
+		{finalized: 1, depositCount: 2, root: libcommon.HexToHash("0x24769a39d96d9174ee01cf3bbb1c45bb463799b40f9625488c246d9ad4ca2f3b")},
+	}
+	for _, tt := range tests {
+		snapshot := &Snapshot{Finalized: []libcommon.Hash{}, DepositRoot: tt.root, DepositCount: tt.depositCount}
+		for i := 0; i < tt.finalized; i++ {
+			snapshot.Finalized = append(snapshot.Finalized, libcommon.Hash{31: byte(i)})
+		}
+		tree, err := snapshot.Tree()
+		require.NoError(t, err, "finalized: %d", tt.finalized)
+		require.Equal(t, tt.root, tree.DepositRoot())
+		require.Equal(t, snapshot.Finalized, tree.Finalized())
+	}
+}
+
+func TestStoreOnFinalized(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	leaves := testLeaves(10)
+	reference := &Tree{}
+	eth1DataAt := func(count int, blockHash libcommon.Hash) *cltypes.Eth1Data {
+		for reference.Count() < uint64(count) {
+			require.NoError(t, reference.Push(leaves[reference.Count()]))
+		}
+		return &cltypes.Eth1Data{Root: reference.DepositRoot(), DepositCount: uint64(count), BlockHash: blockHash}
+	}
+	blockHeight := func(hash libcommon.Hash) (uint64, error) {
+		return uint64(hash[0]), nil
+	}
+
+	s := NewStore()
+	require.NoError(t, s.Load(tx))
+	require.False(t, s.Initialized())
+	require.NoError(t, s.Initialize(tx, &Snapshot{DepositRoot: libcommon.HexToHash("0xd70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e")}, 0))
+	_, ok := s.Snapshot()
+	require.False(t, ok)
+
+	// 6 deposits are included, 4 of them are finalized by the eth1 data
+	require.NoError(t, s.OnFinalized(tx, 32, leaves[:6], 6, eth1DataAt(4, libcommon.Hash{7}), blockHeight))
+	snapshot, ok := s.Snapshot()
+	require.True(t, ok)
+	require.Equal(t, uint64(4), snapshot.DepositCount)
+	require.Equal(t, uint64(7), snapshot.ExecutionBlockHeight)
+	require.Equal(t, reference.DepositRoot(), snapshot.DepositRoot)
+
+	// the pending deposits survive the restart
+	loaded := NewStore()
+	require.NoError(t, loaded.Load(tx))
+	require.Equal(t, uint64(32), loaded.Progress())
+	loadedSnapshot, ok := loaded.Snapshot()
+	require.True(t, ok)
+	require.Equal(t, snapshot, loadedSnapshot)
+
+	require.NoError(t, loaded.OnFinalized(tx, 64, leaves[6:10], 10, eth1DataAt(9, libcommon.Hash{9}), blockHeight))
+	snapshot, ok = loaded.Snapshot()
+	require.True(t, ok)
+	require.Equal(t, uint64(9), snapshot.DepositCount)
+	require.Equal(t, reference.DepositRoot(), snapshot.DepositRoot)
+	require.Equal(t, libcommon.Hash{9}, snapshot.ExecutionBlockHash)
+
+	// missing deposits are detected
+	require.ErrorIs(t, loaded.OnFinalized(tx, 96, nil, 11, eth1DataAt(9, libcommon.Hash{9}), blockHeight), ErrInconsistent)
+	require.NoError(t, loaded.Reset(tx))
+	require.False(t, loaded.Initialized())
+	require.NoError(t, s.Load(tx))
+	require.False(t, s.Initialized())
+}
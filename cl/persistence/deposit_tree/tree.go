@@ -0,0 +1,144 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package deposit_tree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/cl/merkle_tree"
+	"github.com/erigontech/erigon/cl/utils"
+)
+
+// depositContractDepth is DEPOSIT_CONTRACT_DEPTH of the deposit contract.
+const depositContractDepth = 32
+
+var ErrTreeFull = errors.New("deposit tree is full")
+
+// Tree is the incremental merkle tree of the deposit contract. Only the left siblings of the next leaf are kept,
+// which are exactly the finalized nodes of the EIP-4881 snapshot.
+type Tree struct {
+	branch [depositContractDepth]libcommon.Hash
+	count  uint64
+}
+
+// NewTreeFromFinalized restores the tree from the finalized nodes of a snapshot (ordered from the top level down).
+func NewTreeFromFinalized(finalized []libcommon.Hash, count uint64) (*Tree, error) {
+	if count >= 1<<depositContractDepth {
+		return nil, ErrTreeFull
+	}
+	if bits.OnesCount64(count) != len(finalized) {
+		return nil, fmt.Errorf("expected %d finalized nodes for %d deposits, got %d", bits.OnesCount64(count), count, len(finalized))
+	}
+	t := &Tree{count: count}
+	i := 0
+	for h := depositContractDepth - 1; h >= 0; h-- {
+		if (count>>h)&1 == 1 {
+			t.branch[h] = finalized[i]
+			i++
+		}
+	}
+	return t, nil
+}
+
+// Count returns the number of deposits in the tree.
+func (t *Tree) Count() uint64 {
+	return t.count
+}
+
+// Push appends a deposit (hash tree root of DepositData) to the tree.
+func (t *Tree) Push(leaf libcommon.Hash) error {
+	if t.count+1 >= 1<<depositContractDepth {
+		return ErrTreeFull
+	}
+	t.count++
+	size := t.count
+	node := leaf
+	for h := 0; h < depositContractDepth; h++ {
+		if size&1 == 1 {
+			t.branch[h] = node
+			return nil
+		}
+		node = utils.Sha256(t.branch[h][:], node[:])
+		size >>= 1
+	}
+	return nil
+}
+
+// Root returns the root of the tree without the mixed in deposit count.
+func (t *Tree) Root() libcommon.Hash {
+	var node libcommon.Hash
+	size := t.count
+	for h := 0; h < depositContractDepth; h++ {
+		if size&1 == 1 {
+			node = utils.Sha256(t.branch[h][:], node[:])
+		} else {
+			node = utils.Sha256(node[:], merkle_tree.ZeroHashes[h][:])
+		}
+		size >>= 1
+	}
+	return node
+}
+
+// DepositRoot returns the root of the tree as returned by the deposit contract and used in Eth1Data.
+func (t *Tree) DepositRoot() libcommon.Hash {
+	root := t.Root()
+	var count [32]byte
+	binary.LittleEndian.PutUint64(count[:], t.count)
+	return utils.Sha256(root[:], count[:])
+}
+
+// Finalized returns the finalized nodes of the tree, from the top level down.
+func (t *Tree) Finalized() []libcommon.Hash {
+	finalized := make([]libcommon.Hash, 0, bits.OnesCount64(t.count))
+	for h := depositContractDepth - 1; h >= 0; h-- {
+		if (t.count>>h)&1 == 1 {
+			finalized = append(finalized, t.branch[h])
+		}
+	}
+	return finalized
+}
+
+func (t *Tree) copy() *Tree {
+	cpy := *t
+	return &cpy
+}
+
+// Snapshot is the DepositTreeSnapshot of EIP-4881.
+type Snapshot struct {
+	Finalized            []libcommon.Hash `json:"finalized"`
+	DepositRoot          libcommon.Hash   `json:"deposit_root"`
+	DepositCount         uint64           `json:"deposit_count,string"`
+	ExecutionBlockHash   libcommon.Hash   `json:"execution_block_hash"`
+	ExecutionBlockHeight uint64           `json:"execution_block_height,string"`
+}
+
+// Tree validates the snapshot and restores the tree from it.
+func (s *Snapshot) Tree() (*Tree, error) {
+	t, err := NewTreeFromFinalized(s.Finalized, s.DepositCount)
+	if err != nil {
+		return nil, err
+	}
+	if root := t.DepositRoot(); root != s.DepositRoot {
+		return nil, fmt.Errorf("deposit snapshot root mismatch: expected %x, got %x", s.DepositRoot, root)
+	}
+	return t, nil
+}
@@ -19,12 +19,15 @@ package core
 import (
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/erigontech/erigon/cl/cltypes"
+	"github.com/erigontech/erigon/cl/persistence/deposit_tree"
 	"github.com/erigontech/erigon/cl/phase1/core/state"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
@@ -144,3 +147,52 @@ func RetrieveBlock(ctx context.Context, beaconConfig *clparams.BeaconChainConfig
 	}
 	return block, nil
 }
+
+// RetrieveDepositSnapshot fetches the finalized deposit tree snapshot (EIP-4881) from the beacon API
+// of the checkpoint sync endpoints.
+func RetrieveDepositSnapshot(ctx context.Context, net clparams.NetworkType) (*deposit_tree.Snapshot, error) {
+	fetchDepositSnapshot := func(uri string) (*deposit_tree.Snapshot, error) {
+		log.Debug("[Checkpoint Sync] Requesting deposit snapshot", "uri", uri)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		r, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Body.Close()
+		if r.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("deposit snapshot request failed, bad status code %d", r.StatusCode)
+		}
+		var resp struct {
+			Data *deposit_tree.Snapshot `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+			return nil, fmt.Errorf("deposit snapshot decode failed %s", err)
+		}
+		if resp.Data == nil {
+			return nil, errors.New("deposit snapshot is empty")
+		}
+		if _, err := resp.Data.Tree(); err != nil {
+			return nil, err
+		}
+		return resp.Data, nil
+	}
+
+	err := errors.New("no uris for deposit snapshot")
+	for _, uri := range clparams.GetAllCheckpointSyncEndpoints(net) {
+		base, _, found := strings.Cut(uri, "/eth/v2/debug/beacon/states/")
+		if !found {
+			continue
+		}
+		var snapshot *deposit_tree.Snapshot
+		snapshot, err = fetchDepositSnapshot(base + "/eth/v1/beacon/deposit_snapshot")
+		if err == nil {
+			return snapshot, nil
+		}
+		log.Debug("[Checkpoint Sync] Failed to fetch deposit snapshot", "uri", base, "err", err)
+	}
+	return nil, err
+}
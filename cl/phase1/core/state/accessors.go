@@ -95,6 +95,52 @@ func GetBlockRoot(b abstract.BeaconState, epoch uint64) (libcommon.Hash, error)
 	return b.GetBlockRootAtSlot(epoch * b.BeaconConfig().SlotsPerEpoch)
 }
 
+// GetBlockRootAtSlotOrLatest returns the block root at a given slot, or the root of the latest block
+// if the slot is not in the past of the state.
+func GetBlockRootAtSlotOrLatest(b abstract.BeaconState, slot uint64) (libcommon.Hash, error) {
+	if slot < b.Slot() {
+		return b.GetBlockRootAtSlot(slot)
+	}
+	header := b.LatestBlockHeader()
+	if header.Root == (libcommon.Hash{}) {
+		// state root is filled only at the next slot processing
+		stateRoot, err := b.HashSSZ()
+		if err != nil {
+			return libcommon.Hash{}, err
+		}
+		header.Root = stateRoot
+	}
+	return header.HashSSZ()
+}
+
+// ProposerDutiesDependentRootSlot returns the slot of the block which decides the proposer shuffling of an epoch,
+// that is the last slot of the previous epoch (genesis for epoch 0).
+func ProposerDutiesDependentRootSlot(cfg *clparams.BeaconChainConfig, epoch uint64) uint64 {
+	if epoch == 0 {
+		return 0
+	}
+	return epoch*cfg.SlotsPerEpoch - 1
+}
+
+// AttesterDutiesDependentRootSlot returns the slot of the block which decides the attester shuffling of an epoch,
+// that is the last slot of the epoch before the previous one (genesis for epochs 0 and 1).
+func AttesterDutiesDependentRootSlot(cfg *clparams.BeaconChainConfig, epoch uint64) uint64 {
+	if epoch == 0 {
+		return 0
+	}
+	return ProposerDutiesDependentRootSlot(cfg, epoch-1)
+}
+
+// ProposerDutiesDependentRoot returns the dependent root of the proposer duties of a given epoch.
+func ProposerDutiesDependentRoot(b abstract.BeaconState, epoch uint64) (libcommon.Hash, error) {
+	return GetBlockRootAtSlotOrLatest(b, ProposerDutiesDependentRootSlot(b.BeaconConfig(), epoch))
+}
+
+// AttesterDutiesDependentRoot returns the dependent root of the attester duties of a given epoch.
+func AttesterDutiesDependentRoot(b abstract.BeaconState, epoch uint64) (libcommon.Hash, error) {
+	return GetBlockRootAtSlotOrLatest(b, AttesterDutiesDependentRootSlot(b.BeaconConfig(), epoch))
+}
+
 // FinalityDelay determines by how many epochs we are late on finality.
 func FinalityDelay(b abstract.BeaconState) uint64 {
 	return PreviousEpoch(b) - b.FinalizedCheckpoint().Epoch()
@@ -20,6 +20,7 @@ import (
 	"math"
 	"testing"
 
+	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon/cl/clparams"
 	"github.com/erigontech/erigon/cl/cltypes"
 	"github.com/erigontech/erigon/cl/cltypes/solid"
@@ -62,3 +63,34 @@ func TestSyncReward(t *testing.T) {
 	require.Equal(t, propReward, uint64(0x39))
 
 }
+
+func TestDutiesDependentRootSlot(t *testing.T) {
+	cfg := &clparams.MainnetBeaconConfig
+	require.Equal(t, uint64(0), ProposerDutiesDependentRootSlot(cfg, 0))
+	require.Equal(t, uint64(31), ProposerDutiesDependentRootSlot(cfg, 1))
+	require.Equal(t, uint64(63), ProposerDutiesDependentRootSlot(cfg, 2))
+	require.Equal(t, uint64(0), AttesterDutiesDependentRootSlot(cfg, 0))
+	require.Equal(t, uint64(0), AttesterDutiesDependentRootSlot(cfg, 1))
+	require.Equal(t, uint64(31), AttesterDutiesDependentRootSlot(cfg, 2))
+
+	state := New(cfg)
+	utils.DecodeSSZSnappy(state, stateEncoded, int(clparams.Phase0Version))
+	epoch := Epoch(state)
+	expected, err := state.GetBlockRootAtSlot(epoch*cfg.SlotsPerEpoch - 1)
+	require.NoError(t, err)
+	root, err := ProposerDutiesDependentRoot(state, epoch)
+	require.NoError(t, err)
+	require.Equal(t, expected, root)
+	// the duties of the next epoch depend on the latest block
+	header := state.LatestBlockHeader()
+	stateRoot, err := state.HashSSZ()
+	require.NoError(t, err)
+	if header.Root == (libcommon.Hash{}) {
+		header.Root = stateRoot
+	}
+	expected, err = header.HashSSZ()
+	require.NoError(t, err)
+	root, err = ProposerDutiesDependentRoot(state, epoch+1)
+	require.NoError(t, err)
+	require.Equal(t, expected, root)
+}
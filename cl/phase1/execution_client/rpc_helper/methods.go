@@ -26,3 +26,5 @@ const ForkChoiceUpdatedV3 = "engine_forkchoiceUpdatedV3"
 
 const GetPayloadBodiesByHashV1 = "engine_getPayloadBodiesByHashV1"
 const GetPayloadBodiesByRangeV1 = "engine_getPayloadBodiesByRangeV1"
+
+const GetBlockByHash = "eth_getBlockByHash"
@@ -40,6 +40,7 @@ import (
 	"github.com/erigontech/erigon/cl/persistence"
 	"github.com/erigontech/erigon/cl/persistence/beacon_indicies"
 	"github.com/erigontech/erigon/cl/persistence/blob_storage"
+	"github.com/erigontech/erigon/cl/persistence/deposit_tree"
 	state_accessors "github.com/erigontech/erigon/cl/persistence/state"
 	"github.com/erigontech/erigon/cl/phase1/core/state"
 	"github.com/erigontech/erigon/cl/phase1/execution_client"
@@ -75,6 +76,7 @@ type Cfg struct {
 	blobStore               blob_storage.BlobStorage
 	attestationDataProducer attestation_producer.AttestationDataProducer
 	validatorMonitor        monitor.ValidatorMonitor
+	depositTree             *deposit_tree.Store
 
 	hasDownloaded, backfilling, blobBackfilling bool
 }
@@ -109,6 +111,7 @@ func ClStagesCfg(
 	blobStore blob_storage.BlobStorage,
 	attestationDataProducer attestation_producer.AttestationDataProducer,
 	validatorMonitor monitor.ValidatorMonitor,
+	depositTree *deposit_tree.Store,
 ) *Cfg {
 	return &Cfg{
 		rpc:                     rpc,
@@ -131,6 +134,7 @@ func ClStagesCfg(
 		blobBackfilling:         blobBackfilling,
 		attestationDataProducer: attestationDataProducer,
 		validatorMonitor:        validatorMonitor,
+		depositTree:             depositTree,
 	}
 }
 
@@ -233,6 +237,11 @@ func ConsensusClStages(ctx context.Context,
 	// Probably the correct long term solution is to create a third generic parameter that defines shared state
 	// but for now, all it would have are the two gossip sources and the forkChoicesSinceReorg, so i don't think its worth it (yet).
 	shouldForkChoiceSinceReorg := false
+	// last head announced by the ForkChoice stage, used to detect the reorgs
+	var prevHead struct {
+		root, stateRoot common.Hash
+		slot            uint64
+	}
 
 	// clstages run in a single thread - so we don't need to worry about any synchronization.
 	return &clstages.StageGraph[*Cfg, Args]{
@@ -700,6 +709,10 @@ func ConsensusClStages(ctx context.Context,
 					}
 					log.Debug("Incremented state history", "elapsed", time.Since(start), "preverifiedValidators", preverifiedValidators)
 
+					if err := updateDepositTree(ctx, logger, cfg, tx); err != nil {
+						logger.Warn("Could not update deposit tree", "err", err)
+					}
+
 					stateRoot, err := headState.HashSSZ()
 					if err != nil {
 						return fmt.Errorf("failed to hash ssz: %w", err)
@@ -710,20 +723,35 @@ func ConsensusClStages(ctx context.Context,
 					}
 
 					headEpoch := headSlot / cfg.beaconCfg.SlotsPerEpoch
-					previous_duty_dependent_root, err := headState.GetBlockRootAtSlot((headEpoch-1)*cfg.beaconCfg.SlotsPerEpoch - 1)
+					previous_duty_dependent_root, err := state.AttesterDutiesDependentRoot(headState, headEpoch)
 					if err != nil {
 						return fmt.Errorf("failed to get block root at slot for previous_duty_dependent_root: %w", err)
 					}
-					current_duty_dependent_root, err := headState.GetBlockRootAtSlot(headEpoch*cfg.beaconCfg.SlotsPerEpoch - 1)
+					current_duty_dependent_root, err := state.AttesterDutiesDependentRoot(headState, headEpoch+1)
 					if err != nil {
 						return fmt.Errorf("failed to get block root at slot for current_duty_dependent_root: %w", err)
 					}
+					// the old head is not an ancestor of the new one, so the canonical chain was reorganized down to currentSlot
+					if prevHead.root != (common.Hash{}) && prevHead.root != headRoot && currentSlot < prevHead.slot {
+						cfg.emitter.Publish("chain_reorg", map[string]any{
+							"slot":                 strconv.Itoa(int(headSlot)),
+							"depth":                strconv.Itoa(int(prevHead.slot - currentSlot)),
+							"old_head_block":       prevHead.root,
+							"new_head_block":       headRoot,
+							"old_head_state":       prevHead.stateRoot,
+							"new_head_state":       common.Hash(stateRoot),
+							"epoch":                strconv.Itoa(int(headEpoch)),
+							"execution_optimistic": false,
+						})
+					}
+					epochTransition := prevHead.root != (common.Hash{}) && headEpoch != prevHead.slot/cfg.beaconCfg.SlotsPerEpoch
+					prevHead.root, prevHead.slot, prevHead.stateRoot = headRoot, headSlot, stateRoot
 					// emit the head event
 					cfg.emitter.Publish("head", map[string]any{
 						"slot":                         strconv.Itoa(int(headSlot)),
 						"block":                        headRoot,
 						"state":                        common.Hash(stateRoot),
-						"epoch_transition":             true,
+						"epoch_transition":             epochTransition,
 						"previous_duty_dependent_root": previous_duty_dependent_root,
 						"current_duty_dependent_root":  current_duty_dependent_root,
 						"execution_optimistic":         false,
@@ -0,0 +1,92 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cl/persistence/beacon_indicies"
+	"github.com/erigontech/erigon/cl/persistence/deposit_tree"
+)
+
+// updateDepositTree accounts for the deposits of the newly finalized blocks in the deposit tree.
+func updateDepositTree(ctx context.Context, logger log.Logger, cfg *Cfg, tx kv.RwTx) error {
+	if cfg.depositTree == nil || !cfg.depositTree.Initialized() {
+		return nil
+	}
+	finalizedRoot := cfg.forkChoice.FinalizedCheckpoint().BlockRoot()
+	finalizedSlot, err := beacon_indicies.ReadBlockSlotByBlockRoot(tx, finalizedRoot)
+	if err != nil {
+		return err
+	}
+	if finalizedSlot == nil || *finalizedSlot <= cfg.depositTree.Progress() {
+		return nil
+	}
+	finalizedState, err := cfg.forkChoice.GetStateAtBlockRoot(finalizedRoot, false)
+	if err != nil {
+		return err
+	}
+	if finalizedState == nil {
+		return nil
+	}
+
+	var leaves []common.Hash
+	for slot := cfg.depositTree.Progress() + 1; slot <= *finalizedSlot; slot++ {
+		block, err := cfg.blockReader.ReadBlockBySlot(ctx, tx, slot)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			continue
+		}
+		deposits := block.Block.Body.Deposits
+		for i := 0; i < deposits.Len(); i++ {
+			leaf, err := deposits.Get(i).Data.HashSSZ()
+			if err != nil {
+				return err
+			}
+			leaves = append(leaves, leaf)
+		}
+	}
+
+	blockHeight := func(hash common.Hash) (uint64, error) {
+		if cfg.executionClient == nil {
+			return 0, errors.New("execution client is not available")
+		}
+		number, err := cfg.executionClient.HeaderNumber(ctx, hash)
+		if err != nil {
+			return 0, err
+		}
+		if number == nil {
+			return 0, fmt.Errorf("execution block %x not found", hash)
+		}
+		return *number, nil
+	}
+	err = cfg.depositTree.OnFinalized(tx, *finalizedSlot, leaves, finalizedState.Eth1DepositIndex(), finalizedState.Eth1Data(), blockHeight)
+	if errors.Is(err, deposit_tree.ErrInconsistent) {
+		// blocks are missing or the tree was initialized from a bad snapshot, it can't be recovered
+		logger.Warn("Deposit tree is dropped", "err", err)
+		return cfg.depositTree.Reset(tx)
+	}
+	return err
+}
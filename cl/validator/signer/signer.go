@@ -0,0 +1,73 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"context"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon/cl/cltypes"
+	"github.com/erigontech/erigon/cl/cltypes/solid"
+)
+
+// Signer signs messages of the validators, keys are never held by the node.
+type Signer interface {
+	PublicKeys(ctx context.Context) ([]common.Bytes48, error)
+	Sign(ctx context.Context, pubKey common.Bytes48, req *SignRequest) (common.Bytes96, error)
+}
+
+// SignType - type of the signed message, as defined by the Web3Signer eth2 API
+type SignType string
+
+const (
+	SignTypeBlockV2           SignType = "BLOCK_V2"
+	SignTypeAttestation       SignType = "ATTESTATION"
+	SignTypeAggregationSlot   SignType = "AGGREGATION_SLOT"
+	SignTypeAggregateAndProof SignType = "AGGREGATE_AND_PROOF"
+	SignTypeRandaoReveal      SignType = "RANDAO_REVEAL"
+)
+
+// SignRequest - body of the Web3Signer sign request. The signer may recompute the signing root
+// from the message and fork info, only the field which matches Type is set.
+type SignRequest struct {
+	Type              SignType                   `json:"type"`
+	ForkInfo          *ForkInfo                  `json:"fork_info"`
+	SigningRoot       common.Hash                `json:"signingRoot"`
+	BeaconBlock       *BeaconBlockToSign         `json:"beacon_block,omitempty"`
+	Attestation       solid.AttestationData      `json:"attestation,omitempty"`
+	AggregationSlot   *AggregationSlot           `json:"aggregation_slot,omitempty"`
+	AggregateAndProof *cltypes.AggregateAndProof `json:"aggregate_and_proof,omitempty"`
+	RandaoReveal      *RandaoReveal              `json:"randao_reveal,omitempty"`
+}
+
+type ForkInfo struct {
+	Fork                  *cltypes.Fork `json:"fork"`
+	GenesisValidatorsRoot common.Hash   `json:"genesis_validators_root"`
+}
+
+type BeaconBlockToSign struct {
+	Version     string                     `json:"version"` // upper case fork name, e.g. DENEB
+	BlockHeader *cltypes.BeaconBlockHeader `json:"block_header"`
+}
+
+type AggregationSlot struct {
+	Slot uint64 `json:"slot,string"`
+}
+
+type RandaoReveal struct {
+	Epoch uint64 `json:"epoch,string"`
+}
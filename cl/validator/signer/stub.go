@@ -0,0 +1,109 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/Giulio2002/bls"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+// Stub - in-process signer implementing the Web3Signer eth2 API with local keys, to be served with httptest.NewServer.
+// Unlike Web3Signer it trusts the signing root of the request and keeps no slashing protection of its own.
+type Stub struct {
+	router chi.Router
+
+	mu       sync.Mutex
+	keys     map[common.Bytes48]*bls.PrivateKey
+	requests []*SignRequest
+}
+
+func NewStub(keys ...*bls.PrivateKey) *Stub {
+	s := &Stub{keys: map[common.Bytes48]*bls.PrivateKey{}}
+	for _, key := range keys {
+		s.keys[common.Bytes48(bls.CompressPublicKey(key.PublicKey()))] = key
+	}
+	r := chi.NewRouter()
+	r.Get("/upcheck", s.upcheck)
+	r.Get("/api/v1/eth2/publicKeys", s.publicKeys)
+	r.Post("/api/v1/eth2/sign/{identifier}", s.sign)
+	s.router = r
+	return s
+}
+
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// Requests returns the sign requests received so far, with the typed messages stripped.
+func (s *Stub) Requests() []*SignRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*SignRequest{}, s.requests...)
+}
+
+func (s *Stub) upcheck(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("OK"))
+}
+
+func (s *Stub) publicKeys(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	pubKeys := make([]common.Bytes48, 0, len(s.keys))
+	for pubKey := range s.keys {
+		pubKeys = append(pubKeys, pubKey)
+	}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pubKeys)
+}
+
+func (s *Stub) sign(w http.ResponseWriter, r *http.Request) {
+	var pubKey common.Bytes48
+	if err := pubKey.UnmarshalText([]byte(chi.URLParam(r, "identifier"))); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// typed messages are not needed to sign the root
+	req := struct {
+		Type        SignType    `json:"type"`
+		ForkInfo    *ForkInfo   `json:"fork_info"`
+		SigningRoot common.Hash `json:"signingRoot"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	key, ok := s.keys[pubKey]
+	if ok {
+		s.requests = append(s.requests, &SignRequest{Type: req.Type, ForkInfo: req.ForkInfo, SigningRoot: req.SigningRoot})
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "public key not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Signature common.Bytes96 `json:"signature"`
+	}{common.Bytes96(key.Sign(req.SigningRoot[:]).Bytes())})
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+)
+
+var _ Signer = (*Web3Signer)(nil)
+
+// Web3Signer - client of a remote signer implementing the Web3Signer eth2 API
+type Web3Signer struct {
+	url        *url.URL
+	httpClient *http.Client
+}
+
+func NewWeb3Signer(baseUrl string, timeout time.Duration) (*Web3Signer, error) {
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &Web3Signer{
+		url:        u,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Upcheck returns an error if the signer is not available.
+func (s *Web3Signer) Upcheck(ctx context.Context) error {
+	_, err := s.call(ctx, http.MethodGet, "/upcheck", nil)
+	return err
+}
+
+func (s *Web3Signer) PublicKeys(ctx context.Context) ([]common.Bytes48, error) {
+	body, err := s.call(ctx, http.MethodGet, "/api/v1/eth2/publicKeys", nil)
+	if err != nil {
+		return nil, err
+	}
+	var pubKeys []common.Bytes48
+	if err := json.Unmarshal(body, &pubKeys); err != nil {
+		return nil, err
+	}
+	return pubKeys, nil
+}
+
+func (s *Web3Signer) Sign(ctx context.Context, pubKey common.Bytes48, req *SignRequest) (common.Bytes96, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return common.Bytes96{}, err
+	}
+	body, err := s.call(ctx, http.MethodPost, "/api/v1/eth2/sign/"+pubKey.Hex(), payload)
+	if err != nil {
+		return common.Bytes96{}, err
+	}
+	// the signature is either a json object or a plain hex string, depending on the version of the signer
+	var signature hexutility.Bytes
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		resp := struct {
+			Signature hexutility.Bytes `json:"signature"`
+		}{}
+		if err := json.Unmarshal(trimmed, &resp); err != nil {
+			return common.Bytes96{}, err
+		}
+		signature = resp.Signature
+	} else if err := signature.UnmarshalText(trimmed); err != nil {
+		return common.Bytes96{}, err
+	}
+	if len(signature) != 96 {
+		return common.Bytes96{}, fmt.Errorf("invalid signature length %d", len(signature))
+	}
+	return common.Bytes96(signature), nil
+}
+
+func (s *Web3Signer) call(ctx context.Context, method, path string, payload []byte) ([]byte, error) {
+	var payloadReader io.Reader
+	if payload != nil {
+		payloadReader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.url.JoinPath(path).String(), payloadReader)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signer %s %s: status code %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
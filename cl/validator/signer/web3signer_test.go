@@ -0,0 +1,81 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Giulio2002/bls"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon/cl/cltypes"
+)
+
+func TestWeb3SignerWithStub(t *testing.T) {
+	ctx := context.Background()
+	key, err := bls.GenerateKey()
+	require.NoError(t, err)
+	pubKey := common.Bytes48(bls.CompressPublicKey(key.PublicKey()))
+
+	stub := NewStub(key)
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	s, err := NewWeb3Signer(server.URL, time.Second)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Upcheck(ctx))
+	pubKeys, err := s.PublicKeys(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []common.Bytes48{pubKey}, pubKeys)
+
+	req := &SignRequest{
+		Type:         SignTypeRandaoReveal,
+		ForkInfo:     &ForkInfo{Fork: &cltypes.Fork{Epoch: 1}},
+		SigningRoot:  common.HexToHash("0x01"),
+		RandaoReveal: &RandaoReveal{Epoch: 10},
+	}
+	signature, err := s.Sign(ctx, pubKey, req)
+	require.NoError(t, err)
+	ok, err := bls.Verify(signature[:], req.SigningRoot[:], pubKey[:])
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, stub.Requests(), 1)
+	require.Equal(t, SignTypeRandaoReveal, stub.Requests()[0].Type)
+
+	// unknown keys are refused
+	_, err = s.Sign(ctx, common.Bytes48{1}, req)
+	require.Error(t, err)
+}
+
+func TestWeb3SignerPlainSignature(t *testing.T) {
+	signature := common.Bytes96{1, 2, 3}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(signature.Hex()))
+	}))
+	defer server.Close()
+	s, err := NewWeb3Signer(server.URL, time.Second)
+	require.NoError(t, err)
+	res, err := s.Sign(context.Background(), common.Bytes48{}, &SignRequest{Type: SignTypeAggregationSlot, AggregationSlot: &AggregationSlot{Slot: 1}})
+	require.NoError(t, err)
+	require.Equal(t, signature, res)
+}
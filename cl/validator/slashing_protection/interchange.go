@@ -0,0 +1,206 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package slashing_protection
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
+)
+
+// InterchangeFormatVersion - version of the EIP-3076 interchange format which is supported
+const InterchangeFormatVersion = "5"
+
+// Interchange - slashing protection data in the format of EIP-3076, to move validators between clients
+type Interchange struct {
+	Metadata InterchangeMetadata `json:"metadata"`
+	Data     []*InterchangeData  `json:"data"`
+}
+
+type InterchangeMetadata struct {
+	InterchangeFormatVersion string      `json:"interchange_format_version"`
+	GenesisValidatorsRoot    common.Hash `json:"genesis_validators_root"`
+}
+
+type InterchangeData struct {
+	PubKey             common.Bytes48            `json:"pubkey"`
+	SignedBlocks       []*InterchangeBlock       `json:"signed_blocks"`
+	SignedAttestations []*InterchangeAttestation `json:"signed_attestations"`
+}
+
+type InterchangeBlock struct {
+	Slot        uint64       `json:"slot,string"`
+	SigningRoot *common.Hash `json:"signing_root,omitempty"`
+}
+
+type InterchangeAttestation struct {
+	SourceEpoch uint64       `json:"source_epoch,string"`
+	TargetEpoch uint64       `json:"target_epoch,string"`
+	SigningRoot *common.Hash `json:"signing_root,omitempty"`
+}
+
+// Import merges the interchange data into the history. The records which conflict with the history
+// lose their signing roots, so the messages can't be signed again.
+func (s *SlashingProtection) Import(ctx context.Context, r io.Reader, genesisValidatorsRoot common.Hash) error {
+	interchange := &Interchange{}
+	if err := json.NewDecoder(r).Decode(interchange); err != nil {
+		return err
+	}
+	if interchange.Metadata.InterchangeFormatVersion != InterchangeFormatVersion {
+		return fmt.Errorf("unsupported interchange format version %s", interchange.Metadata.InterchangeFormatVersion)
+	}
+	if interchange.Metadata.GenesisValidatorsRoot != genesisValidatorsRoot {
+		return fmt.Errorf("interchange is for another network: genesis validators root %x, expected %x", interchange.Metadata.GenesisValidatorsRoot, genesisValidatorsRoot)
+	}
+	return s.db.Update(ctx, func(tx kv.RwTx) error {
+		for _, data := range interchange.Data {
+			for _, block := range data.SignedBlocks {
+				if err := importRecord(tx, kv.ValidatorSignedBlocks, signedMessageKey(data.PubKey, block.Slot), nil, block.SigningRoot); err != nil {
+					return err
+				}
+			}
+			var minSource, minTarget uint64 = math.MaxUint64, math.MaxUint64
+			for _, attestation := range data.SignedAttestations {
+				if attestation.SourceEpoch > attestation.TargetEpoch {
+					return fmt.Errorf("invalid attestation of %x: source epoch %d is greater than target epoch %d", data.PubKey, attestation.SourceEpoch, attestation.TargetEpoch)
+				}
+				source := make([]byte, 8)
+				binary.BigEndian.PutUint64(source, attestation.SourceEpoch)
+				if err := importRecord(tx, kv.ValidatorSignedAttestations, signedMessageKey(data.PubKey, attestation.TargetEpoch), source, attestation.SigningRoot); err != nil {
+					return err
+				}
+				minSource, minTarget = min(minSource, attestation.SourceEpoch), min(minTarget, attestation.TargetEpoch)
+			}
+			if len(data.SignedAttestations) == 0 {
+				continue
+			}
+			// a watermark raised by pruning is kept, the imported records below it are checked until they are pruned
+			if _, _, ok, err := attestationWatermark(tx, data.PubKey); err != nil || ok {
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if err := putAttestationWatermark(tx, data.PubKey, minSource, minTarget); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func importRecord(tx kv.RwTx, table string, key, prefix []byte, signingRoot *common.Hash) error {
+	v := make([]byte, len(prefix)+length.Hash)
+	copy(v, prefix)
+	if signingRoot != nil {
+		copy(v[len(prefix):], signingRoot[:])
+	}
+	prev, err := tx.GetOne(table, key)
+	if err != nil {
+		return err
+	}
+	if prev != nil && !bytes.Equal(prev, v) {
+		// conflicting records: keep the lowest source epoch and forget the signing root
+		if bytes.Compare(prev[:len(prefix)], prefix) < 0 {
+			copy(v, prev[:len(prefix)])
+		}
+		clear(v[len(prefix):])
+	}
+	return tx.Put(table, key, v)
+}
+
+// Export returns the whole history in the interchange format.
+func (s *SlashingProtection) Export(ctx context.Context, w io.Writer, genesisValidatorsRoot common.Hash) error {
+	interchange := &Interchange{
+		Metadata: InterchangeMetadata{
+			InterchangeFormatVersion: InterchangeFormatVersion,
+			GenesisValidatorsRoot:    genesisValidatorsRoot,
+		},
+		Data: []*InterchangeData{},
+	}
+	byPubKey := map[common.Bytes48]*InterchangeData{}
+	dataOf := func(k []byte) *InterchangeData {
+		pubKey := common.Bytes48(k[:length.Bytes48])
+		data, ok := byPubKey[pubKey]
+		if !ok {
+			data = &InterchangeData{PubKey: pubKey, SignedBlocks: []*InterchangeBlock{}, SignedAttestations: []*InterchangeAttestation{}}
+			byPubKey[pubKey] = data
+			interchange.Data = append(interchange.Data, data)
+		}
+		return data
+	}
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		if err := tx.ForEach(kv.ValidatorSignedBlocks, nil, func(k, v []byte) error {
+			data := dataOf(k)
+			data.SignedBlocks = append(data.SignedBlocks, &InterchangeBlock{
+				Slot:        binary.BigEndian.Uint64(k[length.Bytes48:]),
+				SigningRoot: exportSigningRoot(v),
+			})
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.ForEach(kv.ValidatorSignedAttestations, nil, func(k, v []byte) error {
+			data := dataOf(k)
+			data.SignedAttestations = append(data.SignedAttestations, &InterchangeAttestation{
+				SourceEpoch: binary.BigEndian.Uint64(v),
+				TargetEpoch: binary.BigEndian.Uint64(k[length.Bytes48:]),
+				SigningRoot: exportSigningRoot(v[8:]),
+			})
+			return nil
+		}); err != nil {
+			return err
+		}
+		// the watermark stands for the pruned history, it is exported as an attestation without signing root
+		return tx.ForEach(kv.ValidatorAttestationWatermarks, nil, func(k, v []byte) error {
+			data := dataOf(k)
+			watermark := &InterchangeAttestation{SourceEpoch: binary.BigEndian.Uint64(v), TargetEpoch: binary.BigEndian.Uint64(v[8:])}
+			i := sort.Search(len(data.SignedAttestations), func(i int) bool {
+				return data.SignedAttestations[i].TargetEpoch >= watermark.TargetEpoch
+			})
+			if i < len(data.SignedAttestations) && data.SignedAttestations[i].TargetEpoch == watermark.TargetEpoch {
+				return nil
+			}
+			data.SignedAttestations = slices.Insert(data.SignedAttestations, i, watermark)
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+	sort.Slice(interchange.Data, func(i, j int) bool {
+		return bytes.Compare(interchange.Data[i].PubKey[:], interchange.Data[j].PubKey[:]) < 0
+	})
+	return json.NewEncoder(w).Encode(interchange)
+}
+
+func exportSigningRoot(v []byte) *common.Hash {
+	signingRoot := common.BytesToHash(v)
+	if signingRoot == (common.Hash{}) {
+		return nil
+	}
+	return &signingRoot
+}
@@ -0,0 +1,211 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package slashing_protection
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
+)
+
+var (
+	ErrSlashableBlock       = errors.New("refusing to sign a slashable block")
+	ErrSlashableAttestation = errors.New("refusing to sign a slashable attestation")
+)
+
+// attestationHistoryEpochs is how many epochs of attestations are kept for every validator. The older ones are
+// pruned and only a low watermark is kept for them, as in the minimal interchange format of EIP-3076.
+const attestationHistoryEpochs = 54_000
+
+// SlashingProtection keeps the history of the messages signed by the validators (EIP-3076) and refuses to sign
+// the ones which could get them slashed. Messages are recorded before they are signed, so a failure to sign
+// can only make the validator miss a duty.
+type SlashingProtection struct {
+	db kv.RwDB
+}
+
+func NewSlashingProtection(db kv.RwDB) *SlashingProtection {
+	return &SlashingProtection{db: db}
+}
+
+// CheckAndRecordBlock refuses a block proposal if a block with the same or a higher slot was already signed,
+// unless it is the very same block.
+func (s *SlashingProtection) CheckAndRecordBlock(ctx context.Context, pubKey common.Bytes48, slot uint64, signingRoot common.Hash) error {
+	return s.db.Update(ctx, func(tx kv.RwTx) error {
+		return checkAndRecordBlock(tx, pubKey, slot, signingRoot)
+	})
+}
+
+func checkAndRecordBlock(tx kv.RwTx, pubKey common.Bytes48, slot uint64, signingRoot common.Hash) error {
+	key := signedMessageKey(pubKey, slot)
+	prev, err := tx.GetOne(kv.ValidatorSignedBlocks, key)
+	if err != nil {
+		return err
+	}
+	if prev != nil {
+		if signingRoot != (common.Hash{}) && bytes.Equal(prev, signingRoot[:]) {
+			return nil
+		}
+		return fmt.Errorf("%w: double proposal at slot %d", ErrSlashableBlock, slot)
+	}
+	c, err := tx.Cursor(kv.ValidatorSignedBlocks)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	// the latest block of this validator is the last key with its prefix
+	k, _, err := c.Seek(signedMessageKey(pubKey, slot))
+	if err != nil {
+		return err
+	}
+	if k != nil && bytes.HasPrefix(k, pubKey[:]) {
+		return fmt.Errorf("%w: slot %d is lower than the slot of a signed block %d", ErrSlashableBlock, slot, binary.BigEndian.Uint64(k[length.Bytes48:]))
+	}
+	return tx.Put(kv.ValidatorSignedBlocks, key, signingRoot[:])
+}
+
+// CheckAndRecordAttestation refuses an attestation which is a double vote or a surround vote for one already signed,
+// or which is below the low watermark of the validator: its first attestation, raised above the pruned history.
+func (s *SlashingProtection) CheckAndRecordAttestation(ctx context.Context, pubKey common.Bytes48, sourceEpoch, targetEpoch uint64, signingRoot common.Hash) error {
+	return s.db.Update(ctx, func(tx kv.RwTx) error {
+		return checkAndRecordAttestation(tx, pubKey, sourceEpoch, targetEpoch, signingRoot)
+	})
+}
+
+func checkAndRecordAttestation(tx kv.RwTx, pubKey common.Bytes48, sourceEpoch, targetEpoch uint64, signingRoot common.Hash) error {
+	if sourceEpoch > targetEpoch {
+		return fmt.Errorf("%w: source epoch %d is greater than target epoch %d", ErrSlashableAttestation, sourceEpoch, targetEpoch)
+	}
+	key := signedMessageKey(pubKey, targetEpoch)
+	prev, err := tx.GetOne(kv.ValidatorSignedAttestations, key)
+	if err != nil {
+		return err
+	}
+	if prev != nil {
+		if signingRoot == (common.Hash{}) || !bytes.Equal(prev[8:], signingRoot[:]) {
+			return fmt.Errorf("%w: double vote for target epoch %d", ErrSlashableAttestation, targetEpoch)
+		}
+		// repeated attestation, it was checked against the history when it was recorded
+		return nil
+	}
+	minSource, minTarget, hasWatermark, err := attestationWatermark(tx, pubKey)
+	if err != nil {
+		return err
+	}
+	if hasWatermark && (sourceEpoch < minSource || targetEpoch <= minTarget) {
+		return fmt.Errorf("%w: %d=>%d is older than the history of the validator", ErrSlashableAttestation, sourceEpoch, targetEpoch)
+	}
+	c, err := tx.Cursor(kv.ValidatorSignedAttestations)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	// only the votes with a target above the source epoch can surround this one or be surrounded by it
+	for k, v, err := c.Seek(signedMessageKey(pubKey, sourceEpoch+1)); ; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if k == nil || !bytes.HasPrefix(k, pubKey[:]) {
+			break
+		}
+		target := binary.BigEndian.Uint64(k[length.Bytes48:])
+		source := binary.BigEndian.Uint64(v)
+		switch {
+		case source < sourceEpoch && targetEpoch < target:
+			return fmt.Errorf("%w: surrounded by the vote %d=>%d", ErrSlashableAttestation, source, target)
+		case sourceEpoch < source && target < targetEpoch:
+			return fmt.Errorf("%w: surrounds the vote %d=>%d", ErrSlashableAttestation, source, target)
+		}
+	}
+	v := make([]byte, 8+length.Hash)
+	binary.BigEndian.PutUint64(v, sourceEpoch)
+	copy(v[8:], signingRoot[:])
+	if err := tx.Put(kv.ValidatorSignedAttestations, key, v); err != nil {
+		return err
+	}
+	if !hasWatermark {
+		return putAttestationWatermark(tx, pubKey, sourceEpoch, targetEpoch)
+	}
+	return pruneAttestations(tx, pubKey, minSource, minTarget, targetEpoch)
+}
+
+// pruneAttestations deletes the attestations which are attestationHistoryEpochs older than the target epoch and
+// raises the watermark above them: no vote they could conflict with passes the watermark.
+func pruneAttestations(tx kv.RwTx, pubKey common.Bytes48, minSource, minTarget, targetEpoch uint64) error {
+	if targetEpoch < attestationHistoryEpochs {
+		return nil
+	}
+	cutoff := signedMessageKey(pubKey, targetEpoch-attestationHistoryEpochs)
+	it, err := tx.Prefix(kv.ValidatorSignedAttestations, pubKey[:])
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	var pruned [][]byte
+	source, target := minSource, minTarget
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return err
+		}
+		if bytes.Compare(k, cutoff) >= 0 {
+			break
+		}
+		source = max(source, binary.BigEndian.Uint64(v))
+		target = max(target, binary.BigEndian.Uint64(k[length.Bytes48:]))
+		pruned = append(pruned, common.Copy(k))
+	}
+	it.Close()
+	if len(pruned) == 0 {
+		return nil
+	}
+	for _, k := range pruned {
+		if err := tx.Delete(kv.ValidatorSignedAttestations, k); err != nil {
+			return err
+		}
+	}
+	return putAttestationWatermark(tx, pubKey, source, target)
+}
+
+// attestationWatermark returns the lowest source epoch and the target epoch the validator can no longer sign at or below.
+func attestationWatermark(tx kv.Getter, pubKey common.Bytes48) (source, target uint64, ok bool, err error) {
+	v, err := tx.GetOne(kv.ValidatorAttestationWatermarks, pubKey[:])
+	if err != nil || v == nil {
+		return 0, 0, false, err
+	}
+	return binary.BigEndian.Uint64(v), binary.BigEndian.Uint64(v[8:]), true, nil
+}
+
+func putAttestationWatermark(tx kv.RwTx, pubKey common.Bytes48, source, target uint64) error {
+	v := make([]byte, 16)
+	binary.BigEndian.PutUint64(v, source)
+	binary.BigEndian.PutUint64(v[8:], target)
+	return tx.Put(kv.ValidatorAttestationWatermarks, pubKey[:], v)
+}
+
+func signedMessageKey(pubKey common.Bytes48, slotOrEpoch uint64) []byte {
+	k := make([]byte, length.Bytes48+8)
+	copy(k, pubKey[:])
+	binary.BigEndian.PutUint64(k[length.Bytes48:], slotOrEpoch)
+	return k
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package slashing_protection
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+)
+
+func TestCheckAndRecordBlock(t *testing.T) {
+	ctx := context.Background()
+	s := NewSlashingProtection(memdb.NewTestDB(t))
+	pk1, pk2 := common.Bytes48{1}, common.Bytes48{2}
+	root1, root2 := common.Hash{1}, common.Hash{2}
+
+	require.NoError(t, s.CheckAndRecordBlock(ctx, pk1, 10, root1))
+	// same block can be signed again
+	require.NoError(t, s.CheckAndRecordBlock(ctx, pk1, 10, root1))
+	require.ErrorIs(t, s.CheckAndRecordBlock(ctx, pk1, 10, root2), ErrSlashableBlock)
+	require.ErrorIs(t, s.CheckAndRecordBlock(ctx, pk1, 9, root2), ErrSlashableBlock)
+	require.NoError(t, s.CheckAndRecordBlock(ctx, pk1, 11, root2))
+	require.ErrorIs(t, s.CheckAndRecordBlock(ctx, pk1, 10, root2), ErrSlashableBlock)
+	// other validators are independent
+	require.NoError(t, s.CheckAndRecordBlock(ctx, pk2, 5, root1))
+}
+
+func TestCheckAndRecordAttestation(t *testing.T) {
+	ctx := context.Background()
+	s := NewSlashingProtection(memdb.NewTestDB(t))
+	pk := common.Bytes48{1}
+	root1, root2 := common.Hash{1}, common.Hash{2}
+
+	require.ErrorIs(t, s.CheckAndRecordAttestation(ctx, pk, 3, 2, root1), ErrSlashableAttestation)
+	require.NoError(t, s.CheckAndRecordAttestation(ctx, pk, 2, 3, root1))
+	require.NoError(t, s.CheckAndRecordAttestation(ctx, pk, 2, 3, root1))
+	// double vote
+	require.ErrorIs(t, s.CheckAndRecordAttestation(ctx, pk, 2, 3, root2), ErrSlashableAttestation)
+	require.NoError(t, s.CheckAndRecordAttestation(ctx, pk, 5, 8, root1))
+	// surrounding vote
+	require.ErrorIs(t, s.CheckAndRecordAttestation(ctx, pk, 4, 9, root2), ErrSlashableAttestation)
+	// surrounded vote
+	require.ErrorIs(t, s.CheckAndRecordAttestation(ctx, pk, 6, 7, root2), ErrSlashableAttestation)
+	// older than the history
+	require.ErrorIs(t, s.CheckAndRecordAttestation(ctx, pk, 1, 2, root2), ErrSlashableAttestation)
+	require.NoError(t, s.CheckAndRecordAttestation(ctx, pk, 8, 9, root2))
+}
+
+func TestAttestationHistoryPruning(t *testing.T) {
+	ctx := context.Background()
+	s := NewSlashingProtection(memdb.NewTestDB(t))
+	pk := common.Bytes48{1}
+	root := common.Hash{1}
+
+	require.NoError(t, s.CheckAndRecordAttestation(ctx, pk, 10, 11, root))
+	require.NoError(t, s.CheckAndRecordAttestation(ctx, pk, 20, 21, root))
+	target := 21 + uint64(attestationHistoryEpochs)
+	require.NoError(t, s.CheckAndRecordAttestation(ctx, pk, target-1, target, root))
+	require.NoError(t, s.CheckAndRecordAttestation(ctx, pk, target, target+1, root))
+
+	require.NoError(t, s.db.View(ctx, func(tx kv.Tx) error {
+		count, err := tx.Count(kv.ValidatorSignedAttestations)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), count)
+		source, target, ok, err := attestationWatermark(tx, pk)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, uint64(20), source)
+		require.Equal(t, uint64(21), target)
+		return nil
+	}))
+	// would surround the pruned 20=>21
+	require.ErrorIs(t, s.CheckAndRecordAttestation(ctx, pk, 15, 22, root), ErrSlashableAttestation)
+	require.ErrorIs(t, s.CheckAndRecordAttestation(ctx, pk, 20, 21, root), ErrSlashableAttestation)
+	require.NoError(t, s.CheckAndRecordAttestation(ctx, pk, 20, 22, root))
+
+	// the watermark is exported in place of the pruned history
+	var out bytes.Buffer
+	require.NoError(t, s.Export(ctx, &out, common.Hash{}))
+	exported := &Interchange{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), exported))
+	require.Len(t, exported.Data[0].SignedAttestations, 4)
+	require.Equal(t, &InterchangeAttestation{SourceEpoch: 20, TargetEpoch: 21}, exported.Data[0].SignedAttestations[0])
+}
+
+func TestInterchange(t *testing.T) {
+	ctx := context.Background()
+	gvr := common.HexToHash("0x04700007fabc8282644aed6d1c7c9e21d38a03a0c4ba193f3afe428824b3a673")
+	interchange := `{
+		"metadata": {
+			"interchange_format_version": "5",
+			"genesis_validators_root": "0x04700007fabc8282644aed6d1c7c9e21d38a03a0c4ba193f3afe428824b3a673"
+		},
+		"data": [
+			{
+				"pubkey": "0xb845089a1457f811bfc000588fbb4e713669be8ce060ea6be3c6ece09afc3794106c91ca73acda5e5457122d58723bed",
+				"signed_blocks": [
+					{"slot": "81952", "signing_root": "0x4ff6f743a43f3b4f95350831aeaf0a122a1a392922c45d804280284a69eb850b"},
+					{"slot": "81951"}
+				],
+				"signed_attestations": [
+					{"source_epoch": "2290", "target_epoch": "3007", "signing_root": "0x587d6a4f59a58fe24f406e0502413e77fe1babddee641fda30034ed37ecc884d"},
+					{"source_epoch": "2290", "target_epoch": "3008"}
+				]
+			}
+		]
+	}`
+	pk := common.Bytes48(common.FromHex("0xb845089a1457f811bfc000588fbb4e713669be8ce060ea6be3c6ece09afc3794106c91ca73acda5e5457122d58723bed"))
+
+	s := NewSlashingProtection(memdb.NewTestDB(t))
+	require.Error(t, s.Import(ctx, bytes.NewBufferString(interchange), common.Hash{}))
+	require.NoError(t, s.Import(ctx, bytes.NewBufferString(interchange), gvr))
+
+	require.ErrorIs(t, s.CheckAndRecordBlock(ctx, pk, 81952, common.Hash{1}), ErrSlashableBlock)
+	require.NoError(t, s.CheckAndRecordBlock(ctx, pk, 81952, common.HexToHash("0x4ff6f743a43f3b4f95350831aeaf0a122a1a392922c45d804280284a69eb850b")))
+	require.ErrorIs(t, s.CheckAndRecordAttestation(ctx, pk, 2290, 3008, common.Hash{1}), ErrSlashableAttestation)
+	require.ErrorIs(t, s.CheckAndRecordAttestation(ctx, pk, 2289, 3009, common.Hash{1}), ErrSlashableAttestation)
+	require.NoError(t, s.CheckAndRecordAttestation(ctx, pk, 3008, 3009, common.Hash{1}))
+
+	// conflicting import forgets the signing root
+	conflicting := &Interchange{
+		Metadata: InterchangeMetadata{InterchangeFormatVersion: InterchangeFormatVersion, GenesisValidatorsRoot: gvr},
+		Data:     []*InterchangeData{{PubKey: pk, SignedAttestations: []*InterchangeAttestation{{SourceEpoch: 3000, TargetEpoch: 3009}}}},
+	}
+	encoded, err := json.Marshal(conflicting)
+	require.NoError(t, err)
+	require.NoError(t, s.Import(ctx, bytes.NewBuffer(encoded), gvr))
+	require.ErrorIs(t, s.CheckAndRecordAttestation(ctx, pk, 3008, 3009, common.Hash{1}), ErrSlashableAttestation)
+
+	var out bytes.Buffer
+	require.NoError(t, s.Export(ctx, &out, gvr))
+	exported := &Interchange{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), exported))
+	require.Equal(t, gvr, exported.Metadata.GenesisValidatorsRoot)
+	require.Len(t, exported.Data, 1)
+	require.Len(t, exported.Data[0].SignedBlocks, 2)
+	require.Len(t, exported.Data[0].SignedAttestations, 3)
+	require.Nil(t, exported.Data[0].SignedAttestations[2].SigningRoot)
+	require.Equal(t, uint64(3000), exported.Data[0].SignedAttestations[2].SourceEpoch)
+
+	// export can be imported by another node
+	other := NewSlashingProtection(memdb.NewTestDB(t))
+	require.NoError(t, other.Import(ctx, &out, gvr))
+	require.ErrorIs(t, other.CheckAndRecordBlock(ctx, pk, 81951, common.Hash{1}), ErrSlashableBlock)
+}
@@ -0,0 +1,269 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package validator_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon/cl/clparams"
+	"github.com/erigontech/erigon/cl/cltypes"
+	"github.com/erigontech/erigon/cl/cltypes/solid"
+)
+
+// BeaconApi - endpoints of the beacon node used by the validator client
+type BeaconApi interface {
+	Genesis(ctx context.Context) (*Genesis, error)
+	Fork(ctx context.Context) (*cltypes.Fork, error)
+	// ValidatorIndices returns the indices of the validators which are known to the beacon chain
+	ValidatorIndices(ctx context.Context, pubKeys []common.Bytes48) (map[common.Bytes48]uint64, error)
+	ProposerDuties(ctx context.Context, epoch uint64) ([]*ProposerDuty, error)
+	AttesterDuties(ctx context.Context, epoch uint64, indices []uint64) ([]*AttesterDuty, error)
+	SubscribeBeaconCommittees(ctx context.Context, subscriptions []*cltypes.BeaconCommitteeSubscription) error
+	AttestationData(ctx context.Context, slot, committeeIndex uint64) (solid.AttestationData, error)
+	SubmitAttestations(ctx context.Context, attestations []*solid.Attestation) error
+	AggregateAttestation(ctx context.Context, slot uint64, attestationDataRoot common.Hash) (*solid.Attestation, error)
+	SubmitAggregateAndProofs(ctx context.Context, aggregates []*cltypes.SignedAggregateAndProof) error
+	ProduceBlock(ctx context.Context, slot uint64, randaoReveal common.Bytes96, graffiti common.Hash) (*ProducedBlock, error)
+	SubmitBlock(ctx context.Context, block *cltypes.DenebSignedBeaconBlock) error
+	SubmitBlindedBlock(ctx context.Context, block *cltypes.SignedBlindedBeaconBlock) error
+}
+
+type Genesis struct {
+	GenesisTime           uint64        `json:"genesis_time,string"`
+	GenesisValidatorsRoot common.Hash   `json:"genesis_validators_root"`
+	GenesisForkVersion    common.Bytes4 `json:"genesis_fork_version"`
+}
+
+type ProposerDuty struct {
+	PubKey         common.Bytes48 `json:"pubkey"`
+	ValidatorIndex uint64         `json:"validator_index,string"`
+	Slot           uint64         `json:"slot,string"`
+}
+
+type AttesterDuty struct {
+	PubKey                  common.Bytes48 `json:"pubkey"`
+	ValidatorIndex          uint64         `json:"validator_index,string"`
+	CommitteeIndex          uint64         `json:"committee_index,string"`
+	CommitteeLength         uint64         `json:"committee_length,string"`
+	CommitteesAtSlot        uint64         `json:"committees_at_slot,string"`
+	ValidatorCommitteeIndex uint64         `json:"validator_committee_index,string"`
+	Slot                    uint64         `json:"slot,string"`
+}
+
+// ProducedBlock - unsigned block, either Block or BlindedBlock is set
+type ProducedBlock struct {
+	Version      clparams.StateVersion
+	Block        *cltypes.DenebBeaconBlock
+	BlindedBlock *cltypes.BlindedBeaconBlock
+}
+
+var _ BeaconApi = (*beaconApiClient)(nil)
+
+// beaconApiClient - client of the beacon REST API, as served by Caplin
+type beaconApiClient struct {
+	url          *url.URL
+	httpClient   *http.Client
+	beaconConfig *clparams.BeaconChainConfig
+}
+
+func NewBeaconApiClient(baseUrl string, beaconConfig *clparams.BeaconChainConfig) (BeaconApi, error) {
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &beaconApiClient{
+		url:          u,
+		httpClient:   &http.Client{},
+		beaconConfig: beaconConfig,
+	}, nil
+}
+
+func (b *beaconApiClient) Genesis(ctx context.Context) (*Genesis, error) {
+	genesis := &Genesis{}
+	return genesis, b.call(ctx, http.MethodGet, "/eth/v1/beacon/genesis", nil, nil, genesis)
+}
+
+func (b *beaconApiClient) Fork(ctx context.Context) (*cltypes.Fork, error) {
+	fork := &cltypes.Fork{}
+	return fork, b.call(ctx, http.MethodGet, "/eth/v1/beacon/states/head/fork", nil, nil, fork)
+}
+
+func (b *beaconApiClient) ValidatorIndices(ctx context.Context, pubKeys []common.Bytes48) (map[common.Bytes48]uint64, error) {
+	ids := make([]string, len(pubKeys))
+	for i, pubKey := range pubKeys {
+		ids[i] = pubKey.Hex()
+	}
+	var validators []struct {
+		Index     uint64 `json:"index,string"`
+		Validator struct {
+			PubKey common.Bytes48 `json:"pubkey"`
+		} `json:"validator"`
+	}
+	if err := b.call(ctx, http.MethodPost, "/eth/v1/beacon/states/head/validators", nil, map[string][]string{"ids": ids}, &validators); err != nil {
+		return nil, err
+	}
+	indices := make(map[common.Bytes48]uint64, len(validators))
+	for _, v := range validators {
+		indices[v.Validator.PubKey] = v.Index
+	}
+	return indices, nil
+}
+
+func (b *beaconApiClient) ProposerDuties(ctx context.Context, epoch uint64) ([]*ProposerDuty, error) {
+	var duties []*ProposerDuty
+	return duties, b.call(ctx, http.MethodGet, fmt.Sprintf("/eth/v1/validator/duties/proposer/%d", epoch), nil, nil, &duties)
+}
+
+func (b *beaconApiClient) AttesterDuties(ctx context.Context, epoch uint64, indices []uint64) ([]*AttesterDuty, error) {
+	ids := make([]string, len(indices))
+	for i, index := range indices {
+		ids[i] = strconv.FormatUint(index, 10)
+	}
+	var duties []*AttesterDuty
+	return duties, b.call(ctx, http.MethodPost, fmt.Sprintf("/eth/v1/validator/duties/attester/%d", epoch), nil, ids, &duties)
+}
+
+func (b *beaconApiClient) SubscribeBeaconCommittees(ctx context.Context, subscriptions []*cltypes.BeaconCommitteeSubscription) error {
+	return b.call(ctx, http.MethodPost, "/eth/v1/validator/beacon_committee_subscriptions", nil, subscriptions, nil)
+}
+
+func (b *beaconApiClient) AttestationData(ctx context.Context, slot, committeeIndex uint64) (solid.AttestationData, error) {
+	query := url.Values{}
+	query.Set("slot", strconv.FormatUint(slot, 10))
+	query.Set("committee_index", strconv.FormatUint(committeeIndex, 10))
+	data := solid.NewAttestationData()
+	return data, b.call(ctx, http.MethodGet, "/eth/v1/validator/attestation_data?"+query.Encode(), nil, nil, &data)
+}
+
+func (b *beaconApiClient) SubmitAttestations(ctx context.Context, attestations []*solid.Attestation) error {
+	return b.call(ctx, http.MethodPost, "/eth/v1/beacon/pool/attestations", nil, attestations, nil)
+}
+
+func (b *beaconApiClient) AggregateAttestation(ctx context.Context, slot uint64, attestationDataRoot common.Hash) (*solid.Attestation, error) {
+	query := url.Values{}
+	query.Set("slot", strconv.FormatUint(slot, 10))
+	query.Set("attestation_data_root", attestationDataRoot.Hex())
+	attestation := &solid.Attestation{}
+	return attestation, b.call(ctx, http.MethodGet, "/eth/v1/validator/aggregate_attestation?"+query.Encode(), nil, nil, attestation)
+}
+
+func (b *beaconApiClient) SubmitAggregateAndProofs(ctx context.Context, aggregates []*cltypes.SignedAggregateAndProof) error {
+	return b.call(ctx, http.MethodPost, "/eth/v1/validator/aggregate_and_proofs", nil, aggregates, nil)
+}
+
+func (b *beaconApiClient) ProduceBlock(ctx context.Context, slot uint64, randaoReveal common.Bytes96, graffiti common.Hash) (*ProducedBlock, error) {
+	query := url.Values{}
+	query.Set("randao_reveal", randaoReveal.Hex())
+	query.Set("graffiti", graffiti.Hex())
+	resp := struct {
+		Version string          `json:"version"`
+		Blinded bool            `json:"execution_payload_blinded"`
+		Data    json.RawMessage `json:"data"`
+	}{}
+	if err := b.rawCall(ctx, http.MethodGet, fmt.Sprintf("/eth/v3/validator/blocks/%d?%s", slot, query.Encode()), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	version, err := clparams.StringToClVersion(strings.ToLower(resp.Version))
+	if err != nil {
+		return nil, err
+	}
+	produced := &ProducedBlock{Version: version}
+	if resp.Blinded {
+		produced.BlindedBlock = cltypes.NewBlindedBeaconBlock(b.beaconConfig)
+		produced.BlindedBlock.SetVersion(version)
+		if err := json.Unmarshal(resp.Data, produced.BlindedBlock); err != nil {
+			return nil, err
+		}
+		return produced, nil
+	}
+	produced.Block = cltypes.NewDenebBeaconBlock(b.beaconConfig)
+	produced.Block.Block.SetVersion(version)
+	if err := json.Unmarshal(resp.Data, produced.Block); err != nil {
+		return nil, err
+	}
+	produced.Block.Block.SetVersion(version)
+	return produced, nil
+}
+
+func (b *beaconApiClient) SubmitBlock(ctx context.Context, block *cltypes.DenebSignedBeaconBlock) error {
+	headers := map[string]string{"Eth-Consensus-Version": block.SignedBlock.Version().String()}
+	return b.call(ctx, http.MethodPost, "/eth/v2/beacon/blocks", headers, block, nil)
+}
+
+func (b *beaconApiClient) SubmitBlindedBlock(ctx context.Context, block *cltypes.SignedBlindedBeaconBlock) error {
+	headers := map[string]string{"Eth-Consensus-Version": block.Version().String()}
+	return b.call(ctx, http.MethodPost, "/eth/v2/beacon/blinded_blocks", headers, block, nil)
+}
+
+// call sends the request and decodes the "data" field of the response into out
+func (b *beaconApiClient) call(ctx context.Context, method, path string, headers map[string]string, in, out any) error {
+	if out == nil {
+		return b.rawCall(ctx, method, path, headers, in, nil)
+	}
+	resp := struct {
+		Data any `json:"data"`
+	}{Data: out}
+	return b.rawCall(ctx, method, path, headers, in, &resp)
+}
+
+func (b *beaconApiClient) rawCall(ctx context.Context, method, path string, headers map[string]string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+	u, err := b.url.Parse(path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: status code %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
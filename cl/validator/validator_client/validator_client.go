@@ -0,0 +1,520 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package validator_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cl/clparams"
+	"github.com/erigontech/erigon/cl/cltypes"
+	"github.com/erigontech/erigon/cl/cltypes/solid"
+	"github.com/erigontech/erigon/cl/fork"
+	"github.com/erigontech/erigon/cl/merkle_tree"
+	"github.com/erigontech/erigon/cl/phase1/core/state"
+	"github.com/erigontech/erigon/cl/utils"
+	"github.com/erigontech/erigon/cl/validator/signer"
+	"github.com/erigontech/erigon/cl/validator/slashing_protection"
+)
+
+const retryInterval = 5 * time.Second
+
+type Config struct {
+	PubKeys  []common.Bytes48 // validators to run, all the keys of the signer if empty
+	Graffiti common.Hash
+}
+
+type attesterDuty struct {
+	*AttesterDuty
+	isAggregator   bool
+	selectionProof common.Bytes96
+}
+
+// ValidatorClient performs the duties of the validators through the beacon API: it proposes blocks, attests and
+// aggregates attestations. Messages are signed by a remote signer, after they pass the slashing protection.
+type ValidatorClient struct {
+	beaconConfig *clparams.BeaconChainConfig
+	beacon       BeaconApi
+	signer       signer.Signer
+	protection   *slashing_protection.SlashingProtection
+	cfg          Config
+	logger       log.Logger
+
+	genesis *Genesis
+	pubKeys []common.Bytes48
+
+	mu             sync.Mutex
+	fork           *cltypes.Fork
+	indices        map[common.Bytes48]uint64
+	dutiesEpochs   map[uint64]struct{}
+	proposerDuties map[uint64]*ProposerDuty   // slot => duty
+	attesterDuties map[uint64][]*attesterDuty // slot => duties
+}
+
+func NewValidatorClient(
+	beaconConfig *clparams.BeaconChainConfig,
+	beacon BeaconApi,
+	signer signer.Signer,
+	protection *slashing_protection.SlashingProtection,
+	cfg Config,
+	logger log.Logger,
+) *ValidatorClient {
+	return &ValidatorClient{
+		beaconConfig:   beaconConfig,
+		beacon:         beacon,
+		signer:         signer,
+		protection:     protection,
+		cfg:            cfg,
+		logger:         logger,
+		indices:        map[common.Bytes48]uint64{},
+		dutiesEpochs:   map[uint64]struct{}{},
+		proposerDuties: map[uint64]*ProposerDuty{},
+		attesterDuties: map[uint64][]*attesterDuty{},
+	}
+}
+
+// Run performs the duties of the validators at every slot until the context is cancelled.
+func (v *ValidatorClient) Run(ctx context.Context) error {
+	if err := v.init(ctx); err != nil {
+		return err
+	}
+	v.logger.Info("[Validator client] Started", "validators", len(v.pubKeys))
+	dutiesEpoch := uint64(0)
+	dutiesUpdated := false
+	for {
+		slot := v.nextSlot(time.Now())
+		if err := sleepUntil(ctx, v.slotStart(slot)); err != nil {
+			return err
+		}
+		epoch := slot / v.beaconConfig.SlotsPerEpoch
+		if !dutiesUpdated || epoch != dutiesEpoch {
+			if err := v.updateDuties(ctx, epoch); err != nil {
+				v.logger.Warn("[Validator client] Failed to update duties", "epoch", epoch, "err", err)
+			} else {
+				dutiesEpoch, dutiesUpdated = epoch, true
+			}
+		}
+		go v.onSlot(ctx, slot)
+	}
+}
+
+func (v *ValidatorClient) init(ctx context.Context) error {
+	for {
+		err := func() (err error) {
+			if v.genesis, err = v.beacon.Genesis(ctx); err != nil {
+				return fmt.Errorf("beacon node is not available: %w", err)
+			}
+			if len(v.cfg.PubKeys) > 0 {
+				v.pubKeys = v.cfg.PubKeys
+			} else if v.pubKeys, err = v.signer.PublicKeys(ctx); err != nil {
+				return fmt.Errorf("signer is not available: %w", err)
+			}
+			if len(v.pubKeys) == 0 {
+				return errors.New("signer has no keys")
+			}
+			return nil
+		}()
+		if err == nil {
+			return nil
+		}
+		v.logger.Warn("[Validator client] Waiting to start", "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func (v *ValidatorClient) slotStart(slot uint64) time.Time {
+	return time.Unix(int64(v.genesis.GenesisTime+slot*v.beaconConfig.SecondsPerSlot), 0)
+}
+
+// nextSlot returns the first slot which starts after now
+func (v *ValidatorClient) nextSlot(now time.Time) uint64 {
+	if now.Unix() < int64(v.genesis.GenesisTime) {
+		return 0
+	}
+	return (uint64(now.Unix())-v.genesis.GenesisTime)/v.beaconConfig.SecondsPerSlot + 1
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// updateDuties fetches the duties of the current and the next epoch, and subscribes to the committees of the aggregators.
+func (v *ValidatorClient) updateDuties(ctx context.Context, epoch uint64) error {
+	f, err := v.beacon.Fork(ctx)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.fork = f
+	v.mu.Unlock()
+
+	indices, err := v.beacon.ValidatorIndices(ctx, v.pubKeys)
+	if err != nil {
+		return err
+	}
+	validatorIndices := make([]uint64, 0, len(indices))
+	for _, index := range indices {
+		validatorIndices = append(validatorIndices, index)
+	}
+	v.mu.Lock()
+	v.indices = indices
+	v.mu.Unlock()
+	if len(validatorIndices) == 0 {
+		v.logger.Info("[Validator client] No active validators", "epoch", epoch)
+		return nil
+	}
+
+	proposerDuties, err := v.beacon.ProposerDuties(ctx, epoch)
+	if err != nil {
+		return err
+	}
+	var subscriptions []*cltypes.BeaconCommitteeSubscription
+	newAttesterDuties := map[uint64][]*attesterDuty{}
+	for _, e := range []uint64{epoch, epoch + 1} {
+		v.mu.Lock()
+		_, ok := v.dutiesEpochs[e]
+		v.mu.Unlock()
+		if ok {
+			continue
+		}
+		duties, err := v.beacon.AttesterDuties(ctx, e, validatorIndices)
+		if err != nil {
+			return err
+		}
+		for _, duty := range duties {
+			d := &attesterDuty{AttesterDuty: duty}
+			if d.selectionProof, err = v.signSelectionProof(ctx, duty.PubKey, duty.Slot); err != nil {
+				return err
+			}
+			d.isAggregator = state.IsAggregator(v.beaconConfig, duty.CommitteeLength, duty.CommitteeIndex, d.selectionProof)
+			newAttesterDuties[duty.Slot] = append(newAttesterDuties[duty.Slot], d)
+			subscriptions = append(subscriptions, &cltypes.BeaconCommitteeSubscription{
+				ValidatorIndex:   duty.ValidatorIndex,
+				CommitteeIndex:   duty.CommitteeIndex,
+				CommitteesAtSlot: duty.CommitteesAtSlot,
+				Slot:             duty.Slot,
+				IsAggregator:     d.isAggregator,
+			})
+		}
+		v.mu.Lock()
+		v.dutiesEpochs[e] = struct{}{}
+		v.mu.Unlock()
+	}
+	if len(subscriptions) > 0 {
+		if err := v.beacon.SubscribeBeaconCommittees(ctx, subscriptions); err != nil {
+			v.logger.Warn("[Validator client] Failed to subscribe to beacon committees", "err", err)
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, duty := range proposerDuties {
+		if _, ok := indices[duty.PubKey]; ok {
+			v.proposerDuties[duty.Slot] = duty
+		}
+	}
+	for slot, duties := range newAttesterDuties {
+		v.attesterDuties[slot] = duties
+	}
+	// forget the past
+	firstSlot := epoch * v.beaconConfig.SlotsPerEpoch
+	for slot := range v.proposerDuties {
+		if slot < firstSlot {
+			delete(v.proposerDuties, slot)
+		}
+	}
+	for slot := range v.attesterDuties {
+		if slot < firstSlot {
+			delete(v.attesterDuties, slot)
+		}
+	}
+	for e := range v.dutiesEpochs {
+		if e < epoch {
+			delete(v.dutiesEpochs, e)
+		}
+	}
+	return nil
+}
+
+// onSlot proposes a block at the start of the slot, attests at 1/3 of the slot and aggregates at 2/3 of the slot.
+func (v *ValidatorClient) onSlot(ctx context.Context, slot uint64) {
+	v.mu.Lock()
+	proposerDuty := v.proposerDuties[slot]
+	attesterDuties := v.attesterDuties[slot]
+	v.mu.Unlock()
+
+	if proposerDuty != nil {
+		if err := v.proposeBlock(ctx, slot, proposerDuty); err != nil {
+			v.logger.Warn("[Validator client] Failed to propose block", "slot", slot, "validator", proposerDuty.ValidatorIndex, "err", err)
+		}
+	}
+	if len(attesterDuties) == 0 {
+		return
+	}
+	third := time.Duration(v.beaconConfig.SecondsPerSlot) * time.Second / 3
+	if err := sleepUntil(ctx, v.slotStart(slot).Add(third)); err != nil {
+		return
+	}
+	data, err := v.attest(ctx, slot, attesterDuties)
+	if err != nil {
+		v.logger.Warn("[Validator client] Failed to attest", "slot", slot, "err", err)
+	}
+	if err := sleepUntil(ctx, v.slotStart(slot).Add(2*third)); err != nil {
+		return
+	}
+	if err := v.aggregate(ctx, slot, attesterDuties, data); err != nil {
+		v.logger.Warn("[Validator client] Failed to aggregate", "slot", slot, "err", err)
+	}
+}
+
+func (v *ValidatorClient) proposeBlock(ctx context.Context, slot uint64, duty *ProposerDuty) error {
+	epoch := slot / v.beaconConfig.SlotsPerEpoch
+	randaoRoot, err := v.signingRoot(merkle_tree.Uint64Root(epoch), v.beaconConfig.DomainRandao, epoch)
+	if err != nil {
+		return err
+	}
+	randaoReveal, err := v.sign(ctx, duty.PubKey, &signer.SignRequest{
+		Type:         signer.SignTypeRandaoReveal,
+		SigningRoot:  randaoRoot,
+		RandaoReveal: &signer.RandaoReveal{Epoch: epoch},
+	})
+	if err != nil {
+		return err
+	}
+	produced, err := v.beacon.ProduceBlock(ctx, slot, randaoReveal, v.cfg.Graffiti)
+	if err != nil {
+		return err
+	}
+
+	header := &cltypes.BeaconBlockHeader{}
+	var bodyRoot common.Hash
+	if produced.BlindedBlock != nil {
+		b := produced.BlindedBlock
+		header.Slot, header.ProposerIndex, header.ParentRoot, header.Root = b.Slot, b.ProposerIndex, b.ParentRoot, b.StateRoot
+		bodyRoot, err = b.Body.HashSSZ()
+	} else {
+		b := produced.Block.Block
+		header.Slot, header.ProposerIndex, header.ParentRoot, header.Root = b.Slot, b.ProposerIndex, b.ParentRoot, b.StateRoot
+		bodyRoot, err = b.Body.HashSSZ()
+	}
+	if err != nil {
+		return err
+	}
+	header.BodyRoot = bodyRoot
+	if header.Slot != slot || header.ProposerIndex != duty.ValidatorIndex {
+		return fmt.Errorf("unexpected block at slot %d of proposer %d", header.Slot, header.ProposerIndex)
+	}
+	blockRoot, err := header.HashSSZ()
+	if err != nil {
+		return err
+	}
+	signingRoot, err := v.signingRoot(blockRoot, v.beaconConfig.DomainBeaconProposer, epoch)
+	if err != nil {
+		return err
+	}
+	if err := v.protection.CheckAndRecordBlock(ctx, duty.PubKey, slot, signingRoot); err != nil {
+		return err
+	}
+	signature, err := v.sign(ctx, duty.PubKey, &signer.SignRequest{
+		Type:        signer.SignTypeBlockV2,
+		SigningRoot: signingRoot,
+		BeaconBlock: &signer.BeaconBlockToSign{
+			Version:     strings.ToUpper(produced.Version.String()),
+			BlockHeader: header,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if produced.BlindedBlock != nil {
+		err = v.beacon.SubmitBlindedBlock(ctx, &cltypes.SignedBlindedBeaconBlock{Signature: signature, Block: produced.BlindedBlock})
+	} else {
+		err = v.beacon.SubmitBlock(ctx, &cltypes.DenebSignedBeaconBlock{
+			SignedBlock: &cltypes.SignedBeaconBlock{Signature: signature, Block: produced.Block.Block},
+			KZGProofs:   produced.Block.KZGProofs,
+			Blobs:       produced.Block.Blobs,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	v.logger.Info("[Validator client] Block proposed", "slot", slot, "validator", duty.ValidatorIndex, "root", common.Hash(blockRoot), "blinded", produced.BlindedBlock != nil)
+	return nil
+}
+
+// attest signs the attestations of the slot, it returns the attestation data by committee index.
+func (v *ValidatorClient) attest(ctx context.Context, slot uint64, duties []*attesterDuty) (map[uint64]solid.AttestationData, error) {
+	attestationData := map[uint64]solid.AttestationData{}
+	var attestations []*solid.Attestation
+	var errs []error
+	for _, duty := range duties {
+		data, ok := attestationData[duty.CommitteeIndex]
+		if !ok {
+			var err error
+			if data, err = v.beacon.AttestationData(ctx, slot, duty.CommitteeIndex); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			attestationData[duty.CommitteeIndex] = data
+		}
+		attestation, err := v.signAttestation(ctx, duty, data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("validator %d: %w", duty.ValidatorIndex, err))
+			continue
+		}
+		attestations = append(attestations, attestation)
+	}
+	if len(attestations) > 0 {
+		if err := v.beacon.SubmitAttestations(ctx, attestations); err != nil {
+			errs = append(errs, err)
+		} else {
+			v.logger.Debug("[Validator client] Attestations submitted", "slot", slot, "count", len(attestations))
+		}
+	}
+	return attestationData, errors.Join(errs...)
+}
+
+func (v *ValidatorClient) signAttestation(ctx context.Context, duty *attesterDuty, data solid.AttestationData) (*solid.Attestation, error) {
+	dataRoot, err := data.HashSSZ()
+	if err != nil {
+		return nil, err
+	}
+	source, target := data.Source().Epoch(), data.Target().Epoch()
+	signingRoot, err := v.signingRoot(dataRoot, v.beaconConfig.DomainBeaconAttester, target)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.protection.CheckAndRecordAttestation(ctx, duty.PubKey, source, target, signingRoot); err != nil {
+		return nil, err
+	}
+	signature, err := v.sign(ctx, duty.PubKey, &signer.SignRequest{
+		Type:        signer.SignTypeAttestation,
+		SigningRoot: signingRoot,
+		Attestation: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return solid.NewAttestionFromParameters(aggregationBits(duty.CommitteeLength, duty.ValidatorCommitteeIndex), data, signature), nil
+}
+
+// aggregationBits returns the bitlist of the committee with the single bit of the validator set
+func aggregationBits(committeeLength, validatorCommitteeIndex uint64) []byte {
+	bits := make([]byte, committeeLength/8+1)
+	bits[validatorCommitteeIndex/8] |= 1 << (validatorCommitteeIndex % 8)
+	// length bit of the bitlist
+	bits[committeeLength/8] |= 1 << (committeeLength % 8)
+	return bits
+}
+
+func (v *ValidatorClient) aggregate(ctx context.Context, slot uint64, duties []*attesterDuty, attestationData map[uint64]solid.AttestationData) error {
+	epoch := slot / v.beaconConfig.SlotsPerEpoch
+	var aggregates []*cltypes.SignedAggregateAndProof
+	var errs []error
+	for _, duty := range duties {
+		data, ok := attestationData[duty.CommitteeIndex]
+		if !duty.isAggregator || !ok {
+			continue
+		}
+		dataRoot, err := data.HashSSZ()
+		if err != nil {
+			return err
+		}
+		aggregate, err := v.beacon.AggregateAttestation(ctx, slot, dataRoot)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		msg := &cltypes.AggregateAndProof{
+			AggregatorIndex: duty.ValidatorIndex,
+			Aggregate:       aggregate,
+			SelectionProof:  duty.selectionProof,
+		}
+		msgRoot, err := msg.HashSSZ()
+		if err != nil {
+			return err
+		}
+		signingRoot, err := v.signingRoot(msgRoot, v.beaconConfig.DomainAggregateAndProof, epoch)
+		if err != nil {
+			return err
+		}
+		signature, err := v.sign(ctx, duty.PubKey, &signer.SignRequest{
+			Type:              signer.SignTypeAggregateAndProof,
+			SigningRoot:       signingRoot,
+			AggregateAndProof: msg,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("validator %d: %w", duty.ValidatorIndex, err))
+			continue
+		}
+		aggregates = append(aggregates, &cltypes.SignedAggregateAndProof{Message: msg, Signature: signature})
+	}
+	if len(aggregates) > 0 {
+		if err := v.beacon.SubmitAggregateAndProofs(ctx, aggregates); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (v *ValidatorClient) signSelectionProof(ctx context.Context, pubKey common.Bytes48, slot uint64) (common.Bytes96, error) {
+	signingRoot, err := v.signingRoot(merkle_tree.Uint64Root(slot), v.beaconConfig.DomainSelectionProof, slot/v.beaconConfig.SlotsPerEpoch)
+	if err != nil {
+		return common.Bytes96{}, err
+	}
+	return v.sign(ctx, pubKey, &signer.SignRequest{
+		Type:            signer.SignTypeAggregationSlot,
+		SigningRoot:     signingRoot,
+		AggregationSlot: &signer.AggregationSlot{Slot: slot},
+	})
+}
+
+func (v *ValidatorClient) signingRoot(objectRoot common.Hash, domainType common.Bytes4, epoch uint64) (common.Hash, error) {
+	v.mu.Lock()
+	f := v.fork
+	v.mu.Unlock()
+	domain, err := fork.Domain(f, epoch, domainType, v.genesis.GenesisValidatorsRoot)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return utils.Sha256(objectRoot[:], domain), nil
+}
+
+func (v *ValidatorClient) sign(ctx context.Context, pubKey common.Bytes48, req *signer.SignRequest) (common.Bytes96, error) {
+	v.mu.Lock()
+	req.ForkInfo = &signer.ForkInfo{Fork: v.fork, GenesisValidatorsRoot: v.genesis.GenesisValidatorsRoot}
+	v.mu.Unlock()
+	return v.signer.Sign(ctx, pubKey, req)
+}
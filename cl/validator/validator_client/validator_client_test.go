@@ -0,0 +1,204 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package validator_client
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Giulio2002/bls"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cl/clparams"
+	"github.com/erigontech/erigon/cl/cltypes"
+	"github.com/erigontech/erigon/cl/cltypes/solid"
+	"github.com/erigontech/erigon/cl/utils"
+	"github.com/erigontech/erigon/cl/validator/signer"
+	"github.com/erigontech/erigon/cl/validator/slashing_protection"
+)
+
+type mockBeaconApi struct {
+	genesis        *Genesis
+	fork           *cltypes.Fork
+	indices        map[common.Bytes48]uint64
+	proposerDuties []*ProposerDuty
+	attesterDuties []*AttesterDuty
+	data           solid.AttestationData
+	block          *cltypes.DenebBeaconBlock
+
+	subscriptions []*cltypes.BeaconCommitteeSubscription
+	attestations  []*solid.Attestation
+	aggregates    []*cltypes.SignedAggregateAndProof
+	blocks        []*cltypes.DenebSignedBeaconBlock
+}
+
+func (m *mockBeaconApi) Genesis(ctx context.Context) (*Genesis, error)   { return m.genesis, nil }
+func (m *mockBeaconApi) Fork(ctx context.Context) (*cltypes.Fork, error) { return m.fork, nil }
+func (m *mockBeaconApi) ValidatorIndices(ctx context.Context, pubKeys []common.Bytes48) (map[common.Bytes48]uint64, error) {
+	return m.indices, nil
+}
+func (m *mockBeaconApi) ProposerDuties(ctx context.Context, epoch uint64) ([]*ProposerDuty, error) {
+	return m.proposerDuties, nil
+}
+func (m *mockBeaconApi) AttesterDuties(ctx context.Context, epoch uint64, indices []uint64) ([]*AttesterDuty, error) {
+	var duties []*AttesterDuty
+	for _, duty := range m.attesterDuties {
+		if duty.Slot/32 == epoch {
+			duties = append(duties, duty)
+		}
+	}
+	return duties, nil
+}
+func (m *mockBeaconApi) SubscribeBeaconCommittees(ctx context.Context, subscriptions []*cltypes.BeaconCommitteeSubscription) error {
+	m.subscriptions = append(m.subscriptions, subscriptions...)
+	return nil
+}
+func (m *mockBeaconApi) AttestationData(ctx context.Context, slot, committeeIndex uint64) (solid.AttestationData, error) {
+	return m.data, nil
+}
+func (m *mockBeaconApi) SubmitAttestations(ctx context.Context, attestations []*solid.Attestation) error {
+	m.attestations = append(m.attestations, attestations...)
+	return nil
+}
+func (m *mockBeaconApi) AggregateAttestation(ctx context.Context, slot uint64, attestationDataRoot common.Hash) (*solid.Attestation, error) {
+	return m.attestations[0], nil
+}
+func (m *mockBeaconApi) SubmitAggregateAndProofs(ctx context.Context, aggregates []*cltypes.SignedAggregateAndProof) error {
+	m.aggregates = append(m.aggregates, aggregates...)
+	return nil
+}
+func (m *mockBeaconApi) ProduceBlock(ctx context.Context, slot uint64, randaoReveal common.Bytes96, graffiti common.Hash) (*ProducedBlock, error) {
+	return &ProducedBlock{Version: clparams.DenebVersion, Block: m.block}, nil
+}
+func (m *mockBeaconApi) SubmitBlock(ctx context.Context, block *cltypes.DenebSignedBeaconBlock) error {
+	m.blocks = append(m.blocks, block)
+	return nil
+}
+func (m *mockBeaconApi) SubmitBlindedBlock(ctx context.Context, block *cltypes.SignedBlindedBeaconBlock) error {
+	return nil
+}
+
+func setupValidatorClient(t *testing.T) (*ValidatorClient, *mockBeaconApi, *signer.Stub, common.Bytes48) {
+	key, err := bls.GenerateKey()
+	require.NoError(t, err)
+	pubKey := common.Bytes48(bls.CompressPublicKey(key.PublicKey()))
+	stub := signer.NewStub(key)
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	s, err := signer.NewWeb3Signer(server.URL, time.Second)
+	require.NoError(t, err)
+
+	cfg := &clparams.MainnetBeaconConfig
+	block := cltypes.NewDenebBeaconBlock(cfg)
+	block.Block.SetVersion(clparams.DenebVersion)
+	block.Block.Body.SyncAggregate = cltypes.NewSyncAggregate()
+	block.Block.Body.ExecutionPayload.Extra = solid.NewExtraData()
+	block.Block.Body.ExecutionPayload.Transactions = solid.NewTransactionsSSZFromTransactions(nil)
+	block.Block.Body.ExecutionPayload.Withdrawals = solid.NewStaticListSSZ[*cltypes.Withdrawal](int(cfg.MaxWithdrawalsPerPayload), 44)
+	block.Block.Slot = 65
+	block.Block.ProposerIndex = 7
+	beacon := &mockBeaconApi{
+		genesis: &Genesis{GenesisTime: uint64(time.Now().Unix()), GenesisValidatorsRoot: common.HexToHash("0x01")},
+		fork:    &cltypes.Fork{CurrentVersion: utils.Uint32ToBytes4(uint32(cfg.DenebForkVersion)), PreviousVersion: utils.Uint32ToBytes4(uint32(cfg.CapellaForkVersion))},
+		indices: map[common.Bytes48]uint64{pubKey: 7},
+		proposerDuties: []*ProposerDuty{
+			{PubKey: pubKey, ValidatorIndex: 7, Slot: 65},
+			{PubKey: common.Bytes48{1}, ValidatorIndex: 8, Slot: 66},
+		},
+		attesterDuties: []*AttesterDuty{
+			// single validator committee, so that it is always an aggregator
+			{PubKey: pubKey, ValidatorIndex: 7, CommitteeIndex: 2, CommitteeLength: 1, CommitteesAtSlot: 4, Slot: 66},
+		},
+		data: solid.NewAttestionDataFromParameters(66, 2, common.HexToHash("0x02"),
+			solid.NewCheckpointFromParameters(common.HexToHash("0x03"), 1),
+			solid.NewCheckpointFromParameters(common.HexToHash("0x04"), 2)),
+		block: block,
+	}
+	protection := slashing_protection.NewSlashingProtection(memdb.NewTestDB(t))
+	v := NewValidatorClient(cfg, beacon, s, protection, Config{}, log.New())
+	require.NoError(t, v.init(context.Background()))
+	return v, beacon, stub, pubKey
+}
+
+func TestValidatorClientDuties(t *testing.T) {
+	ctx := context.Background()
+	v, beacon, _, _ := setupValidatorClient(t)
+
+	require.NoError(t, v.updateDuties(ctx, 2))
+	require.Len(t, v.proposerDuties, 1)
+	require.NotNil(t, v.proposerDuties[65])
+	require.Len(t, v.attesterDuties[66], 1)
+	require.True(t, v.attesterDuties[66][0].isAggregator)
+	require.Len(t, beacon.subscriptions, 1)
+	require.True(t, beacon.subscriptions[0].IsAggregator)
+
+	// duties of the known epochs are not fetched again
+	require.NoError(t, v.updateDuties(ctx, 2))
+	require.Len(t, beacon.subscriptions, 1)
+}
+
+func TestValidatorClientAttestAndAggregate(t *testing.T) {
+	ctx := context.Background()
+	v, beacon, stub, pubKey := setupValidatorClient(t)
+	require.NoError(t, v.updateDuties(ctx, 2))
+
+	data, err := v.attest(ctx, 66, v.attesterDuties[66])
+	require.NoError(t, err)
+	require.Len(t, beacon.attestations, 1)
+	attestation := beacon.attestations[0]
+	require.Equal(t, []byte{0b11}, attestation.AggregationBits())
+	signingRoot := stub.Requests()[len(stub.Requests())-1].SigningRoot
+	signature := attestation.Signature()
+	ok, err := bls.Verify(signature[:], signingRoot[:], pubKey[:])
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, v.aggregate(ctx, 66, v.attesterDuties[66], data))
+	require.Len(t, beacon.aggregates, 1)
+	require.Equal(t, uint64(7), beacon.aggregates[0].Message.AggregatorIndex)
+	require.Equal(t, signer.SignTypeAggregateAndProof, stub.Requests()[len(stub.Requests())-1].Type)
+
+	// a conflicting vote for the same target is refused by the slashing protection
+	beacon.data = solid.NewAttestionDataFromParameters(66, 2, common.HexToHash("0x05"),
+		solid.NewCheckpointFromParameters(common.HexToHash("0x03"), 1),
+		solid.NewCheckpointFromParameters(common.HexToHash("0x04"), 2))
+	_, err = v.attest(ctx, 66, v.attesterDuties[66])
+	require.ErrorIs(t, err, slashing_protection.ErrSlashableAttestation)
+	require.Len(t, beacon.attestations, 1)
+}
+
+func TestValidatorClientPropose(t *testing.T) {
+	ctx := context.Background()
+	v, beacon, stub, _ := setupValidatorClient(t)
+	require.NoError(t, v.updateDuties(ctx, 2))
+
+	require.NoError(t, v.proposeBlock(ctx, 65, v.proposerDuties[65]))
+	require.Len(t, beacon.blocks, 1)
+	requests := stub.Requests()
+	require.Equal(t, signer.SignTypeRandaoReveal, requests[len(requests)-2].Type)
+	require.Equal(t, signer.SignTypeBlockV2, requests[len(requests)-1].Type)
+
+	// a different block at the same slot is refused by the slashing protection
+	beacon.block.Block.ParentRoot = common.HexToHash("0x06")
+	require.ErrorIs(t, v.proposeBlock(ctx, 65, v.proposerDuties[65]), slashing_protection.ErrSlashableBlock)
+	require.Len(t, beacon.blocks, 1)
+}
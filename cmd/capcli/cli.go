@@ -56,6 +56,7 @@ import (
 	"github.com/erigontech/erigon/cl/phase1/stages"
 	"github.com/erigontech/erigon/cl/rpc"
 	"github.com/erigontech/erigon/cl/utils/eth_clock"
+	"github.com/erigontech/erigon/cl/validator/slashing_protection"
 	"github.com/erigontech/erigon/cmd/caplin/caplin1"
 	"github.com/erigontech/erigon/common"
 	"github.com/erigontech/erigon/eth/ethconfig"
@@ -76,6 +77,9 @@ var CLI struct {
 	BlobArchiveStoreCheck   BlobArchiveStoreCheck   `cmd:"" help:"blob archive store check"`
 	DumpBlobsSnapshots      DumpBlobsSnapshots      `cmd:"" help:"dump blobs snapshots"`
 	CheckBlobsSnapshots     CheckBlobsSnapshots     `cmd:"" help:"check blobs snapshots"`
+
+	ImportSlashingProtection ImportSlashingProtection `cmd:"" help:"import the slashing protection history of the validator client (EIP-3076)"`
+	ExportSlashingProtection ExportSlashingProtection `cmd:"" help:"export the slashing protection history of the validator client (EIP-3076)"`
 }
 
 type chainCfg struct {
@@ -992,3 +996,68 @@ func (c *CheckBlobsSnapshots) Run(ctx *Context) error {
 	}
 	return nil
 }
+
+type slashingProtectionCfg struct {
+	chainCfg
+	outputFolder
+	File string `help:"EIP-3076 interchange file" required:""`
+}
+
+func (c *slashingProtectionCfg) open(ctx context.Context) (*slashing_protection.SlashingProtection, kv.RwDB, libcommon.Hash, error) {
+	_, beaconConfig, networkType, err := clparams.GetConfigsByNetworkName(c.Chain)
+	if err != nil {
+		return nil, nil, libcommon.Hash{}, err
+	}
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StderrHandler))
+	bs, err := core.RetrieveBeaconState(ctx, beaconConfig, networkType)
+	if err != nil {
+		return nil, nil, libcommon.Hash{}, err
+	}
+	dirs := datadir.New(c.Datadir)
+	db := caplin1.OpenSlashingProtectionDatabase(dirs.CaplinValidator)
+	return slashing_protection.NewSlashingProtection(db), db, bs.GenesisValidatorsRoot(), nil
+}
+
+type ImportSlashingProtection struct {
+	slashingProtectionCfg
+}
+
+func (c *ImportSlashingProtection) Run(ctx *Context) error {
+	protection, db, genesisValidatorsRoot, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	f, err := os.Open(c.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := protection.Import(ctx, f, genesisValidatorsRoot); err != nil {
+		return err
+	}
+	log.Info("Slashing protection imported", "file", c.File)
+	return nil
+}
+
+type ExportSlashingProtection struct {
+	slashingProtectionCfg
+}
+
+func (c *ExportSlashingProtection) Run(ctx *Context) error {
+	protection, db, genesisValidatorsRoot, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	f, err := os.Create(c.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := protection.Export(ctx, f, genesisValidatorsRoot); err != nil {
+		return err
+	}
+	log.Info("Slashing protection exported", "file", c.File)
+	return nil
+}
@@ -18,7 +18,9 @@ package caplin1
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"path"
 	"time"
@@ -33,7 +35,9 @@ import (
 	"github.com/erigontech/erigon/cl/aggregation"
 	"github.com/erigontech/erigon/cl/antiquary"
 	"github.com/erigontech/erigon/cl/beacon"
+	"github.com/erigontech/erigon/cl/beacon/beacon_router_configuration"
 	"github.com/erigontech/erigon/cl/beacon/beaconevents"
+	"github.com/erigontech/erigon/cl/beacon/builder"
 	"github.com/erigontech/erigon/cl/beacon/handler"
 	"github.com/erigontech/erigon/cl/beacon/synced_data"
 	"github.com/erigontech/erigon/cl/clparams/initial_state"
@@ -46,7 +50,10 @@ import (
 	"github.com/erigontech/erigon/cl/utils/eth_clock"
 	"github.com/erigontech/erigon/cl/validator/attestation_producer"
 	"github.com/erigontech/erigon/cl/validator/committee_subscription"
+	"github.com/erigontech/erigon/cl/validator/signer"
+	"github.com/erigontech/erigon/cl/validator/slashing_protection"
 	"github.com/erigontech/erigon/cl/validator/sync_contribution_pool"
+	"github.com/erigontech/erigon/cl/validator/validator_client"
 	"github.com/erigontech/erigon/cl/validator/validator_params"
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/params"
@@ -56,9 +63,11 @@ import (
 
 	"github.com/erigontech/erigon/cl/persistence/beacon_indicies"
 	"github.com/erigontech/erigon/cl/persistence/blob_storage"
+	"github.com/erigontech/erigon/cl/persistence/deposit_tree"
 	"github.com/erigontech/erigon/cl/persistence/format/snapshot_format"
 	state_accessors "github.com/erigontech/erigon/cl/persistence/state"
 	"github.com/erigontech/erigon/cl/persistence/state/historical_states_reader"
+	"github.com/erigontech/erigon/cl/phase1/core"
 	"github.com/erigontech/erigon/cl/phase1/core/state"
 	"github.com/erigontech/erigon/cl/phase1/execution_client"
 	"github.com/erigontech/erigon/cl/phase1/forkchoice"
@@ -118,6 +127,45 @@ func OpenCaplinDatabase(ctx context.Context,
 	return db, blob_storage.NewBlobStore(blobDB, afero.NewBasePathFs(afero.NewOsFs(), blobDir), blobPruneDistance, beaconConfig, ethClock), nil
 }
 
+// OpenSlashingProtectionDatabase opens the slashing protection history of the built-in validator client.
+func OpenSlashingProtectionDatabase(dbPath string) kv.RwDB {
+	os.MkdirAll(dbPath, 0700)
+	return mdbx.MustOpen(path.Join(dbPath, "slashing_protection"))
+}
+
+// openDepositTree loads the persisted deposit tree. If there is none, the tree is seeded either as empty,
+// if no deposit is processed by the anchor state, or from the deposit snapshot of the checkpoint sync endpoints.
+func openDepositTree(ctx context.Context, indexDB kv.RwDB, beaconConfig *clparams.BeaconChainConfig, anchorState *state.CachingBeaconState, logger log.Logger) (*deposit_tree.Store, error) {
+	depositTree := deposit_tree.NewStore()
+	if err := indexDB.View(ctx, depositTree.Load); err != nil {
+		return nil, err
+	}
+	if depositTree.Initialized() {
+		return depositTree, nil
+	}
+	snapshot := &deposit_tree.Snapshot{DepositRoot: (&deposit_tree.Tree{}).DepositRoot()}
+	if anchorState.Eth1DepositIndex() > 0 {
+		fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		var err error
+		if snapshot, err = core.RetrieveDepositSnapshot(fetchCtx, clparams.NetworkType(beaconConfig.DepositNetworkID)); err != nil {
+			logger.Info("Deposit snapshot is not available", "err", err)
+			return depositTree, nil
+		}
+		// the snapshot must account for the deposits processed by the anchor state and no more
+		if snapshot.DepositCount != anchorState.Eth1DepositIndex() {
+			logger.Info("Deposit snapshot does not match the anchor state", "deposits", snapshot.DepositCount, "eth1DepositIndex", anchorState.Eth1DepositIndex())
+			return depositTree, nil
+		}
+	}
+	if err := indexDB.Update(ctx, func(tx kv.RwTx) error {
+		return depositTree.Initialize(tx, snapshot, anchorState.Slot())
+	}); err != nil {
+		return nil, err
+	}
+	return depositTree, nil
+}
+
 func RunCaplinPhase1(ctx context.Context, engine execution_client.ExecutionEngine, config *ethconfig.Config, networkConfig *clparams.NetworkConfig,
 	beaconConfig *clparams.BeaconChainConfig, ethClock eth_clock.EthereumClock, state *state.CachingBeaconState, dirs datadir.Dirs, eth1Getter snapshot_format.ExecutionBlockReaderByNumber,
 	snDownloader proto_downloader.DownloaderClient, indexDB kv.RwDB, blobStorage blob_storage.BlobStorage, creds credentials.TransportCredentials, snBuildSema *semaphore.Weighted, caplinOptions ...CaplinOption) error {
@@ -291,7 +339,25 @@ func RunCaplinPhase1(ctx context.Context, engine execution_client.ExecutionEngin
 
 	statesReader := historical_states_reader.NewHistoricalStatesReader(beaconConfig, rcsn, vTables, genesisState)
 	validatorParameters := validator_params.NewValidatorParams()
+	// the deposit tree is only served by the beacon api
+	var depositTree *deposit_tree.Store
+	if config.BeaconRouter.Active {
+		if depositTree, err = openDepositTree(ctx, indexDB, beaconConfig, state, logger); err != nil {
+			return err
+		}
+	}
 	if config.BeaconRouter.Active {
+		var builderCircuitBreaker *builder.CircuitBreaker
+		if option.builderClient != nil {
+			builderCircuitBreaker = builder.NewCircuitBreaker(builder.CircuitBreakerConfig{
+				MaxMissedSlotsPerEpoch: config.CaplinConfig.MevMaxMissedSlots,
+				MaxRelayFaults:         config.CaplinConfig.MevMaxRelayFaults,
+				FaultCooldownEpochs:    config.CaplinConfig.MevFaultCooldownEpochs,
+				MinBid:                 new(big.Int).Mul(new(big.Int).SetUint64(config.CaplinConfig.MevMinBidGwei), big.NewInt(1_000_000_000)),
+				LocalValueBoost:        config.CaplinConfig.MevLocalValueBoost,
+				HeaderTimeout:          config.CaplinConfig.MevHeaderTimeout,
+			})
+		}
 		apiHandler := handler.NewApiHandler(
 			logger,
 			networkConfig,
@@ -323,13 +389,20 @@ func RunCaplinPhase1(ctx context.Context, engine execution_client.ExecutionEngin
 			blsToExecutionChangeService,
 			proposerSlashingService,
 			option.builderClient,
+			builderCircuitBreaker,
 			validatorMonitor,
+			depositTree,
 		)
 		go beacon.ListenAndServe(&beacon.LayeredBeaconHandler{
 			ArchiveApi: apiHandler,
 		}, config.BeaconRouter)
 		log.Info("Beacon API started", "addr", config.BeaconRouter.Address)
 	}
+	if config.CaplinConfig.ValidatorClientEnabled() {
+		if err := runValidatorClient(ctx, beaconConfig, config.CaplinConfig, config.BeaconRouter, dirs, logger); err != nil {
+			return err
+		}
+	}
 
 	stageCfg := stages.ClStagesCfg(
 		beaconRpc,
@@ -352,6 +425,7 @@ func RunCaplinPhase1(ctx context.Context, engine execution_client.ExecutionEngin
 		blobStorage,
 		attestationProducer,
 		validatorMonitor,
+		depositTree,
 	)
 	sync := stages.ConsensusClStages(ctx, stageCfg)
 
@@ -363,3 +437,36 @@ func RunCaplinPhase1(ctx context.Context, engine execution_client.ExecutionEngin
 	}
 	return err
 }
+
+// runValidatorClient starts the built-in validator client, it performs the duties through the beacon API of this node
+// and delegates signing to the remote signer.
+func runValidatorClient(ctx context.Context, beaconConfig *clparams.BeaconChainConfig, config clparams.CaplinConfig,
+	router beacon_router_configuration.RouterConfiguration, dirs datadir.Dirs, logger log.Logger) error {
+	if !router.Active || !router.Beacon || !router.Validator {
+		return errors.New("the validator client requires the beacon and validator endpoints of the beacon API")
+	}
+	remoteSigner, err := signer.NewWeb3Signer(config.ValidatorSignerUrl, config.ValidatorSignerTimeout)
+	if err != nil {
+		return err
+	}
+	beaconApi, err := validator_client.NewBeaconApiClient("http://"+router.Address, beaconConfig)
+	if err != nil {
+		return err
+	}
+	db := OpenSlashingProtectionDatabase(dirs.CaplinValidator)
+	validatorClient := validator_client.NewValidatorClient(
+		beaconConfig,
+		beaconApi,
+		remoteSigner,
+		slashing_protection.NewSlashingProtection(db),
+		validator_client.Config{PubKeys: config.ValidatorPubKeys, Graffiti: config.ValidatorGraffiti},
+		logger,
+	)
+	go func() {
+		defer db.Close()
+		if err := validatorClient.Run(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("[Validator client] Stopped", "err", err)
+		}
+	}()
+	return nil
+}
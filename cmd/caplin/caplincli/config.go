@@ -56,6 +56,8 @@ type CaplinCliCfg struct {
 	MevRelayUrl           string        `json:"mev_relay_url"`
 	JwtSecret             []byte
 
+	CaplinConfig clparams.CaplinConfig `json:"-"`
+
 	AllowedMethods   []string `json:"allowed_methods"`
 	AllowedOrigins   []string `json:"allowed_origins"`
 	AllowCredentials bool     `json:"allow_credentials"`
@@ -125,6 +127,8 @@ func SetupCaplinCli(ctx *cli.Context) (cfg *CaplinCliCfg, err error) {
 	cfg.TransitionChain = ctx.Bool(caplinflags.TransitionChainFlag.Name)
 	cfg.InitialSync = ctx.Bool(caplinflags.InitSyncFlag.Name)
 	cfg.MevRelayUrl = ctx.String(caplinflags.MevRelayUrl.Name)
+	utils.SetCaplinMevCircuitBreaker(ctx, &cfg.CaplinConfig)
+	utils.SetCaplinValidatorClient(ctx, &cfg.CaplinConfig)
 
 	return cfg, err
 }
@@ -37,6 +37,16 @@ var CliFlags = []cli.Flag{
 	&EngineApiHostFlag,
 	&EngineApiPortFlag,
 	&MevRelayUrl,
+	&utils.CaplinMevMaxMissedSlotsFlag,
+	&utils.CaplinMevMaxRelayFaultsFlag,
+	&utils.CaplinMevFaultCooldownEpochsFlag,
+	&utils.CaplinMevMinBidFlag,
+	&utils.CaplinMevLocalValueBoostFlag,
+	&utils.CaplinMevHeaderTimeoutFlag,
+	&utils.CaplinValidatorSignerUrlFlag,
+	&utils.CaplinValidatorSignerTimeoutFlag,
+	&utils.CaplinValidatorPubKeysFlag,
+	&utils.CaplinValidatorGraffitiFlag,
 	&JwtSecret,
 	&utils.DataDirFlag,
 	&utils.BeaconApiAllowCredentialsFlag,
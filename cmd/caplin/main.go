@@ -29,7 +29,6 @@ import (
 	"github.com/erigontech/erigon-lib/common/mem"
 	"github.com/erigontech/erigon-lib/log/v3"
 	"github.com/erigontech/erigon/cl/beacon/beacon_router_configuration"
-	"github.com/erigontech/erigon/cl/clparams"
 	"github.com/erigontech/erigon/cl/phase1/core"
 	"github.com/erigontech/erigon/cl/phase1/core/state"
 	execution_client2 "github.com/erigontech/erigon/cl/phase1/execution_client"
@@ -161,6 +160,6 @@ func runCaplinNode(cliCtx *cli.Context) error {
 		CaplinDiscoveryPort:    uint64(cfg.Port),
 		CaplinDiscoveryTCPPort: uint64(cfg.ServerTcpPort),
 		BeaconRouter:           rcfg,
-		CaplinConfig:           clparams.CaplinConfig{},
+		CaplinConfig:           cfg.CaplinConfig,
 	}, cfg.NetworkCfg, cfg.BeaconCfg, ethClock, state, cfg.Dirs, nil, nil, indiciesDB, blobStorage, nil, blockSnapBuildSema, options...)
 }
@@ -175,6 +175,10 @@ func (m *BlockProducer) Configure(baseNode NodeArgs, nodeNumber int) error {
 		core.DevnetEtherbase = m.account.Address
 		core.DevnetSignPrivateKey = m.account.SigKey()
 
+		if len(m.HttpApi) == 0 {
+			m.HttpApi = "admin,eth,erigon,web3,net,debug,trace,txpool,parity,ots,clique"
+		}
+
 	case networkname.BorDevnetChainName:
 		m.account = accounts.NewAccount(m.GetName() + "-etherbase")
 
@@ -0,0 +1,165 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cmd/devnet/clique"
+	"github.com/erigontech/erigon/cmd/devnet/requests"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/logging"
+)
+
+var (
+	SealerURLsFlag = cli.StringSliceFlag{
+		Name:     "sealers",
+		Usage:    "Comma separated RPC endpoints (host:port) of the sealing nodes, the first one is used for queries",
+		Required: true,
+	}
+
+	SignerFlag = cli.StringFlag{
+		Name:     "signer",
+		Usage:    "Address of the signer to vote on",
+		Required: true,
+	}
+
+	DropFlag = cli.BoolFlag{
+		Name:  "drop",
+		Usage: "Vote to deauthorize the signer instead of authorizing it",
+	}
+
+	AddSignerFlag = cli.StringFlag{
+		Name:  "add",
+		Usage: "Address of the signer to vote in",
+	}
+
+	RemoveSignerFlag = cli.StringFlag{
+		Name:  "remove",
+		Usage: "Address of the signer to vote out",
+	}
+
+	StatusBlocksFlag = cli.Uint64Flag{
+		Name:  "blocks",
+		Usage: "Number of recent blocks to report the signer activity for",
+		Value: 64,
+	}
+
+	VoteTimeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "How long to wait for the rotation votes to pass",
+		Value: 10 * time.Minute,
+	}
+)
+
+var cliqueCommand = cli.Command{
+	Name:  "clique",
+	Usage: "Manage the signer set of a running clique network",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "propose",
+			Usage:  "Make the sealers vote on a signer in the headers they seal",
+			Action: cliqueAction(cliquePropose),
+			Flags:  []cli.Flag{&SealerURLsFlag, &SignerFlag, &DropFlag},
+		},
+		{
+			Name:   "discard",
+			Usage:  "Make the sealers stop voting on a signer",
+			Action: cliqueAction(cliqueDiscard),
+			Flags:  []cli.Flag{&SealerURLsFlag, &SignerFlag},
+		},
+		{
+			Name:   "status",
+			Usage:  "Print the signers, pending proposals and signer activity",
+			Action: cliqueAction(cliqueStatus),
+			Flags:  []cli.Flag{&SealerURLsFlag, &StatusBlocksFlag},
+		},
+		{
+			Name:   "rotate",
+			Usage:  "Vote a signer in and/or another one out, waiting for each vote to pass",
+			Action: cliqueAction(cliqueRotate),
+			Flags:  []cli.Flag{&SealerURLsFlag, &AddSignerFlag, &RemoveSignerFlag, &VoteTimeoutFlag},
+		},
+	},
+}
+
+func cliqueAction(action func(ctx *cli.Context, sealers []requests.RequestGenerator, logger log.Logger) error) cli.ActionFunc {
+	return func(ctx *cli.Context) error {
+		logger := logging.SetupLoggerCtx("devnet", ctx, log.LvlInfo, log.LvlInfo, false /* rootLogger */)
+
+		var sealers []requests.RequestGenerator
+		for _, url := range ctx.StringSlice(SealerURLsFlag.Name) {
+			sealers = append(sealers, requests.NewRequestGenerator(strings.TrimPrefix(url, "http://"), logger))
+		}
+
+		return action(ctx, sealers, logger)
+	}
+}
+
+func cliquePropose(ctx *cli.Context, sealers []requests.RequestGenerator, logger log.Logger) error {
+	return clique.Propose(ctx.Context, sealers, libcommon.HexToAddress(ctx.String(SignerFlag.Name)), !ctx.Bool(DropFlag.Name))
+}
+
+func cliqueDiscard(ctx *cli.Context, sealers []requests.RequestGenerator, logger log.Logger) error {
+	return clique.Discard(ctx.Context, sealers, libcommon.HexToAddress(ctx.String(SignerFlag.Name)))
+}
+
+func cliqueStatus(ctx *cli.Context, sealers []requests.RequestGenerator, logger log.Logger) error {
+	signers, err := sealers[0].CliqueGetSigners(ctx.Context, rpc.LatestBlockNumber)
+	if err != nil {
+		return err
+	}
+	status, err := sealers[0].CliqueStatus(ctx.Context, ctx.Uint64(StatusBlocksFlag.Name))
+	if err != nil {
+		return err
+	}
+	proposals := make([]map[libcommon.Address]bool, len(sealers))
+	for i, sealer := range sealers {
+		if proposals[i], err = sealer.CliqueProposals(ctx.Context); err != nil {
+			return fmt.Errorf("sealer %d: %w", i, err)
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Signers   []libcommon.Address          `json:"signers"`
+		Proposals []map[libcommon.Address]bool `json:"proposals"`
+		Status    *requests.CliqueStatus       `json:"status"`
+	}{signers, proposals, status})
+}
+
+func cliqueRotate(ctx *cli.Context, sealers []requests.RequestGenerator, logger log.Logger) error {
+	if !ctx.IsSet(AddSignerFlag.Name) && !ctx.IsSet(RemoveSignerFlag.Name) {
+		return fmt.Errorf("at least one of --%s and --%s is required", AddSignerFlag.Name, RemoveSignerFlag.Name)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx.Context, ctx.Duration(VoteTimeoutFlag.Name))
+	defer cancel()
+
+	return clique.Rotate(runCtx, sealers, libcommon.HexToAddress(ctx.String(AddSignerFlag.Name)), libcommon.HexToAddress(ctx.String(RemoveSignerFlag.Name)), logger)
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cmd/devnet/devnet"
+	"github.com/erigontech/erigon/cmd/devnet/requests"
+	"github.com/erigontech/erigon/cmd/devnet/scenarios"
+	"github.com/erigontech/erigon/rpc"
+)
+
+const pollInterval = time.Second
+
+func init() {
+	scenarios.MustRegisterStepHandlers(
+		scenarios.StepHandler(CliquePropose),
+		scenarios.StepHandler(CliqueDiscard),
+		scenarios.StepHandler(AwaitCliqueSigner),
+	)
+}
+
+// CliquePropose casts the vote on all the block producers of the current network
+func CliquePropose(ctx context.Context, address string, auth bool) error {
+	return Propose(ctx, blockProducers(ctx), libcommon.HexToAddress(address), auth)
+}
+
+// CliqueDiscard drops the vote from all the block producers of the current network
+func CliqueDiscard(ctx context.Context, address string) error {
+	return Discard(ctx, blockProducers(ctx), libcommon.HexToAddress(address))
+}
+
+// AwaitCliqueSigner waits until the signer set of the current node includes (or excludes) the address
+func AwaitCliqueSigner(ctx context.Context, address string, authorized bool, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return AwaitSigner(ctx, devnet.SelectNode(ctx), libcommon.HexToAddress(address), authorized)
+}
+
+func blockProducers(ctx context.Context) []requests.RequestGenerator {
+	var sealers []requests.RequestGenerator
+	for _, node := range devnet.CurrentNetwork(ctx).BlockProducers() {
+		sealers = append(sealers, node)
+	}
+	return sealers
+}
+
+// Propose makes every sealer vote for (or against) the address in the headers it seals
+func Propose(ctx context.Context, sealers []requests.RequestGenerator, address libcommon.Address, auth bool) error {
+	for i, sealer := range sealers {
+		if err := sealer.CliquePropose(ctx, address, auth); err != nil {
+			return fmt.Errorf("sealer %d: propose %s: %w", i, address, err)
+		}
+	}
+	return nil
+}
+
+// Discard makes every sealer stop voting on the address
+func Discard(ctx context.Context, sealers []requests.RequestGenerator, address libcommon.Address) error {
+	for i, sealer := range sealers {
+		if err := sealer.CliqueDiscard(ctx, address); err != nil {
+			return fmt.Errorf("sealer %d: discard %s: %w", i, address, err)
+		}
+	}
+	return nil
+}
+
+// AwaitSigner polls the node until the signer set at the chain head includes
+// (authorized) or excludes (!authorized) the address
+func AwaitSigner(ctx context.Context, node requests.RequestGenerator, address libcommon.Address, authorized bool) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		signers, err := node.CliqueGetSigners(ctx, rpc.LatestBlockNumber)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(signers, address) == authorized {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("awaiting signer %s (authorized=%t): %w", address, authorized, ctx.Err())
+		}
+	}
+}
+
+// Rotate replaces a signer of the network: the sealers vote the new signer in,
+// then vote the old one out. Each proposal is discarded once it has passed, so
+// the sealers don't keep pushing it after the rotation. A zero address skips
+// the corresponding half of the rotation.
+func Rotate(ctx context.Context, sealers []requests.RequestGenerator, add, remove libcommon.Address, logger log.Logger) error {
+	if len(sealers) == 0 {
+		return fmt.Errorf("no sealers to vote")
+	}
+
+	if add != (libcommon.Address{}) {
+		logger.Info("Voting signer in", "signer", add, "sealers", len(sealers))
+		if err := vote(ctx, sealers, add, true); err != nil {
+			return err
+		}
+		logger.Info("Signer authorized", "signer", add)
+	}
+
+	if remove != (libcommon.Address{}) {
+		logger.Info("Voting signer out", "signer", remove, "sealers", len(sealers))
+		if err := vote(ctx, sealers, remove, false); err != nil {
+			return err
+		}
+		logger.Info("Signer deauthorized", "signer", remove)
+	}
+
+	return nil
+}
+
+func vote(ctx context.Context, sealers []requests.RequestGenerator, address libcommon.Address, auth bool) error {
+	if err := Propose(ctx, sealers, address, auth); err != nil {
+		return err
+	}
+	if err := AwaitSigner(ctx, sealers[0], address, auth); err != nil {
+		return err
+	}
+	return Discard(ctx, sealers, address)
+}
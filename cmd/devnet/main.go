@@ -53,10 +53,9 @@ import (
 
 var (
 	DataDirFlag = flags.DirectoryFlag{
-		Name:     "datadir",
-		Usage:    "Data directory for the devnet",
-		Value:    flags.DirectoryString(""),
-		Required: true,
+		Name:  "datadir",
+		Usage: "Data directory for the devnet",
+		Value: flags.DirectoryString(""),
 	}
 
 	ChainFlag = cli.StringFlag{
@@ -173,6 +172,9 @@ func main() {
 	app := cli.NewApp()
 	app.Version = params.VersionWithCommit(params.GitCommit)
 	app.Action = mainContext
+	app.Commands = []*cli.Command{
+		&cliqueCommand,
+	}
 
 	app.Flags = []cli.Flag{
 		&DataDirFlag,
@@ -247,6 +249,11 @@ func connectDiagnosticsIfEnabled(ctx *cli.Context, logger log.Logger) {
 }
 
 func mainContext(ctx *cli.Context) error {
+	// not marked as required, as the subcommands don't run a devnet
+	if !ctx.IsSet(DataDirFlag.Name) {
+		return fmt.Errorf("required flag %q not set", DataDirFlag.Name)
+	}
+
 	debug.RaiseFdLimit()
 
 	logger, err := setupLogger(ctx)
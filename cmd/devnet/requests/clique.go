@@ -0,0 +1,70 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package requests
+
+import (
+	"context"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon/rpc"
+)
+
+type CliqueStatus struct {
+	InturnPercent       float64                       `json:"inturnPercent"`
+	SealerActivity      map[libcommon.Address]int     `json:"sealerActivity"`
+	SealerInturnPercent map[libcommon.Address]float64 `json:"sealerInturnPercent"`
+	NumBlocks           uint64                        `json:"numBlocks"`
+}
+
+func (req *requestGenerator) CliqueGetSigners(ctx context.Context, blockNum rpc.BlockNumber) ([]libcommon.Address, error) {
+	var result []libcommon.Address
+
+	if err := req.rpcCall(ctx, &result, Methods.CliqueGetSigners, blockNum); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (req *requestGenerator) CliqueProposals(ctx context.Context) (map[libcommon.Address]bool, error) {
+	var result map[libcommon.Address]bool
+
+	if err := req.rpcCall(ctx, &result, Methods.CliqueProposals); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (req *requestGenerator) CliquePropose(ctx context.Context, address libcommon.Address, auth bool) error {
+	return req.rpcCall(ctx, nil, Methods.CliquePropose, address, auth)
+}
+
+func (req *requestGenerator) CliqueDiscard(ctx context.Context, address libcommon.Address) error {
+	return req.rpcCall(ctx, nil, Methods.CliqueDiscard, address)
+}
+
+func (req *requestGenerator) CliqueStatus(ctx context.Context, numBlocks uint64) (*CliqueStatus, error) {
+	var result CliqueStatus
+
+	if err := req.rpcCall(ctx, &result, Methods.CliqueStatus, hexutil.Uint64(numBlocks)); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
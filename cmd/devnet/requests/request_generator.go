@@ -92,6 +92,12 @@ type RequestGenerator interface {
 	GasPrice() (*big.Int, error)
 
 	GetRootHash(ctx context.Context, startBlock uint64, endBlock uint64) (libcommon.Hash, error)
+
+	CliqueGetSigners(ctx context.Context, blockNum rpc.BlockNumber) ([]libcommon.Address, error)
+	CliqueProposals(ctx context.Context) (map[libcommon.Address]bool, error)
+	CliquePropose(ctx context.Context, address libcommon.Address, auth bool) error
+	CliqueDiscard(ctx context.Context, address libcommon.Address) error
+	CliqueStatus(ctx context.Context, numBlocks uint64) (*CliqueStatus, error)
 }
 
 type requestGenerator struct {
@@ -145,6 +151,11 @@ var Methods = struct {
 	ETHGetTransactionReceipt RPCMethod
 	BorGetRootHash           RPCMethod
 	ETHCall                  RPCMethod
+	CliqueGetSigners         RPCMethod
+	CliqueProposals          RPCMethod
+	CliquePropose            RPCMethod
+	CliqueDiscard            RPCMethod
+	CliqueStatus             RPCMethod
 }{
 	ETHGetTransactionCount:   "eth_getTransactionCount",
 	ETHGetBalance:            "eth_getBalance",
@@ -168,6 +179,11 @@ var Methods = struct {
 	ETHGetTransactionReceipt: "eth_getTransactionReceipt",
 	BorGetRootHash:           "bor_getRootHash",
 	ETHCall:                  "eth_call",
+	CliqueGetSigners:         "clique_getSigners",
+	CliqueProposals:          "clique_proposals",
+	CliquePropose:            "clique_propose",
+	CliqueDiscard:            "clique_discard",
+	CliqueStatus:             "clique_status",
 }
 
 func (req *requestGenerator) rpcCallJSON(method RPCMethod, body string, response interface{}) callResult {
@@ -0,0 +1,145 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/tests"
+)
+
+var (
+	EOFHexFlag = cli.StringFlag{
+		Name:  "hex",
+		Usage: "hex encoded EOF container",
+	}
+	EOFInitcodeFlag = cli.BoolFlag{
+		Name:  "initcode",
+		Usage: "validate the containers as initcode (creation txn, EOFCREATE) instead of runtime code",
+	}
+	EOFPrintFlag = cli.BoolFlag{
+		Name:  "print",
+		Usage: "print the layout of the valid containers",
+	}
+)
+
+var eofParseCommand = cli.Command{
+	Action:    eofParseCmd,
+	Name:      "eofparse",
+	Aliases:   []string{"eof"},
+	Usage:     "parses and validates EOF containers: the --hex one, hex lines from stdin or the given EOF test files",
+	ArgsUsage: "<file>...",
+	Flags: []cli.Flag{
+		&EOFHexFlag,
+		&EOFInitcodeFlag,
+		&EOFPrintFlag,
+	},
+}
+
+// EOFTestResult is the outcome of an EOF test from a test file.
+type EOFTestResult struct {
+	Name  string `json:"name"`
+	Pass  bool   `json:"pass"`
+	Error string `json:"error,omitempty"`
+}
+
+func eofParseCmd(ctx *cli.Context) error {
+	if ctx.Args().Len() > 0 {
+		for _, fname := range ctx.Args().Slice() {
+			if err := runEOFTest(fname); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	isInitcode, print := ctx.Bool(EOFInitcodeFlag.Name), ctx.Bool(EOFPrintFlag.Name)
+	if ctx.IsSet(EOFHexFlag.Name) {
+		fmt.Println(parseEOF(ctx.String(EOFHexFlag.Name), isInitcode, print))
+		return nil
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 2*1024*1024) // hex of the max initcode size fits
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fmt.Println(parseEOF(line, isInitcode, print))
+	}
+	return scanner.Err()
+}
+
+// parseEOF returns "OK <code sections>" for the valid container and "err: <reason>" otherwise
+func parseEOF(input string, isInitcode, print bool) string {
+	code, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return fmt.Sprintf("err: invalid hex: %v", err)
+	}
+	c, err := vm.ParseAndValidateEOF(code, isInitcode)
+	if err != nil {
+		return fmt.Sprintf("err: %v", err)
+	}
+	if print {
+		return "OK\n" + c.String()
+	}
+	sections := make([]string, c.NumCodeSections())
+	for i := range sections {
+		sections[i] = fmt.Sprintf("%x", c.CodeSection(i))
+	}
+	return "OK " + strings.Join(sections, ",")
+}
+
+// runEOFTest loads the EOF tests given by fname, validates their vectors and prints the results.
+func runEOFTest(fname string) error {
+	src, err := os.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	var eofTests map[string]tests.EOFTest
+	if err = json.Unmarshal(src, &eofTests); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(eofTests))
+	for name := range eofTests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := make([]EOFTestResult, 0, len(eofTests))
+	for _, name := range names {
+		test := eofTests[name]
+		result := EOFTestResult{Name: name, Pass: true}
+		if err := test.Run(); err != nil {
+			result.Pass, result.Error = false, err.Error()
+		}
+		results = append(results, result)
+	}
+
+	out, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,328 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/common/math"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rlp"
+)
+
+//go:generate gencodec -type header -field-override headerMarshaling -out gen_header.go
+type header struct {
+	ParentHash            libcommon.Hash     `json:"parentHash"`
+	OmmerHash             *libcommon.Hash    `json:"ommersHash"`
+	Coinbase              *libcommon.Address `json:"miner"`
+	Root                  libcommon.Hash     `json:"stateRoot"        gencodec:"required"`
+	TxHash                *libcommon.Hash    `json:"transactionsRoot"`
+	ReceiptHash           *libcommon.Hash    `json:"receiptsRoot"`
+	Bloom                 types.Bloom        `json:"logsBloom"`
+	Difficulty            *big.Int           `json:"difficulty"`
+	Number                *big.Int           `json:"number"           gencodec:"required"`
+	GasLimit              uint64             `json:"gasLimit"         gencodec:"required"`
+	GasUsed               uint64             `json:"gasUsed"`
+	Time                  uint64             `json:"timestamp"        gencodec:"required"`
+	Extra                 []byte             `json:"extraData"`
+	MixDigest             libcommon.Hash     `json:"mixHash"`
+	Nonce                 *types.BlockNonce  `json:"nonce"`
+	BaseFee               *big.Int           `json:"baseFeePerGas"`
+	WithdrawalsHash       *libcommon.Hash    `json:"withdrawalsRoot"`
+	BlobGasUsed           *uint64            `json:"blobGasUsed"`
+	ExcessBlobGas         *uint64            `json:"excessBlobGas"`
+	ParentBeaconBlockRoot *libcommon.Hash    `json:"parentBeaconBlockRoot"`
+	RequestsRoot          *libcommon.Hash    `json:"requestsRoot"`
+}
+
+type headerMarshaling struct {
+	Difficulty    *math.HexOrDecimal256
+	Number        *math.HexOrDecimal256
+	GasLimit      math.HexOrDecimal64
+	GasUsed       math.HexOrDecimal64
+	Time          math.HexOrDecimal64
+	Extra         hexutility.Bytes
+	BaseFee       *math.HexOrDecimal256
+	BlobGasUsed   *math.HexOrDecimal64
+	ExcessBlobGas *math.HexOrDecimal64
+}
+
+// bbInput is the input of the block builder, every part may be read from stdin.
+type bbInput struct {
+	Header      *header             `json:"header,omitempty"`
+	OmmersRlp   []string            `json:"ommers,omitempty"`
+	TxRlp       string              `json:"txs,omitempty"`
+	Withdrawals []*types.Withdrawal `json:"withdrawals,omitempty"`
+	RequestsRlp string              `json:"requests,omitempty"`
+
+	Ommers   []*types.Header     `json:"-"`
+	Txs      []types.Transaction `json:"-"`
+	Requests types.Requests      `json:"-"`
+}
+
+// ToBlock assembles the block. The header roots which aren't given are derived from the body.
+func (i *bbInput) ToBlock() *types.Block {
+	h := &types.Header{
+		ParentHash:            i.Header.ParentHash,
+		UncleHash:             types.EmptyUncleHash,
+		Root:                  i.Header.Root,
+		TxHash:                types.EmptyRootHash,
+		ReceiptHash:           types.EmptyRootHash,
+		Bloom:                 i.Header.Bloom,
+		Difficulty:            libcommon.Big0,
+		Number:                i.Header.Number,
+		GasLimit:              i.Header.GasLimit,
+		GasUsed:               i.Header.GasUsed,
+		Time:                  i.Header.Time,
+		Extra:                 i.Header.Extra,
+		MixDigest:             i.Header.MixDigest,
+		BaseFee:               i.Header.BaseFee,
+		WithdrawalsHash:       i.Header.WithdrawalsHash,
+		BlobGasUsed:           i.Header.BlobGasUsed,
+		ExcessBlobGas:         i.Header.ExcessBlobGas,
+		ParentBeaconBlockRoot: i.Header.ParentBeaconBlockRoot,
+		RequestsRoot:          i.Header.RequestsRoot,
+	}
+
+	// Fill optional values.
+	if i.Header.OmmerHash != nil {
+		h.UncleHash = *i.Header.OmmerHash
+	} else if len(i.Ommers) != 0 {
+		h.UncleHash = types.CalcUncleHash(i.Ommers)
+	}
+	if i.Header.Coinbase != nil {
+		h.Coinbase = *i.Header.Coinbase
+	}
+	if i.Header.TxHash != nil {
+		h.TxHash = *i.Header.TxHash
+	} else if len(i.Txs) != 0 {
+		h.TxHash = types.DeriveSha(types.Transactions(i.Txs))
+	}
+	if i.Header.ReceiptHash != nil {
+		h.ReceiptHash = *i.Header.ReceiptHash
+	}
+	if i.Header.Nonce != nil {
+		h.Nonce = *i.Header.Nonce
+	}
+	if i.Header.Difficulty != nil {
+		h.Difficulty = i.Header.Difficulty
+	}
+	if i.Withdrawals != nil && h.WithdrawalsHash == nil {
+		withdrawalsHash := types.DeriveSha(types.Withdrawals(i.Withdrawals))
+		h.WithdrawalsHash = &withdrawalsHash
+	}
+	if i.Requests != nil && h.RequestsRoot == nil {
+		requestsRoot := types.DeriveSha(i.Requests)
+		h.RequestsRoot = &requestsRoot
+	}
+
+	// the body parts of the forks are present if the header has their roots
+	body := &types.Body{Transactions: i.Txs, Uncles: i.Ommers, Withdrawals: i.Withdrawals, Requests: i.Requests}
+	if h.WithdrawalsHash != nil && body.Withdrawals == nil {
+		body.Withdrawals = []*types.Withdrawal{}
+	}
+	if h.RequestsRoot != nil && body.Requests == nil {
+		body.Requests = types.Requests{}
+	}
+	return types.NewBlockFromNetwork(h, body)
+}
+
+// BuildBlock is the entry point of the block builder (b11r): it assembles the block RLP from
+// the header, ommers, transactions, withdrawals and requests.
+func BuildBlock(ctx *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StderrHandler))
+
+	baseDir, err := createBasedir(ctx)
+	if err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed creating output basedir: %v", err))
+	}
+	inputData, err := readBlockInput(ctx)
+	if err != nil {
+		return err
+	}
+	block := inputData.ToBlock()
+	return dispatchBlock(ctx, baseDir, block)
+}
+
+func readBlockInput(ctx *cli.Context) (*bbInput, error) {
+	var (
+		headerStr      = ctx.String(InputHeaderFlag.Name)
+		ommersStr      = ctx.String(InputOmmersFlag.Name)
+		withdrawalsStr = ctx.String(InputWithdrawalsFlag.Name)
+		txsStr         = ctx.String(InputTxsRlpFlag.Name)
+		requestsStr    = ctx.String(InputRequestsRlpFlag.Name)
+		inputData      = &bbInput{}
+	)
+	if headerStr == stdinSelector || ommersStr == stdinSelector || txsStr == stdinSelector || withdrawalsStr == stdinSelector || requestsStr == stdinSelector {
+		decoder := json.NewDecoder(os.Stdin)
+		if err := decoder.Decode(inputData); err != nil {
+			return nil, NewError(ErrorJson, fmt.Errorf("failed unmarshaling stdin: %v", err))
+		}
+	}
+	if headerStr != stdinSelector {
+		var env header
+		if err := readFile(headerStr, "header", &env); err != nil {
+			return nil, err
+		}
+		inputData.Header = &env
+	}
+	if inputData.Header == nil {
+		return nil, NewError(ErrorVMConfig, errors.New("missing header"))
+	}
+	if ommersStr != stdinSelector && ommersStr != "" {
+		var ommers []string
+		if err := readFile(ommersStr, "ommers", &ommers); err != nil {
+			return nil, err
+		}
+		inputData.OmmersRlp = ommers
+	}
+	if withdrawalsStr != stdinSelector && withdrawalsStr != "" {
+		var withdrawals []*types.Withdrawal
+		if err := readFile(withdrawalsStr, "withdrawals", &withdrawals); err != nil {
+			return nil, err
+		}
+		inputData.Withdrawals = withdrawals
+	}
+	if txsStr != stdinSelector {
+		var txs string
+		if err := readFile(txsStr, "txs", &txs); err != nil {
+			return nil, err
+		}
+		inputData.TxRlp = txs
+	}
+	if requestsStr != stdinSelector && requestsStr != "" {
+		var requests string
+		if err := readFile(requestsStr, "requests", &requests); err != nil {
+			return nil, err
+		}
+		inputData.RequestsRlp = requests
+	}
+
+	// Deserialize the RLP parts
+	for _, str := range inputData.OmmersRlp {
+		var ommer types.Header
+		if err := rlp.DecodeBytes(libcommon.FromHex(str), &ommer); err != nil {
+			return nil, NewError(ErrorRlp, fmt.Errorf("unable to decode ommer: %v", err))
+		}
+		inputData.Ommers = append(inputData.Ommers, &ommer)
+	}
+	txs, err := decodeTxsRlp(inputData.TxRlp)
+	if err != nil {
+		return nil, err
+	}
+	inputData.Txs = txs
+	if inputData.RequestsRlp != "" {
+		if err := rlp.DecodeBytes(libcommon.FromHex(inputData.RequestsRlp), &inputData.Requests); err != nil {
+			return nil, NewError(ErrorRlp, fmt.Errorf("unable to decode requests: %v", err))
+		}
+	}
+	return inputData, nil
+}
+
+// decodeTxsRlp decodes the hex of the RLP list of transactions, the t8n body output
+func decodeTxsRlp(txsRlp string) ([]types.Transaction, error) {
+	if len(txsRlp) == 0 {
+		return nil, nil
+	}
+	s := rlp.NewStream(bytes.NewReader(libcommon.FromHex(txsRlp)), 0)
+	if _, err := s.List(); err != nil {
+		return nil, NewError(ErrorRlp, fmt.Errorf("unable to decode transactions: %v", err))
+	}
+	var txs []types.Transaction
+	for {
+		tx, err := types.DecodeRLPTransaction(s, false /* blobTxnsAreWrappedWithBlobs */)
+		if errors.Is(err, rlp.EOL) {
+			break
+		}
+		if err != nil {
+			return nil, NewError(ErrorRlp, fmt.Errorf("unable to decode transaction %d: %v", len(txs), err))
+		}
+		txs = append(txs, tx)
+	}
+	if err := s.ListEnd(); err != nil {
+		return nil, NewError(ErrorRlp, fmt.Errorf("unable to decode transactions: %v", err))
+	}
+	return txs, nil
+}
+
+// readFile decodes the JSON file into v
+func readFile(path, desc string, v interface{}) error {
+	inFile, err := os.Open(path)
+	if err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed reading %s file: %v", desc, err))
+	}
+	defer inFile.Close()
+	decoder := json.NewDecoder(inFile)
+	if err := decoder.Decode(v); err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed unmarshaling %s file: %v", desc, err))
+	}
+	return nil
+}
+
+// createBasedir makes sure the --output.basedir exists and returns it
+func createBasedir(ctx *cli.Context) (string, error) {
+	baseDir := ""
+	if ctx.IsSet(OutputBasedir.Name) {
+		if base := ctx.String(OutputBasedir.Name); len(base) > 0 {
+			if err := os.MkdirAll(base, 0755); err != nil {
+				return "", err
+			}
+			baseDir = base
+		}
+	}
+	return baseDir, nil
+}
+
+// dispatchBlock writes the RLP and the hash of the block to stdout, stderr or the --output.block file
+func dispatchBlock(ctx *cli.Context, baseDir string, block *types.Block) error {
+	raw, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return NewError(ErrorRlp, fmt.Errorf("failed encoding block: %v", err))
+	}
+	type blockInfo struct {
+		Rlp  hexutility.Bytes `json:"rlp"`
+		Hash libcommon.Hash   `json:"hash"`
+	}
+	enc, err := json.MarshalIndent(blockInfo{Rlp: raw, Hash: block.Hash()}, "", "  ")
+	if err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+	}
+	switch dest := ctx.String(OutputBlockFlag.Name); dest {
+	case "stdout":
+		os.Stdout.Write(enc)
+		os.Stdout.Write([]byte("\n"))
+	case "stderr":
+		os.Stderr.Write(enc)
+		os.Stderr.Write([]byte("\n"))
+	default:
+		if err := saveFile(baseDir, dest, blockInfo{Rlp: raw, Hash: block.Hash()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
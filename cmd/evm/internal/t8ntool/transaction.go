@@ -0,0 +1,158 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rlp"
+	"github.com/erigontech/erigon/tests"
+)
+
+// txResult is the outcome of the validation of a single transaction
+type txResult struct {
+	Error        error
+	Address      libcommon.Address
+	Hash         libcommon.Hash
+	IntrinsicGas uint64
+}
+
+func (r *txResult) MarshalJSON() ([]byte, error) {
+	type xx struct {
+		Error        *string            `json:"error,omitempty"`
+		Address      *libcommon.Address `json:"address,omitempty"`
+		Hash         *libcommon.Hash    `json:"hash,omitempty"`
+		IntrinsicGas hexutil.Uint64     `json:"intrinsicGas,omitempty"`
+	}
+	var out xx
+	if r.Error != nil {
+		errMsg := r.Error.Error()
+		out.Error = &errMsg
+	}
+	if r.Address != (libcommon.Address{}) {
+		out.Address = &r.Address
+	}
+	if r.Hash != (libcommon.Hash{}) {
+		out.Hash = &r.Hash
+	}
+	out.IntrinsicGas = hexutil.Uint64(r.IntrinsicGas)
+	return json.Marshal(out)
+}
+
+// Transaction is the entry point of the transaction validator (t9n): it decodes the RLP list of
+// transactions and checks them against the fork rules, returning sender, hash and intrinsic gas.
+func Transaction(ctx *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StderrHandler))
+
+	// Construct the chainconfig
+	chainConfig, _, err := tests.GetChainConfig(ctx.String(ForknameFlag.Name))
+	if err != nil {
+		return NewError(ErrorVMConfig, fmt.Errorf("failed constructing chain configuration: %v", err))
+	}
+	// Set the chain id
+	chainConfig.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
+
+	var body string
+	if txStr := ctx.String(InputTxsFlag.Name); txStr == stdinSelector {
+		var inputData struct {
+			TxRlp string `json:"txsRlp,omitempty"`
+		}
+		decoder := json.NewDecoder(os.Stdin)
+		if err := decoder.Decode(&inputData); err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed unmarshaling stdin: %v", err))
+		}
+		body = inputData.TxRlp
+	} else if err := readFile(txStr, "txs", &body); err != nil {
+		return err
+	}
+
+	var (
+		rules   = chainConfig.Rules(0, 0)
+		signer  = types.MakeSigner(chainConfig, 0, 0)
+		results []txResult
+	)
+	it, err := rlp.NewListIterator(libcommon.FromHex(body))
+	if err != nil {
+		return NewError(ErrorRlp, fmt.Errorf("failed decoding transactions list: %v", err))
+	}
+	for it.Next() {
+		if err := it.Err(); err != nil {
+			return NewError(ErrorIO, err)
+		}
+		tx, err := types.DecodeTransaction(it.Value())
+		if err != nil {
+			results = append(results, txResult{Error: err})
+			continue
+		}
+		result := txResult{Hash: tx.Hash()}
+		result.Address, result.Error = signer.Sender(tx)
+		if result.Error != nil {
+			results = append(results, result)
+			continue
+		}
+		result.IntrinsicGas, result.Error = validateTransaction(tx, rules)
+		results = append(results, result)
+	}
+
+	out, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// validateTransaction returns the intrinsic gas of the transaction and the error of the checks
+// which don't depend on the state: fee caps, initcode size and intrinsic gas.
+func validateTransaction(tx types.Transaction, rules *chain.Rules) (uint64, error) {
+	var authorizationsLen uint64
+	if setCodeTx, ok := tx.(*types.SetCodeTransaction); ok {
+		authorizationsLen = uint64(len(setCodeTx.GetAuthorizations()))
+	}
+	isCreate := tx.GetTo() == nil
+	gas, err := core.IntrinsicGas(tx.GetData(), tx.GetAccessList(), isCreate, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai, authorizationsLen)
+	if err != nil {
+		return gas, err
+	}
+	if tx.GetGas() < gas {
+		return gas, fmt.Errorf("%w: have %d, want %d", core.ErrIntrinsicGas, tx.GetGas(), gas)
+	}
+	if tx.GetFeeCap().Cmp(tx.GetTip()) < 0 {
+		return gas, core.ErrTipAboveFeeCap
+	}
+	if rules.IsShanghai && isCreate && len(tx.GetData()) > params.MaxInitCodeSize {
+		return gas, fmt.Errorf("%w: code size %d limit %d", core.ErrMaxInitCodeSizeExceeded, len(tx.GetData()), params.MaxInitCodeSize)
+	}
+	if isCreate && (tx.Type() == types.BlobTxType || tx.Type() == types.SetCodeTxType) {
+		return gas, errors.New("transaction type can't create a contract")
+	}
+	return gas, nil
+}
@@ -158,6 +158,36 @@ var stateTransitionCommand = cli.Command{
 	},
 }
 
+var transactionCommand = cli.Command{
+	Name:    "transaction",
+	Aliases: []string{"t9n"},
+	Usage:   "performs transaction validation",
+	Action:  t8ntool.Transaction,
+	Flags: []cli.Flag{
+		&t8ntool.InputTxsFlag,
+		&t8ntool.ChainIDFlag,
+		&t8ntool.ForknameFlag,
+		&t8ntool.VerbosityFlag,
+	},
+}
+
+var blockBuilderCommand = cli.Command{
+	Name:    "block-builder",
+	Aliases: []string{"b11r"},
+	Usage:   "builds a block",
+	Action:  t8ntool.BuildBlock,
+	Flags: []cli.Flag{
+		&t8ntool.OutputBasedir,
+		&t8ntool.OutputBlockFlag,
+		&t8ntool.InputHeaderFlag,
+		&t8ntool.InputOmmersFlag,
+		&t8ntool.InputWithdrawalsFlag,
+		&t8ntool.InputTxsRlpFlag,
+		&t8ntool.InputRequestsRlpFlag,
+		&t8ntool.VerbosityFlag,
+	},
+}
+
 func init() {
 	app.Flags = []cli.Flag{
 		&BenchFlag,
@@ -187,9 +217,12 @@ func init() {
 	app.Commands = []*cli.Command{
 		&compileCommand,
 		&disasmCommand,
+		&eofParseCommand,
 		&runCommand,
 		&stateTestCommand,
 		&stateTransitionCommand,
+		&transactionCommand,
+		&blockBuilderCommand,
 	}
 }
 
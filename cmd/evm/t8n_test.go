@@ -253,6 +253,158 @@ func TestT8n(t *testing.T) {
 	}
 }
 
+type t9nInput struct {
+	inTxs  string
+	stFork string
+}
+
+func (args *t9nInput) get(base string) []string {
+	var out []string
+	if opt := args.inTxs; opt != "" {
+		out = append(out, "--input.txs")
+		out = append(out, fmt.Sprintf("%v/%v", base, opt))
+	}
+	if opt := args.stFork; opt != "" {
+		out = append(out, "--state.fork", opt)
+	}
+	return out
+}
+
+func TestT9n(t *testing.T) {
+	tt := new(testT8n)
+	tt.TestCmd = cmdtest.NewTestCmd(t, tt)
+	for i, tc := range []struct {
+		base        string
+		input       t9nInput
+		expExitCode int
+		expOut      string
+	}{
+		{ // London txs: valid, intrinsic gas too low, tip above fee cap, valid creation
+			base: "./testdata/15",
+			input: t9nInput{
+				inTxs:  "txs.json",
+				stFork: "London",
+			},
+			expOut: "exp.json",
+		},
+		{ // Same txs on Frontier: no replay protection nor typed txs
+			base: "./testdata/15",
+			input: t9nInput{
+				inTxs:  "txs.json",
+				stFork: "Frontier",
+			},
+			expOut: "exp_frontier.json",
+		},
+		{ // Unknown fork
+			base: "./testdata/15",
+			input: t9nInput{
+				inTxs:  "txs.json",
+				stFork: "Frontier+1346",
+			},
+			expExitCode: 3,
+		},
+	} {
+		args := []string{"t9n"}
+		args = append(args, tc.input.get(tc.base)...)
+		tt.Logf("args: %v\n", strings.Join(args, " "))
+		tt.Run("evm-test", args...)
+		if tc.expOut != "" {
+			want, err := os.ReadFile(fmt.Sprintf("%v/%v", tc.base, tc.expOut))
+			if err != nil {
+				t.Fatalf("test %d: could not read expected output: %v", i, err)
+			}
+			have := tt.Output()
+			ok, err := cmpJson(have, want)
+			switch {
+			case err != nil:
+				t.Fatalf("test %d, json parsing failed: %v", i, err)
+			case !ok:
+				t.Fatalf("test %d: output wrong, have \n%v\nwant\n%v\n", i, string(have), string(want))
+			}
+		}
+		tt.WaitExit()
+		if have, want := tt.ExitStatus(), tc.expExitCode; have != want {
+			t.Fatalf("test %d: wrong exit code, have %d, want %d", i, have, want)
+		}
+	}
+}
+
+type b11rInput struct {
+	inHeader string
+	inOmmers string
+	inTxsRlp string
+}
+
+func (args *b11rInput) get(base string) []string {
+	var out []string
+	if opt := args.inHeader; opt != "" {
+		out = append(out, "--input.header")
+		out = append(out, fmt.Sprintf("%v/%v", base, opt))
+	}
+	if opt := args.inOmmers; opt != "" {
+		out = append(out, "--input.ommers")
+		out = append(out, fmt.Sprintf("%v/%v", base, opt))
+	}
+	if opt := args.inTxsRlp; opt != "" {
+		out = append(out, "--input.txs")
+		out = append(out, fmt.Sprintf("%v/%v", base, opt))
+	}
+	out = append(out, "--output.block", "stdout")
+	return out
+}
+
+func TestB11r(t *testing.T) {
+	tt := new(testT8n)
+	tt.TestCmd = cmdtest.NewTestCmd(t, tt)
+	for i, tc := range []struct {
+		base        string
+		input       b11rInput
+		expExitCode int
+		expOut      string
+	}{
+		{ // block with legacy and dynamic fee txs
+			base: "./testdata/20",
+			input: b11rInput{
+				inHeader: "header.json",
+				inOmmers: "ommers.json",
+				inTxsRlp: "txs.rlp",
+			},
+			expOut: "exp.json",
+		},
+		{ // missing header file
+			base: "./testdata/20",
+			input: b11rInput{
+				inHeader: "missing.json",
+				inTxsRlp: "txs.rlp",
+			},
+			expExitCode: 11,
+		},
+	} {
+		args := []string{"b11r"}
+		args = append(args, tc.input.get(tc.base)...)
+		tt.Logf("args: %v\n", strings.Join(args, " "))
+		tt.Run("evm-test", args...)
+		if tc.expOut != "" {
+			want, err := os.ReadFile(fmt.Sprintf("%v/%v", tc.base, tc.expOut))
+			if err != nil {
+				t.Fatalf("test %d: could not read expected output: %v", i, err)
+			}
+			have := tt.Output()
+			ok, err := cmpJson(have, want)
+			switch {
+			case err != nil:
+				t.Fatalf("test %d, json parsing failed: %v", i, err)
+			case !ok:
+				t.Fatalf("test %d: output wrong, have \n%v\nwant\n%v\n", i, string(have), string(want))
+			}
+		}
+		tt.WaitExit()
+		if have, want := tt.ExitStatus(), tc.expExitCode; have != want {
+			t.Fatalf("test %d: wrong exit code, have %d, want %d", i, have, want)
+		}
+	}
+}
+
 // cmpJson compares the JSON in two byte slices.
 func cmpJson(a, b []byte) (bool, error) {
 	var j, j2 interface{}
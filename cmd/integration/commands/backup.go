@@ -0,0 +1,83 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/kv/backup"
+
+	"github.com/erigontech/erigon/turbo/debug"
+)
+
+var cmdBackup = &cobra.Command{
+	Use:   "backup",
+	Short: "Online backup of '--datadir' (chaindata, consensus DBs and snapshots) to new dir '--to'. Node may keep running.",
+	Example: `integration backup --datadir=/erigon --to=/backups/2024-10-01
+integration backup --datadir=/erigon --to=/backups/2024-10-02 --previous=/backups/2024-10-01`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := debug.SetupCobra(cmd, "integration")
+		ctx, _ := common.RootContext()
+		cfg := backup.DatadirCfg{
+			Previous:         backupPrevious,
+			Link:             backupLink,
+			ReadAheadThreads: backup.ReadAheadThreads,
+			Workers:          backupWorkers,
+		}
+		if _, err := backup.Datadir(ctx, datadir.New(datadirCli), backupDir, cfg, logger); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				logger.Error(err.Error())
+			}
+			return err
+		}
+		return nil
+	},
+}
+
+var cmdRestore = &cobra.Command{
+	Use:   "restore",
+	Short: "Verify backup '--from' and restore it to '--datadir', which must have no chaindata, consensus DBs and snapshots",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := debug.SetupCobra(cmd, "integration")
+		ctx, _ := common.RootContext()
+		cfg := backup.RestoreCfg{Link: backupLink, Workers: backupWorkers}
+		if err := backup.Restore(ctx, backupDir, datadir.New(datadirCli), cfg, logger); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				logger.Error(err.Error())
+			}
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	withDataDir2(cmdBackup)
+	withBackup(cmdBackup, "to", "new dir for the backup")
+	cmdBackup.Flags().StringVar(&backupPrevious, "previous", "", "dir of previous backup: makes backup incremental - snapshot files stored there are not copied again")
+	must(cmdBackup.MarkFlagDirname("previous"))
+	rootCmd.AddCommand(cmdBackup)
+
+	withDataDir2(cmdRestore)
+	withBackup(cmdRestore, "from", "dir of the backup")
+	rootCmd.AddCommand(cmdRestore)
+}
@@ -60,6 +60,10 @@ var (
 	_forceSetHistoryV3    bool
 	workers, reconWorkers uint64
 	dbWriteMap            bool
+
+	backupDir, backupPrevious string
+	backupLink                bool
+	backupWorkers             int
 )
 
 func must(err error) {
@@ -158,6 +162,14 @@ func withDataDir(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&dbWriteMap, utils.DbWriteMapFlag.Name, utils.DbWriteMapFlag.Value, utils.DbWriteMapFlag.Usage)
 }
 
+func withBackup(cmd *cobra.Command, dirFlag, dirUsage string) {
+	cmd.Flags().StringVar(&backupDir, dirFlag, "", dirUsage)
+	must(cmd.MarkFlagDirname(dirFlag))
+	must(cmd.MarkFlagRequired(dirFlag))
+	cmd.Flags().BoolVar(&backupLink, "link", false, "hard-link snapshot files instead of copying them (must be same filesystem)")
+	cmd.Flags().IntVar(&backupWorkers, "backup.workers", 4, "amount of files copied in parallel")
+}
+
 func withBatchSize(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&batchSizeStr, "batchSize", cli.BatchSizeFlag.Value, cli.BatchSizeFlag.Usage)
 }
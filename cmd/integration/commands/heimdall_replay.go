@@ -0,0 +1,72 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/polygon/heimdall"
+	"github.com/erigontech/erigon/turbo/debug"
+)
+
+var (
+	heimdallReplayDir  string
+	heimdallReplayAddr string
+)
+
+var cmdHeimdallReplay = &cobra.Command{
+	Use:   "heimdall_replay",
+	Short: "Serve heimdall responses recorded by node with '--bor.heimdall.record' - to run polygon sync offline",
+	Example: `erigon --chain=amoy --polygon.sync --bor.heimdall.record=/heimdall-records ...
+integration heimdall_replay --from=/heimdall-records --addr=localhost:1317
+erigon --chain=amoy --polygon.sync --bor.heimdall=http://localhost:1317 ...`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := debug.SetupCobra(cmd, "integration")
+		ctx, _ := common.RootContext()
+
+		server := &http.Server{
+			Addr:              heimdallReplayAddr,
+			Handler:           heimdall.NewReplayHandler(heimdallReplayDir, logger),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			<-ctx.Done()
+			_ = server.Close()
+		}()
+
+		logger.Info("[integration] serving recorded heimdall responses", "dir", heimdallReplayDir, "addr", heimdallReplayAddr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(err.Error())
+			return err
+		}
+		return ctx.Err()
+	},
+}
+
+func init() {
+	cmdHeimdallReplay.Flags().StringVar(&heimdallReplayDir, "from", "", "dir with recorded heimdall responses")
+	must(cmdHeimdallReplay.MarkFlagRequired("from"))
+	must(cmdHeimdallReplay.MarkFlagDirname("from"))
+	cmdHeimdallReplay.Flags().StringVar(&heimdallReplayAddr, "addr", "localhost:1317", "listen address")
+	rootCmd.AddCommand(cmdHeimdallReplay)
+}
@@ -75,6 +75,7 @@ import (
 	"github.com/erigontech/erigon/node/nodecfg"
 	"github.com/erigontech/erigon/polygon/bor"
 	"github.com/erigontech/erigon/polygon/bor/borcfg"
+	"github.com/erigontech/erigon/polygon/bridge"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/rpc/rpccfg"
 	"github.com/erigontech/erigon/turbo/debug"
@@ -109,6 +110,7 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().Uint64Var(&cfg.MaxTraces, "trace.maxtraces", 200, "Sets a limit on traces that can be returned in trace_filter")
 
 	rootCmd.PersistentFlags().StringVar(&cfg.RpcAllowListFilePath, utils.RpcAccessListFlag.Name, "", "Specify granular (method-by-method) API allowlist")
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcQuotasFilePath, utils.RpcQuotasFileFlag.Name, "", utils.RpcQuotasFileFlag.Usage)
 	rootCmd.PersistentFlags().UintVar(&cfg.RpcBatchConcurrency, utils.RpcBatchConcurrencyFlag.Name, 2, utils.RpcBatchConcurrencyFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.RpcStreamingDisable, utils.RpcStreamingDisableFlag.Name, false, utils.RpcStreamingDisableFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.DebugSingleRequest, utils.HTTPDebugSingleFlag.Name, false, utils.HTTPDebugSingleFlag.Usage)
@@ -121,6 +123,10 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().StringVar(&cfg.GRPCListenAddress, "grpc.addr", nodecfg.DefaultGRPCHost, "GRPC server listening interface")
 	rootCmd.PersistentFlags().IntVar(&cfg.GRPCPort, "grpc.port", nodecfg.DefaultGRPCPort, "GRPC server listening port")
 	rootCmd.PersistentFlags().BoolVar(&cfg.GRPCHealthCheckEnabled, "grpc.healthcheck", false, "Enable GRPC health check")
+	rootCmd.PersistentFlags().DurationVar(&cfg.HealthCheckTimeout, "health.timeout", health.DefaultConfig.CheckTimeout, "Timeout of every check of /health/live, /health/ready, /health/startup probes")
+	rootCmd.PersistentFlags().DurationVar(&cfg.HealthMaxHeadAge, "health.max_head_age", health.DefaultConfig.MaxHeadAge, "/health/ready fails if the head (set by last forkchoice update) is older")
+	rootCmd.PersistentFlags().Uint64Var(&cfg.HealthMaxBlocksBehind, "health.max_blocks_behind", health.DefaultConfig.MaxBlocksBehind, "/health/ready fails if the executed block is behind the highest seen block by more blocks")
+	rootCmd.PersistentFlags().Uint64Var(&cfg.HealthMaxCacheLag, "health.max_cache_lag", health.DefaultConfig.MaxCacheLag, "/health/ready fails if the state cache is behind the db by more state versions")
 	rootCmd.PersistentFlags().Float64Var(&ethconfig.Defaults.RPCTxFeeCap, utils.RPCGlobalTxFeeCapFlag.Name, utils.RPCGlobalTxFeeCapFlag.Value, utils.RPCGlobalTxFeeCapFlag.Usage)
 	rootCmd.PersistentFlags().StringVar(&cfg.TLSCertfile, "tls.cert", "", "certificate for client side TLS handshake for GRPC")
 	rootCmd.PersistentFlags().StringVar(&cfg.TLSKeyFile, "tls.key", "", "key file for client side TLS handshake for GRPC")
@@ -522,10 +528,19 @@ func RemoteServices(ctx context.Context, cfg *httpcfg.HttpCfg, logger log.Logger
 
 				borConfig := cc.Bor.(*borcfg.BorConfig)
 
-				engine = bor.NewRo(cc, borKv, blockReader,
+				borEngine := bor.NewRo(cc, borKv, blockReader,
 					bor.NewChainSpanner(bor.GenesisContractValidatorSetABI(), cc, true, logger),
 					bor.NewGenesisContractsClient(cc, borConfig.ValidatorContract, borConfig.StateReceiverContract, logger), logger)
 
+				// polygon bridge db exists only if node runs with polygon sync
+				bridgeReader, err := bridge.AssembleReader(ctx, cfg.DataDir, logger, borConfig, bor.GenesisContractStateReceiverABI())
+				if err == nil {
+					borEngine.SetBridgeReader(bridgeReader)
+				} else if !errors.Is(err, kv2.ErrDBDoesNotExists) {
+					logger.Warn("[rpc] Opening polygon bridge db", "err", err)
+				}
+				engine = borEngine
+
 			default:
 				engine = ethash.NewFaker()
 			}
@@ -562,9 +577,10 @@ func RemoteServices(ctx context.Context, cfg *httpcfg.HttpCfg, logger log.Logger
 	return db, eth, txPool, mining, stateCache, blockReader, engine, ff, err
 }
 
-func StartRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []rpc.API, logger log.Logger) error {
+// StartRpcServer - healthComponents are checked by /health/live, /health/ready, /health/startup probes, nil - probes are disabled
+func StartRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []rpc.API, healthComponents *health.Components, logger log.Logger) error {
 	if cfg.Enabled {
-		return startRegularRpcServer(ctx, cfg, rpcAPI, logger)
+		return startRegularRpcServer(ctx, cfg, rpcAPI, healthComponents, logger)
 	}
 
 	return nil
@@ -582,7 +598,7 @@ func StartRpcServerWithJwtAuthentication(ctx context.Context, cfg *httpcfg.HttpC
 	return nil
 }
 
-func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []rpc.API, logger log.Logger) error {
+func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []rpc.API, healthComponents *health.Components, logger log.Logger) error {
 	// register apis and create handler stack
 	srv := rpc.NewServer(cfg.RpcBatchConcurrency, cfg.TraceRequests, cfg.DebugSingleRequest, cfg.RpcStreamingDisable, logger, cfg.RPCSlowLogThreshold)
 
@@ -592,6 +608,14 @@ func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []r
 	}
 	srv.SetAllowList(allowListForRPC)
 
+	quotas, err := parseQuotasForRPC(cfg.RpcQuotasFilePath)
+	if err != nil {
+		return fmt.Errorf("could not load RPC quotas: %w", err)
+	}
+	if quotas != nil {
+		srv.SetQuotas(quotas)
+	}
+
 	srv.SetBatchLimit(cfg.BatchLimit)
 
 	defer srv.Stop()
@@ -648,7 +672,16 @@ func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []r
 		wsHandler = srv.WebsocketHandler([]string{"*"}, nil, cfg.WebsocketCompression, logger)
 	}
 	graphQLHandler := graphql.CreateHandler(defaultAPIList)
-	apiHandler, err := createHandler(cfg, defaultAPIList, httpHandler, wsHandler, graphQLHandler, nil)
+	var probes *health.Probes
+	if healthComponents != nil {
+		probes = health.NewProbes(healthComponents, health.Config{
+			CheckTimeout:    cfg.HealthCheckTimeout,
+			MaxHeadAge:      cfg.HealthMaxHeadAge,
+			MaxBlocksBehind: cfg.HealthMaxBlocksBehind,
+			MaxCacheLag:     cfg.HealthMaxCacheLag,
+		})
+	}
+	apiHandler, err := createHandler(cfg, defaultAPIList, httpHandler, wsHandler, graphQLHandler, probes, nil)
 	if err != nil {
 		return err
 	}
@@ -829,13 +862,16 @@ func ObtainJWTSecret(cfg *httpcfg.HttpCfg, logger log.Logger) ([]byte, error) {
 	return jwtSecret, nil
 }
 
-func createHandler(cfg *httpcfg.HttpCfg, apiList []rpc.API, httpHandler http.Handler, wsHandler http.Handler, graphQLHandler http.Handler, jwtSecret []byte) (http.Handler, error) {
+func createHandler(cfg *httpcfg.HttpCfg, apiList []rpc.API, httpHandler http.Handler, wsHandler http.Handler, graphQLHandler http.Handler, probes *health.Probes, jwtSecret []byte) (http.Handler, error) {
 	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if cfg.GraphQLEnabled && graphql.ProcessGraphQLcheckIfNeeded(graphQLHandler, w, r) {
 			return
 		}
 
 		// adding a healthcheck here
+		if probes != nil && probes.ProcessProbeIfNeeded(w, r) {
+			return
+		}
 		if health.ProcessHealthcheckIfNeeded(w, r, apiList) {
 			return
 		}
@@ -874,7 +910,7 @@ func createEngineListener(cfg *httpcfg.HttpCfg, engineApi []rpc.API, logger log.
 
 	graphQLHandler := graphql.CreateHandler(engineApi)
 
-	engineApiHandler, err := createHandler(cfg, engineApi, engineHttpHandler, wsHandler, graphQLHandler, jwtSecret)
+	engineApiHandler, err := createHandler(cfg, engineApi, engineHttpHandler, wsHandler, graphQLHandler, nil, jwtSecret)
 	if err != nil {
 		return nil, nil, "", err
 	}
@@ -67,6 +67,7 @@ type HttpCfg struct {
 	WebsocketCompression              bool
 	WebsocketSubscribeLogsChannelSize int
 	RpcAllowListFilePath              string
+	RpcQuotasFilePath                 string
 	RpcBatchConcurrency               uint
 	RpcStreamingDisable               bool
 	RpcFiltersConfig                  rpchelper.FiltersConfig
@@ -83,6 +84,12 @@ type HttpCfg struct {
 	GRPCPort               int
 	GRPCHealthCheckEnabled bool
 
+	// Thresholds of /health/live, /health/ready, /health/startup probes, zero - default
+	HealthCheckTimeout    time.Duration
+	HealthMaxHeadAge      time.Duration
+	HealthMaxBlocksBehind uint64
+	HealthMaxCacheLag     uint64
+
 	// Socket Server
 	SocketServerEnabled bool
 	SocketListenUrl     string
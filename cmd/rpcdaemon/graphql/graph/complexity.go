@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"github.com/erigontech/erigon/cmd/rpcdaemon/graphql/graph/model"
+)
+
+const (
+	// maxBlocks is the largest range of blocks returned by a single blocks query
+	maxBlocks = 25
+	// maxLogsBlocks is the largest range of blocks scanned by a single logs query
+	maxLogsBlocks = 1000
+
+	// MaxQueryComplexity is the limit of the complexity of a single query, see Complexity.
+	MaxQueryComplexity = 100_000
+
+	// complexity of loading a block with its receipts
+	blockComplexity = 10
+	// complexity of reading account state
+	stateComplexity = 10
+	// complexity of executing a call, gas estimation takes a number of calls to binary search the gas limit
+	callComplexity        = 100
+	estimateGasComplexity = 25 * callComplexity
+	// complexity of scanning the logs index for a logs query
+	logsComplexity = 1000
+	// assumed length of lists whose length isn't known before execution
+	transactionsListSize = 100
+	logsListSize         = 100
+	txLogsListSize       = 10
+	ommersListSize       = 2
+)
+
+// Complexity estimates the cost of every field which loads blocks or state, executes calls, or returns
+// a list of objects loaded in turn. Fields not listed here cost 1 plus the complexity of their subfields.
+// Together with MaxQueryComplexity it keeps a single nested query from scanning a large part of the chain.
+func Complexity() ComplexityRoot {
+	var c ComplexityRoot
+
+	blockLoad := func(childComplexity int) int { return blockComplexity + childComplexity }
+	stateRead := func(childComplexity int) int { return stateComplexity + childComplexity }
+	call := func(childComplexity int, _ model.CallData) int { return callComplexity + childComplexity }
+	estimateGas := func(childComplexity int, _ model.CallData) int { return estimateGasComplexity + childComplexity }
+
+	c.Query.Block = func(childComplexity int, _ *string, _ *string) int { return blockLoad(childComplexity) }
+	c.Query.Blocks = func(childComplexity int, from *uint64, to *uint64) int {
+		blocks := uint64(maxBlocks)
+		if from != nil && to != nil && *to >= *from && *to-*from < maxBlocks {
+			blocks = *to - *from + 1
+		}
+		return int(blocks) * blockLoad(childComplexity)
+	}
+	c.Query.Transaction = func(childComplexity int, _ string) int { return blockLoad(childComplexity) }
+	c.Query.Logs = func(childComplexity int, _ model.FilterCriteria) int {
+		return logsComplexity + logsListSize*childComplexity
+	}
+
+	c.Block.Parent = blockLoad
+	c.Block.Ommers = func(childComplexity int) int { return 1 + ommersListSize*childComplexity }
+	c.Block.Transactions = func(childComplexity int) int { return 1 + transactionsListSize*childComplexity }
+	c.Block.Logs = func(childComplexity int, _ model.BlockFilterCriteria) int {
+		return 1 + logsListSize*childComplexity
+	}
+	c.Block.Call = call
+	c.Block.EstimateGas = estimateGas
+
+	c.Pending.TransactionCount = blockLoad
+	c.Pending.Transactions = func(childComplexity int) int {
+		return blockComplexity + transactionsListSize*childComplexity
+	}
+	c.Pending.Call = call
+	c.Pending.EstimateGas = estimateGas
+
+	c.Transaction.Block = blockLoad
+	c.Transaction.Logs = func(childComplexity int) int { return 1 + txLogsListSize*childComplexity }
+	// the transaction of a log returned by the logs query is loaded with its block
+	c.Log.Transaction = blockLoad
+
+	c.Account.Balance = stateRead
+	c.Account.TransactionCount = stateRead
+	c.Account.Code = stateRead
+	c.Account.Storage = func(childComplexity int, _ string) int { return stateRead(childComplexity) }
+
+	return c
+}
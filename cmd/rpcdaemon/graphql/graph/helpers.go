@@ -3,8 +3,10 @@ package graph
 import (
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"reflect"
 	"strconv"
+	"strings"
 
 	hexutil2 "github.com/erigontech/erigon-lib/common/hexutil"
 
@@ -12,8 +14,13 @@ import (
 
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutility"
+	types2 "github.com/erigontech/erigon-lib/types"
 
+	"github.com/erigontech/erigon/cmd/rpcdaemon/graphql/graph/model"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/adapter/ethapi"
 )
 
 func convertDataToStringP(abstractMap map[string]interface{}, field string) *string {
@@ -135,3 +142,292 @@ func convertStrHexToDec(hexString *string) *string {
 
 	return &result
 }
+
+// convertOptionalStringP is convertDataToStringP for fields which may be absent or nil.
+func convertOptionalStringP(abstractMap map[string]interface{}, field string) *string {
+	if v, ok := abstractMap[field]; !ok || v == nil {
+		return nil
+	}
+	return convertDataToStringP(abstractMap, field)
+}
+
+// convertBlock builds the block model from the result of GraphQLAPI.GetBlockDetails.
+func convertBlock(res map[string]interface{}) *model.Block {
+	block := convertHeader(res["block"].(map[string]interface{}))
+
+	// Ommers
+	block.Ommers = []*model.Block{}
+	for _, ommer := range res["ommers"].([]map[string]interface{}) {
+		block.Ommers = append(block.Ommers, convertHeader(ommer))
+	}
+	ommerCount := len(block.Ommers)
+	block.OmmerCount = &ommerCount
+
+	// Transactions
+	block.Transactions = []*model.Transaction{}
+	for _, transReceipt := range res["receipts"].([]map[string]interface{}) {
+		block.Transactions = append(block.Transactions, convertTransaction(transReceipt, &block.Number))
+	}
+
+	// Withdrawals
+	block.Withdrawals = []*model.Withdrawal{}
+	for _, withdrawal := range res["withdrawals"].([]map[string]interface{}) {
+		wthd := &model.Withdrawal{}
+		wthd.Index = *convertDataToIntP(withdrawal, "index")
+		wthd.Validator = *convertDataToIntP(withdrawal, "validator")
+		wthd.Address = *convertDataToStringP(withdrawal, "address")
+		wthd.Amount = *convertDataToStringP(withdrawal, "amount")
+
+		block.Withdrawals = append(block.Withdrawals, wthd)
+	}
+
+	return block
+}
+
+// convertHeader builds the block model without transactions, ommers and withdrawals, as ommers are returned.
+func convertHeader(blk map[string]interface{}) *model.Block {
+	block := &model.Block{}
+	block.Difficulty = *convertDataToStringP(blk, "difficulty")
+	block.ExtraData = *convertDataToStringP(blk, "extraData")
+	block.GasLimit = *convertDataToUint64P(blk, "gasLimit")
+	block.GasUsed = *convertDataToUint64P(blk, "gasUsed")
+	block.Hash = *convertDataToStringP(blk, "hash")
+	if address := convertOptionalStringP(blk, "miner"); address != nil {
+		block.MinerAddress = strings.ToLower(*address)
+	}
+	if mixHash := convertOptionalStringP(blk, "mixHash"); mixHash != nil {
+		block.MixHash = *mixHash
+	}
+	if blockNonce := convertOptionalStringP(blk, "nonce"); blockNonce != nil {
+		block.Nonce = *blockNonce
+	}
+	block.Number = *convertDataToUint64P(blk, "number")
+	block.ParentHash = *convertDataToStringP(blk, "parentHash")
+	block.ReceiptsRoot = *convertDataToStringP(blk, "receiptsRoot")
+	block.StateRoot = *convertDataToStringP(blk, "stateRoot")
+	block.Timestamp = *convertDataToStringP(blk, "timestamp")
+	if _, ok := blk["transactionCount"]; ok {
+		block.TransactionCount = convertDataToIntP(blk, "transactionCount")
+	}
+	block.TransactionsRoot = *convertDataToStringP(blk, "transactionsRoot")
+	block.TotalDifficulty = *convertDataToStringP(blk, "totalDifficulty")
+	block.BaseFeePerGas = convertOptionalStringP(blk, "baseFeePerGas")
+	block.NextBaseFeePerGas = convertOptionalStringP(blk, "nextBaseFeePerGas")
+	block.LogsBloom = "0x" + *convertDataToStringP(blk, "logsBloom")
+	block.OmmerHash = *convertDataToStringP(blk, "sha3Uncles")
+	block.RawHeader = *convertDataToStringP(blk, "rawHeader")
+	if raw := convertOptionalStringP(blk, "raw"); raw != nil {
+		block.Raw = *raw
+	}
+	return block
+}
+
+// convertTransaction builds the transaction model from the transaction fields returned by GraphQLAPI,
+// blockNumber is nil for pending transactions, which have no receipt fields.
+func convertTransaction(transReceipt map[string]interface{}, blockNumber *uint64) *model.Transaction {
+	trans := &model.Transaction{BlockNumber: blockNumber}
+	trans.Hash = *convertDataToStringP(transReceipt, "transactionHash")
+	trans.Nonce = *convertDataToStringP(transReceipt, "nonce")
+	trans.Value = *convertDataToStringP(transReceipt, "value")
+	trans.GasPrice = *convertDataToStringP(transReceipt, "gasPrice")
+	trans.MaxFeePerGas = convertOptionalStringP(transReceipt, "maxFeePerGas")
+	trans.MaxPriorityFeePerGas = convertOptionalStringP(transReceipt, "maxPriorityFeePerGas")
+	trans.EffectiveTip = convertOptionalStringP(transReceipt, "effectiveTip")
+	trans.Gas = *convertDataToUint64P(transReceipt, "gas")
+	trans.InputData = *convertDataToStringP(transReceipt, "data")
+	trans.R = *convertDataToStringP(transReceipt, "r")
+	trans.S = *convertDataToStringP(transReceipt, "s")
+	trans.V = *convertDataToStringP(transReceipt, "v")
+	trans.Type = convertDataToIntP(transReceipt, "type")
+	trans.Raw = *convertDataToStringP(transReceipt, "raw")
+	if accessList, ok := transReceipt["accessList"].(types2.AccessList); ok {
+		trans.AccessList = make([]*model.AccessTuple, 0, len(accessList))
+		for _, tuple := range accessList {
+			storageKeys := make([]string, 0, len(tuple.StorageKeys))
+			for _, key := range tuple.StorageKeys {
+				storageKeys = append(storageKeys, key.String())
+			}
+			trans.AccessList = append(trans.AccessList, &model.AccessTuple{
+				Address:     strings.ToLower(tuple.Address.String()),
+				StorageKeys: storageKeys,
+			})
+		}
+	}
+
+	trans.FromAddress = strings.ToLower(*convertDataToStringP(transReceipt, "from"))
+	// To address could be nil in case of contract creation
+	if address := convertOptionalStringP(transReceipt, "to"); address != nil {
+		to := strings.ToLower(*address)
+		trans.ToAddress = &to
+	}
+
+	if blockNumber == nil {
+		return trans
+	}
+
+	trans.Index = convertDataToIntP(transReceipt, "transactionIndex")
+	trans.Status = convertDataToUint64P(transReceipt, "status")
+	trans.GasUsed = convertDataToUint64P(transReceipt, "gasUsed")
+	trans.CumulativeGasUsed = convertDataToUint64P(transReceipt, "cumulativeGasUsed")
+	trans.EffectiveGasPrice = convertDataToStringP(transReceipt, "effectiveGasPrice")
+	trans.RawReceipt = *convertDataToStringP(transReceipt, "rawReceipt")
+	if address := convertOptionalStringP(transReceipt, "contractAddress"); address != nil {
+		contract := strings.ToLower(*address)
+		trans.CreatedContractAddress = &contract
+	}
+
+	trans.Logs = make([]*model.Log, 0)
+	for _, rlog := range transReceipt["logs"].(types.Logs) {
+		tlog := convertLog(rlog)
+		tlog.Tx = trans
+		trans.Logs = append(trans.Logs, tlog)
+	}
+	return trans
+}
+
+func convertLog(rlog *types.Log) *model.Log {
+	tlog := &model.Log{
+		Index:           int(rlog.Index),
+		Data:            "0x" + hex.EncodeToString(rlog.Data),
+		Topics:          make([]string, 0, len(rlog.Topics)),
+		AccountAddress:  strings.ToLower(rlog.Address.String()),
+		TransactionHash: rlog.TxHash.String(),
+	}
+	for _, rtopic := range rlog.Topics {
+		tlog.Topics = append(tlog.Topics, rtopic.String())
+	}
+	return tlog
+}
+
+// convertLogFilter converts addresses and topics of the filter, topics are matched as by eth_getLogs.
+func convertLogFilter(addresses []string, topics [][]string) ([]libcommon.Address, [][]libcommon.Hash) {
+	var addrs []libcommon.Address
+	for _, address := range addresses {
+		addrs = append(addrs, libcommon.HexToAddress(address))
+	}
+	var hashes [][]libcommon.Hash
+	for _, position := range topics {
+		positionHashes := make([]libcommon.Hash, 0, len(position))
+		for _, topic := range position {
+			positionHashes = append(positionHashes, libcommon.HexToHash(topic))
+		}
+		hashes = append(hashes, positionHashes)
+	}
+	return addrs, hashes
+}
+
+// matchLog reports whether the log matches the filter, the same way types.Logs.Filter does.
+func matchLog(tlog *model.Log, addresses []libcommon.Address, topics [][]libcommon.Hash) bool {
+	if len(addresses) > 0 {
+		address := libcommon.HexToAddress(tlog.AccountAddress)
+		found := false
+		for _, a := range addresses {
+			if a == address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(topics) > len(tlog.Topics) {
+		return false
+	}
+	for i, position := range topics {
+		if len(position) == 0 {
+			continue // empty rule set == wildcard
+		}
+		topic := libcommon.HexToHash(tlog.Topics[i])
+		found := false
+		for _, t := range position {
+			if t == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// convertCallData converts the call arguments, BigInt values may be decimal or 0x prefixed hexadecimal.
+func convertCallData(data model.CallData) (ethapi.CallArgs, error) {
+	var args ethapi.CallArgs
+	if data.From != nil {
+		from := libcommon.HexToAddress(*data.From)
+		args.From = &from
+	}
+	if data.To != nil {
+		to := libcommon.HexToAddress(*data.To)
+		args.To = &to
+	}
+	if data.Gas != nil {
+		gas := hexutil2.Uint64(*data.Gas)
+		args.Gas = &gas
+	}
+	for _, v := range []struct {
+		value *string
+		arg   **hexutil2.Big
+	}{
+		{data.GasPrice, &args.GasPrice},
+		{data.MaxFeePerGas, &args.MaxFeePerGas},
+		{data.MaxPriorityFeePerGas, &args.MaxPriorityFeePerGas},
+		{data.Value, &args.Value},
+	} {
+		if v.value == nil {
+			continue
+		}
+		n, err := parseBigInt(*v.value)
+		if err != nil {
+			return args, err
+		}
+		*v.arg = (*hexutil2.Big)(n)
+	}
+	if data.Data != nil {
+		input, err := hexutil2.Decode(*data.Data)
+		if err != nil {
+			return args, fmt.Errorf("invalid call data: %w", err)
+		}
+		args.Data = (*hexutility.Bytes)(&input)
+	}
+	return args, nil
+}
+
+func parseBigInt(s string) (*big.Int, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return hexutil2.DecodeBig(s)
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid BigInt %q", s)
+	}
+	return n, nil
+}
+
+func convertCallResult(res *evmtypes.ExecutionResult) *model.CallResult {
+	result := &model.CallResult{
+		Data:    hexutility.Bytes(res.ReturnData).String(),
+		GasUsed: res.UsedGas,
+		Status:  1,
+	}
+	if res.Failed() {
+		result.Status = 0
+	}
+	return result
+}
+
+// blockNumberOrLatest is the block of the account state, latest if not specified.
+func blockNumberOrLatest(block *uint64) rpc.BlockNumberOrHash {
+	if block == nil {
+		return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	}
+	return rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(*block))
+}
+
+// blockState is the state after the block, which account, call and estimateGas of the block are resolved at.
+func blockState(block *model.Block) rpc.BlockNumberOrHash {
+	return rpc.BlockNumberOrHashWithHash(libcommon.HexToHash(block.Hash), false)
+}
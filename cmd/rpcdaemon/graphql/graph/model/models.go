@@ -0,0 +1,90 @@
+package model
+
+import (
+	"github.com/erigontech/erigon/rpc"
+)
+
+// Models below replace ones gqlgen would generate. Fields which are expensive to load or take arguments
+// are left out, so gqlgen generates resolvers for them and they are loaded only when queried.
+
+// Account is the state of the account at the block.
+type Account struct {
+	Address string                `json:"address"`
+	Block   rpc.BlockNumberOrHash `json:"-"`
+}
+
+type Block struct {
+	Number            uint64         `json:"number"`
+	Hash              string         `json:"hash"`
+	Nonce             string         `json:"nonce"`
+	TransactionsRoot  string         `json:"transactionsRoot"`
+	TransactionCount  *int           `json:"transactionCount,omitempty"`
+	StateRoot         string         `json:"stateRoot"`
+	ReceiptsRoot      string         `json:"receiptsRoot"`
+	ExtraData         string         `json:"extraData"`
+	GasLimit          uint64         `json:"gasLimit"`
+	GasUsed           uint64         `json:"gasUsed"`
+	BaseFeePerGas     *string        `json:"baseFeePerGas,omitempty"`
+	NextBaseFeePerGas *string        `json:"nextBaseFeePerGas,omitempty"`
+	Timestamp         string         `json:"timestamp"`
+	LogsBloom         string         `json:"logsBloom"`
+	MixHash           string         `json:"mixHash"`
+	Difficulty        string         `json:"difficulty"`
+	TotalDifficulty   string         `json:"totalDifficulty"`
+	OmmerCount        *int           `json:"ommerCount,omitempty"`
+	Ommers            []*Block       `json:"ommers,omitempty"`
+	OmmerHash         string         `json:"ommerHash"`
+	Transactions      []*Transaction `json:"transactions,omitempty"`
+	RawHeader         string         `json:"rawHeader"`
+	Raw               string         `json:"raw"`
+	Withdrawals       []*Withdrawal  `json:"withdrawals,omitempty"`
+
+	ParentHash   string `json:"-"`
+	MinerAddress string `json:"-"`
+}
+
+type Transaction struct {
+	Hash                 string         `json:"hash"`
+	Nonce                string         `json:"nonce"`
+	Index                *int           `json:"index,omitempty"`
+	Value                string         `json:"value"`
+	GasPrice             string         `json:"gasPrice"`
+	MaxFeePerGas         *string        `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *string        `json:"maxPriorityFeePerGas,omitempty"`
+	EffectiveTip         *string        `json:"effectiveTip,omitempty"`
+	Gas                  uint64         `json:"gas"`
+	InputData            string         `json:"inputData"`
+	Status               *uint64        `json:"status,omitempty"`
+	GasUsed              *uint64        `json:"gasUsed,omitempty"`
+	CumulativeGasUsed    *uint64        `json:"cumulativeGasUsed,omitempty"`
+	EffectiveGasPrice    *string        `json:"effectiveGasPrice,omitempty"`
+	Logs                 []*Log         `json:"logs,omitempty"`
+	R                    string         `json:"r"`
+	S                    string         `json:"s"`
+	V                    string         `json:"v"`
+	Type                 *int           `json:"type,omitempty"`
+	AccessList           []*AccessTuple `json:"accessList,omitempty"`
+	Raw                  string         `json:"raw"`
+	RawReceipt           string         `json:"rawReceipt"`
+
+	FromAddress            string  `json:"-"`
+	ToAddress              *string `json:"-"`
+	CreatedContractAddress *string `json:"-"`
+	// BlockNumber is nil for pending transactions
+	BlockNumber *uint64 `json:"-"`
+}
+
+type Log struct {
+	Index  int      `json:"index"`
+	Topics []string `json:"topics"`
+	Data   string   `json:"data"`
+
+	AccountAddress  string `json:"-"`
+	TransactionHash string `json:"-"`
+	// Tx is the transaction emitting the log, if it is already loaded
+	Tx *Transaction `json:"-"`
+}
+
+// Pending is resolved entirely by the resolvers, from the pending block.
+type Pending struct {
+}
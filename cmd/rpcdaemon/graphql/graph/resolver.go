@@ -1,7 +1,12 @@
 package graph
 
 import (
+	"context"
+
+	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/graphql/graph/model"
+	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/jsonrpc"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/services"
@@ -17,3 +22,60 @@ type Resolver struct {
 	filters     *rpchelper.Filters
 	blockReader services.FullBlockReader
 }
+
+// blockByNumber returns nil if the block is not found.
+func (r *Resolver) blockByNumber(ctx context.Context, blockNumber rpc.BlockNumber) (*model.Block, error) {
+	res, err := r.GraphQLAPI.GetBlockDetails(ctx, blockNumber)
+	if err != nil || res == nil {
+		return nil, err
+	}
+	return convertBlock(res), ctx.Err()
+}
+
+// blockByHash returns nil if the block is not found or is not canonical.
+func (r *Resolver) blockByHash(ctx context.Context, hash string) (*model.Block, error) {
+	blockHash := common.HexToHash(hash)
+	blockNumber, err := r.GraphQLAPI.GetBlockNumberByHash(ctx, blockHash)
+	if err != nil || blockNumber == nil {
+		return nil, err
+	}
+	block, err := r.blockByNumber(ctx, rpc.BlockNumber(*blockNumber))
+	if err != nil || block == nil || common.HexToHash(block.Hash) != blockHash {
+		return nil, err
+	}
+	return block, nil
+}
+
+// transactionByHash looks the transaction up in the canonical chain first and then among pending transactions,
+// returns nil if it is found in neither.
+func (r *Resolver) transactionByHash(ctx context.Context, hash string) (*model.Transaction, error) {
+	txHash := common.HexToHash(hash)
+	blockNumber, err := r.GraphQLAPI.GetTransactionBlockNumber(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if blockNumber != nil {
+		block, err := r.blockByNumber(ctx, rpc.BlockNumber(*blockNumber))
+		if err != nil || block == nil {
+			return nil, err
+		}
+		for _, trans := range block.Transactions {
+			if common.HexToHash(trans.Hash) == txHash {
+				return trans, nil
+			}
+		}
+		return nil, nil
+	}
+
+	pending, err := r.GraphQLAPI.GetPendingTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range pending {
+		trans := convertTransaction(transaction, nil)
+		if common.HexToHash(trans.Hash) == txHash {
+			return trans, nil
+		}
+	}
+	return nil, nil
+}
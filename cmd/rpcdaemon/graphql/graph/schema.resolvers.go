@@ -6,21 +6,199 @@ package graph
 
 import (
 	"context"
-	"encoding/hex"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/erigontech/erigon/cmd/rpcdaemon/graphql/graph/model"
-	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/filters"
 	"github.com/erigontech/erigon/rpc"
 )
 
+// Balance is the resolver for the balance field.
+func (r *accountResolver) Balance(ctx context.Context, obj *model.Account) (string, error) {
+	balance, err := r.GraphQLAPI.GetBalance(ctx, common.HexToAddress(obj.Address), obj.Block)
+	if err != nil {
+		return "", err
+	}
+	return balance.String(), nil
+}
+
+// TransactionCount is the resolver for the transactionCount field.
+func (r *accountResolver) TransactionCount(ctx context.Context, obj *model.Account) (uint64, error) {
+	nonce, err := r.GraphQLAPI.GetTransactionCount(ctx, common.HexToAddress(obj.Address), obj.Block)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(*nonce), nil
+}
+
+// Code is the resolver for the code field.
+func (r *accountResolver) Code(ctx context.Context, obj *model.Account) (string, error) {
+	code, err := r.GraphQLAPI.GetCode(ctx, common.HexToAddress(obj.Address), obj.Block)
+	if err != nil {
+		return "", err
+	}
+	return code.String(), nil
+}
+
+// Storage is the resolver for the storage field.
+func (r *accountResolver) Storage(ctx context.Context, obj *model.Account, slot string) (string, error) {
+	return r.GraphQLAPI.GetStorageAt(ctx, common.HexToAddress(obj.Address), slot, obj.Block)
+}
+
+// Parent is the resolver for the parent field.
+func (r *blockResolver) Parent(ctx context.Context, obj *model.Block) (*model.Block, error) {
+	if obj.Number == 0 {
+		return nil, nil
+	}
+	return r.blockByHash(ctx, obj.ParentHash)
+}
+
+// Miner is the resolver for the miner field.
+func (r *blockResolver) Miner(ctx context.Context, obj *model.Block, block *uint64) (*model.Account, error) {
+	return &model.Account{Address: obj.MinerAddress, Block: blockNumberOrLatest(block)}, nil
+}
+
+// OmmerAt is the resolver for the ommerAt field.
+func (r *blockResolver) OmmerAt(ctx context.Context, obj *model.Block, index int) (*model.Block, error) {
+	if index < 0 || index >= len(obj.Ommers) {
+		return nil, nil
+	}
+	return obj.Ommers[index], nil
+}
+
+// TransactionAt is the resolver for the transactionAt field.
+func (r *blockResolver) TransactionAt(ctx context.Context, obj *model.Block, index int) (*model.Transaction, error) {
+	if index < 0 || index >= len(obj.Transactions) {
+		return nil, nil
+	}
+	return obj.Transactions[index], nil
+}
+
+// Logs is the resolver for the logs field.
+func (r *blockResolver) Logs(ctx context.Context, obj *model.Block, filter model.BlockFilterCriteria) ([]*model.Log, error) {
+	addresses, topics := convertLogFilter(filter.Addresses, filter.Topics)
+	logs := []*model.Log{}
+	for _, trans := range obj.Transactions {
+		for _, tlog := range trans.Logs {
+			if matchLog(tlog, addresses, topics) {
+				logs = append(logs, tlog)
+			}
+		}
+	}
+	return logs, nil
+}
+
+// Account is the resolver for the account field.
+func (r *blockResolver) Account(ctx context.Context, obj *model.Block, address string) (*model.Account, error) {
+	return &model.Account{Address: strings.ToLower(address), Block: blockState(obj)}, nil
+}
+
+// Call is the resolver for the call field.
+func (r *blockResolver) Call(ctx context.Context, obj *model.Block, data model.CallData) (*model.CallResult, error) {
+	args, err := convertCallData(data)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.GraphQLAPI.Call(ctx, args, blockState(obj))
+	if err != nil {
+		return nil, err
+	}
+	return convertCallResult(res), nil
+}
+
+// EstimateGas is the resolver for the estimateGas field.
+func (r *blockResolver) EstimateGas(ctx context.Context, obj *model.Block, data model.CallData) (uint64, error) {
+	args, err := convertCallData(data)
+	if err != nil {
+		return 0, err
+	}
+	gas, err := r.GraphQLAPI.EstimateGas(ctx, args, blockState(obj))
+	return uint64(gas), err
+}
+
+// Account is the resolver for the account field.
+func (r *logResolver) Account(ctx context.Context, obj *model.Log, block *uint64) (*model.Account, error) {
+	return &model.Account{Address: obj.AccountAddress, Block: blockNumberOrLatest(block)}, nil
+}
+
+// Transaction is the resolver for the transaction field.
+func (r *logResolver) Transaction(ctx context.Context, obj *model.Log) (*model.Transaction, error) {
+	if obj.Tx != nil {
+		return obj.Tx, nil
+	}
+	trans, err := r.transactionByHash(ctx, obj.TransactionHash)
+	if err != nil {
+		return nil, err
+	}
+	if trans == nil {
+		return nil, fmt.Errorf("transaction %s not found", obj.TransactionHash)
+	}
+	return trans, nil
+}
+
 // SendRawTransaction is the resolver for the sendRawTransaction field.
 func (r *mutationResolver) SendRawTransaction(ctx context.Context, data string) (string, error) {
-	panic("not implemented: SendRawTransaction - sendRawTransaction")
+	encodedTx, err := hexutil.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	hash, err := r.GraphQLAPI.SendRawTransaction(ctx, encodedTx)
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// TransactionCount is the resolver for the transactionCount field.
+func (r *pendingResolver) TransactionCount(ctx context.Context, obj *model.Pending) (int, error) {
+	pending, err := r.GraphQLAPI.GetPendingTransactions(ctx)
+	return len(pending), err
+}
+
+// Transactions is the resolver for the transactions field.
+func (r *pendingResolver) Transactions(ctx context.Context, obj *model.Pending) ([]*model.Transaction, error) {
+	pending, err := r.GraphQLAPI.GetPendingTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transactions := make([]*model.Transaction, 0, len(pending))
+	for _, transaction := range pending {
+		transactions = append(transactions, convertTransaction(transaction, nil))
+	}
+	return transactions, nil
+}
+
+// Account is the resolver for the account field.
+func (r *pendingResolver) Account(ctx context.Context, obj *model.Pending, address string) (*model.Account, error) {
+	return &model.Account{Address: strings.ToLower(address), Block: rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)}, nil
+}
+
+// Call is the resolver for the call field.
+func (r *pendingResolver) Call(ctx context.Context, obj *model.Pending, data model.CallData) (*model.CallResult, error) {
+	args, err := convertCallData(data)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.GraphQLAPI.Call(ctx, args, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber))
+	if err != nil {
+		return nil, err
+	}
+	return convertCallResult(res), nil
+}
+
+// EstimateGas is the resolver for the estimateGas field.
+func (r *pendingResolver) EstimateGas(ctx context.Context, obj *model.Pending, data model.CallData) (uint64, error) {
+	args, err := convertCallData(data)
+	if err != nil {
+		return 0, err
+	}
+	gas, err := r.GraphQLAPI.EstimateGas(ctx, args, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber))
+	return uint64(gas), err
 }
 
 // Block is the resolver for the block field.
@@ -39,168 +217,54 @@ func (r *queryResolver) Block(ctx context.Context, number *string, hash *string)
 				// Hexadecimal, 0x prefixed
 				blockNumber = rpc.BlockNumber(bNum)
 			} else {
-				var err error
-				return nil, err
+				// Not a block number, no such block
+				return nil, nil
 			}
 		}
+	} else if hash != nil {
+		return r.blockByHash(ctx, *hash)
 	} else {
-		if hash != nil {
-			blockHash, _ := hexutil.DecodeBig(*hash)
-			fmt.Println("TODO/GraphQL/Implement me, get Block by hash=", blockHash)
-			hash = nil
-		}
-	}
-
-	if number == nil && hash == nil {
 		// If neither number or hash is specified (nil), we should deliver "latest" block
-		/*
-			rpc.LatestExecutedBlockNumber = BlockNumber(-5)
-			rpc.FinalizedBlockNumber      = BlockNumber(-4)
-			rpc.SafeBlockNumber           = BlockNumber(-3)
-			rpc.PendingBlockNumber        = BlockNumber(-2)
-			rpc.LatestBlockNumber         = BlockNumber(-1)
-		*/
 		blockNumber = rpc.LatestBlockNumber
 	}
 
-	res, err := r.GraphQLAPI.GetBlockDetails(ctx, blockNumber)
-	if err != nil {
-		return nil, err
-	}
-
-	block := &model.Block{}
-	absBlk := res["block"]
-
-	if absBlk != nil {
-		blk := absBlk.(map[string]interface{})
-
-		block.Difficulty = *convertDataToStringP(blk, "difficulty")
-		block.ExtraData = *convertDataToStringP(blk, "extraData")
-		block.GasLimit = uint64(*convertDataToUint64P(blk, "gasLimit"))
-		block.GasUsed = *convertDataToUint64P(blk, "gasUsed")
-		block.Hash = *convertDataToStringP(blk, "hash")
-		block.Miner = &model.Account{}
-		address := convertDataToStringP(blk, "miner")
-		if address != nil {
-			block.Miner.Address = strings.ToLower(*address)
-		}
-		mixHash := convertDataToStringP(blk, "mixHash")
-		if mixHash != nil {
-			block.MixHash = *mixHash
-		}
-		blockNonce := convertDataToStringP(blk, "nonce")
-		if blockNonce != nil {
-			block.Nonce = *blockNonce
-		}
-		block.Number = *convertDataToUint64P(blk, "number")
-		block.Parent = &model.Block{}
-		block.Parent.Hash = *convertDataToStringP(blk, "parentHash")
-		block.ReceiptsRoot = *convertDataToStringP(blk, "receiptsRoot")
-		block.StateRoot = *convertDataToStringP(blk, "stateRoot")
-		block.Timestamp = *convertDataToStringP(blk, "timestamp")
-		block.TransactionCount = convertDataToIntP(blk, "transactionCount")
-		block.TransactionsRoot = *convertDataToStringP(blk, "transactionsRoot")
-		block.TotalDifficulty = *convertDataToStringP(blk, "totalDifficulty")
-		block.BaseFeePerGas = convertDataToStringP(blk, "baseFeePerGas")
-		block.Transactions = []*model.Transaction{}
-
-		block.LogsBloom = "0x" + *convertDataToStringP(blk, "logsBloom")
-		block.OmmerHash = *convertDataToStringP(blk, "sha3Uncles")
-
-		// Ommers
-		block.Ommers = []*model.Block{}
-		for _, ommerHash := range blk["uncles"].([]common.Hash) {
-			block.Ommers = append(block.Ommers, &model.Block{Hash: ommerHash.String()})
-		}
-
-		ommerCount := len(block.Ommers)
-		block.OmmerCount = &ommerCount
-
-		// Transactions
-		absRcp := res["receipts"]
-		rcp := absRcp.([]map[string]interface{})
-		for _, transReceipt := range rcp {
-			trans := &model.Transaction{}
-			trans.CumulativeGasUsed = convertDataToUint64P(transReceipt, "cumulativeGasUsed")
-			trans.InputData = *convertDataToStringP(transReceipt, "data")
-			trans.EffectiveGasPrice = convertDataToStringP(transReceipt, "effectiveGasPrice")
-			trans.GasPrice = *convertDataToStringP(transReceipt, "effectiveGasPrice")
-			trans.GasUsed = convertDataToUint64P(transReceipt, "gasUsed")
-			trans.Hash = *convertDataToStringP(transReceipt, "transactionHash")
-			trans.Index = convertDataToIntP(transReceipt, "transactionIndex")
-			transNonce := convertDataToStringP(transReceipt, "nonce")
-			if transNonce != nil {
-				trans.Nonce = *transNonce
-			}
-			trans.Status = convertDataToUint64P(transReceipt, "status")
-			trans.Type = convertDataToIntP(transReceipt, "type")
-			trans.Value = *convertDataToStringP(transReceipt, "value")
-
-			trans.Logs = make([]*model.Log, 0)
-			for _, rlog := range transReceipt["logs"].(types.Logs) {
-				tlog := model.Log{
-					Index: int(rlog.Index),
-					Data:  "0x" + hex.EncodeToString(rlog.Data),
-				}
-				tlog.Account = &model.Account{}
-				tlog.Account.Address = strings.ToLower(rlog.Address.String())
-
-				for _, rtopic := range rlog.Topics {
-					tlog.Topics = append(tlog.Topics, rtopic.String())
-				}
-
-				trans.Logs = append(trans.Logs, &tlog)
-			}
-
-			trans.From = &model.Account{}
-			trans.From.Address = strings.ToLower(*convertDataToStringP(transReceipt, "from"))
-
-			trans.To = &model.Account{}
-			address := convertDataToStringP(transReceipt, "to")
-			// To address could be nil in case of contract creation
-			if address != nil {
-				trans.To.Address = strings.ToLower(*convertDataToStringP(transReceipt, "to"))
-			}
-
-			block.Transactions = append(block.Transactions, trans)
-		}
-
-		// Withdrawals
-		block.Withdrawals = []*model.Withdrawal{}
-		absWthd := res["withdrawals"]
-		wthd := absWthd.([]map[string]interface{})
-		for _, withdrawal := range wthd {
-			wthd := &model.Withdrawal{}
-			wthd.Index = *convertDataToIntP(withdrawal, "index")
-			wthd.Validator = *convertDataToIntP(withdrawal, "validator")
-			wthd.Address = *convertDataToStringP(withdrawal, "address")
-			wthd.Amount = *convertDataToStringP(withdrawal, "amount")
-
-			block.Withdrawals = append(block.Withdrawals, wthd)
-		}
-	}
-
-	return block, ctx.Err()
+	return r.blockByNumber(ctx, blockNumber)
 }
 
 // Blocks is the resolver for the blocks field.
 func (r *queryResolver) Blocks(ctx context.Context, from *uint64, to *uint64) ([]*model.Block, error) {
-	var blocks []*model.Block
-
-	const maxBlocks = 25
-
+	if from == nil {
+		return nil, fmt.Errorf("from block number must be specified")
+	}
 	fromBlockNumber := *from
-	toBlockNumber := *to
+	var toBlockNumber uint64
+	if to != nil {
+		toBlockNumber = *to
+	} else {
+		latest, err := r.GraphQLAPI.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		toBlockNumber = uint64(latest)
+	}
 
-	if toBlockNumber >= fromBlockNumber && (toBlockNumber-fromBlockNumber+1) < maxBlocks {
+	blocks := []*model.Block{}
+	if toBlockNumber < fromBlockNumber {
+		return blocks, nil
+	}
+	if toBlockNumber-fromBlockNumber >= maxBlocks {
+		return nil, fmt.Errorf("blocks range %d-%d exceeds the limit of %d blocks", fromBlockNumber, toBlockNumber, maxBlocks)
+	}
 
-		for i := fromBlockNumber; i <= toBlockNumber; i++ {
-			blockNumberStr := strconv.FormatUint(i, 10)
-			block, _ := r.Block(ctx, &blockNumberStr, nil)
-			if block != nil {
-				blocks = append(blocks, block)
-			}
+	for i := fromBlockNumber; i <= toBlockNumber; i++ {
+		block, err := r.blockByNumber(ctx, rpc.BlockNumber(i))
+		if err != nil {
+			return nil, err
 		}
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
 	}
 
 	return blocks, ctx.Err()
@@ -208,32 +272,83 @@ func (r *queryResolver) Blocks(ctx context.Context, from *uint64, to *uint64) ([
 
 // Pending is the resolver for the pending field.
 func (r *queryResolver) Pending(ctx context.Context) (*model.Pending, error) {
-	panic("not implemented: Pending - pending")
+	return &model.Pending{}, nil
 }
 
 // Transaction is the resolver for the transaction field.
 func (r *queryResolver) Transaction(ctx context.Context, hash string) (*model.Transaction, error) {
-	panic("not implemented: Transaction - transaction")
+	return r.transactionByHash(ctx, hash)
 }
 
 // Logs is the resolver for the logs field.
 func (r *queryResolver) Logs(ctx context.Context, filter model.FilterCriteria) ([]*model.Log, error) {
-	panic("not implemented: Logs - logs")
+	// Both ends of the range default to the latest block
+	if filter.FromBlock == nil || filter.ToBlock == nil {
+		latest, err := r.GraphQLAPI.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if filter.FromBlock == nil {
+			filter.FromBlock = (*uint64)(&latest)
+		}
+		if filter.ToBlock == nil {
+			filter.ToBlock = (*uint64)(&latest)
+		}
+	}
+	if *filter.ToBlock >= *filter.FromBlock && *filter.ToBlock-*filter.FromBlock >= maxLogsBlocks {
+		return nil, fmt.Errorf("logs range %d-%d exceeds the limit of %d blocks", *filter.FromBlock, *filter.ToBlock, maxLogsBlocks)
+	}
+
+	crit := filters.FilterCriteria{
+		FromBlock: new(big.Int).SetUint64(*filter.FromBlock),
+		ToBlock:   new(big.Int).SetUint64(*filter.ToBlock),
+	}
+	crit.Addresses, crit.Topics = convertLogFilter(filter.Addresses, filter.Topics)
+	rlogs, err := r.GraphQLAPI.GetLogs(ctx, crit)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]*model.Log, 0, len(rlogs))
+	for _, rlog := range rlogs {
+		logs = append(logs, convertLog(rlog))
+	}
+	return logs, nil
 }
 
 // GasPrice is the resolver for the gasPrice field.
 func (r *queryResolver) GasPrice(ctx context.Context) (string, error) {
-	panic("not implemented: GasPrice - gasPrice")
+	price, err := r.GraphQLAPI.GasPrice(ctx)
+	if err != nil {
+		return "", err
+	}
+	return price.String(), nil
 }
 
 // MaxPriorityFeePerGas is the resolver for the maxPriorityFeePerGas field.
 func (r *queryResolver) MaxPriorityFeePerGas(ctx context.Context) (string, error) {
-	panic("not implemented: MaxPriorityFeePerGas - maxPriorityFeePerGas")
+	tip, err := r.GraphQLAPI.MaxPriorityFeePerGas(ctx)
+	if err != nil {
+		return "", err
+	}
+	return tip.String(), nil
 }
 
 // Syncing is the resolver for the syncing field.
 func (r *queryResolver) Syncing(ctx context.Context) (*model.SyncState, error) {
-	panic("not implemented: Syncing - syncing")
+	res, err := r.GraphQLAPI.Syncing(ctx)
+	if err != nil {
+		return nil, err
+	}
+	progress, ok := res.(map[string]interface{})
+	if !ok {
+		// not syncing
+		return nil, nil
+	}
+	return &model.SyncState{
+		CurrentBlock: *convertDataToUint64P(progress, "currentBlock"),
+		HighestBlock: *convertDataToUint64P(progress, "highestBlock"),
+	}, nil
 }
 
 // ChainID is the resolver for the chainID field.
@@ -243,11 +358,60 @@ func (r *queryResolver) ChainID(ctx context.Context) (string, error) {
 	return "0x" + strconv.FormatUint(chainID.Uint64(), 16), err
 }
 
+// From is the resolver for the from field.
+func (r *transactionResolver) From(ctx context.Context, obj *model.Transaction, block *uint64) (*model.Account, error) {
+	return &model.Account{Address: obj.FromAddress, Block: blockNumberOrLatest(block)}, nil
+}
+
+// To is the resolver for the to field.
+func (r *transactionResolver) To(ctx context.Context, obj *model.Transaction, block *uint64) (*model.Account, error) {
+	if obj.ToAddress == nil {
+		return nil, nil
+	}
+	return &model.Account{Address: *obj.ToAddress, Block: blockNumberOrLatest(block)}, nil
+}
+
+// Block is the resolver for the block field.
+func (r *transactionResolver) Block(ctx context.Context, obj *model.Transaction) (*model.Block, error) {
+	if obj.BlockNumber == nil {
+		return nil, nil
+	}
+	return r.blockByNumber(ctx, rpc.BlockNumber(*obj.BlockNumber))
+}
+
+// CreatedContract is the resolver for the createdContract field.
+func (r *transactionResolver) CreatedContract(ctx context.Context, obj *model.Transaction, block *uint64) (*model.Account, error) {
+	if obj.CreatedContractAddress == nil {
+		return nil, nil
+	}
+	return &model.Account{Address: *obj.CreatedContractAddress, Block: blockNumberOrLatest(block)}, nil
+}
+
+// Account returns AccountResolver implementation.
+func (r *Resolver) Account() AccountResolver { return &accountResolver{r} }
+
+// Block returns BlockResolver implementation.
+func (r *Resolver) Block() BlockResolver { return &blockResolver{r} }
+
+// Log returns LogResolver implementation.
+func (r *Resolver) Log() LogResolver { return &logResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Pending returns PendingResolver implementation.
+func (r *Resolver) Pending() PendingResolver { return &pendingResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Transaction returns TransactionResolver implementation.
+func (r *Resolver) Transaction() TransactionResolver { return &transactionResolver{r} }
+
+type accountResolver struct{ *Resolver }
+type blockResolver struct{ *Resolver }
+type logResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type pendingResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type transactionResolver struct{ *Resolver }
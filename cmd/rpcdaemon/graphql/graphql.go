@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
 	"github.com/99designs/gqlgen/graphql/playground"
 
 	"github.com/erigontech/erigon/cmd/rpcdaemon/graphql/graph"
@@ -49,7 +50,9 @@ func CreateHandler(api []rpc.API) *handler.Server {
 	resolver := graph.Resolver{}
 	resolver.GraphQLAPI = graphqlAPI
 
-	return handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver})) // TODO : init resolver.DB here !!!
+	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver, Complexity: graph.Complexity()}))
+	srv.Use(extension.FixedComplexityLimit(graph.MaxQueryComplexity))
+	return srv
 }
 
 func ProcessGraphQLcheckIfNeeded(
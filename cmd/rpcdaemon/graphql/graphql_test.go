@@ -17,13 +17,194 @@
 package graphql
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
+	"github.com/erigontech/erigon-lib/kv/kvcache"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/rpc/rpccfg"
+	"github.com/erigontech/erigon/turbo/jsonrpc"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/stages/mock"
 )
 
+func newTestServer(t *testing.T) (*httptest.Server, *core.ChainPack) {
+	t.Helper()
+	m, chain, _ := rpcdaemontest.CreateTestSentry(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, mock.Mock(t))
+	mining := txpool.NewMiningClient(conn)
+	ff := rpchelper.New(ctx, rpchelper.DefaultFiltersConfig, nil, nil, mining, func() {}, m.Log)
+	base := jsonrpc.NewBaseApi(ff, stateCache, m.BlockReader, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs)
+	eth := jsonrpc.NewEthAPI(base, m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+	handler := CreateHandler([]rpc.API{{Namespace: "graphql", Service: jsonrpc.NewGraphQLAPI(base, m.DB, eth)}})
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv, chain
+}
+
+type testResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func postQuery(t *testing.T, srv *httptest.Server, query string) testResponse {
+	t.Helper()
+	body, err := json.Marshal(map[string]string{"query": query})
+	require.NoError(t, err)
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(string(body)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var res testResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	return res
+}
+
+func TestGraphQLResolvers(t *testing.T) {
+	srv, chain := newTestServer(t)
+
+	// the first block with logs
+	blockIdx := -1
+	for i, receipts := range chain.Receipts {
+		for _, receipt := range receipts {
+			if len(receipt.Logs) > 0 && blockIdx < 0 {
+				blockIdx = i
+			}
+		}
+	}
+	require.GreaterOrEqual(t, blockIdx, 0)
+	block := chain.Blocks[blockIdx]
+	txn := block.Transactions()[0]
+	receipt := chain.Receipts[blockIdx][0]
+
+	res := postQuery(t, srv, fmt.Sprintf(`{
+		block(number: %d) {
+			number hash parent { hash } transactionCount
+			transactions { hash status gasUsed from { address } logs { index account { address } transaction { hash } } }
+			logs(filter: {addresses: ["%s"]}) { index }
+			account(address: "%s") { transactionCount }
+		}
+		transaction(hash: "%s") { hash index block { number } }
+	}`, block.NumberU64(), receipt.Logs[0].Address, chain.TopBlock.Coinbase(), txn.Hash()))
+	require.Empty(t, res.Errors)
+
+	var data struct {
+		Block struct {
+			Number           uint64
+			Hash             string
+			Parent           struct{ Hash string }
+			TransactionCount int
+			Transactions     []struct {
+				Hash    string
+				Status  uint64
+				GasUsed uint64
+				Logs    []struct {
+					Index       int
+					Transaction struct{ Hash string }
+				}
+			}
+			Logs []struct{ Index int }
+		}
+		Transaction struct {
+			Hash  string
+			Index int
+			Block struct{ Number uint64 }
+		}
+	}
+	require.NoError(t, json.Unmarshal(res.Data, &data))
+	require.Equal(t, block.NumberU64(), data.Block.Number)
+	require.Equal(t, block.Hash().String(), data.Block.Hash)
+	require.Equal(t, block.ParentHash().String(), data.Block.Parent.Hash)
+	require.Equal(t, len(block.Transactions()), data.Block.TransactionCount)
+	require.Len(t, data.Block.Transactions, len(block.Transactions()))
+	require.Equal(t, txn.Hash().String(), data.Block.Transactions[0].Hash)
+	require.Equal(t, receipt.Status, data.Block.Transactions[0].Status)
+	require.Equal(t, receipt.GasUsed, data.Block.Transactions[0].GasUsed)
+	require.Len(t, data.Block.Transactions[0].Logs, len(receipt.Logs))
+	require.Equal(t, txn.Hash().String(), data.Block.Transactions[0].Logs[0].Transaction.Hash)
+	require.NotEmpty(t, data.Block.Logs)
+	require.Equal(t, txn.Hash().String(), data.Transaction.Hash)
+	require.Equal(t, block.NumberU64(), data.Transaction.Block.Number)
+
+	// logs query, transactions of the logs are looked up by hash
+	res = postQuery(t, srv, fmt.Sprintf(`{ logs(filter: {fromBlock: %d, toBlock: %d}) { index transaction { hash } } }`, block.NumberU64(), block.NumberU64()))
+	require.Empty(t, res.Errors)
+	var logs struct {
+		Logs []struct {
+			Index       int
+			Transaction struct{ Hash string }
+		}
+	}
+	require.NoError(t, json.Unmarshal(res.Data, &logs))
+	var blockLogs int
+	for _, r := range chain.Receipts[blockIdx] {
+		blockLogs += len(r.Logs)
+	}
+	require.Len(t, logs.Logs, blockLogs)
+	require.Equal(t, txn.Hash().String(), logs.Logs[0].Transaction.Hash)
+
+	// unknown block and transaction
+	res = postQuery(t, srv, `{ block(hash: "0x0000000000000000000000000000000000000000000000000000000000000001") { number } transaction(hash: "0x0000000000000000000000000000000000000000000000000000000000000001") { hash } }`)
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"block":null,"transaction":null}`, string(res.Data))
+
+	// call and estimateGas at the latest block, the contract has no fallback function, so a call without data fails
+	res = postQuery(t, srv, fmt.Sprintf(`{ block { call(data: {to: "%s"}) { status gasUsed } estimateGas(data: {to: "%s"}) } }`, txn.GetTo(), chain.TopBlock.Coinbase()))
+	require.Empty(t, res.Errors)
+	var call struct {
+		Block struct {
+			Call        struct{ Status, GasUsed uint64 }
+			EstimateGas uint64
+		}
+	}
+	require.NoError(t, json.Unmarshal(res.Data, &call))
+	require.Zero(t, call.Block.Call.Status)
+	require.NotZero(t, call.Block.Call.GasUsed)
+	require.Equal(t, uint64(21000), call.Block.EstimateGas)
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	res := postQuery(t, srv, `{ blocks(from: 0, to: 100) { number } }`)
+	require.Len(t, res.Errors, 1)
+	require.Contains(t, res.Errors[0].Message, "exceeds the limit of 25 blocks")
+
+	res = postQuery(t, srv, `{ logs(filter: {fromBlock: 0, toBlock: 100000}) { index } }`)
+	require.Len(t, res.Errors, 1)
+	require.Contains(t, res.Errors[0].Message, "exceeds the limit of 1000 blocks")
+
+	// each nested level of lists multiplies the complexity
+	res = postQuery(t, srv, `{ blocks(from: 0, to: 10) { transactions { logs { transaction { block { transactions { hash } } } } } } }`)
+	require.Len(t, res.Errors, 1)
+	require.Contains(t, res.Errors[0].Message, "exceeds the limit")
+
+	res = postQuery(t, srv, `{ blocks(from: 0, to: 5) { number transactions { hash } } }`)
+	require.Empty(t, res.Errors)
+	var blocks struct {
+		Blocks []struct{ Number uint64 }
+	}
+	require.NoError(t, json.Unmarshal(res.Data, &blocks))
+	require.Len(t, blocks.Blocks, 6)
+	require.Equal(t, uint64(5), blocks.Blocks[5].Number)
+}
+
 func TestGraphQLQueryBlock(t *testing.T) {
 	t.Skip("Not a unit test")
 
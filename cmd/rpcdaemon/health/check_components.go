@@ -0,0 +1,166 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package health
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/kvcache"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+var (
+	errHeadTooOld           = errors.New("head too old")
+	errSnapshotsDownloading = errors.New("snapshots are not downloaded yet")
+	errStateCacheBehind     = errors.New("state cache is behind")
+	errNoHead               = errors.New("no head block")
+)
+
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// DBCheck - db (remote one for rpcdaemon with --private.api.addr) is reachable
+func DBCheck(db kv.RoDB) Check {
+	return Check{Name: "db", Run: func(ctx context.Context) error {
+		return db.View(ctx, func(tx kv.Tx) error {
+			_, err := stages.GetStageProgress(tx, stages.Finish)
+			return err
+		})
+	}}
+}
+
+// TxPoolCheck - txpool grpc is reachable
+func TxPoolCheck(client txpool.TxpoolClient) Check {
+	return Check{Name: "txpool", Run: func(ctx context.Context) error {
+		_, err := client.Status(ctx, &txpool.StatusRequest{})
+		return err
+	}}
+}
+
+// stateVersioned - implemented by kvcache.Coherent, kvcache.DummyCache is never stale
+type stateVersioned interface {
+	LatestStateVersionID() uint64
+}
+
+// StateCacheCheck - state cache received the state changes of the latest blocks
+func StateCacheCheck(cache kvcache.Cache, db kv.RoDB, maxLag uint64) Check {
+	return Check{Name: "state_cache", Run: func(ctx context.Context) error {
+		versioned, ok := cache.(stateVersioned)
+		if !ok {
+			return nil
+		}
+
+		var dbVersion uint64
+		if err := db.View(ctx, func(tx kv.Tx) error {
+			v, err := tx.GetOne(kv.Sequence, kv.PlainStateVersion)
+			if len(v) == 8 {
+				dbVersion = binary.BigEndian.Uint64(v)
+			}
+			return err
+		}); err != nil {
+			return err
+		}
+
+		if cacheVersion := versioned.LatestStateVersionID(); dbVersion > cacheVersion+maxLag {
+			return fmt.Errorf("%w: state version %d, db %d", errStateCacheBehind, cacheVersion, dbVersion)
+		}
+		return nil
+	}}
+}
+
+// HeadFreshnessCheck - head set by the last forkchoice update of Caplin or external CL (engine API) is recent,
+// pre-merge - the current head
+func HeadFreshnessCheck(db kv.RoDB, maxAge time.Duration) Check {
+	return Check{Name: "head_freshness", Run: func(ctx context.Context) error {
+		var header *types.Header
+		if err := db.View(ctx, func(tx kv.Tx) error {
+			if hash := rawdb.ReadForkchoiceHead(tx); hash != (libcommon.Hash{}) {
+				if number := rawdb.ReadHeaderNumber(tx, hash); number != nil {
+					header = rawdb.ReadHeader(tx, hash, *number)
+				}
+			}
+			if header == nil {
+				header = rawdb.ReadCurrentHeader(tx)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		if header == nil {
+			return errNoHead
+		}
+
+		if age := time.Since(time.Unix(int64(header.Time), 0)); age > maxAge {
+			return fmt.Errorf("%w: block %d is %s old, max %s", errHeadTooOld, header.Number.Uint64(), age.Truncate(time.Second), maxAge)
+		}
+		return nil
+	}}
+}
+
+// SyncedCheck - executed block is close to the highest seen block, same as eth_syncing
+func SyncedCheck(db kv.RoDB, maxBlocksBehind uint64) Check {
+	return Check{Name: "synced", Run: func(ctx context.Context) error {
+		return db.View(ctx, func(tx kv.Tx) error {
+			highest, err := rawdb.ReadLastNewBlockSeen(tx)
+			if err != nil {
+				return err
+			}
+			current, err := stages.GetStageProgress(tx, stages.Execution)
+			if err != nil {
+				return err
+			}
+
+			if highest == 0 {
+				return fmt.Errorf("%w: highest block is unknown", errNotSynced)
+			}
+			if highest > current+maxBlocksBehind {
+				return fmt.Errorf("%w: executed block %d, highest %d", errNotSynced, current, highest)
+			}
+			return nil
+		})
+	}}
+}
+
+// SnapshotsCheck - initial snapshots download is complete: snapshots stage or the stages after it made progress
+func SnapshotsCheck(db kv.RoDB) Check {
+	return Check{Name: "snapshots", Run: func(ctx context.Context) error {
+		return db.View(ctx, func(tx kv.Tx) error {
+			for _, stage := range []stages.SyncStage{stages.Snapshots, stages.Headers} {
+				progress, err := stages.GetStageProgress(tx, stage)
+				if err != nil {
+					return err
+				}
+				if progress > 0 {
+					return nil
+				}
+			}
+			return errSnapshotsDownloading
+		})
+	}}
+}
@@ -210,10 +210,10 @@ func reportHealthFromHeaders(errCheckSynced, errCheckPeer, errCheckBlock, errChe
 	return writeResponse(w, errs, statusCode)
 }
 
-func writeResponse(w http.ResponseWriter, errs map[string]string, statusCode int) error {
+func writeResponse(w http.ResponseWriter, body interface{}, statusCode int) error {
 	w.WriteHeader(statusCode)
 
-	bodyJson, err := json.Marshal(errs)
+	bodyJson, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
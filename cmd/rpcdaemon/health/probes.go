@@ -0,0 +1,180 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package health
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/kvcache"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// Kubernetes-style probes, served next to the legacy urlPath
+const (
+	livePath    = urlPath + "/live"
+	readyPath   = urlPath + "/ready"
+	startupPath = urlPath + "/startup"
+
+	statusHealthy   = "HEALTHY"
+	statusUnhealthy = "UNHEALTHY"
+)
+
+// Components of the node checked by the probes, nil ones are not checked
+type Components struct {
+	DB         kv.RoDB
+	TxPool     txpool.TxpoolClient
+	StateCache kvcache.Cache
+}
+
+// Config - thresholds of the checks, zero values are replaced by defaults
+type Config struct {
+	CheckTimeout    time.Duration // of every check
+	MaxHeadAge      time.Duration // age of the head set by last forkchoice update (or the current head pre-merge)
+	MaxBlocksBehind uint64        // executed block vs the highest seen block
+	MaxCacheLag     uint64        // state versions the state cache is behind the db
+}
+
+var DefaultConfig = Config{
+	CheckTimeout:    3 * time.Second,
+	MaxHeadAge:      time.Minute,
+	MaxBlocksBehind: 8,
+	MaxCacheLag:     2,
+}
+
+func (c Config) withDefaults() Config {
+	if c.CheckTimeout == 0 {
+		c.CheckTimeout = DefaultConfig.CheckTimeout
+	}
+	if c.MaxHeadAge == 0 {
+		c.MaxHeadAge = DefaultConfig.MaxHeadAge
+	}
+	if c.MaxBlocksBehind == 0 {
+		c.MaxBlocksBehind = DefaultConfig.MaxBlocksBehind
+	}
+	if c.MaxCacheLag == 0 {
+		c.MaxCacheLag = DefaultConfig.MaxCacheLag
+	}
+	return c
+}
+
+// Probe is a set of checks, it is healthy if all of them pass
+type Probe []Check
+
+type CheckResult struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+}
+
+type ProbeReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Run runs the checks concurrently, each with the timeout
+func (p Probe) Run(ctx context.Context, timeout time.Duration) *ProbeReport {
+	report := &ProbeReport{Status: statusHealthy, Checks: make(map[string]CheckResult, len(p))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range p {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Run(checkCtx)
+			result := CheckResult{Status: errorStringOrOK(err), Latency: time.Since(start).String()}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if err != nil {
+				report.Status = statusUnhealthy
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Probes - liveness (restart if fails), startup (initial sync/download is done) and readiness (can serve requests)
+type Probes struct {
+	Live    Probe
+	Startup Probe
+	Ready   Probe
+
+	timeout time.Duration
+}
+
+func NewProbes(components *Components, cfg Config) *Probes {
+	cfg = cfg.withDefaults()
+	probes := &Probes{timeout: cfg.CheckTimeout}
+	if components.DB == nil {
+		return probes
+	}
+
+	db := DBCheck(components.DB)
+	probes.Live = Probe{db}
+	probes.Startup = Probe{db, SnapshotsCheck(components.DB)}
+	probes.Ready = Probe{db, HeadFreshnessCheck(components.DB, cfg.MaxHeadAge), SyncedCheck(components.DB, cfg.MaxBlocksBehind)}
+	if components.TxPool != nil {
+		probes.Ready = append(probes.Ready, TxPoolCheck(components.TxPool))
+	}
+	if components.StateCache != nil {
+		probes.Ready = append(probes.Ready, StateCacheCheck(components.StateCache, components.DB, cfg.MaxCacheLag))
+	}
+
+	return probes
+}
+
+// ProcessProbeIfNeeded serves the probes, returns false if the request is not for them
+func (p *Probes) ProcessProbeIfNeeded(w http.ResponseWriter, r *http.Request) bool {
+	var probe Probe
+	switch strings.ToLower(r.URL.Path) {
+	case livePath:
+		probe = p.Live
+	case startupPath:
+		probe = p.Startup
+	case readyPath:
+		probe = p.Ready
+	default:
+		return false
+	}
+
+	report := probe.Run(r.Context(), p.timeout)
+	statusCode := http.StatusOK
+	if report.Status != statusHealthy {
+		statusCode = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := writeResponse(w, report, statusCode); err != nil {
+		log.Root().Warn("unable to process healthcheck request", "err", err)
+	}
+
+	return true
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package health
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/kvcache"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+func serveProbe(t *testing.T, probes *Probes, path string) (int, ProbeReport) {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	require.True(t, probes.ProcessProbeIfNeeded(w, r))
+
+	var report ProbeReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+func TestProbe_Run(t *testing.T) {
+	probe := Probe{
+		{Name: "ok", Run: func(ctx context.Context) error { return nil }},
+		{Name: "failing", Run: func(ctx context.Context) error { return errors.New("boom") }},
+		{Name: "slow", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}
+
+	report := probe.Run(context.Background(), 10*time.Millisecond)
+	require.Equal(t, statusUnhealthy, report.Status)
+	require.Equal(t, statusHealthy, report.Checks["ok"].Status)
+	require.Equal(t, "ERROR: boom", report.Checks["failing"].Status)
+	require.True(t, strings.HasPrefix(report.Checks["slow"].Status, "ERROR: context deadline exceeded"))
+	require.NotEmpty(t, report.Checks["slow"].Latency)
+
+	report = probe[:1].Run(context.Background(), time.Second)
+	require.Equal(t, statusHealthy, report.Status)
+}
+
+func TestProbes(t *testing.T) {
+	ctx := context.Background()
+	db := memdb.NewTestDB(t)
+	cache := kvcache.New(kvcache.DefaultCoherentConfig)
+	probes := NewProbes(&Components{DB: db, StateCache: cache}, Config{})
+
+	r := httptest.NewRequest(http.MethodGet, "/health", nil)
+	require.False(t, probes.ProcessProbeIfNeeded(httptest.NewRecorder(), r))
+
+	code, report := serveProbe(t, probes, "/health/live")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, statusHealthy, report.Checks["db"].Status)
+
+	code, report = serveProbe(t, probes, "/health/startup")
+	require.Equal(t, http.StatusInternalServerError, code)
+	require.Contains(t, report.Checks["snapshots"].Status, errSnapshotsDownloading.Error())
+
+	code, report = serveProbe(t, probes, "/health/ready")
+	require.Equal(t, http.StatusInternalServerError, code)
+	require.Contains(t, report.Checks["head_freshness"].Status, errNoHead.Error())
+	require.Contains(t, report.Checks["synced"].Status, errNotSynced.Error())
+	require.Equal(t, statusHealthy, report.Checks["state_cache"].Status)
+
+	header := &types.Header{Number: big.NewInt(100), Time: uint64(time.Now().Unix()), Difficulty: big.NewInt(0)}
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		if err := rawdb.WriteHeader(tx, header); err != nil {
+			return err
+		}
+		rawdb.WriteForkchoiceHead(tx, header.Hash())
+		if err := rawdb.WriteLastNewBlockSeen(tx, 100); err != nil {
+			return err
+		}
+		if err := stages.SaveStageProgress(tx, stages.Execution, 95); err != nil {
+			return err
+		}
+		return stages.SaveStageProgress(tx, stages.Headers, 100)
+	}))
+
+	code, _ = serveProbe(t, probes, "/health/startup")
+	require.Equal(t, http.StatusOK, code)
+
+	code, report = serveProbe(t, probes, "/health/ready")
+	require.Equal(t, http.StatusOK, code, report)
+
+	// state cache didn't receive the latest blocks
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(kv.Sequence, kv.PlainStateVersion, binary.BigEndian.AppendUint64(nil, 3))
+	}))
+	code, report = serveProbe(t, probes, "/health/ready")
+	require.Equal(t, http.StatusInternalServerError, code)
+	require.Contains(t, report.Checks["state_cache"].Status, errStateCacheBehind.Error())
+
+	// stricter threshold
+	probes = NewProbes(&Components{DB: db}, Config{MaxBlocksBehind: 2, MaxHeadAge: time.Nanosecond})
+	_, report = serveProbe(t, probes, "/health/ready")
+	require.Contains(t, report.Checks["synced"].Status, errNotSynced.Error())
+	require.Contains(t, report.Checks["head_freshness"].Status, errHeadTooOld.Error())
+}
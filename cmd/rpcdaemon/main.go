@@ -24,6 +24,7 @@ import (
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon/cmd/rpcdaemon/cli"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/health"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/debug"
 	"github.com/erigontech/erigon/turbo/jsonrpc"
@@ -51,7 +52,8 @@ func main() {
 
 		apiList := jsonrpc.APIList(db, backend, txPool, mining, ff, stateCache, blockReader, cfg, engine, logger)
 		rpc.PreAllocateRPCMetricLabels(apiList)
-		if err := cli.StartRpcServer(ctx, cfg, apiList, logger); err != nil {
+		healthComponents := &health.Components{DB: db, TxPool: txPool, StateCache: stateCache}
+		if err := cli.StartRpcServer(ctx, cfg, apiList, healthComponents, logger); err != nil {
 			logger.Error(err.Error())
 			return nil
 		}
@@ -80,7 +80,7 @@ func cmp(cliCtx *cli.Context) error {
 
 	var loc1, loc2 *sync.Locator
 
-	var rcCli *downloader.RCloneClient
+	rcCli := sync.NewRemoteClient(logger)
 	var torrentCli *sync.TorrentClient
 
 	dataDir := cliCtx.String(utils.DataDirFlag.Name)
@@ -105,18 +105,6 @@ func cmp(cliCtx *cli.Context) error {
 
 	var err error
 
-	checkRemote := func(src string) error {
-		if rcCli == nil {
-			rcCli, err = downloader.NewRCloneClient(logger)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		return sync.CheckRemote(rcCli, src)
-	}
-
 	var chain string
 
 	pos := 0
@@ -130,7 +118,7 @@ func cmp(cliCtx *cli.Context) error {
 
 		switch loc1.LType {
 		case sync.RemoteFs:
-			if err = checkRemote(loc1.Src); err != nil {
+			if err = rcCli.Check(loc1); err != nil {
 				return err
 			}
 
@@ -149,7 +137,7 @@ func cmp(cliCtx *cli.Context) error {
 
 		switch loc2.LType {
 		case sync.RemoteFs:
-			if err = checkRemote(loc2.Src); err != nil {
+			if err = rcCli.Check(loc2); err != nil {
 				return err
 			}
 
@@ -193,21 +181,19 @@ func cmp(cliCtx *cli.Context) error {
 	var session1 sync.DownloadSession
 	var session2 sync.DownloadSession
 
-	if rcCli != nil {
-		if loc1.LType == sync.RemoteFs {
-			session1, err = rcCli.NewSession(cliCtx.Context, filepath.Join(tempDir, "l1"), loc1.Src+":"+loc1.Root, nil)
+	if loc1.LType == sync.RemoteFs {
+		session1, err = rcCli.NewSession(cliCtx.Context, filepath.Join(tempDir, "l1"), loc1)
 
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
+	}
 
-		if loc2.LType == sync.RemoteFs {
-			session2, err = rcCli.NewSession(cliCtx.Context, filepath.Join(tempDir, "l2"), loc2.Src+":"+loc2.Root, nil)
+	if loc2.LType == sync.RemoteFs {
+		session2, err = rcCli.NewSession(cliCtx.Context, filepath.Join(tempDir, "l2"), loc2)
 
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
 	}
 
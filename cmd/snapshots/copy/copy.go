@@ -99,7 +99,7 @@ func copy(cliCtx *cli.Context) error {
 	var src, dst *sync.Locator
 	var err error
 
-	var rcCli *downloader.RCloneClient
+	rcCli := sync.NewRemoteClient(logger)
 	var torrentCli *sync.TorrentClient
 
 	pos := 0
@@ -117,27 +117,23 @@ func copy(cliCtx *cli.Context) error {
 	if cliCtx.Args().Len() > pos {
 		val := cliCtx.Args().Get(pos)
 
-		if src, err = sync.ParseLocator(val); err != nil {
+		if dst, err = sync.ParseLocator(val); err != nil {
 			return err
 		}
 
 		pos++
 	}
 
+	if src == nil || dst == nil {
+		return errors.New("src and dst locators are required")
+	}
+
 	switch dst.LType {
 	case sync.TorrentFs:
 		return errors.New("can't copy to torrent - need intermediate local fs")
 
 	case sync.RemoteFs:
-		if rcCli == nil {
-			rcCli, err = downloader.NewRCloneClient(logger)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		if err = sync.CheckRemote(rcCli, src.Src); err != nil {
+		if err = rcCli.Check(dst); err != nil {
 			return err
 		}
 	}
@@ -151,15 +147,7 @@ func copy(cliCtx *cli.Context) error {
 		}
 
 	case sync.RemoteFs:
-		if rcCli == nil {
-			rcCli, err = downloader.NewRCloneClient(logger)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		if err = sync.CheckRemote(rcCli, src.Src); err != nil {
+		if err = rcCli.Check(src); err != nil {
 			return err
 		}
 	}
@@ -242,11 +230,11 @@ func torrentToLocal(torrentCli *sync.TorrentClient, src *sync.Locator, dst *sync
 	return errors.New("TODO")
 }
 
-func torrentToRemote(torrentCli *sync.TorrentClient, rcCli *downloader.RCloneClient, src *sync.Locator, dst *sync.Locator, from uint64, to uint64, snapTypes []snaptype.Type, torrents, hashes, manifest bool) error {
+func torrentToRemote(torrentCli *sync.TorrentClient, rcCli *sync.RemoteClient, src *sync.Locator, dst *sync.Locator, from uint64, to uint64, snapTypes []snaptype.Type, torrents, hashes, manifest bool) error {
 	return errors.New("TODO")
 }
 
-func localToRemote(rcCli *downloader.RCloneClient, src *sync.Locator, dst *sync.Locator, from uint64, to uint64, snapTypes []snaptype.Type, torrents, hashes, manifest bool) error {
+func localToRemote(rcCli *sync.RemoteClient, src *sync.Locator, dst *sync.Locator, from uint64, to uint64, snapTypes []snaptype.Type, torrents, hashes, manifest bool) error {
 	return errors.New("TODO")
 }
 
@@ -254,14 +242,10 @@ func localToLocal(src *sync.Locator, dst *sync.Locator, from uint64, to uint64,
 	return errors.New("TODO")
 }
 
-func remoteToLocal(ctx context.Context, rcCli *downloader.RCloneClient, src *sync.Locator, dst *sync.Locator, from uint64, to uint64, snapTypes []snaptype.Type, torrents, hashes, manifest bool) error {
+func remoteToLocal(ctx context.Context, rcCli *sync.RemoteClient, src *sync.Locator, dst *sync.Locator, from uint64, to uint64, snapTypes []snaptype.Type, torrents, hashes, manifest bool) error {
 	logger := sync.Logger(ctx)
 
-	if rcCli == nil {
-		return errors.New("no remote downloader")
-	}
-
-	session, err := rcCli.NewSession(ctx, dst.Root, src.Src+":"+src.Root, nil)
+	session, err := rcCli.NewSession(ctx, dst.Root, src)
 
 	if err != nil {
 		return err
@@ -281,7 +265,7 @@ func remoteToLocal(ctx context.Context, rcCli *downloader.RCloneClient, src *syn
 	return session.Download(ctx, files...)
 }
 
-func remoteToRemote(rcCli *downloader.RCloneClient, src *sync.Locator, dst *sync.Locator, from uint64, to uint64, snapTypes []snaptype.Type, torrents, hashes, manifest bool) error {
+func remoteToRemote(rcCli *sync.RemoteClient, src *sync.Locator, dst *sync.Locator, from uint64, to uint64, snapTypes []snaptype.Type, torrents, hashes, manifest bool) error {
 	return errors.New("TODO")
 }
 
@@ -95,7 +95,7 @@ func manifest(cliCtx *cli.Context, command string) error {
 	var src *sync.Locator
 	var err error
 
-	var rcCli *downloader.RCloneClient
+	rcCli := sync.NewRemoteClient(logger)
 
 	pos := 0
 
@@ -111,20 +111,12 @@ func manifest(cliCtx *cli.Context, command string) error {
 
 	switch src.LType {
 	case sync.RemoteFs:
-		if rcCli == nil {
-			rcCli, err = downloader.NewRCloneClient(logger)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		if err = sync.CheckRemote(rcCli, src.Src); err != nil {
+		if err = rcCli.Check(src); err != nil {
 			return err
 		}
 	}
 
-	var srcSession *downloader.RCloneSession
+	var srcSession downloader.RemoteSession
 
 	tempDir, err := os.MkdirTemp("", "snapshot-manifest-")
 
@@ -134,13 +126,11 @@ func manifest(cliCtx *cli.Context, command string) error {
 
 	defer os.RemoveAll(tempDir)
 
-	if rcCli != nil {
-		if src != nil && src.LType == sync.RemoteFs {
-			srcSession, err = rcCli.NewSession(cliCtx.Context, tempDir, src.Src+":"+src.Root, nil)
+	if src != nil && src.LType == sync.RemoteFs {
+		srcSession, err = rcCli.NewSession(cliCtx.Context, tempDir, src)
 
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
 	}
 
@@ -167,7 +157,7 @@ func manifest(cliCtx *cli.Context, command string) error {
 	}
 }
 
-func listManifest(ctx context.Context, srcSession *downloader.RCloneSession, out *os.File) error {
+func listManifest(ctx context.Context, srcSession downloader.RemoteSession, out *os.File) error {
 	entries, err := DownloadManifest(ctx, srcSession)
 
 	if err != nil {
@@ -181,7 +171,7 @@ func listManifest(ctx context.Context, srcSession *downloader.RCloneSession, out
 	return nil
 }
 
-func updateManifest(ctx context.Context, tmpDir string, srcSession *downloader.RCloneSession, version *snaptype.Version) error {
+func updateManifest(ctx context.Context, tmpDir string, srcSession downloader.RemoteSession, version *snaptype.Version) error {
 	entities, err := srcSession.ReadRemoteDir(ctx, true)
 
 	if err != nil {
@@ -238,7 +228,7 @@ func updateManifest(ctx context.Context, tmpDir string, srcSession *downloader.R
 	return srcSession.Upload(ctx, manifestFile)
 }
 
-func verifyManifest(ctx context.Context, srcSession *downloader.RCloneSession, version *snaptype.Version, out *os.File) error {
+func verifyManifest(ctx context.Context, srcSession downloader.RemoteSession, version *snaptype.Version, out *os.File) error {
 	manifestEntries, err := DownloadManifest(ctx, srcSession)
 
 	if err != nil {
@@ -365,7 +355,7 @@ func (e dirEntry) Info() (fs.FileInfo, error) {
 	return e, nil
 }
 
-func DownloadManifest(ctx context.Context, session *downloader.RCloneSession) ([]fs.DirEntry, error) {
+func DownloadManifest(ctx context.Context, session downloader.RemoteSession) ([]fs.DirEntry, error) {
 
 	reader, err := session.Cat(ctx, "manifest.txt")
 
@@ -373,6 +363,8 @@ func DownloadManifest(ctx context.Context, session *downloader.RCloneSession) ([
 		return nil, err
 	}
 
+	defer reader.Close()
+
 	var entries []fs.DirEntry
 
 	scanner := bufio.NewScanner(reader)
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"slices"
@@ -88,9 +89,36 @@ func (l Locator) String() string {
 }
 
 var locatorExp, _ = regexp.Compile(`^(?:(\w+)\:)?([^\:]*)(?:\:(v\d+))?`)
+var urlLocatorExp, _ = regexp.Compile(`^(s3|https?):(//[^/]+(?:/[^\:]*)?)(?:\:(v\d+))?$`)
 var srcExp, _ = regexp.Compile(`^erigon-v\d+-snapshots-(.*)$`)
 
+// ParseLocator parses torrent[:v<version>], <rclone remote>:<root>[:v<version>], s3://<bucket>/<root>[:v<version>],
+// http(s)://<host>/<root>[:v<version>] and local paths. Object store urls are kept as Src: <scheme>, Root: //<host>/<root>
+// so Src+":"+Root is the url of the remote.
 func ParseLocator(value string) (*Locator, error) {
+	if matches := urlLocatorExp.FindStringSubmatch(value); len(matches) > 0 {
+		loc := Locator{
+			LType: RemoteFs,
+			Src:   matches[1],
+			Root:  strings.TrimSuffix(matches[2], "/"),
+		}
+
+		if matches := srcExp.FindStringSubmatch(path.Base(loc.Root)); len(matches) > 1 {
+			loc.Chain = matches[1]
+		}
+
+		if len(matches[3]) > 0 {
+			version, err := strconv.ParseUint(matches[3][1:], 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("can't parse version: %s: %w", matches[3], err)
+			}
+
+			loc.Version = snaptype.Version(version)
+		}
+
+		return &loc, nil
+	}
+
 	if matches := locatorExp.FindStringSubmatch(value); len(matches) > 0 {
 		var loc Locator
 
@@ -482,13 +510,15 @@ func NewTorrentSession(cli *TorrentClient, chain string) *torrentSession {
 }
 
 func DownloadManifest(ctx context.Context, session DownloadSession) ([]fs.DirEntry, error) {
-	if session, ok := session.(*downloader.RCloneSession); ok {
+	if session, ok := session.(downloader.RemoteSession); ok {
 		reader, err := session.Cat(ctx, "manifest.txt")
 
 		if err != nil {
 			return nil, err
 		}
 
+		defer reader.Close()
+
 		var entries []fs.DirEntry
 
 		scanner := bufio.NewScanner(reader)
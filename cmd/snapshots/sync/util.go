@@ -21,8 +21,72 @@ import (
 	"fmt"
 
 	"github.com/erigontech/erigon-lib/downloader"
+	"github.com/erigontech/erigon-lib/log/v3"
 )
 
+// RemoteClient opens sessions with remote locators: object store urls are served natively,
+// rclone remotes by an rclone instance started on first use.
+type RemoteClient struct {
+	logger      log.Logger
+	rclone      *downloader.RCloneClient
+	objectStore *downloader.ObjectStoreClient
+}
+
+func NewRemoteClient(logger log.Logger) *RemoteClient {
+	return &RemoteClient{logger: logger}
+}
+
+func (c *RemoteClient) remoteFs(loc *Locator) string {
+	return loc.Src + ":" + loc.Root
+}
+
+// Check verifies that the remote of the locator is known.
+func (c *RemoteClient) Check(loc *Locator) error {
+	if downloader.IsObjectStoreFs(c.remoteFs(loc)) {
+		return nil
+	}
+
+	if c.rclone == nil {
+		rclone, err := downloader.NewRCloneClient(c.logger)
+
+		if err != nil {
+			return err
+		}
+
+		c.rclone = rclone
+	}
+
+	return CheckRemote(c.rclone, loc.Src)
+}
+
+func (c *RemoteClient) NewSession(ctx context.Context, localFs string, loc *Locator) (downloader.RemoteSession, error) {
+	if remoteFs := c.remoteFs(loc); downloader.IsObjectStoreFs(remoteFs) {
+		if c.objectStore == nil {
+			c.objectStore = downloader.NewObjectStoreClient(c.logger)
+		}
+
+		session, err := c.objectStore.NewSession(ctx, localFs, remoteFs, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return session, nil
+	}
+
+	if err := c.Check(loc); err != nil {
+		return nil, err
+	}
+
+	session, err := c.rclone.NewSession(ctx, localFs, c.remoteFs(loc), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
 func CheckRemote(rcCli *downloader.RCloneClient, src string) error {
 
 	remotes, err := rcCli.ListRemotes(context.Background())
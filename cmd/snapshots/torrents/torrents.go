@@ -140,24 +140,16 @@ func torrents(cliCtx *cli.Context, command string) error {
 		return errors.New("missing data source")
 	}
 
-	var rcCli *downloader.RCloneClient
+	rcCli := sync.NewRemoteClient(logger)
 
 	switch src.LType {
 	case sync.RemoteFs:
-		if rcCli == nil {
-			rcCli, err = downloader.NewRCloneClient(logger)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		if err = sync.CheckRemote(rcCli, src.Src); err != nil {
+		if err = rcCli.Check(src); err != nil {
 			return err
 		}
 	}
 
-	var srcSession *downloader.RCloneSession
+	var srcSession downloader.RemoteSession
 
 	dataDir := cliCtx.String(utils.DataDirFlag.Name)
 	var tempDir string
@@ -177,14 +169,12 @@ func torrents(cliCtx *cli.Context, command string) error {
 		}
 	}
 
-	if rcCli != nil {
-		if src != nil && src.LType == sync.RemoteFs {
-			ctx := cliCtx.Context // avoiding sonar dup complaint
-			srcSession, err = rcCli.NewSession(ctx, filepath.Join(tempDir, "src"), src.Src+":"+src.Root, nil)
+	if src != nil && src.LType == sync.RemoteFs {
+		ctx := cliCtx.Context // avoiding sonar dup complaint
+		srcSession, err = rcCli.NewSession(ctx, filepath.Join(tempDir, "src"), src)
 
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
 	}
 
@@ -231,7 +221,7 @@ func torrents(cliCtx *cli.Context, command string) error {
 	return listTorrents(cliCtx.Context, srcSession, os.Stdout, firstBlock, lastBlock)
 }
 
-func listTorrents(ctx context.Context, srcSession *downloader.RCloneSession, out *os.File, from uint64, to uint64) error {
+func listTorrents(ctx context.Context, srcSession downloader.RemoteSession, out *os.File, from uint64, to uint64) error {
 	entries, err := manifest.DownloadManifest(ctx, srcSession)
 
 	if err != nil {
@@ -265,7 +255,7 @@ func listTorrents(ctx context.Context, srcSession *downloader.RCloneSession, out
 	return nil
 }
 
-func torrentHashes(ctx context.Context, srcSession *downloader.RCloneSession, from uint64, to uint64) error {
+func torrentHashes(ctx context.Context, srcSession downloader.RemoteSession, from uint64, to uint64) error {
 	entries, err := manifest.DownloadManifest(ctx, srcSession)
 
 	if err != nil {
@@ -314,6 +304,7 @@ func torrentHashes(ctx context.Context, srcSession *downloader.RCloneSession, fr
 				}
 
 				mi, err = metainfo.Load(reader)
+				reader.Close()
 
 				if err != nil {
 					errs++
@@ -357,7 +348,7 @@ func torrentHashes(ctx context.Context, srcSession *downloader.RCloneSession, fr
 	return nil
 }
 
-func updateTorrents(ctx context.Context, srcSession *downloader.RCloneSession, from uint64, to uint64, logger log.Logger) error {
+func updateTorrents(ctx context.Context, srcSession downloader.RemoteSession, from uint64, to uint64, logger log.Logger) error {
 	entries, err := manifest.DownloadManifest(ctx, srcSession)
 
 	if err != nil {
@@ -414,7 +405,7 @@ func updateTorrents(ctx context.Context, srcSession *downloader.RCloneSession, f
 	return g.Wait()
 }
 
-func verifyTorrents(ctx context.Context, srcSession *downloader.RCloneSession, from uint64, to uint64, logger log.Logger) error {
+func verifyTorrents(ctx context.Context, srcSession downloader.RemoteSession, from uint64, to uint64, logger log.Logger) error {
 	entries, err := manifest.DownloadManifest(ctx, srcSession)
 
 	if err != nil {
@@ -460,6 +451,7 @@ func verifyTorrents(ctx context.Context, srcSession *downloader.RCloneSession, f
 				}
 
 				mi, err = metainfo.Load(reader)
+				reader.Close()
 
 				if err != nil {
 					errs++
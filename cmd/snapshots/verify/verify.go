@@ -25,7 +25,6 @@ import (
 
 	"github.com/urfave/cli/v2"
 
-	"github.com/erigontech/erigon-lib/downloader"
 	"github.com/erigontech/erigon-lib/downloader/snaptype"
 	"github.com/erigontech/erigon/cmd/snapshots/flags"
 	"github.com/erigontech/erigon/cmd/snapshots/sync"
@@ -102,7 +101,7 @@ func verify(cliCtx *cli.Context) error {
 	var src, dst *sync.Locator
 	var err error
 
-	var rcCli *downloader.RCloneClient
+	rcCli := sync.NewRemoteClient(logger)
 	var torrentCli *sync.TorrentClient
 
 	if src, err = sync.ParseLocator(cliCtx.String(SrcFlag.Name)); err != nil {
@@ -124,15 +123,7 @@ func verify(cliCtx *cli.Context) error {
 		}
 
 	case sync.RemoteFs:
-		if rcCli == nil {
-			rcCli, err = downloader.NewRCloneClient(logger)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		if err = sync.CheckRemote(rcCli, src.Src); err != nil {
+		if err = rcCli.Check(dst); err != nil {
 			return err
 		}
 
@@ -152,15 +143,7 @@ func verify(cliCtx *cli.Context) error {
 		}
 
 	case sync.RemoteFs:
-		if rcCli == nil {
-			rcCli, err = downloader.NewRCloneClient(logger)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		if err = sync.CheckRemote(rcCli, src.Src); err != nil {
+		if err = rcCli.Check(src); err != nil {
 			return err
 		}
 
@@ -221,21 +204,19 @@ func verify(cliCtx *cli.Context) error {
 		}
 	}
 
-	if rcCli != nil {
-		if src != nil && src.LType == sync.RemoteFs {
-			srcSession, err = rcCli.NewSession(cliCtx.Context, filepath.Join(tempDir, "src"), src.Src+":"+src.Root, nil)
+	if src != nil && src.LType == sync.RemoteFs {
+		srcSession, err = rcCli.NewSession(cliCtx.Context, filepath.Join(tempDir, "src"), src)
 
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
+	}
 
-		if dst.LType == sync.RemoteFs {
-			dstSession, err = rcCli.NewSession(cliCtx.Context, filepath.Join(tempDir, "dst"), dst.Src+":"+dst.Root, nil)
+	if dst.LType == sync.RemoteFs {
+		dstSession, err = rcCli.NewSession(cliCtx.Context, filepath.Join(tempDir, "dst"), dst)
 
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
 	}
 
@@ -0,0 +1,214 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	chain2 "github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/config3"
+	"github.com/erigontech/erigon-lib/kv"
+	kv2 "github.com/erigontech/erigon-lib/kv/mdbx"
+	"github.com/erigontech/erigon-lib/kv/temporal"
+	"github.com/erigontech/erigon-lib/log/v3"
+	libstate "github.com/erigontech/erigon-lib/state"
+
+	"github.com/erigontech/erigon/cmd/state/exec3"
+	"github.com/erigontech/erigon/cmd/state/statediff"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/ethconsensusconfig"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/ethdb/prune"
+	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+var (
+	stateDiffsFrom    uint64
+	stateDiffsTo      uint64
+	stateDiffsWorkers int
+	stateDiffsOutput  string
+)
+
+func init() {
+	withDataDir(stateDiffsCmd)
+	stateDiffsCmd.Flags().Uint64Var(&stateDiffsFrom, "from", 0, "first block to re-execute")
+	stateDiffsCmd.Flags().Uint64Var(&stateDiffsTo, "to", 0, "last block to re-execute, 0 - the last executed block")
+	stateDiffsCmd.Flags().IntVar(&stateDiffsWorkers, "workers", 0, "amount of exec workers, 0 - by the amount of CPUs")
+	stateDiffsCmd.Flags().StringVar(&stateDiffsOutput, "output", "", "JSONL file to write the diffs to (gzip-compressed if ends with .gz), stdout by default")
+	rootCmd.AddCommand(stateDiffsCmd)
+}
+
+var stateDiffsCmd = &cobra.Command{
+	Use:   "stateDiffs",
+	Short: "Re-executes blocks of the existing datadir and writes state changed by every block as JSONL",
+	Long: `Re-executes blocks [--from, --to] on top of the historical state of the datadir in parallel and writes
+one line per block: {"block", "hash", "accounts": [{"address", "created", "deleted", "balance": {"from", "to"},
+"nonce": {"from", "to"}, "code", "storage": [{"key", "from", "to"}]}]}.
+Changes by the system txns (block rewards, withdrawals, system contracts) are included.
+Doesn't write to the datadir, Erigon can keep running.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := log.Root()
+		return stateDiffs(cmd.Context(), datadir.New(datadirCli), stateDiffsFrom, stateDiffsTo, stateDiffsWorkers, stateDiffsOutput, logger)
+	},
+}
+
+func stateDiffs(ctx context.Context, dirs datadir.Dirs, fromBlock, toBlock uint64, workers int, output string, logger log.Logger) (err error) {
+	rawDB, err := kv2.NewMDBX(logger).Path(chaindata).Label(kv.ChainDB).Accede().Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer rawDB.Close()
+
+	var cc *chain2.Config
+	if err := rawDB.View(ctx, func(tx kv.Tx) error {
+		genesisHash, err := rawdb.ReadCanonicalHash(tx, 0)
+		if err != nil {
+			return err
+		}
+		cc, err = rawdb.ReadChainConfig(tx, genesisHash)
+		return err
+	}); err != nil {
+		return err
+	}
+	if cc == nil {
+		return errors.New("chain config not found in db, need to start erigon at least once on this datadir")
+	}
+
+	snapCfg := ethconfig.NewSnapCfg(true, true, false, false)
+	blockSnapshots := freezeblocks.NewRoSnapshots(snapCfg, dirs.Snap, 0, logger)
+	defer blockSnapshots.Close()
+	borSnapshots := freezeblocks.NewBorRoSnapshots(snapCfg, dirs.Snap, 0, logger)
+	defer borSnapshots.Close()
+	blockSnapshots.OptimisticReopenWithDB(rawDB)
+	borSnapshots.OptimisticalyReopenWithDB(rawDB)
+	blockReader := freezeblocks.NewBlockReader(blockSnapshots, borSnapshots)
+
+	agg, err := libstate.NewAggregator(ctx, dirs, config3.HistoryV3AggregationStep, rawDB, rawdb.NewCanonicalReader(), logger)
+	if err != nil {
+		return fmt.Errorf("create aggregator: %w", err)
+	}
+	defer agg.Close()
+	if err := agg.OpenFolder(); err != nil {
+		return err
+	}
+	db, err := temporal.New(rawDB, agg)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	executed, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return err
+	}
+	if toBlock == 0 {
+		toBlock = executed
+	}
+	if fromBlock > toBlock {
+		return fmt.Errorf("invalid block range: %d > %d", fromBlock, toBlock)
+	}
+	if toBlock > executed {
+		return fmt.Errorf("block %d is not executed yet, executed up to %d", toBlock, executed)
+	}
+	pm, err := prune.Get(tx)
+	if err != nil {
+		return err
+	}
+	if pm.History.Enabled() {
+		if historyFrom := pm.History.PruneTo(executed); fromBlock < historyFrom {
+			return fmt.Errorf("history is pruned before block %d, --from=%d", historyFrom, fromBlock)
+		}
+	}
+
+	g := genesis
+	if genesisPath == "" {
+		g = core.GenesisBlockByChainName(cc.ChainName)
+	}
+	execArgs := &exec3.ExecArgs{
+		ChainDB:     db,
+		Genesis:     g,
+		BlockReader: blockReader,
+		Engine:      ethconsensusconfig.CreateConsensusEngineBareBones(ctx, cc, logger),
+		Dirs:        dirs,
+		ChainConfig: cc,
+		Workers:     workers,
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		w = f
+		if strings.HasSuffix(output, ".gz") {
+			gz := gzip.NewWriter(f)
+			defer func() {
+				if closeErr := gz.Close(); err == nil {
+					err = closeErr
+				}
+			}()
+			w = gz
+		}
+	}
+	bw := bufio.NewWriterSize(w, 1024*1024)
+	defer func() {
+		if flushErr := bw.Flush(); err == nil {
+			err = flushErr
+		}
+	}()
+
+	logger.Info("[stateDiffs] start", "from", fromBlock, "to", toBlock, "output", output)
+	enc := json.NewEncoder(bw)
+	logEvery := time.NewTicker(30 * time.Second)
+	defer logEvery.Stop()
+	prevBlock, prevTime := fromBlock, time.Now()
+	return statediff.Run(ctx, fromBlock, toBlock, tx.(kv.TemporalTx), execArgs, func(diff *statediff.BlockDiff) error {
+		select {
+		case <-logEvery.C:
+			speed := float64(diff.Number-prevBlock) / time.Since(prevTime).Seconds()
+			logger.Info("[stateDiffs] progress", "block", diff.Number, "blk/s", fmt.Sprintf("%.1f", speed))
+			prevBlock, prevTime = diff.Number, time.Now()
+		default:
+		}
+		return enc.Encode(diff)
+	}, logger)
+}
@@ -74,9 +74,16 @@ type HistoricalTraceWorker struct {
 }
 
 type TraceConsumer struct {
+	// NewTracer is optional, it's called by workers for every txn
 	NewTracer func() GenericTracer
+	// Map is optional, it's called by workers right after the txn execution, in parallel and in random order.
+	// It can collect results from the tracer and the state changes of the txn (ibs.CommitBlock) into task.MapResult.
+	Map func(task *state.TxTask, tracer GenericTracer, ibs *state.IntraBlockState) error
 	//Reduce receiving results of execution. They are sorted and have no gaps.
 	Reduce func(task *state.TxTask, tx kv.Tx) error
+	// SystemTxs - Map is called also for block initialisation and finalisation (rewards, withdrawals, system contracts),
+	// with nil tracer
+	SystemTxs bool
 }
 
 func NewHistoricalTraceWorker(
@@ -180,10 +187,11 @@ func (rw *HistoricalTraceWorker) RunTxTask(txTask *state.TxTask) {
 	default:
 		txHash := txTask.Tx.Hash()
 		rw.taskGasPool.Reset(txTask.Tx.GetGas(), rw.execArgs.ChainConfig.GetMaxBlobGasPerBlock())
-		if tracer := rw.consumer.NewTracer(); tracer != nil {
-			rw.vmConfig.Debug = true
-			rw.vmConfig.Tracer = tracer
+		var tracer GenericTracer
+		if rw.consumer.NewTracer != nil {
+			tracer = rw.consumer.NewTracer()
 		}
+		rw.vmConfig.Debug, rw.vmConfig.Tracer = tracer != nil, tracer
 		rw.vmConfig.SkipAnalysis = txTask.SkipAnalysis
 		ibs.SetTxContext(txHash, txTask.BlockHash, txTask.TxIndex)
 		msg := txTask.TxAsMessage
@@ -208,9 +216,16 @@ func (rw *HistoricalTraceWorker) RunTxTask(txTask *state.TxTask) {
 			// Update the state with pending changes
 			ibs.SoftFinalise()
 			txTask.Logs = ibs.GetLogs(txHash)
+			if rw.consumer.Map != nil {
+				txTask.Error = rw.consumer.Map(txTask, tracer, ibs)
+			}
 		}
 		//txTask.Tracer = tracer
 	}
+
+	if rw.consumer.SystemTxs && rw.consumer.Map != nil && txTask.Error == nil && (txTask.TxIndex == -1 || txTask.Final) {
+		txTask.Error = rw.consumer.Map(txTask, nil, ibs)
+	}
 }
 func (rw *HistoricalTraceWorker) ResetTx(chainTx kv.Tx) {
 	if rw.background && rw.chainTx != nil {
@@ -327,7 +342,7 @@ func processResultQueueHistorical(consumer TraceConsumer, rws *state.ResultsQueu
 			applyWorker.RunTxTask(txTask)
 		}
 		if txTask.Error != nil {
-			return outputTxNum, false, err
+			return outputTxNum, false, fmt.Errorf("block %d, txIndex %d: %w", txTask.BlockNum, txTask.TxIndex, txTask.Error)
 		}
 		if err := consumer.Reduce(txTask, applyWorker.chainTx); err != nil {
 			return outputTxNum, false, err
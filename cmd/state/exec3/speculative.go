@@ -0,0 +1,356 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package exec3
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/tracing"
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+)
+
+/*
+SpeculativeExecutor - optimistic concurrent execution of the transactions of one block (Block-STM like),
+to cut the latency of block import at the chain tip where blocks are executed one by one.
+
+  - Execute runs all transactions of the block in parallel on the state after the block initialisation.
+    Each speculative run keeps its read set (same format as StateReaderV3) and records its writes instead
+    of applying them.
+  - RunTxTask is called in block order by the applying goroutine. It validates the read set of the
+    speculative run against the state which includes all preceding transactions (StateV3.ReadsValid):
+    if nothing it read has changed, the recorded writes are replayed to the state. Otherwise (or if the
+    speculative run failed) the transaction is re-executed serially by the apply worker.
+
+So results are always the ones of serial execution, speculation only decides how much work is done twice.
+Balance increases of accounts which were not read are not conflicts: they are applied from BalanceIncreaseSet.
+For the fee to the coinbase, speculative runs don't read the coinbase before the transaction (vm.Config.NoCoinbaseRead),
+and the fee is written as serial execution writes it, unless the transaction touched the coinbase (it's a conflict then).
+
+The state is read through the RwTx of the applying goroutine, which is bound to its OS thread.
+That's why speculative workers don't read it directly: their reads (and block hashes) are sent to
+the goroutine calling Execute, which serves them until all transactions are executed.
+*/
+type SpeculativeExecutor struct {
+	ctx         context.Context
+	logger      log.Logger
+	applyWorker *Worker
+	workers     []*speculativeWorker
+
+	calls   chan speculativeCall          // reads of the workers, served by the goroutine calling Execute
+	results []*speculativeResult          // of the block being executed, by TxIndex
+	mu      sync.Mutex                    // protects getHash
+	getHash map[uint64]libcommon.Hash     // block hashes read by the workers, the same for the whole block
+	hashFn  func(n uint64) libcommon.Hash // of the block being executed
+	stats   SpeculativeStats              // of the block being executed
+}
+
+// SpeculativeStats - how speculative execution of a block went
+type SpeculativeStats struct {
+	Txs        int // transactions executed speculatively
+	ReExecuted int // transactions which conflicted (or failed) and were executed again
+}
+
+type speculativeCall struct {
+	f    func()
+	done chan struct{}
+}
+
+type speculativeResult struct {
+	txTask *state.TxTask
+	writes []func(w state.StateWriter) error
+}
+
+func NewSpeculativeExecutor(ctx context.Context, applyWorker *Worker, workerCount int, logger log.Logger) *SpeculativeExecutor {
+	se := &SpeculativeExecutor{
+		ctx:         ctx,
+		logger:      logger,
+		applyWorker: applyWorker,
+		calls:       make(chan speculativeCall, workerCount),
+	}
+	se.workers = make([]*speculativeWorker, workerCount)
+	for i := range se.workers {
+		se.workers[i] = newSpeculativeWorker(se, applyWorker.chainConfig, applyWorker.engine)
+	}
+	return se
+}
+
+// Execute runs txTasks - all transactions of one block, in order - speculatively on the current state,
+// which must already include the block initialisation. Must be called by the goroutine owning the state tx.
+func (se *SpeculativeExecutor) Execute(txTasks []*state.TxTask) {
+	se.results = make([]*speculativeResult, len(txTasks))
+	se.stats = SpeculativeStats{}
+	se.getHash, se.hashFn = map[uint64]libcommon.Hash{}, nil
+	if len(txTasks) > 0 {
+		se.hashFn = txTasks[0].GetHashFn
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for _, sw := range se.workers {
+		sw.reader.StateReaderV3 = state.NewStateReaderV3(se.applyWorker.rs.Domains())
+		wg.Add(1)
+		go func(sw *speculativeWorker) {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < len(txTasks); i = int(next.Add(1) - 1) {
+				if se.ctx.Err() != nil {
+					return
+				}
+				if txTasks[i].TxAsMessage.FeeCap().IsZero() && sw.engine != nil {
+					continue // may be a service transaction, it's checked by a system call: leave it for serial execution
+				}
+				se.results[i] = sw.run(txTasks[i])
+			}
+		}(sw)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case call := <-se.calls:
+			call.f()
+			call.done <- struct{}{}
+		case <-done:
+			se.stats.Txs = len(txTasks)
+			return
+		}
+	}
+}
+
+// RunTxTask - replacement of Worker.RunTxTaskNoLock for the transactions of the block passed to Execute,
+// must be called in block order.
+func (se *SpeculativeExecutor) RunTxTask(txTask *state.TxTask) {
+	var res *speculativeResult
+	if txTask.TxIndex >= 0 && txTask.TxIndex < len(se.results) {
+		res = se.results[txTask.TxIndex]
+		se.results[txTask.TxIndex] = nil
+	}
+	if res == nil || res.txTask.Error != nil || !se.applyWorker.rs.ReadsValid(res.txTask.ReadLists) {
+		se.stats.ReExecuted++
+		se.applyWorker.RunTxTaskNoLock(txTask)
+		return
+	}
+
+	se.applyWorker.stateWriter.SetTxNum(se.ctx, txTask.TxNum)
+	for _, write := range res.writes {
+		if err := write(se.applyWorker.stateWriter); err != nil {
+			panic(err) // as MakeWriteSet in serial execution
+		}
+	}
+	// speculative runs don't read the coinbase before the transaction (vm.Config.NoCoinbaseRead), serial execution
+	// does: if the transaction didn't touch it, its fee is written as serial execution writes it
+	balanceIncreases := res.txTask.BalanceIncreaseSet
+	coinbase := txTask.EvmBlockContext.Coinbase
+	if increase, ok := balanceIncreases[coinbase]; ok {
+		ibs := state.New(se.applyWorker.stateReader)
+		ibs.GetBalance(coinbase)
+		ibs.AddBalance(coinbase, &increase, tracing.BalanceIncreaseRewardTransactionFee)
+		delete(balanceIncreases, coinbase)
+		for addr, increase := range ibs.BalanceIncreaseSet() {
+			balanceIncreases[addr] = increase
+		}
+		if err := ibs.MakeWriteSet(txTask.Rules, se.applyWorker.stateWriter); err != nil {
+			panic(err)
+		}
+	}
+	txTask.Error = nil
+	txTask.Failed = res.txTask.Failed
+	txTask.UsedGas = res.txTask.UsedGas
+	txTask.Logs = res.txTask.Logs
+	txTask.TraceFroms, txTask.TraceTos = res.txTask.TraceFroms, res.txTask.TraceTos
+	txTask.BalanceIncreaseSet = balanceIncreases
+}
+
+// Stats - of the last block passed to Execute
+func (se *SpeculativeExecutor) Stats() SpeculativeStats { return se.stats }
+
+// onApplier runs f on the goroutine calling Execute
+func (se *SpeculativeExecutor) onApplier(f func(), done chan struct{}) {
+	se.calls <- speculativeCall{f: f, done: done}
+	<-done
+}
+
+func (se *SpeculativeExecutor) blockHash(n uint64, done chan struct{}) libcommon.Hash {
+	se.mu.Lock()
+	h, ok := se.getHash[n]
+	se.mu.Unlock()
+	if ok {
+		return h
+	}
+	se.onApplier(func() { h = se.hashFn(n) }, done)
+	se.mu.Lock()
+	se.getHash[n] = h
+	se.mu.Unlock()
+	return h
+}
+
+type speculativeWorker struct {
+	se          *SpeculativeExecutor
+	chainConfig *chain.Config
+	engine      consensus.Engine
+	reader      *speculativeStateReader
+	writer      *stateWriteRecorder
+
+	callTracer  *CallTracer
+	taskGasPool *core.GasPool
+
+	evm   *vm.EVM
+	ibs   *state.IntraBlockState
+	vmCfg vm.Config
+}
+
+func newSpeculativeWorker(se *SpeculativeExecutor, chainConfig *chain.Config, engine consensus.Engine) *speculativeWorker {
+	sw := &speculativeWorker{
+		se:          se,
+		chainConfig: chainConfig,
+		engine:      engine,
+		reader:      &speculativeStateReader{se: se, done: make(chan struct{}, 1)},
+		writer:      &stateWriteRecorder{},
+		evm:         vm.NewEVM(evmtypes.BlockContext{}, evmtypes.TxContext{}, nil, chainConfig, vm.Config{}),
+		callTracer:  NewCallTracer(),
+		taskGasPool: new(core.GasPool),
+	}
+	sw.taskGasPool.AddBlobGas(chainConfig.GetMaxBlobGasPerBlock())
+	sw.vmCfg = vm.Config{Debug: true, Tracer: sw.callTracer, NoCoinbaseRead: true}
+	sw.ibs = state.New(sw.reader)
+	return sw
+}
+
+// run executes a copy of txTask as the default case of Worker.RunTxTaskNoLock, recording its writes
+func (sw *speculativeWorker) run(task *state.TxTask) *speculativeResult {
+	txTask := *task
+	sw.reader.ResetReadSet()
+	sw.writer.writes = nil
+	sw.ibs.Reset()
+	ibs := sw.ibs
+
+	txHash := txTask.Tx.Hash()
+	sw.taskGasPool.Reset(txTask.Tx.GetGas(), sw.chainConfig.GetMaxBlobGasPerBlock())
+	sw.callTracer.Reset()
+	sw.vmCfg.SkipAnalysis = txTask.SkipAnalysis
+	ibs.SetTxContext(txHash, txTask.BlockHash, txTask.TxIndex)
+	msg := txTask.TxAsMessage
+
+	blockContext := txTask.EvmBlockContext
+	blockContext.GetHash = func(n uint64) libcommon.Hash { return sw.se.blockHash(n, sw.reader.done) }
+	sw.evm.ResetBetweenBlocks(blockContext, core.NewEVMTxContext(msg), ibs, sw.vmCfg, txTask.Rules)
+
+	applyRes, err := core.ApplyMessage(sw.evm, msg, sw.taskGasPool, true /* refunds */, false /* gasBailout */)
+	if err != nil {
+		txTask.Error = err
+		return &speculativeResult{txTask: &txTask}
+	}
+	txTask.Failed = applyRes.Failed()
+	txTask.UsedGas = applyRes.UsedGas
+	ibs.SoftFinalise()
+	txTask.Logs = ibs.GetLogs(txHash)
+	txTask.TraceFroms = sw.callTracer.Froms()
+	txTask.TraceTos = sw.callTracer.Tos()
+
+	txTask.BalanceIncreaseSet = ibs.BalanceIncreaseSet()
+	if err = ibs.MakeWriteSet(txTask.Rules, sw.writer); err != nil {
+		txTask.Error = err
+		return &speculativeResult{txTask: &txTask}
+	}
+	txTask.ReadLists = sw.reader.ReadSet()
+	return &speculativeResult{txTask: &txTask, writes: sw.writer.writes}
+}
+
+// speculativeStateReader - StateReaderV3 (with its read set) reading on the goroutine calling Execute
+type speculativeStateReader struct {
+	*state.StateReaderV3
+	se   *SpeculativeExecutor
+	done chan struct{}
+}
+
+func (r *speculativeStateReader) ReadAccountData(address libcommon.Address) (acc *accounts.Account, err error) {
+	r.se.onApplier(func() { acc, err = r.StateReaderV3.ReadAccountData(address) }, r.done)
+	return acc, err
+}
+
+func (r *speculativeStateReader) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) (enc []byte, err error) {
+	r.se.onApplier(func() { enc, err = r.StateReaderV3.ReadAccountStorage(address, incarnation, key) }, r.done)
+	return enc, err
+}
+
+func (r *speculativeStateReader) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (code []byte, err error) {
+	r.se.onApplier(func() { code, err = r.StateReaderV3.ReadAccountCode(address, incarnation, codeHash) }, r.done)
+	return code, err
+}
+
+func (r *speculativeStateReader) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (size int, err error) {
+	r.se.onApplier(func() { size, err = r.StateReaderV3.ReadAccountCodeSize(address, incarnation, codeHash) }, r.done)
+	return size, err
+}
+
+// stateWriteRecorder - records the writes of a speculative run, to replay them to StateWriterV3 in the same order
+type stateWriteRecorder struct {
+	writes []func(w state.StateWriter) error
+}
+
+func (r *stateWriteRecorder) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	originalCopy, accountCopy := *original, *account
+	r.writes = append(r.writes, func(w state.StateWriter) error {
+		return w.UpdateAccountData(address, &originalCopy, &accountCopy)
+	})
+	return nil
+}
+
+func (r *stateWriteRecorder) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	r.writes = append(r.writes, func(w state.StateWriter) error {
+		return w.UpdateAccountCode(address, incarnation, codeHash, code)
+	})
+	return nil
+}
+
+func (r *stateWriteRecorder) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	originalCopy := *original
+	r.writes = append(r.writes, func(w state.StateWriter) error {
+		return w.DeleteAccount(address, &originalCopy)
+	})
+	return nil
+}
+
+func (r *stateWriteRecorder) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	keyCopy, originalCopy, valueCopy := *key, *original, *value
+	r.writes = append(r.writes, func(w state.StateWriter) error {
+		return w.WriteAccountStorage(address, incarnation, &keyCopy, &originalCopy, &valueCopy)
+	})
+	return nil
+}
+
+func (r *stateWriteRecorder) CreateContract(address libcommon.Address) error {
+	r.writes = append(r.writes, func(w state.StateWriter) error {
+		return w.CreateContract(address)
+	})
+	return nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package exec3
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/temporal/temporaltest"
+	"github.com/erigontech/erigon-lib/log/v3"
+	libstate "github.com/erigontech/erigon-lib/state"
+
+	"github.com/erigontech/erigon/consensus/ethash"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/tracing"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/params"
+)
+
+// counterCode increments slot 0 and stores the hash of the previous block to slot 1
+var counterCode = []byte{
+	0x60, 0x00, 0x54, 0x60, 0x01, 0x01, 0x60, 0x00, 0x55, // sstore(0, sload(0) + 1)
+	0x60, 0x01, 0x43, 0x03, 0x40, 0x60, 0x01, 0x55, // sstore(1, blockhash(number - 1))
+	0x00,
+}
+
+type speculativeTestBlock struct {
+	keys    []*ecdsa.PrivateKey
+	counter libcommon.Address
+	txs     types.Transactions
+	addrs   []libcommon.Address // all accounts touched by the block
+}
+
+func newSpeculativeTestBlock(t *testing.T) *speculativeTestBlock {
+	b := &speculativeTestBlock{counter: libcommon.HexToAddress("0xc0")}
+	for i := 0; i < 8; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		b.keys = append(b.keys, key)
+		b.addrs = append(b.addrs, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	signer := types.LatestSignerForChainID(params.TestChainConfig.ChainID)
+	tx := func(key int, nonce uint64, to libcommon.Address, value uint64) {
+		txn, err := types.SignTx(types.NewTransaction(nonce, to, uint256.NewInt(value), 100_000, uint256.NewInt(1), nil), *signer, b.keys[key])
+		require.NoError(t, err)
+		b.txs = append(b.txs, txn)
+	}
+	fresh := func(i byte) libcommon.Address {
+		addr := libcommon.BytesToAddress([]byte{0xf0, i})
+		b.addrs = append(b.addrs, addr)
+		return addr
+	}
+	tx(0, 0, fresh(0), 1)           // conflicts with nothing
+	tx(0, 1, fresh(1), 1)           // same sender: nonce conflict
+	tx(1, 0, b.counter, 0)          // conflicts with nothing
+	tx(2, 0, b.counter, 0)          // storage conflict
+	tx(3, 0, b.addrs[4], 1_000_000) // conflicts with nothing
+	tx(4, 0, fresh(2), 1)           // its sender received funds: balance conflict
+	tx(5, 0, fresh(3), 1)           // conflicts with nothing
+	tx(0, 2, b.counter, 0)          // nonce and storage conflict
+	tx(6, 0, fresh(4), 1)           // conflicts with nothing
+	b.addrs = append(b.addrs, b.counter)
+	return b
+}
+
+// execute runs the block on a fresh state, serially or speculatively, and returns the tasks and the post state
+func (b *speculativeTestBlock) execute(t *testing.T, workerCount int) ([]*state.TxTask, map[string][]byte, SpeculativeStats) {
+	logger := log.New()
+	ctx := context.Background()
+	db, _ := temporaltest.NewTestDB(t, datadir.New(t.TempDir()))
+	tx, err := db.BeginRw(ctx) //nolint:gocritic
+	require.NoError(t, err)
+	defer tx.Rollback()
+	domains, err := libstate.NewSharedDomains(tx, logger)
+	require.NoError(t, err)
+	defer domains.Close()
+	rs := state.NewStateV3(domains, logger)
+
+	chainConfig := params.TestChainConfig
+	rules := chainConfig.Rules(1, 0)
+
+	// pre-state: funded senders and the counter contract
+	genesis := state.New(state.NewStateReaderV3(domains))
+	for _, key := range b.keys {
+		genesis.AddBalance(crypto.PubkeyToAddress(key.PublicKey), uint256.NewInt(params.Ether), tracing.BalanceChangeUnspecified)
+	}
+	genesis.SetCode(b.counter, counterCode)
+	require.NoError(t, genesis.CommitBlock(rules, state.NewStateWriterV3(rs, nil)))
+
+	engine := ethash.NewFaker()
+	applyWorker := NewWorker(&sync.Mutex{}, logger, nil, ctx, false, db, rs, nil, nil, chainConfig, nil, nil, engine, datadir.New(t.TempDir()))
+	applyWorker.ResetTx(tx)
+	applyWorker.DiscardReadList()
+
+	coinbase := libcommon.HexToAddress("0xcb")
+	header := &types.Header{Number: big.NewInt(1), GasLimit: 30_000_000, Coinbase: coinbase, Difficulty: big.NewInt(1)}
+	getHashFn := func(n uint64) libcommon.Hash { return libcommon.BigToHash(new(big.Int).SetUint64(n + 0x1234)) }
+	blockContext := core.NewEVMBlockContext(header, getHashFn, engine, &coinbase, chainConfig)
+	signer := types.MakeSigner(chainConfig, 1, 0)
+
+	txTasks := make([]*state.TxTask, len(b.txs))
+	for i, txn := range b.txs {
+		msg, err := txn.AsMessage(*signer, nil, rules)
+		require.NoError(t, err)
+		sender, err := signer.Sender(txn)
+		require.NoError(t, err)
+		txTasks[i] = &state.TxTask{
+			TxNum: uint64(1 + i), BlockNum: 1, Header: header, Coinbase: coinbase, Rules: rules,
+			Txs: b.txs, TxIndex: i, Tx: txn, TxAsMessage: msg, Sender: &sender,
+			GetHashFn: getHashFn, EvmBlockContext: blockContext, BlockHash: header.Hash(),
+		}
+	}
+
+	var se *SpeculativeExecutor
+	if workerCount > 0 {
+		se = NewSpeculativeExecutor(ctx, applyWorker, workerCount, logger)
+		se.Execute(txTasks)
+	}
+	for _, txTask := range txTasks {
+		rs.SetTxNum(txTask.TxNum, txTask.BlockNum)
+		if se != nil {
+			se.RunTxTask(txTask)
+		} else {
+			applyWorker.RunTxTaskNoLock(txTask)
+		}
+		require.NoError(t, txTask.Error)
+		require.NoError(t, rs.ApplyState4(ctx, txTask))
+	}
+
+	post := map[string][]byte{}
+	for _, addr := range append(b.addrs, coinbase) {
+		enc, _, err := domains.DomainGet(kv.AccountsDomain, addr[:], nil)
+		require.NoError(t, err)
+		post[string(addr[:])] = enc
+	}
+	for _, slot := range []libcommon.Hash{{}, libcommon.BigToHash(big.NewInt(1))} {
+		enc, _, err := domains.DomainGet(kv.StorageDomain, b.counter[:], slot[:])
+		require.NoError(t, err)
+		post[string(b.counter[:])+string(slot[:])] = enc
+	}
+	var stats SpeculativeStats
+	if se != nil {
+		stats = se.Stats()
+	}
+	return txTasks, post, stats
+}
+
+func TestSpeculativeExecutor(t *testing.T) {
+	b := newSpeculativeTestBlock(t)
+	serialTasks, serialPost, _ := b.execute(t, 0)
+	specTasks, specPost, stats := b.execute(t, 4)
+
+	require.Equal(t, serialPost, specPost)
+	for i := range serialTasks {
+		require.Equal(t, serialTasks[i].UsedGas, specTasks[i].UsedGas, "tx %d", i)
+		require.Equal(t, serialTasks[i].Failed, specTasks[i].Failed, "tx %d", i)
+		require.Equal(t, serialTasks[i].BalanceIncreaseSet, specTasks[i].BalanceIncreaseSet, "tx %d", i)
+		require.Equal(t, serialTasks[i].TraceTos, specTasks[i].TraceTos, "tx %d", i)
+	}
+	require.Equal(t, SpeculativeStats{Txs: len(b.txs), ReExecuted: 4}, stats)
+}
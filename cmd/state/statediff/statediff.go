@@ -0,0 +1,183 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+// Package statediff re-executes blocks on top of historical state and produces the state changed by every block.
+package statediff
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cmd/state/exec3"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/turbo/indexer"
+)
+
+// BlockDiff is the state changed by the block: its txns and system txns (rewards, withdrawals, system contracts).
+// Accounts are sorted by address, storage by key.
+type BlockDiff struct {
+	Number   uint64         `json:"block"`
+	Hash     libcommon.Hash `json:"hash"`
+	Accounts []AccountDiff  `json:"accounts"`
+}
+
+type AccountDiff struct {
+	Address libcommon.Address `json:"address"`
+	Created bool              `json:"created,omitempty"` // didn't exist before the block
+	Deleted bool              `json:"deleted,omitempty"` // doesn't exist after the block
+	Balance *BalanceChange    `json:"balance,omitempty"`
+	Nonce   *NonceChange      `json:"nonce,omitempty"`
+	Code    hexutility.Bytes  `json:"code,omitempty"` // deployed by the block
+	Storage []StorageChange   `json:"storage,omitempty"`
+}
+
+type BalanceChange struct {
+	From *hexutil.Big `json:"from"`
+	To   *hexutil.Big `json:"to"`
+}
+
+type NonceChange struct {
+	From hexutil.Uint64 `json:"from"`
+	To   hexutil.Uint64 `json:"to"`
+}
+
+type StorageChange struct {
+	Key  libcommon.Hash `json:"key"`
+	From libcommon.Hash `json:"from"`
+	To   libcommon.Hash `json:"to"`
+}
+
+// Run re-executes blocks [fromBlock, toBlock] by exec3 workers in parallel, every worker reads the historical state
+// as of its txn. Diffs of the blocks are passed to the callback in the order of blocks.
+func Run(ctx context.Context, fromBlock, toBlock uint64, tx kv.TemporalTx, cfg *exec3.ExecArgs, onBlock func(*BlockDiff) error, logger log.Logger) error {
+	return exec3.CustomTraceMapReduce(fromBlock, toBlock, consumer(onBlock), ctx, tx, cfg, logger)
+}
+
+func consumer(onBlock func(*BlockDiff) error) exec3.TraceConsumer {
+	var block *blockCollector
+	return exec3.TraceConsumer{
+		SystemTxs: true,
+		Map: func(task *state.TxTask, tracer exec3.GenericTracer, ibs *state.IntraBlockState) error {
+			w := indexer.NewStateDiffWriter()
+			// CommitBlock, not MakeWriteSet: balance increases of not loaded accounts (e.g. fees of coinbase) are included
+			if err := ibs.CommitBlock(task.Rules, w); err != nil {
+				return err
+			}
+			task.MapResult = w.Diff()
+			return nil
+		},
+		Reduce: func(task *state.TxTask, tx kv.Tx) error {
+			if block == nil || block.number != task.BlockNum {
+				block = newBlockCollector(task.BlockNum, task.BlockHash)
+			}
+			if diff, ok := task.MapResult.(*indexer.StateDiff); ok {
+				block.add(diff)
+			}
+			if !task.Final {
+				return nil
+			}
+			diff := block.diff()
+			block = nil
+			return onBlock(diff)
+		},
+	}
+}
+
+// blockCollector merges diffs of the txns of a block: the first original and the last value of every change
+type blockCollector struct {
+	number   uint64
+	hash     libcommon.Hash
+	accounts map[libcommon.Address]*indexer.AccountDiff
+}
+
+func newBlockCollector(number uint64, hash libcommon.Hash) *blockCollector {
+	return &blockCollector{number: number, hash: hash, accounts: map[libcommon.Address]*indexer.AccountDiff{}}
+}
+
+func (c *blockCollector) add(diff *indexer.StateDiff) {
+	for address, txDiff := range diff.Accounts {
+		d, ok := c.accounts[address]
+		if !ok {
+			d = &indexer.AccountDiff{Original: txDiff.Original, Storage: map[libcommon.Hash]indexer.StorageDiff{}}
+			c.accounts[address] = d
+		}
+		d.Account = txDiff.Account
+		if txDiff.Code != nil {
+			d.Code = txDiff.Code
+		}
+		for key, s := range txDiff.Storage {
+			if prev, ok := d.Storage[key]; ok {
+				s.Original = prev.Original
+			}
+			d.Storage[key] = s
+		}
+	}
+}
+
+func (c *blockCollector) diff() *BlockDiff {
+	result := &BlockDiff{Number: c.number, Hash: c.hash, Accounts: []AccountDiff{}}
+	for address, d := range c.accounts {
+		if diff, changed := accountDiff(address, d); changed {
+			result.Accounts = append(result.Accounts, diff)
+		}
+	}
+	sort.Slice(result.Accounts, func(i, j int) bool {
+		return bytes.Compare(result.Accounts[i].Address[:], result.Accounts[j].Address[:]) < 0
+	})
+	return result
+}
+
+// accountDiff returns false if the changes of the block cancelled each other out
+func accountDiff(address libcommon.Address, d *indexer.AccountDiff) (AccountDiff, bool) {
+	diff := AccountDiff{Address: address, Code: d.Code}
+
+	original, account := d.Original, d.Account
+	switch {
+	case original == nil && account == nil: // created and deleted by the block
+		return diff, false
+	case original == nil:
+		diff.Created = true
+		original = &accounts.Account{}
+	case account == nil:
+		diff.Deleted = true
+		account = &accounts.Account{}
+	}
+	if !original.Balance.Eq(&account.Balance) {
+		diff.Balance = &BalanceChange{From: (*hexutil.Big)(original.Balance.ToBig()), To: (*hexutil.Big)(account.Balance.ToBig())}
+	}
+	if original.Nonce != account.Nonce {
+		diff.Nonce = &NonceChange{From: hexutil.Uint64(original.Nonce), To: hexutil.Uint64(account.Nonce)}
+	}
+
+	for key, s := range d.Storage {
+		if s.Original.Eq(&s.Value) {
+			continue
+		}
+		diff.Storage = append(diff.Storage, StorageChange{Key: key, From: s.Original.Bytes32(), To: s.Value.Bytes32()})
+	}
+	sort.Slice(diff.Storage, func(i, j int) bool { return bytes.Compare(diff.Storage[i].Key[:], diff.Storage[j].Key[:]) < 0 })
+
+	changed := diff.Created || diff.Deleted || diff.Balance != nil || diff.Nonce != nil || len(diff.Code) > 0 || len(diff.Storage) > 0
+	return diff, changed
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package statediff
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/cmd/state/exec3"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/turbo/indexer"
+)
+
+func TestBlockCollector(t *testing.T) {
+	created, updated, deleted := libcommon.HexToAddress("0x01"), libcommon.HexToAddress("0x02"), libcommon.HexToAddress("0x03")
+	key1, key2 := libcommon.HexToHash("0x01"), libcommon.HexToHash("0x02")
+	account := func(balance, nonce uint64) *accounts.Account {
+		return &accounts.Account{Initialised: true, Balance: *uint256.NewInt(balance), Nonce: nonce}
+	}
+
+	c := newBlockCollector(10, libcommon.HexToHash("0xaa"))
+	c.add(&indexer.StateDiff{Accounts: map[libcommon.Address]*indexer.AccountDiff{
+		created: {Account: account(1, 0), Code: []byte{0x60}},
+		updated: {Original: account(5, 1), Account: account(4, 2), Storage: map[libcommon.Hash]indexer.StorageDiff{
+			key1: {Original: *uint256.NewInt(0), Value: *uint256.NewInt(1)},
+			key2: {Original: *uint256.NewInt(7), Value: *uint256.NewInt(8)},
+		}},
+		deleted: {Original: account(3, 0), Account: account(3, 0)},
+	}})
+	c.add(&indexer.StateDiff{Accounts: map[libcommon.Address]*indexer.AccountDiff{
+		updated: {Original: account(4, 2), Account: account(5, 2), Storage: map[libcommon.Hash]indexer.StorageDiff{
+			key1: {Original: *uint256.NewInt(1), Value: *uint256.NewInt(0)}, // reverted by the block
+		}},
+		deleted: {Original: account(3, 0)},
+	}})
+
+	diff := c.diff()
+	require.Equal(t, uint64(10), diff.Number)
+	require.Len(t, diff.Accounts, 3)
+
+	require.Equal(t, created, diff.Accounts[0].Address)
+	require.True(t, diff.Accounts[0].Created)
+	require.Equal(t, uint64(1), diff.Accounts[0].Balance.To.ToInt().Uint64())
+	require.Equal(t, []byte{0x60}, []byte(diff.Accounts[0].Code))
+
+	require.Equal(t, updated, diff.Accounts[1].Address)
+	require.Nil(t, diff.Accounts[1].Balance) // 5 -> 4 -> 5
+	require.Equal(t, &NonceChange{From: 1, To: 2}, diff.Accounts[1].Nonce)
+	require.Equal(t, []StorageChange{{Key: key2, From: libcommon.BigToHash(uint256.NewInt(7).ToBig()), To: libcommon.BigToHash(uint256.NewInt(8).ToBig())}}, diff.Accounts[1].Storage)
+
+	require.Equal(t, deleted, diff.Accounts[2].Address)
+	require.True(t, diff.Accounts[2].Deleted)
+	require.Equal(t, uint64(0), diff.Accounts[2].Balance.To.ToInt().Uint64())
+}
+
+func TestRun(t *testing.T) {
+	m, chain, _ := rpcdaemontest.CreateTestSentry(t)
+	cfg := &exec3.ExecArgs{
+		ChainDB:     m.DB,
+		BlockReader: m.BlockReader,
+		Engine:      m.Engine,
+		Dirs:        m.Dirs,
+		ChainConfig: m.ChainConfig,
+		Workers:     2,
+	}
+
+	tx, err := m.DB.BeginRo(m.Ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	var diffs []*BlockDiff
+	toBlock := uint64(len(chain.Blocks))
+	err = Run(m.Ctx, 1, toBlock, tx.(kv.TemporalTx), cfg, func(diff *BlockDiff) error {
+		diffs = append(diffs, diff)
+		return nil
+	}, log.New())
+	require.NoError(t, err)
+	require.Len(t, diffs, len(chain.Blocks))
+
+	// diffs match the state before and after the block
+	reader := state.NewHistoryReaderV3()
+	reader.SetTx(tx)
+	var changes int
+	for i, diff := range diffs {
+		block := chain.Blocks[i]
+		require.Equal(t, block.NumberU64(), diff.Number)
+		require.Equal(t, block.Hash(), diff.Hash)
+
+		before, err := rawdbv3.TxNums.Min(tx, diff.Number)
+		require.NoError(t, err)
+		after, err := rawdbv3.TxNums.Max(tx, diff.Number)
+		require.NoError(t, err)
+		for _, account := range diff.Accounts {
+			changes++
+			reader.SetTxNum(before)
+			original, err := reader.ReadAccountData(account.Address)
+			require.NoError(t, err)
+			require.Equal(t, account.Created, original == nil, account.Address)
+			reader.SetTxNum(after + 1)
+			current, err := reader.ReadAccountData(account.Address)
+			require.NoError(t, err)
+			require.Equal(t, account.Deleted, current == nil, account.Address)
+
+			if account.Balance != nil {
+				if original != nil {
+					require.Equal(t, original.Balance.ToBig(), account.Balance.From.ToInt(), account.Address)
+				}
+				if current != nil {
+					require.Equal(t, current.Balance.ToBig(), account.Balance.To.ToInt(), account.Address)
+				}
+			}
+			if account.Nonce != nil && current != nil {
+				require.Equal(t, current.Nonce, uint64(account.Nonce.To), account.Address)
+			}
+			for _, s := range account.Storage {
+				value, err := reader.ReadAccountStorage(account.Address, 0, &s.Key)
+				require.NoError(t, err)
+				require.Equal(t, s.To, libcommon.BytesToHash(value), account.Address)
+			}
+		}
+	}
+	require.NotZero(t, changes)
+}
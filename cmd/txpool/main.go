@@ -74,6 +74,15 @@ var (
 	priceBump          uint64
 	blobPriceBump      uint64
 
+	trustedSenders   []string
+	localLaneSlots   int
+	trustedLaneSlots int
+	remoteLaneSlots  int
+	peerTxsPerSecond float64
+	peerTxsBurst     int
+
+	discardHistoryRetention time.Duration
+
 	noTxGossip bool
 
 	mdbxWriteMap bool
@@ -107,6 +116,13 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&noTxGossip, utils.TxPoolGossipDisableFlag.Name, utils.TxPoolGossipDisableFlag.Value, utils.TxPoolGossipDisableFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&mdbxWriteMap, utils.DbWriteMapFlag.Name, utils.DbWriteMapFlag.Value, utils.DbWriteMapFlag.Usage)
 	rootCmd.Flags().StringSliceVar(&traceSenders, utils.TxPoolTraceSendersFlag.Name, []string{}, utils.TxPoolTraceSendersFlag.Usage)
+	rootCmd.Flags().StringSliceVar(&trustedSenders, utils.TxPoolTrustedSendersFlag.Name, []string{}, utils.TxPoolTrustedSendersFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&localLaneSlots, utils.TxPoolLocalLaneSlotsFlag.Name, utils.TxPoolLocalLaneSlotsFlag.Value, utils.TxPoolLocalLaneSlotsFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&trustedLaneSlots, utils.TxPoolTrustedLaneSlotsFlag.Name, utils.TxPoolTrustedLaneSlotsFlag.Value, utils.TxPoolTrustedLaneSlotsFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&remoteLaneSlots, utils.TxPoolRemoteLaneSlotsFlag.Name, utils.TxPoolRemoteLaneSlotsFlag.Value, utils.TxPoolRemoteLaneSlotsFlag.Usage)
+	rootCmd.PersistentFlags().Float64Var(&peerTxsPerSecond, utils.TxPoolPeerTxsPerSecondFlag.Name, utils.TxPoolPeerTxsPerSecondFlag.Value, utils.TxPoolPeerTxsPerSecondFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&peerTxsBurst, utils.TxPoolPeerTxsBurstFlag.Name, utils.TxPoolPeerTxsBurstFlag.Value, utils.TxPoolPeerTxsBurstFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&discardHistoryRetention, utils.TxPoolDiscardHistoryRetentionFlag.Name, utils.TxPoolDiscardHistoryRetentionFlag.Value, utils.TxPoolDiscardHistoryRetentionFlag.Usage)
 }
 
 var rootCmd = &cobra.Command{
@@ -174,6 +190,12 @@ func doTxpool(ctx context.Context, logger log.Logger) error {
 	cfg.PriceBump = priceBump
 	cfg.BlobPriceBump = blobPriceBump
 	cfg.NoGossip = noTxGossip
+	cfg.LocalLaneSlots = localLaneSlots
+	cfg.TrustedLaneSlots = trustedLaneSlots
+	cfg.RemoteLaneSlots = remoteLaneSlots
+	cfg.PeerTxsPerSecond = peerTxsPerSecond
+	cfg.PeerTxsBurst = peerTxsBurst
+	cfg.DiscardHistoryRetention = discardHistoryRetention
 	cfg.MdbxWriteMap = mdbxWriteMap
 
 	cacheConfig := kvcache.DefaultCoherentConfig
@@ -184,6 +206,11 @@ func doTxpool(ctx context.Context, logger log.Logger) error {
 		sender := common.HexToAddress(senderHex)
 		cfg.TracedSenders[i] = string(sender[:])
 	}
+	cfg.TrustedSenders = make([]string, len(trustedSenders))
+	for i, senderHex := range trustedSenders {
+		sender := common.HexToAddress(senderHex)
+		cfg.TrustedSenders[i] = string(sender[:])
+	}
 
 	newTxs := make(chan types.Announcements, 1024)
 	defer close(newTxs)
@@ -21,11 +21,13 @@ package clique
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/erigontech/erigon/eth/consensuschain"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/log/v3"
 	"github.com/erigontech/erigon/core/types"
@@ -33,6 +35,13 @@ import (
 	"github.com/erigontech/erigon/turbo/services"
 )
 
+// defaultStatusBlocks is the number of blocks clique_status looks back by default.
+const defaultStatusBlocks = 64
+
+// errNoEngine is returned when the node serving the API doesn't run the clique
+// engine in-process (e.g. a standalone rpcdaemon).
+var errNoEngine = errors.New("clique engine is not available")
+
 // API is a user facing RPC API to allow controlling the signer and voting
 // mechanisms of the proof-of-authority scheme.
 type API struct {
@@ -45,6 +54,9 @@ type API struct {
 
 // GetSnapshot retrieves the state snapshot at a given block.
 func (api *API) GetSnapshot(ctx context.Context, number *rpc.BlockNumber) (*Snapshot, error) {
+	if api.clique == nil {
+		return nil, errNoEngine
+	}
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -74,6 +86,9 @@ func (api *API) GetSnapshot(ctx context.Context, number *rpc.BlockNumber) (*Snap
 
 // GetSnapshotAtHash retrieves the state snapshot at a given block.
 func (api *API) GetSnapshotAtHash(ctx context.Context, hash libcommon.Hash) (*Snapshot, error) {
+	if api.clique == nil {
+		return nil, errNoEngine
+	}
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -96,6 +111,9 @@ func (api *API) GetSnapshotAtHash(ctx context.Context, hash libcommon.Hash) (*Sn
 
 // GetSigners retrieves the list of authorized signers at the specified block.
 func (api *API) GetSigners(ctx context.Context, number *rpc.BlockNumber) ([]libcommon.Address, error) {
+	if api.clique == nil {
+		return nil, errNoEngine
+	}
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -124,6 +142,9 @@ func (api *API) GetSigners(ctx context.Context, number *rpc.BlockNumber) ([]libc
 
 // GetSignersAtHash retrieves the list of authorized signers at the specified block.
 func (api *API) GetSignersAtHash(ctx context.Context, hash libcommon.Hash) ([]libcommon.Address, error) {
+	if api.clique == nil {
+		return nil, errNoEngine
+	}
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -143,46 +164,46 @@ func (api *API) GetSignersAtHash(ctx context.Context, hash libcommon.Hash) ([]li
 }
 
 // Proposals returns the current proposals the node tries to uphold and vote on.
-func (api *API) Proposals() map[libcommon.Address]bool {
-	api.clique.lock.RLock()
-	defer api.clique.lock.RUnlock()
-
-	proposals := make(map[libcommon.Address]bool)
-	for address, auth := range api.clique.proposals {
-		proposals[address] = auth
+func (api *API) Proposals() (map[libcommon.Address]bool, error) {
+	if api.clique == nil {
+		return nil, errNoEngine
 	}
-	return proposals
+	return api.clique.Proposals(), nil
 }
 
 // Propose injects a new authorization proposal that the signer will attempt to
-// push through.
-func (api *API) Propose(address libcommon.Address, auth bool) {
-	api.clique.lock.Lock()
-	defer api.clique.lock.Unlock()
-
-	api.clique.proposals[address] = auth
+// push through. Proposals are persisted and survive restarts.
+func (api *API) Propose(address libcommon.Address, auth bool) error {
+	if api.clique == nil {
+		return errNoEngine
+	}
+	return api.clique.Propose(address, auth)
 }
 
 // Discard drops a currently running proposal, stopping the signer from casting
 // further votes (either for or against).
-func (api *API) Discard(address libcommon.Address) {
-	api.clique.lock.Lock()
-	defer api.clique.lock.Unlock()
-
-	delete(api.clique.proposals, address)
+func (api *API) Discard(address libcommon.Address) error {
+	if api.clique == nil {
+		return errNoEngine
+	}
+	return api.clique.Discard(address)
 }
 
 type status struct {
-	InturnPercent float64                   `json:"inturnPercent"`
-	SigningStatus map[libcommon.Address]int `json:"sealerActivity"`
-	NumBlocks     uint64                    `json:"numBlocks"`
+	InturnPercent       float64                       `json:"inturnPercent"`
+	SigningStatus       map[libcommon.Address]int     `json:"sealerActivity"`
+	SignerInturnPercent map[libcommon.Address]float64 `json:"sealerInturnPercent"`
+	NumBlocks           uint64                        `json:"numBlocks"`
 }
 
-// Status returns the status of the last N blocks,
-// - the number of active signers,
-// - the number of signers,
+// Status returns the status of the last N blocks (64 unless specified),
+// - the number of blocks sealed by each signer,
+// - the percentage of in-turn blocks of each signer,
 // - the percentage of in-turn blocks
-func (api *API) Status(ctx context.Context) (*status, error) {
+func (api *API) Status(ctx context.Context, blocks *hexutil.Uint64) (*status, error) {
+	if api.clique == nil {
+		return nil, errNoEngine
+	}
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -191,11 +212,16 @@ func (api *API) Status(ctx context.Context) (*status, error) {
 	chain := consensuschain.NewReader(api.clique.ChainConfig, tx, api.blockReader, api.logger)
 
 	var (
-		numBlocks = uint64(64)
+		numBlocks = uint64(defaultStatusBlocks)
 		header    = chain.CurrentHeader()
-		diff      = uint64(0)
 		optimals  = 0
 	)
+	if blocks != nil {
+		numBlocks = uint64(*blocks)
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
 	snap, err := api.clique.Snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
 	if err != nil {
 		return nil, err
@@ -205,11 +231,12 @@ func (api *API) Status(ctx context.Context) (*status, error) {
 		end     = header.Number.Uint64()
 		start   = end - numBlocks
 	)
-	if numBlocks > end {
+	if numBlocks >= end {
 		start = 1
 		numBlocks = end - start
 	}
 	signStatus := make(map[libcommon.Address]int)
+	inturns := make(map[libcommon.Address]int)
 	for _, s := range signers {
 		signStatus[s] = 0
 	}
@@ -218,19 +245,31 @@ func (api *API) Status(ctx context.Context) (*status, error) {
 		if h == nil {
 			return nil, fmt.Errorf("missing block %d", n)
 		}
-		if h.Difficulty.Cmp(DiffInTurn) == 0 {
-			optimals++
-		}
-		diff += h.Difficulty.Uint64()
 		sealer, err := api.clique.Author(h)
 		if err != nil {
 			return nil, err
 		}
+		if h.Difficulty.Cmp(DiffInTurn) == 0 {
+			optimals++
+			inturns[sealer]++
+		}
 		signStatus[sealer]++
 	}
+	signerInturn := make(map[libcommon.Address]float64, len(signStatus))
+	for s, sealed := range signStatus {
+		signerInturn[s] = percent(inturns[s], sealed)
+	}
 	return &status{
-		InturnPercent: float64(100*optimals) / float64(numBlocks),
-		SigningStatus: signStatus,
-		NumBlocks:     numBlocks,
+		InturnPercent:       percent(optimals, int(numBlocks)),
+		SigningStatus:       signStatus,
+		SignerInturnPercent: signerInturn,
+		NumBlocks:           numBlocks,
 	}, nil
 }
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(100*n) / float64(total)
+}
@@ -232,6 +232,14 @@ func New(cfg *chain.Config, snapshotConfig *params.ConsensusSnapshotConfig, cliq
 		logger:         logger,
 	}
 
+	// restore the proposals the signer was pushing through before the restart
+	proposals, err := loadProposals(cliqueDB)
+	if err != nil {
+		logger.Error("on Clique init while loading proposals", "err", err)
+	} else {
+		c.proposals = proposals
+	}
+
 	// warm the cache
 	snapNum, err := lastSnapshot(cliqueDB, logger)
 	if err != nil {
@@ -413,6 +421,66 @@ func (c *Clique) Authorize(signer libcommon.Address, signFn SignerFn) {
 	c.signFn = signFn
 }
 
+// Proposals returns the current proposals the node tries to uphold and vote on.
+func (c *Clique) Proposals() map[libcommon.Address]bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	proposals := make(map[libcommon.Address]bool, len(c.proposals))
+	for address, auth := range c.proposals {
+		proposals[address] = auth
+	}
+	return proposals
+}
+
+// Propose injects a new authorization proposal that the signer will attempt to
+// push through. The proposal is persisted, so it outlives restarts of the node.
+func (c *Clique) Propose(address libcommon.Address, auth bool) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	vote := []byte{0}
+	if auth {
+		vote[0] = 1
+	}
+	if err := c.DB.Update(context.Background(), func(tx kv.RwTx) error {
+		return tx.Put(kv.CliqueProposals, address[:], vote)
+	}); err != nil {
+		return fmt.Errorf("failed to store clique proposal: %w", err)
+	}
+	c.proposals[address] = auth
+	return nil
+}
+
+// Discard drops a currently running proposal, stopping the signer from casting
+// further votes (either for or against).
+func (c *Clique) Discard(address libcommon.Address) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.DB.Update(context.Background(), func(tx kv.RwTx) error {
+		return tx.Delete(kv.CliqueProposals, address[:])
+	}); err != nil {
+		return fmt.Errorf("failed to delete clique proposal: %w", err)
+	}
+	delete(c.proposals, address)
+	return nil
+}
+
+// loadProposals reads the persisted proposals of the local signer.
+func loadProposals(db kv.RoDB) (map[libcommon.Address]bool, error) {
+	proposals := make(map[libcommon.Address]bool)
+	if err := db.View(context.Background(), func(tx kv.Tx) error {
+		return tx.ForEach(kv.CliqueProposals, nil, func(k, v []byte) error {
+			proposals[libcommon.BytesToAddress(k)] = len(v) > 0 && v[0] == 1
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
 // Seal implements consensus.Engine, attempting to create a sealed block using
 // the local signing credentials.
 func (c *Clique) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
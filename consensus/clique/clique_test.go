@@ -20,7 +20,9 @@
 package clique_test
 
 import (
+	"bytes"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/holiman/uint256"
@@ -35,6 +37,7 @@ import (
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/eth/consensuschain"
 	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/turbo/stages/mock"
 )
@@ -142,3 +145,56 @@ func TestReimportMirroredState(t *testing.T) {
 	}
 
 }
+
+// Tests that the proposals of the local signer survive a restart of the engine
+// and are cast as votes into the headers prepared by the mining stages.
+func TestPersistentProposals(t *testing.T) {
+	var (
+		cliqueDB  = memdb.NewTestDB(t)
+		key, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr      = crypto.PubkeyToAddress(key.PublicKey)
+		engine    = clique.New(params.AllCliqueProtocolChanges, params.CliqueSnapshot, cliqueDB, log.New())
+		candidate = libcommon.HexToAddress("0xc0ffee")
+		dropped   = libcommon.HexToAddress("0xdead")
+	)
+	genspec := &types.Genesis{
+		ExtraData: make([]byte, clique.ExtraVanity+length.Addr+clique.ExtraSeal),
+		Config:    params.AllCliqueProtocolChanges,
+	}
+	copy(genspec.ExtraData[clique.ExtraVanity:], addr[:])
+	m := mock.MockWithGenesisEngine(t, genspec, engine, false, true)
+
+	if err := engine.Propose(candidate, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Propose(dropped, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Discard(dropped); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart the engine on top of the same database
+	restarted := clique.New(params.AllCliqueProtocolChanges, params.CliqueSnapshot, cliqueDB, log.New())
+	want := map[libcommon.Address]bool{candidate: true}
+	if have := restarted.Proposals(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("proposals mismatch: have %v, want %v", have, want)
+	}
+
+	if err := m.DB.View(m.Ctx, func(tx kv.Tx) error {
+		chain := consensuschain.NewReader(m.ChainConfig, tx, m.BlockReader, log.New())
+		header := &types.Header{Number: big.NewInt(1), ParentHash: m.Genesis.Hash()}
+		if err := restarted.Prepare(chain, header, nil); err != nil {
+			return err
+		}
+		if header.Coinbase != candidate {
+			t.Errorf("vote target mismatch: have %x, want %x", header.Coinbase, candidate)
+		}
+		if !bytes.Equal(header.Nonce[:], clique.NonceAuthVote) {
+			t.Errorf("vote nonce mismatch: have %x, want %x", header.Nonce, clique.NonceAuthVote)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rlp"
+)
+
+// BadBlocksLimit - max amount of blocks kept in kv.BadBlocks, the lowest ones are evicted first
+const BadBlocksLimit = 128
+
+// BadBlock - block rejected by validation, kept for later analysis (debug_getBadBlocks)
+type BadBlock struct {
+	Block        *types.Block
+	Err          string
+	PreStateRoot common.Hash // state root of the parent block
+	Time         uint64      // unix time when the block was rejected
+}
+
+// WriteBadBlock stores the rejected block, evicts the lowest blocks if there are more than BadBlocksLimit of them
+func WriteBadBlock(tx kv.RwTx, block *types.Block, validationErr error, preStateRoot common.Hash) error {
+	badBlock := &BadBlock{Block: block, PreStateRoot: preStateRoot, Time: uint64(time.Now().Unix())}
+	if validationErr != nil {
+		badBlock.Err = validationErr.Error()
+	}
+	data, err := rlp.EncodeToBytes(badBlock)
+	if err != nil {
+		return fmt.Errorf("WriteBadBlock: %w", err)
+	}
+	if err := tx.Put(kv.BadBlocks, dbutils.HeaderKey(block.NumberU64(), block.Hash()), data); err != nil {
+		return err
+	}
+
+	count, err := tx.Count(kv.BadBlocks)
+	if err != nil {
+		return err
+	}
+	if count <= BadBlocksLimit {
+		return nil
+	}
+	c, err := tx.RwCursor(kv.BadBlocks)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for ; count > BadBlocksLimit; count-- {
+		if _, _, err := c.First(); err != nil {
+			return err
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadBadBlock returns nil if the block is not in the bad blocks table
+func ReadBadBlock(tx kv.Tx, hash common.Hash) (*BadBlock, error) {
+	var found *BadBlock
+	if err := tx.ForEach(kv.BadBlocks, nil, func(k, v []byte) error {
+		if found != nil || common.BytesToHash(k[length.BlockNum:]) != hash {
+			return nil
+		}
+		var err error
+		found, err = decodeBadBlock(v)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// ReadBadBlocks returns the stored bad blocks, highest first
+func ReadBadBlocks(tx kv.Tx) ([]*BadBlock, error) {
+	c, err := tx.Cursor(kv.BadBlocks)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var badBlocks []*BadBlock
+	for k, v, err := c.Last(); k != nil || err != nil; k, v, err = c.Prev() {
+		if err != nil {
+			return nil, err
+		}
+		badBlock, err := decodeBadBlock(v)
+		if err != nil {
+			return nil, err
+		}
+		badBlocks = append(badBlocks, badBlock)
+	}
+	return badBlocks, nil
+}
+
+func decodeBadBlock(data []byte) (*BadBlock, error) {
+	badBlock := &BadBlock{}
+	if err := rlp.DecodeBytes(data, badBlock); err != nil {
+		return nil, fmt.Errorf("invalid bad block RLP: %w", err)
+	}
+	return badBlock, nil
+}
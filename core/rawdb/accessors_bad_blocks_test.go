@@ -0,0 +1,71 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+)
+
+func TestBadBlocks(t *testing.T) {
+	t.Parallel()
+	_, tx := memdb.NewTestTx(t)
+
+	badBlock := func(number uint64) *types.Block {
+		header := &types.Header{Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(0), Extra: []byte("bad block")}
+		return types.NewBlockWithHeader(header)
+	}
+
+	block := badBlock(1)
+	preStateRoot := libcommon.HexToHash("0x01")
+	require.NoError(t, rawdb.WriteBadBlock(tx, block, errors.New("invalid gas used"), preStateRoot))
+
+	stored, err := rawdb.ReadBadBlock(tx, block.Hash())
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	require.Equal(t, block.Hash(), stored.Block.Hash())
+	require.Equal(t, "invalid gas used", stored.Err)
+	require.Equal(t, preStateRoot, stored.PreStateRoot)
+	require.NotZero(t, stored.Time)
+
+	stored, err = rawdb.ReadBadBlock(tx, libcommon.HexToHash("0x02"))
+	require.NoError(t, err)
+	require.Nil(t, stored)
+
+	// the table is bounded, lowest blocks are evicted
+	for number := uint64(2); number <= rawdb.BadBlocksLimit+5; number++ {
+		require.NoError(t, rawdb.WriteBadBlock(tx, badBlock(number), nil, libcommon.Hash{}))
+	}
+	badBlocks, err := rawdb.ReadBadBlocks(tx)
+	require.NoError(t, err)
+	require.Len(t, badBlocks, rawdb.BadBlocksLimit)
+	require.Equal(t, uint64(rawdb.BadBlocksLimit+5), badBlocks[0].Block.NumberU64())
+	require.Equal(t, uint64(6), badBlocks[len(badBlocks)-1].Block.NumberU64())
+
+	stored, err = rawdb.ReadBadBlock(tx, block.Hash())
+	require.NoError(t, err)
+	require.Nil(t, stored)
+}
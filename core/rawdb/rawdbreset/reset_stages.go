@@ -162,7 +162,7 @@ func ResetTxLookup(tx kv.RwTx) error {
 }
 
 var Tables = map[stages.SyncStage][]string{
-	stages.CustomTrace: {},
+	stages.CustomTrace: {kv.TblCustomIndexKeys, kv.TblCustomIndexIdx, kv.TblCustomDomainVals, kv.TblCustomDomainHistory, kv.TblCustomIndexProgress},
 	stages.Finish:      {},
 }
 var stateBuckets = []string{
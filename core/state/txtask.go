@@ -69,6 +69,8 @@ type TxTask struct {
 	Logs               []*types.Log
 	TraceFroms         map[libcommon.Address]struct{}
 	TraceTos           map[libcommon.Address]struct{}
+	// MapResult is produced from the txn execution by exec3.TraceConsumer.Map, and consumed by its Reduce
+	MapResult any
 
 	UsedGas uint64
 
@@ -114,6 +116,7 @@ func (t *TxTask) Reset() {
 	t.Logs = nil
 	t.TraceFroms = nil
 	t.TraceTos = nil
+	t.MapResult = nil
 }
 
 // TxTaskQueue non-thread-safe priority-queue
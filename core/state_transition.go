@@ -317,7 +317,10 @@ func (st *StateTransition) TransitionDb(refunds bool, gasBailout bool) (*evmtype
 	coinbase := st.evm.Context.Coinbase
 
 	senderInitBalance := st.state.GetBalance(st.msg.From()).Clone()
-	coinbaseInitBalance := st.state.GetBalance(coinbase).Clone()
+	var coinbaseInitBalance *uint256.Int
+	if !st.evm.Config().NoCoinbaseRead || st.evm.Context.PostApplyMessage != nil {
+		coinbaseInitBalance = st.state.GetBalance(coinbase).Clone()
+	}
 
 	// First check this message satisfies all consensus rules before
 	// applying the message. The rules include these clauses
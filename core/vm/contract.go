@@ -64,6 +64,8 @@ type Contract struct {
 
 	Gas   uint64
 	value *uint256.Int
+
+	IsEOFInitcode bool // the code is an EOF initcode container run by a creation
 }
 
 // NewContract returns a new contract environment for the execution of EVM.
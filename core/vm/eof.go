@@ -0,0 +1,367 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EVM Object Format (EOF) v1 container, EIP-3540 and the rest of the EIP-7692 bundle.
+//
+//	container := header, body
+//	header := magic, version, kind_type, type_size, kind_code, num_code_sections, code_size+,
+//	          [kind_container, num_container_sections, container_size+], kind_data, data_size, terminator
+//	body := types_section, code_section+, container_section*, data_section
+//	types_section := (inputs, outputs, max_stack_increase)+
+const (
+	eofFormatByte = 0xef
+	eofVersion1   = 1
+
+	kindTypes     = 1
+	kindCode      = 2
+	kindContainer = 3
+	kindData      = 4
+
+	eofTypeSize             = 4    // inputs(1) + outputs(1) + max_stack_increase(2)
+	eofNonReturning         = 0x80 // outputs of a code section which never returns (RETF)
+	eofMaxIO                = 0x7f // max inputs and outputs of a code section
+	eofMaxStackHeight       = 1023 // max inputs + max_stack_increase of a code section
+	eofMaxCodeSections      = 1024
+	eofMaxContainerSections = 256
+	eofMaxReturnStackDepth  = 1024
+	eofMaxDataSize          = 0xffff
+)
+
+var eofMagic = []byte{eofFormatByte, 0x00}
+
+var (
+	errEOFIncomplete          = errors.New("incomplete container")
+	errEOFInvalidMagic        = errors.New("invalid magic")
+	errEOFInvalidVersion      = errors.New("invalid version")
+	errEOFMissingTypeHeader   = errors.New("missing type header")
+	errEOFInvalidTypeSize     = errors.New("invalid type section size")
+	errEOFMissingCodeHeader   = errors.New("missing code header")
+	errEOFInvalidSectionCount = errors.New("invalid section count")
+	errEOFZeroSectionSize     = errors.New("zero section size")
+	errEOFMissingDataHeader   = errors.New("missing data header")
+	errEOFMissingTerminator   = errors.New("missing header terminator")
+	errEOFTrailingBytes       = errors.New("trailing bytes after container")
+	errEOFTruncatedData       = errors.New("truncated data section")
+	errEOFInvalidSectionType  = errors.New("invalid code section type")
+	errEOFInvalidFirstSection = errors.New("first code section must have 0 inputs and be non-returning")
+	errEOFTooLargeStackHeight = errors.New("max stack height above limit")
+)
+
+// HasEOFMagic returns true if the code starts with the EOF magic 0xEF00.
+func HasEOFMagic(code []byte) bool {
+	return len(code) >= len(eofMagic) && code[0] == eofMagic[0] && code[1] == eofMagic[1]
+}
+
+// functionMetadata is an entry of the type section, the signature of a code section.
+type functionMetadata struct {
+	inputs           uint8
+	outputs          uint8 // eofNonReturning if the section never returns to the caller
+	maxStackIncrease uint16
+}
+
+func (m *functionMetadata) returning() bool { return m.outputs != eofNonReturning }
+
+// Container is a decoded EOF v1 container. Code sections, subcontainers and the data section
+// are slices of the encoded container.
+type Container struct {
+	types         []*functionMetadata
+	codeSections  [][]byte
+	subContainers [][]byte // encoded, decoded and validated with the parent by ValidateContainer
+	data          []byte
+	dataSize      int // declared in the header, len(data) is less if the data section is truncated
+}
+
+// CodeSection returns the body of the i-th code section.
+func (c *Container) CodeSection(i int) []byte { return c.codeSections[i] }
+
+// NumCodeSections returns the amount of code sections in the container.
+func (c *Container) NumCodeSections() int { return len(c.codeSections) }
+
+// NumSubContainers returns the amount of container sections in the container.
+func (c *Container) NumSubContainers() int { return len(c.subContainers) }
+
+// Data returns the data section, it's shorter than DataSize if truncated.
+func (c *Container) Data() []byte { return c.data }
+
+// DataSize returns the size of the data section declared in the header.
+func (c *Container) DataSize() int { return c.dataSize }
+
+// UnmarshalBinary decodes the container from b, the data section must be complete and
+// nothing must follow the container. Only the header and section sizes are checked,
+// ValidateContainer validates the code.
+func (c *Container) UnmarshalBinary(b []byte) error {
+	size, err := c.unmarshal(b, false)
+	if err != nil {
+		return err
+	}
+	if size != len(b) {
+		return errEOFTrailingBytes
+	}
+	return nil
+}
+
+// unmarshal decodes the container from the beginning of b and returns its size. If allowTruncatedData
+// is set, b may end inside the data section (subcontainer to be deployed with aux data by RETURNCONTRACT).
+func (c *Container) unmarshal(b []byte, allowTruncatedData bool) (int, error) {
+	if !HasEOFMagic(b) {
+		return 0, errEOFInvalidMagic
+	}
+	if len(b) < 3 {
+		return 0, errEOFIncomplete
+	}
+	if b[2] != eofVersion1 {
+		return 0, errEOFInvalidVersion
+	}
+	offset := 3
+
+	// type section header
+	typesSize, offset, err := parseSectionSize(b, offset, kindTypes, errEOFMissingTypeHeader)
+	if err != nil {
+		return 0, err
+	}
+	if typesSize < eofTypeSize || typesSize%eofTypeSize != 0 {
+		return 0, fmt.Errorf("%w: %d", errEOFInvalidTypeSize, typesSize)
+	}
+	if typesSize/eofTypeSize > eofMaxCodeSections {
+		return 0, fmt.Errorf("%w: %d code sections", errEOFInvalidSectionCount, typesSize/eofTypeSize)
+	}
+
+	// code sections header
+	codeSizes, offset, err := parseSectionList(b, offset, kindCode, 2, eofMaxCodeSections, errEOFMissingCodeHeader)
+	if err != nil {
+		return 0, err
+	}
+	if len(codeSizes) != typesSize/eofTypeSize {
+		return 0, fmt.Errorf("%w: %d code sections, %d types", errEOFInvalidTypeSize, len(codeSizes), typesSize/eofTypeSize)
+	}
+
+	// optional container sections header
+	var containerSizes []int
+	if offset < len(b) && b[offset] == kindContainer {
+		containerSizes, offset, err = parseSectionList(b, offset, kindContainer, 4, eofMaxContainerSections, nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// data section header
+	dataSize, offset, err := parseSectionSize(b, offset, kindData, errEOFMissingDataHeader)
+	if err != nil {
+		return 0, err
+	}
+	if offset >= len(b) {
+		return 0, errEOFIncomplete
+	}
+	if b[offset] != 0 {
+		return 0, errEOFMissingTerminator
+	}
+	offset++
+
+	// body
+	bodySize := typesSize + dataSize
+	for _, size := range codeSizes {
+		bodySize += size
+	}
+	for _, size := range containerSizes {
+		bodySize += size
+	}
+	if len(b) < offset+bodySize-dataSize {
+		return 0, errEOFIncomplete
+	}
+
+	c.types = make([]*functionMetadata, 0, len(codeSizes))
+	for i := 0; i < len(codeSizes); i++ {
+		m := &functionMetadata{
+			inputs:           b[offset],
+			outputs:          b[offset+1],
+			maxStackIncrease: binary.BigEndian.Uint16(b[offset+2:]),
+		}
+		if m.inputs > eofMaxIO || (m.outputs > eofMaxIO && m.outputs != eofNonReturning) {
+			return 0, fmt.Errorf("%w: section %d, inputs %d, outputs %d", errEOFInvalidSectionType, i, m.inputs, m.outputs)
+		}
+		if int(m.inputs)+int(m.maxStackIncrease) > eofMaxStackHeight {
+			return 0, fmt.Errorf("%w: section %d, %d", errEOFTooLargeStackHeight, i, int(m.inputs)+int(m.maxStackIncrease))
+		}
+		c.types = append(c.types, m)
+		offset += eofTypeSize
+	}
+	if c.types[0].inputs != 0 || c.types[0].returning() {
+		return 0, errEOFInvalidFirstSection
+	}
+
+	c.codeSections = make([][]byte, 0, len(codeSizes))
+	for _, size := range codeSizes {
+		c.codeSections = append(c.codeSections, b[offset:offset+size])
+		offset += size
+	}
+	c.subContainers = make([][]byte, 0, len(containerSizes))
+	for _, size := range containerSizes {
+		c.subContainers = append(c.subContainers, b[offset:offset+size])
+		offset += size
+	}
+
+	c.dataSize = dataSize
+	if len(b) < offset+dataSize {
+		if !allowTruncatedData {
+			return 0, fmt.Errorf("%w: %d of %d bytes", errEOFTruncatedData, len(b)-offset, dataSize)
+		}
+		c.data = b[offset:]
+		return len(b), nil
+	}
+	c.data = b[offset : offset+dataSize]
+	return offset + dataSize, nil
+}
+
+// parseSectionSize parses the section header: kind and 2-byte size
+func parseSectionSize(b []byte, offset int, kind byte, missingErr error) (int, int, error) {
+	if offset >= len(b) {
+		return 0, 0, errEOFIncomplete
+	}
+	if b[offset] != kind {
+		return 0, 0, fmt.Errorf("%w: found section kind %d", missingErr, b[offset])
+	}
+	if offset+3 > len(b) {
+		return 0, 0, errEOFIncomplete
+	}
+	return int(binary.BigEndian.Uint16(b[offset+1:])), offset + 3, nil
+}
+
+// parseSectionList parses the header of multiple sections: kind, 2-byte amount and the sizeLen-byte sizes of the sections
+func parseSectionList(b []byte, offset int, kind byte, sizeLen int, maxSections int, missingErr error) ([]int, int, error) {
+	if offset >= len(b) {
+		return nil, 0, errEOFIncomplete
+	}
+	if b[offset] != kind {
+		return nil, 0, fmt.Errorf("%w: found section kind %d", missingErr, b[offset])
+	}
+	if offset+3 > len(b) {
+		return nil, 0, errEOFIncomplete
+	}
+	count := int(binary.BigEndian.Uint16(b[offset+1:]))
+	offset += 3
+	if count == 0 || count > maxSections {
+		return nil, 0, fmt.Errorf("%w: %d sections of kind %d", errEOFInvalidSectionCount, count, kind)
+	}
+	if offset+count*sizeLen > len(b) {
+		return nil, 0, errEOFIncomplete
+	}
+	sizes := make([]int, count)
+	for i := range sizes {
+		if sizeLen == 2 {
+			sizes[i] = int(binary.BigEndian.Uint16(b[offset:]))
+		} else {
+			sizes[i] = int(binary.BigEndian.Uint32(b[offset:]))
+		}
+		if sizes[i] == 0 {
+			return nil, 0, fmt.Errorf("%w: section %d of kind %d", errEOFZeroSectionSize, i, kind)
+		}
+		offset += sizeLen
+	}
+	return sizes, offset, nil
+}
+
+// MarshalBinary encodes the container, the declared data size is kept even if the data section is truncated.
+func (c *Container) MarshalBinary() []byte {
+	b := make([]byte, 0, c.size())
+	b = append(b, eofMagic...)
+	b = append(b, eofVersion1)
+
+	b = append(b, kindTypes)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.types)*eofTypeSize))
+	b = append(b, kindCode)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.codeSections)))
+	for _, code := range c.codeSections {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(code)))
+	}
+	if len(c.subContainers) > 0 {
+		b = append(b, kindContainer)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(c.subContainers)))
+		for _, sub := range c.subContainers {
+			b = binary.BigEndian.AppendUint32(b, uint32(len(sub)))
+		}
+	}
+	b = append(b, kindData)
+	b = binary.BigEndian.AppendUint16(b, uint16(c.dataSize))
+	b = append(b, 0) // terminator
+
+	for _, m := range c.types {
+		b = append(b, m.inputs, m.outputs)
+		b = binary.BigEndian.AppendUint16(b, m.maxStackIncrease)
+	}
+	for _, code := range c.codeSections {
+		b = append(b, code...)
+	}
+	for _, sub := range c.subContainers {
+		b = append(b, sub...)
+	}
+	return append(b, c.data...)
+}
+
+func (c *Container) size() int {
+	size := 3 + 3 + 3 + 2*len(c.codeSections) + 3 + 1 + len(c.types)*eofTypeSize + len(c.data)
+	if len(c.subContainers) > 0 {
+		size += 3 + 4*len(c.subContainers)
+	}
+	for _, code := range c.codeSections {
+		size += len(code)
+	}
+	for _, sub := range c.subContainers {
+		size += len(sub)
+	}
+	return size
+}
+
+// withAuxData returns the encoded container with the data section extended by aux (RETURNCONTRACT),
+// the data section must become complete and must fit into 2-byte size.
+func (c *Container) withAuxData(aux []byte) ([]byte, error) {
+	dataSize := len(c.data) + len(aux)
+	if dataSize < c.dataSize {
+		return nil, fmt.Errorf("%w: %d of %d bytes after aux data", errEOFTruncatedData, dataSize, c.dataSize)
+	}
+	if dataSize > eofMaxDataSize {
+		return nil, fmt.Errorf("data section too large: %d", dataSize)
+	}
+	deployed := *c
+	deployed.data = make([]byte, 0, dataSize)
+	deployed.data = append(append(deployed.data, c.data...), aux...)
+	deployed.dataSize = dataSize
+	return deployed.MarshalBinary(), nil
+}
+
+// String returns the human readable layout of the container.
+func (c *Container) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Header\n  - Types: %d\n  - Code sections: %d\n  - Containers: %d\n  - Data size: %d (%d present)\n",
+		len(c.types)*eofTypeSize, len(c.codeSections), len(c.subContainers), c.dataSize, len(c.data))
+	for i, code := range c.codeSections {
+		m := c.types[i]
+		fmt.Fprintf(&sb, "Code section %d: inputs %d, outputs %d, max stack increase %d\n  %x\n", i, m.inputs, m.outputs, m.maxStackIncrease, code)
+	}
+	for i, sub := range c.subContainers {
+		fmt.Fprintf(&sb, "Container %d: %x\n", i, sub)
+	}
+	fmt.Fprintf(&sb, "Data: %x\n", c.data)
+	return sb.String()
+}
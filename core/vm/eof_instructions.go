@@ -0,0 +1,533 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/tracing"
+	"github.com/erigontech/erigon/core/vm/stack"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/params"
+)
+
+const (
+	eofMinRetainedGas = 5000 // EXT*CALL: gas kept by the caller
+	eofMinCalleeGas   = 2300 // EXT*CALL: min gas passed to the callee, otherwise light failure
+
+	// EXT*CALL status codes
+	extCallSuccess = 0
+	extCallRevert  = 1
+	extCallFailure = 2
+)
+
+// eofCodeHash is the code hash of EOF contracts as seen by legacy EXTCODEHASH
+var eofCodeHash = crypto.Keccak256Hash(eofMagic)
+
+// returnFrame is the return stack item pushed by CALLF
+type returnFrame struct {
+	section uint64
+	pc      uint64 // of the instruction following CALLF
+}
+
+// setCodeSection switches the execution to the EOF code section, the interpreter reads
+// instructions from Contract.Code.
+func (scope *ScopeContext) setCodeSection(section uint64) {
+	scope.codeSection = section
+	scope.Contract.Code = scope.eof.codeSections[section]
+}
+
+// enable3540 applies EIP-3540 to the legacy instructions: EOF contracts look like 0xEF00 to EXTCODE*
+func enable3540(jt *JumpTable) {
+	jt[EXTCODESIZE].execute = opExtCodeSizeEOF
+	jt[EXTCODECOPY].execute = opExtCodeCopyEOF
+	jt[EXTCODEHASH].execute = opExtCodeHashEOF
+}
+
+func opExtCodeSizeEOF(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	slot := scope.Stack.Peek()
+	code := interpreter.evm.IntraBlockState().GetCode(slot.Bytes20())
+	if HasEOFMagic(code) {
+		code = eofMagic
+	}
+	slot.SetUint64(uint64(len(code)))
+	return nil, nil
+}
+
+func opExtCodeCopyEOF(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		stack      = scope.Stack
+		a          = stack.Pop()
+		memOffset  = stack.Pop()
+		codeOffset = stack.Pop()
+		length     = stack.Pop()
+	)
+	code := interpreter.evm.IntraBlockState().GetCode(a.Bytes20())
+	if HasEOFMagic(code) {
+		code = eofMagic
+	}
+	len64 := length.Uint64()
+	scope.Memory.Set(memOffset.Uint64(), len64, getDataBig(code, &codeOffset, len64))
+	return nil, nil
+}
+
+func opExtCodeHashEOF(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	slot := scope.Stack.Peek()
+	address := libcommon.Address(slot.Bytes20())
+	ibs := interpreter.evm.IntraBlockState()
+	switch {
+	case ibs.Empty(address):
+		slot.Clear()
+	case HasEOFMagic(ibs.GetCode(address)):
+		slot.SetBytes(eofCodeHash.Bytes())
+	default:
+		slot.SetBytes(ibs.GetCodeHash(address).Bytes())
+	}
+	return nil, nil
+}
+
+// enable4200 applies EIP-4200 (static relative jumps)
+func enable4200(jt *JumpTable) {
+	jt[RJUMP] = &operation{
+		execute:     opRjump,
+		constantGas: GasQuickStep,
+	}
+	jt[RJUMPI] = &operation{
+		execute:     opRjumpi,
+		constantGas: 4,
+		numPop:      1,
+	}
+	jt[RJUMPV] = &operation{
+		execute:     opRjumpv,
+		constantGas: 4,
+		numPop:      1,
+	}
+}
+
+// relative jumps are relative to the end of the immediate, the interpreter loop increments pc after the instruction
+
+func opRjump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	offset := int16(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+	*pc = uint64(int64(*pc) + 2 + int64(offset))
+	return nil, nil
+}
+
+func opRjumpi(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	cond := scope.Stack.Pop()
+	if cond.IsZero() {
+		*pc += 2
+		return nil, nil
+	}
+	return opRjump(pc, interpreter, scope)
+}
+
+func opRjumpv(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code  = scope.Contract.Code
+		count = uint64(code[*pc+1]) + 1
+		idx   = scope.Stack.Pop()
+	)
+	if !idx.LtUint64(count) {
+		*pc += 1 + count*2
+		return nil, nil
+	}
+	offset := int16(binary.BigEndian.Uint16(code[*pc+2+idx.Uint64()*2:]))
+	*pc = uint64(int64(*pc) + 1 + int64(count*2) + int64(offset))
+	return nil, nil
+}
+
+// enable4750 applies EIP-4750 (functions)
+func enable4750(jt *JumpTable) {
+	jt[CALLF] = &operation{
+		execute:     opCallf,
+		constantGas: GasFastStep,
+	}
+	jt[RETF] = &operation{
+		execute:     opRetf,
+		constantGas: GasFastestStep,
+	}
+}
+
+func opCallf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	section := uint64(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+	typ := scope.eof.types[section]
+	if limit := int(params.StackLimit) - int(typ.maxStackIncrease); scope.Stack.Len() > limit {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.Len(), limit: limit}
+	}
+	if len(scope.returnStack) >= eofMaxReturnStackDepth {
+		return nil, ErrReturnStackExceeded
+	}
+	scope.returnStack = append(scope.returnStack, returnFrame{section: scope.codeSection, pc: *pc + 3})
+	scope.setCodeSection(section)
+	*pc = 0
+	*pc-- // wraps, the interpreter loop increments it to 0
+	return nil, nil
+}
+
+func opRetf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	frame := scope.returnStack[len(scope.returnStack)-1]
+	scope.returnStack = scope.returnStack[:len(scope.returnStack)-1]
+	scope.setCodeSection(frame.section)
+	*pc = frame.pc - 1
+	return nil, nil
+}
+
+// enable6206 applies EIP-6206 (JUMPF)
+func enable6206(jt *JumpTable) {
+	jt[JUMPF] = &operation{
+		execute:     opJumpf,
+		constantGas: GasFastStep,
+	}
+}
+
+func opJumpf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	section := uint64(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+	typ := scope.eof.types[section]
+	if limit := int(params.StackLimit) - int(typ.maxStackIncrease); scope.Stack.Len() > limit {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.Len(), limit: limit}
+	}
+	scope.setCodeSection(section)
+	*pc = 0
+	*pc-- // wraps, the interpreter loop increments it to 0
+	return nil, nil
+}
+
+// enable663 applies EIP-663 (DUPN, SWAPN, EXCHANGE). The stack requirements depend on the
+// immediates and are guaranteed by the code validation.
+func enable663(jt *JumpTable) {
+	jt[DUPN] = &operation{
+		execute:     opDupN,
+		constantGas: GasFastestStep,
+		numPush:     1,
+	}
+	jt[SWAPN] = &operation{
+		execute:     opSwapN,
+		constantGas: GasFastestStep,
+	}
+	jt[EXCHANGE] = &operation{
+		execute:     opExchange,
+		constantGas: GasFastestStep,
+	}
+}
+
+func opDupN(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	n := int(scope.Contract.Code[*pc+1])
+	scope.Stack.Dup(n + 1)
+	*pc++
+	return nil, nil
+}
+
+func opSwapN(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	n := int(scope.Contract.Code[*pc+1])
+	scope.Stack.Swap(n + 2)
+	*pc++
+	return nil, nil
+}
+
+func opExchange(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	imm := scope.Contract.Code[*pc+1]
+	n, m := int(imm>>4)+1, int(imm&0x0f)+1
+	a, b := scope.Stack.Back(n), scope.Stack.Back(n+m)
+	*a, *b = *b, *a
+	*pc++
+	return nil, nil
+}
+
+// enable7480 applies EIP-7480 (data section access)
+func enable7480(jt *JumpTable) {
+	jt[DATALOAD] = &operation{
+		execute:     opDataLoad,
+		constantGas: 4,
+		numPop:      1,
+		numPush:     1,
+	}
+	jt[DATALOADN] = &operation{
+		execute:     opDataLoadN,
+		constantGas: GasFastestStep,
+		numPush:     1,
+	}
+	jt[DATASIZE] = &operation{
+		execute:     opDataSize,
+		constantGas: GasQuickStep,
+		numPush:     1,
+	}
+	jt[DATACOPY] = &operation{
+		execute:     opDataCopy,
+		constantGas: GasFastestStep,
+		dynamicGas:  gasDataCopy,
+		numPop:      3,
+		memorySize:  memoryDataCopy,
+	}
+}
+
+func opDataLoad(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	offset := scope.Stack.Peek()
+	offset.SetBytes32(getDataBig(scope.eof.data, offset, 32))
+	return nil, nil
+}
+
+func opDataLoadN(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	offset := uint64(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+	scope.Stack.Push(new(uint256.Int).SetBytes32(getData(scope.eof.data, offset, 32)))
+	*pc += 2
+	return nil, nil
+}
+
+func opDataSize(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	scope.Stack.Push(new(uint256.Int).SetUint64(uint64(len(scope.eof.data))))
+	return nil, nil
+}
+
+func opDataCopy(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		memOffset = scope.Stack.Pop()
+		offset    = scope.Stack.Pop()
+		length    = scope.Stack.Pop()
+	)
+	scope.Memory.Set(memOffset.Uint64(), length.Uint64(), getDataBig(scope.eof.data, &offset, length.Uint64()))
+	return nil, nil
+}
+
+// enable7069 applies EIP-7069 (EXTCALL, EXTDELEGATECALL, EXTSTATICCALL, RETURNDATALOAD)
+func enable7069(jt *JumpTable) {
+	jt[EXTCALL] = &operation{
+		execute:     opExtCall,
+		constantGas: params.WarmStorageReadCostEIP2929,
+		dynamicGas:  gasExtCall,
+		numPop:      4,
+		numPush:     1,
+		memorySize:  memoryExtCall,
+	}
+	jt[EXTDELEGATECALL] = &operation{
+		execute:     opExtDelegateCall,
+		constantGas: params.WarmStorageReadCostEIP2929,
+		dynamicGas:  gasExtStaticCall,
+		numPop:      3,
+		numPush:     1,
+		memorySize:  memoryExtCall,
+	}
+	jt[EXTSTATICCALL] = &operation{
+		execute:     opExtStaticCall,
+		constantGas: params.WarmStorageReadCostEIP2929,
+		dynamicGas:  gasExtStaticCall,
+		numPop:      3,
+		numPush:     1,
+		memorySize:  memoryExtCall,
+	}
+	jt[RETURNDATALOAD] = &operation{
+		execute:     opReturnDataLoad,
+		constantGas: GasFastestStep,
+		numPop:      1,
+		numPush:     1,
+	}
+}
+
+func opReturnDataLoad(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	offset := scope.Stack.Peek()
+	offset.SetBytes32(getDataBig(interpreter.returnData, offset, 32))
+	return nil, nil
+}
+
+func opExtCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	return extCall(EXTCALL, interpreter, scope)
+}
+
+func opExtDelegateCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	return extCall(EXTDELEGATECALL, interpreter, scope)
+}
+
+func opExtStaticCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	return extCall(EXTSTATICCALL, interpreter, scope)
+}
+
+// extCall pushes the status instead of success flag: 0 - success, 1 - revert or light failure
+// (nothing executed, no gas consumed), 2 - failure. The callee gets all but max(1/64, 5000) of the gas.
+func extCall(typ OpCode, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		evm                    = interpreter.evm
+		stack                  = scope.Stack
+		status, inOffset, size = stack.Pop(), stack.Pop(), stack.Pop() // status reuses the address item
+		toAddr                 = libcommon.Address(status.Bytes20())
+		args                   = scope.Memory.GetPtr(int64(inOffset.Uint64()), int64(size.Uint64()))
+		value                  *uint256.Int
+	)
+	switch typ {
+	case EXTCALL:
+		v := stack.Pop()
+		value = &v
+		if !value.IsZero() && interpreter.readOnly {
+			return nil, ErrWriteProtection
+		}
+	case EXTSTATICCALL:
+		value = new(uint256.Int)
+	}
+	interpreter.returnData = nil
+
+	var callGas uint64
+	if retained := max(scope.Contract.Gas/64, eofMinRetainedGas); scope.Contract.Gas > retained {
+		callGas = scope.Contract.Gas - retained
+	}
+	lightFailure := callGas < eofMinCalleeGas ||
+		interpreter.Depth() > int(params.CallCreateDepth) ||
+		(value != nil && !value.IsZero() && !evm.Context.CanTransfer(evm.IntraBlockState(), scope.Contract.Address(), value))
+	if !lightFailure && typ == EXTDELEGATECALL {
+		// only EOF contracts can be delegated to
+		_, isPrecompile := evm.precompile(toAddr)
+		lightFailure = isPrecompile || !HasEOFMagic(evm.IntraBlockState().GetCode(toAddr))
+	}
+	if lightFailure {
+		stack.Push(status.SetUint64(extCallRevert))
+		return nil, nil
+	}
+
+	scope.Contract.UseGas(callGas, tracing.GasChangeCallOpCode)
+	ret, returnGas, err := evm.call(typ, scope.Contract, toAddr, args, callGas, value, false /* bailout */)
+	switch err {
+	case nil:
+		status.SetUint64(extCallSuccess)
+		interpreter.returnData = ret
+	case ErrExecutionReverted:
+		status.SetUint64(extCallRevert)
+		interpreter.returnData = ret
+	default:
+		status.SetUint64(extCallFailure)
+	}
+	stack.Push(&status)
+	scope.Contract.RefundGas(returnGas, tracing.GasChangeCallLeftOverRefunded)
+	return nil, nil
+}
+
+// makeGasExtCall returns the gas function of EXT*CALL: memory expansion, cold access of the target and, for EXTCALL,
+// value transfer. The callee gas is deducted by extCall. Target address with non-zero upper 12 bytes is an exceptional halt.
+func makeGasExtCall(withValue bool) gasFunc {
+	return func(evm *EVM, contract *Contract, stack *stack.Stack, mem *Memory, memorySize uint64) (uint64, error) {
+		target := stack.Back(0)
+		if target.BitLen() > 160 {
+			return 0, ErrInvalidEOFAddress
+		}
+		gas, err := memoryGasCost(mem, memorySize)
+		if err != nil {
+			return 0, err
+		}
+		addr := libcommon.Address(target.Bytes20())
+		if evm.IntraBlockState().AddAddressToAccessList(addr) {
+			// the warm access cost is charged as constant gas
+			gas += params.ColdAccountAccessCostEIP2929 - params.WarmStorageReadCostEIP2929
+		}
+		if withValue && !stack.Back(3).IsZero() {
+			gas += params.CallValueTransferGas
+			if evm.IntraBlockState().Empty(addr) {
+				gas += params.CallNewAccountGas
+			}
+		}
+		return gas, nil
+	}
+}
+
+var (
+	gasExtCall       = makeGasExtCall(true)
+	gasExtStaticCall = makeGasExtCall(false)
+)
+
+func memoryExtCall(stack *stack.Stack) (uint64, bool) {
+	return calcMemSize64(stack.Back(1), stack.Back(2))
+}
+
+func memoryDataCopy(stack *stack.Stack) (uint64, bool) {
+	return calcMemSize64(stack.Back(0), stack.Back(2))
+}
+
+var gasDataCopy = memoryCopierGas(2)
+
+// enable7620 applies EIP-7620 (EOFCREATE, RETURNCONTRACT)
+func enable7620(jt *JumpTable) {
+	jt[EOFCREATE] = &operation{
+		execute:     opEOFCreate,
+		constantGas: params.CreateGas,
+		dynamicGas:  pureMemoryGascost,
+		numPop:      4,
+		numPush:     1,
+		memorySize:  memoryEOFCreate,
+	}
+	jt[RETURNCONTRACT] = &operation{
+		execute:    opReturnContract,
+		dynamicGas: pureMemoryGascost,
+		numPop:     2,
+		memorySize: memoryReturn,
+	}
+}
+
+func memoryEOFCreate(stack *stack.Stack) (uint64, bool) {
+	return calcMemSize64(stack.Back(2), stack.Back(3))
+}
+
+func opEOFCreate(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if interpreter.readOnly {
+		return nil, ErrWriteProtection
+	}
+	var (
+		initcode                  = scope.eof.subContainers[scope.Contract.Code[*pc+1]]
+		value, salt, offset, size = scope.Stack.Pop(), scope.Stack.Pop(), scope.Stack.Pop(), scope.Stack.Peek()
+		input                     = scope.Memory.GetCopy(int64(offset.Uint64()), int64(size.Uint64()))
+	)
+	*pc++
+	// hashing of the initcode for the address, the memory expansion is charged by the dynamic gas
+	if !scope.Contract.UseGas(ToWordSize(uint64(len(initcode)))*params.Keccak256WordGas, tracing.GasChangeCallContractCreation) {
+		return nil, ErrOutOfGas
+	}
+	gas := scope.Contract.Gas
+	gas -= gas / 64
+	scope.Contract.UseGas(gas, tracing.GasChangeCallContractCreation)
+	// reuse size int for stackvalue
+	stackValue := size
+	res, addr, returnGas, suberr := interpreter.evm.EOFCreate(scope.Contract, initcode, input, gas, &value, &salt)
+	if suberr != nil {
+		stackValue.Clear()
+	} else {
+		stackValue.SetBytes(addr.Bytes())
+	}
+	scope.Contract.RefundGas(returnGas, tracing.GasChangeCallLeftOverRefunded)
+
+	if suberr == ErrExecutionReverted {
+		interpreter.returnData = res // set REVERT data to return data buffer
+		return nil, nil
+	}
+	interpreter.returnData = nil // clear dirty return data buffer
+	return nil, nil
+}
+
+// opReturnContract ends the initcode execution, returns the subcontainer with the data section extended
+// by the aux data from memory to be deployed.
+func opReturnContract(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		sub          Container
+		offset, size = scope.Stack.Pop(), scope.Stack.Pop()
+		aux          = scope.Memory.GetPtr(int64(offset.Uint64()), int64(size.Uint64()))
+	)
+	if _, err := sub.unmarshal(scope.eof.subContainers[scope.Contract.Code[*pc+1]], true); err != nil {
+		return nil, err
+	}
+	deployed, err := sub.withAuxData(aux)
+	if err != nil {
+		return nil, err
+	}
+	return deployed, errStopToken
+}
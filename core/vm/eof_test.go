@@ -0,0 +1,202 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/erigontech/erigon-lib/common/hexutil"
+)
+
+// testContainer builds the container, the types are (inputs, outputs, max_stack_increase) per code section
+func testContainer(types [][3]int, code [][]byte, subContainers [][]byte, data []byte) []byte {
+	c := &Container{codeSections: code, subContainers: subContainers, data: data, dataSize: len(data)}
+	for _, t := range types {
+		c.types = append(c.types, &functionMetadata{inputs: uint8(t[0]), outputs: uint8(t[1]), maxStackIncrease: uint16(t[2])})
+	}
+	return c.MarshalBinary()
+}
+
+var (
+	eofStop         = testContainer([][3]int{{0, 0x80, 0}}, [][]byte{{byte(STOP)}}, nil, nil)
+	eofTruncatedSub = func() []byte {
+		// data size 32 declared, no data present
+		b := testContainer([][3]int{{0, 0x80, 0}}, [][]byte{{byte(STOP)}}, nil, make([]byte, 32))
+		return b[:len(b)-32]
+	}()
+)
+
+func TestEOFMarshalBinary(t *testing.T) {
+	t.Parallel()
+	// magic, version, types (4), code sections (1 of 1), data (0), terminator, types, code
+	want := hexutil.MustDecode("0xef000101000402000100010400000000800000" + "00")
+	if !bytes.Equal(eofStop, want) {
+		t.Fatalf("encoding mismatch: have %x, want %x", eofStop, want)
+	}
+
+	b := testContainer([][3]int{{0, 0x80, 2}, {2, 1, 0}},
+		[][]byte{
+			{byte(PUSH1), 1, byte(PUSH1), 2, byte(CALLF), 0, 1, byte(DATALOADN), 0, 0, byte(POP), byte(POP), byte(STOP)},
+			{byte(ADD), byte(RETF)},
+		},
+		[][]byte{eofStop},
+		bytes.Repeat([]byte{0xaa}, 40),
+	)
+	var c Container
+	if err := c.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if c.NumCodeSections() != 2 || c.NumSubContainers() != 1 || c.DataSize() != 40 || len(c.Data()) != 40 {
+		t.Fatalf("unexpected layout:\n%v", c.String())
+	}
+	if !bytes.Equal(c.CodeSection(1), []byte{byte(ADD), byte(RETF)}) {
+		t.Fatalf("unexpected code section 1: %x", c.CodeSection(1))
+	}
+	if have := c.MarshalBinary(); !bytes.Equal(have, b) {
+		t.Fatalf("round trip mismatch: have %x, want %x", have, b)
+	}
+
+	if err := c.UnmarshalBinary(append(b, 0)); !errors.Is(err, errEOFTrailingBytes) {
+		t.Fatalf("trailing bytes: have %v", err)
+	}
+	if err := c.UnmarshalBinary(b[:len(b)-1]); !errors.Is(err, errEOFTruncatedData) {
+		t.Fatalf("truncated data: have %v", err)
+	}
+	if _, err := c.unmarshal(b[:len(b)-1], true); err != nil {
+		t.Fatalf("truncated data allowed: have %v", err)
+	}
+	if err := c.UnmarshalBinary(b[:len(b)-41]); !errors.Is(err, errEOFIncomplete) {
+		t.Fatalf("truncated body: have %v", err)
+	}
+}
+
+func TestEOFWithAuxData(t *testing.T) {
+	t.Parallel()
+	var c Container
+	if _, err := c.unmarshal(eofTruncatedSub, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.withAuxData(make([]byte, 31)); !errors.Is(err, errEOFTruncatedData) {
+		t.Fatalf("short aux data: have %v", err)
+	}
+	deployed, err := c.withAuxData(bytes.Repeat([]byte{1}, 40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAndValidateEOF(deployed, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UnmarshalBinary(deployed); err != nil || c.DataSize() != 40 {
+		t.Fatalf("deployed container: data size %d, err %v", c.DataSize(), err)
+	}
+}
+
+func TestEOFValidation(t *testing.T) {
+	t.Parallel()
+	stop := [][]byte{{byte(STOP)}}
+	returnContract := []byte{byte(PUSH0), byte(PUSH0), byte(RETURNCONTRACT), 0}
+	tests := []struct {
+		name       string
+		code       []byte
+		isInitcode bool
+		err        error
+	}{
+		{"stop", eofStop, false, nil},
+		{"stop in initcode", eofStop, true, errEOFIncompatibleKind},
+		{"invalid magic", []byte{0xef, 0x01, 0x01}, false, errEOFInvalidMagic},
+		{"invalid version", []byte{0xef, 0x00, 0x02}, false, errEOFInvalidVersion},
+		{"returning first section", testContainer([][3]int{{0, 0, 0}}, [][]byte{{byte(RETF)}}, nil, nil), false, errEOFInvalidFirstSection},
+		{"rjumpi", testContainer([][3]int{{0, 0x80, 1}}, [][]byte{{byte(PUSH0), byte(RJUMPI), 0, 0, byte(STOP)}}, nil, nil), false, nil},
+		{"rjumpv", testContainer([][3]int{{0, 0x80, 1}}, [][]byte{{byte(PUSH0), byte(RJUMPV), 1, 0, 0, 0, 1, byte(INVALID), byte(STOP)}}, nil, nil), false, nil},
+		{"rjump loop", testContainer([][3]int{{0, 0x80, 0}}, [][]byte{{byte(RJUMP), 0xff, 0xfd}}, nil, nil), false, nil},
+		{"rjump into immediate", testContainer([][3]int{{0, 0x80, 1}}, [][]byte{{byte(RJUMP), 0, 1, byte(PUSH1), 0, byte(STOP)}}, nil, nil), false, errEOFInvalidJumpDest},
+		{"rjump out of code", testContainer([][3]int{{0, 0x80, 0}}, [][]byte{{byte(RJUMP), 0, 1, byte(STOP)}}, nil, nil), false, errEOFInvalidJumpDest},
+		{"legacy jump", testContainer([][3]int{{0, 0x80, 1}}, [][]byte{{byte(PUSH0), byte(JUMP)}}, nil, nil), false, errEOFUndefinedInstruction},
+		{"truncated push", testContainer([][3]int{{0, 0x80, 1}}, [][]byte{{byte(PUSH2), 0}}, nil, nil), false, errEOFTruncatedImmediate},
+		{"no termination", testContainer([][3]int{{0, 0x80, 1}}, [][]byte{{byte(PUSH0)}}, nil, nil), false, errEOFNoTermination},
+		{"unreachable code", testContainer([][3]int{{0, 0x80, 0}}, [][]byte{{byte(STOP), byte(STOP)}}, nil, nil), false, errEOFUnreachableCode},
+		{"stack underflow", testContainer([][3]int{{0, 0x80, 0}}, [][]byte{{byte(POP), byte(STOP)}}, nil, nil), false, errEOFStackUnderflow},
+		{"wrong max stack", testContainer([][3]int{{0, 0x80, 1}}, stop, nil, nil), false, errEOFInvalidMaxStack},
+		{"backward jump height", testContainer([][3]int{{0, 0x80, 1}}, [][]byte{{byte(PUSH0), byte(RJUMP), 0xff, 0xfc}}, nil, nil), false, errEOFStackHeightMismatch},
+		{
+			"callf",
+			testContainer([][3]int{{0, 0x80, 2}, {2, 1, 0}},
+				[][]byte{{byte(PUSH1), 1, byte(PUSH1), 2, byte(CALLF), 0, 1, byte(POP), byte(STOP)}, {byte(ADD), byte(RETF)}}, nil, nil),
+			false, nil,
+		},
+		{
+			"callf to non-returning",
+			testContainer([][3]int{{0, 0x80, 0}, {0, 0x80, 0}}, [][]byte{{byte(CALLF), 0, 1, byte(STOP)}, {byte(STOP)}}, nil, nil),
+			false, errEOFCallfToNonReturning,
+		},
+		{
+			"retf height",
+			testContainer([][3]int{{0, 0x80, 1}, {0, 1, 0}}, [][]byte{{byte(CALLF), 0, 1, byte(STOP)}, {byte(RETF)}}, nil, nil),
+			false, errEOFStackHeightMismatch,
+		},
+		{
+			"jumpf",
+			testContainer([][3]int{{0, 0x80, 0}, {0, 0x80, 0}}, [][]byte{{byte(JUMPF), 0, 1}, {byte(STOP)}}, nil, nil),
+			false, nil,
+		},
+		{
+			"unreachable section",
+			testContainer([][3]int{{0, 0x80, 0}, {0, 0x80, 0}}, [][]byte{{byte(STOP)}, {byte(STOP)}}, nil, nil),
+			false, errEOFUnreachableSections,
+		},
+		{
+			"dupn swapn exchange",
+			testContainer([][3]int{{0, 0x80, 4}}, [][]byte{{byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(DUPN), 2, byte(SWAPN), 2, byte(EXCHANGE), 0x00, byte(STOP)}}, nil, nil),
+			false, nil,
+		},
+		{"dupn underflow", testContainer([][3]int{{0, 0x80, 2}}, [][]byte{{byte(PUSH0), byte(DUPN), 1, byte(STOP)}}, nil, nil), false, errEOFStackUnderflow},
+		{"dataloadn", testContainer([][3]int{{0, 0x80, 1}}, [][]byte{{byte(DATALOADN), 0, 8, byte(STOP)}}, nil, make([]byte, 40)), false, nil},
+		{"dataloadn out of data", testContainer([][3]int{{0, 0x80, 1}}, [][]byte{{byte(DATALOADN), 0, 9, byte(STOP)}}, nil, make([]byte, 40)), false, errEOFInvalidDataloadn},
+		{"returncontract", testContainer([][3]int{{0, 0x80, 2}}, [][]byte{returnContract}, [][]byte{eofStop}, nil), true, nil},
+		{"returncontract truncated data", testContainer([][3]int{{0, 0x80, 2}}, [][]byte{returnContract}, [][]byte{eofTruncatedSub}, nil), true, nil},
+		{"returncontract in runtime", testContainer([][3]int{{0, 0x80, 2}}, [][]byte{returnContract}, [][]byte{eofStop}, nil), false, errEOFIncompatibleKind},
+		{
+			"eofcreate truncated data",
+			testContainer([][3]int{{0, 0x80, 4}}, [][]byte{{byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(EOFCREATE), 0, byte(POP), byte(STOP)}},
+				[][]byte{eofTruncatedSub}, nil),
+			false, errEOFTruncatedData,
+		},
+		{
+			"eofcreate of runtime container",
+			testContainer([][3]int{{0, 0x80, 4}}, [][]byte{{byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(EOFCREATE), 0, byte(POP), byte(STOP)}},
+				[][]byte{eofStop}, nil),
+			false, errEOFIncompatibleKind,
+		},
+		{"unreferenced container", testContainer([][3]int{{0, 0x80, 0}}, stop, [][]byte{eofStop}, nil), false, errEOFUnreferencedContainer},
+		{"invalid container index", testContainer([][3]int{{0, 0x80, 2}}, [][]byte{{byte(PUSH0), byte(PUSH0), byte(RETURNCONTRACT), 1}}, [][]byte{eofStop}, nil), true, errEOFInvalidContainerIndex},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseAndValidateEOF(tt.code, tt.isInitcode)
+			if tt.err == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, have %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/params"
+)
+
+var (
+	errEOFUndefinedInstruction   = errors.New("undefined instruction")
+	errEOFTruncatedImmediate     = errors.New("truncated immediate")
+	errEOFInvalidJumpDest        = errors.New("invalid relative jump destination")
+	errEOFInvalidSectionArgument = errors.New("invalid code section index")
+	errEOFCallfToNonReturning    = errors.New("CALLF to non-returning section")
+	errEOFJumpfOutputs           = errors.New("JUMPF to section with more outputs")
+	errEOFInvalidNonReturning    = errors.New("non-returning flag doesn't match the code")
+	errEOFInvalidDataloadn       = errors.New("DATALOADN out of data section")
+	errEOFInvalidContainerIndex  = errors.New("invalid container section index")
+	errEOFIncompatibleKind       = errors.New("instruction incompatible with container kind")
+	errEOFUnreachableCode        = errors.New("unreachable code")
+	errEOFNoTermination          = errors.New("code section doesn't end with terminating instruction")
+	errEOFStackUnderflow         = errors.New("stack underflow")
+	errEOFStackOverflow          = errors.New("stack overflow")
+	errEOFStackHeightMismatch    = errors.New("stack height mismatch")
+	errEOFInvalidMaxStack        = errors.New("max stack increase doesn't match the code")
+	errEOFUnreachableSections    = errors.New("unreachable code sections")
+	errEOFUnreferencedContainer  = errors.New("unreferenced container section")
+	errEOFAmbiguousContainer     = errors.New("container section referenced by both EOFCREATE and RETURNCONTRACT")
+)
+
+// ParseAndValidateEOF decodes the container and validates it and its subcontainers.
+// Initcode containers (creation txn, EOFCREATE) may use RETURNCONTRACT and may not use RETURN and STOP.
+func ParseAndValidateEOF(code []byte, isInitcode bool) (*Container, error) {
+	var c Container
+	if err := c.UnmarshalBinary(code); err != nil {
+		return nil, err
+	}
+	if err := c.ValidateCode(isInitcode); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// validatedContainersCacheSize is the number of validated codes kept in validatedContainers
+const validatedContainersCacheSize = 4096
+
+type validatedContainerKey struct {
+	codeHash   libcommon.Hash
+	isInitcode bool
+}
+
+// validatedContainers caches the decoded containers of valid EOF codes and nil for invalid ones
+var validatedContainers, _ = lru.New[validatedContainerKey, *Container](validatedContainersCacheSize)
+
+// validatedContainer returns the decoded container of the contract code, nil if it isn't valid EOF.
+// Code is validated on deployment, but unvalidated code with the EOF magic can still be run: genesis
+// allocations, test prestates and code deployed before London. The result is cached per code hash,
+// as for jumpdest analysis initcode without the code hash is validated for each run.
+func validatedContainer(contract *Contract) *Container {
+	if contract.CodeHash == (libcommon.Hash{}) {
+		c, err := ParseAndValidateEOF(contract.Code, contract.IsEOFInitcode)
+		if err != nil {
+			return nil
+		}
+		return c
+	}
+	key := validatedContainerKey{codeHash: contract.CodeHash, isInitcode: contract.IsEOFInitcode}
+	if c, ok := validatedContainers.Get(key); ok {
+		return c
+	}
+	c, err := ParseAndValidateEOF(contract.Code, contract.IsEOFInitcode)
+	if err != nil {
+		c = nil
+	}
+	validatedContainers.Add(key, c)
+	return c
+}
+
+// ValidateCode validates the code sections of the container and, recursively, of its subcontainers.
+func (c *Container) ValidateCode(isInitcode bool) error {
+	jt := &eofInstructionSet
+	// sections reachable from the first one by CALLF and JUMPF
+	reachable := make([]bool, len(c.codeSections))
+	reachable[0] = true
+	queue := []int{0}
+	// EOFCREATE and RETURNCONTRACT references to subcontainers
+	eofCreateRefs := make([]bool, len(c.subContainers))
+	returnContractRefs := make([]bool, len(c.subContainers))
+
+	for len(queue) > 0 {
+		section := queue[0]
+		queue = queue[1:]
+		refs, err := c.validateSection(section, jt, isInitcode)
+		if err != nil {
+			return fmt.Errorf("code section %d: %w", section, err)
+		}
+		for _, s := range refs.sections {
+			if !reachable[s] {
+				reachable[s] = true
+				queue = append(queue, s)
+			}
+		}
+		for _, i := range refs.eofCreate {
+			eofCreateRefs[i] = true
+		}
+		for _, i := range refs.returnContract {
+			returnContractRefs[i] = true
+		}
+	}
+	for i := range reachable {
+		if !reachable[i] {
+			return fmt.Errorf("%w: section %d", errEOFUnreachableSections, i)
+		}
+	}
+
+	for i, sub := range c.subContainers {
+		if eofCreateRefs[i] && returnContractRefs[i] {
+			return fmt.Errorf("%w: container %d", errEOFAmbiguousContainer, i)
+		}
+		if !eofCreateRefs[i] && !returnContractRefs[i] {
+			return fmt.Errorf("%w: container %d", errEOFUnreferencedContainer, i)
+		}
+		var subContainer Container
+		// only the containers to be deployed may have the data section truncated, RETURNCONTRACT appends aux data
+		size, err := subContainer.unmarshal(sub, returnContractRefs[i])
+		if err == nil && size != len(sub) {
+			err = errEOFTrailingBytes
+		}
+		if err == nil {
+			err = subContainer.ValidateCode(eofCreateRefs[i])
+		}
+		if err != nil {
+			return fmt.Errorf("container %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// sectionRefs are the sections and containers referenced by a code section
+type sectionRefs struct {
+	sections       []int
+	eofCreate      []int
+	returnContract []int
+}
+
+// immediateSize returns the size of the immediate arguments of the instruction at pos, the code may be truncated
+func immediateSize(code []byte, pos int) int {
+	op := OpCode(code[pos])
+	switch {
+	case op >= PUSH1 && op <= PUSH32:
+		return int(op-PUSH1) + 1
+	}
+	switch op {
+	case RJUMP, RJUMPI, CALLF, JUMPF, DATALOADN:
+		return 2
+	case RJUMPV:
+		if pos+1 >= len(code) {
+			return 1
+		}
+		return 1 + (int(code[pos+1])+1)*2
+	case DUPN, SWAPN, EXCHANGE, EOFCREATE, RETURNCONTRACT:
+		return 1
+	}
+	return 0
+}
+
+// isTerminating returns true for the instructions which never pass control to the next instruction
+func isTerminating(op OpCode) bool {
+	switch op {
+	case STOP, RETURN, REVERT, INVALID, RETF, JUMPF, RETURNCONTRACT:
+		return true
+	}
+	return false
+}
+
+// relativeJumpTargets returns the destinations of RJUMP, RJUMPI, RJUMPV at pos
+func relativeJumpTargets(code []byte, pos int) []int {
+	switch OpCode(code[pos]) {
+	case RJUMP, RJUMPI:
+		return []int{pos + 3 + int(int16(binary.BigEndian.Uint16(code[pos+1:])))}
+	case RJUMPV:
+		count := int(code[pos+1]) + 1
+		end := pos + 2 + count*2
+		targets := make([]int, count)
+		for i := range targets {
+			targets[i] = end + int(int16(binary.BigEndian.Uint16(code[pos+2+i*2:])))
+		}
+		return targets
+	}
+	return nil
+}
+
+// validateSection validates instructions and immediates of the code section (EIP-3670, EIP-4200, EIP-4750,
+// EIP-6206, EIP-7480, EIP-7620) and its stack (EIP-5450).
+func (c *Container) validateSection(section int, jt *JumpTable, isInitcode bool) (*sectionRefs, error) {
+	var (
+		code      = c.codeSections[section]
+		typ       = c.types[section]
+		refs      = &sectionRefs{}
+		starts    = make([]bool, len(code)) // instruction boundaries
+		targets   []int
+		returning bool
+	)
+	for pos := 0; pos < len(code); {
+		op := OpCode(code[pos])
+		starts[pos] = true
+		if jt[op].undefined && op != INVALID {
+			return nil, fmt.Errorf("%w: %v at pos %d", errEOFUndefinedInstruction, op, pos)
+		}
+		size := immediateSize(code, pos)
+		if pos+1+size > len(code) {
+			return nil, fmt.Errorf("%w: %v at pos %d", errEOFTruncatedImmediate, op, pos)
+		}
+		switch op {
+		case RJUMP, RJUMPI, RJUMPV:
+			targets = append(targets, relativeJumpTargets(code, pos)...)
+		case CALLF, JUMPF:
+			idx := int(binary.BigEndian.Uint16(code[pos+1:]))
+			if idx >= len(c.codeSections) {
+				return nil, fmt.Errorf("%w: %v %d at pos %d", errEOFInvalidSectionArgument, op, idx, pos)
+			}
+			target := c.types[idx]
+			if op == CALLF && !target.returning() {
+				return nil, fmt.Errorf("%w: %d at pos %d", errEOFCallfToNonReturning, idx, pos)
+			}
+			if op == JUMPF && target.returning() {
+				returning = true
+				if typ.returning() && target.outputs > typ.outputs {
+					return nil, fmt.Errorf("%w: %d at pos %d", errEOFJumpfOutputs, idx, pos)
+				}
+			}
+			refs.sections = append(refs.sections, idx)
+		case RETF:
+			returning = true
+		case DATALOADN:
+			offset := int(binary.BigEndian.Uint16(code[pos+1:]))
+			if offset+32 > c.dataSize {
+				return nil, fmt.Errorf("%w: offset %d, data size %d", errEOFInvalidDataloadn, offset, c.dataSize)
+			}
+		case EOFCREATE, RETURNCONTRACT:
+			idx := int(code[pos+1])
+			if idx >= len(c.subContainers) {
+				return nil, fmt.Errorf("%w: %v %d at pos %d", errEOFInvalidContainerIndex, op, idx, pos)
+			}
+			if op == EOFCREATE {
+				refs.eofCreate = append(refs.eofCreate, idx)
+			} else {
+				if !isInitcode {
+					return nil, fmt.Errorf("%w: %v in runtime container", errEOFIncompatibleKind, op)
+				}
+				refs.returnContract = append(refs.returnContract, idx)
+			}
+		case RETURN, STOP:
+			if isInitcode {
+				return nil, fmt.Errorf("%w: %v in initcode container", errEOFIncompatibleKind, op)
+			}
+		}
+		pos += 1 + size
+	}
+	for _, target := range targets {
+		if target < 0 || target >= len(code) || !starts[target] {
+			return nil, fmt.Errorf("%w: %d", errEOFInvalidJumpDest, target)
+		}
+	}
+	if returning != typ.returning() {
+		return nil, fmt.Errorf("%w: outputs %d", errEOFInvalidNonReturning, typ.outputs)
+	}
+	if err := c.validateStack(section, jt); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// stackBounds is the range of the possible stack heights before an instruction
+type stackBounds struct {
+	min, max int
+}
+
+// validateStack checks that the stack can't underflow or overflow and that the heights at the instructions
+// reached by backward jumps don't depend on the path (EIP-5450). Instructions are visited in order,
+// every instruction must be reached by a forward jump or from the previous instruction.
+func (c *Container) validateStack(section int, jt *JumpTable) error {
+	var (
+		code      = c.codeSections[section]
+		typ       = c.types[section]
+		bounds    = make([]stackBounds, len(code))
+		visited   = make([]bool, len(code))
+		maxHeight = int(typ.inputs)
+	)
+	bounds[0] = stackBounds{int(typ.inputs), int(typ.inputs)}
+	visited[0] = true
+
+	for pos := 0; pos < len(code); {
+		op := OpCode(code[pos])
+		if !visited[pos] {
+			return fmt.Errorf("%w: %v at pos %d", errEOFUnreachableCode, op, pos)
+		}
+		cur := bounds[pos]
+		maxHeight = max(maxHeight, cur.max)
+		pops, pushes := jt[op].numPop, jt[op].numPush
+		switch op {
+		case CALLF, JUMPF:
+			target := c.types[binary.BigEndian.Uint16(code[pos+1:])]
+			if cur.max+int(target.maxStackIncrease) > int(params.StackLimit) {
+				return fmt.Errorf("%w: %v at pos %d", errEOFStackOverflow, op, pos)
+			}
+			pops, pushes = int(target.inputs), int(target.outputs)
+			if op == JUMPF && target.returning() {
+				// the outputs of the target are returned to the caller of the section
+				if want := int(typ.outputs) + int(target.inputs) - int(target.outputs); cur.min != want || cur.max != want {
+					return fmt.Errorf("%w: JUMPF at pos %d with stack [%d, %d], want %d", errEOFStackHeightMismatch, pos, cur.min, cur.max, want)
+				}
+			}
+		case RETF:
+			if cur.min != int(typ.outputs) || cur.max != int(typ.outputs) {
+				return fmt.Errorf("%w: RETF at pos %d with stack [%d, %d], want %d", errEOFStackHeightMismatch, pos, cur.min, cur.max, typ.outputs)
+			}
+		case DUPN:
+			pops, pushes = int(code[pos+1])+1, int(code[pos+1])+2
+		case SWAPN:
+			pops, pushes = int(code[pos+1])+2, int(code[pos+1])+2
+		case EXCHANGE:
+			n, m := int(code[pos+1]>>4)+1, int(code[pos+1]&0x0f)+1
+			pops, pushes = n+m+1, n+m+1
+		}
+		if cur.min < pops {
+			return fmt.Errorf("%w: %v at pos %d with stack %d, requires %d", errEOFStackUnderflow, op, pos, cur.min, pops)
+		}
+		next := stackBounds{cur.min - pops + pushes, cur.max - pops + pushes}
+
+		nextPos := pos + 1 + immediateSize(code, pos)
+		var successors []int
+		if !isTerminating(op) && op != RJUMP {
+			if nextPos >= len(code) {
+				return fmt.Errorf("%w: %v at pos %d", errEOFNoTermination, op, pos)
+			}
+			successors = append(successors, nextPos)
+		}
+		successors = append(successors, relativeJumpTargets(code, pos)...)
+		for _, s := range successors {
+			switch {
+			case s <= pos: // backward jump, the height must be known and the same
+				if bounds[s] != next {
+					return fmt.Errorf("%w: backward jump from pos %d to %d with stack [%d, %d], was [%d, %d]",
+						errEOFStackHeightMismatch, pos, s, next.min, next.max, bounds[s].min, bounds[s].max)
+				}
+			case !visited[s]:
+				bounds[s], visited[s] = next, true
+			default:
+				bounds[s] = stackBounds{min(bounds[s].min, next.min), max(bounds[s].max, next.max)}
+			}
+		}
+		pos = nextPos
+	}
+	if maxHeight > int(params.StackLimit) {
+		return fmt.Errorf("%w: max height %d", errEOFStackOverflow, maxHeight)
+	}
+	if maxHeight-int(typ.inputs) != int(typ.maxStackIncrease) {
+		return fmt.Errorf("%w: computed %d, declared %d", errEOFInvalidMaxStack, maxHeight-int(typ.inputs), typ.maxStackIncrease)
+	}
+	return nil
+}
@@ -44,6 +44,8 @@ var (
 	ErrReturnStackExceeded      = errors.New("return stack limit reached")
 	ErrInvalidCode              = errors.New("invalid code")
 	ErrNonceUintOverflow        = errors.New("nonce uint64 overflow")
+	ErrInvalidEOFInitcode       = errors.New("invalid eof initcode")
+	ErrInvalidEOFAddress        = errors.New("invalid eof call target address")
 
 	// errStopToken is an internal token indicating interpreter loop termination,
 	// never returned to outside callers.
@@ -20,6 +20,7 @@
 package vm
 
 import (
+	"fmt"
 	"sync/atomic"
 
 	"github.com/holiman/uint256"
@@ -182,7 +183,7 @@ func (evm *EVM) call(typ OpCode, caller ContractRef, addr libcommon.Address, inp
 	if depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
 	}
-	if typ == CALL || typ == CALLCODE {
+	if typ == CALL || typ == CALLCODE || typ == EXTCALL {
 		// Fail if we're trying to transfer more than the available balance
 		if !value.IsZero() && !evm.Context.CanTransfer(evm.intraBlockState, caller.Address(), value) {
 			if !bailout {
@@ -198,7 +199,7 @@ func (evm *EVM) call(typ OpCode, caller ContractRef, addr libcommon.Address, inp
 
 	snapshot := evm.intraBlockState.Snapshot()
 
-	if typ == CALL {
+	if typ == CALL || typ == EXTCALL {
 		if !evm.intraBlockState.Exist(addr) {
 			if !isPrecompile && evm.chainRules.IsSpuriousDragon && value.IsZero() {
 				if evm.config.Debug {
@@ -220,7 +221,7 @@ func (evm *EVM) call(typ OpCode, caller ContractRef, addr libcommon.Address, inp
 			evm.intraBlockState.CreateAccount(addr, false)
 		}
 		evm.Context.Transfer(evm.intraBlockState, caller.Address(), addr, value, bailout)
-	} else if typ == STATICCALL {
+	} else if typ == STATICCALL || typ == EXTSTATICCALL {
 		// We do an AddBalance of zero here, just in order to trigger a touch.
 		// This doesn't matter on Mainnet, where all empties are gone at the time of Byzantium,
 		// but is the correct thing to do and matters on other networks, in tests, and potential
@@ -229,9 +230,9 @@ func (evm *EVM) call(typ OpCode, caller ContractRef, addr libcommon.Address, inp
 	}
 	if evm.config.Debug {
 		v := value
-		if typ == STATICCALL {
+		if typ == STATICCALL || typ == EXTSTATICCALL {
 			v = nil
-		} else if typ == DELEGATECALL {
+		} else if typ == DELEGATECALL || typ == EXTDELEGATECALL {
 			// NOTE: caller must, at all times be a contract. It should never happen
 			// that caller is something other than a Contract.
 			parent := caller.(*Contract)
@@ -269,14 +270,14 @@ func (evm *EVM) call(typ OpCode, caller ContractRef, addr libcommon.Address, inp
 		var contract *Contract
 		if typ == CALLCODE {
 			contract = NewContract(caller, caller.Address(), value, gas, evm.config.SkipAnalysis)
-		} else if typ == DELEGATECALL {
+		} else if typ == DELEGATECALL || typ == EXTDELEGATECALL {
 			contract = NewContract(caller, caller.Address(), value, gas, evm.config.SkipAnalysis).AsDelegate()
 		} else {
 			contract = NewContract(caller, addrCopy, value, gas, evm.config.SkipAnalysis)
 		}
 		contract.SetCallCode(&addrCopy, codeHash, code)
 		readOnly := false
-		if typ == STATICCALL {
+		if typ == STATICCALL || typ == EXTSTATICCALL {
 			readOnly = true
 		}
 		ret, err = run(evm, contract, input, readOnly)
@@ -350,11 +351,11 @@ func (c *codeAndHash) Hash() libcommon.Hash {
 }
 
 func (evm *EVM) OverlayCreate(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *uint256.Int, address libcommon.Address, typ OpCode, incrementNonce bool) ([]byte, libcommon.Address, uint64, error) {
-	return evm.create(caller, codeAndHash, gas, value, address, typ, incrementNonce, false)
+	return evm.create(caller, codeAndHash, nil, gas, value, address, typ, incrementNonce, false)
 }
 
-// create creates a new contract using code as deployment code.
-func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gasRemaining uint64, value *uint256.Int, address libcommon.Address, typ OpCode, incrementNonce bool, bailout bool) ([]byte, libcommon.Address, uint64, error) {
+// create creates a new contract using code as deployment code. The input is passed to EOF initcode only.
+func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, input []byte, gasRemaining uint64, value *uint256.Int, address libcommon.Address, typ OpCode, incrementNonce bool, bailout bool) ([]byte, libcommon.Address, uint64, error) {
 	var ret []byte
 	var err error
 	var gasConsumption uint64
@@ -394,6 +395,22 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gasRemainin
 		}
 		evm.intraBlockState.SetNonce(caller.Address(), nonce+1)
 	}
+	// EIP-7698: data of a creation txn starting with the EOF magic is an initcode container followed by the calldata
+	isEOFInitcode := typ == EOFCREATE
+	if typ == CREATE && depth == 0 && evm.chainRules.IsOsaka && HasEOFMagic(codeAndHash.code) {
+		var container Container
+		size, err := container.unmarshal(codeAndHash.code, false)
+		if err == nil {
+			err = container.ValidateCode(true)
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: %w", ErrInvalidEOFInitcode, err)
+			return nil, libcommon.Address{}, 0, err
+		}
+		input = codeAndHash.code[size:]
+		codeAndHash = NewCodeAndHash(codeAndHash.code[:size])
+		isEOFInitcode = true
+	}
 	// We add this to the access list _before_ taking a snapshot. Even if the creation fails,
 	// the access-list change should not be rolled back
 	if evm.chainRules.IsBerlin {
@@ -417,12 +434,13 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gasRemainin
 	// The contract is a scoped environment for this execution context only.
 	contract := NewContract(caller, address, value, gasRemaining, evm.config.SkipAnalysis)
 	contract.SetCodeOptionalHash(&address, codeAndHash)
+	contract.IsEOFInitcode = isEOFInitcode
 
 	if evm.config.NoRecursion && depth > 0 {
 		return nil, address, gasRemaining, nil
 	}
 
-	ret, err = run(evm, contract, nil, false)
+	ret, err = run(evm, contract, input, false)
 
 	// EIP-170: Contract code size limit
 	if err == nil && evm.chainRules.IsSpuriousDragon && len(ret) > params.MaxCodeSize {
@@ -433,8 +451,8 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gasRemainin
 		}
 	}
 
-	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if err == nil && evm.chainRules.IsLondon && len(ret) >= 1 && ret[0] == 0xEF {
+	// Reject code starting with 0xEF if EIP-3541 is enabled, EOF initcode returns validated EOF code.
+	if err == nil && evm.chainRules.IsLondon && len(ret) >= 1 && ret[0] == 0xEF && !isEOFInitcode {
 		err = ErrInvalidCode
 	}
 	// if the contract creation ran successfully and no errors were returned
@@ -470,7 +488,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gasRemainin
 // DESCRIBED: docs/programmers_guide/guide.md#nonce
 func (evm *EVM) Create(caller ContractRef, code []byte, gasRemaining uint64, endowment *uint256.Int, bailout bool) (ret []byte, contractAddr libcommon.Address, leftOverGas uint64, err error) {
 	contractAddr = crypto.CreateAddress(caller.Address(), evm.intraBlockState.GetNonce(caller.Address()))
-	return evm.create(caller, &codeAndHash{code: code}, gasRemaining, endowment, contractAddr, CREATE, true /* incrementNonce */, bailout)
+	return evm.create(caller, &codeAndHash{code: code}, nil, gasRemaining, endowment, contractAddr, CREATE, true /* incrementNonce */, bailout)
 }
 
 // Create2 creates a new contract using code as deployment code.
@@ -481,13 +499,22 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gasRemaining uint64, end
 func (evm *EVM) Create2(caller ContractRef, code []byte, gasRemaining uint64, endowment *uint256.Int, salt *uint256.Int, bailout bool) (ret []byte, contractAddr libcommon.Address, leftOverGas uint64, err error) {
 	codeAndHash := &codeAndHash{code: code}
 	contractAddr = crypto.CreateAddress2(caller.Address(), salt.Bytes32(), codeAndHash.Hash().Bytes())
-	return evm.create(caller, codeAndHash, gasRemaining, endowment, contractAddr, CREATE2, true /* incrementNonce */, bailout)
+	return evm.create(caller, codeAndHash, nil, gasRemaining, endowment, contractAddr, CREATE2, true /* incrementNonce */, bailout)
+}
+
+// EOFCreate creates a new contract by the EOF initcode container (EIP-7620), the calldata of the initcode is the input.
+//
+// The address is derived as in Create2: keccak256(0xff ++ msg.sender ++ salt ++ keccak256(initcontainer))[12:]
+func (evm *EVM) EOFCreate(caller ContractRef, initcode []byte, input []byte, gasRemaining uint64, endowment *uint256.Int, salt *uint256.Int) (ret []byte, contractAddr libcommon.Address, leftOverGas uint64, err error) {
+	codeAndHash := &codeAndHash{code: initcode}
+	contractAddr = crypto.CreateAddress2(caller.Address(), salt.Bytes32(), codeAndHash.Hash().Bytes())
+	return evm.create(caller, codeAndHash, input, gasRemaining, endowment, contractAddr, EOFCREATE, true /* incrementNonce */, false)
 }
 
 // SysCreate is a special (system) contract creation methods for genesis constructors.
 // Unlike the normal Create & Create2, it doesn't increment caller's nonce.
 func (evm *EVM) SysCreate(caller ContractRef, code []byte, gas uint64, endowment *uint256.Int, contractAddr libcommon.Address) (ret []byte, leftOverGas uint64, err error) {
-	ret, _, leftOverGas, err = evm.create(caller, &codeAndHash{code: code}, gas, endowment, contractAddr, CREATE, false /* incrementNonce */, false)
+	ret, _, leftOverGas, err = evm.create(caller, &codeAndHash{code: code}, nil, gas, endowment, contractAddr, CREATE, false /* incrementNonce */, false)
 	return
 }
 
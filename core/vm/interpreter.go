@@ -20,6 +20,7 @@
 package vm
 
 import (
+	"fmt"
 	"hash"
 	"sync"
 
@@ -27,6 +28,7 @@ import (
 
 	"github.com/erigontech/erigon-lib/chain"
 	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/dbg"
 	"github.com/erigontech/erigon-lib/common/math"
 
 	"github.com/erigontech/erigon/core/tracing"
@@ -46,6 +48,9 @@ type Config struct {
 	StatelessExec bool      // true is certain conditions (like state trie root hash matching) need to be relaxed for stateless EVM execution
 	RestoreState  bool      // Revert all changes made to the state (useful for constant system calls)
 
+	Superinstructions bool // Run legacy code on pre-analysed basic blocks with fused instructions, ignored when debugging
+	NoCoinbaseRead    bool // Don't read the coinbase balance before the transaction (unless PostApplyMessage needs it): fees to an untouched coinbase go to the balance increases of IntraBlockState
+
 	ExtraEips []int // Additional EIPS that are to be enabled
 }
 
@@ -83,6 +88,10 @@ type ScopeContext struct {
 	Memory   *Memory
 	Stack    *stack.Stack
 	Contract *Contract
+
+	eof         *Container    // nil for legacy code
+	codeSection uint64        // executing EOF code section, Contract.Code is its body
+	returnStack []returnFrame // EOF CALLF frames
 }
 
 // keccakState wraps sha3.state. In addition to the usual hash methods, it also supports
@@ -96,7 +105,9 @@ type keccakState interface {
 // EVMInterpreter represents an EVM interpreter
 type EVMInterpreter struct {
 	*VM
-	jt    *JumpTable // EVM instruction table
+	jt    *JumpTable        // EVM instruction table
+	jtKey instructionSetKey // identity of jt for the code analysed by the superinstructions mode
+	eofJt *JumpTable        // instruction table of EOF code, nil before osaka
 	depth int
 }
 
@@ -127,8 +138,10 @@ func copyJumpTable(jt *JumpTable) *JumpTable {
 
 // NewEVMInterpreter returns a new instance of the Interpreter.
 func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
-	var jt *JumpTable
+	var jt, eofJt *JumpTable
 	switch {
+	case evm.ChainRules().IsOsaka:
+		jt, eofJt = &osakaInstructionSet, &eofInstructionSet
 	case evm.ChainRules().IsPrague:
 		jt = &pragueInstructionSet
 	case evm.ChainRules().IsCancun:
@@ -156,6 +169,7 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 	default:
 		jt = &frontierInstructionSet
 	}
+	jtKey := instructionSetKey{base: jt}
 	if len(cfg.ExtraEips) > 0 {
 		jt = copyJumpTable(jt)
 		for i, eip := range cfg.ExtraEips {
@@ -165,6 +179,11 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 				log.Error("EIP activation failed", "eip", eip, "err", err)
 			}
 		}
+		jtKey.extraEips = fmt.Sprint(cfg.ExtraEips)
+	}
+
+	if dbg.VMSuperinstructions {
+		cfg.Superinstructions = true
 	}
 
 	return &EVMInterpreter{
@@ -172,7 +191,9 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 			evm: evm,
 			cfg: cfg,
 		},
-		jt: jt,
+		jt:    jt,
+		jtKey: jtKey,
+		eofJt: eofJt,
 	}
 }
 
@@ -196,6 +217,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 
 	var (
 		op          OpCode // current opcode
+		jt          = in.jt
 		mem         = pool.Get().(*Memory)
 		locStack    = stack.New()
 		callContext = &ScopeContext{
@@ -220,6 +242,20 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 
 	contract.Input = input
 
+	// Code which isn't valid EOF runs as legacy code, where 0xEF is INVALID
+	if in.eofJt != nil && HasEOFMagic(contract.Code) {
+		if container := validatedContainer(contract); container != nil {
+			callContext.eof = container
+			callContext.setCodeSection(0)
+			jt = in.eofJt
+		}
+	}
+
+	var blocks *codeBlocks
+	if in.cfg.Superinstructions && !in.cfg.Debug && callContext.eof == nil {
+		blocks = analysedCode(contract, jt, in.jtKey)
+	}
+
 	// Make sure the readOnly is only set if we aren't in readOnly yet.
 	// This makes also sure that the readOnly flag isn't removed for child calls.
 	restoreReadonly := readOnly && !in.readOnly
@@ -260,10 +296,19 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 			// Capture pre-execution values for tracing.
 			logged, pcCopy, gasCopy = false, _pc, contract.Gas
 		}
+		// Run the whole basic block starting here when it fits, instruction by instruction otherwise
+		if blocks != nil && _pc < uint64(len(blocks.blockAt)) && blocks.blockAt[_pc] >= 0 {
+			if b := &blocks.blocks[blocks.blockAt[_pc]]; b.fits(contract.Gas, locStack.Len()) {
+				if res, err = in.runBlock(jt, blocks, b, pc, callContext); err != nil {
+					break
+				}
+				continue
+			}
+		}
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(_pc)
-		operation := in.jt[op]
+		operation := jt[op]
 		cost = operation.constantGas // For tracing
 		// Validate stack
 		if sLen := locStack.Len(); sLen < operation.numPop {
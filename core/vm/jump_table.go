@@ -51,6 +51,8 @@ type operation struct {
 	opNum   int // only for push, swap, dup
 	// memorySize returns the memory size required for the operation
 	memorySize memorySizeFunc
+	// undefined marks opUndefined slots, rejected by EOF code validation
+	undefined bool
 }
 
 var (
@@ -67,8 +69,17 @@ var (
 	napoliInstructionSet           = newNapoliInstructionSet()
 	cancunInstructionSet           = newCancunInstructionSet()
 	pragueInstructionSet           = newPragueInstructionSet()
+	osakaInstructionSet            = newOsakaInstructionSet()
 )
 
+// eofInstructionSet is assigned in init: code validation refers to it and EOFCREATE
+// refers back to validation through EVM.create, which is an initialization cycle.
+var eofInstructionSet JumpTable
+
+func init() {
+	eofInstructionSet = newEOFInstructionSet()
+}
+
 // JumpTable contains the EVM opcodes supported at a given fork.
 type JumpTable [256]*operation
 
@@ -90,6 +101,36 @@ func validateAndFillMaxStack(jt *JumpTable) {
 	}
 }
 
+// newEOFInstructionSet returns the instructions of EOF v1 code (EIP-7692): osaka ones
+// without code, gas and jump introspection, legacy calls and creates, plus the EOF ones.
+func newEOFInstructionSet() JumpTable {
+	instructionSet := newOsakaInstructionSet()
+	for _, op := range []OpCode{
+		CALLCODE, SELFDESTRUCT, JUMP, JUMPI, PC, CREATE, CREATE2, CALL, STATICCALL, DELEGATECALL,
+		CODESIZE, CODECOPY, EXTCODESIZE, EXTCODECOPY, EXTCODEHASH, GAS,
+	} {
+		instructionSet[op] = &operation{execute: opUndefined, undefined: true}
+	}
+	enable4200(&instructionSet) // Static relative jumps https://eips.ethereum.org/EIPS/eip-4200
+	enable4750(&instructionSet) // Functions https://eips.ethereum.org/EIPS/eip-4750
+	enable6206(&instructionSet) // JUMPF https://eips.ethereum.org/EIPS/eip-6206
+	enable663(&instructionSet)  // DUPN, SWAPN, EXCHANGE https://eips.ethereum.org/EIPS/eip-663
+	enable7480(&instructionSet) // Data section access https://eips.ethereum.org/EIPS/eip-7480
+	enable7069(&instructionSet) // Revamped calls https://eips.ethereum.org/EIPS/eip-7069
+	enable7620(&instructionSet) // EOF contract creation https://eips.ethereum.org/EIPS/eip-7620
+	validateAndFillMaxStack(&instructionSet)
+	return instructionSet
+}
+
+// newOsakaInstructionSet returns the legacy instructions of osaka,
+// EOF code is executed with the EOF instruction set.
+func newOsakaInstructionSet() JumpTable {
+	instructionSet := newPragueInstructionSet()
+	enable3540(&instructionSet) // EOF contracts look like 0xEF00 to EXTCODE* https://eips.ethereum.org/EIPS/eip-3540
+	validateAndFillMaxStack(&instructionSet)
+	return instructionSet
+}
+
 // newPragueInstructionSet returns the frontier, homestead, byzantium,
 // constantinople, istanbul, petersburg, berlin, london, paris, shanghai,
 // cancun, and prague instructions.
@@ -1214,7 +1255,7 @@ func newFrontierInstructionSet() JumpTable {
 	// Fill all unassigned slots with opUndefined.
 	for i, entry := range tbl {
 		if entry == nil {
-			tbl[i] = &operation{execute: opUndefined}
+			tbl[i] = &operation{execute: opUndefined, undefined: true}
 		}
 	}
 
@@ -21,6 +21,8 @@ package runtime
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
@@ -49,6 +51,7 @@ import (
 	"github.com/erigontech/erigon/core/state"
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/crypto"
 	"github.com/erigontech/erigon/eth/tracers/logger"
 	"github.com/erigontech/erigon/rlp"
 )
@@ -748,3 +751,321 @@ func TestEip2929Cases(t *testing.T) {
 			"account (cheap)", code)
 	}
 }
+
+// eofSection is a code section of the test EOF container
+type eofSection struct {
+	inputs, outputs  byte
+	maxStackIncrease uint16
+	code             []byte
+}
+
+// eofContainer encodes the EOF v1 container, the declared data size is dataSize if greater than len(data)
+func eofContainer(sections []eofSection, containers [][]byte, data []byte, dataSize int) []byte {
+	b := []byte{0xef, 0x00, 0x01, 0x01}
+	b = binary.BigEndian.AppendUint16(b, uint16(len(sections)*4))
+	b = append(b, 0x02)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(sections)))
+	for _, s := range sections {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(s.code)))
+	}
+	if len(containers) > 0 {
+		b = append(b, 0x03)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(containers)))
+		for _, c := range containers {
+			b = binary.BigEndian.AppendUint32(b, uint32(len(c)))
+		}
+	}
+	b = append(b, 0x04)
+	b = binary.BigEndian.AppendUint16(b, uint16(max(dataSize, len(data))))
+	b = append(b, 0x00)
+	for _, s := range sections {
+		b = append(b, s.inputs, s.outputs)
+		b = binary.BigEndian.AppendUint16(b, s.maxStackIncrease)
+	}
+	for _, s := range sections {
+		b = append(b, s.code...)
+	}
+	for _, c := range containers {
+		b = append(b, c...)
+	}
+	return append(b, data...)
+}
+
+func TestEOF(t *testing.T) {
+	t.Parallel()
+
+	returnWord := []byte{byte(vm.PUSH0), byte(vm.MSTORE), byte(vm.PUSH1), 32, byte(vm.PUSH0), byte(vm.RETURN)}
+	// deployed by the initcode containers, data section of 4 bytes is filled by the aux data
+	runtimeContainer := eofContainer([]eofSection{{0, 0x80, 0, []byte{byte(vm.STOP)}}}, nil, nil, 4)
+
+	newState := func(t *testing.T) *state.IntraBlockState {
+		_, tx, _ := NewTestTemporalDb(t)
+		domains, err := stateLib.NewSharedDomains(tx, log.New())
+		require.NoError(t, err)
+		t.Cleanup(domains.Close)
+		return state.New(state.NewReaderV4(domains))
+	}
+
+	t.Run("functions and data", func(t *testing.T) {
+		t.Parallel()
+		statedb := newState(t)
+		address := libcommon.HexToAddress("0xaa")
+		statedb.SetCode(address, eofContainer([]eofSection{
+			{0, 0x80, 2, append([]byte{
+				byte(vm.PUSH1), 1, byte(vm.PUSH1), 2, byte(vm.CALLF), 0, 1, // add(1, 2)
+				byte(vm.DATALOADN), 0, 0, byte(vm.ADD), // + data[0:32]
+				byte(vm.DUP1), byte(vm.RJUMPI), 0, 1, byte(vm.INVALID),
+			}, returnWord...)},
+			{2, 1, 0, []byte{byte(vm.ADD), byte(vm.RETF)}},
+		}, nil, common.LeftPadBytes([]byte{0x10}, 32), 0))
+
+		ret, _, err := Call(address, nil, &Config{State: statedb})
+		require.NoError(t, err)
+		require.Equal(t, common.LeftPadBytes([]byte{0x13}, 32), ret)
+	})
+
+	t.Run("unvalidated code", func(t *testing.T) {
+		t.Parallel()
+		// code which never went through validation runs as legacy code: 0xEF is INVALID
+		for name, code := range map[string][]byte{
+			"callf":     {byte(vm.CALLF), 0, 5},
+			"jumpf":     {byte(vm.JUMPF), 0, 5},
+			"rjump":     {byte(vm.RJUMP), 0x7f, 0xff},
+			"dataloadn": {byte(vm.DATALOADN), 0x7f, 0xff, byte(vm.STOP)},
+			"eofcreate": {byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.EOFCREATE), 5, byte(vm.STOP)},
+		} {
+			statedb := newState(t)
+			address := libcommon.HexToAddress("0xaa")
+			statedb.SetCode(address, eofContainer([]eofSection{{0, 0x80, 4, code}}, nil, nil, 0))
+
+			_, _, err := Call(address, nil, &Config{State: statedb, GasLimit: 100_000})
+			var invalidOpCode *vm.ErrInvalidOpCode
+			require.ErrorAs(t, err, &invalidOpCode, name)
+		}
+	})
+
+	t.Run("ext calls", func(t *testing.T) {
+		t.Parallel()
+		statedb := newState(t)
+		var (
+			caller = libcommon.HexToAddress("0xaa")
+			callee = libcommon.HexToAddress("0xbb")
+			legacy = libcommon.HexToAddress("0xcc")
+		)
+		statedb.SetCode(callee, eofContainer([]eofSection{{0, 0x80, 2, append([]byte{byte(vm.PUSH1), 0x2a}, returnWord...)}}, nil, nil, 0))
+		statedb.SetCode(legacy, []byte{byte(vm.STOP)})
+		statedb.SetCode(caller, eofContainer([]eofSection{{0, 0x80, 4, []byte{
+			// extcall(callee), status to mem[0:32], returndataload(0) to mem[32:64]
+			byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.PUSH1), 0xbb, byte(vm.EXTCALL),
+			byte(vm.PUSH0), byte(vm.MSTORE),
+			byte(vm.PUSH0), byte(vm.RETURNDATALOAD), byte(vm.PUSH1), 32, byte(vm.MSTORE),
+			// extdelegatecall(legacy) is a light failure, status to mem[64:96]
+			byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.PUSH1), 0xcc, byte(vm.EXTDELEGATECALL),
+			byte(vm.PUSH1), 64, byte(vm.MSTORE),
+			byte(vm.PUSH1), 96, byte(vm.PUSH0), byte(vm.RETURN),
+		}}}, nil, nil, 0))
+
+		ret, _, err := Call(caller, nil, &Config{State: statedb, GasLimit: 1_000_000})
+		require.NoError(t, err)
+		want := append(common.LeftPadBytes([]byte{0}, 32), common.LeftPadBytes([]byte{0x2a}, 32)...)
+		want = append(want, common.LeftPadBytes([]byte{1}, 32)...)
+		require.Equal(t, want, ret)
+	})
+
+	t.Run("eofcreate", func(t *testing.T) {
+		t.Parallel()
+		statedb := newState(t)
+		factory := libcommon.HexToAddress("0xaa")
+		// returns the deployed container with 4 bytes of aux data from memory
+		initcode := eofContainer([]eofSection{{0, 0x80, 2, []byte{
+			byte(vm.PUSH4), 1, 2, 3, 4, byte(vm.PUSH0), byte(vm.MSTORE),
+			byte(vm.PUSH1), 4, byte(vm.PUSH1), 28, byte(vm.RETURNCONTRACT), 0,
+		}}}, [][]byte{runtimeContainer}, nil, 0)
+		statedb.SetCode(factory, eofContainer([]eofSection{{0, 0x80, 4, append([]byte{
+			byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.PUSH1), 7, byte(vm.PUSH0), byte(vm.EOFCREATE), 0,
+		}, returnWord...)}}, [][]byte{initcode}, nil, 0))
+
+		ret, _, err := Call(factory, nil, &Config{State: statedb, GasLimit: 1_000_000})
+		require.NoError(t, err)
+		created := libcommon.BytesToAddress(ret)
+		require.Equal(t, crypto.CreateAddress2(factory, libcommon.BytesToHash([]byte{7}), crypto.Keccak256(initcode)), created)
+		require.Equal(t, append(runtimeContainer[:len(runtimeContainer):len(runtimeContainer)], 1, 2, 3, 4), statedb.GetCode(created))
+	})
+
+	t.Run("creation transaction", func(t *testing.T) {
+		t.Parallel()
+		// returns the deployed container with the calldata following the initcode as aux data
+		initcode := eofContainer([]eofSection{{0, 0x80, 3, []byte{
+			byte(vm.CALLDATASIZE), byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.CALLDATACOPY),
+			byte(vm.CALLDATASIZE), byte(vm.PUSH0), byte(vm.RETURNCONTRACT), 0,
+		}}}, [][]byte{runtimeContainer}, nil, 0)
+
+		code, _, _, err := Create(append(initcode, 5, 6, 7, 8), &Config{State: newState(t)}, 0)
+		require.NoError(t, err)
+		require.Equal(t, append(runtimeContainer[:len(runtimeContainer):len(runtimeContainer)], 5, 6, 7, 8), code)
+
+		invalid := append([]byte{}, initcode...)
+		invalid[2] = 0x02 // version
+		_, _, leftOverGas, err := Create(invalid, &Config{State: newState(t), GasLimit: 100_000}, 0)
+		require.ErrorIs(t, err, vm.ErrInvalidEOFInitcode)
+		require.Zero(t, leftOverGas)
+	})
+}
+
+// superinstructionsPrograms are legacy codes exercising the edge cases of the superinstructions mode:
+// fused instructions, gas observed within blocks, stack bounds and invalid jumps and instructions.
+var superinstructionsPrograms = map[string][]byte{
+	"loop": {
+		byte(vm.PUSH1), 3, // [n]
+		byte(vm.JUMPDEST),                              // pc 2
+		byte(vm.DUP1), byte(vm.PUSH0), byte(vm.MSTORE), // mem[0:32] = n
+		byte(vm.PUSH1), 32, byte(vm.PUSH0), byte(vm.KECCAK256), // [n, h]
+		byte(vm.DUP2), byte(vm.SWAP1), byte(vm.TSTORE), // transient[h] = n
+		// staticcall identity with all the gas, gas left to mem[32:64]
+		byte(vm.PUSH0), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.PUSH1), 4, byte(vm.GAS), byte(vm.STATICCALL), byte(vm.POP),
+		byte(vm.GAS), byte(vm.PUSH1), 32, byte(vm.MSTORE),
+		byte(vm.PUSH1), 1, byte(vm.SWAP1), byte(vm.SUB), // [n-1]
+		byte(vm.DUP1), byte(vm.PUSH1), 2, byte(vm.JUMPI),
+		byte(vm.PUSH1), 64, byte(vm.PUSH0), byte(vm.RETURN),
+	},
+	"sstore sentry": {
+		byte(vm.PUSH1), 1, byte(vm.PUSH0), byte(vm.SSTORE),
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 2, byte(vm.ADD), byte(vm.POP), byte(vm.STOP),
+	},
+	"revert": {
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH0), byte(vm.MSTORE), byte(vm.GAS), byte(vm.PUSH1), 32, byte(vm.MSTORE),
+		byte(vm.PUSH1), 64, byte(vm.PUSH0), byte(vm.REVERT),
+	},
+	"stack underflow": {
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 2, byte(vm.ADD), byte(vm.ADD), byte(vm.STOP),
+	},
+	"undefined instruction": {
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 2, 0x0c,
+	},
+	"jump to push data": {
+		byte(vm.PUSH1), 4, byte(vm.JUMP), byte(vm.PUSH1), byte(vm.JUMPDEST), byte(vm.STOP),
+	},
+	"dynamic jump": {
+		byte(vm.PUSH1), 3, byte(vm.PUSH1), 3, byte(vm.ADD), byte(vm.JUMP), byte(vm.JUMPDEST), byte(vm.GAS), byte(vm.PUSH0), byte(vm.MSTORE),
+		byte(vm.PUSH1), 32, byte(vm.PUSH0), byte(vm.RETURN),
+	},
+	"truncated push": {
+		byte(vm.PUSH1), 1, byte(vm.PUSH2), 0xff,
+	},
+}
+
+// TestSuperinstructions checks the superinstructions mode returns the same data, gas and error as the
+// interpreter loop, with the gas limits around where each program runs out of gas.
+func TestSuperinstructions(t *testing.T) {
+	t.Parallel()
+
+	for name, code := range superinstructionsPrograms {
+		code := code
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, tx, _ := NewTestTemporalDb(t)
+			domains, err := stateLib.NewSharedDomains(tx, log.New())
+			require.NoError(t, err)
+			t.Cleanup(domains.Close)
+			statedb := state.New(state.NewReaderV4(domains))
+			address := libcommon.HexToAddress("0xaa")
+			statedb.SetCode(address, code)
+
+			call := func(gas uint64, superinstructions bool) ([]byte, uint64, error) {
+				snapshot := statedb.Snapshot()
+				defer statedb.RevertToSnapshot(snapshot)
+				return Call(address, nil, &Config{State: statedb, GasLimit: gas, EVMConfig: vm.Config{Superinstructions: superinstructions}})
+			}
+			limit := uint64(1_000) // failing programs consume all the gas
+			if _, left, err := call(1_000_000, false); err == nil || errors.Is(err, vm.ErrExecutionReverted) {
+				limit = 1_000_000 - left + 200
+			}
+			for gas := uint64(1); gas <= limit; gas++ {
+				ret, left, err := call(gas, false)
+				superRet, superLeft, superErr := call(gas, true)
+				require.Equal(t, ret, superRet, "gas %d", gas)
+				require.Equal(t, left, superLeft, "gas %d", gas)
+				require.Equal(t, err, superErr, "gas %d", gas)
+			}
+		})
+	}
+}
+
+// tokenCode is the initcode of cmd/pics/contracts/token.sol
+var tokenCode = libcommon.Hex2Bytes("608060405234801561001057600080fd5b506040516102cd3803806102cd8339818101604052602081101561003357600080fd5b5051600280546001600160a01b0319166001600160a01b0390921691909117905561026a806100636000396000f3fe608060405234801561001057600080fd5b50600436106100575760003560e01c8063075461721461005c57806318160ddd1461008057806340c10f191461009a57806370a08231146100da578063a9059cbb14610100575b600080fd5b61006461012c565b604080516001600160a01b039092168252519081900360200190f35b61008861013b565b60408051918252519081900360200190f35b6100c6600480360360408110156100b057600080fd5b506001600160a01b038135169060200135610141565b604080519115158252519081900360200190f35b610088600480360360208110156100f057600080fd5b50356001600160a01b03166101b1565b6100c66004803603604081101561011657600080fd5b506001600160a01b0381351690602001356101c3565b6002546001600160a01b031681565b60005481565b6002546000906001600160a01b0316331461015b57600080fd5b6001600160a01b03831660009081526001602052604090205482810181111561018357600080fd5b6001600160a01b03841660009081526001602081905260408220928501909255805484019055905092915050565b60016020526000908152604090205481565b33600090815260016020526040808220546001600160a01b038516835290822054838210156101f157600080fd5b80848201101561020057600080fd5b336000908152600160208190526040808320948790039094556001600160a01b03969096168152919091209201909155509056fea2646970667358221220db4c7b3ba8d073604af68ade92006926639bb4003f2a18929524d580777155fb64736f6c63430007020033")
+
+// tokenCalldata returns the calldata of mint (0x40c10f19) or transfer (0xa9059cbb) to the address
+func tokenCalldata(selector []byte, to libcommon.Address, value uint64) []byte {
+	input := append(libcommon.Copy(selector), common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(input, common.LeftPadBytes(new(big.Int).SetUint64(value).Bytes(), 32)...)
+}
+
+// BenchmarkSuperinstructions compares the interpreter loop and the superinstructions mode on a block
+// of token transfers between distinct accounts, the bulk of mainnet blocks, and on the simple loops.
+func BenchmarkSuperinstructions(b *testing.B) {
+	var (
+		mintSelector     = []byte{0x40, 0xc1, 0x0f, 0x19}
+		transferSelector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+	)
+	for _, superinstructions := range []bool{false, true} {
+		mode := "plain"
+		if superinstructions {
+			mode = "superinstructions"
+		}
+
+		b.Run("token transfers/"+mode, func(b *testing.B) {
+			_, tx, _ := NewTestTemporalDb(b)
+			domains, err := stateLib.NewSharedDomains(tx, log.New())
+			require.NoError(b, err)
+			defer domains.Close()
+			cfg := &Config{
+				Origin:    libcommon.HexToAddress("0x1000"),
+				State:     state.New(state.NewReaderV4(domains)),
+				GasLimit:  10_000_000,
+				EVMConfig: vm.Config{Superinstructions: superinstructions},
+			}
+			_, token, _, err := Create(append(libcommon.Copy(tokenCode), common.LeftPadBytes(cfg.Origin.Bytes(), 32)...), cfg, 0)
+			require.NoError(b, err)
+			_, _, err = Call(token, tokenCalldata(mintSelector, cfg.Origin, 1<<60), cfg)
+			require.NoError(b, err)
+
+			transfers := make([][]byte, 200)
+			for i := range transfers {
+				transfers[i] = tokenCalldata(transferSelector, libcommon.BigToAddress(big.NewInt(int64(0x2000+i))), 1)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, input := range transfers {
+					if _, _, err := Call(token, input, cfg); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+
+		b.Run("loops/"+mode, func(b *testing.B) {
+			// keccak, transient storage, memory and calls, as in BenchmarkSimpleLoop but limited by the counter
+			code := libcommon.Copy(superinstructionsPrograms["loop"])
+			code[1] = 0xff // iterations
+			_, tx, _ := NewTestTemporalDb(b)
+			domains, err := stateLib.NewSharedDomains(tx, log.New())
+			require.NoError(b, err)
+			defer domains.Close()
+			cfg := &Config{
+				State:     state.New(state.NewReaderV4(domains)),
+				GasLimit:  100_000_000,
+				EVMConfig: vm.Config{Superinstructions: superinstructions},
+			}
+			address := libcommon.HexToAddress("0xaa")
+			cfg.State.SetCode(address, code)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := Call(address, nil, cfg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/math"
+
+	"github.com/erigontech/erigon/common"
+	"github.com/erigontech/erigon/core/tracing"
+	"github.com/erigontech/erigon/params"
+)
+
+// Superinstructions mode (Config.Superinstructions) runs legacy code on a pre-analysed form of it:
+// the code is split into basic blocks, the static gas of a block is charged and its stack bounds
+// are checked once on entry, and frequent instruction sequences are fused into one instruction.
+//
+// The results are the same as the ones of the interpreter loop, which shapes the design:
+//   - A block runs in one go only if its stack bounds hold and the gas covers its static gas,
+//     otherwise the interpreter loop executes it instruction by instruction, failing the same way.
+//   - The instructions observing the gas left (the ones with dynamic gas, GAS) get back the static
+//     gas charged ahead for the rest of the block while they execute, so SSTORE, CALL and GAS see
+//     the same gas. When the gas left can't cover the rest of the block after such an instruction,
+//     the interpreter loop takes over the remaining instructions to fail at the same one.
+//   - PUSH and JUMP/JUMPI are fused only for a valid static destination, the other jumps keep the
+//     run-time checks (and logging) of their destination.
+//   - Undefined instructions are left out of the blocks, they fail in the interpreter loop.
+
+// superOp is the kind of an instruction of the analysed code
+type superOp uint8
+
+const (
+	superPlain      superOp = iota // jump table operation
+	superPush                      // PUSH0-PUSH32 with a decoded immediate
+	superPushJump                  // PUSHn JUMP to a valid JUMPDEST
+	superPushJumpi                 // PUSHn JUMPI to a valid JUMPDEST
+	superPushMstore                // PUSHn MSTORE
+	superDupSwap                   // DUPn SWAPm
+)
+
+type superInstr struct {
+	kind superOp
+	op   OpCode // the jump table operation of superPlain and superPushMstore
+	dup  uint8  // superDupSwap: n of DUPn
+	swap uint8  // superDupSwap: m of SWAPm, plus one as in makeSwap
+	pc   uint32 // pc of the (first) instruction
+	// arg is the index of the immediate in codeBlocks.consts for superPush and superPushMstore,
+	// the destination for superPushJump and superPushJumpi
+	arg uint32
+	// gasAhead is the static gas of the instructions following this one in the block, charged
+	// ahead on block entry
+	gasAhead uint64
+}
+
+type codeBlock struct {
+	first, last uint32 // instructions of the block: codeBlocks.instrs[first:last]
+	end         uint64 // pc following the block
+	staticGas   uint64
+	// bounds of the stack height on block entry making all its instructions pass the stack checks
+	minStack, maxStack int
+}
+
+// codeBlocks is the analysed code of a contract for an instruction set
+type codeBlocks struct {
+	blockAt []int32 // index in blocks of the block starting at pc, -1 if none does
+	blocks  []codeBlock
+	instrs  []superInstr
+	consts  []uint256.Int
+}
+
+// codeBlocksCacheSize is the number of analysed codes kept in codeBlocksCache
+const codeBlocksCacheSize = 4096
+
+type codeBlocksKey struct {
+	codeHash       libcommon.Hash
+	instructionSet instructionSetKey
+}
+
+// instructionSetKey identifies the instruction set code is analysed for: the instruction set of the fork and
+// the extra EIPs enabled on its copy. The copy itself is made per interpreter, so it can't be the key.
+type instructionSetKey struct {
+	base      *JumpTable
+	extraEips string
+}
+
+var codeBlocksCache, _ = lru.New[codeBlocksKey, *codeBlocks](codeBlocksCacheSize)
+
+// analysedCode returns the analysed code of the contract, cached per code hash and instruction set.
+// As for jumpdest analysis, initcode without the code hash is analysed for each run.
+func analysedCode(contract *Contract, jt *JumpTable, instructionSet instructionSetKey) *codeBlocks {
+	if contract.CodeHash == (libcommon.Hash{}) {
+		return analyseCodeBlocks(contract.Code, jt)
+	}
+	key := codeBlocksKey{codeHash: contract.CodeHash, instructionSet: instructionSet}
+	if blocks, ok := codeBlocksCache.Get(key); ok {
+		return blocks
+	}
+	blocks := analyseCodeBlocks(contract.Code, jt)
+	codeBlocksCache.Add(key, blocks)
+	return blocks
+}
+
+// analyseCodeBlocks splits the code into basic blocks: a block starts at pc 0, at a JUMPDEST and
+// after the end of the previous block, it ends before an undefined instruction or a JUMPDEST and
+// after a JUMP, JUMPI or halting instruction.
+func analyseCodeBlocks(code []byte, jt *JumpTable) *codeBlocks {
+	cb := &codeBlocks{blockAt: make([]int32, len(code))}
+	for i := range cb.blockAt {
+		cb.blockAt[i] = -1
+	}
+	bitmap := codeBitmap(code)
+
+	var (
+		block *codeBlock // open block
+		depth int        // stack height change since the block entry
+	)
+	closeBlock := func(end uint64) {
+		if block == nil {
+			return
+		}
+		block.last, block.end = uint32(len(cb.instrs)), end
+		for i := block.first; i < block.last; i++ {
+			// gasAhead holds the static gas up to the instruction until here
+			cb.instrs[i].gasAhead = block.staticGas - cb.instrs[i].gasAhead
+		}
+		block = nil
+	}
+	// account adds the static gas and stack checks of op to the open block
+	account := func(op OpCode) {
+		operation := jt[op]
+		block.staticGas += operation.constantGas
+		block.minStack = max(block.minStack, operation.numPop-depth)
+		block.maxStack = min(block.maxStack, operation.maxStack-depth)
+		depth += operation.numPush - operation.numPop
+	}
+
+	for pc := uint64(0); pc < uint64(len(code)); {
+		op := OpCode(code[pc])
+		if jt[op].undefined {
+			closeBlock(pc)
+			pc++
+			continue
+		}
+		if op == JUMPDEST {
+			closeBlock(pc)
+		}
+		if block == nil {
+			cb.blockAt[pc] = int32(len(cb.blocks))
+			cb.blocks = append(cb.blocks, codeBlock{first: uint32(len(cb.instrs)), maxStack: int(params.StackLimit)})
+			block, depth = &cb.blocks[len(cb.blocks)-1], 0
+		}
+
+		instr := superInstr{kind: superPlain, op: op, pc: uint32(pc)}
+		next := pc + 1
+		account(op)
+		switch {
+		case op == PUSH0 || jt[op].isPush:
+			size := uint64(0)
+			if op != PUSH0 {
+				size = uint64(jt[op].opNum)
+			}
+			var imm uint256.Int
+			start, end := min(pc+1, uint64(len(code))), min(pc+1+size, uint64(len(code)))
+			imm.SetBytes(common.RightPadBytes(code[start:end], int(size)))
+			instr.kind, instr.arg = superPush, uint32(len(cb.consts))
+			cb.consts = append(cb.consts, imm)
+			next = pc + 1 + size
+
+			if next >= uint64(len(code)) {
+				break
+			}
+			switch nextOp := OpCode(code[next]); {
+			case (nextOp == JUMP || nextOp == JUMPI) && validStaticJumpdest(code, bitmap, &imm):
+				instr.kind, instr.arg = superPushJump, uint32(imm.Uint64())
+				if nextOp == JUMPI {
+					instr.kind = superPushJumpi
+				}
+				account(nextOp)
+				op, next = nextOp, next+1
+			case nextOp == MSTORE:
+				instr.kind, instr.op = superPushMstore, MSTORE
+				account(nextOp)
+				op, next = nextOp, next+1
+			}
+		case jt[op].isDup && next < uint64(len(code)) && jt[OpCode(code[next])].isSwap:
+			nextOp := OpCode(code[next])
+			instr.kind, instr.dup, instr.swap = superDupSwap, uint8(jt[op].opNum), uint8(jt[nextOp].opNum+1)
+			account(nextOp)
+			op, next = nextOp, next+1
+		}
+		instr.gasAhead = block.staticGas
+		cb.instrs = append(cb.instrs, instr)
+
+		pc = next
+		switch op {
+		case JUMP, JUMPI, STOP, RETURN, REVERT, SELFDESTRUCT:
+			closeBlock(pc)
+		}
+	}
+	closeBlock(uint64(len(code)))
+	return cb
+}
+
+// validStaticJumpdest reports whether dest is a JUMPDEST of the code, valid whether jumpdest
+// analysis is skipped or not.
+func validStaticJumpdest(code []byte, bitmap []uint64, dest *uint256.Int) bool {
+	udest, overflow := dest.Uint64WithOverflow()
+	if overflow || udest >= uint64(len(code)) {
+		return false
+	}
+	return OpCode(code[udest]) == JUMPDEST && isCodeFromAnalysis(bitmap, udest)
+}
+
+// fits reports whether the block can run in one go with the given gas and stack height
+func (b *codeBlock) fits(gas uint64, stackLen int) bool {
+	return gas >= b.staticGas && stackLen >= b.minStack && stackLen <= b.maxStack
+}
+
+// runBlock executes the block, which must fit the gas and the stack, and sets pc to the next
+// instruction to execute: the destination of a jump, the end of the block, or the instruction
+// the interpreter loop takes over from.
+func (in *EVMInterpreter) runBlock(jt *JumpTable, cb *codeBlocks, b *codeBlock, pc *uint64, scope *ScopeContext) ([]byte, error) {
+	var (
+		contract = scope.Contract
+		locStack = scope.Stack
+		instrs   = cb.instrs[b.first:b.last]
+	)
+	contract.Gas -= b.staticGas
+	for i := range instrs {
+		instr := &instrs[i]
+		switch instr.kind {
+		case superPush:
+			locStack.Push(&cb.consts[instr.arg])
+			continue
+		case superDupSwap:
+			locStack.Dup(int(instr.dup))
+			locStack.Swap(int(instr.swap))
+			continue
+		case superPushJump:
+			*pc = uint64(instr.arg)
+			return nil, nil
+		case superPushJumpi:
+			if cond := locStack.Pop(); !cond.IsZero() {
+				*pc = uint64(instr.arg)
+				return nil, nil
+			}
+			continue
+		case superPushMstore:
+			locStack.Push(&cb.consts[instr.arg])
+		}
+
+		operation := jt[instr.op]
+		*pc = uint64(instr.pc)
+		if operation.dynamicGas == nil && instr.op != GAS {
+			res, err := operation.execute(pc, in, scope)
+			if err != nil {
+				return res, err
+			}
+			if instr.op == JUMP || instr.op == JUMPI {
+				*pc++ // as the interpreter loop, after the destination minus one
+				return nil, nil
+			}
+			continue
+		}
+
+		// give back the gas charged ahead so the instruction observes the gas of the interpreter loop
+		contract.Gas += instr.gasAhead
+		res, err := in.executeDynamic(operation, pc, scope)
+		if err != nil {
+			return res, err
+		}
+		if contract.Gas < instr.gasAhead {
+			*pc = b.end
+			if i+1 < len(instrs) {
+				*pc = uint64(instrs[i+1].pc)
+			}
+			return nil, nil
+		}
+		contract.Gas -= instr.gasAhead
+	}
+	*pc = b.end
+	return nil, nil
+}
+
+// executeDynamic charges the dynamic gas of the operation, expands the memory and executes it,
+// as the interpreter loop does without tracing.
+func (in *EVMInterpreter) executeDynamic(operation *operation, pc *uint64, scope *ScopeContext) ([]byte, error) {
+	if operation.dynamicGas != nil {
+		var memorySize uint64
+		if operation.memorySize != nil {
+			memSize, overflow := operation.memorySize(scope.Stack)
+			if overflow {
+				return nil, ErrGasUintOverflow
+			}
+			if memorySize, overflow = math.SafeMul(ToWordSize(memSize), 32); overflow {
+				return nil, ErrGasUintOverflow
+			}
+		}
+		dynamicCost, err := operation.dynamicGas(in.evm, scope.Contract, scope.Stack, scope.Memory, memorySize)
+		if err != nil || !scope.Contract.UseGas(dynamicCost, tracing.GasChangeIgnored) {
+			return nil, ErrOutOfGas
+		}
+		if memorySize > 0 {
+			scope.Memory.Resize(memorySize)
+		}
+	}
+	return operation.execute(pc, in, scope)
+}
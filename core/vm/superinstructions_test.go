@@ -0,0 +1,120 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+	"github.com/erigontech/erigon/params"
+)
+
+func TestAnalyseCodeBlocks(t *testing.T) {
+	t.Parallel()
+
+	code := []byte{
+		// block 0: pc 0-10
+		byte(PUSH1), 0x01, byte(PUSH1), 0x20, byte(MSTORE), // PUSH1, PUSH1 MSTORE
+		byte(DUP1), byte(SWAP1), // DUP1 SWAP1
+		byte(CALLER), byte(PUSH1), 13, byte(JUMPI), // CALLER, PUSH1 JUMPI
+		// block 1: pc 11-12, ends before the undefined instruction
+		byte(PUSH0), byte(POP),
+		// block 2: pc 13-15
+		byte(JUMPDEST), byte(GAS), byte(JUMP),
+		// no block: undefined instruction
+		0x0c,
+		// block 3: pc 17, truncated push
+		byte(PUSH2), 0xff,
+	}
+	cb := analyseCodeBlocks(code, &cancunInstructionSet)
+
+	starts := map[int]int32{}
+	for pc, idx := range cb.blockAt {
+		if idx >= 0 {
+			starts[pc] = idx
+		}
+	}
+	require.Equal(t, map[int]int32{0: 0, 11: 1, 13: 2, 17: 3}, starts)
+
+	b := cb.blocks[0]
+	require.Equal(t, uint64(11), b.end)
+	require.Equal(t, 3+3+3+3+3+2+3+10, int(b.staticGas))
+	require.Equal(t, 1, b.minStack) // DUP1 after MSTORE takes an item from before the block
+	var kinds []superOp
+	for _, instr := range cb.instrs[b.first:b.last] {
+		kinds = append(kinds, instr.kind)
+	}
+	require.Equal(t, []superOp{superPush, superPushMstore, superDupSwap, superPlain, superPushJumpi}, kinds)
+	// the gas charged ahead after PUSH1 MSTORE, the instruction getting it back
+	require.Equal(t, uint64(3+3+2+3+10), cb.instrs[b.first+1].gasAhead)
+	require.Equal(t, uint32(13), cb.instrs[b.last-1].arg)
+
+	b = cb.blocks[1]
+	require.Equal(t, uint64(13), b.end)
+	require.Equal(t, 1023, b.maxStack) // room for the item of PUSH0
+
+	b = cb.blocks[2]
+	require.Equal(t, uint64(16), b.end)
+	require.Equal(t, 0, b.minStack) // JUMP pops the destination pushed by GAS
+	require.Equal(t, []superOp{superPlain, superPlain, superPlain}, []superOp{cb.instrs[b.first].kind, cb.instrs[b.first+1].kind, cb.instrs[b.first+2].kind})
+
+	b = cb.blocks[3]
+	require.Equal(t, uint64(len(code)), b.end)
+	require.Equal(t, uint64(0xff00), cb.consts[cb.instrs[b.first].arg].Uint64())
+}
+
+func TestAnalyseCodeBlocksJumps(t *testing.T) {
+	t.Parallel()
+
+	code := []byte{
+		byte(PUSH1), 7, byte(JUMP), // to the JUMPDEST in push data: not fused
+		byte(PUSH1), 10, byte(JUMP), // to the JUMPDEST: fused
+		byte(PUSH1), byte(JUMPDEST), // push data
+		byte(PUSH1), 12, // to the STOP: not fused
+		byte(JUMPDEST), byte(JUMP), byte(STOP),
+	}
+	cb := analyseCodeBlocks(code, &cancunInstructionSet)
+
+	require.Equal(t, int32(-1), cb.blockAt[7])
+	require.Equal(t, superPlain, cb.instrs[cb.blocks[cb.blockAt[0]].first+1].kind)
+	fused := cb.instrs[cb.blocks[cb.blockAt[3]].first]
+	require.Equal(t, superPushJump, fused.kind)
+	require.Equal(t, uint32(10), fused.arg)
+	require.Equal(t, superPlain, cb.instrs[cb.blocks[cb.blockAt[10]].first+1].kind)
+}
+
+func TestAnalysedCodeCacheExtraEips(t *testing.T) {
+	t.Parallel()
+
+	newInterpreter := func(extraEips ...int) *EVMInterpreter {
+		evm := NewEVM(evmtypes.BlockContext{}, evmtypes.TxContext{}, &dummyStatedb{}, params.TestChainConfig, Config{ExtraEips: extraEips})
+		return evm.interpreter.(*EVMInterpreter)
+	}
+	contract := &Contract{Code: []byte{byte(PUSH1), 1, byte(PUSH1), 0, byte(MSTORE), byte(STOP)}, CodeHash: libcommon.Hash{1}}
+
+	// interpreters with the same extra EIPs have their own copies of the instruction set, but share the analysed code
+	in1, in2 := newInterpreter(3855), newInterpreter(3855)
+	require.NotSame(t, in1.jt, in2.jt)
+	cb := analysedCode(contract, in1.jt, in1.jtKey)
+	require.Same(t, cb, analysedCode(contract, in2.jt, in2.jtKey))
+
+	in3 := newInterpreter()
+	require.NotSame(t, cb, analysedCode(contract, in3.jt, in3.jtKey))
+}
@@ -0,0 +1,331 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package commitment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/rlp"
+)
+
+// Proof is a Merkle proof of an account and a set of its storage slots, in the shape served by eth_getProof.
+// Every node is RLP-encoded; nodes shorter than 32 bytes are embedded into their parent and are not listed,
+// except for the root of each trie which is always present.
+type Proof struct {
+	AccountProof [][]byte    // nodes on the path from the state root towards the account
+	StorageHash  common.Hash // root of the account storage trie, EmptyRootHash if account has no storage or does not exist
+	StorageProof [][][]byte  // for each requested storage location, nodes on the path from StorageHash towards it
+}
+
+// GenerateProof walks the trie from its current root towards the given account and its storage locations,
+// reading branches through PatriciaContext, and collects the nodes along the way. Every produced node is checked
+// against the reference stored in its parent, so the proof is consistent with RootHash().
+// Trie must not have active rows, e.g. it should be freshly restored by SetState or have finished processing updates.
+func (hph *HexPatriciaHashed) GenerateProof(accountPlainKey []byte, storageLocs [][]byte) (*Proof, error) {
+	if hph.activeRows != 0 {
+		return nil, errors.New("GenerateProof: trie has active rows")
+	}
+	if len(accountPlainKey) != hph.accountKeyLen {
+		return nil, fmt.Errorf("GenerateProof: account key length %d, expected %d", len(accountPlainKey), hph.accountKeyLen)
+	}
+	root := hph.root
+	rootRef, err := hph.computeCellHash(&root, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &Proof{StorageHash: common.Hash(EmptyRootHash), StorageProof: make([][][]byte, len(storageLocs))}
+	root = hph.root
+	var account *Cell
+	proof.AccountProof, account, err = hph.proofPath(&root, 0, hph.hashedNibbles(accountPlainKey), accountPlainKey, common.Copy(rootRef))
+	if err != nil {
+		return nil, fmt.Errorf("account %x: %w", accountPlainKey, err)
+	}
+	if account == nil {
+		// account does not exist, so storage is empty and every storage proof is empty as well
+		return proof, nil
+	}
+
+	storageRoot, err := hph.storageRootCell(account)
+	if err != nil {
+		return nil, fmt.Errorf("account %x: %w", accountPlainKey, err)
+	}
+	if proof.StorageHash, err = hph.storageRootHash(account); err != nil {
+		return nil, fmt.Errorf("account %x: %w", accountPlainKey, err)
+	}
+	storageRef := append([]byte{0x80 + length.Hash}, proof.StorageHash[:]...)
+
+	plainKey := make([]byte, len(accountPlainKey), len(accountPlainKey)+length.Hash)
+	copy(plainKey, accountPlainKey)
+	for i, loc := range storageLocs {
+		plainKey = append(plainKey[:len(accountPlainKey)], loc...)
+		cell := storageRoot
+		if proof.StorageProof[i], _, err = hph.proofPath(&cell, 64, hph.hashedNibbles(plainKey), plainKey, storageRef); err != nil {
+			return nil, fmt.Errorf("storage %x: %w", plainKey, err)
+		}
+	}
+	return proof, nil
+}
+
+// proofPath descends from cell, which references subtrie at hashedKey[:depth] by ref, towards hashedKey.
+// Returns collected nodes and the leaf cell of plainKey, or nil leaf if the key is absent from the trie.
+func (hph *HexPatriciaHashed) proofPath(cell *Cell, depth int, hashedKey, plainKey, ref []byte) (nodes [][]byte, leaf *Cell, err error) {
+	for first := true; ; first = false {
+		var node []byte
+		switch {
+		case cell.accountPlainKeyLen > 0:
+			if node, err = hph.accountLeafNode(cell, depth); err != nil {
+				return nil, nil, err
+			}
+			if nodes, err = appendProofNode(hph.keccak, nodes, node, ref, first); err != nil {
+				return nil, nil, err
+			}
+			if !bytes.Equal(cell.accountPlainKey[:cell.accountPlainKeyLen], plainKey) {
+				return nodes, nil, nil
+			}
+			return nodes, cell, nil
+		case cell.storagePlainKeyLen > 0:
+			node = hph.storageLeafNode(cell, depth)
+			if nodes, err = appendProofNode(hph.keccak, nodes, node, ref, first); err != nil {
+				return nil, nil, err
+			}
+			if !bytes.Equal(cell.storagePlainKey[:cell.storagePlainKeyLen], plainKey) {
+				return nodes, nil, nil
+			}
+			return nodes, cell, nil
+		case cell.extLen > 0:
+			if cell.HashLen == 0 {
+				return nil, nil, fmt.Errorf("extension without hash at depth %d", depth)
+			}
+			node = extensionNode(cell.extension[:cell.extLen], cell.hash[:cell.HashLen])
+			if nodes, err = appendProofNode(hph.keccak, nodes, node, ref, first); err != nil {
+				return nil, nil, err
+			}
+			if !bytes.HasPrefix(hashedKey[depth:], cell.extension[:cell.extLen]) {
+				return nodes, nil, nil
+			}
+			depth += cell.extLen
+			ref = append([]byte{0x80 + length.Hash}, cell.hash[:cell.HashLen]...)
+			cell.extLen = 0
+			// branch node referenced by extension is processed on the next iteration
+		case cell.HashLen > 0:
+			if depth >= len(hashedKey) {
+				return nil, nil, fmt.Errorf("branch at depth %d is deeper than the key", depth)
+			}
+			children, refs, node, err := hph.proofBranchNode(hashedKey[:depth], depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if nodes, err = appendProofNode(hph.keccak, nodes, node, ref, first); err != nil {
+				return nil, nil, err
+			}
+			nibble := hashedKey[depth]
+			if refs[nibble] == nil {
+				return nodes, nil, nil
+			}
+			cell, ref = &children[nibble], refs[nibble]
+			depth++
+		default:
+			return nodes, nil, nil // empty (sub)trie
+		}
+	}
+}
+
+// proofBranchNode reads branch at the given nibble prefix and restores its children located at depth.
+// Returns children cells, their references as they are included into the branch node, and the RLP of the node.
+func (hph *HexPatriciaHashed) proofBranchNode(prefix []byte, depth int) (children *[16]Cell, refs [16][]byte, node []byte, err error) {
+	key := hexToCompact(prefix)
+	if len(key) == 0 {
+		key = temporalReplacementForEmpty
+	}
+	branchData, _, err := hph.ctx.GetBranch(key)
+	if err != nil {
+		return nil, refs, nil, err
+	}
+	if len(branchData) < 4 {
+		return nil, refs, nil, fmt.Errorf("branch %x not found", prefix)
+	}
+	branchData = branchData[2:] // skip touch map
+	bitmap := binary.BigEndian.Uint16(branchData[0:])
+	pos := 2
+
+	children = new([16]Cell)
+	payloadLen := 17 - bits.OnesCount16(bitmap)
+	for bitset := bitmap; bitset != 0; {
+		bit := bitset & -bitset
+		nibble := bits.TrailingZeros16(bit)
+		cell := &children[nibble]
+		if pos >= len(branchData) {
+			return nil, refs, nil, fmt.Errorf("branch %x: buffer too small", prefix)
+		}
+		fieldBits := branchData[pos]
+		pos++
+		if pos, err = cell.fillFromFields(branchData, pos, PartFlags(fieldBits)); err != nil {
+			return nil, refs, nil, fmt.Errorf("branch %x: %w", prefix, err)
+		}
+		if cell.accountPlainKeyLen > 0 {
+			if err = hph.ctx.GetAccount(cell.accountPlainKey[:cell.accountPlainKeyLen], cell); err != nil {
+				return nil, refs, nil, fmt.Errorf("proofBranchNode GetAccount: %w", err)
+			}
+		}
+		if cell.storagePlainKeyLen > 0 {
+			if err = hph.ctx.GetStorage(cell.storagePlainKey[:cell.storagePlainKeyLen], cell); err != nil {
+				return nil, refs, nil, fmt.Errorf("proofBranchNode GetStorage: %w", err)
+			}
+		}
+		// computeCellHash may return the slice of the aux buffer, so the reference is copied
+		ref, err := hph.computeCellHash(cell, depth, nil)
+		if err != nil {
+			return nil, refs, nil, err
+		}
+		refs[nibble] = common.Copy(ref)
+		payloadLen += len(ref)
+		bitset ^= bit
+	}
+
+	node = make([]byte, 0, rlp.ListPrefixLen(payloadLen)+payloadLen)
+	node = appendListPrefix(node, payloadLen)
+	for nibble := 0; nibble < 16; nibble++ {
+		if refs[nibble] == nil {
+			node = append(node, 0x80)
+			continue
+		}
+		node = append(node, refs[nibble]...)
+	}
+	node = append(node, 0x80) // branch value is always empty
+	return children, refs, node, nil
+}
+
+// storageRootCell returns a cell which references storage trie of the account cell at depth 64.
+func (hph *HexPatriciaHashed) storageRootCell(account *Cell) (root Cell, err error) {
+	if account.storagePlainKeyLen > 0 {
+		root.storagePlainKeyLen = account.storagePlainKeyLen
+		copy(root.storagePlainKey[:], account.storagePlainKey[:account.storagePlainKeyLen])
+		root.setStorage(account.Storage[:account.StorageLen])
+		return root, nil
+	}
+	if account.extLen > 0 && account.HashLen == 0 {
+		return root, errors.New("storage extension without hash")
+	}
+	root.extLen = account.extLen
+	copy(root.extension[:], account.extension[:account.extLen])
+	root.HashLen = account.HashLen
+	copy(root.hash[:], account.hash[:account.HashLen])
+	return root, nil
+}
+
+// storageRootHash computes the storage root of the account cell the same way computeCellHash does.
+func (hph *HexPatriciaHashed) storageRootHash(account *Cell) (h common.Hash, err error) {
+	switch {
+	case account.storagePlainKeyLen > 0:
+		leaf := hph.storageLeafNode(account, 64)
+		hph.keccak.Reset()
+		hph.keccak.Write(leaf)
+		hph.keccak.Read(h[:])
+	case account.extLen > 0:
+		return hph.extensionHash(account.extension[:account.extLen], account.hash[:account.HashLen])
+	case account.HashLen > 0:
+		copy(h[:], account.hash[:account.HashLen])
+	default:
+		copy(h[:], EmptyRootHash)
+	}
+	return h, nil
+}
+
+// accountLeafNode encodes account leaf node for the cell located at depth.
+func (hph *HexPatriciaHashed) accountLeafNode(cell *Cell, depth int) ([]byte, error) {
+	if depth > 64 {
+		return nil, fmt.Errorf("account leaf at depth %d", depth)
+	}
+	storageRoot, err := hph.storageRootHash(cell)
+	if err != nil {
+		return nil, err
+	}
+	var valBuf [128]byte
+	valLen := cell.accountForHashing(valBuf[:], storageRoot)
+
+	key := hph.hashedNibbles(cell.accountPlainKey[:cell.accountPlainKeyLen])[depth:64]
+	return leafNode(hexToCompact(append(key, 16)), rlpString(valBuf[:valLen])), nil
+}
+
+// storageLeafNode encodes storage leaf node for the cell located at depth (64 is the root of storage trie).
+func (hph *HexPatriciaHashed) storageLeafNode(cell *Cell, depth int) []byte {
+	key := hph.hashedNibbles(cell.storagePlainKey[:cell.storagePlainKeyLen])[depth:128]
+	return leafNode(hexToCompact(append(key, 16)), rlpString(rlpString(cell.Storage[:cell.StorageLen])))
+}
+
+// hashedNibbles returns nibbles of hashed plain key: 64 nibbles for account key and 128 nibbles for storage key.
+func (hph *HexPatriciaHashed) hashedNibbles(plainKey []byte) []byte {
+	keyLen := min(len(plainKey), hph.accountKeyLen)
+	nibbles := make([]byte, 64, 128+1) // room for storage part and terminator
+	_ = hashKey(hph.keccak, plainKey[:keyLen], nibbles, 0)
+	if len(plainKey) > keyLen {
+		nibbles = nibbles[:128]
+		_ = hashKey(hph.keccak, plainKey[keyLen:], nibbles[64:], 0)
+	}
+	return nibbles
+}
+
+// appendProofNode checks node against the reference kept by its parent and appends it to the proof
+// unless the node is embedded into the parent.
+func appendProofNode(keccak keccakState, nodes [][]byte, node, ref []byte, first bool) ([][]byte, error) {
+	if len(ref) == length.Hash+1 && ref[0] == 0x80+length.Hash {
+		var h [length.Hash]byte
+		keccak.Reset()
+		keccak.Write(node)
+		keccak.Read(h[:])
+		if !bytes.Equal(h[:], ref[1:]) {
+			return nil, fmt.Errorf("proof node hash mismatch: expected %x, got %x", ref[1:], h)
+		}
+	} else if !bytes.Equal(node, ref) {
+		return nil, fmt.Errorf("embedded proof node mismatch: expected %x, got %x", ref, node)
+	}
+	if len(node) < length.Hash && !first {
+		return nodes, nil
+	}
+	return append(nodes, node), nil
+}
+
+func extensionNode(ext, hash []byte) []byte {
+	return leafNode(hexToCompact(ext), append([]byte{0x80 + length.Hash}, hash...))
+}
+
+// leafNode encodes a two-item trie node, value is expected to be RLP-encoded already.
+func leafNode(compactKey, value []byte) []byte {
+	key := rlpString(compactKey)
+	payloadLen := len(key) + len(value)
+	node := make([]byte, 0, rlp.ListPrefixLen(payloadLen)+payloadLen)
+	node = appendListPrefix(node, payloadLen)
+	node = append(node, key...)
+	return append(node, value...)
+}
+
+func appendListPrefix(buf []byte, payloadLen int) []byte {
+	var prefix [10]byte // EncodeListPrefix uses up to 10 bytes of scratch space for long lists
+	return append(buf, prefix[:rlp.EncodeListPrefix(payloadLen, prefix[:])]...)
+}
+
+func rlpString(s []byte) []byte {
+	buf := make([]byte, rlp.StringLen(s)+9) // EncodeString uses up to 9 bytes of scratch space for long strings
+	return buf[:rlp.EncodeString(s, buf)]
+}
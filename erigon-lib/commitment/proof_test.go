@@ -0,0 +1,218 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package commitment
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/rlp"
+)
+
+func Test_HexPatriciaHashed_GenerateProof(t *testing.T) {
+	ctx := context.Background()
+	ms := NewMockState(t)
+
+	ub := NewUpdateBuilder().
+		Balance("68ee6c0e9cdc73b2b2d52dbd79f19d24fe25e2f9", 4).
+		Balance("18f4dcf2d94402019d5b00f71d5f9d02e4f70e40", 900234).
+		Nonce("18f4dcf2d94402019d5b00f71d5f9d02e4f70e40", 169356).
+		Balance("8e5476fc5990638a4fb0b5fd3f61bb4b5c5f395e", 1233).
+		Storage("8e5476fc5990638a4fb0b5fd3f61bb4b5c5f395e", "24f3a02dc65eda502dbf75919e795458413d3c45b38bb35b51235432707900ed", "0401").
+		Balance("27456647f49ba65e220e86cba9abfc4fc1587b81", 065606).
+		Balance("b13363d527cdc18173c54ac5d4a54af05dbec22e", 4*1e17).
+		Balance("d995768ab23a0a333eb9584df006da740e66f0aa", 5).
+		Balance("eabf041afbb6c6059fbd25eab0d3202db84e842d", 6).
+		CodeHash("eabf041afbb6c6059fbd25eab0d3202db84e842d", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a471").
+		Balance("93fe03620e4d70ea39ab6e8c0e04dd0d83e041f2", 7).
+		Storage("93fe03620e4d70ea39ab6e8c0e04dd0d83e041f2", "de3fea338c95ca16954e80eb603cd81a261ed6e2b10a03d0c86cf953fe8769a4", "060606").
+		Balance("ba7a3b7b095d3370c022ca655c790f0c0ead66f5", 5*1e17).
+		Storage("ba7a3b7b095d3370c022ca655c790f0c0ead66f5", "0fa41642c48ecf8f2059c275353ce4fee173b3a8ce5480f040c4d2901603d14e", "050505")
+	// account with enough storage to have branches and extensions in its storage trie
+	const richAccount = "a8f8d73af90eee32dc9729ce8d5bb762f30d21a4"
+	ub.Balance(richAccount, 9*1e16)
+	for i := 0; i < 40; i++ {
+		ub.Storage(richAccount, fmt.Sprintf("%064x", i), fmt.Sprintf("%04x", 0x100+i*7))
+	}
+	plainKeys, updates := ub.Build()
+	require.NoError(t, ms.applyPlainUpdates(plainKeys, updates))
+
+	hph := NewHexPatriciaHashed(length.Addr, ms, ms.TempDir())
+	rootHash, err := hph.ProcessKeys(ctx, plainKeys, "")
+	require.NoError(t, err)
+
+	absentLoc := common.FromHex("00000000000000000000000000000000000000000000000000000000000000ff")
+	verifyAll := func(t *testing.T, hph *HexPatriciaHashed) {
+		t.Helper()
+		for _, pk := range plainKeys {
+			if len(pk) != length.Addr {
+				continue
+			}
+			var locs [][]byte
+			for _, sk := range plainKeys {
+				if len(sk) == length.Addr+length.Hash && common.BytesToAddress(sk[:length.Addr]) == common.BytesToAddress(pk) {
+					locs = append(locs, sk[length.Addr:])
+				}
+			}
+			locs = append(locs, absentLoc)
+
+			proof, err := hph.GenerateProof(pk, locs)
+			require.NoError(t, err)
+
+			var cell Cell
+			require.NoError(t, ms.GetAccount(pk, &cell))
+			accountLeaf := verifyTestProof(t, rootHash, hph.hashedNibbles(pk), proof.AccountProof)
+			require.NotNil(t, accountLeaf, "account %x", pk)
+			nonce, balance, storageRoot := decodeTestAccount(t, accountLeaf)
+			require.Equal(t, cell.Nonce, nonce)
+			require.Equal(t, cell.Balance, balance)
+			require.Equal(t, proof.StorageHash[:], storageRoot)
+
+			for i, loc := range locs {
+				sk := append(common.Copy(pk), loc...)
+				leaf := verifyTestProof(t, proof.StorageHash[:], hph.hashedNibbles(sk)[64:], proof.StorageProof[i])
+				if i == len(locs)-1 {
+					require.Nil(t, leaf, "absent storage %x", sk)
+					continue
+				}
+				var scell Cell
+				require.NoError(t, ms.GetStorage(sk, &scell))
+				_, dataLen, err := rlp.String(leaf, 0)
+				require.NoError(t, err)
+				require.Equal(t, scell.Storage[:scell.StorageLen], leaf[len(leaf)-dataLen:], "storage %x", sk)
+			}
+		}
+
+		absent := common.FromHex("00000000000000000000000000000000000000ab")
+		proof, err := hph.GenerateProof(absent, [][]byte{absentLoc})
+		require.NoError(t, err)
+		require.Nil(t, verifyTestProof(t, rootHash, hph.hashedNibbles(absent), proof.AccountProof))
+		require.Equal(t, EmptyRootHash, proof.StorageHash[:])
+		require.Empty(t, proof.StorageProof[0])
+	}
+
+	t.Run("after processing", func(t *testing.T) {
+		verifyAll(t, hph)
+	})
+	t.Run("after state restore", func(t *testing.T) {
+		state, err := hph.EncodeCurrentState(nil)
+		require.NoError(t, err)
+		restored := NewHexPatriciaHashed(length.Addr, ms, ms.TempDir())
+		require.NoError(t, restored.SetState(state))
+		restoredRoot, err := restored.RootHash()
+		require.NoError(t, err)
+		require.Equal(t, rootHash, restoredRoot)
+		verifyAll(t, restored)
+	})
+}
+
+// verifyTestProof follows proof nodes from root along hashedKey and returns the leaf value, or nil if proof shows
+// that the key is absent.
+func verifyTestProof(t *testing.T, root []byte, hashedKey []byte, proof [][]byte) []byte {
+	t.Helper()
+	nodes := make(map[common.Hash][]byte, len(proof))
+	keccak := sha3.NewLegacyKeccak256()
+	for _, node := range proof {
+		keccak.Reset()
+		keccak.Write(node)
+		nodes[common.BytesToHash(keccak.Sum(nil))] = node
+	}
+	if common.BytesToHash(root) == common.Hash(EmptyRootHash) {
+		require.Empty(t, proof)
+		return nil
+	}
+	node, ok := nodes[common.BytesToHash(root)]
+	require.True(t, ok, "root node %x is missing", root)
+	for depth := 0; ; {
+		items := decodeTestNode(t, node)
+		var next []byte
+		switch len(items) {
+		case 17:
+			require.Less(t, depth, len(hashedKey))
+			next = items[hashedKey[depth]]
+			depth++
+		case 2:
+			_, keyLen, err := rlp.String(items[0], 0)
+			require.NoError(t, err)
+			key := CompactedKeyToHex(items[0][len(items[0])-keyLen:])
+			if hasTerm(key) {
+				if !equalNibbles(hashedKey[depth:], key[:len(key)-1]) {
+					return nil
+				}
+				_, valLen, err := rlp.String(items[1], 0)
+				require.NoError(t, err)
+				return items[1][len(items[1])-valLen:]
+			}
+			if !equalNibbles(hashedKey[depth:depth+min(len(key), len(hashedKey)-depth)], key) {
+				return nil
+			}
+			next = items[1]
+			depth += len(key)
+		default:
+			t.Fatalf("unexpected node with %d items: %x", len(items), node)
+		}
+		switch {
+		case len(next) == 1 && next[0] == 0x80:
+			return nil
+		case len(next) == length.Hash+1:
+			node, ok = nodes[common.BytesToHash(next[1:])]
+			require.True(t, ok, "node %x is missing", next[1:])
+		default:
+			node = next // embedded node
+		}
+	}
+}
+
+func equalNibbles(a, b []byte) bool {
+	return len(a) == len(b) && string(a) == string(b)
+}
+
+// decodeTestNode splits RLP list into the raw encodings of its items.
+func decodeTestNode(t *testing.T, node []byte) (items [][]byte) {
+	t.Helper()
+	pos, listLen, err := rlp.List(node, 0)
+	require.NoError(t, err)
+	require.Equal(t, len(node), pos+listLen)
+	for pos < len(node) {
+		dataPos, dataLen, _, err := rlp.Prefix(node, pos)
+		require.NoError(t, err)
+		items = append(items, node[pos:dataPos+dataLen])
+		pos = dataPos + dataLen
+	}
+	return items
+}
+
+func decodeTestAccount(t *testing.T, enc []byte) (nonce uint64, balance uint256.Int, storageRoot []byte) {
+	t.Helper()
+	pos, _, err := rlp.List(enc, 0)
+	require.NoError(t, err)
+	pos, nonce, err = rlp.U64(enc, pos)
+	require.NoError(t, err)
+	pos, err = rlp.U256(enc, pos, &balance)
+	require.NoError(t, err)
+	storageRoot = make([]byte, length.Hash)
+	_, err = rlp.ParseHash(enc, pos, storageRoot)
+	require.NoError(t, err)
+	return nonce, balance, storageRoot
+}
@@ -61,6 +61,9 @@ var (
 	OnlyCreateDB          = EnvBool("ONLY_CREATE_DB", false)
 
 	CommitEachStage = EnvBool("COMMIT_EACH_STAGE", false)
+
+	// VMSuperinstructions - run the EVM with pre-analysed basic blocks and fused instructions (vm.Config.Superinstructions)
+	VMSuperinstructions = EnvBool("VM_SUPERINSTRUCTIONS", false)
 )
 
 func ReadMemStats(m *runtime.MemStats) {
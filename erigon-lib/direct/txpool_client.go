@@ -119,3 +119,63 @@ func (s *TxPoolClient) Status(ctx context.Context, in *txpool_proto.StatusReques
 func (s *TxPoolClient) Nonce(ctx context.Context, in *txpool_proto.NonceRequest, opts ...grpc.CallOption) (*txpool_proto.NonceReply, error) {
 	return s.server.Nonce(ctx, in)
 }
+
+func (s *TxPoolClient) TransactionStatus(ctx context.Context, in *txpool_proto.TransactionStatusRequest, opts ...grpc.CallOption) (*txpool_proto.TransactionStatusReply, error) {
+	return s.server.TransactionStatus(ctx, in)
+}
+
+func (s *TxPoolClient) DiscardHistory(ctx context.Context, in *txpool_proto.DiscardHistoryRequest, opts ...grpc.CallOption) (*txpool_proto.DiscardHistoryReply, error) {
+	return s.server.DiscardHistory(ctx, in)
+}
+
+// -- start OnDiscard
+
+func (s *TxPoolClient) OnDiscard(ctx context.Context, in *txpool_proto.OnDiscardRequest, opts ...grpc.CallOption) (txpool_proto.Txpool_OnDiscardClient, error) {
+	ch := make(chan *onDiscardReply, 16384)
+	streamServer := &TxPoolOnDiscardS{ch: ch, ctx: ctx}
+	go func() {
+		defer close(ch)
+		streamServer.Err(s.server.OnDiscard(in, streamServer))
+	}()
+	return &TxPoolOnDiscardC{ch: ch, ctx: ctx}, nil
+}
+
+type onDiscardReply struct {
+	r   *txpool_proto.OnDiscardReply
+	err error
+}
+
+type TxPoolOnDiscardS struct {
+	ch  chan *onDiscardReply
+	ctx context.Context
+	grpc.ServerStream
+}
+
+func (s *TxPoolOnDiscardS) Send(m *txpool_proto.OnDiscardReply) error {
+	s.ch <- &onDiscardReply{r: m}
+	return nil
+}
+func (s *TxPoolOnDiscardS) Context() context.Context { return s.ctx }
+func (s *TxPoolOnDiscardS) Err(err error) {
+	if err == nil {
+		return
+	}
+	s.ch <- &onDiscardReply{err: err}
+}
+
+type TxPoolOnDiscardC struct {
+	ch  chan *onDiscardReply
+	ctx context.Context
+	grpc.ClientStream
+}
+
+func (c *TxPoolOnDiscardC) Recv() (*txpool_proto.OnDiscardReply, error) {
+	m, ok := <-c.ch
+	if !ok || m == nil {
+		return nil, io.EOF
+	}
+	return m.r, m.err
+}
+func (c *TxPoolOnDiscardC) Context() context.Context { return c.ctx }
+
+// -- end OnDiscard
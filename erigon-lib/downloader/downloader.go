@@ -69,8 +69,8 @@ type Downloader struct {
 	db                  kv.RwDB
 	pieceCompletionDB   storage.PieceCompletion
 	torrentClient       *torrent.Client
-	webDownloadClient   *RCloneClient
-	webDownloadSessions map[string]*RCloneSession
+	webDownloadClient   *ObjectStoreClient
+	webDownloadSessions map[string]*ObjectStoreSession
 
 	cfg *downloadercfg.Cfg
 
@@ -339,7 +339,7 @@ func New(ctx context.Context, cfg *downloadercfg.Cfg, logger log.Logger, verbosi
 		torrentFS:           &AtomicTorrentFS{dir: cfg.Dirs.Snap},
 		snapshotLock:        snapLock,
 		webDownloadInfo:     map[string]webDownloadInfo{},
-		webDownloadSessions: map[string]*RCloneSession{},
+		webDownloadSessions: map[string]*ObjectStoreSession{},
 		downloading:         map[string]*downloadInfo{},
 		webseedsDiscover:    discover,
 	}
@@ -866,9 +866,9 @@ func (d *Downloader) mainLoop(silent bool) error {
 		pieceSlots = int(512 * datasize.MB / downloadercfg.DefaultPieceSize)
 	}
 
-	//TODO: feature is not ready yet
-	//d.webDownloadClient, _ = NewRCloneClient(d.logger)
-	d.webDownloadClient = nil
+	if d.cfg.WebDownload {
+		d.webDownloadClient = NewObjectStoreClient(d.logger)
+	}
 
 	d.wg.Add(1)
 	go func() {
@@ -1607,7 +1607,7 @@ func (d *Downloader) torrentDownload(t *torrent.Torrent, statusChan chan downloa
 	}(t)
 }
 
-func (d *Downloader) webDownload(peerUrls []*url.URL, t *torrent.Torrent, i *webDownloadInfo, statusChan chan downloadStatus) (*RCloneSession, error) {
+func (d *Downloader) webDownload(peerUrls []*url.URL, t *torrent.Torrent, i *webDownloadInfo, statusChan chan downloadStatus) (*ObjectStoreSession, error) {
 	if d.webDownloadClient == nil {
 		return nil, errors.New("webdownload client not enabled")
 	}
@@ -58,6 +58,8 @@ type Cfg struct {
 	AddTorrentsFromDisk             bool
 	SnapshotLock                    bool
 	ChainName                       string
+	// WebDownload - download files straight from the webseeds when their torrents make no progress
+	WebDownload bool
 
 	Dirs datadir.Dirs
 
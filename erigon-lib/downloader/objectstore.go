@@ -0,0 +1,964 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spaolacci/murmur3"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+
+	"github.com/erigontech/erigon-lib/common/dir"
+	"github.com/erigontech/erigon-lib/downloader/snaptype"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// RemoteSession syncs files of a local dir with a remote fs. It is implemented by RCloneSession,
+// which needs the rclone binary, and by ObjectStoreSession, which talks to S3 compatible and plain
+// HTTP stores natively.
+type RemoteSession interface {
+	Upload(ctx context.Context, files ...string) error
+	Download(ctx context.Context, files ...string) error
+	Cat(ctx context.Context, file string) (io.ReadCloser, error)
+	ReadLocalDir(ctx context.Context) ([]fs.DirEntry, error)
+	ReadRemoteDir(ctx context.Context, refresh bool) ([]fs.DirEntry, error)
+	LocalFsRoot() string
+	RemoteFsRoot() string
+	Label() string
+	Stop()
+}
+
+var (
+	_ RemoteSession = (*RCloneSession)(nil)
+	_ RemoteSession = (*ObjectStoreSession)(nil)
+)
+
+// IsObjectStoreFs reports whether the remote fs is an s3://bucket/prefix or http(s)://host/path url
+// served by ObjectStoreClient rather than an rclone remote.
+func IsObjectStoreFs(remoteFs string) bool {
+	scheme, _, ok := strings.Cut(remoteFs, "://")
+	return ok && (scheme == "s3" || scheme == "http" || scheme == "https")
+}
+
+// NewRemoteSession opens a session with the object store for urls accepted by IsObjectStoreFs and
+// with rclone for anything else.
+func NewRemoteSession(ctx context.Context, logger log.Logger, localFs string, remoteFs string, headers http.Header) (RemoteSession, error) {
+	if IsObjectStoreFs(remoteFs) {
+		session, err := NewObjectStoreClient(logger).NewSession(ctx, localFs, remoteFs, headers)
+		if err != nil {
+			return nil, err
+		}
+		return session, nil
+	}
+
+	rclone, err := NewRCloneClient(logger)
+	if err != nil {
+		return nil, err
+	}
+	session, err := rclone.NewSession(ctx, localFs, remoteFs, headers)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+const (
+	objectStoreRetries     = 5
+	objectStoreConcurrency = 16
+	objectStoreBurst       = 1024 * 1024
+	// partFileSuffix marks downloads in progress, they are resumed with range requests
+	partFileSuffix = ".part"
+	// validatorFileSuffix marks the ETag or Last-Modified of the remote file a part file is downloaded from,
+	// it is sent as If-Range so a remote file changed in the meantime is downloaded from scratch
+	validatorFileSuffix = ".validator"
+)
+
+// ObjectStoreClient is a native replacement of RCloneClient for S3 compatible stores (s3://bucket/prefix)
+// and plain HTTP servers accepting PUT and range GET requests (http(s)://host/path).
+type ObjectStoreClient struct {
+	// S3 configures access to s3:// remotes, it is read from the environment by NewObjectStoreClient
+	S3 S3Config
+
+	client    *http.Client
+	logger    log.Logger
+	bwLimit   *rate.Limit
+	limiter   *rate.Limiter
+	lock      sync.Mutex
+	transfers map[*transfer]struct{}
+}
+
+// transfer is a download in progress reported by Stats
+type transfer struct {
+	group   string
+	name    string
+	size    int64
+	bytes   atomic.Int64
+	started time.Time
+}
+
+func NewObjectStoreClient(logger log.Logger) *ObjectStoreClient {
+	return &ObjectStoreClient{
+		S3:        S3ConfigFromEnv(),
+		client:    &http.Client{}, // no timeout - transfers of large files take long
+		logger:    logger,
+		limiter:   rate.NewLimiter(rate.Inf, objectStoreBurst),
+		transfers: map[*transfer]struct{}{},
+	}
+}
+
+// Stats reports downloads in progress in the same shape as RCloneClient.Stats.
+func (c *ObjectStoreClient) Stats(ctx context.Context) (*RCloneStats, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var stats RCloneStats
+
+	for t := range c.transfers {
+		bytes := uint64(t.bytes.Load())
+		elapsed := time.Since(t.started).Seconds()
+
+		ts := RCloneTransferStats{
+			Bytes: bytes,
+			Group: t.group,
+			Name:  t.name,
+			Size:  uint64(t.size),
+		}
+
+		if t.size > 0 {
+			ts.Percentage = uint(bytes * 100 / uint64(t.size))
+		}
+
+		if elapsed > 0 {
+			ts.Speed = float64(bytes) / elapsed
+			ts.SpeedAvg = ts.Speed
+		}
+
+		if ts.Speed > 0 && t.size > int64(bytes) {
+			ts.Eta = uint(float64(uint64(t.size)-bytes) / ts.Speed)
+		}
+
+		stats.Bytes += bytes
+		stats.TotalBytes += uint64(t.size)
+		stats.Speed += ts.Speed
+		stats.Transferring = append(stats.Transferring, ts)
+	}
+
+	stats.TotalTransfers = uint(len(stats.Transferring))
+	return &stats, nil
+}
+
+func (c *ObjectStoreClient) startTransfer(group string, name string, size int64, offset int64) *transfer {
+	t := &transfer{group: group, name: name, size: size, started: time.Now()}
+	t.bytes.Store(offset)
+
+	c.lock.Lock()
+	c.transfers[t] = struct{}{}
+	c.lock.Unlock()
+
+	return t
+}
+
+func (c *ObjectStoreClient) endTransfer(t *transfer) {
+	c.lock.Lock()
+	delete(c.transfers, t)
+	c.lock.Unlock()
+}
+
+func (c *ObjectStoreClient) GetBwLimit() rate.Limit {
+	if c.bwLimit != nil {
+		return *c.bwLimit
+	}
+
+	return 0
+}
+
+// SetBwLimit limits the total transfer rate of the client in bytes/sec, zero removes the limit.
+func (c *ObjectStoreClient) SetBwLimit(ctx context.Context, limit rate.Limit) {
+	if c.bwLimit == nil || limit != *c.bwLimit {
+		c.bwLimit = &limit
+		c.logger.Trace("Setting object store bw limit", "bytes", int64(limit))
+
+		if limit <= 0 {
+			c.limiter.SetLimit(rate.Inf)
+		} else {
+			c.limiter.SetLimit(limit)
+		}
+	}
+}
+
+func (c *ObjectStoreClient) NewSession(ctx context.Context, localFs string, remoteFs string, headers http.Header) (*ObjectStoreSession, error) {
+	remoteUrl, err := url.Parse(strings.TrimSuffix(remoteFs, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote fs: %s: %w", remoteFs, err)
+	}
+
+	var store objectStore
+
+	switch remoteUrl.Scheme {
+	case "s3":
+		store, err = newS3Store(c.S3, remoteUrl.Host, strings.Trim(remoteUrl.Path, "/"))
+		if err != nil {
+			return nil, err
+		}
+	case "http", "https":
+		store = &httpStore{base: remoteUrl}
+	default:
+		return nil, fmt.Errorf("unsupported remote fs: %s", remoteFs)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &ObjectStoreSession{
+		ObjectStoreClient: c,
+		ctx:               ctx,
+		cancel:            cancel,
+		store:             store,
+		files:             map[string]*rcloneInfo{},
+		remoteFs:          strings.TrimSuffix(remoteFs, "/"),
+		localFs:           localFs,
+		headers:           headers,
+	}, nil
+}
+
+// objectStore addresses and lists the objects of a remote fs
+type objectStore interface {
+	// url returns the location of the file, an empty file addresses the root of the store
+	url(file string, query url.Values) *url.URL
+	// sign authorizes the request, payloadHash is the hex sha256 of the body or UNSIGNED-PAYLOAD
+	sign(req *http.Request, payloadHash string) error
+	list(ctx context.Context, s *ObjectStoreSession) ([]remoteInfo, error)
+	upload(ctx context.Context, s *ObjectStoreSession, file string, f *os.File, size int64) error
+}
+
+type ObjectStoreSession struct {
+	*ObjectStoreClient
+	sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	store    objectStore
+	files    map[string]*rcloneInfo
+	oplock   sync.Mutex
+	remoteFs string
+	localFs  string
+	headers  http.Header
+}
+
+func (s *ObjectStoreSession) RemoteFsRoot() string {
+	return s.remoteFs
+}
+
+func (s *ObjectStoreSession) LocalFsRoot() string {
+	return s.localFs
+}
+
+func (s *ObjectStoreSession) Stop() {
+	s.cancel()
+}
+
+func (s *ObjectStoreSession) Label() string {
+	return strconv.FormatUint(murmur3.Sum64([]byte(s.localFs+"<->"+s.remoteFs)), 36)
+}
+
+func (s *ObjectStoreSession) ReadLocalDir(ctx context.Context) ([]fs.DirEntry, error) {
+	return dir.ReadDir(s.localFs)
+}
+
+// withSession returns a context which is also canceled when the session is stopped
+func (s *ObjectStoreSession) withSession(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(s.ctx, cancel)
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// ObjectStoreError is an unexpected response of the remote store
+type ObjectStoreError struct {
+	Method     string
+	Url        string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *ObjectStoreError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.Url, e.StatusCode, http.StatusText(e.StatusCode))
+
+	if len(e.Code) > 0 {
+		msg += ": " + e.Code
+	}
+
+	if len(e.Message) > 0 {
+		msg += ": " + e.Message
+	}
+
+	return msg
+}
+
+func (e *ObjectStoreError) Is(target error) bool {
+	switch target {
+	case os.ErrNotExist:
+		return e.StatusCode == http.StatusNotFound
+	case ErrAccessDenied:
+		return e.StatusCode == http.StatusForbidden || e.Code == "AccessDenied"
+	}
+
+	return false
+}
+
+func (e *ObjectStoreError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	e := &ObjectStoreError{
+		Method:     resp.Request.Method,
+		Url:        resp.Request.URL.Redacted(),
+		StatusCode: resp.StatusCode,
+	}
+
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+
+	if err := xml.Unmarshal(body, &s3Err); err == nil {
+		e.Code, e.Message = s3Err.Code, s3Err.Message
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	return e
+}
+
+func isRetryableError(err error) bool {
+	var storeErr *ObjectStoreError
+	if errors.As(err, &storeErr) {
+		return storeErr.retryable()
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || isConnectionError(err)
+}
+
+// body returns a fresh request body for every attempt, so failed requests can be retried
+type body func() (io.Reader, int64, error)
+
+// do sends a request for the file and retries it on connection errors and server side failures, responses
+// with a status other than the accepted ones are returned as *ObjectStoreError.
+func (s *ObjectStoreSession) do(ctx context.Context, method string, file string, query url.Values, header http.Header, body body, accept ...int) (*http.Response, error) {
+	if len(accept) == 0 {
+		accept = []int{http.StatusOK}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := s.send(ctx, method, file, query, header, body)
+
+		if err == nil {
+			if slices.Contains(accept, resp.StatusCode) {
+				return resp, nil
+			}
+
+			err = responseError(resp)
+			resp.Body.Close()
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if attempt >= objectStoreRetries || !isRetryableError(err) {
+			return nil, err
+		}
+
+		delay := time.NewTimer(calcBackoff(200*time.Millisecond, 10*time.Second, attempt+1, resp))
+
+		select {
+		case <-ctx.Done():
+			delay.Stop()
+			return nil, ctx.Err()
+		case <-delay.C:
+		}
+	}
+}
+
+func (s *ObjectStoreSession) send(ctx context.Context, method string, file string, query url.Values, header http.Header, body body) (*http.Response, error) {
+	var reader io.Reader
+	var size int64
+	payloadHash := emptyPayloadHash
+
+	if body != nil {
+		var err error
+		if reader, size, err = body(); err != nil {
+			return nil, err
+		}
+		reader = &limitedReader{ctx: ctx, r: reader, limiter: s.limiter}
+		payloadHash = unsignedPayload
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.store.url(file, query).String(), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+
+	for key, values := range s.headers {
+		req.Header[key] = values
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	if err := s.store.sign(req, payloadHash); err != nil {
+		return nil, err
+	}
+
+	return s.client.Do(req)
+}
+
+// limitedReader applies the bandwidth limit of the client to transfers
+type limitedReader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiter  *rate.Limiter
+	transfer *transfer
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > objectStoreBurst {
+		p = p[:objectStoreBurst]
+	}
+
+	n, err := r.r.Read(p)
+
+	if r.transfer != nil {
+		r.transfer.bytes.Add(int64(n))
+	}
+
+	if n > 0 && r.limiter.Limit() != rate.Inf {
+		if werr := r.limiter.WaitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
+
+// Cat returns the content of the remote file, the caller must close it
+func (s *ObjectStoreSession) Cat(ctx context.Context, file string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, file, nil, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (s *ObjectStoreSession) Upload(ctx context.Context, files ...string) error {
+	ctx, cancel := s.withSession(ctx)
+	defer cancel()
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(objectStoreConcurrency)
+
+	for _, file := range files {
+		file := file
+
+		g.Go(func() error {
+			f, err := os.Open(filepath.Join(s.localFs, file))
+
+			if err != nil {
+				return fmt.Errorf("can't upload: %s: %w", file, err)
+			}
+
+			defer f.Close()
+
+			localInfo, err := f.Stat()
+
+			if err != nil {
+				return fmt.Errorf("can't upload: %s: %w", file, err)
+			}
+
+			if !localInfo.Mode().IsRegular() || localInfo.Size() == 0 {
+				return fmt.Errorf("can't upload: %s: %s", file, "file is not uploadable")
+			}
+
+			if err := s.store.upload(gctx, s, file, f, localInfo.Size()); err != nil {
+				return fmt.Errorf("can't upload: %s: %w", file, err)
+			}
+
+			s.synced(file, localInfo)
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+func (s *ObjectStoreSession) put(ctx context.Context, file string, query url.Values, header http.Header, f *os.File, offset int64, size int64) (*http.Response, error) {
+	return s.do(ctx, http.MethodPut, file, query, header, func() (io.Reader, int64, error) {
+		return io.NewSectionReader(f, offset, size), size, nil
+	}, http.StatusOK, http.StatusCreated, http.StatusNoContent)
+}
+
+func (s *ObjectStoreSession) Download(ctx context.Context, files ...string) error {
+	ctx, cancel := s.withSession(ctx)
+	defer cancel()
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(objectStoreConcurrency)
+
+	for _, file := range files {
+		file := file
+
+		g.Go(func() error {
+			var err error
+
+			// transfers interrupted by network errors are resumed from the received part of the file
+			for attempt := 0; attempt <= objectStoreRetries; attempt++ {
+				if err = s.download(gctx, file); err == nil || gctx.Err() != nil || !isRetryableError(err) {
+					break
+				}
+			}
+
+			if err != nil {
+				return fmt.Errorf("can't download: %s: %w", file, err)
+			}
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// errInvalidRemoteName is returned for remote file names which would resolve outside of the local directory
+var errInvalidRemoteName = errors.New("invalid remote file name")
+
+// isLocalName returns true if the remote file name is a plain file name within the local directory
+func isLocalName(name string) bool {
+	return filepath.IsLocal(name) && !strings.ContainsRune(name, '/') && !strings.ContainsRune(name, filepath.Separator)
+}
+
+func (s *ObjectStoreSession) download(ctx context.Context, file string) error {
+	if !isLocalName(file) {
+		return fmt.Errorf("%w: %q", errInvalidRemoteName, file)
+	}
+
+	path := filepath.Join(s.localFs, file)
+	partPath := path + partFileSuffix
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+
+	if err != nil {
+		return err
+	}
+
+	validatorPath := partPath + validatorFileSuffix
+	validator, _ := os.ReadFile(validatorPath)
+
+	if offset > 0 && len(validator) == 0 {
+		// the part file can't be matched with the remote file
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+
+		if offset, err = f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	var header http.Header
+
+	if offset > 0 {
+		header = http.Header{
+			"Range":    []string{fmt.Sprintf("bytes=%d-", offset)},
+			"If-Range": []string{string(validator)},
+		}
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, file, nil, header, nil,
+		http.StatusOK, http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	size := resp.ContentLength
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// the server ignored the range or the remote file changed, start from scratch
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		offset = 0
+
+	case http.StatusPartialContent:
+		if start, total, ok := parseContentRange(resp.Header.Get("Content-Range")); !ok || start != offset {
+			return fmt.Errorf("unexpected content range: %q, requested from: %d", resp.Header.Get("Content-Range"), offset)
+		} else {
+			size = total - offset
+		}
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the part file is either complete or larger than the remote file
+		if _, total, ok := parseContentRange(resp.Header.Get("Content-Range")); !ok || total != offset {
+			if err := f.Truncate(0); err != nil {
+				return err
+			}
+
+			return fmt.Errorf("part file doesn't match remote: %w", io.ErrUnexpectedEOF)
+		}
+
+		size = 0
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		if err := writeValidator(validatorPath, resp.Header); err != nil {
+			return err
+		}
+	}
+
+	t := s.startTransfer(s.remoteFs, file, offset+max(size, 0), offset)
+	defer s.endTransfer(t)
+
+	written, err := io.Copy(f, &limitedReader{ctx: ctx, r: resp.Body, limiter: s.limiter, transfer: t})
+
+	if err != nil {
+		return err
+	}
+
+	if size >= 0 && written != size {
+		return fmt.Errorf("received %d of %d bytes: %w", written, size, io.ErrUnexpectedEOF)
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(partPath, path); err != nil {
+		return err
+	}
+
+	if err := os.Remove(validatorPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	localInfo, err := os.Stat(path)
+
+	if err != nil {
+		return err
+	}
+
+	s.synced(file, localInfo)
+	return nil
+}
+
+// writeValidator records the ETag or the Last-Modified of the response for the part file, or removes the
+// record if the response has neither: such a part file is downloaded from scratch
+func writeValidator(validatorPath string, header http.Header) error {
+	validator := header.Get("ETag")
+
+	if len(validator) == 0 || strings.HasPrefix(validator, "W/") {
+		// weak ETags can't be used for range requests
+		validator = header.Get("Last-Modified")
+	}
+
+	if len(validator) == 0 {
+		if err := os.Remove(validatorPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	return os.WriteFile(validatorPath, []byte(validator), 0644)
+}
+
+// parseContentRange parses "bytes <start>-<end>/<total>" and "bytes */<total>" values
+func parseContentRange(value string) (start int64, total int64, ok bool) {
+	value, ok = strings.CutPrefix(value, "bytes ")
+
+	if !ok {
+		return 0, 0, false
+	}
+
+	rng, totalStr, ok := strings.Cut(value, "/")
+
+	if !ok {
+		return 0, 0, false
+	}
+
+	total, err := strconv.ParseInt(totalStr, 10, 64)
+
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if rng == "*" {
+		return 0, total, true
+	}
+
+	startStr, _, ok := strings.Cut(rng, "-")
+
+	if !ok {
+		return 0, 0, false
+	}
+
+	if start, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+		return 0, 0, false
+	}
+
+	return start, total, true
+}
+
+// synced records a file which is the same locally and remotely after a transfer
+func (s *ObjectStoreSession) synced(file string, localInfo fs.FileInfo) {
+	s.Lock()
+	info, ok := s.files[file]
+
+	if !ok {
+		info = s.newInfo(file)
+		s.files[file] = info
+	}
+	s.Unlock()
+
+	info.Lock()
+	info.localInfo = localInfo
+	info.remoteInfo = remoteInfo{
+		Name:    file,
+		Size:    uint64(localInfo.Size()),
+		ModTime: localInfo.ModTime(),
+	}
+	info.Unlock()
+}
+
+func (s *ObjectStoreSession) newInfo(file string) *rcloneInfo {
+	info := &rcloneInfo{file: file}
+
+	if snapInfo, isStateFile, ok := snaptype.ParseFileName(s.localFs, file); ok && !isStateFile {
+		info.snapInfo = &snapInfo
+	}
+
+	return info
+}
+
+func (s *ObjectStoreSession) ReadRemoteDir(ctx context.Context, refresh bool) ([]fs.DirEntry, error) {
+	s.oplock.Lock()
+	defer s.oplock.Unlock()
+
+	s.Lock()
+	fileCount := len(s.files)
+	s.Unlock()
+
+	if fileCount == 0 || refresh {
+		ctx, cancel := s.withSession(ctx)
+		defer cancel()
+
+		list, err := s.store.list(ctx, s)
+
+		if err != nil {
+			return nil, fmt.Errorf("can't get remote list: %w", err)
+		}
+
+		s.Lock()
+		for _, fi := range list {
+			localInfo, _ := os.Stat(filepath.Join(s.localFs, fi.Name))
+
+			info, ok := s.files[fi.Name]
+
+			if !ok {
+				info = s.newInfo(fi.Name)
+				s.files[fi.Name] = info
+			}
+
+			info.Lock()
+			info.localInfo = localInfo
+			info.remoteInfo = fi
+			info.Unlock()
+		}
+		s.Unlock()
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	var entries = make([]fs.DirEntry, 0, len(s.files))
+
+	for _, info := range s.files {
+		if info.remoteInfo.Size > 0 {
+			entries = append(entries, &dirEntry{&fileInfo{info}})
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return entries, nil
+}
+
+// httpStore is a plain HTTP server: files are uploaded with PUT, downloaded with range GET
+// and listed in manifest.txt at the root of the store, as webseeds do.
+type httpStore struct {
+	base *url.URL
+}
+
+func (h *httpStore) url(file string, query url.Values) *url.URL {
+	u := *h.base
+
+	if len(file) > 0 {
+		u = *u.JoinPath(file)
+	}
+
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+func (h *httpStore) sign(req *http.Request, payloadHash string) error {
+	return nil
+}
+
+func (h *httpStore) list(ctx context.Context, s *ObjectStoreSession) ([]remoteInfo, error) {
+	resp, err := s.do(ctx, http.MethodGet, "manifest.txt", nil, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var names []string
+
+	scanner := bufio.NewScanner(resp.Body)
+
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); len(name) > 0 {
+			if !isLocalName(name) {
+				return nil, fmt.Errorf("%w in manifest: %q", errInvalidRemoteName, name)
+			}
+			names = append(names, name)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	list := make([]remoteInfo, len(names))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(objectStoreConcurrency)
+
+	for i, name := range names {
+		i, name := i, name
+
+		g.Go(func() error {
+			resp, err := s.do(gctx, http.MethodHead, name, nil, nil, nil)
+
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					// listed but not served, skipped by ReadRemoteDir as empty
+					return nil
+				}
+
+				return err
+			}
+
+			resp.Body.Close()
+
+			modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+			list[i] = remoteInfo{
+				Name:    name,
+				ModTime: modTime,
+			}
+
+			if resp.ContentLength > 0 {
+				list[i].Size = uint64(resp.ContentLength)
+			}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (h *httpStore) upload(ctx context.Context, s *ObjectStoreSession, file string, f *os.File, size int64) error {
+	resp, err := s.put(ctx, file, nil, nil, f, 0, size)
+
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
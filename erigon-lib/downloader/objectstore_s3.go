@@ -0,0 +1,465 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// emptyPayloadHash is the hex sha256 of an empty body
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// unsignedPayload lets uploads stream without hashing the files upfront
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+
+	DefaultS3PartSize = 256 * 1024 * 1024
+	// s3MaxParts is the limit of parts in a multipart upload
+	s3MaxParts = 10000
+)
+
+// S3Config configures access to S3 compatible stores (AWS, R2, MinIO, ...).
+type S3Config struct {
+	// Endpoint is the url of the store, e.g. https://<account>.r2.cloudflarestorage.com. Buckets of custom
+	// endpoints are addressed with path style urls, AWS (the default) gets virtual hosted style ones.
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// PartSize is the size of parts of multipart uploads, files up to this size are uploaded with a single PUT
+	PartSize int64
+}
+
+// S3ConfigFromEnv reads the config from the standard AWS_* environment variables.
+func S3ConfigFromEnv() S3Config {
+	cfg := S3Config{
+		Endpoint:        os.Getenv("AWS_ENDPOINT_URL_S3"),
+		Region:          os.Getenv("AWS_REGION"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if len(cfg.Endpoint) == 0 {
+		cfg.Endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+
+	if len(cfg.Region) == 0 {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	return cfg
+}
+
+type s3Store struct {
+	cfg       S3Config
+	endpoint  *url.URL
+	pathStyle bool
+	bucket    string
+	prefix    string
+}
+
+func newS3Store(cfg S3Config, bucket string, prefix string) (*s3Store, error) {
+	if len(bucket) == 0 {
+		return nil, errors.New("s3 bucket undefined")
+	}
+
+	if len(cfg.Region) == 0 {
+		// also accepted by R2 and most other S3 compatible stores
+		cfg.Region = "us-east-1"
+	}
+
+	if cfg.PartSize <= 0 {
+		cfg.PartSize = DefaultS3PartSize
+	}
+
+	s := &s3Store{cfg: cfg, bucket: bucket, prefix: prefix}
+
+	if len(cfg.Endpoint) > 0 {
+		endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid s3 endpoint: %s: %w", cfg.Endpoint, err)
+		}
+		s.endpoint, s.pathStyle = endpoint, true
+	} else {
+		s.endpoint = &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, cfg.Region)}
+	}
+
+	return s, nil
+}
+
+func (s *s3Store) key(file string) string {
+	if len(s.prefix) == 0 {
+		return file
+	}
+
+	return s.prefix + "/" + file
+}
+
+func (s *s3Store) url(file string, query url.Values) *url.URL {
+	u := *s.endpoint
+
+	path := u.Path
+
+	if s.pathStyle {
+		path += "/" + s.bucket
+	}
+
+	path += "/"
+
+	if len(file) > 0 {
+		path += s.key(file)
+	}
+
+	u.Path = path
+	u.RawPath = s3Escape(path, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+func (s *s3Store) sign(req *http.Request, payloadHash string) error {
+	if len(s.cfg.AccessKeyID) == 0 {
+		// anonymous access to public buckets
+		return nil
+	}
+
+	signV4(req, s.cfg, payloadHash, time.Now())
+	return nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         uint64    `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+func (s *s3Store) list(ctx context.Context, session *ObjectStoreSession) ([]remoteInfo, error) {
+	prefix := s.key("")
+
+	query := url.Values{
+		"list-type": []string{"2"},
+		"delimiter": []string{"/"},
+	}
+
+	if len(prefix) > 0 {
+		query.Set("prefix", prefix)
+	}
+
+	var list []remoteInfo
+
+	for {
+		resp, err := session.do(ctx, http.MethodGet, "", query, nil, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("can't decode remote list: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			if name := strings.TrimPrefix(object.Key, prefix); len(name) > 0 {
+				if !isLocalName(name) {
+					return nil, fmt.Errorf("%w: %q", errInvalidRemoteName, name)
+				}
+				list = append(list, remoteInfo{
+					Name:    name,
+					Size:    object.Size,
+					ModTime: object.LastModified,
+				})
+			}
+		}
+
+		if !result.IsTruncated || len(result.NextContinuationToken) == 0 {
+			return list, nil
+		}
+
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *s3Store) upload(ctx context.Context, session *ObjectStoreSession, file string, f *os.File, size int64) error {
+	partSize := s.cfg.PartSize
+
+	if size <= partSize {
+		resp, err := session.put(ctx, file, nil, nil, f, 0, size)
+
+		if err != nil {
+			return err
+		}
+
+		return resp.Body.Close()
+	}
+
+	if parts := (size + partSize - 1) / partSize; parts > s3MaxParts {
+		partSize = (size + s3MaxParts - 1) / s3MaxParts
+	}
+
+	resp, err := session.do(ctx, http.MethodPost, file, url.Values{"uploads": []string{""}}, nil, nil)
+
+	if err != nil {
+		return err
+	}
+
+	var initiated struct {
+		UploadId string `xml:"UploadId"`
+	}
+
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	resp.Body.Close()
+
+	if err != nil {
+		return fmt.Errorf("can't decode multipart upload: %w", err)
+	}
+
+	uploadId := url.Values{"uploadId": []string{initiated.UploadId}}
+
+	err = func() error {
+		var completed struct {
+			XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+			Parts   []completedPart `xml:"Part"`
+		}
+
+		for offset, partNumber := int64(0), 1; offset < size; offset, partNumber = offset+partSize, partNumber+1 {
+			query := url.Values{
+				"partNumber": []string{strconv.Itoa(partNumber)},
+				"uploadId":   []string{initiated.UploadId},
+			}
+
+			resp, err := session.put(ctx, file, query, nil, f, offset, min(partSize, size-offset))
+
+			if err != nil {
+				return fmt.Errorf("part %d: %w", partNumber, err)
+			}
+
+			resp.Body.Close()
+
+			completed.Parts = append(completed.Parts, completedPart{partNumber, resp.Header.Get("ETag")})
+		}
+
+		body, err := xml.Marshal(completed)
+
+		if err != nil {
+			return err
+		}
+
+		resp, err := session.do(ctx, http.MethodPost, file, uploadId, nil, func() (io.Reader, int64, error) {
+			return bytes.NewReader(body), int64(len(body)), nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		defer resp.Body.Close()
+
+		// completion errors may come with 200 OK as the response is sent before the parts are assembled
+		respBody, err := io.ReadAll(resp.Body)
+
+		if err != nil {
+			return err
+		}
+
+		var result struct {
+			XMLName xml.Name
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+
+		if err := xml.Unmarshal(respBody, &result); err == nil && result.XMLName.Local == "Error" {
+			return &ObjectStoreError{
+				Method:     http.MethodPost,
+				Url:        resp.Request.URL.Redacted(),
+				StatusCode: resp.StatusCode,
+				Code:       result.Code,
+				Message:    result.Message,
+			}
+		}
+
+		return nil
+	}()
+
+	if err != nil {
+		if resp, abortErr := session.do(context.WithoutCancel(ctx), http.MethodDelete, file, uploadId, nil, nil, http.StatusNoContent, http.StatusOK); abortErr == nil {
+			resp.Body.Close()
+		} else {
+			session.logger.Debug("[downloader] can't abort multipart upload", "file", file, "err", abortErr)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// signV4 adds AWS Signature Version 4 authorization headers to the request, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func signV4(req *http.Request, cfg S3Config, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	if len(cfg.SessionToken) > 0 {
+		req.Header.Set("X-Amz-Security-Token", cfg.SessionToken)
+	}
+
+	host := req.Host
+
+	if len(host) == 0 {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+
+	for key, values := range req.Header {
+		key = strings.ToLower(key)
+
+		if strings.HasPrefix(key, "x-amz-") || key == "range" || key == "content-type" || key == "content-md5" {
+			trimmed := make([]string, len(values))
+
+			for i, value := range values {
+				trimmed[i] = strings.Join(strings.Fields(value), " ")
+			}
+
+			headers[key] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	var canonicalHeaders strings.Builder
+
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3Escape(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + cfg.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery encodes the query sorted by keys and values with s3Escape, the way requests are signed
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	var parts []string
+
+	for _, key := range keys {
+		values := slices.Clone(query[key])
+		slices.Sort(values)
+
+		for _, value := range values {
+			parts = append(parts, s3Escape(key, true)+"="+s3Escape(value, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything but unreserved characters (and slashes unless encodeSlash is set)
+func s3Escape(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+
+	return b.String()
+}
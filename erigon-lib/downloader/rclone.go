@@ -592,7 +592,8 @@ func (c *RCloneSession) Download(ctx context.Context, files ...string) error {
 	return <-cerr
 }
 
-func (c *RCloneSession) Cat(ctx context.Context, file string) (io.Reader, error) {
+// Cat returns the content of the remote file, the caller must close it
+func (c *RCloneSession) Cat(ctx context.Context, file string) (io.ReadCloser, error) {
 	rclone, err := exec.LookPath("rclone")
 
 	if err != nil {
@@ -611,7 +612,23 @@ func (c *RCloneSession) Cat(ctx context.Context, file string) (io.Reader, error)
 		return nil, err
 	}
 
-	return stdout, nil
+	return &catReader{stdout, cmd}, nil
+}
+
+// catReader waits for rclone cat to exit when it is closed
+type catReader struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (r *catReader) Close() error {
+	err := r.ReadCloser.Close()
+
+	if werr := r.cmd.Wait(); err == nil {
+		err = werr
+	}
+
+	return err
 }
 
 func (c *RCloneSession) ReadLocalDir(ctx context.Context) ([]fs.DirEntry, error) {
@@ -21,15 +21,18 @@ import (
 const _ = grpc.SupportPackageIsVersion8
 
 const (
-	Txpool_Version_FullMethodName      = "/txpool.Txpool/Version"
-	Txpool_FindUnknown_FullMethodName  = "/txpool.Txpool/FindUnknown"
-	Txpool_Add_FullMethodName          = "/txpool.Txpool/Add"
-	Txpool_Transactions_FullMethodName = "/txpool.Txpool/Transactions"
-	Txpool_All_FullMethodName          = "/txpool.Txpool/All"
-	Txpool_Pending_FullMethodName      = "/txpool.Txpool/Pending"
-	Txpool_OnAdd_FullMethodName        = "/txpool.Txpool/OnAdd"
-	Txpool_Status_FullMethodName       = "/txpool.Txpool/Status"
-	Txpool_Nonce_FullMethodName        = "/txpool.Txpool/Nonce"
+	Txpool_Version_FullMethodName           = "/txpool.Txpool/Version"
+	Txpool_FindUnknown_FullMethodName       = "/txpool.Txpool/FindUnknown"
+	Txpool_Add_FullMethodName               = "/txpool.Txpool/Add"
+	Txpool_Transactions_FullMethodName      = "/txpool.Txpool/Transactions"
+	Txpool_All_FullMethodName               = "/txpool.Txpool/All"
+	Txpool_Pending_FullMethodName           = "/txpool.Txpool/Pending"
+	Txpool_OnAdd_FullMethodName             = "/txpool.Txpool/OnAdd"
+	Txpool_Status_FullMethodName            = "/txpool.Txpool/Status"
+	Txpool_Nonce_FullMethodName             = "/txpool.Txpool/Nonce"
+	Txpool_TransactionStatus_FullMethodName = "/txpool.Txpool/TransactionStatus"
+	Txpool_DiscardHistory_FullMethodName    = "/txpool.Txpool/DiscardHistory"
+	Txpool_OnDiscard_FullMethodName         = "/txpool.Txpool/OnDiscard"
 )
 
 // TxpoolClient is the client API for Txpool service.
@@ -55,6 +58,12 @@ type TxpoolClient interface {
 	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusReply, error)
 	// returns nonce for given account
 	Nonce(ctx context.Context, in *NonceRequest, opts ...grpc.CallOption) (*NonceReply, error)
+	// returns the sub-pool of the transaction, or why it was discarded
+	TransactionStatus(ctx context.Context, in *TransactionStatusRequest, opts ...grpc.CallOption) (*TransactionStatusReply, error)
+	// returns persisted discard and replacement events, oldest first
+	DiscardHistory(ctx context.Context, in *DiscardHistoryRequest, opts ...grpc.CallOption) (*DiscardHistoryReply, error)
+	// subscribe to discard and replacement events
+	OnDiscard(ctx context.Context, in *OnDiscardRequest, opts ...grpc.CallOption) (Txpool_OnDiscardClient, error)
 }
 
 type txpoolClient struct {
@@ -178,6 +187,59 @@ func (c *txpoolClient) Nonce(ctx context.Context, in *NonceRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *txpoolClient) TransactionStatus(ctx context.Context, in *TransactionStatusRequest, opts ...grpc.CallOption) (*TransactionStatusReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransactionStatusReply)
+	err := c.cc.Invoke(ctx, Txpool_TransactionStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *txpoolClient) DiscardHistory(ctx context.Context, in *DiscardHistoryRequest, opts ...grpc.CallOption) (*DiscardHistoryReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DiscardHistoryReply)
+	err := c.cc.Invoke(ctx, Txpool_DiscardHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *txpoolClient) OnDiscard(ctx context.Context, in *OnDiscardRequest, opts ...grpc.CallOption) (Txpool_OnDiscardClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Txpool_ServiceDesc.Streams[1], Txpool_OnDiscard_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &txpoolOnDiscardClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Txpool_OnDiscardClient interface {
+	Recv() (*OnDiscardReply, error)
+	grpc.ClientStream
+}
+
+type txpoolOnDiscardClient struct {
+	grpc.ClientStream
+}
+
+func (x *txpoolOnDiscardClient) Recv() (*OnDiscardReply, error) {
+	m := new(OnDiscardReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // TxpoolServer is the server API for Txpool service.
 // All implementations must embed UnimplementedTxpoolServer
 // for forward compatibility
@@ -201,6 +263,12 @@ type TxpoolServer interface {
 	Status(context.Context, *StatusRequest) (*StatusReply, error)
 	// returns nonce for given account
 	Nonce(context.Context, *NonceRequest) (*NonceReply, error)
+	// returns the sub-pool of the transaction, or why it was discarded
+	TransactionStatus(context.Context, *TransactionStatusRequest) (*TransactionStatusReply, error)
+	// returns persisted discard and replacement events, oldest first
+	DiscardHistory(context.Context, *DiscardHistoryRequest) (*DiscardHistoryReply, error)
+	// subscribe to discard and replacement events
+	OnDiscard(*OnDiscardRequest, Txpool_OnDiscardServer) error
 	mustEmbedUnimplementedTxpoolServer()
 }
 
@@ -235,6 +303,15 @@ func (UnimplementedTxpoolServer) Status(context.Context, *StatusRequest) (*Statu
 func (UnimplementedTxpoolServer) Nonce(context.Context, *NonceRequest) (*NonceReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Nonce not implemented")
 }
+func (UnimplementedTxpoolServer) TransactionStatus(context.Context, *TransactionStatusRequest) (*TransactionStatusReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransactionStatus not implemented")
+}
+func (UnimplementedTxpoolServer) DiscardHistory(context.Context, *DiscardHistoryRequest) (*DiscardHistoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiscardHistory not implemented")
+}
+func (UnimplementedTxpoolServer) OnDiscard(*OnDiscardRequest, Txpool_OnDiscardServer) error {
+	return status.Errorf(codes.Unimplemented, "method OnDiscard not implemented")
+}
 func (UnimplementedTxpoolServer) mustEmbedUnimplementedTxpoolServer() {}
 
 // UnsafeTxpoolServer may be embedded to opt out of forward compatibility for this service.
@@ -413,6 +490,63 @@ func _Txpool_Nonce_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Txpool_TransactionStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TxpoolServer).TransactionStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Txpool_TransactionStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TxpoolServer).TransactionStatus(ctx, req.(*TransactionStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Txpool_DiscardHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiscardHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TxpoolServer).DiscardHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Txpool_DiscardHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TxpoolServer).DiscardHistory(ctx, req.(*DiscardHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Txpool_OnDiscard_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(OnDiscardRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TxpoolServer).OnDiscard(m, &txpoolOnDiscardServer{ServerStream: stream})
+}
+
+type Txpool_OnDiscardServer interface {
+	Send(*OnDiscardReply) error
+	grpc.ServerStream
+}
+
+type txpoolOnDiscardServer struct {
+	grpc.ServerStream
+}
+
+func (x *txpoolOnDiscardServer) Send(m *OnDiscardReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Txpool_ServiceDesc is the grpc.ServiceDesc for Txpool service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -452,6 +586,14 @@ var Txpool_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Nonce",
 			Handler:    _Txpool_Nonce_Handler,
 		},
+		{
+			MethodName: "TransactionStatus",
+			Handler:    _Txpool_TransactionStatus_Handler,
+		},
+		{
+			MethodName: "DiscardHistory",
+			Handler:    _Txpool_DiscardHistory_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -459,6 +601,11 @@ var Txpool_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Txpool_OnAdd_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "OnDiscard",
+			Handler:       _Txpool_OnDiscard_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "txpool/txpool.proto",
 }
@@ -40,29 +40,45 @@ import (
 )
 
 func OpenPair(from, to string, label kv.Label, targetPageSize datasize.ByteSize, logger log.Logger) (kv.RoDB, kv.RwDB) {
+	src, dst, err := openPair(context.Background(), from, to, label, targetPageSize, logger)
+	if err != nil {
+		panic(err)
+	}
+	return src, dst
+}
+
+func openPair(ctx context.Context, from, to string, label kv.Label, targetPageSize datasize.ByteSize, logger log.Logger) (kv.RoDB, kv.RwDB, error) {
 	const ThreadsHardLimit = 9_000
-	src := mdbx2.NewMDBX(logger).Path(from).
+	src, err := mdbx2.NewMDBX(logger).Path(from).
 		Label(label).
 		RoTxsLimiter(semaphore.NewWeighted(ThreadsHardLimit)).
 		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg { return kv.TablesCfgByLabel(label) }).
 		Flags(func(flags uint) uint { return flags | mdbx.Accede }).
-		MustOpen()
+		Open(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 	if targetPageSize <= 0 {
 		targetPageSize = datasize.ByteSize(src.PageSize())
 	}
 	info, err := src.(*mdbx2.MdbxKV).Env().Info(nil)
 	if err != nil {
-		panic(err)
+		src.Close()
+		return nil, nil, err
 	}
-	dst := mdbx2.NewMDBX(logger).Path(to).
+	dst, err := mdbx2.NewMDBX(logger).Path(to).
 		Label(label).
 		PageSize(targetPageSize.Bytes()).
 		MapSize(datasize.ByteSize(info.Geo.Upper)).
 		GrowthStep(4 * datasize.GB).
 		Flags(func(flags uint) uint { return flags | mdbx.WriteMap }).
 		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg { return kv.TablesCfgByLabel(label) }).
-		MustOpen()
-	return src, dst
+		Open(ctx)
+	if err != nil {
+		src.Close()
+		return nil, nil, err
+	}
+	return src, dst, nil
 }
 
 func Kv2kv(ctx context.Context, src kv.RoDB, dst kv.RwDB, tables []string, readAheadThreads int, logger log.Logger) error {
@@ -71,7 +87,11 @@ func Kv2kv(ctx context.Context, src kv.RoDB, dst kv.RwDB, tables []string, readA
 		return err1
 	}
 	defer srcTx.Rollback()
+	return Kv2kvTx(ctx, src, srcTx, dst, tables, readAheadThreads, logger)
+}
 
+// Kv2kvTx - same as Kv2kv, but copies the view of given srcTx. Allows backup of db consistent with other data (files) captured after srcTx start.
+func Kv2kvTx(ctx context.Context, src kv.RoDB, srcTx kv.Tx, dst kv.RwDB, tables []string, readAheadThreads int, logger log.Logger) error {
 	commitEvery := time.NewTicker(5 * time.Minute)
 	defer commitEvery.Stop()
 	logEvery := time.NewTicker(20 * time.Second)
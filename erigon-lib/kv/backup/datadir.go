@@ -0,0 +1,598 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/kv"
+	mdbx2 "github.com/erigontech/erigon-lib/kv/mdbx"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// Datadir backup: chaindata is copied table by table in one read transaction (the node may keep running),
+// files of datadir/snapshots are listed and opened after the start of that transaction - so they cover all
+// data pruned from chaindata by then, and are readable even if the node merges them away during the copy.
+// Everything is described by a manifest with sha256 of all files, which restore validates before writing anything.
+// Consensus DBs (clique/aura/bor snapshots of signers/validators) are copied after chaindata: they are keyed by block
+// hash and rebuilt from headers when missing, so being a bit ahead of chaindata is harmless.
+// Caplin DBs (datadir/caplin) are not backed up: caplin indexes and blobs are re-synced from the beacon network.
+
+const (
+	ManifestFileName = "backup.json"
+	manifestVersion  = 1
+	// snapshotsOpenAttempts - files listed, but deleted by merge before open, need a fresh listing
+	snapshotsOpenAttempts = 16
+)
+
+// consensusDBs - names of dirs in datadir which node.OpenDatabase uses for kv.ConsensusDB
+var consensusDBs = []string{"clique", "aura", "bor"}
+
+var ErrBackupMismatch = errors.New("backup doesn't match its manifest")
+
+// FileEntry - file of backup. Path is slash-separated and relative to both: datadir and backup dir.
+type FileEntry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Sha256  string    `json:"sha256"`
+	// Base - dir of an earlier backup which stores the file. Set for files incremental backup took from the previous one.
+	Base string `json:"base,omitempty"`
+}
+
+func (e FileEntry) location(backupDir string) string {
+	if len(e.Base) > 0 {
+		backupDir = e.Base
+	}
+	return filepath.Join(backupDir, filepath.FromSlash(e.Path))
+}
+
+type Manifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	// Previous - backup dir incremental backup is based on
+	Previous  string      `json:"previous,omitempty"`
+	Chaindata []FileEntry `json:"chaindata"`
+	Consensus []FileEntry `json:"consensus,omitempty"`
+	Snapshots []FileEntry `json:"snapshots"`
+}
+
+// entries - all files of backup: databases first, then snapshots
+func (m *Manifest) entries() []FileEntry {
+	return append(append(append([]FileEntry{}, m.Chaindata...), m.Consensus...), m.Snapshots...)
+}
+
+func ReadManifest(backupDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(backupDir, ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBackupMismatch, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("%w: unsupported manifest version %d", ErrBackupMismatch, m.Version)
+	}
+	for _, e := range m.entries() {
+		// restore writes to datadir/path: path must not escape it
+		if !filepath.IsLocal(filepath.FromSlash(e.Path)) {
+			return nil, fmt.Errorf("%w: %s: path outside of datadir", ErrBackupMismatch, e.Path)
+		}
+	}
+	return &m, nil
+}
+
+func writeManifest(backupDir string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	// manifest is written last and atomically: backup without it is incomplete
+	tmpPath := filepath.Join(backupDir, ManifestFileName+".tmp")
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filepath.Join(backupDir, ManifestFileName))
+}
+
+type DatadirCfg struct {
+	// Previous - dir of previous backup. Makes backup incremental: snapshot files stored there are not copied again.
+	Previous string
+	// Link - hard-link snapshot files instead of copying (backup dir must be on same filesystem). Fallback to copy if can't.
+	Link             bool
+	PageSize         datasize.ByteSize
+	ReadAheadThreads int
+	Workers          int
+}
+
+type snapshotFile struct {
+	path string // relative to datadir, slash-separated
+	f    *os.File
+	info fs.FileInfo
+}
+
+// Datadir - makes online backup of chaindata, consensus DBs and snapshot files of dirs to the new dir `to`.
+func Datadir(ctx context.Context, dirs datadir.Dirs, to string, cfg DatadirCfg, logger log.Logger) (*Manifest, error) {
+	to, err := filepath.Abs(to)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNoFiles(to); err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{Version: manifestVersion, Created: time.Now().UTC()}
+
+	previous := map[string]FileEntry{}
+	if len(cfg.Previous) > 0 {
+		if m.Previous, err = filepath.Abs(cfg.Previous); err != nil {
+			return nil, err
+		}
+		prev, err := ReadManifest(m.Previous)
+		if err != nil {
+			return nil, fmt.Errorf("previous backup: %w", err)
+		}
+		for _, e := range prev.Snapshots {
+			if len(e.Base) == 0 {
+				e.Base = m.Previous
+			}
+			previous[e.Path] = e
+		}
+	}
+
+	chaindataRel, err := filepath.Rel(dirs.DataDir, dirs.Chaindata)
+	if err != nil {
+		return nil, err
+	}
+	toChaindata := filepath.Join(to, chaindataRel)
+	if err := os.MkdirAll(toChaindata, 0740); err != nil {
+		return nil, err
+	}
+
+	src, dst, err := openPair(ctx, dirs.Chaindata, toChaindata, kv.ChainDB, cfg.PageSize, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	defer dst.Close()
+
+	srcTx, err := src.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer srcTx.Rollback()
+
+	files, err := openSnapshotFiles(dirs)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, sf := range files {
+			sf.f.Close()
+		}
+	}()
+	logger.Info("[backup] start", "to", to, "snapshot_files", len(files), "incremental", len(m.Previous) > 0)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// files are copied in background, db - by the goroutine owning srcTx
+	m.Snapshots = make([]FileEntry, len(files))
+	var copied atomic.Int64
+	filesDone := make(chan error, 1)
+	go func() {
+		filesDone <- forEach(ctx, len(files), cfg.Workers, "[backup] snapshots", logger, func(ctx context.Context, i int) error {
+			sf := files[i]
+			if prev, ok := previous[sf.path]; ok && prev.Size == sf.info.Size() && prev.ModTime.Equal(sf.info.ModTime()) {
+				m.Snapshots[i] = prev
+				return nil
+			}
+			hash, err := storeFile(ctx, sf, filepath.Join(to, filepath.FromSlash(sf.path)), cfg.Link)
+			if err != nil {
+				return fmt.Errorf("%s: %w", sf.path, err)
+			}
+			copied.Add(1)
+			m.Snapshots[i] = FileEntry{Path: sf.path, Size: sf.info.Size(), ModTime: sf.info.ModTime(), Sha256: hash}
+			return nil
+		})
+	}()
+
+	if err := Kv2kvTx(ctx, src, srcTx, dst, nil, cfg.ReadAheadThreads, logger); err != nil {
+		cancel()
+		<-filesDone
+		return nil, err
+	}
+	srcTx.Rollback()
+	dst.Close()
+	entry, err := shrinkDB(ctx, to, chaindataRel, kv.ChainDB, logger)
+	if err != nil {
+		cancel()
+		<-filesDone
+		return nil, err
+	}
+	m.Chaindata = []FileEntry{entry}
+
+	for _, name := range consensusDBs {
+		entry, err := backupConsensusDB(ctx, dirs, to, name, cfg, logger)
+		if err != nil {
+			cancel()
+			<-filesDone
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if entry != nil {
+			m.Consensus = append(m.Consensus, *entry)
+		}
+	}
+
+	if err := <-filesDone; err != nil {
+		return nil, err
+	}
+
+	if err := writeManifest(to, m); err != nil {
+		return nil, err
+	}
+	logger.Info("[backup] done", "to", to, "snapshot_files", len(files), "copied", copied.Load())
+	return m, nil
+}
+
+// backupConsensusDB - copies datadir/name consensus DB if the node has one. Returns nil entry if it doesn't.
+func backupConsensusDB(ctx context.Context, dirs datadir.Dirs, to, name string, cfg DatadirCfg, logger log.Logger) (*FileEntry, error) {
+	from := filepath.Join(dirs.DataDir, name)
+	if _, err := os.Stat(filepath.Join(from, "mdbx.dat")); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(to, name), 0740); err != nil {
+		return nil, err
+	}
+	src, dst, err := openPair(ctx, from, filepath.Join(to, name), kv.ConsensusDB, cfg.PageSize, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	defer dst.Close()
+	if err := Kv2kv(ctx, src, dst, nil, cfg.ReadAheadThreads, logger); err != nil {
+		return nil, err
+	}
+	dst.Close()
+	entry, err := shrinkDB(ctx, to, name, kv.ConsensusDB, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// shrinkDB - backup DB grew by big steps: re-open it with default geometry to shrink the file to used size,
+// then hash/restore don't read empty tail. rel - dir of DB relative to backup dir `to`.
+func shrinkDB(ctx context.Context, to, rel string, label kv.Label, logger log.Logger) (FileEntry, error) {
+	db, err := mdbx2.NewMDBX(logger).Path(filepath.Join(to, rel)).Label(label).
+		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg { return kv.TablesCfgByLabel(label) }).
+		Open(ctx)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	db.Close()
+	file := filepath.ToSlash(filepath.Join(rel, "mdbx.dat"))
+	return hashEntry(filepath.Join(to, filepath.FromSlash(file)), file)
+}
+
+// openSnapshotFiles - lists and opens all immutable files of datadir/snapshots. Open files stay readable after merge deletes them.
+func openSnapshotFiles(dirs datadir.Dirs) ([]*snapshotFile, error) {
+	for attempt := 1; ; attempt++ {
+		files, err := tryOpenSnapshotFiles(dirs)
+		if err == nil {
+			return files, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) || attempt >= snapshotsOpenAttempts {
+			return nil, err
+		}
+		// listed file was merged away: next listing has the merged one
+	}
+}
+
+func tryOpenSnapshotFiles(dirs datadir.Dirs) (files []*snapshotFile, err error) {
+	defer func() {
+		if err != nil {
+			for _, sf := range files {
+				sf.f.Close()
+			}
+		}
+	}()
+	// not immutable: downloader's db of older datadirs
+	skipDir := filepath.Join(dirs.Snap, "db")
+	err = filepath.WalkDir(dirs.Snap, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == skipDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || isTmpFile(d.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(dirs.DataDir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return err
+		}
+		files = append(files, &snapshotFile{path: filepath.ToSlash(rel), f: f, info: info})
+		return nil
+	})
+	return files, err
+}
+
+func isTmpFile(name string) bool {
+	for _, suffix := range []string{".tmp", ".part", ".lock", ".lck"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkNoFiles(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return fmt.Errorf("dir is not empty: %s", path)
+		}
+		return nil
+	})
+}
+
+// storeFile - puts the file to the backup by hard-link or copy, returns sha256 of its content
+func storeFile(ctx context.Context, sf *snapshotFile, to string, link bool) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(to), 0740); err != nil {
+		return "", err
+	}
+	r := io.NewSectionReader(sf.f, 0, sf.info.Size())
+	if link {
+		if err := os.Link(sf.f.Name(), to); err == nil {
+			return hashReader(ctx, r)
+		}
+	}
+	return copyFile(ctx, r, to)
+}
+
+func copyFile(ctx context.Context, r io.Reader, to string) (string, error) {
+	f, err := os.OpenFile(to, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), &ctxReader{ctx, r}); err != nil {
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashReader(ctx context.Context, r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, &ctxReader{ctx, r}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashEntry(path, rel string) (FileEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return FileEntry{}, err
+	}
+	hash, err := hashReader(context.Background(), f)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	return FileEntry{Path: rel, Size: info.Size(), ModTime: info.ModTime(), Sha256: hash}, nil
+}
+
+// ctxReader - makes copy of big files cancelable
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// Verify - checks that all files of backup (including files of earlier backups incremental one refers to) are in place and match manifest.
+func Verify(ctx context.Context, backupDir string, workers int, logger log.Logger) (*Manifest, error) {
+	m, err := ReadManifest(backupDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Chaindata) == 0 {
+		return nil, fmt.Errorf("%w: no chaindata", ErrBackupMismatch)
+	}
+	entries := m.entries()
+	err = forEach(ctx, len(entries), workers, "[backup] verify", logger, func(ctx context.Context, i int) error {
+		e := entries[i]
+		f, err := os.Open(e.location(backupDir))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("%w: %s: missing at %s", ErrBackupMismatch, e.Path, e.location(backupDir))
+			}
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if info.Size() != e.Size {
+			return fmt.Errorf("%w: %s: size %d, expected %d", ErrBackupMismatch, e.Path, info.Size(), e.Size)
+		}
+		hash, err := hashReader(ctx, f)
+		if err != nil {
+			return err
+		}
+		if hash != e.Sha256 {
+			return fmt.Errorf("%w: %s: sha256 %s, expected %s", ErrBackupMismatch, e.Path, hash, e.Sha256)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type RestoreCfg struct {
+	// Link - hard-link snapshot files instead of copying. Databases are always copied: node will modify them.
+	Link    bool
+	Workers int
+}
+
+// Restore - verifies the backup and restores it to the datadir, which must have no chaindata and snapshot files.
+func Restore(ctx context.Context, backupDir string, dirs datadir.Dirs, cfg RestoreCfg, logger log.Logger) error {
+	backupDir, err := filepath.Abs(backupDir)
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{dirs.Chaindata, dirs.Snap} {
+		if err := checkNoFiles(dir); err != nil {
+			return fmt.Errorf("can't restore to datadir with data: %w", err)
+		}
+	}
+	for _, name := range consensusDBs {
+		if err := checkNoFiles(filepath.Join(dirs.DataDir, name)); err != nil {
+			return fmt.Errorf("can't restore to datadir with data: %w", err)
+		}
+	}
+
+	m, err := Verify(ctx, backupDir, cfg.Workers, logger)
+	if err != nil {
+		return err
+	}
+	logger.Info("[backup] restore", "from", backupDir, "created", m.Created, "datadir", dirs.DataDir)
+
+	entries := m.entries()
+	err = forEach(ctx, len(entries), cfg.Workers, "[backup] restore", logger, func(ctx context.Context, i int) error {
+		e := entries[i]
+		to := filepath.Join(dirs.DataDir, filepath.FromSlash(e.Path))
+		if err := os.MkdirAll(filepath.Dir(to), 0740); err != nil {
+			return err
+		}
+		if cfg.Link && i >= len(m.Chaindata)+len(m.Consensus) {
+			if err := os.Link(e.location(backupDir), to); err == nil {
+				return nil
+			}
+		}
+		f, err := os.Open(e.location(backupDir))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		hash, err := copyFile(ctx, f, to)
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.Path, err)
+		}
+		if hash != e.Sha256 {
+			return fmt.Errorf("%w: %s: sha256 %s, expected %s", ErrBackupMismatch, e.Path, hash, e.Sha256)
+		}
+		return os.Chtimes(to, e.ModTime, e.ModTime)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("[backup] restore done", "datadir", dirs.DataDir, "files", len(entries))
+	return nil
+}
+
+// forEach - runs fn for [0, n) by `workers` goroutines, with progress logs
+func forEach(ctx context.Context, n, workers int, logPrefix string, logger log.Logger, fn func(ctx context.Context, i int) error) error {
+	if workers <= 0 {
+		workers = 1
+	}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(workers)
+
+	var done atomic.Int64
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+
+	for i := 0; i < n; i++ {
+		i := i
+		select {
+		case <-logEvery.C:
+			logger.Info(logPrefix, "progress", fmt.Sprintf("%d/%d", done.Load(), n))
+		default:
+		}
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(gctx, i); err != nil {
+				return err
+			}
+			done.Add(1)
+			return nil
+		})
+	}
+	return g.Wait()
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/mdbx"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func TestDatadirBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New()
+	dirs := datadir.New(t.TempDir())
+
+	db := mdbx.NewMDBX(logger).Path(dirs.Chaindata).Label(kv.ChainDB).MustOpen()
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(kv.Headers, []byte{1}, []byte("header"))
+	}))
+	db.Close()
+
+	consensusDB := mdbx.NewMDBX(logger).Path(filepath.Join(dirs.DataDir, "clique")).Label(kv.ConsensusDB).MustOpen()
+	require.NoError(t, consensusDB.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(kv.CliqueSeparate, []byte{2}, []byte("snapshot"))
+	}))
+	consensusDB.Close()
+
+	writeFile := func(dir, name, content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	writeFile(dirs.Snap, "v1-000000-000500-headers.seg", "headers")
+	writeFile(dirs.Snap, "salt-blocks.txt", "salt")
+	writeFile(dirs.SnapDomain, "v1-accounts.0-32.kv", "accounts")
+	writeFile(dirs.SnapDomain, "v1-accounts.0-32.kv.tmp", "unfinished")
+
+	full := filepath.Join(t.TempDir(), "full")
+	m, err := Datadir(ctx, dirs, full, DatadirCfg{Workers: 2}, logger)
+	require.NoError(t, err)
+	require.Len(t, m.Chaindata, 1)
+	require.Len(t, m.Consensus, 1)
+	require.Len(t, m.Snapshots, 3)
+	require.NoFileExists(t, filepath.Join(full, "snapshots", "domain", "v1-accounts.0-32.kv.tmp"))
+
+	// backup target must be new
+	_, err = Datadir(ctx, dirs, full, DatadirCfg{}, logger)
+	require.Error(t, err)
+
+	// merge replaced file, new file added
+	require.NoError(t, os.Remove(filepath.Join(dirs.SnapDomain, "v1-accounts.0-32.kv")))
+	writeFile(dirs.SnapDomain, "v1-accounts.0-64.kv", "merged accounts")
+
+	incremental := filepath.Join(t.TempDir(), "incremental")
+	m, err = Datadir(ctx, dirs, incremental, DatadirCfg{Previous: full, Link: true, Workers: 2}, logger)
+	require.NoError(t, err)
+	require.Len(t, m.Snapshots, 3)
+	for _, e := range m.Snapshots {
+		if e.Path == "snapshots/domain/v1-accounts.0-64.kv" {
+			require.Empty(t, e.Base)
+			require.FileExists(t, filepath.Join(incremental, filepath.FromSlash(e.Path)))
+			continue
+		}
+		require.Equal(t, full, e.Base)
+		require.NoFileExists(t, filepath.Join(incremental, filepath.FromSlash(e.Path)))
+	}
+
+	restored := datadir.New(t.TempDir())
+	require.NoError(t, Restore(ctx, incremental, restored, RestoreCfg{Workers: 2}, logger))
+	for _, e := range m.Snapshots {
+		require.FileExists(t, filepath.Join(restored.DataDir, filepath.FromSlash(e.Path)))
+	}
+	require.NoFileExists(t, filepath.Join(restored.SnapDomain, "v1-accounts.0-32.kv"))
+
+	db = mdbx.NewMDBX(logger).Path(restored.Chaindata).Label(kv.ChainDB).MustOpen()
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		v, err := tx.GetOne(kv.Headers, []byte{1})
+		require.Equal(t, "header", string(v))
+		return err
+	}))
+	db.Close()
+
+	db = mdbx.NewMDBX(logger).Path(filepath.Join(restored.DataDir, "clique")).Label(kv.ConsensusDB).MustOpen()
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		v, err := tx.GetOne(kv.CliqueSeparate, []byte{2})
+		require.Equal(t, "snapshot", string(v))
+		return err
+	}))
+	db.Close()
+
+	// restore doesn't overwrite data
+	err = Restore(ctx, incremental, restored, RestoreCfg{}, logger)
+	require.ErrorContains(t, err, "can't restore to datadir with data")
+
+	// corruption of file in base backup is detected before restore starts
+	writeFile(filepath.Join(full, "snapshots"), "v1-000000-000500-headers.seg", "HEADERS")
+	restored = datadir.New(t.TempDir())
+	err = Restore(ctx, incremental, restored, RestoreCfg{}, logger)
+	require.ErrorIs(t, err, ErrBackupMismatch)
+	require.NoFileExists(t, filepath.Join(restored.Chaindata, "mdbx.dat"))
+}
+
+func TestRestoreRejectsPathOutsideDatadir(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New()
+	backupDir := t.TempDir()
+
+	content := []byte("evil")
+	require.NoError(t, os.WriteFile(filepath.Join(backupDir, "x"), content, 0644))
+	hash, err := hashReader(ctx, bytes.NewReader(content))
+	require.NoError(t, err)
+	// location of the file in backup is base/../x, its restore target - datadir/../x
+	entry := FileEntry{Path: "../x", Size: int64(len(content)), Sha256: hash, Base: filepath.Join(backupDir, "base")}
+	require.NoError(t, writeManifest(backupDir, &Manifest{
+		Version:   manifestVersion,
+		Chaindata: []FileEntry{entry},
+	}))
+
+	restored := datadir.New(filepath.Join(t.TempDir(), "datadir"))
+	err = Restore(ctx, backupDir, restored, RestoreCfg{}, logger)
+	require.ErrorIs(t, err, ErrBackupMismatch)
+	require.NoFileExists(t, filepath.Join(filepath.Dir(restored.DataDir), "x"))
+}
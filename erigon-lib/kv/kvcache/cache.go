@@ -714,6 +714,14 @@ func (c *Coherent) evictRoots() {
 		delete(c.roots, txID)
 	}
 }
+
+// LatestStateVersionID - state version of the last block the cache received, to compare with kv.PlainStateVersion of db
+func (c *Coherent) LatestStateVersionID() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.latestStateVersionID
+}
+
 func (c *Coherent) Len() int {
 	c.lock.Lock()
 	defer c.lock.Unlock()
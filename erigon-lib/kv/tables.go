@@ -256,6 +256,8 @@ const (
 
 	BlockBody = "BlockBody" // block_num_u64 + hash -> block body
 
+	BadBlocks = "BadBlocks" // block_num_u64 + hash -> rlp(block, validation error, pre-state root, time); bounded, see rawdb.BadBlocksLimit
+
 	// Naming:
 	//  TxNum - Ethereum canonical transaction number - same across all nodes.
 	//  TxnID - auto-increment ID - can be differrent across all nodes
@@ -308,6 +310,7 @@ const (
 	CliqueSeparate     = "CliqueSeparate"
 	CliqueSnapshot     = "CliqueSnapshot"
 	CliqueLastSnapshot = "CliqueLastSnapshot"
+	CliqueProposals    = "CliqueProposals" // signer address -> pending vote of the local sealer (1 - authorize, 0 - drop)
 
 	// Proof-of-stake
 	// Beacon chain head that is been executed at the current time
@@ -405,6 +408,18 @@ const (
 	TblTracesToKeys   = "TracesToKeys"
 	TblTracesToIdx    = "TracesToIdx"
 
+	// Indices and domains of user-defined indexers of CustomTrace stage, keys of all tables start with
+	// [1byte len of indexer name][indexer name], except ones keyed by txNum:
+	// inverted index: txNum -> [indexer][key] and [indexer][key] -> txNum
+	TblCustomIndexKeys = "CustomIndexKeys"
+	TblCustomIndexIdx  = "CustomIndexIdx"
+	// domain: [indexer][key] -> latest value, and txNum -> [indexer][2bytes len of key][key][value before txNum]
+	// for unwind
+	TblCustomDomainVals    = "CustomDomainVals"
+	TblCustomDomainHistory = "CustomDomainHistory"
+	// [indexer name] -> [8bytes block number], the last block processed by the indexer
+	TblCustomIndexProgress = "CustomIndexProgress"
+
 	// Prune progress of execution: tableName -> [8bytes of invStep]latest pruned key
 	// Could use table constants `Tbl{Account,Storage,Code,Commitment}Keys` for domains
 	// corresponding history tables `Tbl{Account,Storage,Code,Commitment}HistoryKeys` for history
@@ -465,6 +480,17 @@ const (
 	// BlockRoot => Beacon Block Header
 	BeaconBlockHeaders = "BeaconBlockHeaders"
 
+	// [Key] => snapshot of the finalized deposit tree (EIP-4881), deposits pending its finalization and progress
+	DepositTree = "DepositTree"
+
+	// Slashing protection of the built-in validator client (EIP-3076)
+	// PublicKey + Slot => signing root of the block
+	ValidatorSignedBlocks = "ValidatorSignedBlocks"
+	// PublicKey + Target Epoch => Source Epoch + signing root of the attestation
+	ValidatorSignedAttestations = "ValidatorSignedAttestations"
+	// PublicKey => Source Epoch + Target Epoch: low watermark of the attestations, the pruned history is below it
+	ValidatorAttestationWatermarks = "ValidatorAttestationWatermarks"
+
 	// Period (one every 27 hours) => LightClientUpdate
 	LightClientUpdates = "LightClientUpdates"
 	// Beacon historical data
@@ -555,6 +581,7 @@ var ChaindataTables = []string{
 	ContractCode,
 	HeaderNumber,
 	BadHeaderNumber,
+	BadBlocks,
 	BlockBody,
 	Receipts,
 	TxLookup,
@@ -566,6 +593,7 @@ var ChaindataTables = []string{
 	CliqueSeparate,
 	CliqueLastSnapshot,
 	CliqueSnapshot,
+	CliqueProposals,
 	SyncStageProgress,
 	PlainState,
 	PlainContractCode,
@@ -644,6 +672,12 @@ var ChaindataTables = []string{
 	TblTracesToKeys,
 	TblTracesToIdx,
 
+	TblCustomIndexKeys,
+	TblCustomIndexIdx,
+	TblCustomDomainVals,
+	TblCustomDomainHistory,
+	TblCustomIndexProgress,
+
 	TblPruningProgress,
 
 	Snapshots,
@@ -673,6 +707,10 @@ var ChaindataTables = []string{
 	BlockRootToBlockHash,
 	BlockRootToBlockNumber,
 	LastBeaconSnapshot,
+	DepositTree,
+	ValidatorSignedBlocks,
+	ValidatorSignedAttestations,
+	ValidatorAttestationWatermarks,
 	// Blob Storage
 	BlockRootToKzgCommitments,
 	KzgCommitmentToBlob,
@@ -710,12 +748,18 @@ const (
 	RecentLocalTransaction = "RecentLocalTransaction" // sequence_u64 -> tx_hash
 	PoolTransaction        = "PoolTransaction"        // txHash -> sender+tx_rlp
 	PoolInfo               = "PoolInfo"               // option_key -> option_value
+	PoolDiscardHistory     = "PoolDiscardHistory"     // timestamp_u64 + tx_hash -> discard_event
+	PoolDiscardHistoryIdx  = "PoolDiscardHistoryIdx"  // tx_hash -> timestamp_u64 of the latest discard
+	PoolDiscardBySender    = "PoolDiscardBySender"    // sender + timestamp_u64 + tx_hash -> empty
 )
 
 var TxPoolTables = []string{
 	RecentLocalTransaction,
 	PoolTransaction,
 	PoolInfo,
+	PoolDiscardHistory,
+	PoolDiscardHistoryIdx,
+	PoolDiscardBySender,
 }
 var SentryTables = []string{}
 var DownloaderTables = []string{
@@ -820,6 +864,9 @@ var ChaindataTablesCfg = TableCfg{
 	TblTracesFromIdx:         {Flags: DupSort},
 	TblTracesToKeys:          {Flags: DupSort},
 	TblTracesToIdx:           {Flags: DupSort},
+	TblCustomIndexKeys:       {Flags: DupSort},
+	TblCustomIndexIdx:        {Flags: DupSort},
+	TblCustomDomainHistory:   {Flags: DupSort},
 	TblPruningProgress:       {Flags: DupSort},
 
 	RAccountKeys: {Flags: DupSort},
@@ -855,7 +902,7 @@ var ReconTablesCfg = TableCfg{
 
 func TablesCfgByLabel(label Label) TableCfg {
 	switch label {
-	case ChainDB:
+	case ChainDB, ConsensusDB:
 		return ChaindataTablesCfg
 	case TxPoolDB:
 		return TxpoolTablesCfg
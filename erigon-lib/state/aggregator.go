@@ -97,6 +97,8 @@ type Aggregator struct {
 	ctxAutoIncrement atomic.Uint64
 
 	produce bool
+
+	keepCommitmentHistory bool // keep commitment domain history in db, required to build proofs of past blocks
 }
 
 type OnFreezeFunc func(frozenFileNames []string)
@@ -1819,6 +1821,12 @@ func (a *Aggregator) SetProduceMod(produce bool) {
 	a.produce = produce
 }
 
+// SetKeepCommitmentHistory enables writing history of commitment domain (disabled by default).
+// History stays in db only and is pruned same as other histories with disabled snapshots.
+func (a *Aggregator) SetKeepCommitmentHistory(keep bool) {
+	a.keepCommitmentHistory = keep
+}
+
 // Returns channel which is closed when aggregation is done
 func (a *Aggregator) BuildFilesInBackground(txNum uint64) chan struct{} {
 	fin := make(chan struct{})
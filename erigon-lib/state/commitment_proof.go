@@ -0,0 +1,179 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/erigontech/erigon-lib/commitment"
+	"github.com/erigontech/erigon-lib/common/cryptozerocopy"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/types"
+)
+
+var (
+	// ErrCommitmentProofUnavailable is returned when commitment state for the requested block is not present
+	// in commitment history (pruned, or commitment was not computed for that block).
+	ErrCommitmentProofUnavailable = errors.New("commitment state is not available for the block")
+	// ErrCommitmentProofTooExpensive is returned when proof generation exceeds the limit of branch reads.
+	ErrCommitmentProofTooExpensive = errors.New("commitment proof exceeds branch reads limit")
+)
+
+// CommitmentProof restores commitment trie as it was right before txNum, which must be the first txNum
+// after blockNum, and generates the Merkle proof of the account and its storage locations.
+// maxBranchReads limits the amount of distinct branches read from commitment domain, 0 means no limit.
+func (ac *AggregatorRoTx) CommitmentProof(tx kv.Tx, blockNum, txNum uint64, accountKey []byte, storageLocs [][]byte, maxBranchReads int) (proof *commitment.Proof, rootHash []byte, err error) {
+	hc := &historicalCommitmentContext{
+		ac:             ac,
+		roTx:           tx,
+		txNum:          txNum,
+		keccak:         sha3.NewLegacyKeccak256().(cryptozerocopy.KeccakState),
+		branches:       make(map[string][]byte),
+		maxBranchReads: maxBranchReads,
+	}
+
+	// state is not counted towards the limit
+	encState, err := ac.d[kv.CommitmentDomain].GetAsOf(keyCommitmentState, txNum, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	cs := new(commitmentState)
+	if err := cs.Decode(encState); err != nil {
+		return nil, nil, fmt.Errorf("%w: block %d: %w", ErrCommitmentProofUnavailable, blockNum, err)
+	}
+	// commitment history is pruned and GetAsOf of a pruned txNum silently falls back to the next known value,
+	// so it's the block number stored along with the trie state that tells whether history is retained
+	if cs.blockNum != blockNum || cs.txNum >= txNum {
+		return nil, nil, fmt.Errorf("%w: block %d, found state of block %d", ErrCommitmentProofUnavailable, blockNum, cs.blockNum)
+	}
+
+	hph := commitment.NewHexPatriciaHashed(length.Addr, hc, ac.a.dirs.Tmp)
+	if err := hph.SetState(cs.trieState); err != nil {
+		return nil, nil, fmt.Errorf("failed restore state: %w", err)
+	}
+	if rootHash, err = hph.RootHash(); err != nil {
+		return nil, nil, err
+	}
+	if proof, err = hph.GenerateProof(accountKey, storageLocs); err != nil {
+		return nil, nil, err
+	}
+	return proof, rootHash, nil
+}
+
+// historicalCommitmentContext is read-only commitment.PatriciaContext serving branches and state as of txNum.
+type historicalCommitmentContext struct {
+	ac     *AggregatorRoTx
+	roTx   kv.Tx
+	txNum  uint64
+	keccak cryptozerocopy.KeccakState
+
+	branches       map[string][]byte
+	maxBranchReads int
+}
+
+func (hc *historicalCommitmentContext) GetBranch(prefix []byte) ([]byte, uint64, error) {
+	if v, ok := hc.branches[string(prefix)]; ok {
+		return v, 0, nil
+	}
+	if hc.maxBranchReads > 0 && len(hc.branches) >= hc.maxBranchReads {
+		return nil, 0, ErrCommitmentProofTooExpensive
+	}
+
+	cd := hc.ac.d[kv.CommitmentDomain]
+	v, ok, err := cd.ht.HistorySeek(prefix, hc.txNum, hc.roTx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("commitment prefix %x history read error: %w", prefix, err)
+	}
+	if !ok {
+		// branch has not been changed since txNum, latest value is the one. Unlike history and db,
+		// files may keep references to shortened keys which have to be replaced back
+		var found bool
+		v, _, found, err = cd.getLatestFromDb(prefix, hc.roTx)
+		if err != nil {
+			return nil, 0, fmt.Errorf("commitment prefix %x read error: %w", prefix, err)
+		}
+		if !found {
+			var startTx, endTx uint64
+			v, found, startTx, endTx, err = cd.getFromFiles(prefix)
+			if err != nil {
+				return nil, 0, fmt.Errorf("commitment prefix %x read error: %w", prefix, err)
+			}
+			if found {
+				if v, err = hc.ac.replaceShortenedKeysInBranch(prefix, commitment.BranchData(v), startTx, endTx); err != nil {
+					return nil, 0, err
+				}
+			}
+		}
+	}
+	hc.branches[string(prefix)] = v
+	return v, 0, nil
+}
+
+func (hc *historicalCommitmentContext) PutBranch(prefix []byte, data []byte, prevData []byte, prevStep uint64) error {
+	return errors.New("historical commitment context is read-only")
+}
+
+func (hc *historicalCommitmentContext) GetAccount(plainKey []byte, cell *commitment.Cell) error {
+	encAccount, err := hc.ac.d[kv.AccountsDomain].GetAsOf(plainKey, hc.txNum, hc.roTx)
+	if err != nil {
+		return fmt.Errorf("GetAccount failed: %w", err)
+	}
+	cell.Nonce = 0
+	cell.Balance.Clear()
+	if len(encAccount) > 0 {
+		nonce, balance, chash := types.DecodeAccountBytesV3(encAccount)
+		cell.Nonce = nonce
+		cell.Balance.Set(balance)
+		if len(chash) > 0 {
+			copy(cell.CodeHash[:], chash)
+		}
+	}
+	if bytes.Equal(cell.CodeHash[:], commitment.EmptyCodeHash) {
+		cell.Delete = len(encAccount) == 0
+		return nil
+	}
+
+	code, err := hc.ac.d[kv.CodeDomain].GetAsOf(plainKey, hc.txNum, hc.roTx)
+	if err != nil {
+		return fmt.Errorf("GetAccount: failed to read code: %w", err)
+	}
+	if len(code) > 0 {
+		hc.keccak.Reset()
+		hc.keccak.Write(code)
+		hc.keccak.Read(cell.CodeHash[:])
+	} else {
+		cell.CodeHash = commitment.EmptyCodeHashArray
+	}
+	cell.Delete = len(encAccount) == 0 && len(code) == 0
+	return nil
+}
+
+func (hc *historicalCommitmentContext) GetStorage(plainKey []byte, cell *commitment.Cell) error {
+	enc, err := hc.ac.d[kv.StorageDomain].GetAsOf(plainKey, hc.txNum, hc.roTx)
+	if err != nil {
+		return err
+	}
+	cell.StorageLen = len(enc)
+	copy(cell.Storage[:], enc)
+	cell.Delete = cell.StorageLen == 0
+	return nil
+}
@@ -19,11 +19,13 @@ package state
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/erigontech/erigon-lib/commitment"
 	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/recsplit"
 )
 
@@ -217,6 +219,67 @@ func (dt *DomainRoTx) lookupByShortenedKey(shortKey []byte, getter ArchiveGetter
 	return fullKey, true
 }
 
+// replaceShortenedKeysInBranch replaces shortened keys in the branch with full keys
+func (ac *AggregatorRoTx) replaceShortenedKeysInBranch(prefix []byte, branch commitment.BranchData, fStartTxNum uint64, fEndTxNum uint64) (commitment.BranchData, error) {
+	if !ac.d[kv.CommitmentDomain].d.replaceKeysInValues && ac.a.commitmentValuesTransform {
+		panic("domain.replaceKeysInValues is disabled, but agg.commitmentValuesTransform is enabled")
+	}
+
+	if !ac.a.commitmentValuesTransform ||
+		len(branch) == 0 ||
+		ac.minimaxTxNumInDomainFiles() == 0 ||
+		bytes.Equal(prefix, keyCommitmentState) || ((fEndTxNum-fStartTxNum)/ac.a.StepSize())%2 != 0 {
+
+		return branch, nil // do not transform, return as is
+	}
+
+	sto := ac.d[kv.StorageDomain]
+	acc := ac.d[kv.AccountsDomain]
+	storageItem := sto.lookupFileByItsRange(fStartTxNum, fEndTxNum)
+	if storageItem == nil {
+		ac.a.logger.Crit(fmt.Sprintf("storage file of steps %d-%d not found\n", fStartTxNum/ac.a.aggregationStep, fEndTxNum/ac.a.aggregationStep))
+		return nil, errors.New("storage file not found")
+	}
+	accountItem := acc.lookupFileByItsRange(fStartTxNum, fEndTxNum)
+	if accountItem == nil {
+		ac.a.logger.Crit(fmt.Sprintf("storage file of steps %d-%d not found\n", fStartTxNum/ac.a.aggregationStep, fEndTxNum/ac.a.aggregationStep))
+		return nil, errors.New("account file not found")
+	}
+	storageGetter := NewArchiveGetter(storageItem.decompressor.MakeGetter(), sto.d.compression)
+	accountGetter := NewArchiveGetter(accountItem.decompressor.MakeGetter(), acc.d.compression)
+
+	aux := make([]byte, 0, 256)
+	return branch.ReplacePlainKeys(aux, func(key []byte, isStorage bool) ([]byte, error) {
+		if isStorage {
+			if len(key) == length.Addr+length.Hash {
+				return nil, nil // save storage key as is
+			}
+			// Optimised key referencing a state file record (file number and offset within the file)
+			storagePlainKey, found := sto.lookupByShortenedKey(key, storageGetter)
+			if !found {
+				s0, s1 := fStartTxNum/ac.a.StepSize(), fEndTxNum/ac.a.StepSize()
+				ac.a.logger.Crit("replace back lost storage full key", "shortened", fmt.Sprintf("%x", key),
+					"decoded", fmt.Sprintf("step %d-%d; offt %d", s0, s1, decodeShorterKey(key)))
+				return nil, fmt.Errorf("replace back lost storage full key: %x", key)
+			}
+			return storagePlainKey, nil
+		}
+
+		if len(key) == length.Addr {
+			return nil, nil // save account key as is
+		}
+
+		apkBuf, found := acc.lookupByShortenedKey(key, accountGetter)
+		if !found {
+			s0, s1 := fStartTxNum/ac.a.StepSize(), fEndTxNum/ac.a.StepSize()
+			ac.a.logger.Crit("replace back lost account full key", "shortened", fmt.Sprintf("%x", key),
+				"decoded", fmt.Sprintf("step %d-%d; offt %d", s0, s1, decodeShorterKey(key)))
+			return nil, fmt.Errorf("replace back lost account full key: %x", key)
+		}
+		return apkBuf, nil
+	})
+}
+
 // commitmentValTransform parses the value of the commitment record to extract references
 // to accounts and storage items, then looks them up in the new, merged files, and replaces them with
 // the updated references
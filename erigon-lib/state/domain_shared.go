@@ -41,7 +41,6 @@ import (
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/assert"
 	"github.com/erigontech/erigon-lib/common/dbg"
-	"github.com/erigontech/erigon-lib/common/length"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/order"
 	"github.com/erigontech/erigon-lib/kv/rawdbv3"
@@ -119,7 +118,9 @@ func NewSharedDomains(tx kv.Tx, logger log.Logger) (*SharedDomains, error) {
 	}
 	sd.SetTx(tx)
 
-	sd.aggTx.a.DiscardHistory(kv.CommitmentDomain)
+	if !sd.aggTx.a.keepCommitmentHistory {
+		sd.aggTx.a.DiscardHistory(kv.CommitmentDomain)
+	}
 
 	for id, ii := range sd.aggTx.iis {
 		sd.iiWriters[id] = ii.NewWriter()
@@ -399,74 +400,13 @@ func (sd *SharedDomains) LatestCommitment(prefix []byte) ([]byte, uint64, error)
 	}
 
 	// replace shortened keys in the branch with full keys to allow HPH work seamlessly
-	rv, err := sd.replaceShortenedKeysInBranch(prefix, commitment.BranchData(v), startTx, endTx)
+	rv, err := sd.aggTx.replaceShortenedKeysInBranch(prefix, commitment.BranchData(v), startTx, endTx)
 	if err != nil {
 		return nil, 0, err
 	}
 	return rv, endTx / sd.aggTx.a.StepSize(), nil
 }
 
-// replaceShortenedKeysInBranch replaces shortened keys in the branch with full keys
-func (sd *SharedDomains) replaceShortenedKeysInBranch(prefix []byte, branch commitment.BranchData, fStartTxNum uint64, fEndTxNum uint64) (commitment.BranchData, error) {
-	if !sd.aggTx.d[kv.CommitmentDomain].d.replaceKeysInValues && sd.aggTx.a.commitmentValuesTransform {
-		panic("domain.replaceKeysInValues is disabled, but agg.commitmentValuesTransform is enabled")
-	}
-
-	if !sd.aggTx.a.commitmentValuesTransform ||
-		len(branch) == 0 ||
-		sd.aggTx.minimaxTxNumInDomainFiles() == 0 ||
-		bytes.Equal(prefix, keyCommitmentState) || ((fEndTxNum-fStartTxNum)/sd.aggTx.a.StepSize())%2 != 0 {
-
-		return branch, nil // do not transform, return as is
-	}
-
-	sto := sd.aggTx.d[kv.StorageDomain]
-	acc := sd.aggTx.d[kv.AccountsDomain]
-	storageItem := sto.lookupFileByItsRange(fStartTxNum, fEndTxNum)
-	if storageItem == nil {
-		sd.logger.Crit(fmt.Sprintf("storage file of steps %d-%d not found\n", fStartTxNum/sd.aggTx.a.aggregationStep, fEndTxNum/sd.aggTx.a.aggregationStep))
-		return nil, errors.New("storage file not found")
-	}
-	accountItem := acc.lookupFileByItsRange(fStartTxNum, fEndTxNum)
-	if accountItem == nil {
-		sd.logger.Crit(fmt.Sprintf("storage file of steps %d-%d not found\n", fStartTxNum/sd.aggTx.a.aggregationStep, fEndTxNum/sd.aggTx.a.aggregationStep))
-		return nil, errors.New("account file not found")
-	}
-	storageGetter := NewArchiveGetter(storageItem.decompressor.MakeGetter(), sto.d.compression)
-	accountGetter := NewArchiveGetter(accountItem.decompressor.MakeGetter(), acc.d.compression)
-
-	aux := make([]byte, 0, 256)
-	return branch.ReplacePlainKeys(aux, func(key []byte, isStorage bool) ([]byte, error) {
-		if isStorage {
-			if len(key) == length.Addr+length.Hash {
-				return nil, nil // save storage key as is
-			}
-			// Optimised key referencing a state file record (file number and offset within the file)
-			storagePlainKey, found := sto.lookupByShortenedKey(key, storageGetter)
-			if !found {
-				s0, s1 := fStartTxNum/sd.aggTx.a.StepSize(), fEndTxNum/sd.aggTx.a.StepSize()
-				sd.logger.Crit("replace back lost storage full key", "shortened", fmt.Sprintf("%x", key),
-					"decoded", fmt.Sprintf("step %d-%d; offt %d", s0, s1, decodeShorterKey(key)))
-				return nil, fmt.Errorf("replace back lost storage full key: %x", key)
-			}
-			return storagePlainKey, nil
-		}
-
-		if len(key) == length.Addr {
-			return nil, nil // save account key as is
-		}
-
-		apkBuf, found := acc.lookupByShortenedKey(key, accountGetter)
-		if !found {
-			s0, s1 := fStartTxNum/sd.aggTx.a.StepSize(), fEndTxNum/sd.aggTx.a.StepSize()
-			sd.logger.Crit("replace back lost account full key", "shortened", fmt.Sprintf("%x", key),
-				"decoded", fmt.Sprintf("step %d-%d; offt %d", s0, s1, decodeShorterKey(key)))
-			return nil, fmt.Errorf("replace back lost account full key: %x", key)
-		}
-		return apkBuf, nil
-	})
-}
-
 const CodeSizeTableFake = "CodeSize"
 
 func (sd *SharedDomains) ReadsValid(readLists map[string]*KvList) bool {
@@ -514,3 +514,82 @@ func TestSharedDomain_StorageIter(t *testing.T) {
 	domains.Close()
 	ac.Close()
 }
+
+func TestSharedDomain_CommitmentProof(t *testing.T) {
+	stepSize := uint64(100)
+	db, agg := testDbAndAggregatorv3(t, stepSize)
+	agg.SetKeepCommitmentHistory(true)
+
+	ctx := context.Background()
+	rwTx, err := db.BeginRw(ctx)
+	require.NoError(t, err)
+	defer rwTx.Rollback()
+
+	ac := agg.BeginFilesRo()
+	defer ac.Close()
+
+	domains, err := NewSharedDomains(WrapTxWithCtx(rwTx, ac), log.New())
+	require.NoError(t, err)
+	defer domains.Close()
+
+	const blocks, txsPerBlock = 20, 10
+	addrs := make([][]byte, 16)
+	for i := range addrs {
+		addrs[i] = make([]byte, length.Addr)
+		binary.BigEndian.PutUint64(addrs[i][length.Addr-8:], uint64(i+1))
+	}
+	loc := make([]byte, length.Hash)
+	roots := make([][]byte, blocks+1)
+	for blockNum := uint64(1); blockNum <= blocks; blockNum++ {
+		txNum := blockNum * txsPerBlock
+		domains.SetTxNum(txNum)
+		domains.SetBlockNum(blockNum)
+		for i, addr := range addrs {
+			if uint64(i) > blockNum {
+				break // new accounts appear block by block
+			}
+			prev, step, err := domains.DomainGet(kv.AccountsDomain, addr, nil)
+			require.NoError(t, err)
+			acc := types.EncodeAccountBytesV3(blockNum, uint256.NewInt(blockNum*1000+uint64(i)), nil, 0)
+			require.NoError(t, domains.DomainPut(kv.AccountsDomain, addr, nil, acc, prev, step))
+
+			binary.BigEndian.PutUint64(loc[length.Hash-8:], blockNum%3)
+			prev, step, err = domains.DomainGet(kv.StorageDomain, addr, loc)
+			require.NoError(t, err)
+			require.NoError(t, domains.DomainPut(kv.StorageDomain, addr, loc, []byte{byte(blockNum), byte(i)}, prev, step))
+		}
+		roots[blockNum], err = domains.ComputeCommitment(ctx, true, blockNum, "")
+		require.NoError(t, err)
+	}
+	require.NoError(t, domains.Flush(ctx, rwTx))
+	domains.Close()
+	require.NoError(t, rwTx.Commit())
+	ac.Close()
+
+	roTx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer roTx.Rollback()
+	ac = agg.BeginFilesRo()
+	defer ac.Close()
+
+	locs := make([][]byte, 3)
+	for i := range locs {
+		locs[i] = make([]byte, length.Hash)
+		binary.BigEndian.PutUint64(locs[i][length.Hash-8:], uint64(i))
+	}
+	for blockNum := uint64(1); blockNum <= blocks; blockNum++ {
+		for _, addr := range addrs[:4] {
+			proof, rootHash, err := ac.CommitmentProof(roTx, blockNum, (blockNum+1)*txsPerBlock, addr, locs, 0)
+			require.NoError(t, err, "block %d", blockNum)
+			require.Equal(t, roots[blockNum], rootHash, "block %d", blockNum)
+			require.NotEmpty(t, proof.AccountProof)
+			require.Len(t, proof.StorageProof, len(locs))
+		}
+	}
+
+	_, _, err = ac.CommitmentProof(roTx, 5, 7*txsPerBlock, addrs[0], nil, 0)
+	require.ErrorIs(t, err, ErrCommitmentProofUnavailable)
+
+	_, _, err = ac.CommitmentProof(roTx, blocks, (blocks+1)*txsPerBlock, addrs[0], locs, 1)
+	require.ErrorIs(t, err, ErrCommitmentProofTooExpensive)
+}
@@ -0,0 +1,256 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	"github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/stream"
+	"github.com/erigontech/erigon-lib/txpool/txpoolcfg"
+)
+
+// DiscardEvent - a txn which was evicted from the pool or replaced by another one
+type DiscardEvent struct {
+	Hash       common.Hash
+	Sender     common.Address
+	Nonce      uint64
+	Reason     txpoolcfg.DiscardReason
+	ReplacedBy common.Hash // zero if the txn wasn't replaced
+	BlockNum   uint64      // last block seen by the pool at the moment of discard
+	Time       uint64      // unix seconds
+}
+
+// hash + sender + nonce + reason + replacedBy + blockNum + time
+const discardEventLen = 32 + 20 + 8 + 1 + 32 + 8 + 8
+
+// how many expired events are deleted by one db commit, to keep commits short
+const discardHistoryPruneLimit = 10_000
+
+func (e *DiscardEvent) encode() []byte {
+	v := make([]byte, discardEventLen)
+	copy(v, e.Hash[:])
+	copy(v[32:], e.Sender[:])
+	binary.BigEndian.PutUint64(v[52:], e.Nonce)
+	v[60] = byte(e.Reason)
+	copy(v[61:], e.ReplacedBy[:])
+	binary.BigEndian.PutUint64(v[93:], e.BlockNum)
+	binary.BigEndian.PutUint64(v[101:], e.Time)
+	return v
+}
+
+func decodeDiscardEvent(v []byte) (*DiscardEvent, error) {
+	if len(v) != discardEventLen {
+		return nil, fmt.Errorf("unexpected discard event length: %d", len(v))
+	}
+	e := &DiscardEvent{
+		Nonce:    binary.BigEndian.Uint64(v[52:]),
+		Reason:   txpoolcfg.DiscardReason(v[60]),
+		BlockNum: binary.BigEndian.Uint64(v[93:]),
+		Time:     binary.BigEndian.Uint64(v[101:]),
+	}
+	copy(e.Hash[:], v)
+	copy(e.Sender[:], v[32:])
+	copy(e.ReplacedBy[:], v[61:])
+	return e, nil
+}
+
+func (e *DiscardEvent) ToProto() *txpoolproto.DiscardEvent {
+	res := &txpoolproto.DiscardEvent{
+		Hash:        gointerfaces.ConvertHashToH256(e.Hash),
+		Sender:      gointerfaces.ConvertAddressToH160(e.Sender),
+		Nonce:       e.Nonce,
+		Reason:      uint32(e.Reason),
+		BlockNumber: e.BlockNum,
+		Timestamp:   e.Time,
+	}
+	if e.ReplacedBy != (common.Hash{}) {
+		res.ReplacedBy = gointerfaces.ConvertHashToH256(e.ReplacedBy)
+	}
+	return res
+}
+
+func discardEventKey(t uint64, hash []byte) []byte {
+	k := make([]byte, 8+32)
+	binary.BigEndian.PutUint64(k, t)
+	copy(k[8:], hash)
+	return k
+}
+
+// discardHistory - keeps discard and replacement events of txs for the retention window:
+// events are buffered in memory and persisted by the next db commit of the pool
+// non thread-safe: guarded by the pool lock
+type discardHistory struct {
+	retention time.Duration
+	unflushed []*DiscardEvent
+}
+
+func newDiscardHistory(cfg txpoolcfg.Config) *discardHistory {
+	return &discardHistory{retention: cfg.DiscardHistoryRetention}
+}
+
+func (h *discardHistory) add(e *DiscardEvent) {
+	if h.retention <= 0 {
+		return
+	}
+	h.unflushed = append(h.unflushed, e)
+}
+
+// flush - persists buffered events and deletes the ones which are beyond the retention window.
+// Buffer is not cleaned here, because db txn may fail - see `flushed`
+func (h *discardHistory) flush(tx kv.RwTx, now time.Time) error {
+	if h.retention <= 0 {
+		return nil
+	}
+	for _, e := range h.unflushed {
+		k := discardEventKey(e.Time, e.Hash[:])
+		if err := tx.Put(kv.PoolDiscardHistory, k, e.encode()); err != nil {
+			return err
+		}
+		if err := tx.Put(kv.PoolDiscardHistoryIdx, e.Hash[:], k[:8]); err != nil {
+			return err
+		}
+		if err := tx.Put(kv.PoolDiscardBySender, append(e.Sender[:], k...), nil); err != nil {
+			return err
+		}
+	}
+	return h.prune(tx, uint64(now.Add(-h.retention).Unix()))
+}
+
+func (h *discardHistory) prune(tx kv.RwTx, before uint64) error {
+	c, err := tx.RwCursor(kv.PoolDiscardHistory)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	var pruned int
+	for k, v, err := c.First(); k != nil && pruned < discardHistoryPruneLimit; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if binary.BigEndian.Uint64(k) >= before {
+			break
+		}
+		// index points to the latest event of the txn, which may be not this one
+		latest, err := tx.GetOne(kv.PoolDiscardHistoryIdx, k[8:])
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(latest, k[:8]) {
+			if err := tx.Delete(kv.PoolDiscardHistoryIdx, k[8:]); err != nil {
+				return err
+			}
+		}
+		if len(v) == discardEventLen {
+			if err := tx.Delete(kv.PoolDiscardBySender, append(common.Copy(v[32:52]), k...)); err != nil {
+				return err
+			}
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return err
+		}
+		pruned++
+	}
+	return nil
+}
+
+// flushed - must be called after successful commit of `flush`, returns the persisted events
+func (h *discardHistory) flushed() []*DiscardEvent {
+	events := h.unflushed
+	h.unflushed = nil
+	return events
+}
+
+// latest - returns the latest discard event of the txn or nil
+func (h *discardHistory) latest(tx kv.Tx, hash []byte) (*DiscardEvent, error) {
+	for i := len(h.unflushed) - 1; i >= 0; i-- {
+		if bytes.Equal(h.unflushed[i].Hash[:], hash) {
+			return h.unflushed[i], nil
+		}
+	}
+	t, err := tx.GetOne(kv.PoolDiscardHistoryIdx, hash)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	v, err := tx.GetOne(kv.PoolDiscardHistory, append(common.Copy(t), hash...))
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return decodeDiscardEvent(v)
+}
+
+// list - returns events which happened not earlier than `from`, oldest first.
+// If sender is not nil - only events of this sender are returned, they are found by the sender index
+func (h *discardHistory) list(tx kv.Tx, sender *common.Address, from uint64, limit int) ([]*DiscardEvent, error) {
+	var events []*DiscardEvent
+	match := func(e *DiscardEvent) bool {
+		return e.Time >= from && (sender == nil || e.Sender == *sender)
+	}
+	fromKey := make([]byte, 8)
+	binary.BigEndian.PutUint64(fromKey, from)
+	var (
+		it  stream.KV
+		err error
+	)
+	if sender == nil {
+		it, err = tx.Range(kv.PoolDiscardHistory, fromKey, nil)
+	} else {
+		toKey, _ := kv.NextSubtree(sender[:])
+		it, err = tx.Range(kv.PoolDiscardBySender, append(common.Copy(sender[:]), fromKey...), toKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	for it.HasNext() && (limit <= 0 || len(events) < limit) {
+		k, v, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if sender != nil {
+			if v, err = tx.GetOne(kv.PoolDiscardHistory, k[length.Addr:]); err != nil {
+				return nil, err
+			}
+			if v == nil {
+				continue
+			}
+		}
+		e, err := decodeDiscardEvent(v)
+		if err != nil {
+			return nil, err
+		}
+		if match(e) {
+			events = append(events, e)
+		}
+	}
+	for _, e := range h.unflushed {
+		if limit > 0 && len(events) >= limit {
+			break
+		}
+		if match(e) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
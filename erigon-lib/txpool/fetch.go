@@ -370,7 +370,7 @@ func (f *Fetch) handleInboundMessage(ctx context.Context, req *sentry.InboundMes
 		if len(txs.Txs) == 0 {
 			return nil
 		}
-		f.pool.AddRemoteTxs(ctx, txs)
+		f.pool.AddRemoteTxs(ctx, txs, req.PeerId)
 	default:
 		defer f.logger.Trace("[txpool] dropped p2p message", "id", req.Id)
 	}
@@ -0,0 +1,171 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"golang.org/x/time/rate"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	"github.com/erigontech/erigon-lib/metrics"
+	"github.com/erigontech/erigon-lib/txpool/txpoolcfg"
+	"github.com/erigontech/erigon-lib/types"
+)
+
+// Lane is the admission class of a transaction. Every lane has its own slot budget,
+// so that spam in one lane can't crowd out transactions of the other ones:
+//   - LocalLane: transactions submitted through the RPC of this node
+//   - TrustedLane: remote transactions of the configured trusted senders
+//   - RemoteLane: all other transactions received from p2p
+type Lane uint8
+
+const LocalLane Lane = 1
+const TrustedLane Lane = 2
+const RemoteLane Lane = 3
+
+const lanesCount = 3
+
+func (l Lane) String() string {
+	switch l {
+	case LocalLane:
+		return "local"
+	case TrustedLane:
+		return "trusted"
+	case RemoteLane:
+		return "remote"
+	}
+	return fmt.Sprintf("unknown:%d", l)
+}
+
+// how many peers ingress rate limiters are kept for
+const peerLimitersCount = 1024
+
+// lanes - tracks per-lane slot usage, per-peer ingress limiters and per-lane discard metrics
+// non thread-safe: guarded by the pool lock
+type lanes struct {
+	trustedSenders map[common.Address]struct{}
+	limits         [lanesCount]int
+	counts         [lanesCount]int
+	sizeGauges     [lanesCount]metrics.Gauge
+
+	peerRate     rate.Limit
+	peerBurst    int
+	peerLimiters *simplelru.LRU[[64]byte, *rate.Limiter]
+
+	discardCounters map[laneDiscardKey]metrics.Counter
+}
+
+type laneDiscardKey struct {
+	lane   Lane
+	reason txpoolcfg.DiscardReason
+}
+
+func newLanes(cfg txpoolcfg.Config) (*lanes, error) {
+	peerLimiters, err := simplelru.NewLRU[[64]byte, *rate.Limiter](peerLimitersCount, nil)
+	if err != nil {
+		return nil, err
+	}
+	l := &lanes{
+		trustedSenders:  make(map[common.Address]struct{}, len(cfg.TrustedSenders)),
+		limits:          [lanesCount]int{cfg.LocalLaneSlots, cfg.TrustedLaneSlots, cfg.RemoteLaneSlots},
+		peerRate:        rate.Limit(cfg.PeerTxsPerSecond),
+		peerBurst:       cfg.PeerTxsBurst,
+		peerLimiters:    peerLimiters,
+		discardCounters: map[laneDiscardKey]metrics.Counter{},
+	}
+	for _, sender := range cfg.TrustedSenders {
+		l.trustedSenders[common.BytesToAddress([]byte(sender))] = struct{}{}
+	}
+	for _, lane := range []Lane{LocalLane, TrustedLane, RemoteLane} {
+		l.sizeGauges[lane-1] = metrics.GetOrCreateGauge(fmt.Sprintf(`txpool_lane_size{lane="%s"}`, lane))
+	}
+	return l, nil
+}
+
+func (l *lanes) laneOf(isLocal bool, sender common.Address) Lane {
+	if isLocal {
+		return LocalLane
+	}
+	if _, ok := l.trustedSenders[sender]; ok {
+		return TrustedLane
+	}
+	return RemoteLane
+}
+
+// full - returns true if a new txn doesn't fit into the slot budget of the lane
+func (l *lanes) full(lane Lane) bool {
+	if lane == 0 {
+		return false
+	}
+	limit := l.limits[lane-1]
+	return limit > 0 && l.counts[lane-1] >= limit
+}
+
+func (l *lanes) added(lane Lane) {
+	if lane == 0 {
+		return
+	}
+	l.counts[lane-1]++
+}
+
+func (l *lanes) removed(lane Lane) {
+	if lane == 0 {
+		return
+	}
+	l.counts[lane-1]--
+}
+
+func (l *lanes) size(lane Lane) int {
+	return l.counts[lane-1]
+}
+
+// allowPeer - consumes a token of the peer's ingress limiter, txs of unknown peers are not limited
+func (l *lanes) allowPeer(peerID types.PeerID) bool {
+	if l.peerRate <= 0 || peerID == nil {
+		return true
+	}
+	key := gointerfaces.ConvertH512ToHash(peerID)
+	limiter, ok := l.peerLimiters.Get(key)
+	if !ok {
+		limiter = rate.NewLimiter(l.peerRate, l.peerBurst)
+		l.peerLimiters.Add(key, limiter)
+	}
+	return limiter.Allow()
+}
+
+// discarded - counts a txn which was rejected or evicted from the pool
+func (l *lanes) discarded(lane Lane, reason txpoolcfg.DiscardReason) {
+	if lane == 0 {
+		return
+	}
+	key := laneDiscardKey{lane: lane, reason: reason}
+	c, ok := l.discardCounters[key]
+	if !ok {
+		c = metrics.GetOrCreateCounter(fmt.Sprintf(`txpool_discarded{lane="%s",reason="%s"}`, lane, reason))
+		l.discardCounters[key] = c
+	}
+	c.Inc()
+}
+
+func (l *lanes) updateMetrics() {
+	for i, g := range l.sizeGauges {
+		g.SetInt(l.counts[i])
+	}
+}
@@ -87,7 +87,7 @@ type Pool interface {
 	ValidateSerializedTxn(serializedTxn []byte) error
 
 	// Handle 3 main events - new remote txs from p2p, new local txs from RPC, new blocks from execution layer
-	AddRemoteTxs(ctx context.Context, newTxs types.TxSlots)
+	AddRemoteTxs(ctx context.Context, newTxs types.TxSlots, peerID types.PeerID)
 	AddLocalTxs(ctx context.Context, newTxs types.TxSlots, tx kv.Tx) ([]txpoolcfg.DiscardReason, error)
 	OnNewBlock(ctx context.Context, stateChanges *remote.StateChangeBatch, unwindTxs, unwindBlobTxs, minedTxs types.TxSlots, tx kv.Tx) error
 	// IdHashKnown check whether transaction with given Id hash is known to the pool
@@ -131,6 +131,7 @@ type metaTx struct {
 	timestamp                 uint64 // when it was added to pool
 	subPool                   SubPoolMarker
 	currentSubPool            SubPoolType
+	lane                      Lane
 	minedBlockNum             uint64
 }
 
@@ -199,9 +200,12 @@ type TxPool struct {
 	//   - batch notifications about new txs (reduced P2P spam to other nodes about txs propagation)
 	//   - and as a result reducing lock contention
 	unprocessedRemoteTxs    *types.TxSlots
+	unprocessedRemotePeers  []types.PeerID                                  // peer which sent each of unprocessedRemoteTxs
 	unprocessedRemoteByHash map[string]int                                  // to reject duplicates
 	byHash                  map[string]*metaTx                              // tx_hash => txn : only those records not committed to db yet
 	discardReasonsLRU       *simplelru.LRU[string, txpoolcfg.DiscardReason] // tx_hash => discard_reason : non-persisted
+	discardHistory          *discardHistory                                 // discard and replacement events of pooled txs : persisted
+	discardStreams          *DiscardStreams                                 // subscribers of discard events
 	pending                 *PendingPool
 	baseFee                 *SubPool
 	queued                  *SubPool
@@ -212,6 +216,7 @@ type TxPool struct {
 	all                     *BySenderAndNonce                // senderID => (sorted map of txn nonce => *metaTx)
 	deletedTxs              []*metaTx                        // list of discarded txs since last db commit
 	promoted                types.Announcements
+	lanes                   *lanes
 	cfg                     txpoolcfg.Config
 	chainID                 uint256.Int
 	lastSeenBlock           atomic.Uint64
@@ -262,6 +267,10 @@ func New(newTxs chan types.Announcements, coreDB kv.RoDB, cfg txpoolcfg.Config,
 	for _, sender := range cfg.TracedSenders {
 		tracedSenders[common.BytesToAddress([]byte(sender))] = struct{}{}
 	}
+	lanes, err := newLanes(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	lock := &sync.Mutex{}
 
@@ -271,6 +280,8 @@ func New(newTxs chan types.Announcements, coreDB kv.RoDB, cfg txpoolcfg.Config,
 		byHash:                  map[string]*metaTx{},
 		isLocalLRU:              localsHistory,
 		discardReasonsLRU:       discardHistory,
+		discardHistory:          newDiscardHistory(cfg),
+		discardStreams:          &DiscardStreams{},
 		all:                     byNonce,
 		recentlyConnectedPeers:  &recentlyConnectedPeers{},
 		pending:                 NewPendingSubPool(PendingSubPool, cfg.PendingSubPoolLimit),
@@ -279,6 +290,7 @@ func New(newTxs chan types.Announcements, coreDB kv.RoDB, cfg txpoolcfg.Config,
 		newPendingTxs:           newTxs,
 		_stateCache:             cache,
 		senders:                 newSendersCache(tracedSenders),
+		lanes:                   lanes,
 		_chainDB:                coreDB,
 		cfg:                     cfg,
 		chainID:                 chainID,
@@ -544,7 +556,7 @@ func (p *TxPool) processRemoteTxs(ctx context.Context) error {
 		return err
 	}
 
-	_, newTxs, err := p.validateTxs(p.unprocessedRemoteTxs, cacheView)
+	_, newTxs, err := p.validateTxs(p.rateLimitRemoteTxs(), cacheView)
 	if err != nil {
 		return err
 	}
@@ -567,11 +579,35 @@ func (p *TxPool) processRemoteTxs(ctx context.Context) error {
 	}
 
 	p.unprocessedRemoteTxs.Resize(0)
+	p.unprocessedRemotePeers = p.unprocessedRemotePeers[:0]
 	p.unprocessedRemoteByHash = map[string]int{}
 
 	//p.logger.Info("[txpool] on new txs", "amount", len(newPendingTxs.txs), "in", time.Since(t))
 	return nil
 }
+
+// rateLimitRemoteTxs - drops unprocessed remote txs of peers which exceeded their ingress rate limit.
+// Txs of trusted senders are not limited.
+func (p *TxPool) rateLimitRemoteTxs() *types.TxSlots {
+	if p.cfg.PeerTxsPerSecond <= 0 {
+		return p.unprocessedRemoteTxs
+	}
+	allowed := &types.TxSlots{}
+	for i, txn := range p.unprocessedRemoteTxs.Txs {
+		lane := p.laneOf(false, txn.SenderID)
+		if lane == TrustedLane || p.lanes.allowPeer(p.unprocessedRemotePeers[i]) {
+			allowed.Append(txn, p.unprocessedRemoteTxs.Senders.At(i), false)
+			continue
+		}
+		if txn.Traced {
+			p.logger.Info(fmt.Sprintf("TX TRACING: rateLimitRemoteTxs peer rate limited idHash=%x senderId=%d", txn.IDHash, txn.SenderID))
+		}
+		p.discardReasonsLRU.Add(string(txn.IDHash[:]), txpoolcfg.PeerRateLimited)
+		p.lanes.discarded(lane, txpoolcfg.PeerRateLimited)
+	}
+	return allowed
+}
+
 func (p *TxPool) getRlpLocked(tx kv.Tx, hash []byte) (rlpTxn []byte, sender common.Address, isLocal bool, err error) {
 	txn, ok := p.byHash[string(hash)]
 	if ok && txn.Tx.Rlp != nil {
@@ -809,7 +845,7 @@ func (p *TxPool) CountContent() (int, int, int) {
 	defer p.lock.Unlock()
 	return p.pending.Len(), p.baseFee.Len(), p.queued.Len()
 }
-func (p *TxPool) AddRemoteTxs(_ context.Context, newTxs types.TxSlots) {
+func (p *TxPool) AddRemoteTxs(_ context.Context, newTxs types.TxSlots, peerID types.PeerID) {
 	if p.cfg.NoGossip {
 		// if no gossip, then
 		// disable adding remote transactions
@@ -828,6 +864,7 @@ func (p *TxPool) AddRemoteTxs(_ context.Context, newTxs types.TxSlots) {
 		}
 		p.unprocessedRemoteByHash[hashS] = len(p.unprocessedRemoteTxs.Txs)
 		p.unprocessedRemoteTxs.Append(txn, newTxs.Senders.At(i), false)
+		p.unprocessedRemotePeers = append(p.unprocessedRemotePeers, peerID)
 	}
 }
 
@@ -1107,6 +1144,7 @@ func (p *TxPool) validateTxs(txs *types.TxSlots, stateCache kvcache.CacheView) (
 		if reason == txpoolcfg.Spammer {
 			p.punishSpammer(txn.SenderID)
 		}
+		p.lanes.discarded(p.laneOf(txs.IsLocal[i], txn.SenderID), reason)
 		reasons[i] = reason
 	}
 
@@ -1224,6 +1262,10 @@ func (p *TxPool) AddLocalTxs(ctx context.Context, newTransactions types.TxSlots,
 	}
 	return reasons, nil
 }
+func (p *TxPool) laneOf(isLocal bool, senderID uint64) Lane {
+	return p.lanes.laneOf(isLocal, p.senders.senderID2Addr[senderID])
+}
+
 func (p *TxPool) coreDBWithCache() (kv.RoDB, kvcache.Cache) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -1262,7 +1304,9 @@ func (p *TxPool) addTxs(blockNum uint64, cacheView kvcache.CacheView, senders *s
 			continue
 		}
 		mt := newMetaTx(txn, newTxs.IsLocal[i], blockNum)
+		mt.lane = p.laneOf(newTxs.IsLocal[i], txn.SenderID)
 		if reason := p.addLocked(mt, &announcements); reason != txpoolcfg.NotSet {
+			p.lanes.discarded(mt.lane, reason)
 			discardReasons[i] = reason
 			continue
 		}
@@ -1313,6 +1357,7 @@ func (p *TxPool) addTxsOnNewBlock(blockNum uint64, cacheView kvcache.CacheView,
 			continue
 		}
 		mt := newMetaTx(txn, newTxs.IsLocal[i], blockNum)
+		mt.lane = p.laneOf(newTxs.IsLocal[i], txn.SenderID)
 		if reason := p.addLocked(mt, &announcements); reason != txpoolcfg.NotSet {
 			p.discardLocked(mt, reason)
 			continue
@@ -1366,6 +1411,13 @@ func (p *TxPool) setBlobFee(blobFee uint64) {
 func (p *TxPool) addLocked(mt *metaTx, announcements *types.Announcements) txpoolcfg.DiscardReason {
 	// Insert to pending pool, if pool doesn't have txn with same Nonce and bigger Tip
 	found := p.all.get(mt.Tx.SenderID, mt.Tx.Nonce)
+	// Replacement within the same lane doesn't take an additional slot
+	if (found == nil || found.lane != mt.lane) && p.lanes.full(mt.lane) {
+		if mt.Tx.Traced {
+			p.logger.Info(fmt.Sprintf("TX TRACING: addLocked lane is full idHash=%x lane=%s slots=%d", mt.Tx.IDHash, mt.lane, p.lanes.size(mt.lane)))
+		}
+		return txpoolcfg.LaneOverflow
+	}
 	if found != nil {
 		if found.Tx.Type == types.BlobTxType && mt.Tx.Type != types.BlobTxType {
 			return txpoolcfg.BlobTxReplace
@@ -1418,7 +1470,7 @@ func (p *TxPool) addLocked(mt *metaTx, announcements *types.Announcements) txpoo
 			//already removed
 		}
 
-		p.discardLocked(found, txpoolcfg.ReplacedByHigherTip)
+		p.replaceLocked(found, mt)
 	}
 
 	// Don't add blob txn to queued if it's less than current pending blob base fee
@@ -1438,6 +1490,7 @@ func (p *TxPool) addLocked(mt *metaTx, announcements *types.Announcements) txpoo
 	if mt.subPool&IsLocal != 0 {
 		p.isLocalLRU.Add(hashStr, struct{}{})
 	}
+	p.lanes.added(mt.lane)
 	// All transactions are first added to the queued pool and then immediately promoted from there if required
 	p.queued.Add(mt, "addLocked", p.logger)
 	if mt.Tx.Type == types.BlobTxType {
@@ -1453,11 +1506,34 @@ func (p *TxPool) addLocked(mt *metaTx, announcements *types.Announcements) txpoo
 // dropping transaction from all sub-structures and from db
 // Important: don't call it while iterating by all
 func (p *TxPool) discardLocked(mt *metaTx, reason txpoolcfg.DiscardReason) {
+	p.dropLocked(mt, reason, nil)
+}
+
+// replaceLocked - same as discardLocked, but for txn replaced by another txn with the same sender and nonce
+func (p *TxPool) replaceLocked(found, mt *metaTx) {
+	p.dropLocked(found, txpoolcfg.ReplacedByHigherTip, mt.Tx.IDHash[:])
+}
+
+func (p *TxPool) dropLocked(mt *metaTx, reason txpoolcfg.DiscardReason, replacedBy []byte) {
 	hashStr := string(mt.Tx.IDHash[:])
 	delete(p.byHash, hashStr)
 	p.deletedTxs = append(p.deletedTxs, mt)
 	p.all.delete(mt, reason, p.logger)
 	p.discardReasonsLRU.Add(hashStr, reason)
+	p.lanes.removed(mt.lane)
+	if reason != txpoolcfg.Mined {
+		p.lanes.discarded(mt.lane, reason)
+		event := &DiscardEvent{
+			Hash:     mt.Tx.IDHash,
+			Sender:   p.senders.senderID2Addr[mt.Tx.SenderID],
+			Nonce:    mt.Tx.Nonce,
+			Reason:   reason,
+			BlockNum: p.lastSeenBlock.Load(),
+			Time:     uint64(time.Now().Unix()),
+		}
+		copy(event.ReplacedBy[:], replacedBy)
+		p.discardHistory.add(event)
+	}
 	if mt.Tx.Type == types.BlobTxType {
 		t := p.totalBlobsInPool.Load()
 		p.totalBlobsInPool.Store(t - uint64(len(mt.Tx.BlobHashes)))
@@ -1507,6 +1583,42 @@ func (p *TxPool) deleteMinedBlobTxn(hash string) {
 	delete(p.minedBlobTxsByHash, hash)
 }
 
+// TxnStatus - returns the sub-pool of the txn, or the reason why it's not in the pool anymore.
+// Both results are empty for unknown txs
+func (p *TxPool) TxnStatus(tx kv.Tx, hash []byte) (SubPoolType, *DiscardEvent, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if mt, ok := p.byHash[string(hash)]; ok {
+		return mt.currentSubPool, nil, nil
+	}
+	event, err := p.discardHistory.latest(tx, hash)
+	if err != nil {
+		return 0, nil, err
+	}
+	if event != nil {
+		return 0, event, nil
+	}
+	// txs rejected on validation never make it to the history
+	if reason, ok := p.discardReasonsLRU.Get(string(hash)); ok {
+		event = &DiscardEvent{Reason: reason}
+		copy(event.Hash[:], hash)
+		return 0, event, nil
+	}
+	return 0, nil, nil
+}
+
+// DiscardHistory - returns discard and replacement events which happened not earlier than `from` (unix seconds),
+// oldest first. If sender is not nil - only events of this sender are returned. limit=0 means no limit
+func (p *TxPool) DiscardHistory(tx kv.Tx, sender *common.Address, from uint64, limit int) ([]*DiscardEvent, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.discardHistory.list(tx, sender, from, limit)
+}
+
+func (p *TxPool) addDiscardStream(stream txpoolproto.Txpool_OnDiscardServer) (remove func()) {
+	return p.discardStreams.Add(stream)
+}
+
 func (p *TxPool) NonceFromAddress(addr [20]byte) (nonce uint64, inPool bool) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -1938,7 +2050,7 @@ func MainLoop(ctx context.Context, db kv.RwDB, p *TxPool, newTxs chan types.Anno
 	}
 }
 
-func (p *TxPool) flushNoFsync(ctx context.Context, db kv.RwDB) (written uint64, err error) {
+func (p *TxPool) flushNoFsync(ctx context.Context, db kv.RwDB) (written uint64, discards []*DiscardEvent, err error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	//it's important that write db txn is done inside lock, to make last writes visible for all read operations
@@ -1953,19 +2065,23 @@ func (p *TxPool) flushNoFsync(ctx context.Context, db kv.RwDB) (written uint64,
 		}
 		return nil
 	}); err != nil {
-		return 0, err
+		return 0, nil, err
 	}
-	return written, nil
+	return written, p.discardHistory.flushed(), nil
 }
 
 func (p *TxPool) flush(ctx context.Context, db kv.RwDB) (written uint64, err error) {
 	defer writeToDBTimer.ObserveDuration(time.Now())
 	// 1. get global lock on txpool and flush it to db, without fsync (to release lock asap)
 	// 2. then fsync db without txpool lock
-	written, err = p.flushNoFsync(ctx, db)
+	written, discards, err := p.flushNoFsync(ctx, db)
 	if err != nil {
 		return 0, err
 	}
+	// notify subscribers only about persisted events, and without holding txpool lock
+	if len(discards) > 0 {
+		p.discardStreams.Broadcast(discards, p.logger)
+	}
 
 	// fsync. increase state version - just to make RwTx non-empty (mdbx skips empty RwTx)
 	if err := db.Update(ctx, func(tx kv.RwTx) error {
@@ -2060,6 +2176,9 @@ func (p *TxPool) flushLocked(tx kv.RwTx) (err error) {
 	if err := PutLastSeenBlock(tx, p.lastSeenBlock.Load(), encID); err != nil {
 		return err
 	}
+	if err := p.discardHistory.flush(tx, time.Now()); err != nil {
+		return err
+	}
 
 	// clean - in-memory data structure as later as possible - because if during this txn will happen error,
 	// DB will stay consistent but some in-memory structures may be already cleaned, and retry will not work
@@ -2309,6 +2428,7 @@ func (p *TxPool) logStats() {
 	pendingSubCounter.SetInt(p.pending.Len())
 	basefeeSubCounter.SetInt(p.baseFee.Len())
 	queuedSubCounter.SetInt(p.queued.Len())
+	p.lanes.updateMetrics()
 }
 
 // Deprecated need switch to streaming-like
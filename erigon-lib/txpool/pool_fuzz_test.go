@@ -550,7 +550,7 @@ func FuzzOnNewBlocks(f *testing.F) {
 		checkNotify(types.TxSlots{}, txs3, "fork2 mined")
 
 		// add some remote txs from p2p
-		pool.AddRemoteTxs(ctx, p2pReceived)
+		pool.AddRemoteTxs(ctx, p2pReceived, nil)
 		err = pool.processRemoteTxs(ctx)
 		assert.NoError(err)
 		check(p2pReceived, types.TxSlots{}, "p2pmsg1")
@@ -119,15 +119,15 @@ func (c *MockPoolAddNewGoodPeerCall) DoAndReturn(f func(types.PeerID)) *MockPool
 }
 
 // AddRemoteTxs mocks base method.
-func (m *MockPool) AddRemoteTxs(arg0 context.Context, arg1 types.TxSlots) {
+func (m *MockPool) AddRemoteTxs(arg0 context.Context, arg1 types.TxSlots, arg2 types.PeerID) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "AddRemoteTxs", arg0, arg1)
+	m.ctrl.Call(m, "AddRemoteTxs", arg0, arg1, arg2)
 }
 
 // AddRemoteTxs indicates an expected call of AddRemoteTxs.
-func (mr *MockPoolMockRecorder) AddRemoteTxs(arg0, arg1 any) *MockPoolAddRemoteTxsCall {
+func (mr *MockPoolMockRecorder) AddRemoteTxs(arg0, arg1, arg2 any) *MockPoolAddRemoteTxsCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRemoteTxs", reflect.TypeOf((*MockPool)(nil).AddRemoteTxs), arg0, arg1)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRemoteTxs", reflect.TypeOf((*MockPool)(nil).AddRemoteTxs), arg0, arg1, arg2)
 	return &MockPoolAddRemoteTxsCall{Call: call}
 }
 
@@ -143,13 +143,13 @@ func (c *MockPoolAddRemoteTxsCall) Return() *MockPoolAddRemoteTxsCall {
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockPoolAddRemoteTxsCall) Do(f func(context.Context, types.TxSlots)) *MockPoolAddRemoteTxsCall {
+func (c *MockPoolAddRemoteTxsCall) Do(f func(context.Context, types.TxSlots, types.PeerID)) *MockPoolAddRemoteTxsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockPoolAddRemoteTxsCall) DoAndReturn(f func(context.Context, types.TxSlots)) *MockPoolAddRemoteTxsCall {
+func (c *MockPoolAddRemoteTxsCall) DoAndReturn(f func(context.Context, types.TxSlots, types.PeerID)) *MockPoolAddRemoteTxsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
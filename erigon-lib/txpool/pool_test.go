@@ -23,6 +23,7 @@ import (
 	"math"
 	"math/big"
 	"testing"
+	"time"
 
 	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
 	"github.com/holiman/uint256"
@@ -551,7 +552,7 @@ func TestTxPoke(t *testing.T) {
 		}
 		txSlot.IDHash[0] = 1
 		txSlots.Append(txSlot, addr[:], true)
-		pool.AddRemoteTxs(ctx, txSlots)
+		pool.AddRemoteTxs(ctx, txSlots, nil)
 		nonce, ok := pool.NonceFromAddress(addr)
 		assert.True(ok)
 		assert.Equal(uint64(2), nonce)
@@ -573,7 +574,7 @@ func TestTxPoke(t *testing.T) {
 		}
 		txSlot.IDHash[0] = 2
 		txSlots.Append(txSlot, addr[:], true)
-		pool.AddRemoteTxs(ctx, txSlots)
+		pool.AddRemoteTxs(ctx, txSlots, nil)
 		nonce, ok := pool.NonceFromAddress(addr)
 		assert.True(ok)
 		assert.Equal(uint64(2), nonce)
@@ -1054,7 +1055,7 @@ func TestDropRemoteAtNoGossip(t *testing.T) {
 		txSlot.IDHash[0] = 1
 		txSlots.Append(txSlot, addr[:], true)
 
-		txPool.AddRemoteTxs(ctx, txSlots)
+		txPool.AddRemoteTxs(ctx, txSlots, nil)
 	}
 
 	// empty because AddRemoteTxs logic is intentionally empty
@@ -1228,3 +1229,261 @@ func TestGasLimitChanged(t *testing.T) {
 
 	assert.Zero(mtx.subPool&NotTooMuchGas, "Should now have block space (again) for the tx")
 }
+
+func TestLaneSlots(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+	ch := make(chan types.Announcements, 100)
+
+	coreDB, _ := temporaltest.NewTestDB(t, datadir.New(t.TempDir()))
+	db := memdb.NewTestPoolDB(t)
+
+	var remoteAddr, trustedAddr, trustedAddr2 [20]byte
+	remoteAddr[0] = 1
+	trustedAddr[0] = 2
+	trustedAddr2[0] = 3
+
+	cfg := txpoolcfg.DefaultConfig
+	cfg.AccountSlots = 1
+	cfg.RemoteLaneSlots = 1
+	cfg.TrustedLaneSlots = 3
+	cfg.TrustedSenders = []string{string(trustedAddr[:]), string(trustedAddr2[:])}
+	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, nil, log.New())
+	assert.NoError(err)
+	require.True(pool != nil)
+	ctx := context.Background()
+	h1 := gointerfaces.ConvertHashToH256([32]byte{})
+	change := &remote.StateChangeBatch{
+		PendingBlockBaseFee: 200000,
+		BlockGasLimit:       1000000,
+		ChangeBatch: []*remote.StateChange{
+			{BlockHeight: 0, BlockHash: h1},
+		},
+	}
+	v := types.EncodeAccountBytesV3(0, uint256.NewInt(1*common.Ether), make([]byte, 32), 1)
+	for _, addr := range [][20]byte{remoteAddr, trustedAddr, trustedAddr2} {
+		change.ChangeBatch[0].Changes = append(change.ChangeBatch[0].Changes, &remote.AccountChange{
+			Action:  remote.Action_UPSERT,
+			Address: gointerfaces.ConvertAddressToH160(addr),
+			Data:    v,
+		})
+	}
+	tx, err := db.BeginRw(ctx)
+	require.NoError(err)
+	defer tx.Rollback()
+	err = pool.OnNewBlock(ctx, change, types.TxSlots{}, types.TxSlots{}, types.TxSlots{}, tx)
+	assert.NoError(err)
+
+	var idHash byte
+	// the txs are not local: AddLocalTxs only returns their discard reasons, they take the lanes of remote txs
+	addTx := func(addr [20]byte, nonce uint64, fee uint64) txpoolcfg.DiscardReason {
+		idHash++
+		txSlot := &types.TxSlot{
+			Tip:    *uint256.NewInt(fee),
+			FeeCap: *uint256.NewInt(fee),
+			Gas:    100000,
+			Nonce:  nonce,
+		}
+		txSlot.IDHash[0] = idHash
+		var txSlots types.TxSlots
+		txSlots.Append(txSlot, addr[:], false)
+		reasons, err := pool.AddLocalTxs(ctx, txSlots, tx)
+		require.NoError(err)
+		require.Len(reasons, 1)
+		return reasons[0]
+	}
+
+	assert.Equal(txpoolcfg.Success, addTx(remoteAddr, 0, 300000))
+	assert.Equal(txpoolcfg.LaneOverflow, addTx(remoteAddr, 1, 300000))
+	// replacement doesn't need an additional slot
+	assert.Equal(txpoolcfg.Success, addTx(remoteAddr, 0, 300000*2))
+	assert.Equal(1, pool.lanes.size(RemoteLane))
+
+	// trusted senders have their own budget, but are still limited by AccountSlots
+	assert.Equal(txpoolcfg.Success, addTx(trustedAddr, 0, 300000))
+	assert.Equal(txpoolcfg.Success, addTx(trustedAddr, 1, 300000))
+	assert.Equal(txpoolcfg.Success, addTx(trustedAddr2, 0, 300000))
+	assert.Equal(txpoolcfg.LaneOverflow, addTx(trustedAddr2, 1, 300000))
+	assert.Equal(3, pool.lanes.size(TrustedLane))
+	assert.Equal(0, pool.lanes.size(LocalLane))
+	// the spammer loses half of its txs
+	assert.Equal(txpoolcfg.Spammer, addTx(trustedAddr, 2, 300000))
+	assert.Equal(2, pool.lanes.size(TrustedLane))
+}
+
+func TestPeerRateLimit(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+	ch := make(chan types.Announcements, 100)
+
+	coreDB, _ := temporaltest.NewTestDB(t, datadir.New(t.TempDir()))
+	db := memdb.NewTestPoolDB(t)
+
+	cfg := txpoolcfg.DefaultConfig
+	cfg.PeerTxsPerSecond = 0.001
+	cfg.PeerTxsBurst = 1
+	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, nil, log.New())
+	assert.NoError(err)
+	require.True(pool != nil)
+	ctx := context.Background()
+	require.NoError(pool.Start(ctx, db))
+
+	var addr [20]byte
+	addr[0] = 1
+	h1 := gointerfaces.ConvertHashToH256([32]byte{})
+	change := &remote.StateChangeBatch{
+		PendingBlockBaseFee: 200000,
+		BlockGasLimit:       1000000,
+		ChangeBatch: []*remote.StateChange{
+			{BlockHeight: 0, BlockHash: h1, Changes: []*remote.AccountChange{{
+				Action:  remote.Action_UPSERT,
+				Address: gointerfaces.ConvertAddressToH160(addr),
+				Data:    types.EncodeAccountBytesV3(0, uint256.NewInt(1*common.Ether), make([]byte, 32), 1),
+			}}},
+		},
+	}
+	tx, err := db.BeginRw(ctx)
+	require.NoError(err)
+	defer tx.Rollback()
+	err = pool.OnNewBlock(ctx, change, types.TxSlots{}, types.TxSlots{}, types.TxSlots{}, tx)
+	assert.NoError(err)
+
+	peer1 := gointerfaces.ConvertHashToH512([64]byte{1})
+	peer2 := gointerfaces.ConvertHashToH512([64]byte{2})
+	txSlots := func(nonces ...uint64) types.TxSlots {
+		var slots types.TxSlots
+		for _, nonce := range nonces {
+			txSlot := &types.TxSlot{
+				Tip:    *uint256.NewInt(300000),
+				FeeCap: *uint256.NewInt(300000),
+				Gas:    100000,
+				Nonce:  nonce,
+			}
+			txSlot.IDHash[0] = byte(nonce + 1)
+			slots.Append(txSlot, addr[:], false)
+		}
+		return slots
+	}
+	pool.AddRemoteTxs(ctx, txSlots(0, 1), peer1)
+	pool.AddRemoteTxs(ctx, txSlots(2), peer2)
+	require.NoError(pool.processRemoteTxs(ctx))
+
+	// the burst of peer1 allows only one txn
+	_, ok := pool.byHash[string([]byte{1})+string(make([]byte, 31))]
+	assert.True(ok)
+	reason, ok := pool.discardReasonsLRU.Get(string([]byte{2}) + string(make([]byte, 31)))
+	assert.True(ok)
+	assert.Equal(txpoolcfg.PeerRateLimited, reason, reason.String())
+	_, ok = pool.byHash[string([]byte{3})+string(make([]byte, 31))]
+	assert.True(ok)
+	assert.Equal(0, len(pool.unprocessedRemotePeers))
+}
+
+func TestDiscardHistory(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+	ch := make(chan types.Announcements, 100)
+
+	coreDB, _ := temporaltest.NewTestDB(t, datadir.New(t.TempDir()))
+	db := memdb.NewTestPoolDB(t)
+
+	cfg := txpoolcfg.DefaultConfig
+	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	pool, err := New(ch, coreDB, cfg, sendersCache, *u256.N1, nil, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, nil, log.New())
+	assert.NoError(err)
+	require.True(pool != nil)
+	ctx := context.Background()
+	var addr [20]byte
+	addr[0] = 1
+	h1 := gointerfaces.ConvertHashToH256([32]byte{})
+	change := &remote.StateChangeBatch{
+		PendingBlockBaseFee: 200000,
+		BlockGasLimit:       1000000,
+		ChangeBatch: []*remote.StateChange{
+			{BlockHeight: 0, BlockHash: h1},
+		},
+	}
+	v := types.EncodeAccountBytesV3(0, uint256.NewInt(1*common.Ether), make([]byte, 32), 1)
+	change.ChangeBatch[0].Changes = append(change.ChangeBatch[0].Changes, &remote.AccountChange{
+		Action:  remote.Action_UPSERT,
+		Address: gointerfaces.ConvertAddressToH160(addr),
+		Data:    v,
+	})
+	tx, err := db.BeginRw(ctx)
+	require.NoError(err)
+	defer tx.Rollback()
+	err = pool.OnNewBlock(ctx, change, types.TxSlots{}, types.TxSlots{}, types.TxSlots{}, tx)
+	assert.NoError(err)
+
+	add := func(idHash byte, fee uint64) {
+		txSlot := &types.TxSlot{
+			Tip:    *uint256.NewInt(fee),
+			FeeCap: *uint256.NewInt(fee),
+			Gas:    100000,
+			Nonce:  0,
+		}
+		txSlot.IDHash[0] = idHash
+		var txSlots types.TxSlots
+		txSlots.Append(txSlot, addr[:], true)
+		reasons, err := pool.AddLocalTxs(ctx, txSlots, tx)
+		require.NoError(err)
+		require.Equal([]txpoolcfg.DiscardReason{txpoolcfg.Success}, reasons)
+	}
+	add(1, 300000)
+	add(2, 300000*2)
+
+	replaced, replacement := common.Hash{1}, common.Hash{2}
+	subPool, discard, err := pool.TxnStatus(tx, replacement[:])
+	require.NoError(err)
+	assert.Equal(PendingSubPool, subPool)
+	assert.Nil(discard)
+
+	// not persisted yet, but already visible
+	_, discard, err = pool.TxnStatus(tx, replaced[:])
+	require.NoError(err)
+	require.NotNil(discard)
+	assert.Equal(txpoolcfg.ReplacedByHigherTip, discard.Reason)
+	assert.Equal(replacement, discard.ReplacedBy)
+	assert.Equal(common.Address(addr), discard.Sender)
+
+	require.NoError(pool.flushLocked(tx))
+	assert.Len(pool.discardHistory.flushed(), 1)
+	_, discard, err = pool.TxnStatus(tx, replaced[:])
+	require.NoError(err)
+	require.NotNil(discard)
+	assert.Equal(replacement, discard.ReplacedBy)
+
+	events, err := pool.DiscardHistory(tx, (*common.Address)(&addr), 0, 0)
+	require.NoError(err)
+	require.Len(events, 1)
+	assert.Equal(replaced, events[0].Hash)
+	events, err = pool.DiscardHistory(tx, &common.Address{2}, 0, 0)
+	require.NoError(err)
+	assert.Empty(events)
+	events, err = pool.DiscardHistory(tx, nil, uint64(time.Now().Add(time.Hour).Unix()), 0)
+	require.NoError(err)
+	assert.Empty(events)
+	events, err = pool.DiscardHistory(tx, (*common.Address)(&addr), uint64(time.Now().Add(time.Hour).Unix()), 0)
+	require.NoError(err)
+	assert.Empty(events)
+	bySender, err := tx.Count(kv.PoolDiscardBySender)
+	require.NoError(err)
+	assert.Equal(uint64(1), bySender)
+
+	// expired events are pruned
+	require.NoError(pool.discardHistory.prune(tx, uint64(time.Now().Add(time.Hour).Unix())))
+	events, err = pool.DiscardHistory(tx, nil, 0, 0)
+	require.NoError(err)
+	assert.Empty(events)
+	bySender, err = tx.Count(kv.PoolDiscardBySender)
+	require.NoError(err)
+	assert.Zero(bySender)
+	_, discard, err = pool.TxnStatus(tx, replaced[:])
+	require.NoError(err)
+	require.NotNil(discard) // still known by the in-memory LRU
+	assert.Equal(common.Hash{}, discard.ReplacedBy)
+
+	subPool, discard, err = pool.TxnStatus(tx, common.Hash{3}.Bytes())
+	require.NoError(err)
+	assert.Equal(SubPoolType(0), subPool)
+	assert.Nil(discard)
+}
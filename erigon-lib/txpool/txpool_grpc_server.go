@@ -48,7 +48,7 @@ import (
 )
 
 // TxPoolAPIVersion
-var TxPoolAPIVersion = &types2.VersionReply{Major: 1, Minor: 0, Patch: 0}
+var TxPoolAPIVersion = &types2.VersionReply{Major: 1, Minor: 1, Patch: 0}
 
 type txPool interface {
 	ValidateSerializedTxn(serializedTxn []byte) error
@@ -60,6 +60,9 @@ type txPool interface {
 	CountContent() (int, int, int)
 	IdHashKnown(tx kv.Tx, hash []byte) (bool, error)
 	NonceFromAddress(addr [20]byte) (nonce uint64, inPool bool)
+	TxnStatus(tx kv.Tx, hash []byte) (SubPoolType, *DiscardEvent, error)
+	DiscardHistory(tx kv.Tx, sender *common.Address, from uint64, limit int) ([]*DiscardEvent, error)
+	addDiscardStream(stream txpool_proto.Txpool_OnDiscardServer) (remove func())
 }
 
 var _ txpool_proto.TxpoolServer = (*GrpcServer)(nil)   // compile-time interface check
@@ -98,6 +101,15 @@ func (*GrpcDisabled) Status(ctx context.Context, request *txpool_proto.StatusReq
 func (*GrpcDisabled) Nonce(ctx context.Context, request *txpool_proto.NonceRequest) (*txpool_proto.NonceReply, error) {
 	return nil, ErrPoolDisabled
 }
+func (*GrpcDisabled) TransactionStatus(ctx context.Context, request *txpool_proto.TransactionStatusRequest) (*txpool_proto.TransactionStatusReply, error) {
+	return nil, ErrPoolDisabled
+}
+func (*GrpcDisabled) DiscardHistory(ctx context.Context, request *txpool_proto.DiscardHistoryRequest) (*txpool_proto.DiscardHistoryReply, error) {
+	return nil, ErrPoolDisabled
+}
+func (*GrpcDisabled) OnDiscard(request *txpool_proto.OnDiscardRequest, server txpool_proto.Txpool_OnDiscardServer) error {
+	return ErrPoolDisabled
+}
 
 type GrpcServer struct {
 	txpool_proto.UnimplementedTxpoolServer
@@ -304,6 +316,75 @@ func (s *GrpcServer) Nonce(ctx context.Context, in *txpool_proto.NonceRequest) (
 	}, nil
 }
 
+func (s *GrpcServer) TransactionStatus(ctx context.Context, in *txpool_proto.TransactionStatusRequest) (*txpool_proto.TransactionStatusReply, error) {
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	h := gointerfaces.ConvertH256ToHash(in.Hash)
+	subPool, discard, err := s.txPool.TxnStatus(tx, h[:])
+	if err != nil {
+		return nil, err
+	}
+	reply := &txpool_proto.TransactionStatusReply{}
+	switch {
+	case subPool == PendingSubPool:
+		reply.Status = txpool_proto.TransactionStatusReply_PENDING
+	case subPool == BaseFeeSubPool:
+		reply.Status = txpool_proto.TransactionStatusReply_BASE_FEE
+	case subPool == QueuedSubPool:
+		reply.Status = txpool_proto.TransactionStatusReply_QUEUED
+	case discard != nil:
+		reply.Status = txpool_proto.TransactionStatusReply_DISCARDED
+		reply.Discard = discard.ToProto()
+	default:
+		reply.Status = txpool_proto.TransactionStatusReply_UNKNOWN
+	}
+	return reply, nil
+}
+
+func (s *GrpcServer) DiscardHistory(ctx context.Context, in *txpool_proto.DiscardHistoryRequest) (*txpool_proto.DiscardHistoryReply, error) {
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var sender *common.Address
+	if in.Sender != nil {
+		addr := common.Address(gointerfaces.ConvertH160toAddress(in.Sender))
+		sender = &addr
+	}
+	events, err := s.txPool.DiscardHistory(tx, sender, in.FromTimestamp, int(in.Limit))
+	if err != nil {
+		return nil, err
+	}
+	return &txpool_proto.DiscardHistoryReply{Events: discardEventsToProto(events)}, nil
+}
+
+func (s *GrpcServer) OnDiscard(req *txpool_proto.OnDiscardRequest, stream txpool_proto.Txpool_OnDiscardServer) error {
+	s.logger.Info("New discarded txs subscriber joined")
+	//txpool.flush does send messages to this streams
+	remove := s.txPool.addDiscardStream(stream)
+	defer remove()
+	select {
+	case <-stream.Context().Done():
+		return stream.Context().Err()
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+func discardEventsToProto(events []*DiscardEvent) []*txpool_proto.DiscardEvent {
+	res := make([]*txpool_proto.DiscardEvent, len(events))
+	for i, e := range events {
+		res[i] = e.ToProto()
+	}
+	return res
+}
+
 // NewSlotsStreams - it's safe to use this class as non-pointer
 type NewSlotsStreams struct {
 	chans map[uint]txpool_proto.Txpool_OnAddServer
@@ -349,6 +430,55 @@ func (s *NewSlotsStreams) remove(id uint) {
 	delete(s.chans, id)
 }
 
+// DiscardStreams - it's safe to use this class as non-pointer
+type DiscardStreams struct {
+	chans map[uint]txpool_proto.Txpool_OnDiscardServer
+	mu    sync.Mutex
+	id    uint
+}
+
+func (s *DiscardStreams) Add(stream txpool_proto.Txpool_OnDiscardServer) (remove func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.chans == nil {
+		s.chans = make(map[uint]txpool_proto.Txpool_OnDiscardServer)
+	}
+	s.id++
+	id := s.id
+	s.chans[id] = stream
+	return func() { s.remove(id) }
+}
+
+func (s *DiscardStreams) Broadcast(events []*DiscardEvent, logger log.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.chans) == 0 {
+		return
+	}
+	reply := &txpool_proto.OnDiscardReply{Events: discardEventsToProto(events)}
+	for id, stream := range s.chans {
+		err := stream.Send(reply)
+		if err != nil {
+			logger.Debug("failed send to discarded txs stream", "err", err)
+			select {
+			case <-stream.Context().Done():
+				delete(s.chans, id)
+			default:
+			}
+		}
+	}
+}
+
+func (s *DiscardStreams) remove(id uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.chans[id]
+	if !ok { // double-unsubscribe support
+		return
+	}
+	delete(s.chans, id)
+}
+
 func StartGrpc(txPoolServer txpool_proto.TxpoolServer, miningServer txpool_proto.MiningServer, addr string, creds *credentials.TransportCredentials, logger log.Logger) (*grpc.Server, error) {
 	lis, err := net.Listen("tcp", addr)
 	if err != nil {
@@ -47,6 +47,18 @@ type Config struct {
 	BlobPriceBump       uint64 //Price bump percentage to replace an existing 4844 blob txn (type-3)
 	OverridePragueTime  *big.Int
 
+	// lanes: every txn belongs to the local, trusted or remote lane, each lane has its own slot budget
+	TrustedSenders   []string // List of senders whose remote txs are admitted into the trusted lane
+	LocalLaneSlots   int      // Max number of local txs in the pool, 0 - no limit
+	TrustedLaneSlots int      // Max number of txs of trusted senders in the pool, 0 - no limit
+	RemoteLaneSlots  int      // Max number of remote txs in the pool, 0 - no limit
+
+	// per-peer ingress limits of remote txs, trusted senders are exempt
+	PeerTxsPerSecond float64 // Rate of txs accepted from a single peer, 0 - no limit
+	PeerTxsBurst     int     // Number of txs a single peer can send at once above the rate
+
+	DiscardHistoryRetention time.Duration // How long discard and replacement events are kept in db, 0 - don't persist them
+
 	// regular batch tasks processing
 	SyncToNewPeersEvery   time.Duration
 	ProcessRemoteTxsEvery time.Duration
@@ -79,6 +91,10 @@ var DefaultConfig = Config{
 	PriceBump:          10,  // Price bump percentage to replace an already existing transaction
 	BlobPriceBump:      100,
 
+	PeerTxsBurst: 1_000,
+
+	DiscardHistoryRetention: 24 * time.Hour,
+
 	NoGossip:     false,
 	MdbxWriteMap: false,
 }
@@ -118,6 +134,8 @@ const (
 	UnmatchedBlobTxExt  DiscardReason = 29 // KZGcommitments must match the corresponding blobs and proofs
 	BlobTxReplace       DiscardReason = 30 // Cannot replace type-3 blob txn with another type of txn
 	BlobPoolOverflow    DiscardReason = 31 // The total number of blobs (through blob txs) in the pool has reached its limit
+	LaneOverflow        DiscardReason = 32 // The lane (local, trusted or remote) of the transaction has reached its slot budget
+	PeerRateLimited     DiscardReason = 33 // The peer which sent the transaction exceeded its ingress rate limit
 
 )
 
@@ -177,12 +195,22 @@ func (r DiscardReason) String() string {
 		return "blob transactions must have at least one blob"
 	case TooManyBlobs:
 		return "max number of blobs exceeded"
+	case UnequalBlobTxExt:
+		return "blob hashes, blobs, commitments and proofs must have equal number"
+	case BlobHashCheckFail:
+		return "commitment's versioned hash doesn't match blob hash"
+	case UnmatchedBlobTxExt:
+		return "commitments don't match blobs and proofs"
 	case BlobTxReplace:
 		return "can't replace blob-txn with a non-blob-txn"
 	case BlobPoolOverflow:
 		return "blobs limit in txpool is full"
+	case LaneOverflow:
+		return "lane is full"
+	case PeerRateLimited:
+		return "peer rate limit exceeded"
 	default:
-		panic(fmt.Sprintf("discard reason: %d", r))
+		return fmt.Sprintf("unknown(%d)", r)
 	}
 }
 
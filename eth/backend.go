@@ -88,6 +88,7 @@ import (
 	"github.com/erigontech/erigon/cl/utils/eth_clock"
 	"github.com/erigontech/erigon/cmd/caplin/caplin1"
 	"github.com/erigontech/erigon/cmd/rpcdaemon/cli"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/health"
 	"github.com/erigontech/erigon/common/debug"
 	"github.com/erigontech/erigon/consensus"
 	"github.com/erigontech/erigon/consensus/clique"
@@ -110,7 +111,6 @@ import (
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/ethdb/privateapi"
 	"github.com/erigontech/erigon/ethdb/prune"
-	"github.com/erigontech/erigon/ethstats"
 	"github.com/erigontech/erigon/node"
 	"github.com/erigontech/erigon/p2p"
 	"github.com/erigontech/erigon/p2p/enode"
@@ -124,6 +124,7 @@ import (
 	"github.com/erigontech/erigon/polygon/bridge"
 	"github.com/erigontech/erigon/polygon/heimdall"
 	polygonsync "github.com/erigontech/erigon/polygon/sync"
+	"github.com/erigontech/erigon/reporter"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/builder"
 	"github.com/erigontech/erigon/turbo/engineapi"
@@ -192,6 +193,7 @@ type Ethereum struct {
 
 	notifications      *shards.Notifications
 	unsubscribeEthstat func()
+	caplinIndexingDB   kv.RoDB
 
 	waitForStageLoopStop chan struct{}
 	waitForMiningStop    chan struct{}
@@ -558,7 +560,7 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 
 	if chainConfig.Bor != nil {
 		if !config.WithoutHeimdall {
-			heimdallClient = heimdall.NewHeimdallClient(config.HeimdallURL, logger)
+			heimdallClient = heimdall.NewHeimdallClient(config.HeimdallURL, logger, heimdallClientOptions(config)...)
 		}
 
 		if config.PolygonSync {
@@ -965,6 +967,7 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		if err != nil {
 			return nil, err
 		}
+		backend.caplinIndexingDB = indiciesDB
 
 		go func() {
 			caplinOpt := []caplin1.CaplinOption{}
@@ -1000,12 +1003,21 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 			executionRpc,
 			config.LoopBlockLimit,
 			polygonBridge,
+			heimdallClientOptions(config)...,
 		)
 	}
 
 	return backend, nil
 }
 
+func heimdallClientOptions(config *ethconfig.Config) []heimdall.ClientOption {
+	var opts []heimdall.ClientOption
+	if len(config.HeimdallRecordDir) > 0 {
+		opts = append(opts, heimdall.WithRecordDir(config.HeimdallRecordDir))
+	}
+	return opts
+}
+
 func (s *Ethereum) Init(stack *node.Node, config *ethconfig.Config, chainConfig *chain.Config) error {
 	ethBackendRPC, miningRPC, stateDiffClient := s.ethBackendRPC, s.miningRPC, s.stateChangesClient
 	blockReader := s.blockReader
@@ -1047,10 +1059,29 @@ func (s *Ethereum) Init(stack *node.Node, config *ethconfig.Config, chainConfig
 	}
 
 	//eth.APIBackend.gpo = gasprice.NewOracle(eth.APIBackend, gpoParams)
+	var reporterURLs []string
 	if config.Ethstats != "" {
+		reporterURLs = append(reporterURLs, "ethstats://"+config.Ethstats)
+	}
+	for _, url := range strings.Split(config.Reporter, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			reporterURLs = append(reporterURLs, url)
+		}
+	}
+	if len(reporterURLs) > 0 {
 		var headCh chan [][]byte
 		headCh, s.unsubscribeEthstat = s.notifications.Events.AddHeaderSubscription()
-		if err := ethstats.New(stack, s.sentryServers, chainKv, s.blockReader, s.engine, config.Ethstats, s.networkID, ctx.Done(), headCh, txPoolRpcClient); err != nil {
+		sources := &reporter.Sources{
+			Node:        stack.Config().NodeName(),
+			NetworkID:   s.networkID,
+			ChainDB:     chainKv,
+			BlockReader: s.blockReader,
+			Sentries:    s.sentryServers,
+			TxPool:      txPoolRpcClient,
+			Downloader:  s.downloaderClient,
+			CaplinDB:    s.caplinIndexingDB,
+		}
+		if err := reporter.New(stack, sources, reporterURLs, config.ReporterInterval, ctx.Done(), headCh, s.logger); err != nil {
 			return err
 		}
 	}
@@ -1082,7 +1113,8 @@ func (s *Ethereum) Init(stack *node.Node, config *ethconfig.Config, chainConfig
 		s.silkwormRPCDaemonService = &silkwormRPCDaemonService
 	} else {
 		go func() {
-			if err := cli.StartRpcServer(ctx, &httpRpcCfg, s.apiList, s.logger); err != nil {
+			healthComponents := &health.Components{DB: chainKv, TxPool: txPoolRpcClient, StateCache: stateCache}
+			if err := cli.StartRpcServer(ctx, &httpRpcCfg, s.apiList, healthComponents, s.logger); err != nil {
 				s.logger.Error("cli.StartRpcServer error", "err", err)
 			}
 		}()
@@ -1457,6 +1489,7 @@ func setUpBlockReader(ctx context.Context, db kv.RwDB, dirs datadir.Dirs, snConf
 	}
 
 	agg.SetProduceMod(snConfig.Snapshot.ProduceE3)
+	agg.SetKeepCommitmentHistory(snConfig.KeepExecutionProofs)
 
 	g := &errgroup.Group{}
 	g.Go(func() error {
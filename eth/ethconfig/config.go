@@ -189,6 +189,9 @@ type Config struct {
 	Prune     prune.Mode
 	BatchSize datasize.ByteSize // Batch size for execution stage
 
+	// KeepExecutionProofs keeps commitment domain history, so eth_getProof can serve blocks other than latest
+	KeepExecutionProofs bool
+
 	ImportMode bool
 
 	BadBlockHash common.Hash // hash of the block marked as bad
@@ -234,6 +237,8 @@ type Config struct {
 
 	// URL to connect to Heimdall node
 	HeimdallURL string
+	// Dir to record Heimdall responses to, empty - don't record
+	HeimdallRecordDir string
 	// No heimdall service
 	WithoutHeimdall bool
 	// Heimdall services active
@@ -246,6 +251,9 @@ type Config struct {
 
 	// Ethstats service
 	Ethstats string
+	// Reporter destinations, comma-separated, see reporter.NewBackend
+	Reporter         string
+	ReporterInterval time.Duration
 	// Consensus layer
 	InternalCL             bool
 	CaplinDiscoveryAddr    string
@@ -280,6 +288,8 @@ type Sync struct {
 	LoopThrottle     time.Duration
 	ExecWorkerCount  int
 	ReconWorkerCount int
+	// SpeculativeExecWorkers - if > 1, transactions of blocks executed at the chain tip run speculatively in parallel
+	SpeculativeExecWorkers int
 
 	BodyCacheLimit             datasize.ByteSize
 	BodyDownloadTimeoutSeconds int // TODO: change to duration
@@ -4,6 +4,7 @@ package ethconfig
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/c2h5oh/datasize"
 	"github.com/erigontech/erigon-lib/chain"
@@ -48,12 +49,15 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		RPCTxFeeCap                    float64 `toml:",omitempty"`
 		StateStream                    bool
 		HeimdallURL                    string
+		HeimdallRecordDir              string
 		WithoutHeimdall                bool
 		WithHeimdallMilestones         bool
 		WithHeimdallWaypointRecording  bool
 		PolygonSync                    bool
 		PolygonSyncStage               bool
 		Ethstats                       string
+		Reporter                       string
+		ReporterInterval               time.Duration
 		InternalCL                     bool
 		CaplinDiscoveryAddr            string
 		CaplinDiscoveryPort            uint64
@@ -101,12 +105,15 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
 	enc.StateStream = c.StateStream
 	enc.HeimdallURL = c.HeimdallURL
+	enc.HeimdallRecordDir = c.HeimdallRecordDir
 	enc.WithoutHeimdall = c.WithoutHeimdall
 	enc.WithHeimdallMilestones = c.WithHeimdallMilestones
 	enc.WithHeimdallWaypointRecording = c.WithHeimdallWaypointRecording
 	enc.PolygonSync = c.PolygonSync
 	enc.PolygonSyncStage = c.PolygonSyncStage
 	enc.Ethstats = c.Ethstats
+	enc.Reporter = c.Reporter
+	enc.ReporterInterval = c.ReporterInterval
 	enc.InternalCL = c.InternalCL
 	enc.CaplinDiscoveryAddr = c.CaplinDiscoveryAddr
 	enc.CaplinDiscoveryPort = c.CaplinDiscoveryPort
@@ -158,12 +165,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		RPCTxFeeCap                    *float64 `toml:",omitempty"`
 		StateStream                    *bool
 		HeimdallURL                    *string
+		HeimdallRecordDir              *string
 		WithoutHeimdall                *bool
 		WithHeimdallMilestones         *bool
 		WithHeimdallWaypointRecording  *bool
 		PolygonSync                    *bool
 		PolygonSyncStage               *bool
 		Ethstats                       *string
+		Reporter                       *string
+		ReporterInterval               *time.Duration
 		InternalCL                     *bool
 		CaplinDiscoveryAddr            *string
 		CaplinDiscoveryPort            *uint64
@@ -264,6 +274,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.HeimdallURL != nil {
 		c.HeimdallURL = *dec.HeimdallURL
 	}
+	if dec.HeimdallRecordDir != nil {
+		c.HeimdallRecordDir = *dec.HeimdallRecordDir
+	}
 	if dec.WithoutHeimdall != nil {
 		c.WithoutHeimdall = *dec.WithoutHeimdall
 	}
@@ -282,6 +295,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.Ethstats != nil {
 		c.Ethstats = *dec.Ethstats
 	}
+	if dec.Reporter != nil {
+		c.Reporter = *dec.Reporter
+	}
+	if dec.ReporterInterval != nil {
+		c.ReporterInterval = *dec.ReporterInterval
+	}
 	if dec.InternalCL != nil {
 		c.InternalCL = *dec.InternalCL
 	}
@@ -78,6 +78,13 @@ var DefaultTxPool2Config = func(fullCfg *Config) txpoolcfg.Config {
 	cfg.LogEvery = 3 * time.Minute
 	cfg.CommitEvery = 5 * time.Minute
 	cfg.TracedSenders = pool1Cfg.TracedSenders
+	cfg.TrustedSenders = fullCfg.TxPool.TrustedSenders
+	cfg.LocalLaneSlots = fullCfg.TxPool.LocalLaneSlots
+	cfg.TrustedLaneSlots = fullCfg.TxPool.TrustedLaneSlots
+	cfg.RemoteLaneSlots = fullCfg.TxPool.RemoteLaneSlots
+	cfg.PeerTxsPerSecond = fullCfg.TxPool.PeerTxsPerSecond
+	cfg.PeerTxsBurst = fullCfg.TxPool.PeerTxsBurst
+	cfg.DiscardHistoryRetention = fullCfg.TxPool.DiscardHistoryRetention
 	cfg.CommitEvery = pool1Cfg.CommitEvery
 
 	return cfg
@@ -25,6 +25,7 @@ import (
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/wrap"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/turbo/indexer"
 )
 
 func DefaultStages(ctx context.Context,
@@ -140,23 +141,24 @@ func DefaultStages(ctx context.Context,
 				return PruneExecutionStage(p, tx, exec, ctx)
 			},
 		},
-		//{
-		//	ID:          stages.CustomTrace,
-		//	Description: "Re-Execute blocks on history state - with custom tracer",
-		//	Disabled:    dbg.StagesOnlyBlocks,
-		//	Forward: func(badBlockUnwind bool, s *StageState, u Unwinder, txc wrap.TxContainer, logger log.Logger) error {
-		//		cfg := StageCustomTraceCfg(exec.db, exec.prune, exec.dirs, exec.blockReader, exec.chainConfig, exec.engine, exec.genesis, &exec.syncCfg)
-		//		return SpawnCustomTrace(s, txc, cfg, ctx, 0, logger)
-		//	},
-		//	Unwind: func(u *UnwindState, s *StageState, txc wrap.TxContainer, logger log.Logger) error {
-		//		cfg := StageCustomTraceCfg(exec.db, exec.prune, exec.dirs, exec.blockReader, exec.chainConfig, exec.engine, exec.genesis, &exec.syncCfg)
-		//		return UnwindCustomTrace(u, s, txc, cfg, ctx, logger)
-		//	},
-		//	Prune: func(p *PruneState, tx kv.RwTx, logger log.Logger) error {
-		//		cfg := StageCustomTraceCfg(exec.db, exec.prune, exec.dirs, exec.blockReader, exec.chainConfig, exec.engine, exec.genesis, &exec.syncCfg)
-		//		return PruneCustomTrace(p, tx, cfg, ctx, logger)
-		//	},
-		//},
+		{
+			ID:                  stages.CustomTrace,
+			Description:         "Re-Execute blocks on history state - with user-defined indexers",
+			Disabled:            dbg.StagesOnlyBlocks || len(indexer.Registered()) == 0,
+			DisabledDescription: "Enabled by registering indexers, see package turbo/indexer",
+			Forward: func(badBlockUnwind bool, s *StageState, u Unwinder, txc wrap.TxContainer, logger log.Logger) error {
+				cfg := StageCustomTraceCfg(exec.db, exec.prune, exec.dirs, exec.blockReader, exec.chainConfig, exec.engine, exec.genesis, &exec.syncCfg)
+				return SpawnCustomTrace(s, txc, cfg, ctx, 0, logger)
+			},
+			Unwind: func(u *UnwindState, s *StageState, txc wrap.TxContainer, logger log.Logger) error {
+				cfg := StageCustomTraceCfg(exec.db, exec.prune, exec.dirs, exec.blockReader, exec.chainConfig, exec.engine, exec.genesis, &exec.syncCfg)
+				return UnwindCustomTrace(u, s, txc, cfg, ctx, logger)
+			},
+			Prune: func(p *PruneState, tx kv.RwTx, logger log.Logger) error {
+				cfg := StageCustomTraceCfg(exec.db, exec.prune, exec.dirs, exec.blockReader, exec.chainConfig, exec.engine, exec.genesis, &exec.syncCfg)
+				return PruneCustomTrace(p, tx, cfg, ctx, logger)
+			},
+		},
 		{
 			ID:          stages.TxLookup,
 			Description: "Generate txn lookup index",
@@ -552,7 +554,7 @@ var DefaultForwardOrder = UnwindOrder{
 	// Stages below don't use Internet
 	stages.Senders,
 	stages.Execution,
-	//stages.CustomTrace,
+	stages.CustomTrace,
 	stages.TxLookup,
 	stages.Finish,
 }
@@ -568,7 +570,7 @@ var DefaultUnwindOrder = UnwindOrder{
 	stages.Finish,
 	stages.TxLookup,
 
-	//stages.CustomTrace,
+	stages.CustomTrace,
 	stages.Execution,
 	stages.Senders,
 
@@ -608,6 +610,7 @@ var DefaultPruneOrder = PruneOrder{
 	stages.Finish,
 	stages.TxLookup,
 
+	stages.CustomTrace,
 	stages.Execution,
 	stages.Senders,
 
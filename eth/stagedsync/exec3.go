@@ -360,6 +360,12 @@ func ExecV3(ctx context.Context,
 	defer stopWorkers()
 	applyWorker.DiscardReadList()
 
+	// at the chain tip blocks are executed one by one: execute the transactions of each block speculatively in parallel
+	var specExec *exec3.SpeculativeExecutor
+	if !parallel && !initialCycle && cfg.syncCfg.SpeculativeExecWorkers > 1 {
+		specExec = exec3.NewSpeculativeExecutor(ctx, applyWorker, cfg.syncCfg.SpeculativeExecWorkers, logger)
+	}
+
 	commitThreshold := batchSize.Bytes()
 	progress := NewProgress(blockNum, commitThreshold, workerCount, execStage.LogPrefix(), logger)
 	logEvery := time.NewTicker(20 * time.Second)
@@ -719,6 +725,7 @@ Loop:
 		// So we skip that check for the first block, if we find half-executed data.
 		skipPostEvaluation := false
 		var usedGas, blobGasUsed uint64
+		speculative, speculated := specExec != nil && len(txs) > 1 && offsetFromBlockBeginning == 0, false
 
 		for txIndex := -1; txIndex <= len(txs); txIndex++ {
 			// Do not oversend, wait for the result heap to go under certain size
@@ -793,7 +800,20 @@ Loop:
 			if txTask.Error != nil {
 				break Loop
 			}
-			applyWorker.RunTxTaskNoLock(txTask)
+			if speculative && txIndex == 0 {
+				// the block initialisation is applied: all transactions see the state they would see if executed first
+				specTasks, err := speculativeTxTasks(txTask, signer)
+				if err != nil {
+					return err
+				}
+				specExec.Execute(specTasks)
+				speculated = true
+			}
+			if speculated && txIndex >= 0 && txIndex < len(txs) {
+				specExec.RunTxTask(txTask)
+			} else {
+				applyWorker.RunTxTaskNoLock(txTask)
+			}
 			if err := func() error {
 				if errors.Is(txTask.Error, context.Canceled) {
 					return err
@@ -830,6 +850,9 @@ Loop:
 					return err
 				}
 				if errors.Is(err, consensus.ErrInvalidBlock) {
+					if err := writeBadBlock(ctx, applyTx, cfg, b, err); err != nil {
+						return err
+					}
 					if err := u.UnwindTo(blockNum-1, BadBlock(header.Hash(), err), applyTx); err != nil {
 						return err
 					}
@@ -851,6 +874,10 @@ Loop:
 			stageProgress = blockNum
 			inputTxNum++
 		}
+		if speculated {
+			stats := specExec.Stats()
+			logger.Debug(fmt.Sprintf("[%s] speculative execution", execStage.LogPrefix()), "block", blockNum, "txs", stats.Txs, "reExecuted", stats.ReExecuted)
+		}
 		if shouldGenerateChangesets {
 			aggTx := applyTx.(state2.HasAggTx).AggTx().(*state2.AggregatorRoTx)
 			aggTx.RestrictSubsetFileDeletions(true)
@@ -902,7 +929,7 @@ Loop:
 					t1, t2, t3 time.Duration
 				)
 
-				if ok, err := flushAndCheckCommitmentV3(ctx, b.HeaderNoCopy(), applyTx, doms, cfg, execStage, stageProgress, parallel, logger, u, inMemExec); err != nil {
+				if ok, err := flushAndCheckCommitmentV3(ctx, b, applyTx, doms, cfg, execStage, stageProgress, parallel, logger, u, inMemExec); err != nil {
 					return err
 				} else if !ok {
 					break Loop
@@ -988,7 +1015,7 @@ Loop:
 
 	if u != nil && !u.HasUnwindPoint() {
 		if b != nil {
-			_, err := flushAndCheckCommitmentV3(ctx, b.HeaderNoCopy(), applyTx, doms, cfg, execStage, stageProgress, parallel, logger, u, inMemExec)
+			_, err := flushAndCheckCommitmentV3(ctx, b, applyTx, doms, cfg, execStage, stageProgress, parallel, logger, u, inMemExec)
 			if err != nil {
 				return err
 			}
@@ -1062,7 +1089,8 @@ func dumpPlainStateDebug(tx kv.RwTx, doms *state2.SharedDomains) {
 }
 
 // flushAndCheckCommitmentV3 - does write state to db and then check commitment
-func flushAndCheckCommitmentV3(ctx context.Context, header *types.Header, applyTx kv.RwTx, doms *state2.SharedDomains, cfg ExecuteBlockCfg, e *StageState, maxBlockNum uint64, parallel bool, logger log.Logger, u Unwinder, inMemExec bool) (bool, error) {
+func flushAndCheckCommitmentV3(ctx context.Context, b *types.Block, applyTx kv.RwTx, doms *state2.SharedDomains, cfg ExecuteBlockCfg, e *StageState, maxBlockNum uint64, parallel bool, logger log.Logger, u Unwinder, inMemExec bool) (bool, error) {
+	header := b.HeaderNoCopy()
 
 	// E2 state root check was in another stage - means we did flush state even if state root will not match
 	// And Unwind expecting it
@@ -1131,12 +1159,52 @@ func flushAndCheckCommitmentV3(ctx context.Context, header *types.Header, applyT
 		return false, fmt.Errorf("%w: requested=%d, minAllowed=%d", ErrTooDeepUnwind, unwindTo, allowedUnwindTo)
 	}
 	logger.Warn("Unwinding due to incorrect root hash", "to", unwindTo)
+	if err := writeBadBlock(ctx, applyTx, cfg, b, ErrInvalidStateRootHash); err != nil {
+		return false, err
+	}
 	if err := u.UnwindTo(allowedUnwindTo, BadBlock(header.Hash(), ErrInvalidStateRootHash), applyTx); err != nil {
 		return false, err
 	}
 	return false, nil
 }
 
+// writeBadBlock - persists the block rejected by execution, to be analysed later (debug_getBadBlocks)
+func writeBadBlock(ctx context.Context, tx kv.RwTx, cfg ExecuteBlockCfg, b *types.Block, validationErr error) error {
+	var preStateRoot common.Hash
+	if b.NumberU64() > 0 {
+		parent, err := cfg.blockReader.Header(ctx, tx, b.ParentHash(), b.NumberU64()-1)
+		if err != nil {
+			return err
+		}
+		if parent != nil {
+			preStateRoot = parent.Root
+		}
+	}
+	return rawdb.WriteBadBlock(tx, b, validationErr, preStateRoot)
+}
+
+// speculativeTxTasks returns the tasks of all transactions of the block, made from the task of the first one
+func speculativeTxTasks(first *state.TxTask, signer types.Signer) ([]*state.TxTask, error) {
+	txTasks := make([]*state.TxTask, len(first.Txs))
+	for i, txn := range first.Txs {
+		txTask := *first
+		txTask.TxNum, txTask.TxIndex, txTask.Tx = first.TxNum+uint64(i), i, txn
+		var err error
+		if txTask.TxAsMessage, err = txn.AsMessage(signer, first.Header.BaseFee, first.Rules); err != nil {
+			return nil, err
+		}
+		sender, ok := txn.GetSender()
+		if !ok {
+			if sender, err = signer.Sender(txn); err != nil {
+				return nil, err
+			}
+		}
+		txTask.Sender = &sender
+		txTasks[i] = &txTask
+	}
+	return txTasks, nil
+}
+
 func blockWithSenders(ctx context.Context, db kv.RoDB, tx kv.Tx, blockReader services.BlockReader, blockNum uint64) (b *types.Block, err error) {
 	if tx == nil {
 		tx, err = db.BeginRo(ctx)
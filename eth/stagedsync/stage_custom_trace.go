@@ -19,25 +19,35 @@ package stagedsync
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/erigontech/erigon-lib/chain"
 	"github.com/erigontech/erigon-lib/common/datadir"
 	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
 	"github.com/erigontech/erigon-lib/log/v3"
 	"github.com/erigontech/erigon-lib/wrap"
 	"github.com/erigontech/erigon/cmd/state/exec3"
 	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/core/state"
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/ethdb/prune"
+	"github.com/erigontech/erigon/turbo/indexer"
 	"github.com/erigontech/erigon/turbo/services"
 )
 
+// customTraceBatchBlocks is the amount of blocks re-executed at once, writes of indexers are kept in memory until
+// the batch is done.
+const customTraceBatchBlocks = 1_000
+
 type CustomTraceCfg struct {
 	tmpdir   string
 	db       kv.RwDB
 	prune    prune.Mode
 	execArgs *exec3.ExecArgs
+	indexers []indexer.Indexer
 }
 
 func StageCustomTraceCfg(db kv.RwDB, prune prune.Mode, dirs datadir.Dirs, br services.FullBlockReader, cc *chain.Config,
@@ -53,14 +63,24 @@ func StageCustomTraceCfg(db kv.RwDB, prune prune.Mode, dirs datadir.Dirs, br ser
 		Workers:     syncCfg.ExecWorkerCount,
 	}
 	return CustomTraceCfg{
+		tmpdir:   dirs.Tmp,
 		db:       db,
 		prune:    prune,
 		execArgs: execArgs,
+		indexers: indexer.Registered(),
 	}
 }
 
+// WithIndexers replaces the registered indexers, e.g. to run a subset of them.
+func (cfg CustomTraceCfg) WithIndexers(indexers ...indexer.Indexer) CustomTraceCfg {
+	cfg.indexers = indexers
+	return cfg
+}
+
+// SpawnCustomTrace re-executes history and feeds results of txns to the registered indexers, see package indexer.
+// Each indexer continues from its own progress, so a newly registered indexer catches up with the others.
 func SpawnCustomTrace(s *StageState, txc wrap.TxContainer, cfg CustomTraceCfg, ctx context.Context, prematureEndBlock uint64, logger log.Logger) error {
-	useExternalTx := txc.Ttx != nil
+	useExternalTx := txc.Tx != nil
 	var tx kv.TemporalRwTx
 	if !useExternalTx {
 		_tx, err := cfg.db.BeginRw(ctx)
@@ -70,117 +90,82 @@ func SpawnCustomTrace(s *StageState, txc wrap.TxContainer, cfg CustomTraceCfg, c
 		defer _tx.Rollback()
 		tx = _tx.(kv.TemporalRwTx)
 	} else {
-		tx = txc.Ttx.(kv.TemporalRwTx)
-	}
-
-	//endBlock, err := s.ExecutionAt(tx)
-	//if err != nil {
-	//	return fmt.Errorf("getting last executed block: %w", err)
-	//}
-	//if s.BlockNumber > endBlock { // Erigon will self-heal (download missed blocks) eventually
-	//	return nil
-	//}
-	//// if prematureEndBlock is nonzero and less than the latest executed block,
-	//// then we only run the log index stage until prematureEndBlock
-	//if prematureEndBlock != 0 && prematureEndBlock < endBlock {
-	//	endBlock = prematureEndBlock
-	//}
-	//// It is possible that prematureEndBlock < s.BlockNumber,
-	//// in which case it is important that we skip this stage,
-	//// or else we could overwrite stage_at with prematureEndBlock
-	//if endBlock <= s.BlockNumber {
-	//	return nil
-	//}
-	//
-	//startBlock := s.BlockNumber
-	//if startBlock > 0 {
-	//	startBlock++
-	//}
-	//
-	//logEvery := time.NewTicker(10 * time.Second)
-	//defer logEvery.Stop()
-	//var m runtime.MemStats
-	//var prevBlockNumLog uint64 = startBlock
-	//
-	//doms, err := state2.NewSharedDomains(tx, logger)
-	//if err != nil {
-	//	return err
-	//}
-	//defer doms.Close()
-	//
-	//cumulative := uint256.NewInt(0)
-	//var lastBlockNum uint64
-	//
-	//canonicalReader := doms.CanonicalReader()
-	//lastFrozenID, err := canonicalReader.LastFrozenTxNum(tx)
-	//if err != nil {
-	//	return err
-	//}
-	//
-	//var baseBlockTxnID, txnID kv.TxnId
-	//fmt.Printf("dbg1: %s\n", tx.ViewID())
-	////TODO: new tracer may get tracer from pool, maybe add it to TxTask field
-	///// maybe need startTxNum/endTxNum
-	//if err = exec3.CustomTraceMapReduce(startBlock, endBlock, exec3.TraceConsumer{
-	//	NewTracer: func() exec3.GenericTracer { return nil },
-	//	Reduce: func(txTask *state.TxTask, tx kv.Tx) error {
-	//		if txTask.Error != nil {
-	//			return err
-	//		}
-	//
-	//		if lastBlockNum != txTask.BlockNum {
-	//			cumulative.Set(u256.N0)
-	//			lastBlockNum = txTask.BlockNum
-	//
-	//			if txTask.TxNum < uint64(lastFrozenID) {
-	//				txnID = kv.TxnId(txTask.TxNum)
-	//			} else {
-	//				h, err := rawdb.ReadCanonicalHash(tx, txTask.BlockNum)
-	//				baseBlockTxnID, err = canonicalReader.BaseTxnID(tx, txTask.BlockNum, h)
-	//				if err != nil {
-	//					return err
-	//				}
-	//				txnID = baseBlockTxnID
-	//			}
-	//		} else {
-	//			txnID++
-	//		}
-	//		cumulative.AddUint64(cumulative, txTask.UsedGas)
-	//
-	//		if txTask.Final || txTask.TxIndex < 0 {
-	//			return nil
-	//		}
-	//		r := txTask.CreateReceipt(cumulative.Uint64())
-	//		v, err := rlp.EncodeToBytes(r)
-	//		if err != nil {
-	//			return err
-	//		}
-	//		doms.SetTx(tx)
-	//		err = doms.AppendablePut(kv.ReceiptsAppendable, txnID, v)
-	//		if err != nil {
-	//			return err
-	//		}
-	//
-	//		select {
-	//		case <-logEvery.C:
-	//			dbg.ReadMemStats(&m)
-	//			log.Info("Scanned", "block", txTask.BlockNum, "blk/sec", float64(txTask.BlockNum-prevBlockNumLog)/10, "alloc", libcommon.ByteCount(m.Alloc), "sys", libcommon.ByteCount(m.Sys))
-	//			prevBlockNumLog = txTask.BlockNum
-	//		default:
-	//		}
-	//
-	//		return nil
-	//	},
-	//}, ctx, tx, cfg.execArgs, logger); err != nil {
-	//	return err
-	//}
-	//if err := doms.Flush(ctx, tx); err != nil {
-	//	return err
-	//}
-	//
-	//if err = s.Update(tx.(kv.RwTx), endBlock); err != nil {
-	//	return err
-	//}
+		tx = txc.Tx.(kv.TemporalRwTx)
+	}
+
+	endBlock, err := s.ExecutionAt(tx)
+	if err != nil {
+		return fmt.Errorf("getting last executed block: %w", err)
+	}
+	// workers of CustomTraceMapReduce read history by their own transactions, so they see only committed blocks
+	committedBlock, err := committedExecutionProgress(ctx, cfg.db)
+	if err != nil {
+		return err
+	}
+	endBlock = min(endBlock, committedBlock)
+	// if prematureEndBlock is nonzero and less than the latest executed block,
+	// then we only run the stage until prematureEndBlock
+	if prematureEndBlock != 0 && prematureEndBlock < endBlock {
+		endBlock = prematureEndBlock
+	}
+
+	// next block to index by each of indexers
+	next := make([]uint64, len(cfg.indexers))
+	startBlock := endBlock + 1
+	for i, ix := range cfg.indexers {
+		progress, ok, err := indexer.Progress(tx, ix.Name())
+		if err != nil {
+			return err
+		}
+		if ok {
+			next[i] = progress + 1
+		}
+		startBlock = min(startBlock, next[i])
+	}
+	if cfg.prune.History.Enabled() {
+		if historyFrom := cfg.prune.History.PruneTo(endBlock); startBlock < historyFrom {
+			logger.Warn(fmt.Sprintf("[%s] history is pruned, indexers skip blocks", s.LogPrefix()), "from", startBlock, "to", historyFrom)
+			startBlock = historyFrom
+		}
+	}
+
+	if startBlock <= endBlock {
+		logger.Info(fmt.Sprintf("[%s] Running indexers", s.LogPrefix()), "indexers", len(cfg.indexers), "from", startBlock, "to", endBlock)
+	}
+	logEvery := time.NewTicker(30 * time.Second)
+	defer logEvery.Stop()
+	prevBlock, prevTime := startBlock, time.Now()
+	var batch indexer.Batch
+	for fromBlock := startBlock; fromBlock <= endBlock; fromBlock += customTraceBatchBlocks {
+		toBlock := min(fromBlock+customTraceBatchBlocks-1, endBlock)
+		if err := exec3.CustomTraceMapReduce(fromBlock, toBlock, customTraceConsumer(cfg.indexers, next, &batch), ctx, tx, cfg.execArgs, logger); err != nil {
+			return err
+		}
+		if err := batch.Flush(tx); err != nil {
+			return err
+		}
+		for i, ix := range cfg.indexers {
+			if next[i] <= toBlock {
+				next[i] = toBlock + 1
+				if err := indexer.SaveProgress(tx, ix.Name(), toBlock); err != nil {
+					return err
+				}
+			}
+		}
+		if err := s.Update(tx, toBlock); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-logEvery.C:
+			speed := float64(toBlock-prevBlock) / time.Since(prevTime).Seconds()
+			logger.Info(fmt.Sprintf("[%s] Progress", s.LogPrefix()), "block", toBlock, "blk/s", fmt.Sprintf("%.1f", speed))
+			prevBlock, prevTime = toBlock, time.Now()
+		default:
+		}
+	}
 
 	if !useExternalTx {
 		if err := tx.Commit(); err != nil {
@@ -191,21 +176,114 @@ func SpawnCustomTrace(s *StageState, txc wrap.TxContainer, cfg CustomTraceCfg, c
 	return nil
 }
 
+// committedExecutionProgress reads in a separate goroutine, because the caller holds RwTx and mdbx doesn't allow
+// read and write transactions in the same thread.
+func committedExecutionProgress(ctx context.Context, db kv.RoDB) (progress uint64, err error) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err = db.View(ctx, func(tx kv.Tx) (err error) {
+			progress, err = stages.GetStageProgress(tx, stages.Execution)
+			return err
+		})
+	}()
+	<-done
+	return progress, err
+}
+
+// customTraceConsumer collects results of txns by parallel workers and feeds them to indexers in the order of txns.
+// Indexers skip blocks before their next block, writes go to the batch.
+func customTraceConsumer(indexers []indexer.Indexer, next []uint64, batch *indexer.Batch) exec3.TraceConsumer {
+	var opts indexer.Options
+	for _, ix := range indexers {
+		o := ix.Options()
+		opts.Calls = opts.Calls || o.Calls
+		opts.StateDiff = opts.StateDiff || o.StateDiff
+	}
+
+	consumer := exec3.TraceConsumer{
+		Map: func(task *state.TxTask, tracer exec3.GenericTracer, ibs *state.IntraBlockState) error {
+			res := &indexer.TxResult{
+				BlockNum:  task.BlockNum,
+				BlockHash: task.BlockHash,
+				TxNum:     task.TxNum,
+				TxIndex:   task.TxIndex,
+				Tx:        task.Tx,
+				Failed:    task.Failed,
+				UsedGas:   task.UsedGas,
+				Logs:      task.Logs,
+			}
+			if task.Sender != nil {
+				res.Sender = *task.Sender
+			}
+			if t, ok := tracer.(*indexer.CallTracer); ok {
+				res.Calls = t.Calls()
+			}
+			if opts.StateDiff {
+				w := indexer.NewStateDiffWriter()
+				// CommitBlock, not MakeWriteSet: balance increases of not loaded accounts (e.g. fees of coinbase) are included
+				if err := ibs.CommitBlock(task.Rules, w); err != nil {
+					return err
+				}
+				res.StateDiff = w.Diff()
+			}
+			task.MapResult = res
+			return nil
+		},
+		Reduce: func(task *state.TxTask, tx kv.Tx) error {
+			res, ok := task.MapResult.(*indexer.TxResult)
+			if !ok { // system txns
+				return nil
+			}
+			for i, ix := range indexers {
+				if task.BlockNum < next[i] {
+					continue
+				}
+				if err := ix.Index(res, batch.Writer(ix.Name(), task.TxNum)); err != nil {
+					return fmt.Errorf("indexer %s, block %d, txIndex %d: %w", ix.Name(), task.BlockNum, task.TxIndex, err)
+				}
+			}
+			return nil
+		},
+	}
+	if opts.Calls {
+		consumer.NewTracer = func() exec3.GenericTracer { return indexer.NewCallTracer() }
+	}
+	return consumer
+}
+
 func UnwindCustomTrace(u *UnwindState, s *StageState, txc wrap.TxContainer, cfg CustomTraceCfg, ctx context.Context, logger log.Logger) (err error) {
-	useExternalTx := txc.Ttx != nil
-	var tx kv.TemporalTx
+	useExternalTx := txc.Tx != nil
+	tx := txc.Tx
 	if !useExternalTx {
-		_tx, err := cfg.db.BeginRw(ctx)
+		tx, err = cfg.db.BeginRw(ctx)
 		if err != nil {
 			return err
 		}
-		defer _tx.Rollback()
-		tx = _tx.(kv.TemporalTx)
-	} else {
-		tx = txc.Ttx
+		defer tx.Rollback()
+	}
+
+	// unwinds all indexers found in the db, registered or not, because they share the tables
+	progresses, err := indexer.Progresses(tx)
+	if err != nil {
+		return err
+	}
+	for name, progress := range progresses {
+		if progress > u.UnwindPoint {
+			if err := indexer.SaveProgress(tx, name, u.UnwindPoint); err != nil {
+				return err
+			}
+		}
+	}
+	maxTxNum, err := rawdbv3.TxNums.Max(tx, u.UnwindPoint)
+	if err != nil {
+		return err
+	}
+	if err := indexer.Unwind(tx, maxTxNum+1); err != nil {
+		return fmt.Errorf("unwind indexers: %w", err)
 	}
 
-	if err := u.Done(tx.(kv.RwTx)); err != nil {
+	if err := u.Done(tx); err != nil {
 		return fmt.Errorf("%w", err)
 	}
 	if !useExternalTx {
@@ -216,6 +294,41 @@ func UnwindCustomTrace(u *UnwindState, s *StageState, txc wrap.TxContainer, cfg
 	return nil
 }
 
+// PruneCustomTrace prunes inverted indices and domain history of indexers together with the history, latest values
+// of domains are kept.
 func PruneCustomTrace(s *PruneState, tx kv.RwTx, cfg CustomTraceCfg, ctx context.Context, logger log.Logger) (err error) {
+	if !cfg.prune.History.Enabled() {
+		return nil
+	}
+	useExternalTx := tx != nil
+	if !useExternalTx {
+		tx, err = cfg.db.BeginRw(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	blockTo := cfg.prune.History.PruneTo(s.ForwardProgress)
+	// limit the amount of deletes per cycle, the first prune may cover the whole history
+	blockTo = min(blockTo, s.PruneProgress+customTraceBatchBlocks)
+	if s.PruneProgress < blockTo {
+		minTxNum, err := rawdbv3.TxNums.Min(tx, blockTo)
+		if err != nil {
+			return err
+		}
+		if err := indexer.Prune(tx, minTxNum); err != nil {
+			return fmt.Errorf("prune indexers: %w", err)
+		}
+		if err = s.DoneAt(tx, blockTo); err != nil {
+			return err
+		}
+	}
+
+	if !useExternalTx {
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
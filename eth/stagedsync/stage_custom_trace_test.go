@@ -0,0 +1,246 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package stagedsync_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon-lib/kv/stream"
+	"github.com/erigontech/erigon-lib/wrap"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/stagedsync"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/ethdb/prune"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/turbo/indexer"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+// recipientsIndexer indexes receivers of value transfers and keeps their latest balance
+type recipientsIndexer struct{}
+
+func (recipientsIndexer) Name() string { return "recipients" }
+func (recipientsIndexer) Options() indexer.Options {
+	return indexer.Options{Calls: true, StateDiff: true}
+}
+func (recipientsIndexer) Index(res *indexer.TxResult, w indexer.Writer) error {
+	for _, call := range res.Calls {
+		if call.Failed || call.Value == nil || call.Value.IsZero() {
+			continue
+		}
+		if err := w.IndexKey(call.To[:]); err != nil {
+			return err
+		}
+		if d, ok := res.StateDiff.Accounts[call.To]; ok && d.Account != nil {
+			if err := w.Put(call.To[:], d.Account.Balance.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type sendersIndexer struct{}
+
+func (sendersIndexer) Name() string             { return "senders" }
+func (sendersIndexer) Options() indexer.Options { return indexer.Options{} }
+func (sendersIndexer) Index(res *indexer.TxResult, w indexer.Writer) error {
+	return w.IndexKey(res.Sender[:])
+}
+
+func TestCustomTraceIndexers(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		a, b   = libcommon.HexToAddress("0xaa"), libcommon.HexToAddress("0xbb")
+		gspec  = &types.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	m := mock.MockWithGenesis(t, gspec, key, false)
+	// blocks 1 and 3 send to a, 2 and 4 to b
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 4, func(i int, block *core.BlockGen) {
+		to := a
+		if i%2 == 1 {
+			to = b
+		}
+		txn, err := types.SignTx(types.NewTransaction(block.TxNonce(sender), to, uint256.NewInt(100), 21000, uint256.NewInt(params.GWei), nil), *signer, key)
+		require.NoError(t, err)
+		block.AddTx(txn)
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	ctx := context.Background()
+	syncCfg := ethconfig.Defaults.Sync
+	syncCfg.ExecWorkerCount = 2
+	cfg := stagedsync.StageCustomTraceCfg(m.DB, prune.DefaultMode, m.Dirs, m.BlockReader, m.ChainConfig, m.Engine, gspec, &syncCfg)
+
+	spawn := func(cfg stagedsync.CustomTraceCfg) {
+		s, err := m.Sync.StageState(stages.CustomTrace, nil, m.DB, false, false)
+		require.NoError(t, err)
+		require.NoError(t, stagedsync.SpawnCustomTrace(s, wrap.TxContainer{}, cfg, ctx, 0, m.Log))
+	}
+	// blocks of txns indexed by the key, with the txn being the first one of the block
+	blocks := func(name string, key libcommon.Address) []uint64 {
+		var res []uint64
+		require.NoError(t, m.DB.View(ctx, func(tx kv.Tx) error {
+			it, err := indexer.IndexRange(tx, name, key[:], -1, -1, order.Asc, -1)
+			require.NoError(t, err)
+			txNums, err := stream.ToArrayU64(it)
+			require.NoError(t, err)
+			for _, txNum := range txNums {
+				ok, blockNum, err := rawdbv3.TxNums.FindBlockNum(tx, txNum)
+				require.NoError(t, err)
+				require.True(t, ok)
+				minTxNum, err := rawdbv3.TxNums.Min(tx, blockNum)
+				require.NoError(t, err)
+				require.Equal(t, minTxNum+1, txNum)
+				res = append(res, blockNum)
+			}
+			return nil
+		}))
+		return res
+	}
+	balance := func(addr libcommon.Address) uint64 {
+		var v []byte
+		require.NoError(t, m.DB.View(ctx, func(tx kv.Tx) (err error) {
+			v, err = indexer.GetValue(tx, "recipients", addr[:])
+			return err
+		}))
+		return new(uint256.Int).SetBytes(v).Uint64()
+	}
+	progress := func() map[string]uint64 {
+		var res map[string]uint64
+		require.NoError(t, m.DB.View(ctx, func(tx kv.Tx) (err error) {
+			res, err = indexer.Progresses(tx)
+			return err
+		}))
+		return res
+	}
+
+	spawn(cfg.WithIndexers(recipientsIndexer{}))
+	require.Equal(t, []uint64{1, 3}, blocks("recipients", a))
+	require.Equal(t, []uint64{2, 4}, blocks("recipients", b))
+	require.Equal(t, uint64(200), balance(b))
+	require.Equal(t, map[string]uint64{"recipients": 4}, progress())
+
+	// the new indexer catches up, the existing one doesn't index the blocks again
+	spawn(cfg.WithIndexers(recipientsIndexer{}, sendersIndexer{}))
+	require.Equal(t, []uint64{1, 2, 3, 4}, blocks("senders", sender))
+	require.Equal(t, []uint64{1, 3}, blocks("recipients", a))
+	require.Equal(t, map[string]uint64{"recipients": 4, "senders": 4}, progress())
+
+	s, err := m.Sync.StageState(stages.CustomTrace, nil, m.DB, false, false)
+	require.NoError(t, err)
+	u := m.Sync.NewUnwindState(stages.CustomTrace, 2, s.BlockNumber, false, false)
+	require.NoError(t, stagedsync.UnwindCustomTrace(u, s, wrap.TxContainer{}, cfg, ctx, m.Log))
+	require.Equal(t, []uint64{1}, blocks("recipients", a))
+	require.Equal(t, []uint64{1, 2}, blocks("senders", sender))
+	require.Equal(t, uint64(100), balance(b))
+	require.Equal(t, map[string]uint64{"recipients": 2, "senders": 2}, progress())
+
+	spawn(cfg.WithIndexers(recipientsIndexer{}, sendersIndexer{}))
+	require.Equal(t, []uint64{1, 3}, blocks("recipients", a))
+	require.Equal(t, []uint64{1, 2, 3, 4}, blocks("senders", sender))
+	require.Equal(t, uint64(200), balance(b))
+}
+
+// coinbaseIndexer keeps the fee the coinbase received for the latest txn
+type coinbaseIndexer struct{ coinbase libcommon.Address }
+
+func (coinbaseIndexer) Name() string             { return "coinbase" }
+func (coinbaseIndexer) Options() indexer.Options { return indexer.Options{StateDiff: true} }
+func (ix coinbaseIndexer) Index(res *indexer.TxResult, w indexer.Writer) error {
+	d, ok := res.StateDiff.Accounts[ix.coinbase]
+	if !ok || d.Account == nil {
+		return nil
+	}
+	if err := w.IndexKey(ix.coinbase[:]); err != nil {
+		return err
+	}
+	fee := d.Account.Balance
+	if d.Original != nil {
+		fee.Sub(&fee, &d.Original.Balance)
+	}
+	return w.Put(ix.coinbase[:], fee.Bytes())
+}
+
+func TestCustomTraceStateDiffCoinbase(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		coinbase = libcommon.HexToAddress("0xcc")
+		gspec    = &types.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer   = types.LatestSigner(gspec.Config)
+		gasPrice = uint256.NewInt(2 * params.GWei)
+	)
+	m := mock.MockWithGenesis(t, gspec, key, false)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 2, func(i int, block *core.BlockGen) {
+		block.SetCoinbase(coinbase)
+		txn, err := types.SignTx(types.NewTransaction(block.TxNonce(sender), libcommon.HexToAddress("0xaa"), uint256.NewInt(100), 21000, gasPrice, nil), *signer, key)
+		require.NoError(t, err)
+		block.AddTx(txn)
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	ctx := context.Background()
+	syncCfg := ethconfig.Defaults.Sync
+	syncCfg.ExecWorkerCount = 2
+	cfg := stagedsync.StageCustomTraceCfg(m.DB, prune.DefaultMode, m.Dirs, m.BlockReader, m.ChainConfig, m.Engine, gspec, &syncCfg)
+	s, err := m.Sync.StageState(stages.CustomTrace, nil, m.DB, false, false)
+	require.NoError(t, err)
+	require.NoError(t, stagedsync.SpawnCustomTrace(s, wrap.TxContainer{}, cfg.WithIndexers(coinbaseIndexer{coinbase}), ctx, 0, m.Log))
+
+	// the coinbase is only credited with the fees, it isn't otherwise touched by the txns
+	var (
+		txNums []uint64
+		v      []byte
+	)
+	require.NoError(t, m.DB.View(ctx, func(tx kv.Tx) error {
+		it, err := indexer.IndexRange(tx, "coinbase", coinbase[:], -1, -1, order.Asc, -1)
+		if err != nil {
+			return err
+		}
+		if txNums, err = stream.ToArrayU64(it); err != nil {
+			return err
+		}
+		v, err = indexer.GetValue(tx, "coinbase", coinbase[:])
+		return err
+	}))
+	require.Len(t, txNums, 2)
+	fee := new(uint256.Int).Mul(gasPrice, uint256.NewInt(21000))
+	require.Equal(t, fee, new(uint256.Int).SetBytes(v))
+}
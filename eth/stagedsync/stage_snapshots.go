@@ -677,8 +677,7 @@ type snapshotUploader struct {
 	cfg             *SnapshotsCfg
 	files           map[string]*uploadState
 	uploadFs        string
-	rclone          *downloader.RCloneClient
-	uploadSession   *downloader.RCloneSession
+	uploadSession   downloader.RemoteSession
 	uploadScheduled atomic.Bool
 	uploading       atomic.Bool
 	manifestMutex   sync.Mutex
@@ -823,6 +822,8 @@ func (u *snapshotUploader) downloadManifest(ctx context.Context) ([]fs.DirEntry,
 		return nil, err
 	}
 
+	defer reader.Close()
+
 	var entries []fs.DirEntry
 
 	scanner := bufio.NewScanner(reader)
@@ -1074,36 +1075,56 @@ func isLocalFs(ctx context.Context, rclient *downloader.RCloneClient, fs string)
 func (u *snapshotUploader) start(ctx context.Context, logger log.Logger) {
 	var err error
 
-	u.rclone, err = downloader.NewRCloneClient(logger)
-
-	if err != nil {
-		logger.Warn("[uploader] Uploading disabled: rclone start failed", "err", err)
-		return
-	}
-
 	uploadFs := u.uploadFs
 
-	if isLocalFs(ctx, u.rclone, uploadFs) {
-		uploadFs = expandHomeDir(filepath.Clean(uploadFs))
+	// s3:// and http(s):// locations are served natively, anything else goes through rclone
+	if downloader.IsObjectStoreFs(uploadFs) {
+		var session *downloader.ObjectStoreSession
 
-		uploadFs, err = filepath.Abs(uploadFs)
+		session, err = downloader.NewObjectStoreClient(logger).NewSession(ctx, u.cfg.dirs.Snap, uploadFs, nil)
 
 		if err != nil {
-			logger.Warn("[uploader] Uploading disabled: invalid upload fs", "err", err, "fs", u.uploadFs)
+			logger.Warn("[uploader] Uploading disabled: object store session failed", "err", err)
 			return
 		}
 
-		if err := os.MkdirAll(uploadFs, 0755); err != nil {
-			logger.Warn("[uploader] Uploading disabled: can't create upload fs", "err", err, "fs", u.uploadFs)
+		u.uploadSession = session
+	} else {
+		var rclone *downloader.RCloneClient
+
+		rclone, err = downloader.NewRCloneClient(logger)
+
+		if err != nil {
+			logger.Warn("[uploader] Uploading disabled: rclone start failed", "err", err)
 			return
 		}
-	}
 
-	u.uploadSession, err = u.rclone.NewSession(ctx, u.cfg.dirs.Snap, uploadFs, nil)
+		if isLocalFs(ctx, rclone, uploadFs) {
+			uploadFs = expandHomeDir(filepath.Clean(uploadFs))
 
-	if err != nil {
-		logger.Warn("[uploader] Uploading disabled: rclone session failed", "err", err)
-		return
+			uploadFs, err = filepath.Abs(uploadFs)
+
+			if err != nil {
+				logger.Warn("[uploader] Uploading disabled: invalid upload fs", "err", err, "fs", u.uploadFs)
+				return
+			}
+
+			if err := os.MkdirAll(uploadFs, 0755); err != nil {
+				logger.Warn("[uploader] Uploading disabled: can't create upload fs", "err", err, "fs", u.uploadFs)
+				return
+			}
+		}
+
+		var session *downloader.RCloneSession
+
+		session, err = rclone.NewSession(ctx, u.cfg.dirs.Snap, uploadFs, nil)
+
+		if err != nil {
+			logger.Warn("[uploader] Uploading disabled: rclone session failed", "err", err)
+			return
+		}
+
+		u.uploadSession = session
 	}
 
 	go func() {
@@ -1367,7 +1388,7 @@ func (u *snapshotUploader) upload(ctx context.Context, logger log.Logger) {
 			err := func() error {
 				state.Lock()
 				defer state.Unlock()
-				if !state.remote && state.torrent != nil && len(state.uploads) == 0 && u.rclone != nil {
+				if !state.remote && state.torrent != nil && len(state.uploads) == 0 && u.uploadSession != nil {
 					state.uploads = []string{state.file, state.file + ".torrent"}
 					uploadList = append(uploadList, state)
 				}
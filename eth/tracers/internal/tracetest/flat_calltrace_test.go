@@ -0,0 +1,311 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/dir"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon/common"
+	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/eth/tracers"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/tests"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+// flatCallTrace is the result of a flatCallTracer run.
+type flatCallTrace struct {
+	Action              flatCallTraceAction `json:"action"`
+	BlockHash           libcommon.Hash      `json:"-"`
+	BlockNumber         uint64              `json:"-"`
+	Error               string              `json:"error,omitempty"`
+	Result              flatCallTraceResult `json:"result,omitempty"`
+	Subtraces           int                 `json:"subtraces"`
+	TraceAddress        []int               `json:"traceAddress"`
+	TransactionHash     libcommon.Hash      `json:"-"`
+	TransactionPosition uint64              `json:"-"`
+	Type                string              `json:"type"`
+	Time                string              `json:"-"`
+}
+
+type flatCallTraceAction struct {
+	Author         libcommon.Address `json:"author,omitempty"`
+	RewardType     string            `json:"rewardType,omitempty"`
+	SelfDestructed libcommon.Address `json:"address,omitempty"`
+	Balance        hexutil.Big       `json:"balance,omitempty"`
+	CallType       string            `json:"callType,omitempty"`
+	CreationMethod string            `json:"creationMethod,omitempty"`
+	From           libcommon.Address `json:"from,omitempty"`
+	Gas            hexutil.Uint64    `json:"gas,omitempty"`
+	Init           hexutility.Bytes  `json:"init,omitempty"`
+	Input          hexutility.Bytes  `json:"input,omitempty"`
+	RefundAddress  libcommon.Address `json:"refundAddress,omitempty"`
+	To             libcommon.Address `json:"to,omitempty"`
+	Value          hexutil.Big       `json:"value,omitempty"`
+}
+
+type flatCallTraceResult struct {
+	Address libcommon.Address `json:"address,omitempty"`
+	Code    hexutility.Bytes  `json:"code,omitempty"`
+	GasUsed hexutil.Uint64    `json:"gasUsed,omitempty"`
+	Output  hexutility.Bytes  `json:"output,omitempty"`
+}
+
+// flatCallTracerTest defines a single test to check the flat call tracer against.
+type flatCallTracerTest struct {
+	Genesis      *types.Genesis  `json:"genesis"`
+	Context      *callContext    `json:"context"`
+	Input        string          `json:"input"`
+	TracerConfig json.RawMessage `json:"tracerConfig"`
+	Result       []flatCallTrace `json:"result"`
+}
+
+func flatCallTracerTestRunner(tracerName string, filename string, dirPath string, t testing.TB) error {
+	// Call tracer test found, read if from disk
+	blob, err := os.ReadFile(filepath.Join("testdata", dirPath, filename))
+	if err != nil {
+		return fmt.Errorf("failed to read testcase: %v", err)
+	}
+	test := new(flatCallTracerTest)
+	if err := json.Unmarshal(blob, test); err != nil {
+		return fmt.Errorf("failed to parse testcase: %v", err)
+	}
+	// Configure a blockchain with the given prestate
+	tx, err := types.UnmarshalTransactionFromBinary(common.FromHex(test.Input), false /* blobTxnsAreWrappedWithBlobs */)
+	if err != nil {
+		return fmt.Errorf("failed to parse testcase input: %v", err)
+	}
+	signer := types.MakeSigner(test.Genesis.Config, uint64(test.Context.Number), uint64(test.Context.Time))
+	context := evmtypes.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    consensus.Transfer,
+		Coinbase:    test.Context.Miner,
+		BlockNumber: uint64(test.Context.Number),
+		Time:        uint64(test.Context.Time),
+		Difficulty:  (*big.Int)(test.Context.Difficulty),
+		GasLimit:    uint64(test.Context.GasLimit),
+	}
+	if test.Context.BaseFee != nil {
+		context.BaseFee, _ = uint256.FromBig((*big.Int)(test.Context.BaseFee))
+	}
+	rules := test.Genesis.Config.Rules(context.BlockNumber, context.Time)
+
+	m := mock.Mock(t)
+	dbTx, err := m.DB.BeginRw(m.Ctx)
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+	statedb, err := tests.MakePreState(rules, dbTx, test.Genesis.Alloc, context.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to create pre-state: %v", err)
+	}
+
+	// Create the tracer, the EVM environment and run it
+	tracer, err := tracers.New(tracerName, new(tracers.Context), test.TracerConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create call tracer: %v", err)
+	}
+	msg, err := tx.AsMessage(*signer, (*big.Int)(test.Context.BaseFee), rules)
+	if err != nil {
+		return fmt.Errorf("failed to prepare transaction for tracing: %v", err)
+	}
+	evm := vm.NewEVM(context, core.NewEVMTxContext(msg), statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+	if _, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.GetGas()).AddBlobGas(tx.GetBlobGas()), true /* refunds */, false /* gasBailout */); err != nil {
+		return fmt.Errorf("failed to execute transaction: %v", err)
+	}
+
+	// Retrieve the trace result and compare against the etalon
+	res, err := tracer.GetResult()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve trace result: %v", err)
+	}
+	ret := make([]flatCallTrace, 0)
+	if err := json.Unmarshal(res, &ret); err != nil {
+		return fmt.Errorf("failed to unmarshal trace result: %v", err)
+	}
+	if !jsonEqualFlat(ret, test.Result) {
+		t.Fatalf("trace mismatch: \nhave %+v\nwant %+v", ret, test.Result)
+	}
+	return nil
+}
+
+// Iterates over all the input-output datasets in the tracer parity test harness and
+// runs the Native tracer against them. The datasets are ported from go-ethereum, with
+// the expectations following the EVM of Erigon where it differs: out of gas errors are
+// not wrapped with the reason, and calls failing the balance check don't enter a frame.
+func TestFlatCallTracerNative(t *testing.T) {
+	testFlatCallTracer("flatCallTracer", "call_tracer_flat", t)
+}
+
+func testFlatCallTracer(tracerName string, dirPath string, t *testing.T) {
+	files, err := dir.ReadDir(filepath.Join("testdata", dirPath))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(file.Name(), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			if err := flatCallTracerTestRunner(tracerName, file.Name(), dirPath, t); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// jsonEqualFlat is similar to reflect.DeepEqual, but does a 'bounce' via json prior to
+// comparison
+func jsonEqualFlat(x, y interface{}) bool {
+	xTrace := new([]flatCallTrace)
+	yTrace := new([]flatCallTrace)
+	if xj, err := json.Marshal(x); err == nil {
+		json.Unmarshal(xj, xTrace)
+	} else {
+		return false
+	}
+	if yj, err := json.Marshal(y); err == nil {
+		json.Unmarshal(yj, yTrace)
+	} else {
+		return false
+	}
+	return reflect.DeepEqual(xTrace, yTrace)
+}
+
+func TestTokenTransferTracer(t *testing.T) {
+	var (
+		token     = libcommon.HexToAddress("0x00000000000000000000000000000000000070c3")
+		reverter  = libcommon.HexToAddress("0x000000000000000000000000000000000000dead")
+		recipient = libcommon.HexToAddress("0x00000000000000000000000000000000000000aa")
+		amount    = byte(0x64)
+	)
+	require.Equal(t, crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")), libcommon.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"))
+
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	require.NoError(t, err)
+	signer := types.LatestSigner(params.MainnetChainConfig)
+	tx, err := types.SignNewTx(privkey, *signer, &types.LegacyTx{
+		GasPrice: uint256.NewInt(0),
+		CommonTx: types.CommonTx{
+			Gas:   100000,
+			To:    &token,
+			Value: uint256.NewInt(7),
+		},
+	})
+	require.NoError(t, err)
+	origin, _ := signer.Sender(tx)
+
+	// Emits Transfer(origin, recipient, 0x64), then calls the reverting
+	// contract forwarding 1 wei, then sends 2 wei to the recipient.
+	transfer := libcommon.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	code := []byte{byte(vm.PUSH1), amount, byte(vm.PUSH1), 0, byte(vm.MSTORE)}
+	code = append(code, byte(vm.PUSH20))
+	code = append(code, recipient.Bytes()...)
+	code = append(code, byte(vm.ORIGIN), byte(vm.PUSH32))
+	code = append(code, transfer.Bytes()...)
+	code = append(code, byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG3))
+	callWithValue := func(to libcommon.Address, value byte) []byte {
+		c := []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.PUSH1), value, byte(vm.PUSH20)}
+		c = append(c, to.Bytes()...)
+		return append(c, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+	}
+	code = append(code, callWithValue(reverter, 1)...)
+	code = append(code, callWithValue(recipient, 2)...)
+	code = append(code, byte(vm.STOP))
+
+	// Emits an unrelated log before reverting, the log must not be counted
+	revertCode := []byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG0),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT),
+	}
+
+	alloc := types.GenesisAlloc{
+		token:    types.GenesisAccount{Nonce: 1, Code: code, Balance: big.NewInt(0)},
+		reverter: types.GenesisAccount{Nonce: 1, Code: revertCode, Balance: big.NewInt(0)},
+		origin:   types.GenesisAccount{Nonce: 0, Balance: big.NewInt(500000000000000)},
+	}
+	context := evmtypes.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    consensus.Transfer,
+		BlockNumber: 8000000,
+		Time:        5,
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+	}
+	rules := params.MainnetChainConfig.Rules(context.BlockNumber, context.Time)
+	m := mock.Mock(t)
+	dbTx, err := m.DB.BeginRw(m.Ctx)
+	require.NoError(t, err)
+	defer dbTx.Rollback()
+	statedb, err := tests.MakePreState(rules, dbTx, alloc, context.BlockNumber)
+	require.NoError(t, err)
+
+	tracer, err := tracers.New("tokenTransferTracer", nil, nil)
+	require.NoError(t, err)
+	msg, err := tx.AsMessage(*signer, nil, rules)
+	require.NoError(t, err)
+	evm := vm.NewEVM(context, core.NewEVMTxContext(msg), statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	_, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.GetGas()), true /* refunds */, false /* gasBailout */)
+	require.NoError(t, err)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	want := `[` +
+		`{"type":"eth","traceAddress":[],"from":"` + strings.ToLower(origin.Hex()) + `","to":"0x00000000000000000000000000000000000070c3","value":"0x7"},` +
+		`{"type":"erc20","traceAddress":[],"token":"0x00000000000000000000000000000000000070c3","from":"` + strings.ToLower(origin.Hex()) + `","to":"0x00000000000000000000000000000000000000aa","value":"0x64","logIndex":"0x0"},` +
+		`{"type":"eth","traceAddress":[1],"from":"0x00000000000000000000000000000000000070c3","to":"0x00000000000000000000000000000000000000aa","value":"0x2"}` +
+		`]`
+	require.Equal(t, want, string(res))
+
+	// ETH transfers can be left out
+	tracer, err = tracers.New("tokenTransferTracer", nil, json.RawMessage(`{"onlyTokens":true}`))
+	require.NoError(t, err)
+	statedb, err = tests.MakePreState(rules, dbTx, alloc, context.BlockNumber)
+	require.NoError(t, err)
+	evm = vm.NewEVM(context, core.NewEVMTxContext(msg), statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	_, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.GetGas()), true /* refunds */, false /* gasBailout */)
+	require.NoError(t, err)
+	res, err = tracer.GetResult()
+	require.NoError(t, err)
+	var events []map[string]interface{}
+	require.NoError(t, json.Unmarshal(res, &events))
+	require.Len(t, events, 1)
+	require.Equal(t, "erc20", events[0]["type"])
+}
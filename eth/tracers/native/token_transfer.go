@@ -0,0 +1,280 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/eth/tracers"
+)
+
+func init() {
+	register("tokenTransferTracer", newTokenTransferTracer)
+}
+
+var (
+	// keccak256("Transfer(address,address,uint256)")
+	transferEventTopic = libcommon.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	// keccak256("Approval(address,address,uint256)")
+	approvalEventTopic = libcommon.HexToHash("0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925")
+	// keccak256("ApprovalForAll(address,address,bool)")
+	approvalForAllEventTopic = libcommon.HexToHash("0x17307eab39ab6107e8899845ad3d59bd9653f200f220920489ca2b5937696c31")
+)
+
+// Kinds of events reported by the tokenTransferTracer.
+const (
+	tokenEventETH            = "eth"
+	tokenEventERC20          = "erc20"
+	tokenEventERC721         = "erc721"
+	tokenEventERC20Approval  = "erc20Approval"
+	tokenEventERC721Approval = "erc721Approval"
+	tokenEventApprovalForAll = "approvalForAll"
+)
+
+// tokenEvent is a single value movement or approval. For approvals From is the
+// owner and To is the approved spender or operator.
+type tokenEvent struct {
+	Type         string             `json:"type"`
+	TraceAddress []int              `json:"traceAddress"`
+	Token        *libcommon.Address `json:"token,omitempty"`
+	From         libcommon.Address  `json:"from"`
+	To           libcommon.Address  `json:"to"`
+	Value        *hexutil.Big       `json:"value,omitempty"`
+	TokenID      *hexutil.Big       `json:"tokenId,omitempty"`
+	Approved     *bool              `json:"approved,omitempty"`
+	LogIndex     *hexutil.Uint64    `json:"logIndex,omitempty"`
+}
+
+// tokenTransferEntry is either a decoded event or a log which isn't a token
+// event; the latter is only kept to compute log indices.
+type tokenTransferEntry struct {
+	event *tokenEvent
+	isLog bool
+}
+
+type tokenTransferFrame struct {
+	traceAddress []int
+	children     int
+	entries      []tokenTransferEntry
+}
+
+// tokenTransferTracer is a go implementation of the Tracer interface which
+// reports the internal ETH value transfers and the ERC-20/ERC-721 Transfer,
+// Approval and ApprovalForAll events of a txn, each tagged with the trace
+// address of the call frame it originated from. Events of reverted frames are
+// dropped.
+//
+// Example:
+//
+//	> debug.traceTransaction( "0x...", {tracer: "tokenTransferTracer"})
+//	[
+//	  {"type": "eth", "traceAddress": [], "from": "0x...", "to": "0x...", "value": "0x1"},
+//	  {"type": "erc20", "traceAddress": [0], "token": "0x...", "from": "0x...", "to": "0x...", "value": "0x64", "logIndex": "0x0"}
+//	]
+type tokenTransferTracer struct {
+	noopTracer
+	config    tokenTransferTracerConfig
+	frames    []*tokenTransferFrame
+	entries   []tokenTransferEntry
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+type tokenTransferTracerConfig struct {
+	OnlyTokens bool `json:"onlyTokens"` // If true, internal ETH value transfers are not reported
+}
+
+// newTokenTransferTracer returns a native go tracer which collects token
+// transfers of a txn, and implements vm.EVMLogger.
+func newTokenTransferTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config tokenTransferTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	return &tokenTransferTracer{config: config}, nil
+}
+
+func (t *tokenTransferTracer) addEvent(ev *tokenEvent, isLog bool) {
+	top := t.frames[len(t.frames)-1]
+	ev.TraceAddress = top.traceAddress
+	top.entries = append(top.entries, tokenTransferEntry{event: ev, isLog: isLog})
+}
+
+func (t *tokenTransferTracer) captureValue(from, to libcommon.Address, value *uint256.Int) {
+	if t.config.OnlyTokens || value == nil || value.IsZero() {
+		return
+	}
+	t.addEvent(&tokenEvent{Type: tokenEventETH, From: from, To: to, Value: (*hexutil.Big)(value.ToBig())}, false)
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *tokenTransferTracer) CaptureStart(env *vm.EVM, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.frames = []*tokenTransferFrame{{traceAddress: []int{}}}
+	t.entries = nil
+	t.captureValue(from, to, value)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *tokenTransferTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	// Nothing is transferred when the txn fails
+	if err == nil {
+		t.entries = t.frames[0].entries
+	}
+	t.frames = nil
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *tokenTransferTracer) CaptureEnter(typ vm.OpCode, from libcommon.Address, to libcommon.Address, precompile, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	if atomic.LoadUint32(&t.interrupt) > 0 || len(t.frames) == 0 {
+		return
+	}
+	parent := t.frames[len(t.frames)-1]
+	frame := &tokenTransferFrame{traceAddress: childTraceAddress(parent.traceAddress, parent.children)}
+	parent.children++
+	t.frames = append(t.frames, frame)
+
+	// Delegated frames run in the context of the caller and don't move value
+	if typ != vm.DELEGATECALL && typ != vm.STATICCALL && typ != vm.CALLCODE {
+		t.captureValue(from, to, value)
+	}
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *tokenTransferTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 || len(t.frames) < 2 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if err == nil {
+		parent := t.frames[len(t.frames)-1]
+		parent.entries = append(parent.entries, frame.entries...)
+	}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *tokenTransferTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || op < vm.LOG0 || op > vm.LOG4 || len(t.frames) == 0 {
+		return
+	}
+	// Skip if tracing was interrupted
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	size := int(op - vm.LOG0)
+	stack := scope.Stack
+	if stack.Len() < 2+size {
+		return
+	}
+	// Don't modify the stack
+	mStart, mSize := stack.Back(0), stack.Back(1)
+	topics := make([]libcommon.Hash, size)
+	for i := 0; i < size; i++ {
+		topics[i] = stack.Back(2 + i).Bytes32()
+	}
+	// Memory is expanded only after the tracer is invoked, the part of the
+	// region beyond the current memory size reads as zeroes.
+	data := make([]byte, mSize.Uint64())
+	copy(data, scope.Memory.GetCopy(int64(mStart.Uint64()), int64(mSize.Uint64())))
+
+	ev := decodeTokenEvent(scope.Contract.Address(), topics, data)
+	if ev == nil {
+		t.addEvent(&tokenEvent{}, true)
+		return
+	}
+	t.addEvent(ev, true)
+}
+
+// decodeTokenEvent decodes a log into a token event. It returns nil if the log
+// isn't an ERC-20 or ERC-721 Transfer, Approval or ApprovalForAll event.
+func decodeTokenEvent(token libcommon.Address, topics []libcommon.Hash, data []byte) *tokenEvent {
+	if len(topics) < 3 {
+		return nil
+	}
+	ev := &tokenEvent{
+		Token: &token,
+		From:  libcommon.BytesToAddress(topics[1][:]),
+		To:    libcommon.BytesToAddress(topics[2][:]),
+	}
+	switch {
+	case topics[0] == transferEventTopic && len(topics) == 3 && len(data) == 32:
+		ev.Type = tokenEventERC20
+		ev.Value = (*hexutil.Big)(new(big.Int).SetBytes(data))
+	case topics[0] == transferEventTopic && len(topics) == 4 && len(data) == 0:
+		ev.Type = tokenEventERC721
+		ev.TokenID = (*hexutil.Big)(new(big.Int).SetBytes(topics[3][:]))
+	case topics[0] == approvalEventTopic && len(topics) == 3 && len(data) == 32:
+		ev.Type = tokenEventERC20Approval
+		ev.Value = (*hexutil.Big)(new(big.Int).SetBytes(data))
+	case topics[0] == approvalEventTopic && len(topics) == 4 && len(data) == 0:
+		ev.Type = tokenEventERC721Approval
+		ev.TokenID = (*hexutil.Big)(new(big.Int).SetBytes(topics[3][:]))
+	case topics[0] == approvalForAllEventTopic && len(topics) == 3 && len(data) == 32:
+		ev.Type = tokenEventApprovalForAll
+		approved := new(big.Int).SetBytes(data).Sign() != 0
+		ev.Approved = &approved
+	default:
+		return nil
+	}
+	return ev
+}
+
+func (t *tokenTransferTracer) CaptureTxStart(gasLimit uint64) {
+	t.entries = nil
+}
+
+// GetResult returns the json-encoded list of token events, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *tokenTransferTracer) GetResult() (json.RawMessage, error) {
+	events := make([]*tokenEvent, 0, len(t.entries))
+	var logIndex uint64
+	for _, entry := range t.entries {
+		if entry.isLog {
+			idx := hexutil.Uint64(logIndex)
+			entry.event.LogIndex = &idx
+			logIndex++
+		}
+		if entry.event.Type != "" {
+			events = append(events, entry.event)
+		}
+	}
+	res, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *tokenTransferTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
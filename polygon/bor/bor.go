@@ -331,6 +331,7 @@ type Bor struct {
 	rootHashCache       *lru.ARCCache[string, string]
 	headerProgress      HeaderProgress
 	polygonBridge       bridge.PolygonBridge
+	bridgeReader        *bridge.Reader
 }
 
 type signer struct {
@@ -377,6 +378,10 @@ func New(
 		polygonBridge:          polygonBridge,
 	}
 
+	if polygonBridge != nil {
+		c.bridgeReader = polygonBridge.Reader()
+	}
+
 	c.authorizedSigner.Store(&signer{
 		libcommon.Address{},
 		func(_ libcommon.Address, _ string, i []byte) ([]byte, error) {
@@ -1320,12 +1325,27 @@ func (c *Bor) Start(chainDB kv.RwDB) {
 	}
 }
 
+// BridgeReader returns nil if there is no polygon bridge data
+func (c *Bor) BridgeReader() *bridge.Reader {
+	return c.bridgeReader
+}
+
+// SetBridgeReader is used by the rpcdaemon, which opens the bridge db of the node itself
+func (c *Bor) SetBridgeReader(reader *bridge.Reader) {
+	c.bridgeReader = reader
+}
+
 func (c *Bor) Close() error {
 	c.closeOnce.Do(func() {
 		if c.DB != nil {
 			c.DB.Close()
 		}
 
+		// reader of the running bridge is closed by the bridge
+		if c.bridgeReader != nil && c.polygonBridge == nil {
+			c.bridgeReader.Close()
+		}
+
 		if c.HeimdallClient != nil {
 			c.HeimdallClient.Close()
 		}
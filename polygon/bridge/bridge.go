@@ -51,6 +51,7 @@ type Bridge struct {
 	stateReceiverABI   abi.ABI
 	stateClientAddress libcommon.Address
 	fetchSyncEvents    fetchSyncEventsType
+	reader             *Reader
 }
 
 func Assemble(dataDir string, logger log.Logger, borConfig *borcfg.BorConfig, fetchSyncEvents fetchSyncEventsType, stateReceiverABI abi.ABI) *Bridge {
@@ -69,6 +70,7 @@ func NewBridge(store Store, logger log.Logger, borConfig *borcfg.BorConfig, fetc
 		lastProcessedEventID:     0,
 		stateReceiverABI:         stateReceiverABI,
 		stateClientAddress:       libcommon.HexToAddress(borConfig.StateReceiverContract),
+		reader:                   NewReader(store, borConfig, stateReceiverABI),
 	}
 }
 
@@ -131,6 +133,11 @@ func (b *Bridge) Close() {
 	b.store.Close()
 }
 
+// Reader gives read access to the bridge data without waiting for Run
+func (b *Bridge) Reader() *Reader {
+	return b.reader
+}
+
 // EngineService interface implementations
 
 // ProcessNewBlocks iterates through all blocks and constructs a map from block number to sync events
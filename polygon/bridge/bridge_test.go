@@ -244,3 +244,91 @@ func TestBridge_Unwind(t *testing.T) {
 	cancel()
 	wg.Wait()
 }
+
+func TestBridge_Reader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	stateReceiverABI := bor.GenesisContractStateReceiverABI()
+	heimdallClient, b := setup(t, stateReceiverABI)
+
+	events := []*heimdall.EventRecordWithTime{
+		{EventRecord: heimdall.EventRecord{ID: 1, ChainID: "80002", Data: hexutil.MustDecode("0x01")}, Time: time.Unix(50, 0)},  // block 4
+		{EventRecord: heimdall.EventRecord{ID: 2, ChainID: "80002", Data: hexutil.MustDecode("0x02")}, Time: time.Unix(100, 0)}, // block 4
+		{EventRecord: heimdall.EventRecord{ID: 3, ChainID: "80002", Data: hexutil.MustDecode("0x03")}, Time: time.Unix(200, 0)}, // block 6
+		{EventRecord: heimdall.EventRecord{ID: 4, ChainID: "80002", Data: hexutil.MustDecode("0x04")}, Time: time.Unix(300, 0)}, // not processed yet
+	}
+
+	heimdallClient.EXPECT().FetchStateSyncEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(events, nil).Times(1)
+	heimdallClient.EXPECT().FetchStateSyncEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]*heimdall.EventRecordWithTime{}, nil).AnyTimes()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func(bridge bridge.Service) {
+		defer wg.Done()
+
+		err := bridge.Run(ctx)
+		if err != nil {
+			if !errors.Is(err, ctx.Err()) {
+				t.Error(err)
+			}
+
+			return
+		}
+	}(b)
+
+	err := b.Synchronize(ctx, &types.Header{Number: big.NewInt(100)}) // hack to wait for b.ready
+	require.NoError(t, err)
+
+	blocks := getBlocks(t, 7)
+
+	err = b.ProcessNewBlocks(ctx, blocks)
+	require.NoError(t, err)
+
+	blockTime := func(_ context.Context, blockNum uint64) (uint64, error) {
+		return blocks[blockNum].Time(), nil
+	}
+
+	reader := b.Reader()
+
+	ranges, err := reader.EventIDRanges(ctx, 0, 6, blockTime)
+	require.NoError(t, err)
+	require.Equal(t, []bridge.EventIDRange{{BlockNum: 4, Start: 0, End: 2}, {BlockNum: 6, Start: 2, End: 3}}, ranges)
+
+	_, _, ok, err := reader.EventIDRange(ctx, 5, blockTime)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	start, end, ok, err := reader.EventIDRange(ctx, 6, blockTime)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	res, err := reader.Events(ctx, start+1, end)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, uint64(3), res[0].ID)
+	require.Equal(t, events[2].Data, res[0].Data)
+
+	for id, expectedBlockNum := range map[uint64]uint64{1: 4, 2: 4, 3: 6} {
+		blockNum, ok, err := reader.EventBlockNum(ctx, id, blockTime)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, expectedBlockNum, blockNum, id)
+	}
+
+	// known, but not processed by any block yet
+	_, ok, err = reader.EventBlockNum(ctx, 4, blockTime)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	event, err := reader.EventByID(ctx, 4)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), event.ID)
+
+	_, err = reader.EventByID(ctx, 5)
+	require.ErrorIs(t, err, heimdall.ErrEventRecordNotFound)
+
+	cancel()
+	wg.Wait()
+}
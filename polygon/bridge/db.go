@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon/accounts/abi"
 	"github.com/erigontech/erigon/polygon/heimdall"
@@ -60,6 +61,17 @@ type Store interface {
 	StoreEventID(ctx context.Context, eventMap map[uint64]uint64) error
 	GetEventIDRange(ctx context.Context, blockNum uint64) (uint64, uint64, error)
 	PruneEventIDs(ctx context.Context, blockNum uint64) error
+
+	GetEvent(ctx context.Context, id uint64) ([]byte, error)
+	GetEventIDRanges(ctx context.Context, fromBlock, toBlock uint64) ([]EventIDRange, error)
+	GetBlockNumByEventID(ctx context.Context, id uint64) (uint64, bool, error)
+}
+
+// EventIDRange - events (Start, End] were processed by block BlockNum. End is 0 for the last block in the map.
+type EventIDRange struct {
+	BlockNum uint64
+	Start    uint64
+	End      uint64
 }
 
 type MdbxStore struct {
@@ -343,3 +355,111 @@ func (s *MdbxStore) PruneEventIDs(ctx context.Context, blockNum uint64) error {
 
 	return tx.Commit()
 }
+
+// GetEvent gets raw event by ID, nil if not found
+func (s *MdbxStore) GetEvent(ctx context.Context, id uint64) ([]byte, error) {
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, id)
+
+	v, err := tx.GetOne(kv.BorEvents, k)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.Copy(v), nil
+}
+
+// GetEventIDRanges returns the state sync event ID ranges of blocks in [fromBlock, toBlock] which have events
+func (s *MdbxStore) GetEventIDRanges(ctx context.Context, fromBlock, toBlock uint64) ([]EventIDRange, error) {
+	var ranges []EventIDRange
+
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	kByte := make([]byte, 8)
+	binary.BigEndian.PutUint64(kByte, fromBlock)
+
+	cursor, err := tx.Cursor(kv.BorEventNums)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var k, v []byte
+	for k, v, err = cursor.Seek(kByte); err == nil && k != nil; k, v, err = cursor.Next() {
+		blockNum, start := binary.BigEndian.Uint64(k), binary.BigEndian.Uint64(v)
+		if len(ranges) > 0 {
+			ranges[len(ranges)-1].End = start
+		}
+		if blockNum > toBlock {
+			return ranges, nil
+		}
+
+		ranges = append(ranges, EventIDRange{BlockNum: blockNum, Start: start})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ranges, nil
+}
+
+// GetBlockNumByEventID returns the last block in the map with start ID < id: the block which processed the event,
+// unless it's the last block in the map - then it depends on the block time.
+func (s *MdbxStore) GetBlockNumByEventID(ctx context.Context, id uint64) (uint64, bool, error) {
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	cursor, err := tx.Cursor(kv.BorEventNums)
+	if err != nil {
+		return 0, false, err
+	}
+	defer cursor.Close()
+
+	k, v, err := cursor.First()
+	if err != nil {
+		return 0, false, err
+	}
+	if k == nil || binary.BigEndian.Uint64(v) >= id {
+		return 0, false, nil
+	}
+	lo := binary.BigEndian.Uint64(k)
+
+	k, _, err = cursor.Last()
+	if err != nil {
+		return 0, false, err
+	}
+	hi := binary.BigEndian.Uint64(k)
+
+	// start IDs grow with block numbers: binary search of the last block with start < id
+	kByte := make([]byte, 8)
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		binary.BigEndian.PutUint64(kByte, mid)
+
+		k, v, err = cursor.Seek(kByte)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if k != nil && binary.BigEndian.Uint64(v) < id {
+			lo = binary.BigEndian.Uint64(k)
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	return lo, true, nil
+}
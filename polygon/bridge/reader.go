@@ -0,0 +1,200 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common/dir"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/mdbx"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/accounts/abi"
+	"github.com/erigontech/erigon/polygon/bor/borcfg"
+	"github.com/erigontech/erigon/polygon/heimdall"
+	"github.com/erigontech/erigon/polygon/polygoncommon"
+)
+
+// BlockTimeFunc returns time of the canonical block. The map stores only the start ID of each block,
+// so the end of the last block in the map is calculated the same way ProcessNewBlocks does it - by block time.
+type BlockTimeFunc func(ctx context.Context, blockNum uint64) (uint64, error)
+
+// Reader gives read access to the state sync events and their mapping to blocks, e.g. for rpc
+type Reader struct {
+	store            Store
+	borConfig        *borcfg.BorConfig
+	stateReceiverABI abi.ABI
+}
+
+func NewReader(store Store, borConfig *borcfg.BorConfig, stateReceiverABI abi.ABI) *Reader {
+	return &Reader{
+		store:            store,
+		borConfig:        borConfig,
+		stateReceiverABI: stateReceiverABI,
+	}
+}
+
+// AssembleReader opens the bridge db of another process (node) in datadir
+func AssembleReader(ctx context.Context, dataDir string, logger log.Logger, borConfig *borcfg.BorConfig, stateReceiverABI abi.ABI) (*Reader, error) {
+	dbPath := filepath.Join(dataDir, kv.PolygonBridgeDB.String())
+	// don't wait for db creation as Accede mode does: node creates it on start, if runs with polygon sync
+	exists, err := dir.FileExist(filepath.Join(dbPath, "mdbx.dat"))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w, path: %s", mdbx.ErrDBDoesNotExists, dbPath)
+	}
+
+	db, err := mdbx.NewMDBX(logger).
+		Label(kv.PolygonBridgeDB).
+		Path(dbPath).
+		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg { return databaseTablesCfg }).
+		Accede().
+		Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bridgeDB := polygoncommon.AsDatabase(db, kv.PolygonBridgeDB, logger)
+	return NewReader(NewStore(bridgeDB), borConfig, stateReceiverABI), nil
+}
+
+// Close is needed only for the reader created by AssembleReader
+func (r *Reader) Close() {
+	r.store.Close()
+}
+
+// EventIDRange returns the ID range (start, end] of state sync events processed by the block, ok is false if there are no events
+func (r *Reader) EventIDRange(ctx context.Context, blockNum uint64, blockTime BlockTimeFunc) (start, end uint64, ok bool, err error) {
+	ranges, err := r.EventIDRanges(ctx, blockNum, blockNum, blockTime)
+	if err != nil || len(ranges) == 0 {
+		return 0, 0, false, err
+	}
+
+	return ranges[0].Start, ranges[0].End, true, nil
+}
+
+// EventIDRanges returns the ID ranges of state sync events processed by blocks in [fromBlock, toBlock]
+func (r *Reader) EventIDRanges(ctx context.Context, fromBlock, toBlock uint64, blockTime BlockTimeFunc) ([]EventIDRange, error) {
+	if err := r.store.Prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	ranges, err := r.store.GetEventIDRanges(ctx, fromBlock, toBlock)
+	if err != nil || len(ranges) == 0 {
+		return nil, err
+	}
+
+	last := &ranges[len(ranges)-1]
+	if last.End == 0 {
+		if last.End, err = r.lastBlockEnd(ctx, last.BlockNum, last.Start, blockTime); err != nil {
+			return nil, err
+		}
+	}
+
+	return ranges, nil
+}
+
+func (r *Reader) lastBlockEnd(ctx context.Context, blockNum, start uint64, blockTime BlockTimeFunc) (uint64, error) {
+	var timeLimit time.Time
+	if r.borConfig.IsIndore(blockNum) {
+		t, err := blockTime(ctx, blockNum)
+		if err != nil {
+			return 0, err
+		}
+
+		timeLimit = time.Unix(int64(t-r.borConfig.CalculateStateSyncDelay(blockNum)), 0)
+	} else {
+		t, err := blockTime(ctx, blockNum-r.borConfig.CalculateSprintLength(blockNum))
+		if err != nil {
+			return 0, err
+		}
+
+		timeLimit = time.Unix(int64(t), 0)
+	}
+
+	end, err := r.store.GetSprintLastEventID(ctx, start, timeLimit, r.stateReceiverABI)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(start, end), nil
+}
+
+// Events returns state sync events with IDs in [fromID, toID]
+func (r *Reader) Events(ctx context.Context, fromID, toID uint64) ([]*heimdall.EventRecordWithTime, error) {
+	if err := r.store.Prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	rawEvents, err := r.store.GetEvents(ctx, fromID, toID+1)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*heimdall.EventRecordWithTime, 0, len(rawEvents))
+	for _, rawEvent := range rawEvents {
+		event, err := heimdall.UnpackEventRecordWithTime(r.stateReceiverABI, rawEvent)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// EventByID returns heimdall.ErrEventRecordNotFound if the bridge doesn't have the event yet
+func (r *Reader) EventByID(ctx context.Context, id uint64) (*heimdall.EventRecordWithTime, error) {
+	if err := r.store.Prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	rawEvent, err := r.store.GetEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rawEvent == nil {
+		return nil, heimdall.ErrEventRecordNotFound
+	}
+
+	return heimdall.UnpackEventRecordWithTime(r.stateReceiverABI, rawEvent)
+}
+
+// EventBlockNum returns the block which processed the event, ok is false if no block processed it yet
+func (r *Reader) EventBlockNum(ctx context.Context, id uint64, blockTime BlockTimeFunc) (uint64, bool, error) {
+	if err := r.store.Prepare(ctx); err != nil {
+		return 0, false, err
+	}
+
+	blockNum, ok, err := r.store.GetBlockNumByEventID(ctx, id)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+
+	_, end, ok, err := r.EventIDRange(ctx, blockNum, blockTime)
+	if err != nil || !ok || id > end {
+		return 0, false, err
+	}
+
+	return blockNum, true, nil
+}
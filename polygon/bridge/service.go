@@ -32,4 +32,5 @@ type PolygonBridge interface {
 type Service interface {
 	PolygonBridge
 	Run(ctx context.Context) error
+	Reader() *Reader
 }
@@ -45,6 +45,7 @@ var (
 	ErrNotInCheckpointList   = errors.New("checkpontId doesn't exist in Heimdall")
 	ErrNotInSpanList         = errors.New("milestoneId doesn't exist in Heimdall")
 	ErrServiceUnavailable    = errors.New("service unavailable")
+	ErrInconsistentResponse  = errors.New("inconsistent response")
 )
 
 const (
@@ -90,6 +91,29 @@ type Client struct {
 	maxRetries   int
 	closeCh      chan struct{}
 	logger       log.Logger
+	cache        *cachingHttpClient       // nil if disabled
+	endpoints    *multiEndpointHttpClient // nil if single endpoint
+}
+
+type ClientOptions struct {
+	recordDir string
+	cacheSize int
+}
+
+type ClientOption func(opts *ClientOptions)
+
+// WithRecordDir - save all responses to the dir, see NewReplayHandler
+func WithRecordDir(dir string) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.recordDir = dir
+	}
+}
+
+// WithResponseCacheSize - amount of cached responses for immutable entities, 0 disables the cache
+func WithResponseCacheSize(size int) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.cacheSize = size
+	}
 }
 
 type Request struct {
@@ -104,22 +128,59 @@ type HttpClient interface {
 	CloseIdleConnections()
 }
 
-func NewHeimdallClient(urlString string, logger log.Logger) *Client {
+// NewHeimdallClient - urlString may have several comma-separated endpoints: requests go to the healthiest one
+func NewHeimdallClient(urlString string, logger log.Logger, opts ...ClientOption) *Client {
 	httpClient := &http.Client{
 		Timeout: apiHeimdallTimeout,
 	}
-	return newHeimdallClient(urlString, httpClient, retryBackOff, maxRetries, logger)
+	return newHeimdallClient(urlString, httpClient, retryBackOff, maxRetries, logger, opts...)
 }
 
-func newHeimdallClient(urlString string, httpClient HttpClient, retryBackOff time.Duration, maxRetries int, logger log.Logger) *Client {
-	return &Client{
+func newHeimdallClient(urlString string, httpClient HttpClient, retryBackOff time.Duration, maxRetries int, logger log.Logger, opts ...ClientOption) *Client {
+	options := ClientOptions{cacheSize: responseCacheSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	c := &Client{
 		urlString:    urlString,
 		logger:       logger,
-		client:       httpClient,
 		retryBackOff: retryBackOff,
 		maxRetries:   maxRetries,
 		closeCh:      make(chan struct{}),
 	}
+
+	if urls := splitURLs(urlString); len(urls) > 1 {
+		endpoints, err := newMultiEndpointHttpClient(httpClient, urls, logger)
+		if err != nil {
+			logger.Error(heimdallLogPrefix("invalid endpoints, using the first one"), "url", urlString, "err", err)
+		} else {
+			c.endpoints, httpClient = endpoints, endpoints
+		}
+		c.urlString = urls[0]
+	}
+
+	if len(options.recordDir) > 0 {
+		var basePath string
+		if u, err := url.Parse(c.urlString); err == nil {
+			basePath = u.Path
+		}
+
+		recorder, err := newRecordingHttpClient(httpClient, options.recordDir, basePath, logger)
+		if err != nil {
+			logger.Error(heimdallLogPrefix("can't record responses"), "dir", options.recordDir, "err", err)
+		} else {
+			httpClient = recorder
+		}
+	}
+
+	if options.cacheSize > 0 {
+		c.cache = newCachingHttpClient(httpClient, options.cacheSize)
+		httpClient = c.cache
+	}
+
+	c.client = httpClient
+	return c
 }
 
 const (
@@ -159,7 +220,16 @@ func (c *Client) FetchStateSyncEvents(ctx context.Context, fromID uint64, to tim
 
 		reqCtx := withRequestType(ctx, stateSyncRequest)
 
-		response, err := FetchWithRetry[StateSyncEventsResponse](reqCtx, c, url, c.logger)
+		validate := func(response *StateSyncEventsResponse) error {
+			for _, event := range response.Result {
+				if event.ID < fromID {
+					return fmt.Errorf("event id %d, expected from %d", event.ID, fromID)
+				}
+			}
+			return nil
+		}
+
+		response, err := fetchWithRetry[StateSyncEventsResponse](reqCtx, c, url, nil, validate, c.logger)
 		if err != nil {
 			if errors.Is(err, ErrNoResponse) {
 				// for more info check https://github.com/maticnetwork/heimdall/pull/993
@@ -206,7 +276,14 @@ func (c *Client) FetchStateSyncEvent(ctx context.Context, id uint64) (*EventReco
 		return !strings.Contains(err.Error(), "could not get state record; No record found")
 	}
 
-	response, err := FetchWithRetryEx[StateSyncEventResponse](ctx, c, url, isRecoverableError, c.logger)
+	validate := func(response *StateSyncEventResponse) error {
+		if response.Result.ID != id {
+			return fmt.Errorf("event id %d, expected %d", response.Result.ID, id)
+		}
+		return nil
+	}
+
+	response, err := fetchWithRetry[StateSyncEventResponse](ctx, c, url, isRecoverableError, validate, c.logger)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "could not get state record; No record found") {
@@ -227,7 +304,11 @@ func (c *Client) FetchLatestSpan(ctx context.Context) (*Span, error) {
 
 	ctx = withRequestType(ctx, spanRequest)
 
-	response, err := FetchWithRetry[SpanResponse](ctx, c, url, c.logger)
+	validate := func(response *SpanResponse) error {
+		return validateSpan(&response.Result)
+	}
+
+	response, err := fetchWithRetry[SpanResponse](ctx, c, url, nil, validate, c.logger)
 	if err != nil {
 		return nil, err
 	}
@@ -243,7 +324,14 @@ func (c *Client) FetchSpan(ctx context.Context, spanID uint64) (*Span, error) {
 
 	ctx = withRequestType(ctx, spanRequest)
 
-	response, err := FetchWithRetry[SpanResponse](ctx, c, url, c.logger)
+	validate := func(response *SpanResponse) error {
+		if response.Result.Id != SpanId(spanID) {
+			return fmt.Errorf("span id %d, expected %d", response.Result.Id, spanID)
+		}
+		return validateSpan(&response.Result)
+	}
+
+	response, err := fetchWithRetry[SpanResponse](ctx, c, url, nil, validate, c.logger)
 	if err != nil {
 		return nil, fmt.Errorf("%w, spanID=%d", err, spanID)
 	}
@@ -260,7 +348,11 @@ func (c *Client) FetchCheckpoint(ctx context.Context, number int64) (*Checkpoint
 
 	ctx = withRequestType(ctx, checkpointRequest)
 
-	response, err := FetchWithRetry[CheckpointResponse](ctx, c, url, c.logger)
+	validate := func(response *CheckpointResponse) error {
+		return validateWaypoint(&response.Result.Fields)
+	}
+
+	response, err := fetchWithRetry[CheckpointResponse](ctx, c, url, nil, validate, c.logger)
 	if err != nil {
 		return nil, err
 	}
@@ -302,7 +394,11 @@ func (c *Client) FetchMilestone(ctx context.Context, number int64) (*Milestone,
 		return !isInvalidMilestoneIndexError(err)
 	}
 
-	response, err := FetchWithRetryEx[MilestoneResponse](ctx, c, url, isRecoverableError, c.logger)
+	validate := func(response *MilestoneResponse) error {
+		return validateWaypoint(&response.Result.Fields)
+	}
+
+	response, err := fetchWithRetry[MilestoneResponse](ctx, c, url, isRecoverableError, validate, c.logger)
 	if err != nil {
 		if isInvalidMilestoneIndexError(err) {
 			return nil, fmt.Errorf("%w: number %d", ErrNotInMilestoneList, number)
@@ -442,6 +538,18 @@ func FetchWithRetryEx[T any](
 	url *url.URL,
 	isRecoverableError func(error) bool,
 	logger log.Logger,
+) (result *T, err error) {
+	return fetchWithRetry[T](ctx, client, url, isRecoverableError, nil, logger)
+}
+
+// fetchWithRetry - invalid response (by validate or json) is retried, endpoint which returned it is penalized
+func fetchWithRetry[T any](
+	ctx context.Context,
+	client *Client,
+	url *url.URL,
+	isRecoverableError func(error) bool,
+	validate func(*T) error,
+	logger log.Logger,
 ) (result *T, err error) {
 	attempt := 0
 	// create a new ticker for retrying the request
@@ -451,12 +559,22 @@ func FetchWithRetryEx[T any](
 	for attempt < client.maxRetries {
 		attempt++
 
+		reqCtx, report := withEndpointReport(ctx)
 		request := &Request{client: client.client, url: url, start: time.Now()}
-		result, err = Fetch[T](ctx, request, logger)
+		result, err = Fetch[T](reqCtx, request, logger)
+		if err == nil && validate != nil {
+			if err = validate(result); err != nil {
+				err = fmt.Errorf("%w: %w", ErrInconsistentResponse, err)
+			}
+		}
 		if err == nil {
 			return result, nil
 		}
 
+		if isInvalidResponseError(err) {
+			client.invalidResponse(url, report)
+		}
+
 		// 503 (Service Unavailable) is thrown when an endpoint isn't activated
 		// yet in heimdall. E.g. when the hard fork hasn't hit yet but heimdall
 		// is upgraded.
@@ -486,6 +604,40 @@ func FetchWithRetryEx[T any](
 	return nil, err
 }
 
+func isInvalidResponseError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.Is(err, ErrInconsistentResponse) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// invalidResponse - drops the response from the cache and penalizes the endpoint which returned it
+func (c *Client) invalidResponse(url *url.URL, report *endpointReport) {
+	if c.cache != nil {
+		c.cache.evict(url)
+	}
+
+	if c.endpoints != nil {
+		c.endpoints.penalize(report)
+	}
+}
+
+func validateSpan(span *Span) error {
+	if span.EndBlock < span.StartBlock {
+		return fmt.Errorf("span %d end block %d < start block %d", span.Id, span.EndBlock, span.StartBlock)
+	}
+	return nil
+}
+
+func validateWaypoint(fields *WaypointFields) error {
+	if fields.StartBlock == nil || fields.EndBlock == nil {
+		return errors.New("no block range")
+	}
+	if fields.EndBlock.Cmp(fields.StartBlock) < 0 {
+		return fmt.Errorf("end block %d < start block %d", fields.EndBlock, fields.StartBlock)
+	}
+	return nil
+}
+
 // Fetch fetches response from heimdall
 func Fetch[T any](ctx context.Context, request *Request, logger log.Logger) (*T, error) {
 	isSuccessful := false
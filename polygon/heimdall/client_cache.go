@@ -0,0 +1,100 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package heimdall
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const responseCacheSize = 4096
+
+// immutablePaths - heimdall entities which never change once they exist: spans, checkpoints, milestones, state sync events by id
+var immutablePaths = regexp.MustCompile(`/(bor/span|checkpoints|milestone|clerk/event-record)/\d+$`)
+
+// cachingHttpClient - caches successful responses for immutable entities
+type cachingHttpClient struct {
+	client HttpClient
+	cache  *lru.Cache[string, []byte]
+}
+
+func newCachingHttpClient(client HttpClient, size int) *cachingHttpClient {
+	cache, err := lru.New[string, []byte](size)
+	if err != nil {
+		panic(err)
+	}
+
+	return &cachingHttpClient{client: client, cache: cache}
+}
+
+func (c *cachingHttpClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !immutablePaths.MatchString(req.URL.Path) {
+		return c.client.Do(req)
+	}
+
+	key := cacheKey(req.URL)
+	if body, ok := c.cache.Get(key); ok {
+		return &http.Response{
+			Status:     http.StatusText(http.StatusOK),
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}, nil
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		return res, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) > 0 {
+		c.cache.Add(key, body)
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+func (c *cachingHttpClient) CloseIdleConnections() {
+	c.client.CloseIdleConnections()
+}
+
+// evict - cached response turned out to be invalid
+func (c *cachingHttpClient) evict(u *url.URL) {
+	c.cache.Remove(cacheKey(u))
+}
+
+// cacheKey - urls made by makeURL have relative path, the ones of http requests - absolute
+func cacheKey(u *url.URL) string {
+	key := "/" + strings.TrimPrefix(u.EscapedPath(), "/")
+	if len(u.RawQuery) > 0 {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package heimdall
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+const (
+	endpointCooldown    = time.Second
+	endpointMaxCooldown = 5 * time.Minute
+	// endpointLatencyWeight - weight of the latest request in latency moving average
+	endpointLatencyWeight = 0.2
+)
+
+// splitURLs - --bor.heimdall may have several comma-separated endpoints, the first one is primary
+func splitURLs(urlString string) []string {
+	var urls []string
+	for _, u := range strings.Split(urlString, ",") {
+		if u = strings.TrimSpace(u); len(u) > 0 {
+			urls = append(urls, u)
+		}
+	}
+
+	return urls
+}
+
+type endpoint struct {
+	url *url.URL
+
+	latency   time.Duration // moving average of successful requests
+	failures  int           // consecutive
+	downUntil time.Time
+}
+
+// score - lower is better
+func (e *endpoint) score() time.Duration {
+	return e.latency * time.Duration(1+e.failures)
+}
+
+// endpointReport - filled by multiEndpointHttpClient with endpoint which served the request.
+// Allows to penalize endpoint for the response which turned out to be invalid.
+type endpointReport struct {
+	endpoint *endpoint
+}
+
+type endpointReportKey struct{}
+
+func withEndpointReport(ctx context.Context) (context.Context, *endpointReport) {
+	report := &endpointReport{}
+	return context.WithValue(ctx, endpointReportKey{}, report), report
+}
+
+// multiEndpointHttpClient - sends each request to the healthiest of several heimdall endpoints,
+// fails over to the next one on transport errors, 5xx and 429. Failed endpoint cools down with exponential backoff.
+type multiEndpointHttpClient struct {
+	client    HttpClient
+	primary   *url.URL // requests are built for primary endpoint and rewritten for the chosen one
+	endpoints []*endpoint
+	mu        sync.Mutex
+	logger    log.Logger
+}
+
+func newMultiEndpointHttpClient(client HttpClient, urls []string, logger log.Logger) (*multiEndpointHttpClient, error) {
+	c := &multiEndpointHttpClient{client: client, logger: logger}
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return nil, err
+		}
+
+		c.endpoints = append(c.endpoints, &endpoint{url: parsed})
+	}
+
+	c.primary = c.endpoints[0].url
+	return c, nil
+}
+
+func (c *multiEndpointHttpClient) Do(req *http.Request) (*http.Response, error) {
+	var res *http.Response
+	var err error
+
+	for i, e := range c.rank() {
+		if i > 0 {
+			if res != nil {
+				_ = res.Body.Close()
+			}
+
+			c.logger.Debug(heimdallLogPrefix("failing over to next endpoint"), "endpoint", e.url.Host, "path", req.URL.Path, "err", err)
+		}
+
+		endpointReq := req.Clone(req.Context())
+		endpointReq.URL = c.rewrite(req.URL, e.url)
+		endpointReq.Host = ""
+
+		start := time.Now()
+		res, err = c.client.Do(endpointReq)
+		if err == nil && !isEndpointFailure(res.StatusCode) {
+			c.succeeded(e, time.Since(start))
+			if report, ok := req.Context().Value(endpointReportKey{}).(*endpointReport); ok {
+				report.endpoint = e
+			}
+
+			return res, nil
+		}
+
+		if req.Context().Err() != nil {
+			return res, err
+		}
+
+		c.failed(e)
+	}
+
+	return res, err
+}
+
+func (c *multiEndpointHttpClient) CloseIdleConnections() {
+	c.client.CloseIdleConnections()
+}
+
+// penalize - endpoint returned invalid response
+func (c *multiEndpointHttpClient) penalize(report *endpointReport) {
+	if report.endpoint != nil {
+		c.logger.Warn(heimdallLogPrefix("endpoint returned inconsistent response"), "endpoint", report.endpoint.url.Host)
+		c.failed(report.endpoint)
+	}
+}
+
+// rank - healthy endpoints by score, then the ones in cooldown - sooner available first
+func (c *multiEndpointHttpClient) rank() []*endpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	ranked := make([]*endpoint, len(c.endpoints))
+	copy(ranked, c.endpoints)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		iDown, jDown := ranked[i].downUntil.After(now), ranked[j].downUntil.After(now)
+		if iDown != jDown {
+			return jDown
+		}
+		if iDown {
+			return ranked[i].downUntil.Before(ranked[j].downUntil)
+		}
+
+		return ranked[i].score() < ranked[j].score()
+	})
+
+	return ranked
+}
+
+func (c *multiEndpointHttpClient) succeeded(e *endpoint, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = time.Duration(endpointLatencyWeight*float64(latency) + (1-endpointLatencyWeight)*float64(e.latency))
+	}
+
+	e.failures = 0
+	e.downUntil = time.Time{}
+}
+
+func (c *multiEndpointHttpClient) failed(e *endpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.failures++
+	cooldown := min(endpointCooldown<<min(e.failures-1, 16), endpointMaxCooldown)
+	e.downUntil = time.Now().Add(cooldown)
+}
+
+func (c *multiEndpointHttpClient) rewrite(u *url.URL, to *url.URL) *url.URL {
+	rewritten := *to
+	rewritten.Path = path.Join(to.Path, strings.TrimPrefix(u.Path, c.primary.Path))
+	rewritten.RawQuery = u.RawQuery
+	return &rewritten
+}
+
+func isEndpointFailure(statusCode int) bool {
+	return statusCode >= 500 || statusCode == http.StatusTooManyRequests
+}
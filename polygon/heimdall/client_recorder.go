@@ -0,0 +1,165 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package heimdall
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// Record/replay: recordingHttpClient saves heimdall responses to a dir, NewReplayHandler serves them back -
+// so polygon sync can run offline against a local replay server, e.g. httptest.NewServer(NewReplayHandler(dir, logger)).
+
+// volatileQueryParams - differ between runs (e.g. state sync events are listed up to time.Now()), not part of the record key
+var volatileQueryParams = []string{"to-time"}
+
+type recordedResponse struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status"`
+	Body       string `json:"body"`
+}
+
+// recordKey - request uri relative to heimdall base path, without volatile params
+func recordKey(u *url.URL, basePath string) string {
+	key := strings.TrimPrefix(strings.TrimPrefix(u.Path, strings.TrimSuffix(basePath, "/")), "/")
+
+	query := u.Query()
+	for _, param := range volatileQueryParams {
+		query.Del(param)
+	}
+	if len(query) > 0 {
+		key += "?" + query.Encode()
+	}
+
+	return key
+}
+
+func recordFileName(key string) string {
+	return url.PathEscape(key) + ".json"
+}
+
+type recordingHttpClient struct {
+	client   HttpClient
+	dir      string
+	basePath string
+	logger   log.Logger
+}
+
+func newRecordingHttpClient(client HttpClient, dir string, basePath string, logger log.Logger) (*recordingHttpClient, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &recordingHttpClient{client: client, dir: dir, basePath: basePath, logger: logger}, nil
+}
+
+func (c *recordingHttpClient) Do(req *http.Request) (*http.Response, error) {
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	key := recordKey(req.URL, c.basePath)
+	if err := c.save(recordedResponse{URL: key, StatusCode: res.StatusCode, Body: string(body)}); err != nil {
+		c.logger.Warn(heimdallLogPrefix("can't record response"), "url", key, "err", err)
+	}
+
+	return res, nil
+}
+
+func (c *recordingHttpClient) save(record recordedResponse) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	fileName := filepath.Join(c.dir, recordFileName(record.URL))
+	tmpFile, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), fileName)
+}
+
+func (c *recordingHttpClient) CloseIdleConnections() {
+	c.client.CloseIdleConnections()
+}
+
+type replayHandler struct {
+	dir    string
+	logger log.Logger
+}
+
+// NewReplayHandler serves heimdall responses recorded with WithRecordDir
+func NewReplayHandler(dir string, logger log.Logger) http.Handler {
+	return &replayHandler{dir: dir, logger: logger}
+}
+
+func (h *replayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := recordKey(r.URL, "")
+
+	data, err := os.ReadFile(filepath.Join(h.dir, recordFileName(key)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			h.logger.Debug(heimdallLogPrefix("replay: no recorded response"), "url", key)
+			http.Error(w, fmt.Sprintf("no recorded response for %s", key), http.StatusNotFound)
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var record recordedResponse
+	if err := json.Unmarshal(data, &record); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(record.Body) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(record.StatusCode)
+	_, _ = io.WriteString(w, record.Body)
+}
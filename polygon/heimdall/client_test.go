@@ -18,8 +18,12 @@ package heimdall
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -78,3 +82,95 @@ func TestHeimdallClientStateSyncEventsReturnsErrNoResponseWhenHttp200WithEmptyBo
 	require.Nil(t, spanRes)
 	require.ErrorIs(t, err, ErrNoResponse)
 }
+
+// spanServer serves spans with the given id shift (non-zero - inconsistent responses) or status code
+func spanServer(t *testing.T, statusCode int, idShift int, requests *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if statusCode != http.StatusOK {
+			w.WriteHeader(statusCode)
+			return
+		}
+
+		var spanID int
+		_, err := fmt.Sscanf(r.URL.Path, "/bor/span/%d", &spanID)
+		require.NoError(t, err)
+
+		response := SpanResponse{Height: "1", Result: Span{Id: SpanId(spanID + idShift), StartBlock: 256, EndBlock: 6655}}
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHeimdallClientFailsOverToHealthyEndpoint(t *testing.T) {
+	ctx := context.Background()
+	logger := testlog.Logger(t, log.LvlDebug)
+	var downRequests, upRequests atomic.Int32
+	down := spanServer(t, http.StatusServiceUnavailable, 0, &downRequests)
+	up := spanServer(t, http.StatusOK, 0, &upRequests)
+	heimdallClient := newHeimdallClient(down.URL+","+up.URL, &http.Client{}, time.Millisecond, 5, logger)
+
+	span, err := heimdallClient.FetchSpan(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, SpanId(1), span.Id)
+	require.Equal(t, int32(1), downRequests.Load())
+	require.Equal(t, int32(1), upRequests.Load())
+
+	// down endpoint is in cooldown
+	span, err = heimdallClient.FetchSpan(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, SpanId(2), span.Id)
+	require.Equal(t, int32(1), downRequests.Load())
+	require.Equal(t, int32(2), upRequests.Load())
+
+	// spans are immutable - served from the cache
+	span, err = heimdallClient.FetchSpan(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, SpanId(1), span.Id)
+	require.Equal(t, int32(2), upRequests.Load())
+}
+
+func TestHeimdallClientPenalizesInconsistentEndpoint(t *testing.T) {
+	ctx := context.Background()
+	logger := testlog.Logger(t, log.LvlDebug)
+	var badRequests, goodRequests atomic.Int32
+	bad := spanServer(t, http.StatusOK, 1, &badRequests)
+	good := spanServer(t, http.StatusOK, 0, &goodRequests)
+	heimdallClient := newHeimdallClient(bad.URL+","+good.URL, &http.Client{}, time.Millisecond, 5, logger)
+
+	span, err := heimdallClient.FetchSpan(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, SpanId(1), span.Id)
+	require.Equal(t, int32(1), badRequests.Load())
+	require.Equal(t, int32(1), goodRequests.Load())
+
+	// single inconsistent endpoint - retries are exhausted
+	heimdallClient = newHeimdallClient(bad.URL, &http.Client{}, time.Millisecond, 2, logger)
+	_, err = heimdallClient.FetchSpan(ctx, 1)
+	require.ErrorIs(t, err, ErrInconsistentResponse)
+}
+
+func TestHeimdallClientRecordReplay(t *testing.T) {
+	ctx := context.Background()
+	logger := testlog.Logger(t, log.LvlDebug)
+	dir := t.TempDir()
+	var requests atomic.Int32
+	server := spanServer(t, http.StatusOK, 0, &requests)
+	heimdallClient := newHeimdallClient(server.URL, &http.Client{}, time.Millisecond, 2, logger, WithRecordDir(dir))
+
+	recorded, err := heimdallClient.FetchSpan(ctx, 7)
+	require.NoError(t, err)
+
+	replay := httptest.NewServer(NewReplayHandler(dir, logger))
+	defer replay.Close()
+	heimdallClient = newHeimdallClient(replay.URL, &http.Client{}, time.Millisecond, 2, logger)
+
+	replayed, err := heimdallClient.FetchSpan(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, recorded, replayed)
+	require.Equal(t, int32(1), requests.Load())
+
+	_, err = heimdallClient.FetchSpan(ctx, 8)
+	require.ErrorIs(t, err, ErrNotSuccessfulResponse)
+}
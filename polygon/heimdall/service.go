@@ -46,9 +46,9 @@ type service struct {
 	spanScraper       *scraper[*Span]
 }
 
-func AssembleService(heimdallUrl string, dataDir string, tmpDir string, logger log.Logger) Service {
+func AssembleService(heimdallUrl string, dataDir string, tmpDir string, logger log.Logger, opts ...ClientOption) Service {
 	store := NewMdbxServiceStore(logger, dataDir, tmpDir)
-	client := NewHeimdallClient(heimdallUrl, logger)
+	client := NewHeimdallClient(heimdallUrl, logger, opts...)
 	return NewService(client, store, logger)
 }
 
@@ -46,6 +46,17 @@ func NewDatabase(dataDir string, label kv.Label, tableCfg kv.TableCfg, logger lo
 	}
 }
 
+// AsDatabase wraps an already opened db, e.g. one opened by rpcdaemon in Accede mode
+func AsDatabase(rwDB kv.RwDB, label kv.Label, logger log.Logger) *Database {
+	db := &Database{
+		db:     rwDB,
+		label:  label,
+		logger: logger,
+	}
+	db.openOnce.Do(func() {})
+	return db
+}
+
 func (db *Database) open(ctx context.Context) error {
 	dbPath := filepath.Join(db.dataDir, db.label.String())
 	db.logger.Info("Opening Database", "label", db.label.String(), "path", dbPath)
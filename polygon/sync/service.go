@@ -59,13 +59,14 @@ func NewService(
 	executionClient executionproto.ExecutionClient,
 	blockLimit uint,
 	polygonBridge bridge.Service,
+	heimdallClientOpts ...heimdall.ClientOption,
 ) Service {
 	borConfig := chainConfig.Bor.(*borcfg.BorConfig)
 	checkpointVerifier := VerifyCheckpointHeaders
 	milestoneVerifier := VerifyMilestoneHeaders
 	blocksVerifier := VerifyBlocks
 	p2pService := p2p.NewService(maxPeers, logger, sentryClient, statusDataProvider.GetStatusData)
-	heimdallService := heimdall.AssembleService(heimdallUrl, dataDir, tmpDir, logger)
+	heimdallService := heimdall.AssembleService(heimdallUrl, dataDir, tmpDir, logger, heimdallClientOpts...)
 	execution := NewExecutionClient(executionClient)
 	store := NewStore(logger, execution, polygonBridge)
 	blockDownloader := NewBlockDownloader(
@@ -17,8 +17,7 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with Erigon. If not, see <http://www.gnu.org/licenses/>.
 
-// Package ethstats implements the network stats reporting service.
-package ethstats
+package reporter
 
 import (
 	"context"
@@ -34,49 +33,42 @@ import (
 	"sync"
 	"time"
 
-	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
-
 	"github.com/gorilla/websocket"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
-	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/log/v3"
-	"github.com/erigontech/erigon/turbo/services"
 
-	"github.com/erigontech/erigon/consensus"
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
-	"github.com/erigontech/erigon/eth/stagedsync/stages"
-	"github.com/erigontech/erigon/node"
-	"github.com/erigontech/erigon/p2p/sentry"
 )
 
 const (
 	// historyUpdateRange is the number of blocks a node should report upon login or
 	// history request.
 	historyUpdateRange = 50
+
+	// latencyReportInterval - latency is measured with the ping, so not on every report
+	latencyReportInterval = 15 * time.Second
+
+	// reconnectBackoff - after a failed connection attempt the server isn't dialed again for this long
+	reconnectBackoff = 10 * time.Second
 )
 
-// Service implements an Ethereum netstats reporting daemon that pushes local
-// chain statistics up to a monitoring server.
-type Service struct {
-	servers   []*sentry.GrpcServer // Peer-to-peer server to retrieve networking infos
-	chaindb   kv.RoDB
-	networkid uint64
-	engine    consensus.Engine // Consensus engine to retrieve variadic block fields
+// ethstatsBackend pushes the status to a server speaking legacy ethstats websocket protocol,
+// it also answers history requests of the server from the chain db.
+type ethstatsBackend struct {
+	sources *Sources
 
 	node string // Name of the node to display on the monitoring page
 	pass string // Password to authorize access to the monitoring page
 	host string // Remote address of the monitoring service
 
-	quitCh <-chan struct{}
-	headCh <-chan [][]byte
+	conn        *connWrapper  // nil if not connected
+	nextDial    time.Time     // no connection attempts before, set after a failed one
+	pongCh      chan struct{} // Pong notifications are fed into this channel
+	lastLatency time.Time
 
-	pongCh chan struct{} // Pong notifications are fed into this channel
-	histCh chan []uint64 // History request block numbers are fed into this channel
-
-	blockReader services.FullBlockReader
-	txPool      txpool.TxpoolClient
+	logger log.Logger
 }
 
 // connWrapper is a wrapper to prevent concurrent-write or concurrent-read on the
@@ -128,52 +120,69 @@ func (w *connWrapper) Close() error {
 	return w.conn.Close()
 }
 
-// New returns a monitoring service ready for stats reporting.
-func New(node *node.Node, servers []*sentry.GrpcServer, chainDB kv.RoDB, blockReader services.FullBlockReader,
-	engine consensus.Engine, url string, networkid uint64, quitCh <-chan struct{}, headCh chan [][]byte, txPoolRpcClient txpool.TxpoolClient) error {
+var ethstatsURL = regexp.MustCompile("([^:@]*)(:([^@]*))?@(.+)")
+
+func newEthstatsBackend(url string, sources *Sources, logger log.Logger) (*ethstatsBackend, error) {
 	// Parse the netstats connection url
-	re := regexp.MustCompile("([^:@]*)(:([^@]*))?@(.+)")
-	parts := re.FindStringSubmatch(url)
+	parts := ethstatsURL.FindStringSubmatch(url)
 	if len(parts) != 5 {
-		return fmt.Errorf("invalid netstats url: \"%s\", should be nodename:secret@host:port", url)
-	}
-	ethstats := &Service{
-		blockReader: blockReader,
-		engine:      engine,
-		servers:     servers,
-		node:        parts[1],
-		pass:        parts[3],
-		host:        parts[4],
-		pongCh:      make(chan struct{}),
-		histCh:      make(chan []uint64, 1),
-		networkid:   networkid,
-		chaindb:     chainDB,
-		headCh:      headCh,
-		quitCh:      quitCh,
-		txPool:      txPoolRpcClient,
-	}
-
-	node.RegisterLifecycle(ethstats)
-	return nil
+		return nil, fmt.Errorf("invalid netstats url: \"%s\", should be nodename:secret@host:port", url)
+	}
+
+	return &ethstatsBackend{
+		sources: sources,
+		node:    parts[1],
+		pass:    parts[3],
+		host:    parts[4],
+		pongCh:  make(chan struct{}),
+		logger:  logger,
+	}, nil
 }
 
-// Start implements node.Lifecycle, starting up the monitoring and reporting daemon.
-func (s *Service) Start() error {
-	go s.loop()
+func (s *ethstatsBackend) String() string {
+	return "ethstats " + s.host
+}
+
+// Report (re)connects to the server if needed and reports the block and stats, latency - once in a while.
+// After a failed connection attempt reports are skipped for reconnectBackoff.
+func (s *ethstatsBackend) Report(ctx context.Context, status *Status) error {
+	if s.conn == nil {
+		if time.Now().Before(s.nextDial) {
+			return nil
+		}
+		conn, err := s.connect(ctx)
+		if err != nil {
+			s.nextDial = time.Now().Add(reconnectBackoff)
+			return err
+		}
+
+		s.conn = conn
+		s.lastLatency = time.Time{}
+		go s.readLoop(conn)
+	}
+
+	if err := s.report(ctx, s.conn, status); err != nil {
+		// Close the current connection and establish a new one on next report
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
 
-	log.Info("Stats daemon started")
 	return nil
 }
 
-// Stop implements node.Lifecycle, terminating the monitoring and reporting daemon.
-func (s *Service) Stop() error {
-	log.Info("Stats daemon stopped")
-	return nil
+func (s *ethstatsBackend) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+	return err
 }
 
-// loop keeps trying to connect to the netstats server, reporting chain events
-// until termination.
-func (s *Service) loop() {
+// connect establishes a websocket connection to the server on any supported URL and authenticates
+func (s *ethstatsBackend) connect(ctx context.Context) (*connWrapper, error) {
 	// Resolve the URL, defaulting to TLS, but falling back to none too
 	path := s.host + "/api"
 	urls := []string{path}
@@ -183,89 +192,41 @@ func (s *Service) loop() {
 		urls = []string{"wss://" + path, "ws://" + path}
 	}
 
-	errTimer := time.NewTimer(0)
-	defer errTimer.Stop()
-	// Loop reporting until termination
-	for {
-		select {
-		case <-s.quitCh:
-			return
-		case <-errTimer.C:
-			// Establish a websocket connection to the server on any supported URL
-			var (
-				conn *connWrapper
-				err  error
-			)
-			dialer := websocket.Dialer{HandshakeTimeout: 5 * time.Second}
-			header := make(http.Header)
-			header.Set("origin", "http://localhost")
-			for _, url := range urls {
-				//nolint
-				c, _, err := dialer.Dial(url, header)
-				if err == nil {
-					conn = newConnectionWrapper(c)
-					break
-				}
-			}
-			if err != nil || conn == nil {
-				log.Warn("Stats server unreachable")
-				errTimer.Reset(10 * time.Second)
-				continue
-			}
-			// Authenticate the client with the server
-			if err = s.login(conn); err != nil {
-				log.Warn("Stats login failed", "err", err)
-				conn.Close()
-				errTimer.Reset(10 * time.Second)
-				continue
-			}
-			go s.readLoop(conn)
-
-			// Keep sending status updates until the connection breaks
-			fullReport := time.NewTicker(15 * time.Second)
-
-			for err == nil {
-				select {
-				case <-s.quitCh:
-					fullReport.Stop()
-					// Make sure the connection is closed
-					conn.Close()
-					return
-
-				case <-fullReport.C:
-					if err = s.report(conn); err != nil {
-						log.Warn("Full stats report failed", "err", err)
-					}
-				case list := <-s.histCh:
-					if err = s.reportHistory(conn, list); err != nil {
-						log.Warn("Requested history report failed", "err", err)
-					}
-				case <-s.headCh:
-					if err = s.reportBlock(conn); err != nil {
-						log.Warn("Block stats report failed", "err", err)
-					}
-
-				}
-			}
-			fullReport.Stop()
-
-			// Close the current connection and establish a new one
-			conn.Close()
-			errTimer.Reset(0)
+	var conn *connWrapper
+	dialer := websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+	header := make(http.Header)
+	header.Set("origin", "http://localhost")
+	for _, url := range urls {
+		//nolint
+		c, _, err := dialer.DialContext(ctx, url, header)
+		if err == nil {
+			conn = newConnectionWrapper(c)
+			break
 		}
 	}
+	if conn == nil {
+		return nil, errors.New("stats server unreachable")
+	}
+
+	// Authenticate the client with the server
+	if err := s.login(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("stats login failed: %w", err)
+	}
+
+	return conn, nil
 }
 
 // readLoop loops as long as the connection is alive and retrieves data packets
 // from the network socket. If any of them match an active request, it forwards
 // it, if they themselves are requests it initiates a reply, and lastly it drops
 // unknown packets.
-func (s *Service) readLoop(conn *connWrapper) {
+func (s *ethstatsBackend) readLoop(conn *connWrapper) {
 	// If the read loop exists, close the connection
 	defer func(conn *connWrapper) {
 		closeErr := conn.Close()
 		if closeErr != nil {
-			log.Warn("Failed to close connection", "err", closeErr)
+			s.logger.Warn("Failed to close connection", "err", closeErr)
 		}
 	}(conn)
 
@@ -273,14 +234,14 @@ func (s *Service) readLoop(conn *connWrapper) {
 		// Retrieve the next generic network packet and bail out on error
 		var blob json.RawMessage
 		if err := conn.ReadJSON(&blob); err != nil {
-			log.Warn("Failed to retrieve stats server message", "err", err)
+			s.logger.Warn("Failed to retrieve stats server message", "err", err)
 			return
 		}
 		// If the network packet is a system ping, respond to it directly
 		var ping string
 		if err := json.Unmarshal(blob, &ping); err == nil && strings.HasPrefix(ping, "primus::ping::") {
 			if err := conn.WriteJSON(strings.ReplaceAll(ping, "ping", "pong")); err != nil {
-				log.Warn("Failed to respond to system ping message", "err", err)
+				s.logger.Warn("Failed to respond to system ping message", "err", err)
 				return
 			}
 			continue
@@ -288,17 +249,17 @@ func (s *Service) readLoop(conn *connWrapper) {
 		// Not a system ping, try to decode an actual state message
 		var msg map[string][]interface{}
 		if err := json.Unmarshal(blob, &msg); err != nil {
-			log.Warn("Failed to decode stats server message", "err", err)
+			s.logger.Warn("Failed to decode stats server message", "err", err)
 			return
 		}
-		log.Trace("Received message from stats server", "msg", msg)
+		s.logger.Trace("Received message from stats server", "msg", msg)
 		if len(msg["emit"]) == 0 {
-			log.Warn("Stats server sent non-broadcast", "msg", msg)
+			s.logger.Warn("Stats server sent non-broadcast", "msg", msg)
 			return
 		}
 		command, ok := msg["emit"][0].(string)
 		if !ok {
-			log.Warn("Invalid stats server message type", "type", msg["emit"][0])
+			s.logger.Warn("Invalid stats server message type", "type", msg["emit"][0])
 			return
 		}
 		// If the message is a ping reply, deliver (someone must be listening!)
@@ -309,25 +270,26 @@ func (s *Service) readLoop(conn *connWrapper) {
 				continue
 			default:
 				// Ping routine dead, abort
-				log.Warn("Stats server pinger seems to have died")
+				s.logger.Warn("Stats server pinger seems to have died")
 				return
 			}
 		}
-		// If the message is a history request, forward to the event processor
+		// If the message is a history request, answer it right away: writes are safe for concurrent calling
 		if len(msg["emit"]) == 2 && command == "history" {
 			// Make sure the request is valid and doesn't crash us
 			request, ok := msg["emit"][1].(map[string]interface{})
 			if !ok {
-				log.Warn("Invalid stats history request", "msg", msg["emit"][1])
-				select {
-				case s.histCh <- nil: // Treat it as an no indexes request
-				default:
+				s.logger.Warn("Invalid stats history request", "msg", msg["emit"][1])
+				// Treat it as an no indexes request
+				if err := s.reportHistory(conn, nil); err != nil {
+					s.logger.Warn("Requested history report failed", "err", err)
+					return
 				}
 				continue
 			}
 			list, ok := request["list"].([]interface{})
 			if !ok {
-				log.Warn("Invalid stats history block list", "list", request["list"])
+				s.logger.Warn("Invalid stats history block list", "list", request["list"])
 				return
 			}
 			// Convert the block number list to an integer list
@@ -335,19 +297,19 @@ func (s *Service) readLoop(conn *connWrapper) {
 			for i, num := range list {
 				n, ok := num.(float64)
 				if !ok {
-					log.Warn("Invalid stats history block number", "number", num)
+					s.logger.Warn("Invalid stats history block number", "number", num)
 					return
 				}
 				numbers[i] = uint64(n)
 			}
-			select {
-			case s.histCh <- numbers:
-				continue
-			default:
+			if err := s.reportHistory(conn, numbers); err != nil {
+				s.logger.Warn("Requested history report failed", "err", err)
+				return
 			}
+			continue
 		}
 		// Report anything else and continue
-		log.Info("Unknown stats message", "msg", msg)
+		s.logger.Info("Unknown stats message", "msg", msg)
 	}
 }
 
@@ -374,19 +336,20 @@ type authMsg struct {
 }
 
 // login tries to authorize the client at the remote server.
-func (s *Service) login(conn *connWrapper) error {
+func (s *ethstatsBackend) login(conn *connWrapper) error {
 	// Construct and send the login authentication
 	// infos := s.server.NodeInfo()
 
-	protocols := make([]string, 0, len(s.servers))
-	for _, srv := range s.servers {
+	servers := s.sources.Sentries
+	protocols := make([]string, 0, len(servers))
+	for _, srv := range servers {
 		for _, p := range srv.Protocols {
 			protocols = append(protocols, fmt.Sprintf("%s/%d", p.Name, p.Version))
 		}
 	}
 	nodeName := "Erigon"
-	if len(s.servers) > 0 {
-		nodeInfo, err := s.servers[0].NodeInfo(context.TODO(), nil)
+	if len(servers) > 0 {
+		nodeInfo, err := servers[0].NodeInfo(context.TODO(), nil)
 		if err != nil {
 			return err
 		}
@@ -399,7 +362,7 @@ func (s *Service) login(conn *connWrapper) error {
 			Name:     s.node,
 			Node:     nodeName,
 			Port:     0,
-			Network:  strconv.FormatUint(s.networkid, 10),
+			Network:  strconv.FormatUint(s.sources.NetworkID, 10),
 			Protocol: strings.Join(protocols, ", "),
 			API:      "No",
 			Os:       runtime.GOOS,
@@ -423,20 +386,22 @@ func (s *Service) login(conn *connWrapper) error {
 	return nil
 }
 
-// report collects all possible data to report and send it to the stats server.
-// This should only be used on reconnects or rarely to avoid overloading the
-// server. Use the individual methods for reporting subscribed events.
-func (s *Service) report(conn *connWrapper) error {
-	if err := s.reportLatency(conn); err != nil {
-		return err
+// report sends the status to the stats server. Latency is measured only on reconnects
+// or once per latencyReportInterval to avoid overloading the server.
+func (s *ethstatsBackend) report(ctx context.Context, conn *connWrapper, status *Status) error {
+	if time.Since(s.lastLatency) >= latencyReportInterval {
+		if err := s.reportLatency(conn); err != nil {
+			return err
+		}
+		s.lastLatency = time.Now()
 	}
-	if err := s.reportBlock(conn); err != nil {
+	if err := s.reportBlock(ctx, conn); err != nil {
 		return err
 	}
-	if err := s.reportPending(conn); err != nil {
+	if err := s.reportPending(conn, status); err != nil {
 		return err
 	}
-	if err := s.reportStats(conn); err != nil {
+	if err := s.reportStats(conn, status); err != nil {
 		return err
 	}
 	return nil
@@ -444,7 +409,7 @@ func (s *Service) report(conn *connWrapper) error {
 
 // reportLatency sends a ping request to the server, measures the RTT time and
 // finally sends a latency update.
-func (s *Service) reportLatency(conn *connWrapper) error {
+func (s *ethstatsBackend) reportLatency(conn *connWrapper) error {
 	// Send the current time to the ethstats server
 	start := time.Now()
 
@@ -468,7 +433,7 @@ func (s *Service) reportLatency(conn *connWrapper) error {
 	latency := strconv.Itoa(int((time.Since(start) / time.Duration(2)).Nanoseconds() / 1000000))
 
 	// Send back the measured latency
-	log.Trace("Sending measured latency to ethstats", "latency", latency)
+	s.logger.Trace("Sending measured latency to ethstats", "latency", latency)
 
 	stats := map[string][]interface{}{
 		"emit": {"latency", map[string]string{
@@ -513,14 +478,14 @@ func (s uncleStats) MarshalJSON() ([]byte, error) {
 }
 
 // reportBlock retrieves the current chain head and reports it to the stats server.
-func (s *Service) reportBlock(conn *connWrapper) error {
-	roTx, err := s.chaindb.BeginRo(context.Background())
+func (s *ethstatsBackend) reportBlock(ctx context.Context, conn *connWrapper) error {
+	roTx, err := s.sources.ChainDB.BeginRo(ctx)
 	if err != nil {
 		return err
 	}
 	defer roTx.Rollback()
 
-	block, err := s.blockReader.CurrentBlock(roTx)
+	block, err := s.sources.BlockReader.CurrentBlock(roTx)
 	if err != nil {
 		return err
 	}
@@ -537,7 +502,7 @@ func (s *Service) reportBlock(conn *connWrapper) error {
 	details := s.assembleBlockStats(block, td)
 
 	// Assemble the block report and send it to the server
-	log.Trace("Sending new block to ethstats", "number", details.Number, "hash", details.Hash)
+	s.logger.Trace("Sending new block to ethstats", "number", details.Number, "hash", details.Hash)
 
 	stats := map[string]interface{}{
 		"id":    s.node,
@@ -551,7 +516,7 @@ func (s *Service) reportBlock(conn *connWrapper) error {
 
 // assembleBlockStats retrieves any required metadata to report a single block
 // and assembles the block stats. If block is nil, the current head is processed.
-func (s *Service) assembleBlockStats(block *types.Block, td *big.Int) *blockStats {
+func (s *ethstatsBackend) assembleBlockStats(block *types.Block, td *big.Int) *blockStats {
 	if td == nil {
 		td = libcommon.Big0
 	}
@@ -580,8 +545,8 @@ func (s *Service) assembleBlockStats(block *types.Block, td *big.Int) *blockStat
 
 // reportHistory retrieves the most recent batch of blocks and reports it to the
 // stats server.
-func (s *Service) reportHistory(conn *connWrapper, list []uint64) error {
-	roTx, err := s.chaindb.BeginRo(context.Background())
+func (s *ethstatsBackend) reportHistory(conn *connWrapper, list []uint64) error {
+	roTx, err := s.sources.ChainDB.BeginRo(context.Background())
 	if err != nil {
 		return err
 	}
@@ -611,7 +576,7 @@ func (s *Service) reportHistory(conn *connWrapper, list []uint64) error {
 	history := make([]*blockStats, len(indexes))
 	for i, number := range indexes {
 		// Retrieve the next block if it's known to us
-		block, err := s.blockReader.BlockByNumber(context.Background(), roTx, number)
+		block, err := s.sources.BlockReader.BlockByNumber(context.Background(), roTx, number)
 		if err != nil {
 			return err
 		}
@@ -630,9 +595,9 @@ func (s *Service) reportHistory(conn *connWrapper, list []uint64) error {
 	}
 	// Assemble the history report and send it to the server
 	if len(history) > 0 {
-		log.Trace("Sending historical blocks to ethstats", "first", history[0].Number, "last", history[len(history)-1].Number)
+		s.logger.Trace("Sending historical blocks to ethstats", "first", history[0].Number, "last", history[len(history)-1].Number)
 	} else {
-		log.Trace("No history to send to stats server")
+		s.logger.Trace("No history to send to stats server")
 	}
 	stats := map[string]interface{}{
 		"id":      s.node,
@@ -649,20 +614,17 @@ type pendStats struct {
 	Pending int `json:"pending"`
 }
 
-// reportPending retrieves the current number of pending transactions and reports
-// it to the stats server.
-func (s *Service) reportPending(conn *connWrapper) error {
-	in := new(txpool.StatusRequest)
-	status, err := s.txPool.Status(context.Background(), in)
-	if err != nil {
-		return err
+// reportPending reports the current number of pending transactions to the stats server.
+func (s *ethstatsBackend) reportPending(conn *connWrapper, status *Status) error {
+	if status.TxPool == nil {
+		return nil
 	}
-	log.Trace("Sending pending transactions to ethstats", "count", status.PendingCount)
+	s.logger.Trace("Sending pending transactions to ethstats", "count", status.TxPool.Pending)
 
 	stats := map[string]interface{}{
 		"id": s.node,
 		"stats": &pendStats{
-			Pending: int(status.PendingCount),
+			Pending: int(status.TxPool.Pending),
 		},
 	}
 	report := map[string][]interface{}{
@@ -682,39 +644,18 @@ type nodeStats struct {
 	Uptime    int  `json:"uptime"`
 }
 
-// reportStats retrieves various stats about the node at the networking and
-// mining layer and reports it to the stats server.
-func (s *Service) reportStats(conn *connWrapper) error {
-	roTx, err := s.chaindb.BeginRo(context.Background())
-	if err != nil {
-		return err
-	}
-	defer roTx.Rollback()
-	sync, err := stages.GetStageProgress(roTx, stages.Execution)
-	if err != nil {
-		return err
-	}
-	finishSync, err := stages.GetStageProgress(roTx, stages.Finish)
-	if err != nil {
-		return err
-	}
-	// TODO(Giulio2002): peer tracking
-	peerCount := 0
-	for _, srv := range s.servers {
-		counts := srv.SimplePeerCount()
-		for _, count := range counts {
-			peerCount += count
-		}
-	}
+// reportStats reports various stats about the node at the networking and
+// mining layer to the stats server.
+func (s *ethstatsBackend) reportStats(conn *connWrapper, status *Status) error {
 	stats := map[string]interface{}{
 		"id": s.node,
 		"stats": &nodeStats{
 			Active:    true,
 			Mining:    false,
 			Hashrate:  0,
-			GoodPeers: peerCount,
+			GoodPeers: status.Peers,
 			GasPrice:  0,
-			Syncing:   sync != finishSync,
+			Syncing:   status.Syncing,
 			Uptime:    100,
 		},
 	}
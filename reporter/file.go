@@ -0,0 +1,55 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+)
+
+// fileBackend appends the status as json line to the file - for local monitoring agents (e.g. log shippers)
+type fileBackend struct {
+	file *os.File
+}
+
+func newFileBackend(path string) (*fileBackend, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileBackend{file: file}, nil
+}
+
+func (b *fileBackend) String() string {
+	return "file " + b.file.Name()
+}
+
+func (b *fileBackend) Report(_ context.Context, status *Status) error {
+	line, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.file.Write(append(line, '\n'))
+	return err
+}
+
+func (b *fileBackend) Close() error {
+	return b.file.Close()
+}
@@ -0,0 +1,194 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+const otlpTimeout = 10 * time.Second
+
+// otlpBackend sends the status as gauges to OTLP/HTTP collector in JSON encoding (opentelemetry-proto ExportMetricsServiceRequest)
+type otlpBackend struct {
+	url    string
+	client *http.Client
+	logger log.Logger
+}
+
+func newOtlpBackend(url string, logger log.Logger) (*otlpBackend, error) {
+	return &otlpBackend{url: url, client: &http.Client{Timeout: otlpTimeout}, logger: logger}, nil
+}
+
+func (b *otlpBackend) String() string {
+	return "otlp " + b.url
+}
+
+func (b *otlpBackend) Report(ctx context.Context, status *Status) error {
+	body, err := json.Marshal(otlpMetricsRequest(status))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("otlp collector: status=%d, body='%s'", res.StatusCode, msg)
+	}
+
+	return nil
+}
+
+func (b *otlpBackend) Close() error {
+	b.client.CloseIdleConnections()
+	return nil
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+func otlpAttr(key, value string) otlpAttribute {
+	attr := otlpAttribute{Key: key}
+	attr.Value.StringValue = value
+	return attr
+}
+
+type otlpDataPoint struct {
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+	TimeUnixNano string          `json:"timeUnixNano"`
+	AsInt        *string         `json:"asInt,omitempty"` // int64 is a string in OTLP JSON
+	AsDouble     *float64        `json:"asDouble,omitempty"`
+}
+
+type otlpMetric struct {
+	Name  string `json:"name"`
+	Unit  string `json:"unit,omitempty"`
+	Gauge struct {
+		DataPoints []otlpDataPoint `json:"dataPoints"`
+	} `json:"gauge"`
+}
+
+type otlpMetrics struct {
+	time    string
+	metrics []*otlpMetric
+	byName  map[string]*otlpMetric
+}
+
+func (m *otlpMetrics) add(name, unit string, point otlpDataPoint) {
+	metric, ok := m.byName[name]
+	if !ok {
+		metric = &otlpMetric{Name: name, Unit: unit}
+		m.byName[name] = metric
+		m.metrics = append(m.metrics, metric)
+	}
+
+	point.TimeUnixNano = m.time
+	metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, point)
+}
+
+func (m *otlpMetrics) addInt(name, unit string, value uint64, attrs ...otlpAttribute) {
+	v := strconv.FormatUint(value, 10)
+	m.add(name, unit, otlpDataPoint{Attributes: attrs, AsInt: &v})
+}
+
+func (m *otlpMetrics) addDouble(name, unit string, value float64, attrs ...otlpAttribute) {
+	m.add(name, unit, otlpDataPoint{Attributes: attrs, AsDouble: &value})
+}
+
+func otlpMetricsRequest(status *Status) map[string]interface{} {
+	m := &otlpMetrics{time: strconv.FormatInt(status.Time.UnixNano(), 10), byName: map[string]*otlpMetric{}}
+
+	if status.Head != nil {
+		m.addInt("erigon.head.number", "", status.Head.Number)
+		m.addInt("erigon.head.time", "s", status.Head.Time)
+	}
+
+	syncing := uint64(0)
+	if status.Syncing {
+		syncing = 1
+	}
+	m.addInt("erigon.syncing", "", syncing)
+
+	for _, stage := range status.Stages {
+		m.addInt("erigon.stage.progress", "", stage.Progress, otlpAttr("stage", stage.Stage))
+		m.addInt("erigon.stage.prune_progress", "", stage.PruneProgress, otlpAttr("stage", stage.Stage))
+	}
+
+	m.addInt("erigon.p2p.peers", "", uint64(status.Peers))
+
+	if status.TxPool != nil {
+		m.addInt("erigon.txpool.size", "", uint64(status.TxPool.Pending), otlpAttr("pool", "pending"))
+		m.addInt("erigon.txpool.size", "", uint64(status.TxPool.BaseFee), otlpAttr("pool", "basefee"))
+		m.addInt("erigon.txpool.size", "", uint64(status.TxPool.Queued), otlpAttr("pool", "queued"))
+	}
+
+	if status.Caplin != nil {
+		m.addInt("erigon.caplin.head_slot", "", status.Caplin.HeadSlot)
+		m.addInt("erigon.caplin.finalized_slot", "", status.Caplin.FinalizedSlot)
+	}
+
+	if status.Snapshots != nil {
+		m.addDouble("erigon.snapshots.progress", "%", float64(status.Snapshots.Progress))
+		m.addInt("erigon.snapshots.bytes_completed", "By", status.Snapshots.BytesCompleted)
+		m.addInt("erigon.snapshots.bytes_total", "By", status.Snapshots.BytesTotal)
+		m.addInt("erigon.snapshots.download_rate", "By/s", status.Snapshots.DownloadRate)
+		m.addInt("erigon.snapshots.peers", "", uint64(status.Snapshots.Peers))
+	}
+
+	return map[string]interface{}{
+		"resourceMetrics": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []otlpAttribute{
+						otlpAttr("service.name", "erigon"),
+						otlpAttr("service.instance.id", status.Node),
+						otlpAttr("network.id", strconv.FormatUint(status.NetworkID, 10)),
+					},
+				},
+				"scopeMetrics": []interface{}{
+					map[string]interface{}{
+						"scope":   map[string]string{"name": "github.com/erigontech/erigon/reporter"},
+						"metrics": m.metrics,
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package reporter
+
+import (
+	"context"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces/downloaderproto"
+	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cl/persistence/base_encoding"
+	"github.com/erigontech/erigon/cl/persistence/beacon_indicies"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/p2p/sentry"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+// Status is the node state shared by all backends. Parts which sources are not available are nil.
+type Status struct {
+	Time      time.Time       `json:"time"`
+	Node      string          `json:"node"`
+	NetworkID uint64          `json:"networkId"`
+	Head      *HeadStatus     `json:"head,omitempty"`
+	Syncing   bool            `json:"syncing"`
+	Stages    []StageStatus   `json:"stages"`
+	Peers     int             `json:"peers"`
+	TxPool    *TxPoolStatus   `json:"txpool,omitempty"`
+	Caplin    *CaplinStatus   `json:"caplin,omitempty"`
+	Snapshots *SnapshotStatus `json:"snapshots,omitempty"`
+}
+
+type HeadStatus struct {
+	Number uint64         `json:"number"`
+	Hash   libcommon.Hash `json:"hash"`
+	Time   uint64         `json:"time"`
+}
+
+type StageStatus struct {
+	Stage         string `json:"stage"`
+	Progress      uint64 `json:"progress"`
+	PruneProgress uint64 `json:"pruneProgress"`
+}
+
+type TxPoolStatus struct {
+	Pending uint32 `json:"pending"`
+	BaseFee uint32 `json:"baseFee"`
+	Queued  uint32 `json:"queued"`
+}
+
+type CaplinStatus struct {
+	HeadSlot      uint64 `json:"headSlot"`
+	FinalizedSlot uint64 `json:"finalizedSlot"`
+}
+
+type SnapshotStatus struct {
+	Completed      bool    `json:"completed"`
+	Progress       float32 `json:"progress"` // percent
+	BytesCompleted uint64  `json:"bytesCompleted"`
+	BytesTotal     uint64  `json:"bytesTotal"`
+	DownloadRate   uint64  `json:"downloadRate"` // bytes/sec
+	Peers          int32   `json:"peers"`
+}
+
+// Sources of the node status, nil ones are not reported
+type Sources struct {
+	Node        string // name of the node
+	NetworkID   uint64
+	ChainDB     kv.RoDB
+	BlockReader services.FullBlockReader
+	Sentries    []*sentry.GrpcServer
+	TxPool      txpool.TxpoolClient
+	Downloader  downloaderproto.DownloaderClient
+	CaplinDB    kv.RoDB // caplin indexing db
+}
+
+// Collect fails only if chaindb is not readable, failures of other sources are logged and their status is skipped
+func (s *Sources) Collect(ctx context.Context, logger log.Logger) (*Status, error) {
+	status := &Status{
+		Time:      time.Now(),
+		Node:      s.Node,
+		NetworkID: s.NetworkID,
+	}
+
+	if err := s.ChainDB.View(ctx, func(tx kv.Tx) error {
+		if header := rawdb.ReadCurrentHeader(tx); header != nil {
+			status.Head = &HeadStatus{Number: header.Number.Uint64(), Hash: header.Hash(), Time: header.Time}
+		}
+
+		status.Stages = make([]StageStatus, 0, len(stages.AllStages))
+		for _, stage := range stages.AllStages {
+			progress, err := stages.GetStageProgress(tx, stage)
+			if err != nil {
+				return err
+			}
+			pruneProgress, err := stages.GetStagePruneProgress(tx, stage)
+			if err != nil {
+				return err
+			}
+
+			status.Stages = append(status.Stages, StageStatus{Stage: string(stage), Progress: progress, PruneProgress: pruneProgress})
+		}
+
+		execution, err := stages.GetStageProgress(tx, stages.Execution)
+		if err != nil {
+			return err
+		}
+		finish, err := stages.GetStageProgress(tx, stages.Finish)
+		if err != nil {
+			return err
+		}
+
+		status.Syncing = execution != finish
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, srv := range s.Sentries {
+		for _, count := range srv.SimplePeerCount() {
+			status.Peers += count
+		}
+	}
+
+	if s.TxPool != nil {
+		reply, err := s.TxPool.Status(ctx, &txpool.StatusRequest{})
+		if err != nil {
+			logger.Debug("[reporter] txpool status", "err", err)
+		} else {
+			status.TxPool = &TxPoolStatus{Pending: reply.PendingCount, BaseFee: reply.BaseFeeCount, Queued: reply.QueuedCount}
+		}
+	}
+
+	if s.CaplinDB != nil {
+		caplin, err := readCaplinStatus(ctx, s.CaplinDB)
+		if err != nil {
+			logger.Debug("[reporter] caplin status", "err", err)
+		} else {
+			status.Caplin = caplin
+		}
+	}
+
+	if s.Downloader != nil {
+		reply, err := s.Downloader.Stats(ctx, &downloaderproto.StatsRequest{})
+		if err != nil {
+			logger.Debug("[reporter] downloader stats", "err", err)
+		} else {
+			status.Snapshots = &SnapshotStatus{
+				Completed:      reply.Completed,
+				Progress:       reply.Progress,
+				BytesCompleted: reply.BytesCompleted,
+				BytesTotal:     reply.BytesTotal,
+				DownloadRate:   reply.DownloadRate,
+				Peers:          reply.PeersUnique,
+			}
+		}
+	}
+
+	return status, nil
+}
+
+func readCaplinStatus(ctx context.Context, db kv.RoDB) (*CaplinStatus, error) {
+	var status CaplinStatus
+	if err := db.View(ctx, func(tx kv.Tx) error {
+		c, err := tx.Cursor(kv.CanonicalBlockRoots)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		k, _, err := c.Last()
+		if err != nil {
+			return err
+		}
+		if len(k) == 4 {
+			status.HeadSlot = base_encoding.Decode64FromBytes4(k)
+		}
+
+		status.FinalizedSlot, err = beacon_indicies.ReadHighestFinalized(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
@@ -29,6 +29,7 @@ var (
 	_ Error = new(invalidMessageError)
 	_ Error = new(InvalidParamsError)
 	_ Error = new(CustomError)
+	_ Error = new(quotaExceededError)
 )
 
 const defaultErrorCode = -32000
@@ -92,3 +93,12 @@ type CustomError struct {
 func (e *CustomError) ErrorCode() int { return e.Code }
 
 func (e *CustomError) Error() string { return e.Message }
+
+// call exceeds the request quota of the API key
+type quotaExceededError struct{ key, method string }
+
+func (e *quotaExceededError) ErrorCode() int { return -32005 }
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("request quota of API key %s exceeded by %s call", e.key, e.method)
+}
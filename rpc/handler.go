@@ -239,6 +239,8 @@ func (h *handler) handleMsg(msg *jsonrpcMessage, stream *jsoniter.Stream) {
 	h.startCallProc(func(cp *callProc) {
 		needWriteStream := false
 		if stream == nil {
+			// the response is written as a single message: streaming it would hold the connection, so
+			// other responses and subscription notifications would wait for the method to finish
 			stream = jsoniter.NewStream(jsoniter.ConfigDefault, nil, 4096)
 			needWriteStream = true
 		}
@@ -462,6 +464,15 @@ func (h *handler) isMethodAllowedByGranularControl(method string) bool {
 	return ok
 }
 
+// acquireQuota charges the call to the API key of the connection, if any.
+func (h *handler) acquireQuota(ctx context.Context, method string) (release func(), err error) {
+	key := apiKeyFromContext(ctx)
+	if key == nil {
+		return func() {}, nil
+	}
+	return key.acquire(ctx, method)
+}
+
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter.Stream) *jsonrpcMessage {
 	if msg.isSubscribe() {
@@ -480,6 +491,13 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter
 	if err != nil {
 		return msg.errorResponse(&InvalidParamsError{err.Error()})
 	}
+	if callb != h.unsubscribeCb {
+		release, err := h.acquireQuota(cp.ctx, msg.Method)
+		if err != nil {
+			return msg.errorResponse(err)
+		}
+		defer release()
+	}
 	start := time.Now()
 	answer := h.runMethod(cp.ctx, msg, callb, args, stream)
 
@@ -520,6 +538,13 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage, stream *jso
 	}
 	args = args[1:]
 
+	// Only the subscription call is charged, not the notifications it produces.
+	release, err := h.acquireQuota(cp.ctx, msg.Method)
+	if err != nil {
+		return msg.errorResponse(err)
+	}
+	defer release()
+
 	// Install notifier in context so the subscription handler can find it.
 	n := &Notifier{h: h, namespace: namespace}
 	cp.notifiers = append(cp.notifiers, n)
@@ -548,9 +573,16 @@ func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *cal
 		stream.WriteMore()
 	}
 	stream.WriteObjectField("result")
-	_, err := callb.call(ctx, msg.Method, args, stream)
+	// result goes through its own stream, so what the method has written before failing is known
+	// even when it has already been flushed to the connection
+	rw := &resultWriter{out: stream}
+	rs := jsoniter.NewStream(jsoniter.ConfigDefault, rw, 4096)
+	_, err := callb.call(ctx, msg.Method, args, rs)
+	if flushErr := rs.Flush(); err == nil {
+		err = flushErr
+	}
 	if err != nil {
-		writeNilIfNotPresent(stream)
+		rw.writeNilIfNotPresent()
 		stream.WriteMore()
 		HandleError(err, stream)
 	}
@@ -561,49 +593,6 @@ func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *cal
 
 var nullAsBytes = []byte{110, 117, 108, 108}
 
-// there are many avenues that could lead to an error being handled in runMethod, so we need to check
-// if nil has already been written to the stream before writing it again here
-func writeNilIfNotPresent(stream *jsoniter.Stream) {
-	if stream == nil {
-		return
-	}
-	b := stream.Buffer()
-	hasNil := true
-	if len(b) >= 4 {
-		b = b[len(b)-4:]
-		for i, v := range nullAsBytes {
-			if v != b[i] {
-				hasNil = false
-				break
-			}
-		}
-	} else {
-		hasNil = false
-	}
-	if hasNil {
-		// not needed
-		return
-	}
-
-	var validJsonEnd bool
-	if len(b) > 0 {
-		// assumption is that api call handlers would write valid json in case of errors
-		// we are not guaranteed that they did write valid json if last elem is "}" or "]"
-		// since we don't check json nested-ness
-		// however appending "null" after "}" or "]" does not help much either
-		lastIdx := len(b) - 1
-		validJsonEnd = b[lastIdx] == '}' || b[lastIdx] == ']'
-	}
-	if validJsonEnd {
-		// not needed
-		return
-	}
-
-	// does not have nil ending
-	// does not have valid json
-	stream.WriteNil()
-}
-
 // unsubscribe is the callback function for all *_unsubscribe calls.
 func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 	h.subLock.Lock()
@@ -255,6 +255,14 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	connInfo.HTTP.UserAgent = r.Header.Get("User-Agent")
 	ctx := r.Context()
 	ctx = context.WithValue(ctx, peerInfoContextKey{}, connInfo)
+	if s.quotas != nil {
+		key := s.quotas.authenticate(r)
+		if key == nil {
+			http.Error(w, errInvalidAPIKey.Error(), http.StatusUnauthorized)
+			return
+		}
+		ctx = contextWithAPIKey(ctx, key)
+	}
 
 	// All checks passed, create a codec that reads directly from the request body
 	// until EOF, writes the response to w, and orders the server to process a
@@ -0,0 +1,256 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+// APIKeyHeader is the HTTP header carrying the API key. The key can also be passed as the only segment
+// of the URL path, e.g. http://localhost:8545/<key>, for clients which can't set headers.
+const APIKeyHeader = "X-API-Key"
+
+const anonymousKeyName = "anonymous"
+
+var errInvalidAPIKey = errors.New("invalid or missing API key")
+
+var keyNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// QuotaConfig defines API keys accepted by the server and limits applied to them.
+type QuotaConfig struct {
+	Keys []KeyQuota `json:"keys"`
+	// Anonymous are limits of requests which don't carry API key, nil means such requests are rejected.
+	Anonymous *KeyQuota `json:"anonymous"`
+	// MethodCosts maps method name to the cost of its call. Name ending with "*" matches all methods
+	// starting with the preceding prefix, e.g. "trace_*"; exact names take precedence over prefixes,
+	// longer prefixes take precedence over shorter ones.
+	MethodCosts map[string]uint64 `json:"methodCosts"`
+	// DefaultCost is the cost of methods not listed in MethodCosts, 0 means 1.
+	DefaultCost uint64 `json:"defaultCost"`
+}
+
+// KeyQuota defines limits of a single API key.
+type KeyQuota struct {
+	// Name identifies the key in logs and metrics, so the key itself is never exposed.
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	// CostPerSecond is the sustained rate of the key in cost units, 0 means no rate limit.
+	CostPerSecond float64 `json:"costPerSecond"`
+	// Burst is the amount of cost units which may be spent at once, 0 means the larger of one second
+	// worth of CostPerSecond and the highest method cost.
+	Burst uint64 `json:"burst"`
+	// MaxConcurrent is the amount of requests of the key executed in parallel, further requests wait for
+	// their turn. 0 means no limit.
+	MaxConcurrent int `json:"maxConcurrent"`
+}
+
+// Quotas authenticates requests by API key and charges each method call against the quota of the key.
+// Quotas apply to HTTP and WebSocket connections, every call of a batch is charged separately.
+// IPC and in-process connections are trusted and not limited.
+type Quotas struct {
+	keys        map[string]*apiKey
+	anonymous   *apiKey
+	costs       map[string]uint64
+	prefixCosts []prefixCost // sorted by prefix length, longest first
+	defaultCost uint64
+}
+
+type prefixCost struct {
+	prefix string
+	cost   uint64
+}
+
+// apiKey is the state of a single API key shared by all its connections.
+type apiKey struct {
+	q           *Quotas
+	name        string
+	limiter     *rate.Limiter // nil if not rate limited
+	concurrency chan struct{} // nil if not concurrency limited
+	active      metrics.Gauge
+}
+
+type apiKeyContextKey struct{}
+
+// NewQuotas validates the config and creates the quotas.
+func NewQuotas(cfg QuotaConfig) (*Quotas, error) {
+	q := &Quotas{
+		keys:        make(map[string]*apiKey, len(cfg.Keys)),
+		costs:       make(map[string]uint64, len(cfg.MethodCosts)),
+		defaultCost: cfg.DefaultCost,
+	}
+	if q.defaultCost == 0 {
+		q.defaultCost = 1
+	}
+	maxCost, maxCostMethod := q.defaultCost, "default"
+	for method, cost := range cfg.MethodCosts {
+		if prefix, ok := strings.CutSuffix(method, "*"); ok {
+			q.prefixCosts = append(q.prefixCosts, prefixCost{prefix: prefix, cost: cost})
+		} else {
+			q.costs[method] = cost
+		}
+		if cost > maxCost || (cost == maxCost && method < maxCostMethod) {
+			maxCost, maxCostMethod = cost, method
+		}
+	}
+	sort.Slice(q.prefixCosts, func(i, j int) bool {
+		return len(q.prefixCosts[i].prefix) > len(q.prefixCosts[j].prefix)
+	})
+
+	names := make(map[string]struct{}, len(cfg.Keys)+1)
+	newKey := func(kq KeyQuota) (*apiKey, error) {
+		if !keyNameRe.MatchString(kq.Name) {
+			return nil, fmt.Errorf("invalid API key name %q: must be non-empty and consist of letters, digits, '_', '.' or '-'", kq.Name)
+		}
+		if _, ok := names[kq.Name]; ok {
+			return nil, fmt.Errorf("duplicate API key name %q", kq.Name)
+		}
+		names[kq.Name] = struct{}{}
+		if kq.CostPerSecond < 0 || kq.MaxConcurrent < 0 {
+			return nil, fmt.Errorf("API key %q: limits must not be negative", kq.Name)
+		}
+
+		k := &apiKey{
+			q:      q,
+			name:   kq.Name,
+			active: metrics.GetOrCreateGauge(fmt.Sprintf(`rpc_key_active{key="%s"}`, kq.Name)),
+		}
+		if kq.CostPerSecond > 0 {
+			burst := kq.Burst
+			if burst == 0 {
+				burst = max(uint64(math.Ceil(kq.CostPerSecond)), maxCost)
+			}
+			if burst < maxCost {
+				return nil, fmt.Errorf("API key %q: burst %d is lower than cost %d of %s method, it would never be allowed", kq.Name, burst, maxCost, maxCostMethod)
+			}
+			if burst > math.MaxInt32 {
+				return nil, fmt.Errorf("API key %q: burst %d is too large", kq.Name, burst)
+			}
+			k.limiter = rate.NewLimiter(rate.Limit(kq.CostPerSecond), int(burst))
+		}
+		if kq.MaxConcurrent > 0 {
+			k.concurrency = make(chan struct{}, kq.MaxConcurrent)
+		}
+		return k, nil
+	}
+
+	for _, kq := range cfg.Keys {
+		if kq.Key == "" {
+			return nil, fmt.Errorf("API key %q: key must not be empty", kq.Name)
+		}
+		if strings.Contains(kq.Key, "/") {
+			return nil, fmt.Errorf("API key %q: key must not contain '/'", kq.Name)
+		}
+		if _, ok := q.keys[kq.Key]; ok {
+			return nil, fmt.Errorf("API key %q: the key is already used by another entry", kq.Name)
+		}
+		k, err := newKey(kq)
+		if err != nil {
+			return nil, err
+		}
+		q.keys[kq.Key] = k
+	}
+	if cfg.Anonymous != nil {
+		anonymous := *cfg.Anonymous
+		if anonymous.Key != "" {
+			return nil, fmt.Errorf("anonymous quota must not have a key")
+		}
+		if anonymous.Name == "" {
+			anonymous.Name = anonymousKeyName
+		}
+		var err error
+		if q.anonymous, err = newKey(anonymous); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// authenticate returns the API key of the request, or nil if the request carries an unknown key, or none
+// while anonymous requests aren't allowed.
+func (q *Quotas) authenticate(r *http.Request) *apiKey {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		key = strings.Trim(r.URL.Path, "/")
+	}
+	if key == "" {
+		return q.anonymous
+	}
+	return q.keys[key]
+}
+
+// cost returns the cost of the method call.
+func (q *Quotas) cost(method string) uint64 {
+	if cost, ok := q.costs[method]; ok {
+		return cost
+	}
+	for _, pc := range q.prefixCosts {
+		if strings.HasPrefix(method, pc.prefix) {
+			return pc.cost
+		}
+	}
+	return q.defaultCost
+}
+
+// acquire charges the cost of the method call to the quota of the key and waits for a free concurrency slot.
+// The returned release function must be called once the call completes.
+func (k *apiKey) acquire(ctx context.Context, method string) (release func(), err error) {
+	cost := k.q.cost(method)
+	metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_key_requests_total{key="%s",method="%s"}`, k.name, method)).Inc()
+	if k.limiter != nil && !k.limiter.AllowN(time.Now(), int(cost)) {
+		metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_key_rejected_total{key="%s",method="%s"}`, k.name, method)).Inc()
+		return nil, &quotaExceededError{key: k.name, method: method}
+	}
+	metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_key_cost_total{key="%s",method="%s"}`, k.name, method)).AddUint64(cost)
+	if k.concurrency != nil {
+		select {
+		case k.concurrency <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	k.active.Inc()
+	return func() {
+		k.active.Dec()
+		if k.concurrency != nil {
+			<-k.concurrency
+		}
+	}, nil
+}
+
+func contextWithAPIKey(ctx context.Context, key *apiKey) context.Context {
+	if key == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+func apiKeyFromContext(ctx context.Context) *apiKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*apiKey)
+	return key
+}
@@ -0,0 +1,234 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+func newQuotaTestServer(t *testing.T, cfg QuotaConfig) (httpURL, wsURL string) {
+	t.Helper()
+	logger := log.New()
+	srv := newTestServer(logger)
+	quotas, err := NewQuotas(cfg)
+	require.NoError(t, err)
+	srv.SetQuotas(quotas)
+	t.Cleanup(srv.Stop)
+
+	httpsrv := httptest.NewServer(srv)
+	t.Cleanup(httpsrv.Close)
+	wssrv := httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false, logger))
+	t.Cleanup(wssrv.Close)
+	return httpsrv.URL, "ws:" + strings.TrimPrefix(wssrv.URL, "http:")
+}
+
+func requireQuotaExceeded(t *testing.T, err error) {
+	t.Helper()
+	var rpcErr Error
+	require.True(t, errors.As(err, &rpcErr), "unexpected error %v", err)
+	require.Equal(t, -32005, rpcErr.ErrorCode())
+}
+
+func TestQuotasAuthentication(t *testing.T) {
+	t.Parallel()
+	logger := log.New()
+	httpURL, wsURL := newQuotaTestServer(t, QuotaConfig{
+		Keys: []KeyQuota{{Name: "auth-test", Key: "secret"}},
+	})
+
+	// key in the header
+	client, err := DialHTTP(httpURL, logger)
+	require.NoError(t, err)
+	defer client.Close()
+	client.SetHeader(APIKeyHeader, "secret")
+	require.NoError(t, client.Call(nil, "test_noArgsRets"))
+
+	// key in the path
+	client, err = DialHTTP(httpURL+"/secret", logger)
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Call(nil, "test_noArgsRets"))
+
+	// unknown key, anonymous requests aren't allowed
+	for _, url := range []string{httpURL, httpURL + "/wrong"} {
+		client, err = DialHTTP(url, logger)
+		require.NoError(t, err)
+		defer client.Close()
+		err = client.Call(nil, "test_noArgsRets")
+		require.ErrorContains(t, err, "401")
+	}
+
+	wsClient, err := DialWebsocket(context.Background(), wsURL+"/secret", "", logger)
+	require.NoError(t, err)
+	defer wsClient.Close()
+	require.NoError(t, wsClient.Call(nil, "test_noArgsRets"))
+
+	_, err = DialWebsocket(context.Background(), wsURL+"/wrong", "", logger)
+	require.Error(t, err)
+	_, err = DialWebsocket(context.Background(), wsURL, "", logger)
+	require.Error(t, err)
+}
+
+func TestQuotasMethodCosts(t *testing.T) {
+	t.Parallel()
+	logger := log.New()
+	cfg := QuotaConfig{
+		// negligible rate, so the burst isn't refilled during the test
+		Keys: []KeyQuota{
+			{Name: "cost-test-http", Key: "http", CostPerSecond: 0.0001, Burst: 10},
+			{Name: "cost-test-ws", Key: "ws", CostPerSecond: 0.0001, Burst: 10},
+			{Name: "cost-test-batch", Key: "batch", CostPerSecond: 0.0001, Burst: 10},
+		},
+		Anonymous:   &KeyQuota{},
+		MethodCosts: map[string]uint64{"test_*": 4, "test_noArgsRets": 1},
+	}
+	httpURL, wsURL := newQuotaTestServer(t, cfg)
+
+	httpClient, err := DialHTTP(httpURL+"/http", logger)
+	require.NoError(t, err)
+	defer httpClient.Close()
+	wsClient, err := DialWebsocket(context.Background(), wsURL+"/ws", "", logger)
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	for name, client := range map[string]*Client{"http": httpClient, "ws": wsClient} {
+		t.Run(name, func(t *testing.T) {
+			var s string
+			require.NoError(t, client.Call(&s, "test_rets"))
+			require.NoError(t, client.Call(&s, "test_rets"))
+			requireQuotaExceeded(t, client.Call(&s, "test_rets"))
+			// cheaper method still fits into the rest of the quota
+			require.NoError(t, client.Call(nil, "test_noArgsRets"))
+			require.NoError(t, client.Call(nil, "test_noArgsRets"))
+			requireQuotaExceeded(t, client.Call(nil, "test_noArgsRets"))
+
+			key := "cost-test-" + name
+			require.Equal(t, uint64(8), metrics.GetOrCreateCounter(`rpc_key_cost_total{key="`+key+`",method="test_rets"}`).GetValueUint64())
+			require.Equal(t, uint64(3), metrics.GetOrCreateCounter(`rpc_key_requests_total{key="`+key+`",method="test_rets"}`).GetValueUint64())
+			require.Equal(t, uint64(1), metrics.GetOrCreateCounter(`rpc_key_rejected_total{key="`+key+`",method="test_rets"}`).GetValueUint64())
+		})
+	}
+
+	t.Run("batch", func(t *testing.T) {
+		client, err := DialHTTP(httpURL+"/batch", logger)
+		require.NoError(t, err)
+		defer client.Close()
+		batch := make([]BatchElem, 3)
+		for i := range batch {
+			batch[i] = BatchElem{Method: "test_rets", Result: new(string)}
+		}
+		require.NoError(t, client.BatchCall(batch))
+		var exceeded int
+		for _, elem := range batch {
+			if elem.Error != nil {
+				requireQuotaExceeded(t, elem.Error)
+				exceeded++
+			}
+		}
+		require.Equal(t, 1, exceeded)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		client, err := DialHTTP(httpURL, logger)
+		require.NoError(t, err)
+		defer client.Close()
+		for i := 0; i < 5; i++ {
+			require.NoError(t, client.Call(nil, "test_noArgsRets"))
+		}
+	})
+}
+
+func TestQuotasConcurrency(t *testing.T) {
+	t.Parallel()
+	logger := log.New()
+	httpURL, _ := newQuotaTestServer(t, QuotaConfig{
+		Keys: []KeyQuota{
+			{Name: "concurrency-test", Key: "limited", MaxConcurrent: 1},
+			{Name: "concurrency-test-other", Key: "other", MaxConcurrent: 1},
+		},
+	})
+	client, err := DialHTTP(httpURL+"/limited", logger)
+	require.NoError(t, err)
+	defer client.Close()
+	other, err := DialHTTP(httpURL+"/other", logger)
+	require.NoError(t, err)
+	defer other.Close()
+
+	blockCtx, unblock := context.WithCancel(context.Background())
+	blockDone := make(chan struct{})
+	go func() {
+		defer close(blockDone)
+		_ = client.CallContext(blockCtx, nil, "test_block")
+	}()
+	active := metrics.GetOrCreateGauge(`rpc_key_active{key="concurrency-test"}`)
+	require.Eventually(t, func() bool { return active.GetValue() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// the only slot of the key is taken, the call waits until it gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.Error(t, client.CallContext(ctx, nil, "test_noArgsRets"))
+	// other keys aren't affected
+	require.NoError(t, other.Call(nil, "test_noArgsRets"))
+
+	unblock()
+	<-blockDone
+	require.NoError(t, client.Call(nil, "test_noArgsRets"))
+}
+
+func TestNewQuotasValidation(t *testing.T) {
+	t.Parallel()
+	for name, cfg := range map[string]QuotaConfig{
+		"empty name":     {Keys: []KeyQuota{{Key: "a"}}},
+		"invalid name":   {Keys: []KeyQuota{{Name: `a"b`, Key: "a"}}},
+		"empty key":      {Keys: []KeyQuota{{Name: "a"}}},
+		"duplicate name": {Keys: []KeyQuota{{Name: "a", Key: "a"}, {Name: "a", Key: "b"}}},
+		"duplicate key":  {Keys: []KeyQuota{{Name: "a", Key: "a"}, {Name: "b", Key: "a"}}},
+		"anonymous key":  {Anonymous: &KeyQuota{Key: "a"}},
+		"burst below cost": {
+			Keys:        []KeyQuota{{Name: "a", Key: "a", CostPerSecond: 10, Burst: 10}},
+			MethodCosts: map[string]uint64{"trace_*": 11},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewQuotas(cfg)
+			require.Error(t, err)
+		})
+	}
+
+	q, err := NewQuotas(QuotaConfig{
+		Keys:        []KeyQuota{{Name: "a", Key: "a", CostPerSecond: 10}},
+		MethodCosts: map[string]uint64{"trace_*": 20, "trace_block": 5, "trace_f*": 50},
+		DefaultCost: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), q.cost("trace_block"))
+	require.Equal(t, uint64(50), q.cost("trace_filter"))
+	require.Equal(t, uint64(20), q.cost("trace_transaction"))
+	require.Equal(t, uint64(2), q.cost("eth_blockNumber"))
+	// default burst fits the most expensive method
+	require.Equal(t, 50, q.keys["a"].limiter.Burst())
+}
@@ -51,6 +51,7 @@ const (
 type Server struct {
 	services        serviceRegistry
 	methodAllowList AllowList
+	quotas          *Quotas
 	idgen           func() ID
 	run             int32
 	codecs          mapset.Set // mapset.Set[ServerCodec] requires go 1.20
@@ -80,6 +81,12 @@ func (s *Server) SetAllowList(allowList AllowList) {
 	s.methodAllowList = allowList
 }
 
+// SetQuotas enables API key authentication of HTTP and WebSocket requests and charges their calls to the
+// quotas of the keys
+func (s *Server) SetQuotas(quotas *Quotas) {
+	s.quotas = quotas
+}
+
 // SetBatchLimit sets limit of number of requests in a batch
 func (s *Server) SetBatchLimit(limit int) {
 	s.batchLimit = limit
@@ -99,6 +106,11 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(codec, nil)
+}
+
+// serveCodec serves the codec, charging calls to the given API key unless it is nil.
+func (s *Server) serveCodec(codec ServerCodec, key *apiKey) {
 	defer codec.Close()
 
 	// Don't serve if server is stopped.
@@ -110,7 +122,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services, s.logger)
+	c := initClient(codec, s.idgen, &s.services, key, s.logger)
 	<-codec.closed()
 	c.Close()
 }
@@ -0,0 +1,138 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// streamFlushSize is the amount of buffered response after which it is written to the connection.
+const streamFlushSize = 32 * 1024
+
+// ArrayStream writes JSON array result of a streamable method element by element.
+//
+// Over HTTP buffered elements are written to the connection as soon as they exceed streamFlushSize, so memory
+// used by the method doesn't depend on the size of its result. Writes block while the client doesn't keep up
+// with reading, and so does Next: a slow client slows the method down instead of letting the response pile up.
+// WebSocket and IPC connections carry other responses and subscription notifications as well, so there the
+// response is buffered and written at once.
+type ArrayStream struct {
+	ctx    context.Context
+	stream *jsoniter.Stream
+	empty  bool
+}
+
+// NewArrayStream starts JSON array in the stream.
+func NewArrayStream(ctx context.Context, stream *jsoniter.Stream) *ArrayStream {
+	stream.WriteArrayStart()
+	return &ArrayStream{ctx: ctx, stream: stream, empty: true}
+}
+
+// Next starts the next element of the array and returns the stream to write it to. It fails if the request
+// is cancelled or the connection is broken, then the method has to stop and return the error via Close.
+func (s *ArrayStream) Next() (*jsoniter.Stream, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.stream.Buffered() >= streamFlushSize {
+		if err := s.stream.Flush(); err != nil {
+			return nil, err
+		}
+	}
+	if !s.empty {
+		s.stream.WriteMore()
+	}
+	s.empty = false
+	return s.stream, nil
+}
+
+// WriteError writes {"error":{...}} element, reporting failure of a single item without failing the whole result.
+func (s *ArrayStream) WriteError(err error) error {
+	stream, nextErr := s.Next()
+	if nextErr != nil {
+		return nextErr
+	}
+	stream.WriteObjectStart()
+	HandleError(err, stream)
+	stream.WriteObjectEnd()
+	return nil
+}
+
+// Close terminates the array and flushes the stream. Non-nil err means the method has failed mid-stream:
+// the array is terminated anyway, so the response carries valid partial result along with the error.
+// Close returns err, or the flush error if err is nil.
+func (s *ArrayStream) Close(err error) error {
+	s.stream.WriteArrayEnd()
+	if flushErr := s.stream.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// resultWriter passes the result of a streamable method through to the response stream. Parts of the result
+// may reach the connection before the method fails, so resultWriter remembers how much of it was written and
+// how it ends, to let runMethod complete the response.
+type resultWriter struct {
+	out  *jsoniter.Stream
+	n    int
+	tail []byte // at most len(nullAsBytes) last written bytes
+}
+
+func (w *resultWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if _, err := w.out.Write(p); err != nil {
+		return 0, err
+	}
+	w.n += len(p)
+	if len(p) >= len(nullAsBytes) {
+		w.tail = append(w.tail[:0], p[len(p)-len(nullAsBytes):]...)
+	} else {
+		w.tail = append(w.tail, p...)
+		if len(w.tail) > len(nullAsBytes) {
+			w.tail = w.tail[len(w.tail)-len(nullAsBytes):]
+		}
+	}
+	return len(p), nil
+}
+
+// writeNilIfNotPresent is used on error: there are many avenues that could lead to an error being handled
+// in runMethod, so we need to check if nil has already been written to the stream before writing it again
+func (w *resultWriter) writeNilIfNotPresent() {
+	if w.n == 0 {
+		w.out.WriteNil()
+		return
+	}
+	if bytes.Equal(w.tail, nullAsBytes) {
+		// not needed
+		return
+	}
+	// assumption is that api call handlers would write valid json in case of errors
+	// we are not guaranteed that they did write valid json if last elem is "}" or "]"
+	// since we don't check json nested-ness
+	// however appending "null" after "}" or "]" does not help much either
+	if last := w.tail[len(w.tail)-1]; last == '}' || last == ']' {
+		return
+	}
+	// does not have nil ending
+	// does not have valid json
+	w.out.WriteNil()
+}
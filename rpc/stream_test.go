@@ -0,0 +1,193 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+type streamTestService struct {
+	started, release chan struct{} // used by Blocking
+}
+
+// Array streams n strings of given size, failing before element failAt if it is less than n.
+func (s *streamTestService) Array(ctx context.Context, n, size, failAt int, stream *jsoniter.Stream) error {
+	as := NewArrayStream(ctx, stream)
+	for i := 0; i < n; i++ {
+		if i == failAt {
+			return as.Close(errors.New("failed mid-stream"))
+		}
+		el, err := as.Next()
+		if err != nil {
+			return as.Close(err)
+		}
+		el.WriteString(strings.Repeat("x", size))
+	}
+	return as.Close(nil)
+}
+
+// Blocking streams n strings of given size, then waits for release before completing the array.
+func (s *streamTestService) Blocking(ctx context.Context, n, size int, stream *jsoniter.Stream) error {
+	as := NewArrayStream(ctx, stream)
+	for i := 0; i < n; i++ {
+		el, err := as.Next()
+		if err != nil {
+			return as.Close(err)
+		}
+		el.WriteString(strings.Repeat("x", size))
+	}
+	s.started <- struct{}{}
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return as.Close(ctx.Err())
+	}
+	return as.Close(nil)
+}
+
+func TestStreamedArray(t *testing.T) {
+	t.Parallel()
+	logger := log.New()
+
+	srv := NewServer(50, false /* traceRequests */, false /* debugSingleRequests */, false /* disableStreaming */, logger, 100)
+	require.NoError(t, srv.RegisterName("test", new(testService)))
+	require.NoError(t, srv.RegisterName("stream", new(streamTestService)))
+	defer srv.Stop()
+	httpsrv := httptest.NewServer(srv)
+	defer httpsrv.Close()
+	wssrv := httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false, logger))
+	defer wssrv.Close()
+
+	httpClient, err := DialHTTP(httpsrv.URL, logger)
+	require.NoError(t, err)
+	defer httpClient.Close()
+	wsClient, err := DialWebsocket(context.Background(), "ws:"+strings.TrimPrefix(wssrv.URL, "http:"), "", logger)
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	for name, client := range map[string]*Client{"http": httpClient, "ws": wsClient} {
+		t.Run(name, func(t *testing.T) {
+			// many times larger than streamFlushSize, so it's written in parts
+			const n, size = 2000, 100
+			var result []string
+			require.NoError(t, client.Call(&result, "stream_array", n, size, n))
+			require.Len(t, result, n)
+			require.Equal(t, strings.Repeat("x", size), result[n-1])
+
+			// a message written in parts must not interleave with others
+			var echo echoResult
+			require.NoError(t, client.Call(&echo, "test_echo", "x", 1))
+			require.Equal(t, "x", echo.String)
+
+			err := client.Call(&result, "stream_array", n, size, n/2)
+			require.EqualError(t, err, "failed mid-stream")
+		})
+	}
+}
+
+func TestStreamedArrayFailsMidStream(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer(50, false /* traceRequests */, false /* debugSingleRequests */, false /* disableStreaming */, log.New(), 100)
+	require.NoError(t, srv.RegisterName("stream", new(streamTestService)))
+	defer srv.Stop()
+	httpsrv := httptest.NewServer(srv)
+	defer httpsrv.Close()
+
+	for _, tt := range []struct {
+		name     string
+		params   string
+		expected string
+	}{
+		{
+			name:     "before first element",
+			params:   `[3,1,0]`,
+			expected: `{"jsonrpc":"2.0","id":1,"result":[],"error":{"code":-32000,"message":"failed mid-stream"}}`,
+		},
+		{
+			name:     "after flushed elements",
+			params:   `[3,20000,2]`,
+			expected: `{"jsonrpc":"2.0","id":1,"result":["` + strings.Repeat("x", 20000) + `","` + strings.Repeat("x", 20000) + `"],"error":{"code":-32000,"message":"failed mid-stream"}}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"jsonrpc":"2.0","id":1,"method":"stream_array","params":` + tt.params + `}`
+			resp, err := httpsrv.Client().Post(httpsrv.URL, contentType, strings.NewReader(body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			var out strings.Builder
+			_, err = io.Copy(&out, resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, strings.TrimSpace(out.String()))
+		})
+	}
+}
+
+func TestStreamedArrayDoesNotBlockNotifications(t *testing.T) {
+	t.Parallel()
+	logger := log.New()
+
+	service := &streamTestService{started: make(chan struct{}), release: make(chan struct{})}
+	srv := NewServer(50, false /* traceRequests */, false /* debugSingleRequests */, false /* disableStreaming */, logger, 100)
+	require.NoError(t, srv.RegisterName("stream", service))
+	require.NoError(t, srv.RegisterName("nftest", new(notificationTestService)))
+	defer srv.Stop()
+	wssrv := httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false, logger))
+	defer wssrv.Close()
+	client, err := DialWebsocket(context.Background(), "ws:"+strings.TrimPrefix(wssrv.URL, "http:"), "", logger)
+	require.NoError(t, err)
+	defer client.Close()
+
+	// many times larger than streamFlushSize, the method blocks after producing it
+	const n, size = 2000, 100
+	var result []string
+	errc := make(chan error, 1)
+	go func() { errc <- client.Call(&result, "stream_blocking", n, size) }()
+	<-service.started
+	var release sync.Once
+	defer release.Do(func() { close(service.release) })
+
+	// the subscription is made and notified while the response is being produced
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	nc := make(chan int)
+	sub, err := client.Subscribe(ctx, "nftest", nc, "someSubscription", 1, 7)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	select {
+	case v := <-nc:
+		require.Equal(t, 7, v)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification is not delivered during the streamed response")
+	}
+
+	release.Do(func() { close(service.release) })
+	require.NoError(t, <-errc)
+	require.Len(t, result, n)
+}
@@ -62,13 +62,20 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, jwtSecret []byte, com
 		if jwtSecret != nil && !CheckJwtSecret(w, r, jwtSecret) {
 			return
 		}
+		var key *apiKey
+		if s.quotas != nil {
+			if key = s.quotas.authenticate(r); key == nil {
+				http.Error(w, errInvalidAPIKey.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			logger.Warn("WebSocket upgrade failed", "err", err)
 			return
 		}
 		codec := NewWebsocketCodec(conn, r.Host, r.Header)
-		s.ServeCodec(codec, 0)
+		s.serveCodec(codec, key)
 	})
 }
 
@@ -0,0 +1,43 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build integration
+
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEOF(t *testing.T) {
+	tm := new(testMatcher)
+	tm.walk(t, eofTestDir, func(t *testing.T, name string, test *EOFTest) {
+		if err := tm.checkFailure(t, test.Run()); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// TestExecutionSpecEOF runs the eof_tests fixtures of the execution-spec-tests
+func TestExecutionSpecEOF(t *testing.T) {
+	tm := new(testMatcher)
+	tm.walk(t, filepath.Join(".", "execution-spec-tests", "eof_tests"), func(t *testing.T, name string, test *EOFTest) {
+		if err := tm.checkFailure(t, test.Run()); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/erigontech/erigon-lib/common/hexutility"
+
+	"github.com/erigontech/erigon/core/vm"
+)
+
+// EOFTest is the JSON structure of a single EOF validation test (EOFTests, eof_tests fixtures).
+type EOFTest struct {
+	Vectors map[string]EOFTestVector `json:"vectors"`
+}
+
+type EOFTestVector struct {
+	Code hexutility.Bytes `json:"code"`
+	// ContainerKind is INITCODE for the containers of creation txns and EOFCREATE, RUNTIME otherwise
+	ContainerKind string                   `json:"containerKind"`
+	Results       map[string]EOFTestResult `json:"results"`
+}
+
+type EOFTestResult struct {
+	Result    bool   `json:"result"`
+	Exception string `json:"exception,omitempty"`
+}
+
+// Run validates the containers of all vectors against the expected results. Results of the forks
+// before EOF activation (Osaka) are skipped: there is no EOF validation to check.
+func (t *EOFTest) Run() error {
+	names := make([]string, 0, len(t.Vectors))
+	for name := range t.Vectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		vector := t.Vectors[name]
+		for fork, expected := range vector.Results {
+			config, ok := Forks[fork]
+			if !ok {
+				return UnsupportedForkError{fork}
+			}
+			if !config.IsOsaka(0) {
+				continue
+			}
+			_, err := vm.ParseAndValidateEOF(vector.Code, vector.ContainerKind == "INITCODE")
+			if expected.Result && err != nil {
+				return fmt.Errorf("vector %s, fork %s: unexpected validation error: %w", name, fork, err)
+			}
+			if !expected.Result && err == nil {
+				return fmt.Errorf("vector %s, fork %s: expected validation error %s", name, fork, expected.Exception)
+			}
+		}
+	}
+	return nil
+}
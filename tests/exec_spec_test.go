@@ -36,6 +36,9 @@ func TestExecutionSpec(t *testing.T) {
 	// TODO(yperbasis) make it work
 	bt.skipLoad(`^prague/eip2935_historical_block_hashes_from_state/block_hashes/block_hashes_history.json`)
 
+	// EOF validation fixtures, run by TestExecutionSpecEOF
+	bt.skipLoad(`^eof_tests/`)
+
 	checkStateRoot := true
 
 	bt.walk(t, dir, func(t *testing.T, name string, test *BlockTest) {
@@ -43,9 +43,17 @@ var DefaultFlags = []cli.Flag{
 	&utils.TxPoolLifetimeFlag,
 	&utils.TxPoolTraceSendersFlag,
 	&utils.TxPoolCommitEveryFlag,
+	&utils.TxPoolTrustedSendersFlag,
+	&utils.TxPoolLocalLaneSlotsFlag,
+	&utils.TxPoolTrustedLaneSlotsFlag,
+	&utils.TxPoolRemoteLaneSlotsFlag,
+	&utils.TxPoolPeerTxsPerSecondFlag,
+	&utils.TxPoolPeerTxsBurstFlag,
+	&utils.TxPoolDiscardHistoryRetentionFlag,
 	&PruneDistanceFlag,
 	&PruneBlocksDistanceFlag,
 	&PruneModeFlag,
+	&PruneIncludeCommitmentHistoryFlag,
 	&BatchSizeFlag,
 	&BodyCacheLimitFlag,
 	&DatabaseVerbosityFlag,
@@ -83,6 +91,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.RpcStreamingDisableFlag,
 	&utils.DBReadConcurrencyFlag,
 	&utils.RpcAccessListFlag,
+	&utils.RpcQuotasFileFlag,
 	&utils.RpcTraceCompatFlag,
 	&utils.RpcGasCapFlag,
 	&utils.RpcBatchLimit,
@@ -119,6 +128,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.TorrentConnsPerFileFlag,
 	&utils.TorrentDownloadSlotsFlag,
 	&utils.TorrentStaticPeersFlag,
+	&utils.TorrentWebDownloadFlag,
 	&utils.TorrentUploadRateFlag,
 	&utils.TorrentDownloadRateFlag,
 	&utils.TorrentVerbosityFlag,
@@ -167,6 +177,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.DownloaderVerifyFlag,
 	&HealthCheckFlag,
 	&utils.HeimdallURLFlag,
+	&utils.HeimdallRecordFlag,
 	&utils.WebSeedsFlag,
 	&utils.WithoutHeimdallFlag,
 	&utils.BorBlockPeriodFlag,
@@ -176,6 +187,8 @@ var DefaultFlags = []cli.Flag{
 	&utils.PolygonSyncFlag,
 	&utils.PolygonSyncStageFlag,
 	&utils.EthStatsURLFlag,
+	&utils.ReporterFlag,
+	&utils.ReporterIntervalFlag,
 	&utils.OverridePragueFlag,
 
 	&utils.CaplinDiscoveryAddrFlag,
@@ -215,7 +228,17 @@ var DefaultFlags = []cli.Flag{
 	&utils.CaplinDisableBlobPruningFlag,
 	&utils.CaplinArchiveFlag,
 	&utils.CaplinMevRelayUrl,
+	&utils.CaplinMevMaxMissedSlotsFlag,
+	&utils.CaplinMevMaxRelayFaultsFlag,
+	&utils.CaplinMevFaultCooldownEpochsFlag,
+	&utils.CaplinMevMinBidFlag,
+	&utils.CaplinMevLocalValueBoostFlag,
+	&utils.CaplinMevHeaderTimeoutFlag,
 	&utils.CaplinValidatorMonitorFlag,
+	&utils.CaplinValidatorSignerUrlFlag,
+	&utils.CaplinValidatorSignerTimeoutFlag,
+	&utils.CaplinValidatorPubKeysFlag,
+	&utils.CaplinValidatorGraffitiFlag,
 
 	&utils.TrustedSetupFile,
 	&utils.RPCSlowFlag,
@@ -224,4 +247,5 @@ var DefaultFlags = []cli.Flag{
 	&SyncLoopBlockLimitFlag,
 	&SyncLoopBreakAfterFlag,
 	&SyncLoopPruneLimitFlag,
+	&SyncExecSpeculativeWorkersFlag,
 }
@@ -98,6 +98,10 @@ var (
 		Name:  "prune.distance.blocks",
 		Usage: `Keep block history for the latest N blocks (default: everything)`,
 	}
+	PruneIncludeCommitmentHistoryFlag = cli.BoolFlag{
+		Name:  "prune.include-commitment-history",
+		Usage: "Keep commitment history in db for recent blocks, required by eth_getProof for blocks other than latest. Increases db size",
+	}
 	ExperimentsFlag = cli.StringFlag{
 		Name: "experiments",
 		Usage: `Enable some experimental stages:
@@ -155,9 +159,15 @@ var (
 		Value: 5_000,
 	}
 
+	SyncExecSpeculativeWorkersFlag = cli.IntFlag{
+		Name:  "sync.exec.speculative.workers",
+		Usage: "Number of workers executing the transactions of a block speculatively in parallel at the chain tip (conflicting transactions are re-executed serially), 0 or 1 to disable",
+		Value: 0,
+	}
+
 	UploadLocationFlag = cli.StringFlag{
 		Name:  "upload.location",
-		Usage: "Location to upload snapshot segments to",
+		Usage: "Location to upload snapshot segments to: an rclone remote, s3://bucket/path (configured by AWS_* env vars) or http(s):// url accepting PUT",
 		Value: "",
 	}
 
@@ -309,6 +319,7 @@ func ApplyFlagsForEthConfig(ctx *cli.Context, cfg *ethconfig.Config, logger log.
 		utils.Fatalf(fmt.Sprintf("error while parsing mode: %v", err))
 	}
 	cfg.Prune = mode
+	cfg.KeepExecutionProofs = ctx.Bool(PruneIncludeCommitmentHistoryFlag.Name)
 	if ctx.String(BatchSizeFlag.Name) != "" {
 		err := cfg.BatchSize.UnmarshalText([]byte(ctx.String(BatchSizeFlag.Name)))
 		if err != nil {
@@ -354,6 +365,10 @@ func ApplyFlagsForEthConfig(ctx *cli.Context, cfg *ethconfig.Config, logger log.
 		cfg.Sync.LoopBlockLimit = limit
 	}
 
+	if workers := ctx.Int(SyncExecSpeculativeWorkersFlag.Name); workers > 0 {
+		cfg.Sync.SpeculativeExecWorkers = workers
+	}
+
 	if location := ctx.String(UploadLocationFlag.Name); len(location) > 0 {
 		cfg.Sync.UploadLocation = location
 	}
@@ -435,6 +450,9 @@ func ApplyFlagsForEthConfigCobra(f *pflag.FlagSet, cfg *ethconfig.Config) {
 		utils.Fatalf("error: --prune.mode must be one of archive, full, minimal")
 	}
 	cfg.Prune = mode
+	if v := f.Bool(PruneIncludeCommitmentHistoryFlag.Name, PruneIncludeCommitmentHistoryFlag.Value, PruneIncludeCommitmentHistoryFlag.Usage); v != nil {
+		cfg.KeepExecutionProofs = *v
+	}
 
 	if v := f.String(BatchSizeFlag.Name, BatchSizeFlag.Value, BatchSizeFlag.Usage); v != nil {
 		err := cfg.BatchSize.UnmarshalText([]byte(*v))
@@ -519,6 +537,7 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logg
 		RpcStreamingDisable:               ctx.Bool(utils.RpcStreamingDisableFlag.Name),
 		DBReadConcurrency:                 ctx.Int(utils.DBReadConcurrencyFlag.Name),
 		RpcAllowListFilePath:              ctx.String(utils.RpcAccessListFlag.Name),
+		RpcQuotasFilePath:                 ctx.String(utils.RpcQuotasFileFlag.Name),
 		RpcFiltersConfig: rpchelper.FiltersConfig{
 			RpcSubscriptionFiltersMaxLogs:      ctx.Int(RpcSubscriptionFiltersMaxLogsFlag.Name),
 			RpcSubscriptionFiltersMaxHeaders:   ctx.Int(RpcSubscriptionFiltersMaxHeadersFlag.Name),
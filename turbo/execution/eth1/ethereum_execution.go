@@ -287,15 +287,26 @@ func (e *EthereumExecutionModule) ValidateChain(ctx context.Context, req *execut
 		validationStatus = execution.ExecutionStatus_MissingSegment
 	}
 	isInvalidChain := status == engine_types.InvalidStatus || status == engine_types.InvalidBlockHashStatus || validationError != nil
+	if isInvalidChain {
+		e.logger.Warn("ethereumExecutionModule.ValidateChain: chain is invalid", "hash", libcommon.Hash(blockHash))
+		validationStatus = execution.ExecutionStatus_BadBlock
+		// payload was validated in memory, so it's the place to persist it for debug_getBadBlocks.
+		// Read the failed block before its header is purged.
+		badHeader, badBody, err := e.failedBlock(ctx, tx, header, body, lvh)
+		if err != nil {
+			return nil, err
+		}
+		if badHeader != nil {
+			if err := e.writeBadBlock(ctx, tx, badHeader, badBody, validationError); err != nil {
+				return nil, err
+			}
+		}
+	}
 	if isInvalidChain && (lvh != libcommon.Hash{}) && lvh != blockHash {
 		if err := e.purgeBadChain(ctx, tx, lvh, blockHash); err != nil {
 			return nil, err
 		}
 	}
-	if isInvalidChain {
-		e.logger.Warn("ethereumExecutionModule.ValidateChain: chain is invalid", "hash", libcommon.Hash(blockHash))
-		validationStatus = execution.ExecutionStatus_BadBlock
-	}
 	validationReceipt := &execution.ValidationReceipt{
 		ValidationStatus: validationStatus,
 		LatestValidHash:  gointerfaces.ConvertHashToH256(lvh),
@@ -306,6 +317,54 @@ func (e *EthereumExecutionModule) ValidateChain(ctx context.Context, req *execut
 	return validationReceipt, tx.Commit()
 }
 
+// failedBlock returns the block of the chain ending with header which failed validation: the child of the latest
+// valid hash, which is an ancestor of header when the failure isn't in the requested block itself.
+// Nil is returned when the block can't be found.
+func (e *EthereumExecutionModule) failedBlock(ctx context.Context, tx kv.Tx, header *types.Header, body *types.Body, latestValidHash libcommon.Hash) (*types.Header, *types.Body, error) {
+	if (latestValidHash == libcommon.Hash{}) || header.ParentHash == latestValidHash || header.Hash() == latestValidHash {
+		return header, body, nil
+	}
+	validNumber := rawdb.ReadHeaderNumber(tx, latestValidHash)
+	if validNumber == nil {
+		return nil, nil, nil
+	}
+	current := header
+	for current.ParentHash != latestValidHash {
+		number := current.Number.Uint64()
+		if number <= *validNumber+1 {
+			return nil, nil, nil
+		}
+		parent, err := e.getHeader(ctx, tx, current.ParentHash, number-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if parent == nil {
+			return nil, nil, nil
+		}
+		current = parent
+	}
+	failedBody, err := e.getBody(ctx, tx, current.Hash(), current.Number.Uint64())
+	if err != nil || failedBody == nil {
+		return nil, nil, err
+	}
+	return current, failedBody, nil
+}
+
+func (e *EthereumExecutionModule) writeBadBlock(ctx context.Context, tx kv.RwTx, header *types.Header, body *types.Body, validationErr error) error {
+	var preStateRoot libcommon.Hash
+	if number := header.Number.Uint64(); number > 0 {
+		parent, err := e.blockReader.Header(ctx, tx, header.ParentHash, number-1)
+		if err != nil {
+			return err
+		}
+		if parent != nil {
+			preStateRoot = parent.Root
+		}
+	}
+	block := types.NewBlockFromStorage(header.Hash(), header, body.Transactions, body.Uncles, body.Withdrawals, body.Requests)
+	return rawdb.WriteBadBlock(tx, block, validationErr, preStateRoot)
+}
+
 func (e *EthereumExecutionModule) purgeBadChain(ctx context.Context, tx kv.RwTx, latestValidHash, headHash libcommon.Hash) error {
 	tip := rawdb.ReadHeaderNumber(tx, headHash)
 
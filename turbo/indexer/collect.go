@@ -0,0 +1,149 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package indexer
+
+import (
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/core/vm"
+)
+
+// CallTracer collects call frames of a txn into TxResult.Calls.
+type CallTracer struct {
+	calls []Call
+	stack []int // indices of calls which are not exited yet
+}
+
+func NewCallTracer() *CallTracer { return &CallTracer{} }
+
+// Calls returns the collected frames, the tracer must not be used afterwards.
+func (t *CallTracer) Calls() []Call { return t.calls }
+
+func (t *CallTracer) SetTransaction(tx types.Transaction) {}
+func (t *CallTracer) Found() bool                         { return len(t.calls) > 0 }
+
+func (t *CallTracer) CaptureTxStart(gasLimit uint64) {}
+func (t *CallTracer) CaptureTxEnd(restGas uint64)    {}
+
+func (t *CallTracer) CaptureStart(env *vm.EVM, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	typ := vm.CALL
+	if create {
+		typ = vm.CREATE
+	}
+	t.enter(typ, from, to, input, value)
+}
+
+func (t *CallTracer) CaptureEnd(output []byte, usedGas uint64, err error) {
+	t.exit(output, err)
+}
+
+func (t *CallTracer) CaptureEnter(typ vm.OpCode, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.enter(typ, from, to, input, value)
+}
+
+func (t *CallTracer) CaptureExit(output []byte, usedGas uint64, err error) {
+	t.exit(output, err)
+}
+
+func (t *CallTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *CallTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *CallTracer) enter(typ vm.OpCode, from, to libcommon.Address, input []byte, value *uint256.Int) {
+	c := Call{Type: typ, Depth: len(t.stack), From: from, To: to, Input: libcommon.Copy(input)}
+	if value != nil {
+		c.Value = value.Clone()
+	}
+	t.stack = append(t.stack, len(t.calls))
+	t.calls = append(t.calls, c)
+}
+
+func (t *CallTracer) exit(output []byte, err error) {
+	if len(t.stack) == 0 {
+		return
+	}
+	c := &t.calls[t.stack[len(t.stack)-1]]
+	t.stack = t.stack[:len(t.stack)-1]
+	c.Output = libcommon.Copy(output)
+	c.Failed = err != nil
+}
+
+// StateDiffWriter collects the state written by IntraBlockState.MakeWriteSet into TxResult.StateDiff.
+type StateDiffWriter struct {
+	diff StateDiff
+}
+
+var _ state.StateWriter = (*StateDiffWriter)(nil)
+
+func NewStateDiffWriter() *StateDiffWriter {
+	return &StateDiffWriter{diff: StateDiff{Accounts: map[libcommon.Address]*AccountDiff{}}}
+}
+
+func (w *StateDiffWriter) Diff() *StateDiff { return &w.diff }
+
+func (w *StateDiffWriter) account(address libcommon.Address, original *accounts.Account) *AccountDiff {
+	d, ok := w.diff.Accounts[address]
+	if !ok {
+		d = &AccountDiff{}
+		w.diff.Accounts[address] = d
+	}
+	if d.Original == nil && original != nil && original.Initialised {
+		d.Original = new(accounts.Account)
+		d.Original.Copy(original)
+	}
+	return d
+}
+
+func (w *StateDiffWriter) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	d := w.account(address, original)
+	d.Account = new(accounts.Account)
+	d.Account.Copy(account)
+	return nil
+}
+
+func (w *StateDiffWriter) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	w.account(address, nil).Code = libcommon.Copy(code)
+	return nil
+}
+
+func (w *StateDiffWriter) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	w.account(address, original).Account = nil
+	return nil
+}
+
+func (w *StateDiffWriter) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	if *original == *value {
+		return nil
+	}
+	d := w.account(address, nil)
+	if d.Storage == nil {
+		d.Storage = map[libcommon.Hash]StorageDiff{}
+	}
+	d.Storage[*key] = StorageDiff{Original: *original, Value: *value}
+	return nil
+}
+
+func (w *StateDiffWriter) CreateContract(address libcommon.Address) error {
+	w.account(address, nil)
+	return nil
+}
@@ -0,0 +1,342 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package indexer
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/stream"
+)
+
+// dbKey is [1byte len of name][name][key], see kv.TblCustomIndexKeys. The name must pass CheckName.
+func dbKey(name string, key []byte) []byte {
+	k := make([]byte, 1+len(name)+len(key))
+	k[0] = byte(len(name))
+	copy(k[1:], name)
+	copy(k[1+len(name):], key)
+	return k
+}
+
+func checkKey(key []byte) error {
+	if len(key) == 0 || len(key) > MaxKeyLen {
+		return fmt.Errorf("key length must be 1-%d, got %d", MaxKeyLen, len(key))
+	}
+	return nil
+}
+
+// Batch buffers writes of indexers in memory. Indexers run in the reducer goroutine of exec3.CustomTraceMapReduce,
+// which doesn't own the RwTx of the stage, so the batch is flushed by the stage afterward.
+type Batch struct {
+	entries []batchEntry
+	size    int
+}
+
+type batchEntry struct {
+	txNum uint64
+	key   []byte // dbKey
+	value []byte // nil deletes the key
+	put   bool   // domain write, otherwise inverted index
+}
+
+func (b *Batch) Len() int  { return len(b.entries) }
+func (b *Batch) Size() int { return b.size }
+
+// Writer returns the writer of the indexer for the txn. Writes of the txn must go through the single writer.
+func (b *Batch) Writer(name string, txNum uint64) Writer {
+	return &txWriter{b: b, name: name, txNum: txNum}
+}
+
+type txWriter struct {
+	b     *Batch
+	name  string
+	txNum uint64
+	seen  map[string]int // dbKey+kind -> index in b.entries
+}
+
+func (w *txWriter) add(key, value []byte, put bool) error {
+	if err := checkKey(key); err != nil {
+		return fmt.Errorf("indexer %s: %w", w.name, err)
+	}
+	if len(value) > MaxValueLen {
+		return fmt.Errorf("indexer %s: value length must not exceed %d, got %d", w.name, MaxValueLen, len(value))
+	}
+	k := dbKey(w.name, key)
+	seenKey := string(k)
+	if put {
+		seenKey += "p"
+	}
+	if w.seen == nil {
+		w.seen = map[string]int{}
+	}
+	// single entry per key and txn: unwind restores the value from before the txn
+	if i, ok := w.seen[seenKey]; ok {
+		e := &w.b.entries[i]
+		w.b.size += len(value) - len(e.value)
+		e.value = libcommon.Copy(value)
+		return nil
+	}
+	w.seen[seenKey] = len(w.b.entries)
+	w.b.entries = append(w.b.entries, batchEntry{txNum: w.txNum, key: k, value: libcommon.Copy(value), put: put})
+	w.b.size += 8 + len(k) + len(value)
+	return nil
+}
+
+func (w *txWriter) IndexKey(key []byte) error   { return w.add(key, nil, false) }
+func (w *txWriter) Put(key, value []byte) error { return w.add(key, value, true) }
+
+// Flush writes the batch in the order of txns and resets it.
+func (b *Batch) Flush(tx kv.RwTx) error {
+	for _, e := range b.entries {
+		txNum := hexutility.EncodeTs(e.txNum)
+		if !e.put {
+			if err := tx.Put(kv.TblCustomIndexKeys, txNum, e.key); err != nil {
+				return err
+			}
+			if err := tx.Put(kv.TblCustomIndexIdx, e.key, txNum); err != nil {
+				return err
+			}
+			continue
+		}
+
+		prev, err := tx.GetOne(kv.TblCustomDomainVals, e.key)
+		if err != nil {
+			return err
+		}
+		if err := tx.Put(kv.TblCustomDomainHistory, txNum, encodeHistory(e.key, prev)); err != nil {
+			return err
+		}
+		if e.value == nil {
+			err = tx.Delete(kv.TblCustomDomainVals, e.key)
+		} else {
+			err = tx.Put(kv.TblCustomDomainVals, e.key, e.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	b.entries, b.size = b.entries[:0], 0
+	return nil
+}
+
+// encodeHistory returns [2bytes len of key][key][1byte 1 if prev exists][prev]
+func encodeHistory(key, prev []byte) []byte {
+	v := make([]byte, 2+len(key)+1+len(prev))
+	binary.BigEndian.PutUint16(v, uint16(len(key)))
+	copy(v[2:], key)
+	if prev != nil {
+		v[2+len(key)] = 1
+		copy(v[3+len(key):], prev)
+	}
+	return v
+}
+
+func decodeHistory(v []byte) (key, prev []byte, err error) {
+	if len(v) < 3 {
+		return nil, nil, fmt.Errorf("invalid %s value: %x", kv.TblCustomDomainHistory, v)
+	}
+	keyLen := int(binary.BigEndian.Uint16(v))
+	if len(v) < 3+keyLen {
+		return nil, nil, fmt.Errorf("invalid %s value: %x", kv.TblCustomDomainHistory, v)
+	}
+	key = v[2 : 2+keyLen]
+	if v[2+keyLen] == 1 {
+		prev = v[3+keyLen:]
+	}
+	return key, prev, nil
+}
+
+// Unwind deletes index entries of txNum >= fromTxNum and restores domain values from before fromTxNum.
+func Unwind(tx kv.RwTx, fromTxNum uint64) error {
+	from := hexutility.EncodeTs(fromTxNum)
+
+	if err := deleteIndex(tx, from, nil); err != nil {
+		return err
+	}
+
+	type restore struct{ key, prev []byte }
+	var restores []restore
+	var txNums [][]byte
+	c, err := tx.CursorDupSort(kv.TblCustomDomainHistory)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	// newest first, so the oldest value is restored last
+	for k, v, err := c.Last(); k != nil; k, v, err = c.Prev() {
+		if err != nil {
+			return err
+		}
+		if string(k) < string(from) {
+			break
+		}
+		key, prev, err := decodeHistory(v)
+		if err != nil {
+			return err
+		}
+		restores = append(restores, restore{key: libcommon.Copy(key), prev: libcommon.Copy(prev)})
+		if len(txNums) == 0 || string(txNums[len(txNums)-1]) != string(k) {
+			txNums = append(txNums, libcommon.Copy(k))
+		}
+	}
+	c.Close()
+	for _, r := range restores {
+		if r.prev == nil {
+			err = tx.Delete(kv.TblCustomDomainVals, r.key)
+		} else {
+			err = tx.Put(kv.TblCustomDomainVals, r.key, r.prev)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, k := range txNums {
+		if err := tx.Delete(kv.TblCustomDomainHistory, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prune deletes index entries and domain history of txNum < toTxNum. Domain values are kept.
+func Prune(tx kv.RwTx, toTxNum uint64) error {
+	to := hexutility.EncodeTs(toTxNum)
+	if err := deleteIndex(tx, nil, to); err != nil {
+		return err
+	}
+	return deleteRange(tx, kv.TblCustomDomainHistory, nil, to)
+}
+
+// deleteIndex deletes inverted index entries of txNums in [from, to), nil means unbounded.
+func deleteIndex(tx kv.RwTx, from, to []byte) error {
+	keys, err := tx.CursorDupSort(kv.TblCustomIndexKeys)
+	if err != nil {
+		return err
+	}
+	defer keys.Close()
+	idx, err := tx.RwCursorDupSort(kv.TblCustomIndexIdx)
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+	for k, v, err := keys.Seek(from); k != nil; k, v, err = keys.Next() {
+		if err != nil {
+			return err
+		}
+		if to != nil && string(k) >= string(to) {
+			break
+		}
+		if err := idx.DeleteExact(v, k); err != nil {
+			return err
+		}
+	}
+	keys.Close()
+	return deleteRange(tx, kv.TblCustomIndexKeys, from, to)
+}
+
+func deleteRange(tx kv.RwTx, table string, from, to []byte) error {
+	c, err := tx.CursorDupSort(table)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, _, err := c.Seek(from); k != nil; k, _, err = c.NextNoDup() {
+		if err != nil {
+			return err
+		}
+		if to != nil && string(k) >= string(to) {
+			break
+		}
+		if err := tx.Delete(table, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Progress returns the last block processed by the indexer, ok is false if the indexer didn't process any block.
+func Progress(tx kv.Getter, name string) (blockNum uint64, ok bool, err error) {
+	v, err := tx.GetOne(kv.TblCustomIndexProgress, []byte(name))
+	if err != nil {
+		return 0, false, err
+	}
+	if len(v) < 8 {
+		return 0, false, nil
+	}
+	return binary.BigEndian.Uint64(v), true, nil
+}
+
+func SaveProgress(tx kv.Putter, name string, blockNum uint64) error {
+	return tx.Put(kv.TblCustomIndexProgress, []byte(name), hexutility.EncodeTs(blockNum))
+}
+
+// Progresses returns the progress of all indexers found in the db, including ones which aren't registered.
+func Progresses(tx kv.Tx) (map[string]uint64, error) {
+	res := map[string]uint64{}
+	if err := tx.ForEach(kv.TblCustomIndexProgress, nil, func(k, v []byte) error {
+		if len(v) >= 8 {
+			res[string(k)] = binary.BigEndian.Uint64(v)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// IndexRange returns txNums of the key in the inverted index of the indexer, in [fromTxNum, toTxNum) for
+// order.Asc and in [toTxNum, fromTxNum) backwards for order.Desc. -1 means unbounded, limit -1 means no limit.
+func IndexRange(tx kv.Tx, name string, key []byte, fromTxNum, toTxNum int, asc order.By, limit int) (stream.U64, error) {
+	if err := CheckName(name); err != nil {
+		return nil, err
+	}
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	var from, to []byte
+	if fromTxNum >= 0 {
+		from = hexutility.EncodeTs(uint64(fromTxNum))
+	}
+	if toTxNum >= 0 {
+		to = hexutility.EncodeTs(uint64(toTxNum))
+	}
+	it, err := tx.RangeDupSort(kv.TblCustomIndexIdx, dbKey(name, key), from, to, asc, limit)
+	if err != nil {
+		return nil, err
+	}
+	return stream.TransformKV2U64(it, func(_, v []byte) (uint64, error) {
+		if len(v) != 8 {
+			return 0, fmt.Errorf("invalid %s value: %x", kv.TblCustomIndexIdx, v)
+		}
+		return binary.BigEndian.Uint64(v), nil
+	}), nil
+}
+
+// GetValue returns the latest value of the key in the domain of the indexer, nil if there is no value.
+func GetValue(tx kv.Getter, name string, key []byte) ([]byte, error) {
+	if err := CheckName(name); err != nil {
+		return nil, err
+	}
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	return tx.GetOne(kv.TblCustomDomainVals, dbKey(name, key))
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package indexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/stream"
+)
+
+func indexRange(t *testing.T, tx kv.Tx, name, key string, asc order.By, limit int) []uint64 {
+	t.Helper()
+	it, err := IndexRange(tx, name, []byte(key), -1, -1, asc, limit)
+	require.NoError(t, err)
+	txNums, err := stream.ToArrayU64(it)
+	require.NoError(t, err)
+	return txNums
+}
+
+func getValue(t *testing.T, tx kv.Tx, name, key string) []byte {
+	t.Helper()
+	v, err := GetValue(tx, name, []byte(key))
+	require.NoError(t, err)
+	return v
+}
+
+func TestBatchUnwindPrune(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	var b Batch
+
+	w := b.Writer("ix", 10)
+	require.NoError(t, w.IndexKey([]byte("a")))
+	require.NoError(t, w.Put([]byte("k"), []byte("1")))
+	// "ixa" of another indexer must not be mixed with "a" of "ix"
+	require.NoError(t, b.Writer("ixa", 10).IndexKey([]byte("b")))
+
+	w = b.Writer("ix", 11)
+	require.NoError(t, w.IndexKey([]byte("a")))
+	require.NoError(t, w.IndexKey([]byte("b")))
+	require.NoError(t, w.Put([]byte("k"), []byte("2")))
+	require.NoError(t, w.Put([]byte("k"), []byte("3")))
+
+	w = b.Writer("ix", 12)
+	require.NoError(t, w.Put([]byte("k"), nil))
+	require.NoError(t, w.Put([]byte("other"), []byte("x")))
+
+	require.Error(t, w.IndexKey(nil))
+	require.Error(t, w.IndexKey([]byte(strings.Repeat("k", MaxKeyLen+1))))
+	require.Error(t, w.Put([]byte("k"), []byte(strings.Repeat("v", MaxValueLen+1))))
+
+	require.Equal(t, 8, b.Len())
+	require.NoError(t, b.Flush(tx))
+	require.Zero(t, b.Len())
+
+	require.Equal(t, []uint64{10, 11}, indexRange(t, tx, "ix", "a", order.Asc, -1))
+	require.Equal(t, []uint64{11}, indexRange(t, tx, "ix", "a", order.Desc, 1))
+	require.Equal(t, []uint64{11}, indexRange(t, tx, "ix", "b", order.Asc, -1))
+	require.Equal(t, []uint64{10}, indexRange(t, tx, "ixa", "b", order.Asc, -1))
+	require.Nil(t, getValue(t, tx, "ix", "k"))
+	require.Equal(t, []byte("x"), getValue(t, tx, "ix", "other"))
+	// length of a longer name would wrap around into the key space of another indexer
+	for _, name := range []string{"", "IX", strings.Repeat("i", 257) + "x", "ix/a"} {
+		_, err := IndexRange(tx, name, []byte("a"), -1, -1, order.Asc, -1)
+		require.Error(t, err, name)
+		_, err = GetValue(tx, name, []byte("k"))
+		require.Error(t, err, name)
+	}
+
+	require.NoError(t, Unwind(tx, 12))
+	require.Equal(t, []byte("3"), getValue(t, tx, "ix", "k"))
+	require.Nil(t, getValue(t, tx, "ix", "other"))
+
+	require.NoError(t, Unwind(tx, 11))
+	require.Equal(t, []byte("1"), getValue(t, tx, "ix", "k"))
+	require.Equal(t, []uint64{10}, indexRange(t, tx, "ix", "a", order.Asc, -1))
+	require.Empty(t, indexRange(t, tx, "ix", "b", order.Asc, -1))
+	require.Equal(t, []uint64{10}, indexRange(t, tx, "ixa", "b", order.Asc, -1))
+
+	require.NoError(t, Prune(tx, 11))
+	require.Empty(t, indexRange(t, tx, "ix", "a", order.Asc, -1))
+	require.Empty(t, indexRange(t, tx, "ixa", "b", order.Asc, -1))
+	require.Equal(t, []byte("1"), getValue(t, tx, "ix", "k"))
+	for _, table := range []string{kv.TblCustomIndexKeys, kv.TblCustomIndexIdx, kv.TblCustomDomainHistory} {
+		cnt, err := tx.Count(table)
+		require.NoError(t, err)
+		require.Zero(t, cnt, table)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	_, ok, err := Progress(tx, "ix")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// block 0 done is not the same as never ran
+	require.NoError(t, SaveProgress(tx, "ix", 0))
+	p, ok, err := Progress(tx, "ix")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Zero(t, p)
+
+	require.NoError(t, SaveProgress(tx, "ix", 5))
+	require.NoError(t, SaveProgress(tx, "other", 7))
+	p, ok, err = Progress(tx, "ix")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(5), p)
+	all, err := Progresses(tx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]uint64{"ix": 5, "other": 7}, all)
+}
@@ -0,0 +1,180 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+// Package indexer lets users build their own indices of the chain history. Indexers are compiled into
+// the binary and registered from init() of their package, the CustomTrace stage re-executes history and
+// feeds results of every txn to all registered indexers. Each indexer has its own progress, its data is
+// unwound together with the chain and its inverted index is pruned together with the history.
+//
+// An indexer writes two kinds of data, both kept under its name:
+//   - inverted index: key -> txNums of txns which called Writer.IndexKey(key)
+//   - domain: key -> value, the latest value set by Writer.Put
+//
+// Written data is read by IndexRange and GetValue, e.g. by rpc namespaces registered with RegisterAPI.
+package indexer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+const (
+	MaxKeyLen   = 256
+	MaxValueLen = 1024
+)
+
+var nameRe = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// CheckName returns an error if the name can't be the name of an indexer. Names prefix keys of all indexers in
+// shared tables, so anything else could read or write keys of another indexer.
+func CheckName(name string) error {
+	if !nameRe.MatchString(name) {
+		return fmt.Errorf("invalid indexer name %q, must be 1-64 characters of a-z, 0-9, '_' or '-'", name)
+	}
+	return nil
+}
+
+// Indexer builds its index from the results of txns. Index is called for every txn of the chain, in the order of
+// txns, starting from the block after the progress of the indexer.
+type Indexer interface {
+	// Name identifies the indexer in the db and in logs. It must not change, otherwise the index is rebuilt
+	// from scratch under the new name.
+	Name() string
+	// Options tells which expensive results the indexer needs.
+	Options() Options
+	Index(res *TxResult, w Writer) error
+}
+
+type Options struct {
+	Calls     bool // collect TxResult.Calls
+	StateDiff bool // collect TxResult.StateDiff
+}
+
+// Writer buffers writes of an indexer for the txn passed to Index.
+type Writer interface {
+	// IndexKey adds the txn to the inverted index of the key.
+	IndexKey(key []byte) error
+	// Put sets the value of the key, nil value deletes the key. The previous value is kept until the txn
+	// is pruned, so the write can be unwound.
+	Put(key, value []byte) error
+}
+
+type TxResult struct {
+	BlockNum  uint64
+	BlockHash libcommon.Hash
+	TxNum     uint64
+	TxIndex   int
+	Tx        types.Transaction
+	Sender    libcommon.Address
+	Failed    bool
+	UsedGas   uint64
+	Logs      types.Logs
+	Calls     []Call     // nil unless Options.Calls
+	StateDiff *StateDiff // nil unless Options.StateDiff
+}
+
+// Call is a call frame of the txn, the top level call included. Calls are in the order they were entered.
+type Call struct {
+	Type   vm.OpCode // CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE, CREATE2 or SELFDESTRUCT
+	Depth  int       // 0 for the top level call
+	From   libcommon.Address
+	To     libcommon.Address
+	Value  *uint256.Int
+	Input  []byte
+	Output []byte
+	Failed bool
+}
+
+// StateDiff is the state changed by the txn.
+type StateDiff struct {
+	Accounts map[libcommon.Address]*AccountDiff
+}
+
+type AccountDiff struct {
+	Original *accounts.Account // nil if the account didn't exist
+	Account  *accounts.Account // nil if the account was deleted
+	Code     []byte            // set if the code was deployed by the txn
+	Storage  map[libcommon.Hash]StorageDiff
+}
+
+type StorageDiff struct {
+	Original, Value uint256.Int
+}
+
+var (
+	registryLock sync.RWMutex
+	indexers     = map[string]Indexer{}
+	apis         = map[string]NewAPI{}
+)
+
+// Register adds the indexer to the CustomTrace stage. It's meant to be called from init() and panics if the name
+// is invalid or already registered.
+func Register(ix Indexer) {
+	name := ix.Name()
+	if err := CheckName(name); err != nil {
+		panic("indexer: " + err.Error())
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := indexers[name]; ok {
+		panic(fmt.Sprintf("indexer: %q is already registered", name))
+	}
+	indexers[name] = ix
+}
+
+// Registered returns registered indexers sorted by name.
+func Registered() []Indexer {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	res := make([]Indexer, 0, len(indexers))
+	for _, ix := range indexers {
+		res = append(res, ix)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name() < res[j].Name() })
+	return res
+}
+
+// NewAPI creates the service of an rpc namespace, see rpc.API.Service.
+type NewAPI func(db kv.RoDB, blockReader services.FullBlockReader) any
+
+// RegisterAPI adds the rpc namespace to rpcdaemon, it's served when the namespace is listed in --http.api.
+// It's meant to be called from init() and panics if the namespace is already registered.
+func RegisterAPI(namespace string, newAPI NewAPI) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := apis[namespace]; ok {
+		panic(fmt.Sprintf("indexer: rpc namespace %q is already registered", namespace))
+	}
+	apis[namespace] = newAPI
+}
+
+// API returns the constructor of the registered rpc namespace, or nil.
+func API(namespace string) NewAPI {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return apis[namespace]
+}
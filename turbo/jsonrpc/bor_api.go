@@ -17,12 +17,16 @@
 package jsonrpc
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/erigontech/erigon-lib/kv"
 
 	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/filters"
 	"github.com/erigontech/erigon/polygon/bor"
 	"github.com/erigontech/erigon/polygon/bor/valset"
 	"github.com/erigontech/erigon/rpc"
@@ -41,6 +45,11 @@ type BorAPI interface {
 	GetSnapshotProposer(blockNrOrHash *rpc.BlockNumberOrHash) (common.Address, error)
 	GetSnapshotProposerSequence(blockNrOrHash *rpc.BlockNumberOrHash) (BlockSigners, error)
 	GetRootHash(start uint64, end uint64) (string, error)
+
+	// State sync events of polygon bridge (see ./bor_state_sync.go)
+	GetStateSyncEvents(ctx context.Context, fromBlock, toBlock rpc.BlockNumber) ([]*StateSyncEvent, error)
+	GetStateSyncEventById(ctx context.Context, id hexutil.Uint64) (*StateSyncEvent, error)
+	GetStateSyncLogs(ctx context.Context, crit filters.FilterCriteria) (types.Logs, error)
 }
 
 // BorImpl is implementation of the BorAPI interface
@@ -0,0 +1,384 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/filters"
+	"github.com/erigontech/erigon/polygon/bor"
+	bortypes "github.com/erigontech/erigon/polygon/bor/types"
+	"github.com/erigontech/erigon/polygon/bridge"
+	"github.com/erigontech/erigon/polygon/heimdall"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+)
+
+const (
+	// maxStateSyncEvents - limit of events returned by one range request
+	maxStateSyncEvents = 10_000
+	// maxStateSyncBlocks - limit of blocks of one range request, bridge data is looked up block by block
+	maxStateSyncBlocks = 100_000
+)
+
+var errBridgeNotAvailable = errors.New("polygon bridge data is not available: node runs without polygon sync")
+
+// StateSyncEvent - heimdall state sync event and the block which processed it (nil until processed)
+type StateSyncEvent struct {
+	ID              hexutil.Uint64   `json:"id"`
+	Contract        common.Address   `json:"contract"`
+	Data            hexutility.Bytes `json:"data"`
+	TxHash          common.Hash      `json:"txHash"` // L1 transaction
+	LogIndex        hexutil.Uint64   `json:"logIndex"`
+	ChainID         string           `json:"chainId"`
+	Time            hexutil.Uint64   `json:"time"`
+	BlockNumber     *hexutil.Uint64  `json:"blockNumber"`
+	BlockHash       *common.Hash     `json:"blockHash"`
+	StateSyncTxHash *common.Hash     `json:"stateSyncTxHash"` // bor state sync transaction of the block
+}
+
+func newStateSyncEvent(event *heimdall.EventRecordWithTime) *StateSyncEvent {
+	return &StateSyncEvent{
+		ID:       hexutil.Uint64(event.ID),
+		Contract: event.Contract,
+		Data:     event.Data,
+		TxHash:   event.TxHash,
+		LogIndex: hexutil.Uint64(event.LogIndex),
+		ChainID:  event.ChainID,
+		Time:     hexutil.Uint64(event.Time.Unix()),
+	}
+}
+
+func (e *StateSyncEvent) setBlock(blockNum uint64, blockHash common.Hash) {
+	num := hexutil.Uint64(blockNum)
+	stateSyncTxHash := bortypes.ComputeBorTxHash(blockNum, blockHash)
+	e.BlockNumber, e.BlockHash, e.StateSyncTxHash = &num, &blockHash, &stateSyncTxHash
+}
+
+// GetStateSyncEvents returns state sync events processed by blocks in [fromBlock, toBlock]
+func (api *BorImpl) GetStateSyncEvents(ctx context.Context, fromBlock, toBlock rpc.BlockNumber) ([]*StateSyncEvent, error) {
+	reader, err := api.bridgeReader()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	from, to, err := api.blockRange(tx, &fromBlock, &toBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges, err := reader.EventIDRanges(ctx, from, to, api.blockTime(tx))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*StateSyncEvent
+	for _, r := range ranges {
+		if len(result)+int(r.End-r.Start) > maxStateSyncEvents {
+			return nil, fmt.Errorf("too many state sync events in [%d, %d], limit is %d", from, to, maxStateSyncEvents)
+		}
+
+		blockHash, err := api._blockReader.CanonicalHash(ctx, tx, r.BlockNum)
+		if err != nil {
+			return nil, err
+		}
+
+		events, err := reader.Events(ctx, r.Start+1, r.End)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			e := newStateSyncEvent(event)
+			e.setBlock(r.BlockNum, blockHash)
+			result = append(result, e)
+		}
+	}
+
+	return result, nil
+}
+
+// GetStateSyncEventById returns the state sync event and the block which processed it, nil if the bridge doesn't have it
+func (api *BorImpl) GetStateSyncEventById(ctx context.Context, id hexutil.Uint64) (*StateSyncEvent, error) {
+	reader, err := api.bridgeReader()
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := reader.EventByID(ctx, uint64(id))
+	if err != nil {
+		if errors.Is(err, heimdall.ErrEventRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := newStateSyncEvent(event)
+
+	blockNum, ok, err := reader.EventBlockNum(ctx, uint64(id), api.blockTime(tx))
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		blockHash, err := api._blockReader.CanonicalHash(ctx, tx, blockNum)
+		if err != nil {
+			return nil, err
+		}
+
+		result.setBlock(blockNum, blockHash)
+	}
+
+	return result, nil
+}
+
+// GetStateSyncLogs is eth_getLogs view of the bor state sync transactions: logs of the state receiver (StateCommitted)
+// and of the receiver contracts, made by re-executing commitState calls of the events on top of the state of the block.
+func (api *BorImpl) GetStateSyncLogs(ctx context.Context, crit filters.FilterCriteria) (types.Logs, error) {
+	reader, err := api.bridgeReader()
+	if err != nil {
+		return nil, err
+	}
+
+	borEngine, err := api.bor()
+	if err != nil {
+		return nil, err
+	}
+	stateReceiver := common.HexToAddress(borEngine.Config().StateReceiverContract)
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to uint64
+	if crit.BlockHash != nil {
+		header, err := api._blockReader.HeaderByHash(ctx, tx, *crit.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("block not found: %x", *crit.BlockHash)
+		}
+
+		from, to = header.Number.Uint64(), header.Number.Uint64()
+	} else {
+		var fromBlock, toBlock *rpc.BlockNumber
+		if crit.FromBlock != nil {
+			n := rpc.BlockNumber(crit.FromBlock.Int64())
+			fromBlock = &n
+		}
+		if crit.ToBlock != nil {
+			n := rpc.BlockNumber(crit.ToBlock.Int64())
+			toBlock = &n
+		}
+
+		if from, to, err = api.blockRange(tx, fromBlock, toBlock); err != nil {
+			return nil, err
+		}
+	}
+
+	ranges, err := reader.EventIDRanges(ctx, from, to, api.blockTime(tx))
+	if err != nil {
+		return nil, err
+	}
+
+	addrMap := make(map[common.Address]struct{}, len(crit.Addresses))
+	for _, v := range crit.Addresses {
+		addrMap[v] = struct{}{}
+	}
+
+	logs := types.Logs{}
+	var eventsCount int
+	for _, r := range ranges {
+		eventsCount += int(r.End - r.Start)
+		if eventsCount > maxStateSyncEvents {
+			return nil, fmt.Errorf("too many state sync events in [%d, %d], limit is %d", from, to, maxStateSyncEvents)
+		}
+
+		events, err := reader.Events(ctx, r.Start+1, r.End)
+		if err != nil {
+			return nil, err
+		}
+
+		blockLogs, err := api.stateSyncLogs(ctx, tx, chainConfig, stateReceiver, r.BlockNum, events)
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, blockLogs.Filter(addrMap, crit.Topics, 0)...)
+	}
+
+	return logs, nil
+}
+
+// stateSyncLogs executes commitState calls of the events like bor.CommitStates does at the end of the block,
+// and returns the logs of the state sync transaction of the block.
+func (api *BorImpl) stateSyncLogs(ctx context.Context, tx kv.Tx, chainConfig *chain.Config, stateReceiver common.Address, blockNum uint64, events []*heimdall.EventRecordWithTime) (types.Logs, error) {
+	if err := api.checkPruneHistory(tx, blockNum); err != nil {
+		return nil, err
+	}
+
+	blockHash, err := api._blockReader.CanonicalHash(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := api.blockWithSenders(ctx, tx, blockHash, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found %d", blockNum)
+	}
+
+	// log indexes of the state sync transaction continue after the logs of the block transactions
+	receipts, err := api.getReceipts(ctx, tx, block, block.Body().SendersFromTxs())
+	if err != nil {
+		return nil, err
+	}
+	var firstLogIndex uint
+	for _, receipt := range receipts {
+		firstLogIndex += uint(len(receipt.Logs))
+	}
+
+	// state sync transaction goes after all transactions of the block
+	txIndex := len(block.Transactions())
+	stateReader, err := rpchelper.CreateHistoryStateReader(tx, blockNum, txIndex, chainConfig.ChainName)
+	if err != nil {
+		return nil, err
+	}
+	ibs := state.New(stateReader)
+	stateSyncTxHash := bortypes.ComputeBorTxHash(blockNum, blockHash)
+	ibs.SetTxContext(stateSyncTxHash, blockHash, txIndex)
+
+	stateReceiverABI := bor.GenesisContractStateReceiverABI()
+	for _, event := range events {
+		data, err := event.Pack(stateReceiverABI)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := core.SysCallContract(stateReceiver, data, chainConfig, ibs, block.HeaderNoCopy(), api.engine(), false /* constCall */); err != nil {
+			return nil, err
+		}
+	}
+
+	logs := types.Logs(ibs.GetLogs(stateSyncTxHash))
+	for _, log := range logs {
+		log.BlockNumber = blockNum
+		log.Index += firstLogIndex
+	}
+
+	return logs, nil
+}
+
+func (api *BorImpl) bridgeReader() (*bridge.Reader, error) {
+	borEngine, err := api.bor()
+	if err != nil {
+		return nil, err
+	}
+
+	reader := borEngine.BridgeReader()
+	if reader == nil {
+		return nil, errBridgeNotAvailable
+	}
+
+	return reader, nil
+}
+
+// blockRange resolves block numbers of range request, nil means latest
+func (api *BorImpl) blockRange(tx kv.Tx, fromBlock, toBlock *rpc.BlockNumber) (uint64, uint64, error) {
+	latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), tx, api.filters)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	resolve := func(n *rpc.BlockNumber) (uint64, error) {
+		if n == nil {
+			return latest, nil
+		}
+		if *n >= 0 {
+			return uint64(*n), nil
+		}
+
+		blockNum, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(*n), tx, api.filters)
+		return blockNum, err
+	}
+
+	from, err := resolve(fromBlock)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	to, err := resolve(toBlock)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if to < from {
+		return 0, 0, fmt.Errorf("end (%d) < begin (%d)", to, from)
+	}
+	if to-from >= maxStateSyncBlocks {
+		return 0, 0, fmt.Errorf("block range [%d, %d] is too wide, limit is %d blocks", from, to, maxStateSyncBlocks)
+	}
+
+	return from, to, nil
+}
+
+func (api *BorImpl) blockTime(tx kv.Tx) bridge.BlockTimeFunc {
+	return func(ctx context.Context, blockNum uint64) (uint64, error) {
+		header, err := api._blockReader.HeaderByNumber(ctx, tx, blockNum)
+		if err != nil {
+			return 0, err
+		}
+		if header == nil {
+			return 0, fmt.Errorf("header not found: %d", blockNum)
+		}
+
+		return header.Time, nil
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/erigontech/erigon/consensus/clique"
 	"github.com/erigontech/erigon/polygon/bor"
 	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/indexer"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/services"
 )
@@ -66,7 +67,8 @@ func APIList(db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, m
 	}
 
 	otsImpl := NewOtterscanAPI(base, db, cfg.OtsMaxPageSize)
-	gqlImpl := NewGraphQLAPI(base, db)
+	gqlImpl := NewGraphQLAPI(base, db, ethImpl)
+	indexerImpl := NewIndexerAPI(base, db)
 	overlayImpl := NewOverlayAPI(base, db, cfg.Gascap, cfg.OverlayGetLogsTimeout, cfg.OverlayReplayBlockTimeout, otsImpl)
 
 	if cfg.GraphQLEnabled {
@@ -175,6 +177,23 @@ func APIList(db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, m
 				Service:   OverlayAPI(overlayImpl),
 				Version:   "1.0",
 			})
+		case "indexer":
+			list = append(list, rpc.API{
+				Namespace: "indexer",
+				Public:    true,
+				Service:   IndexerAPI(indexerImpl),
+				Version:   "1.0",
+			})
+		default:
+			// namespaces of plugins, see indexer.RegisterAPI
+			if newAPI := indexer.API(enabledAPI); newAPI != nil {
+				list = append(list, rpc.API{
+					Namespace: enabledAPI,
+					Public:    true,
+					Service:   newAPI(db, blockReader),
+					Version:   "1.0",
+				})
+			}
 		}
 	}
 
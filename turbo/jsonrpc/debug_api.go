@@ -17,6 +17,7 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -62,6 +63,9 @@ type PrivateDebugAPI interface {
 	AccountAt(ctx context.Context, blockHash common.Hash, txIndex uint64, account common.Address) (*AccountResult, error)
 	GetRawHeader(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error)
 	GetRawBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error)
+	GetRawReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]hexutility.Bytes, error)
+	GetRawTransaction(ctx context.Context, hash common.Hash) (hexutility.Bytes, error)
+	GetBadBlocks(ctx context.Context) ([]*BadBlockResult, error)
 }
 
 // PrivateDebugAPIImpl is implementation of the PrivateDebugAPI interface based on remote Db access
@@ -371,6 +375,10 @@ func (api *PrivateDebugAPIImpl) GetRawBlock(ctx context.Context, blockNrOrHash r
 	defer tx.Rollback()
 	n, h, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
 	if err != nil {
+		// rejected blocks are not in the chain, but still can be requested by hash
+		if badBlock, badErr := api.badBlockByHash(tx, blockNrOrHash); badErr != nil || badBlock != nil {
+			return badBlock, badErr
+		}
 		return nil, err
 	}
 	block, err := api.blockWithSenders(ctx, tx, h, n)
@@ -378,7 +386,132 @@ func (api *PrivateDebugAPIImpl) GetRawBlock(ctx context.Context, blockNrOrHash r
 		return nil, err
 	}
 	if block == nil {
+		if badBlock, badErr := api.badBlockByHash(tx, blockNrOrHash); badErr != nil || badBlock != nil {
+			return badBlock, badErr
+		}
 		return nil, errors.New("block not found")
 	}
 	return rlp.EncodeToBytes(block)
 }
+
+// badBlockByHash returns RLP of the block from the bad blocks table, nil if it's not there or requested by number
+func (api *PrivateDebugAPIImpl) badBlockByHash(tx kv.Tx, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error) {
+	hash, ok := blockNrOrHash.Hash()
+	if !ok {
+		return nil, nil
+	}
+	badBlock, err := rawdb.ReadBadBlock(tx, hash)
+	if err != nil || badBlock == nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(badBlock.Block)
+}
+
+// GetRawReceipts implements debug_getRawReceipts. Returns the consensus encoding of the block receipts.
+func (api *PrivateDebugAPIImpl) GetRawReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]hexutility.Bytes, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	n, h, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.blockWithSenders(ctx, tx, h, n)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	receipts, err := api.getReceipts(ctx, tx, block, block.Body().SendersFromTxs())
+	if err != nil {
+		return nil, fmt.Errorf("getReceipts error: %w", err)
+	}
+
+	result := make([]hexutility.Bytes, len(receipts))
+	for i := range receipts {
+		var buf bytes.Buffer
+		receipts.EncodeIndex(i, &buf)
+		result[i] = buf.Bytes()
+	}
+	return result, nil
+}
+
+// GetRawTransaction implements debug_getRawTransaction. Returns the consensus encoding of the transaction.
+func (api *PrivateDebugAPIImpl) GetRawTransaction(ctx context.Context, hash common.Hash) (hexutility.Bytes, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blockNum, ok, err := api.txnLookup(ctx, tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	for _, txn := range block.Transactions() {
+		if txn.Hash() == hash {
+			var buf bytes.Buffer
+			err = txn.MarshalBinary(&buf)
+			return buf.Bytes(), err
+		}
+	}
+	return nil, nil
+}
+
+// BadBlockResult - block rejected by validation, see rawdb.BadBlock
+type BadBlockResult struct {
+	Hash         common.Hash            `json:"hash"`
+	Block        map[string]interface{} `json:"block"`
+	RLP          hexutility.Bytes       `json:"rlp"`
+	Error        string                 `json:"error"`
+	PreStateRoot common.Hash            `json:"preStateRoot"`
+	Time         hexutil.Uint64         `json:"time"`
+}
+
+// GetBadBlocks implements debug_getBadBlocks. Returns the recently rejected blocks, highest first.
+func (api *PrivateDebugAPIImpl) GetBadBlocks(ctx context.Context) ([]*BadBlockResult, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	badBlocks, err := rawdb.ReadBadBlocks(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*BadBlockResult, 0, len(badBlocks))
+	for _, badBlock := range badBlocks {
+		blockRLP, err := rlp.EncodeToBytes(badBlock.Block)
+		if err != nil {
+			return nil, err
+		}
+		blockJSON, err := ethapi.RPCMarshalBlock(badBlock.Block, true, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, &BadBlockResult{
+			Hash:         badBlock.Block.Hash(),
+			Block:        blockJSON,
+			RLP:          blockRLP,
+			Error:        badBlock.Err,
+			PreStateRoot: badBlock.PreStateRoot,
+			Time:         hexutil.Uint64(badBlock.Time),
+		})
+	}
+	return results, nil
+}
@@ -19,6 +19,7 @@ package jsonrpc
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -29,12 +30,14 @@ import (
 	"github.com/erigontech/erigon-lib/log/v3"
 
 	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/kvcache"
 	"github.com/erigontech/erigon-lib/kv/order"
 	"github.com/erigontech/erigon-lib/kv/rawdbv3"
 	"github.com/erigontech/erigon-lib/kv/stream"
 	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
 	tracersConfig "github.com/erigontech/erigon/eth/tracers/config"
 	"github.com/erigontech/erigon/rpc"
@@ -521,3 +524,65 @@ func TestAccountAt(t *testing.T) {
 		require.Equal(0, int(results.Nonce))
 	})
 }
+
+type rawList []hexutility.Bytes
+
+func (l rawList) Len() int                           { return len(l) }
+func (l rawList) EncodeIndex(i int, w *bytes.Buffer) { w.Write(l[i]) }
+
+func TestGetRawReceiptsAndTransaction(t *testing.T) {
+	m, chain, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+
+	txHash := common.HexToHash(debugTraceTransactionTests[1].txHash)
+	raw, err := api.GetRawTransaction(m.Ctx, txHash)
+	require.NoError(t, err)
+	txn, err := types.DecodeTransaction(raw)
+	require.NoError(t, err)
+	require.Equal(t, txHash, txn.Hash())
+
+	raw, err = api.GetRawTransaction(m.Ctx, common.Hash{})
+	require.NoError(t, err)
+	require.Nil(t, raw)
+
+	for _, block := range chain.Blocks {
+		receipts, err := api.GetRawReceipts(m.Ctx, rpc.BlockNumberOrHashWithHash(block.Hash(), true))
+		require.NoError(t, err)
+		require.Len(t, receipts, len(block.Transactions()))
+		require.Equal(t, block.ReceiptHash(), types.DeriveSha(rawList(receipts)))
+	}
+}
+
+func TestGetBadBlocks(t *testing.T) {
+	m, chain, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+
+	badBlocks, err := api.GetBadBlocks(m.Ctx)
+	require.NoError(t, err)
+	require.Empty(t, badBlocks)
+
+	parent := chain.Blocks[2]
+	header := types.CopyHeader(chain.Blocks[3].HeaderNoCopy())
+	header.Root = common.HexToHash("0xbad")
+	block := types.NewBlockWithHeader(header)
+	require.NoError(t, m.DB.Update(m.Ctx, func(tx kv.RwTx) error {
+		return rawdb.WriteBadBlock(tx, block, errors.New("invalid state root hash"), parent.Root())
+	}))
+
+	badBlocks, err = api.GetBadBlocks(m.Ctx)
+	require.NoError(t, err)
+	require.Len(t, badBlocks, 1)
+	require.Equal(t, block.Hash(), badBlocks[0].Hash)
+	require.Equal(t, "invalid state root hash", badBlocks[0].Error)
+	require.Equal(t, parent.Root(), badBlocks[0].PreStateRoot)
+	require.Equal(t, block.Hash(), badBlocks[0].Block["hash"])
+
+	raw, err := api.GetRawBlock(m.Ctx, rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+	require.NoError(t, err)
+	require.Equal(t, badBlocks[0].RLP, raw)
+
+	// canonical block is still served by number
+	raw, err = api.GetRawBlock(m.Ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(block.NumberU64())))
+	require.NoError(t, err)
+	require.NotEqual(t, badBlocks[0].RLP, raw)
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"github.com/RoaringBitmap/roaring"
+	jsoniter "github.com/json-iterator/go"
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/kv"
@@ -65,21 +66,21 @@ func (api *ErigonImpl) GetLogsByHash(ctx context.Context, hash common.Hash) ([][
 	return logs, nil
 }
 
-// GetLogs implements erigon_getLogs. Returns an array of logs matching a given filter object.
-func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.ErigonLogs, error) {
+// GetLogs implements erigon_getLogs. Streams an array of logs matching a given filter object.
+func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria, stream *jsoniter.Stream) error {
 	var begin, end uint64
-	erigonLogs := types.ErigonLogs{}
 
 	tx, beginErr := api.db.BeginRo(ctx)
 	if beginErr != nil {
-		return erigonLogs, beginErr
+		return beginErr
 	}
 	defer tx.Rollback()
 
 	if crit.BlockHash != nil {
 		header, err := api._blockReader.HeaderByHash(ctx, tx, *crit.BlockHash)
 		if header == nil {
-			return nil, err
+			stream.WriteNil()
+			return err
 		}
 		begin = header.Number.Uint64()
 		end = header.Number.Uint64()
@@ -88,7 +89,7 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 		// Convert the RPC block numbers into internal representations
 		latest, err := rpchelper.GetLatestBlockNumber(tx)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		begin = 0
@@ -96,7 +97,7 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 			if crit.FromBlock.Sign() >= 0 {
 				begin = crit.FromBlock.Uint64()
 			} else if !crit.FromBlock.IsInt64() || crit.FromBlock.Int64() != int64(rpc.LatestBlockNumber) {
-				return nil, fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
+				return fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
 			}
 		}
 		end = latest
@@ -104,18 +105,27 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 			if crit.ToBlock.Sign() >= 0 {
 				end = crit.ToBlock.Uint64()
 			} else if !crit.ToBlock.IsInt64() || crit.ToBlock.Int64() != int64(rpc.LatestBlockNumber) {
-				return nil, fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
+				return fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
 			}
 		}
 	}
 	if end < begin {
-		return nil, fmt.Errorf("end (%d) < begin (%d)", end, begin)
+		return fmt.Errorf("end (%d) < begin (%d)", end, begin)
 	}
 	if end > roaring.MaxUint32 {
-		return nil, fmt.Errorf("end (%d) > MaxUint32", end)
+		return fmt.Errorf("end (%d) > MaxUint32", end)
 	}
 
-	return api.getLogsV3(ctx, tx.(kv.TemporalTx), begin, end, crit)
+	as := rpc.NewArrayStream(ctx, stream)
+	err := api.walkLogsV3(ctx, tx.(kv.TemporalTx), begin, end, crit, func(log *types.ErigonLog) error {
+		el, err := as.Next()
+		if err != nil {
+			return err
+		}
+		el.WriteVal(log)
+		return el.Error
+	})
+	return as.Close(err)
 }
 
 // GetLatestLogs implements erigon_getLatestLogs.
@@ -17,12 +17,14 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"math/big"
 	"testing"
 
 	"github.com/holiman/uint256"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -73,12 +75,50 @@ func TestGetLogs(t *testing.T) {
 	}
 }
 
+// erigonGetLogs calls streaming erigon_getLogs and decodes its result
+func erigonGetLogs(t *testing.T, ctx context.Context, api *ErigonImpl, crit filters.FilterCriteria) types.ErigonLogs {
+	t.Helper()
+	var buf bytes.Buffer
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+	require.NoError(t, api.GetLogs(ctx, crit, stream))
+	var logs types.ErigonLogs
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logs))
+	return logs
+}
+
+func TestErigonGetLogs(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	ethApi := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+
+	crit := filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(10)}
+	expected, err := ethApi.GetLogs(m.Ctx, crit)
+	require.NoError(t, err)
+	logs := erigonGetLogs(t, m.Ctx, api, crit)
+	require.Len(t, logs, len(expected))
+	for i := range expected {
+		require.Equal(t, expected[i].TxHash, logs[i].TxHash)
+		require.Equal(t, expected[i].BlockNumber, logs[i].BlockNumber)
+		require.Equal(t, expected[i].Data, logs[i].Data)
+	}
+
+	// no match is an empty array, not null
+	var buf bytes.Buffer
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+	require.NoError(t, api.GetLogs(m.Ctx, filters.FilterCriteria{
+		FromBlock: big.NewInt(10),
+		ToBlock:   big.NewInt(10),
+		Addresses: common.Addresses{libcommon.Address{}},
+	}, stream))
+	require.Equal(t, "[]", buf.String())
+}
+
 func TestErigonGetLatestLogs(t *testing.T) {
 	assert := assert.New(t)
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	db := m.DB
 	api := NewErigonAPI(newBaseApiForTest(m), db, nil)
-	expectedLogs, _ := api.GetLogs(m.Ctx, filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())})
+	expectedLogs := erigonGetLogs(t, m.Ctx, api, filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())})
 
 	expectedErigonLogs := make(types.ErigonLogs, 0)
 	for i := len(expectedLogs) - 1; i >= 0; i-- {
@@ -124,7 +164,7 @@ func TestErigonGetLatestLogsIgnoreTopics(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	db := m.DB
 	api := NewErigonAPI(newBaseApiForTest(m), db, nil)
-	expectedLogs, _ := api.GetLogs(m.Ctx, filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())})
+	expectedLogs := erigonGetLogs(t, m.Ctx, api, filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())})
 
 	expectedErigonLogs := make([]*types.ErigonLog, 0)
 	for i := len(expectedLogs) - 1; i >= 0; i-- {
@@ -116,6 +116,9 @@ type EthAPI interface {
 	GetProof(ctx context.Context, address common.Address, storageKeys []common.Hash, blockNr rpc.BlockNumberOrHash) (*accounts.AccProofResult, error)
 	CreateAccessList(ctx context.Context, args ethapi2.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash, optimizeGas *bool) (*accessListResult, error)
 
+	// Simulation related (see ./eth_simulation.go)
+	SimulateV1(ctx context.Context, opts SimulationOptions, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error)
+
 	// Mining related (see ./eth_mining.go)
 	Coinbase(ctx context.Context) (common.Address, error)
 	Hashrate(ctx context.Context) (uint64, error)
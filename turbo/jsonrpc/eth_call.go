@@ -31,7 +31,9 @@ import (
 	"github.com/erigontech/erigon-lib/gointerfaces"
 	txpool_proto "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
 	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
 	"github.com/erigontech/erigon-lib/log/v3"
+	libstate "github.com/erigontech/erigon-lib/state"
 	types2 "github.com/erigontech/erigon-lib/types"
 
 	"github.com/erigontech/erigon/core"
@@ -47,12 +49,31 @@ import (
 	ethapi2 "github.com/erigontech/erigon/turbo/adapter/ethapi"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/transactions"
+	"github.com/erigontech/erigon/turbo/trie"
 )
 
 var latestNumOrHash = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
 
 // Call implements eth_call. Executes a new message call immediately without creating a transaction on the block chain.
 func (api *APIImpl) Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi2.StateOverrides) (hexutility.Bytes, error) {
+	result, err := api.doCall(ctx, args, blockNrOrHash, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	// If the result contains a revert reason, try to unpack and return it.
+	if len(result.Revert()) > 0 {
+		return nil, ethapi2.NewRevertError(result)
+	}
+
+	return result.Return(), result.Err
+}
+
+// doCall executes the call on top of the state of the block, it returns nil result if the block is not found.
+func (api *APIImpl) doCall(ctx context.Context, args ethapi2.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi2.StateOverrides) (*evmtypes.ExecutionResult, error) {
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -94,13 +115,7 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHa
 	if len(result.ReturnData) > api.ReturnDataLimit {
 		return nil, fmt.Errorf("call returned result on length %d exceeding --rpc.returndata.limit %d", len(result.ReturnData), api.ReturnDataLimit)
 	}
-
-	// If the result contains a revert reason, try to unpack and return it.
-	if len(result.Revert()) > 0 {
-		return nil, ethapi2.NewRevertError(result)
-	}
-
-	return result.Return(), result.Err
+	return result, nil
 }
 
 // headerByNumberOrHash - intent to read recent headers only, tries from the lru cache before reading from the db
@@ -320,100 +335,114 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 	return hexutil.Uint64(hi), nil
 }
 
-// maxGetProofRewindBlockCount limits the number of blocks into the past that
-// GetProof will allow computing proofs.  Because we must rewind the hash state
-// and re-compute the state trie, the further back in time the request, the more
-// computationally intensive the operation becomes.  The staged sync code
-// assumes that if more than 100_000 blocks are skipped, that the entire trie
-// should be re-computed. Re-computing the entire trie will currently take ~15
-// minutes on mainnet.  The current limit has been chosen arbitrarily as
-// 'useful' without likely being overly computationally intense.
-
-// GetProof is partially implemented; no Storage proofs, and proofs must be for
-// blocks within maxGetProofRewindBlockCount blocks of the head.
-func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, storageKeys []libcommon.Hash, blockNrOrHash rpc.BlockNumberOrHash) (*accounts.AccProofResult, error) {
-	return nil, errors.New("not supported by Erigon3")
-	/*
-		tx, err := api.db.BeginRo(ctx)
-		if err != nil {
-			return nil, err
-		}
-		defer tx.Rollback()
+// getProofMaxBranchReads limits the amount of distinct commitment branches a single eth_getProof call may read
+// from commitment history. Every proof node is a branch, so it roughly caps the size of the response
+// and the amount of db/files lookups spent on one request.
+const getProofMaxBranchReads = 4096
 
-		blockNr, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
-		if err != nil {
-			return nil, err
-		}
+// GetProof implements eth_getProof. Trie of the requested block is restored from commitment domain history,
+// so proofs are available for blocks within api.MaxGetProofRewindBlockCount blocks of the head which are not pruned
+// from commitment history (see --prune.include-commitment-history).
+func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, storageKeys []libcommon.Hash, blockNrOrHash rpc.BlockNumberOrHash) (*accounts.AccProofResult, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-		header, err := api._blockReader.HeaderByNumber(ctx, tx, blockNr)
-		if err != nil {
-			return nil, err
-		}
+	blockNr, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
 
-		latestBlock, err := rpchelper.GetLatestBlockNumber(tx)
-		if err != nil {
-			return nil, err
-		}
+	header, err := api._blockReader.HeaderByNumber(ctx, tx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %d not found", blockNr)
+	}
 
-		if latestBlock < blockNr {
-			// shouldn't happen, but check anyway
-			return nil, fmt.Errorf("block number is in the future latest=%d requested=%d", latestBlock, blockNr)
-		}
+	latestBlock, err := rpchelper.GetLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
 
-		rl := trie.NewRetainList(0)
-		var loader *trie.FlatDBTrieLoader
-		if blockNr < latestBlock {
-			if latestBlock-blockNr > uint64(api.MaxGetProofRewindBlockCount) {
-				return nil, fmt.Errorf("requested block is too old, block must be within %d blocks of the head block number (currently %d)", uint64(api.MaxGetProofRewindBlockCount), latestBlock)
-			}
-			batch := membatchwithdb.NewMemoryBatch(tx, api.dirs.Tmp, api.logger)
-			defer batch.Rollback()
+	if latestBlock < blockNr {
+		// shouldn't happen, but check anyway
+		return nil, fmt.Errorf("block number is in the future latest=%d requested=%d", latestBlock, blockNr)
+	}
+	if latestBlock-blockNr > uint64(api.MaxGetProofRewindBlockCount) {
+		return nil, fmt.Errorf("requested block is too old, block must be within %d blocks of the head block number (currently %d)", uint64(api.MaxGetProofRewindBlockCount), latestBlock)
+	}
 
-			unwindState := &stagedsync.UnwindState{UnwindPoint: blockNr}
-			stageState := &stagedsync.StageState{BlockNumber: latestBlock}
+	// commitment of the block is saved at its last txNum, so state of the block is the one seen by the next txNum
+	txNum, err := rawdbv3.TxNums.Min(tx, blockNr+1)
+	if err != nil {
+		return nil, err
+	}
 
-			hashStageCfg := stagedsync.StageHashStateCfg(nil, api.dirs, api.historyV3(batch))
-			if err := stagedsync.UnwindHashStateStage(unwindState, stageState, batch, hashStageCfg, ctx, api.logger); err != nil {
-				return nil, err
-			}
+	storageLocs := make([][]byte, len(storageKeys))
+	for i := range storageKeys {
+		storageLocs[i] = storageKeys[i][:]
+	}
+	hasAggTx, ok := tx.(libstate.HasAggTx)
+	if !ok {
+		return nil, fmt.Errorf("eth_getProof: unexpected tx type %T", tx)
+	}
+	aggTx, ok := hasAggTx.AggTx().(*libstate.AggregatorRoTx)
+	if !ok {
+		return nil, fmt.Errorf("eth_getProof: unexpected aggregator tx type %T", hasAggTx.AggTx())
+	}
+	proof, root, err := aggTx.CommitmentProof(tx, blockNr, txNum, address[:], storageLocs, getProofMaxBranchReads)
+	if err != nil {
+		return nil, err
+	}
+	if libcommon.BytesToHash(root) != header.Root {
+		return nil, fmt.Errorf("mismatch in expected state root computed %x vs %v indicates bug in proof implementation", root, header.Root)
+	}
 
-			interHashStageCfg := stagedsync.StageTrieCfg(nil, false, false, false, api.dirs.Tmp, api._blockReader, nil, api.historyV3(batch), api._agg)
-			loader, err = stagedsync.UnwindIntermediateHashesForTrieLoader("eth_getProof", rl, unwindState, stageState, batch, interHashStageCfg, nil, nil, ctx.Done(), api.logger)
-			if err != nil {
-				return nil, err
-			}
-			tx = batch
-		} else {
-			loader = trie.NewFlatDBTrieLoader("eth_getProof", rl, nil, nil, false)
-		}
+	reader := state.NewHistoryReaderV3()
+	reader.SetTx(tx)
+	reader.SetTxNum(txNum)
+	a, err := reader.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		// absent account is reported with empty code and storage hashes, as an empty account
+		a = &accounts.Account{CodeHash: trie.EmptyCodeHash}
+		proof.StorageHash = trie.EmptyRoot
+	}
 
-		reader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, 0, api.filters, api.stateCache, "")
-		if err != nil {
-			return nil, err
-		}
-		a, err := reader.ReadAccountData(address)
+	result := &accounts.AccProofResult{
+		Address:      address,
+		AccountProof: make([]hexutility.Bytes, len(proof.AccountProof)),
+		Balance:      (*hexutil.Big)(a.Balance.ToBig()),
+		CodeHash:     a.CodeHash,
+		Nonce:        hexutil.Uint64(a.Nonce),
+		StorageHash:  proof.StorageHash,
+		StorageProof: make([]accounts.StorProofResult, len(storageKeys)),
+	}
+	for i, node := range proof.AccountProof {
+		result.AccountProof[i] = node
+	}
+	for i := range storageKeys {
+		v, err := reader.ReadAccountStorage(address, a.Incarnation, &storageKeys[i])
 		if err != nil {
 			return nil, err
 		}
-		if a == nil {
-			a = &accounts.Account{}
+		sp := accounts.StorProofResult{
+			Key:   storageKeys[i],
+			Value: (*hexutil.Big)(new(big.Int).SetBytes(v)),
+			Proof: make([]hexutility.Bytes, len(proof.StorageProof[i])),
 		}
-		pr, err := trie.NewProofRetainer(address, a, storageKeys, rl)
-		if err != nil {
-			return nil, err
+		for j, node := range proof.StorageProof[i] {
+			sp.Proof[j] = node
 		}
-
-		loader.SetProofRetainer(pr)
-		root, err := loader.CalcTrieRoot(tx, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		if root != header.Root {
-			return nil, fmt.Errorf("mismatch in expected state root computed %v vs %v indicates bug in proof implementation", root, header.Root)
-		}
-		return pr.ProofResult()
-	*/
+		result.StorageProof[i] = sp
+	}
+	return result, nil
 }
 
 func (api *APIImpl) tryBlockFromLru(hash libcommon.Hash) *types.Block {
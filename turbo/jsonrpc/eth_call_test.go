@@ -110,9 +110,6 @@ func TestGetProof(t *testing.T) {
 	var maxGetProofRewindBlockCount = 1 // Note, this is unsafe for parallel tests, but, this test is the only consumer for now
 
 	m, bankAddr, contractAddr := chainWithDeployedContract(t)
-	if m.HistoryV3 {
-		t.Skip("not supported by Erigon3")
-	}
 	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, maxGetProofRewindBlockCount, 128, log.New())
 
 	key := func(b byte) libcommon.Hash {
@@ -127,6 +124,7 @@ func TestGetProof(t *testing.T) {
 		addr        libcommon.Address
 		storageKeys []libcommon.Hash
 		stateVal    uint64
+		absent      bool
 		expectedErr string
 	}{
 		{
@@ -143,6 +141,7 @@ func TestGetProof(t *testing.T) {
 			name:     "currentBlockNoAccount",
 			addr:     libcommon.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddeaddead0"),
 			blockNum: 3,
+			absent:   true,
 		},
 		{
 			name:        "currentBlockWithState",
@@ -171,6 +170,7 @@ func TestGetProof(t *testing.T) {
 			storageKeys: []libcommon.Hash{libcommon.HexToHash("0xdeaddeaddeaddeaddeaddeaddeaddeaddeaddeaddeaddeaddeaddeaddeaddead")},
 			blockNum:    3,
 			stateVal:    0,
+			absent:      true,
 		},
 		{
 			name:        "olderBlockWithState",
@@ -212,6 +212,10 @@ func TestGetProof(t *testing.T) {
 			require.Equal(t, tt.addr, proof.Address)
 			err = trie.VerifyAccountProof(header.Root, proof)
 			require.NoError(t, err)
+			if tt.absent {
+				require.Equal(t, trie.EmptyRoot, proof.StorageHash)
+				require.Equal(t, crypto.Keccak256Hash(nil), proof.CodeHash)
+			}
 
 			require.Equal(t, len(tt.storageKeys), len(proof.StorageProof))
 			for _, storageKey := range tt.storageKeys {
@@ -264,7 +264,17 @@ func applyFiltersV3(tx kv.TemporalTx, begin, end uint64, crit filters.FilterCrit
 
 func (api *BaseAPI) getLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end uint64, crit filters.FilterCriteria) ([]*types.ErigonLog, error) {
 	logs := []*types.ErigonLog{}
+	if err := api.walkLogsV3(ctx, tx, begin, end, crit, func(log *types.ErigonLog) error {
+		logs = append(logs, log)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
 
+// walkLogsV3 calls walker for every log matching crit in blocks [begin, end], stopping at the first error.
+func (api *BaseAPI) walkLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end uint64, crit filters.FilterCriteria, walker func(log *types.ErigonLog) error) error {
 	addrMap := make(map[common.Address]struct{}, len(crit.Addresses))
 	for _, v := range crit.Addresses {
 		addrMap[v] = struct{}{}
@@ -272,7 +282,7 @@ func (api *BaseAPI) getLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end
 
 	chainConfig, err := api.chainConfig(ctx, tx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	exec := exec3.NewTraceWorker(tx, chainConfig, api.engine(), api._blockReader, nil)
 
@@ -281,18 +291,18 @@ func (api *BaseAPI) getLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end
 
 	txNumbers, err := applyFiltersV3(tx, begin, end, crit)
 	if err != nil {
-		return logs, err
+		return err
 	}
 	it := rawdbv3.TxNums2BlockNums(tx, txNumbers, order.Asc)
 	defer it.Close()
 	var timestamp uint64
 	for it.HasNext() {
 		if err = ctx.Err(); err != nil {
-			return nil, err
+			return err
 		}
 		txNum, blockNum, txIndex, isFinalTxn, blockNumChanged, err := it.Next()
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if isFinalTxn {
 			continue
@@ -302,7 +312,7 @@ func (api *BaseAPI) getLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end
 
 		if blockNumChanged {
 			if header, err = api._blockReader.HeaderByNumber(ctx, tx, blockNum); err != nil {
-				return nil, err
+				return err
 			}
 			if header == nil {
 				log.Warn("[rpc] header is nil", "blockNum", blockNum)
@@ -316,7 +326,7 @@ func (api *BaseAPI) getLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end
 		//fmt.Printf("txNum=%d, blockNum=%d, txIndex=%d, maxTxNumInBlock=%d,mixTxNumInBlock=%d\n", txNum, blockNum, txIndex, maxTxNumInBlock, minTxNumInBlock)
 		txn, err := api._txnReader.TxnByIdxInBlock(ctx, tx, blockNum, txIndex)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if txn == nil {
 			continue
@@ -324,7 +334,7 @@ func (api *BaseAPI) getLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end
 
 		_, err = exec.ExecTxn(txNum, txIndex, txn)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		rawLogs := exec.GetLogs(txIndex, txn)
 		//TODO: logIndex within the block! no way to calc it now
@@ -341,7 +351,7 @@ func (api *BaseAPI) getLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end
 		}
 		//TODO: maybe Logs by default and enreach them with
 		for _, filteredLog := range filtered {
-			logs = append(logs, &types.ErigonLog{
+			if err := walker(&types.ErigonLog{
 				Address:     filteredLog.Address,
 				Topics:      filteredLog.Topics,
 				Data:        filteredLog.Data,
@@ -352,13 +362,15 @@ func (api *BaseAPI) getLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end
 				Index:       filteredLog.Index,
 				Removed:     filteredLog.Removed,
 				Timestamp:   timestamp,
-			})
+			}); err != nil {
+				return err
+			}
 		}
 	}
 
 	//stats := api._agg.GetAndResetStats()
 	//log.Info("Finished", "duration", time.Since(start), "history queries", stats.FilesQueries, "ef search duration", stats.EfSearchTime)
-	return logs, nil
+	return nil
 }
 
 // The Topic list restricts matches to particular event topics. Each event has a list
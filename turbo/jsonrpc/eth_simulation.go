@@ -0,0 +1,655 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/consensus/misc"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+)
+
+const (
+	// maxSimulateBlocks limits the number of blocks (including the empty blocks
+	// used to fill gaps between requested block numbers) a single
+	// eth_simulateV1 request may produce.
+	maxSimulateBlocks = 256
+	// simulateBlockTimeIncrement is the default distance in seconds between two
+	// consecutive simulated blocks when no timestamp override is given.
+	simulateBlockTimeIncrement = 12
+)
+
+// Error codes defined by the eth_simulateV1 specification.
+const (
+	simErrCodeNonceTooLow             = -38010
+	simErrCodeNonceTooHigh            = -38011
+	simErrCodeFeeCapTooLow            = -38012
+	simErrCodeIntrinsicGas            = -38013
+	simErrCodeInsufficientFunds       = -38014
+	simErrCodeBlockGasLimitReached    = -38015
+	simErrCodeBlockNumberInvalid      = -38020
+	simErrCodeBlockTimestampInvalid   = -38021
+	simErrCodeSenderIsNotEOA          = -38024
+	simErrCodeMaxInitCodeSizeExceeded = -38025
+	simErrCodeClientLimitExceeded     = -38026
+	simErrCodeInternalError           = -32603
+	simErrCodeInvalidParams           = -32602
+	simErrCodeReverted                = 3
+	simErrCodeVMError                 = -32015
+)
+
+// transferLogAddress is the pseudo-address emitting the ERC-20 style Transfer
+// logs that eth_simulateV1 synthesises for ETH value transfers.
+var transferLogAddress = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+
+// transferTopic is keccak256("Transfer(address,address,uint256)").
+var transferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// SimulationOptions are the parameters of eth_simulateV1.
+type SimulationOptions struct {
+	BlockStateCalls        []SimulatedBlock `json:"blockStateCalls"`
+	TraceTransfers         bool             `json:"traceTransfers"`
+	Validation             bool             `json:"validation"`
+	ReturnFullTransactions bool             `json:"returnFullTransactions"`
+}
+
+// SimulatedBlock describes one block of an eth_simulateV1 request: the header
+// and state overrides applied before the calls, and the calls themselves.
+type SimulatedBlock struct {
+	BlockOverrides *SimulatedBlockOverrides `json:"blockOverrides"`
+	StateOverrides *ethapi.StateOverrides   `json:"stateOverrides"`
+	Calls          []ethapi.CallArgs        `json:"calls"`
+}
+
+// SimulatedBlockOverrides overrides header fields of a simulated block. Fields
+// left empty are derived from the parent block.
+type SimulatedBlockOverrides struct {
+	Number        *hexutil.Uint64 `json:"number"`
+	Time          *hexutil.Uint64 `json:"time"`
+	GasLimit      *hexutil.Uint64 `json:"gasLimit"`
+	FeeRecipient  *common.Address `json:"feeRecipient"`
+	PrevRandao    *common.Hash    `json:"prevRandao"`
+	BaseFeePerGas *hexutil.Big    `json:"baseFeePerGas"`
+	BlobBaseFee   *hexutil.Big    `json:"blobBaseFee"`
+}
+
+// SimulatedCallResult is the outcome of a single call of a simulated block.
+type SimulatedCallResult struct {
+	ReturnValue hexutility.Bytes    `json:"returnData"`
+	Logs        []*types.Log        `json:"logs"`
+	GasUsed     hexutil.Uint64      `json:"gasUsed"`
+	Status      hexutil.Uint64      `json:"status"`
+	Error       *SimulatedCallError `json:"error,omitempty"`
+}
+
+// SimulatedCallError describes why a simulated call failed. Unlike request level
+// errors it doesn't abort the simulation.
+type SimulatedCallError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+// SimulateV1 implements eth_simulateV1. Executes a sequence of calls on top of
+// the given block, grouped into consecutive simulated blocks with optional per
+// block header and state overrides, and returns the resulting blocks together
+// with the result of every call.
+func (api *APIImpl) SimulateV1(ctx context.Context, opts SimulationOptions, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, &rpc.CustomError{Code: simErrCodeInvalidParams, Message: "empty input"}
+	}
+	if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, &rpc.CustomError{Code: simErrCodeClientLimitExceeded, Message: "too many blocks"}
+	}
+	bNrOrHash := latestNumOrHash
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(bNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := api._blockReader.Header(ctx, tx, hash, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("block %d(%x) not found", blockNumber, hash)
+	}
+
+	blocks, err := sanitizeSimulatedBlocks(parent, opts.BlockStateCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	stateReader, err := rpchelper.CreateStateReader(ctx, tx, bNrOrHash, 0, api.filters, api.stateCache, chainConfig.ChainName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(start time.Time) {
+		log.Trace("Executing EVM simulateV1 finished", "blocks", len(blocks), "runtime", time.Since(start))
+	}(time.Now())
+
+	// Setup context so it may be cancelled the call has completed
+	// or, in case of unmetered gas, setup a context with a timeout.
+	var cancel context.CancelFunc
+	if api.evmCallTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, api.evmCallTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	sim := &simulator{
+		api:         api,
+		tx:          tx,
+		chainConfig: chainConfig,
+		ibs:         state.New(stateReader),
+		opts:        &opts,
+		hashes:      make(map[uint64]common.Hash, len(blocks)),
+	}
+	if opts.TraceTransfers {
+		sim.tracer = &transferTracer{}
+	}
+
+	results := make([]map[string]interface{}, 0, len(blocks))
+	for _, b := range blocks {
+		block, fields, err := sim.processBlock(ctx, parent, b)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, fields)
+		parent = block.HeaderNoCopy()
+	}
+	return results, nil
+}
+
+// simulatedBlockSpec is a SimulatedBlock whose number and timestamp have been
+// resolved against its predecessors.
+type simulatedBlockSpec struct {
+	*SimulatedBlock
+	number uint64
+	time   uint64
+}
+
+// sanitizeSimulatedBlocks resolves block numbers and timestamps of the requested
+// blocks, checks they are strictly increasing and fills gaps between requested
+// block numbers with empty blocks.
+func sanitizeSimulatedBlocks(parent *types.Header, blocks []SimulatedBlock) ([]*simulatedBlockSpec, error) {
+	var (
+		res      = make([]*simulatedBlockSpec, 0, len(blocks))
+		prevNum  = parent.Number.Uint64()
+		prevTime = parent.Time
+	)
+	for i := range blocks {
+		b := &blocks[i]
+		overrides := b.BlockOverrides
+		if overrides == nil {
+			overrides = &SimulatedBlockOverrides{}
+		}
+
+		number := prevNum + 1
+		if overrides.Number != nil {
+			number = uint64(*overrides.Number)
+			if number <= prevNum {
+				return nil, &rpc.CustomError{Code: simErrCodeBlockNumberInvalid, Message: fmt.Sprintf("block numbers must be in order: %d <= %d", number, prevNum)}
+			}
+		}
+		if number-parent.Number.Uint64() > maxSimulateBlocks {
+			return nil, &rpc.CustomError{Code: simErrCodeClientLimitExceeded, Message: "too many blocks"}
+		}
+		// Fill the gap with empty blocks
+		for gapNum := prevNum + 1; gapNum < number; gapNum++ {
+			prevTime += simulateBlockTimeIncrement
+			res = append(res, &simulatedBlockSpec{SimulatedBlock: &SimulatedBlock{}, number: gapNum, time: prevTime})
+		}
+
+		timestamp := prevTime + simulateBlockTimeIncrement
+		if overrides.Time != nil {
+			timestamp = uint64(*overrides.Time)
+			if timestamp <= prevTime {
+				return nil, &rpc.CustomError{Code: simErrCodeBlockTimestampInvalid, Message: fmt.Sprintf("block timestamps must be in order: %d <= %d", timestamp, prevTime)}
+			}
+		}
+
+		res = append(res, &simulatedBlockSpec{SimulatedBlock: b, number: number, time: timestamp})
+		prevNum, prevTime = number, timestamp
+	}
+	return res, nil
+}
+
+// simulator holds the state shared by all the blocks of one eth_simulateV1 request.
+type simulator struct {
+	api         *APIImpl
+	tx          kv.Tx
+	chainConfig *chain.Config
+	ibs         *state.IntraBlockState
+	opts        *SimulationOptions
+	tracer      *transferTracer
+	// hashes of the already simulated blocks, served by BLOCKHASH
+	hashes map[uint64]common.Hash
+}
+
+func (s *simulator) getHash(ctx context.Context) func(n uint64) common.Hash {
+	return func(n uint64) common.Hash {
+		if hash, ok := s.hashes[n]; ok {
+			return hash
+		}
+		hash, err := s.api._blockReader.CanonicalHash(ctx, s.tx, n)
+		if err != nil {
+			log.Debug("Can't get block hash by number", "number", n, "only-canonical", true)
+		}
+		return hash
+	}
+}
+
+// makeHeader derives the header of a simulated block from its parent and the
+// block overrides.
+func (s *simulator) makeHeader(parent *types.Header, b *simulatedBlockSpec) *types.Header {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Coinbase:   parent.Coinbase,
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		Number:     new(big.Int).SetUint64(b.number),
+		GasLimit:   parent.GasLimit,
+		Time:       b.time,
+	}
+	if s.chainConfig.IsLondon(b.number) {
+		header.BaseFee = new(big.Int)
+		if s.opts.Validation {
+			header.BaseFee = misc.CalcBaseFee(s.chainConfig, parent)
+		}
+	}
+	if s.chainConfig.IsCancun(b.time) {
+		excessBlobGas := misc.CalcExcessBlobGas(s.chainConfig, parent)
+		header.ExcessBlobGas = &excessBlobGas
+		header.BlobGasUsed = new(uint64)
+		header.ParentBeaconBlockRoot = new(common.Hash)
+	}
+	if overrides := b.BlockOverrides; overrides != nil {
+		if overrides.GasLimit != nil {
+			header.GasLimit = uint64(*overrides.GasLimit)
+		}
+		if overrides.FeeRecipient != nil {
+			header.Coinbase = *overrides.FeeRecipient
+		}
+		if overrides.PrevRandao != nil {
+			header.MixDigest = *overrides.PrevRandao
+		}
+		if overrides.BaseFeePerGas != nil {
+			header.BaseFee = overrides.BaseFeePerGas.ToInt()
+		}
+	}
+	return header
+}
+
+// processBlock executes the calls of a single simulated block on top of the
+// shared state and returns the assembled block and its RPC representation.
+func (s *simulator) processBlock(ctx context.Context, parent *types.Header, b *simulatedBlockSpec) (*types.Block, map[string]interface{}, error) {
+	header := s.makeHeader(parent, b)
+	if b.StateOverrides != nil {
+		if err := b.StateOverrides.Override(s.ibs); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	blockCtx := core.NewEVMBlockContext(header, s.getHash(ctx), s.api.engine(), &header.Coinbase, s.chainConfig)
+	if b.BlockOverrides != nil && b.BlockOverrides.BlobBaseFee != nil {
+		blobBaseFee, overflow := uint256.FromBig(b.BlockOverrides.BlobBaseFee.ToInt())
+		if overflow {
+			return nil, nil, &rpc.CustomError{Code: simErrCodeInvalidParams, Message: "blobBaseFee higher than 2^256-1"}
+		}
+		blockCtx.BlobBaseFee = blobBaseFee
+	} else if !s.opts.Validation && blockCtx.BlobBaseFee != nil {
+		blockCtx.BlobBaseFee = new(uint256.Int)
+	}
+	rules := s.chainConfig.Rules(b.number, b.time)
+
+	vmConfig := vm.Config{NoBaseFee: !s.opts.Validation}
+	if s.tracer != nil {
+		vmConfig.Debug = true
+		vmConfig.Tracer = s.tracer
+	}
+	if rules.IsCancun {
+		syscall := func(contract common.Address, data []byte) ([]byte, error) {
+			return core.SysCallContract(contract, data, s.chainConfig, s.ibs, header, s.api.engine(), false /* constCall */)
+		}
+		misc.ApplyBeaconRootEip4788(header.ParentBeaconBlockRoot, syscall, nil)
+	}
+
+	var (
+		gp       = new(core.GasPool).AddGas(header.GasLimit).AddBlobGas(s.chainConfig.GetMaxBlobGasPerBlock())
+		signer   = types.MakeSigner(s.chainConfig, b.number, b.time)
+		gasUsed  uint64
+		txs      = make(types.Transactions, 0, len(b.Calls))
+		receipts = make(types.Receipts, 0, len(b.Calls))
+		calls    = make([]SimulatedCallResult, 0, len(b.Calls))
+	)
+	for i := range b.Calls {
+		args := b.Calls[i]
+		txn, msg, err := s.prepareCall(&args, header, blockCtx.BaseFee, gasUsed, signer)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.ibs.SetTxContext(txn.Hash(), common.Hash{}, i)
+		if s.tracer != nil {
+			s.tracer.reset()
+		}
+		evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), s.ibs, s.chainConfig, vmConfig)
+		// Wait for the context to be done and cancel the evm. Even if the
+		// EVM has finished, cancelling may be done (repeatedly)
+		go func() {
+			<-ctx.Done()
+			evm.Cancel()
+		}()
+
+		result, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */)
+		if err != nil {
+			return nil, nil, simulateTxError(i, err)
+		}
+		// If the timer caused an abort, return an appropriate error message
+		if evm.Cancelled() {
+			return nil, nil, fmt.Errorf("execution aborted (timeout = %v)", s.api.evmCallTimeout)
+		}
+		if err = s.ibs.FinalizeTx(rules, state.NewNoopWriter()); err != nil {
+			return nil, nil, err
+		}
+		gasUsed += result.UsedGas
+
+		logs := s.ibs.GetLogs(txn.Hash())
+		if s.tracer != nil {
+			logs = s.tracer.logs
+		}
+		receipt := &types.Receipt{
+			Type:              txn.Type(),
+			CumulativeGasUsed: gasUsed,
+			TxHash:            txn.Hash(),
+			GasUsed:           result.UsedGas,
+			Logs:              logs,
+			BlockNumber:       header.Number,
+			TransactionIndex:  uint(i),
+		}
+		if msg.To() == nil {
+			receipt.ContractAddress = crypto.CreateAddress(msg.From(), msg.Nonce())
+		}
+
+		callResult := SimulatedCallResult{
+			ReturnValue: result.Return(),
+			Logs:        logs,
+			GasUsed:     hexutil.Uint64(result.UsedGas),
+		}
+		if result.Failed() {
+			receipt.Status = types.ReceiptStatusFailed
+			callResult.Status = hexutil.Uint64(types.ReceiptStatusFailed)
+			if errors.Is(result.Err, vm.ErrExecutionReverted) {
+				revertErr := ethapi.NewRevertError(result)
+				callResult.Error = &SimulatedCallError{Code: simErrCodeReverted, Message: revertErr.Error(), Data: revertErr.ErrorData().(string)}
+			} else {
+				callResult.Error = &SimulatedCallError{Code: simErrCodeVMError, Message: result.Err.Error()}
+			}
+		} else {
+			receipt.Status = types.ReceiptStatusSuccessful
+			callResult.Status = hexutil.Uint64(types.ReceiptStatusSuccessful)
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		txs = append(txs, txn)
+		receipts = append(receipts, receipt)
+		calls = append(calls, callResult)
+	}
+
+	header.GasUsed = gasUsed
+	var withdrawals []*types.Withdrawal
+	if rules.IsShanghai {
+		withdrawals = []*types.Withdrawal{}
+	}
+	// The state root of a simulated block is left empty: computing it would
+	// require building the commitment for the overlaid state. This makes the
+	// block hashes differ from other clients, see cmd/rpcdaemon/README.md.
+	block := types.NewBlock(header, txs, nil, receipts, withdrawals, nil)
+	blockHash := block.Hash()
+	s.hashes[b.number] = blockHash
+
+	var logIndex uint
+	for _, receipt := range receipts {
+		receipt.BlockHash = blockHash
+		for _, l := range receipt.Logs {
+			l.BlockNumber = b.number
+			l.BlockHash = blockHash
+			l.TxHash = receipt.TxHash
+			l.TxIndex = receipt.TransactionIndex
+			l.Index = logIndex
+			logIndex++
+		}
+	}
+	for i := range calls {
+		if calls[i].Logs == nil {
+			calls[i].Logs = []*types.Log{}
+		}
+	}
+
+	fields, err := ethapi.RPCMarshalBlock(block, true, s.opts.ReturnFullTransactions, map[string]interface{}{"calls": calls})
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, fields, nil
+}
+
+// prepareCall fills in the defaults of a simulated call and converts it into
+// both the message to execute and the transaction to include into the block.
+func (s *simulator) prepareCall(args *ethapi.CallArgs, header *types.Header, baseFee *uint256.Int, gasUsed uint64, signer *types.Signer) (types.Transaction, types.Message, error) {
+	var from common.Address
+	if args.From != nil {
+		from = *args.From
+	}
+	if args.Nonce == nil {
+		nonce := hexutil.Uint64(s.ibs.GetNonce(from))
+		args.Nonce = &nonce
+	}
+	if args.Gas == nil {
+		remaining := hexutil.Uint64(0)
+		if header.GasLimit > gasUsed {
+			remaining = hexutil.Uint64(header.GasLimit - gasUsed)
+		}
+		args.Gas = &remaining
+	}
+	if gasUsed+uint64(*args.Gas) > header.GasLimit {
+		return nil, types.Message{}, &rpc.CustomError{Code: simErrCodeBlockGasLimitReached, Message: fmt.Sprintf("block gas limit reached: %d + %d > %d", gasUsed, uint64(*args.Gas), header.GasLimit)}
+	}
+	if header.BaseFee == nil {
+		baseFee = nil
+	}
+
+	msg, err := args.ToMessage(s.api.GasCap, baseFee)
+	if err != nil {
+		return nil, types.Message{}, &rpc.CustomError{Code: simErrCodeInvalidParams, Message: err.Error()}
+	}
+	msg = types.NewMessage(msg.From(), msg.To(), uint64(*args.Nonce), msg.Value(), msg.Gas(), msg.GasPrice(), msg.FeeCap(), msg.Tip(), msg.Data(), msg.AccessList(), s.opts.Validation /* checkNonce */, false /* isFree */, msg.MaxFeePerBlobGas())
+
+	commonTx := types.CommonTx{
+		Nonce: msg.Nonce(),
+		Gas:   msg.Gas(),
+		To:    msg.To(),
+		Value: msg.Value(),
+		Data:  msg.Data(),
+	}
+	var txn types.Transaction
+	if baseFee == nil || args.GasPrice != nil {
+		txn = &types.LegacyTx{CommonTx: commonTx, GasPrice: msg.GasPrice()}
+	} else {
+		txn = &types.DynamicFeeTransaction{
+			CommonTx:   commonTx,
+			ChainID:    signer.ChainID(),
+			Tip:        msg.Tip(),
+			FeeCap:     msg.FeeCap(),
+			AccessList: msg.AccessList(),
+		}
+	}
+	txn.SetSender(from)
+	return txn, msg, nil
+}
+
+// simulateTxError maps a transaction validation error onto the matching
+// eth_simulateV1 error code.
+func simulateTxError(idx int, err error) error {
+	var code int
+	switch {
+	case errors.Is(err, core.ErrNonceTooLow):
+		code = simErrCodeNonceTooLow
+	case errors.Is(err, core.ErrNonceTooHigh):
+		code = simErrCodeNonceTooHigh
+	case errors.Is(err, core.ErrFeeCapTooLow):
+		code = simErrCodeFeeCapTooLow
+	case errors.Is(err, core.ErrIntrinsicGas):
+		code = simErrCodeIntrinsicGas
+	case errors.Is(err, core.ErrInsufficientFunds):
+		code = simErrCodeInsufficientFunds
+	case errors.Is(err, core.ErrGasLimitReached):
+		code = simErrCodeBlockGasLimitReached
+	case errors.Is(err, core.ErrSenderNoEOA):
+		code = simErrCodeSenderIsNotEOA
+	case errors.Is(err, core.ErrMaxInitCodeSizeExceeded):
+		code = simErrCodeMaxInitCodeSizeExceeded
+	default:
+		code = simErrCodeInternalError
+	}
+	return &rpc.CustomError{Code: code, Message: fmt.Sprintf("call %d: %v", idx, err)}
+}
+
+// transferTracer collects the logs of a simulated transaction in execution
+// order, interleaved with ERC-20 style Transfer logs for every ETH value
+// transfer. Logs of reverted call frames are dropped.
+type transferTracer struct {
+	frames [][]*types.Log
+	logs   []*types.Log
+}
+
+func (t *transferTracer) reset() {
+	t.frames = t.frames[:0]
+	t.logs = nil
+}
+
+func (t *transferTracer) addLog(l *types.Log) {
+	last := len(t.frames) - 1
+	t.frames[last] = append(t.frames[last], l)
+}
+
+func (t *transferTracer) captureTransfer(from, to common.Address, value *uint256.Int) {
+	if value == nil || value.IsZero() {
+		return
+	}
+	data := value.Bytes32()
+	t.addLog(&types.Log{
+		Address: transferLogAddress,
+		Topics:  []common.Hash{transferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    data[:],
+	})
+}
+
+func (t *transferTracer) CaptureTxStart(gasLimit uint64) {}
+
+func (t *transferTracer) CaptureTxEnd(restGas uint64) {}
+
+func (t *transferTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.frames = append(t.frames[:0], nil)
+	t.captureTransfer(from, to, value)
+}
+
+func (t *transferTracer) CaptureEnd(output []byte, usedGas uint64, err error) {
+	if err == nil && len(t.frames) > 0 {
+		t.logs = t.frames[0]
+	}
+	t.frames = t.frames[:0]
+}
+
+func (t *transferTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.frames = append(t.frames, nil)
+	if typ != vm.DELEGATECALL && typ != vm.STATICCALL && typ != vm.CALLCODE {
+		t.captureTransfer(from, to, value)
+	}
+}
+
+func (t *transferTracer) CaptureExit(output []byte, usedGas uint64, err error) {
+	last := len(t.frames) - 1
+	if last < 1 {
+		return
+	}
+	frame := t.frames[last]
+	t.frames = t.frames[:last]
+	if err == nil {
+		t.frames[last-1] = append(t.frames[last-1], frame...)
+	}
+}
+
+func (t *transferTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || op < vm.LOG0 || op > vm.LOG4 || len(t.frames) == 0 {
+		return
+	}
+	size := int(op - vm.LOG0)
+	stack := scope.Stack
+	if stack.Len() < 2+size {
+		return
+	}
+	mStart, mSize := stack.Back(0), stack.Back(1)
+	topics := make([]common.Hash, size)
+	for i := 0; i < size; i++ {
+		topics[i] = stack.Back(2 + i).Bytes32()
+	}
+	// Memory is expanded after the tracer is invoked, so the part of the
+	// region beyond the current memory size is zero-filled.
+	data := make([]byte, mSize.Uint64())
+	if mem := scope.Memory.Data(); mStart.IsUint64() && mStart.Uint64() < uint64(len(mem)) {
+		copy(data, mem[mStart.Uint64():])
+	}
+	t.addLog(&types.Log{Address: scope.Contract.Address(), Topics: topics, Data: data})
+}
+
+func (t *transferTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+)
+
+func TestSimulateV1(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+
+	var (
+		sender    = libcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+		recipient = libcommon.HexToAddress("0x2000000000000000000000000000000000000002")
+		reverter  = libcommon.HexToAddress("0x3000000000000000000000000000000000000003")
+		coinbase  = libcommon.HexToAddress("0x4000000000000000000000000000000000000004")
+		balance   = (*hexutil.Big)(big.NewInt(1e18))
+		value     = (*hexutil.Big)(big.NewInt(1000))
+		// PUSH1 0 PUSH1 0 REVERT
+		revertCode = hexutility.Bytes{0x60, 0x00, 0x60, 0x00, 0xfd}
+		timestamp  = hexutil.Uint64(1 << 40)
+	)
+
+	res, err := api.SimulateV1(context.Background(), SimulationOptions{
+		TraceTransfers:         true,
+		ReturnFullTransactions: true,
+		BlockStateCalls: []SimulatedBlock{
+			{
+				StateOverrides: &ethapi.StateOverrides{
+					sender:   {Balance: &balance},
+					reverter: {Code: &revertCode},
+				},
+				Calls: []ethapi.CallArgs{
+					{From: &sender, To: &recipient, Value: value},
+					{From: &sender, To: &reverter},
+				},
+			},
+			{
+				BlockOverrides: &SimulatedBlockOverrides{Time: &timestamp, FeeRecipient: &coinbase},
+				Calls:          []ethapi.CallArgs{{From: &sender, To: &recipient, Value: value}},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	calls := res[0]["calls"].([]SimulatedCallResult)
+	require.Len(t, calls, 2)
+	require.Equal(t, hexutil.Uint64(1), calls[0].Status)
+	require.Len(t, calls[0].Logs, 1)
+	transfer := calls[0].Logs[0]
+	require.Equal(t, transferLogAddress, transfer.Address)
+	require.Equal(t, []libcommon.Hash{transferTopic, libcommon.BytesToHash(sender.Bytes()), libcommon.BytesToHash(recipient.Bytes())}, transfer.Topics)
+	require.Equal(t, uint64(1000), new(big.Int).SetBytes(transfer.Data).Uint64())
+	require.Equal(t, res[0]["hash"], transfer.BlockHash)
+
+	require.Equal(t, hexutil.Uint64(0), calls[1].Status)
+	require.NotNil(t, calls[1].Error)
+	require.Equal(t, simErrCodeReverted, calls[1].Error.Code)
+
+	require.Equal(t, res[0]["hash"], res[1]["parentHash"])
+	require.Equal(t, hexutil.Uint64(timestamp), res[1]["timestamp"])
+	require.Equal(t, coinbase, res[1]["miner"])
+	require.Equal(t, (*hexutil.Big)(new(big.Int).Add(res[0]["number"].(*hexutil.Big).ToInt(), big.NewInt(1))), res[1]["number"])
+	// the nonce of the sender is carried over between the simulated blocks
+	txs := res[1]["transactions"].([]interface{})
+	require.Len(t, txs, 1)
+	require.Equal(t, hexutil.Uint64(2), txs[0].(*ethapi.RPCTransaction).Nonce)
+	calls = res[1]["calls"].([]SimulatedCallResult)
+	require.Len(t, calls, 1)
+	require.Equal(t, hexutil.Uint64(1), calls[0].Status)
+}
+
+func TestSimulateV1Errors(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+
+	var (
+		sender    = libcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+		recipient = libcommon.HexToAddress("0x2000000000000000000000000000000000000002")
+		early     = hexutil.Uint64(1)
+		nonce     = hexutil.Uint64(5)
+		gasLimit  = hexutil.Uint64(50_000)
+		gas       = hexutil.Uint64(30_000)
+	)
+
+	requireCode := func(t *testing.T, err error, code int) {
+		t.Helper()
+		var rpcErr rpc.Error
+		require.True(t, errors.As(err, &rpcErr), "unexpected error %v", err)
+		require.Equal(t, code, rpcErr.ErrorCode())
+	}
+
+	_, err := api.SimulateV1(context.Background(), SimulationOptions{}, nil)
+	requireCode(t, err, simErrCodeInvalidParams)
+
+	_, err = api.SimulateV1(context.Background(), SimulationOptions{
+		BlockStateCalls: []SimulatedBlock{{BlockOverrides: &SimulatedBlockOverrides{Time: &early}}},
+	}, nil)
+	requireCode(t, err, simErrCodeBlockTimestampInvalid)
+
+	_, err = api.SimulateV1(context.Background(), SimulationOptions{
+		Validation:      true,
+		BlockStateCalls: []SimulatedBlock{{Calls: []ethapi.CallArgs{{From: &sender, To: &recipient, Nonce: &nonce}}}},
+	}, nil)
+	requireCode(t, err, simErrCodeNonceTooHigh)
+
+	_, err = api.SimulateV1(context.Background(), SimulationOptions{
+		BlockStateCalls: []SimulatedBlock{{
+			BlockOverrides: &SimulatedBlockOverrides{GasLimit: &gasLimit},
+			Calls:          []ethapi.CallArgs{{From: &sender, To: &recipient, Gas: &gas}, {From: &sender, To: &recipient, Gas: &gas}},
+		}},
+	}, nil)
+	requireCode(t, err, simErrCodeBlockGasLimitReached)
+	require.ErrorContains(t, err, "21000 + 30000 > 50000")
+}
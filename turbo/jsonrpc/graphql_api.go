@@ -17,17 +17,25 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"math/big"
 
-	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/holiman/uint256"
 
 	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
 	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/consensus/misc"
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
 	"github.com/erigontech/erigon/eth/ethutils"
+	"github.com/erigontech/erigon/eth/filters"
+	"github.com/erigontech/erigon/rlp"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/adapter/ethapi"
 	"github.com/erigontech/erigon/turbo/rpchelper"
@@ -35,18 +43,37 @@ import (
 
 type GraphQLAPI interface {
 	GetBlockDetails(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error)
+	GetBlockNumberByHash(ctx context.Context, hash common.Hash) (*uint64, error)
+	GetTransactionBlockNumber(ctx context.Context, hash common.Hash) (*uint64, error)
+	GetPendingTransactions(ctx context.Context) ([]map[string]interface{}, error)
 	GetChainID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (hexutil.Uint64, error)
+	GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error)
+	GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error)
+	GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error)
+	GetStorageAt(ctx context.Context, address common.Address, index string, blockNrOrHash rpc.BlockNumberOrHash) (string, error)
+	Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (*evmtypes.ExecutionResult, error)
+	EstimateGas(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Uint64, error)
+	GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.Logs, error)
+	GasPrice(ctx context.Context) (*hexutil.Big, error)
+	MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error)
+	Syncing(ctx context.Context) (interface{}, error)
+	SendRawTransaction(ctx context.Context, encodedTx hexutility.Bytes) (common.Hash, error)
 }
 
+// GraphQLAPIImpl serves GraphQL resolvers. Account state, calls, logs and the transaction pool are served
+// the same way as by eth namespace.
 type GraphQLAPIImpl struct {
 	*BaseAPI
-	db kv.RoDB
+	db  kv.RoDB
+	eth *APIImpl
 }
 
-func NewGraphQLAPI(base *BaseAPI, db kv.RoDB) *GraphQLAPIImpl {
+func NewGraphQLAPI(base *BaseAPI, db kv.RoDB, eth *APIImpl) *GraphQLAPIImpl {
 	return &GraphQLAPIImpl{
 		BaseAPI: base,
 		db:      db,
+		eth:     eth,
 	}
 }
 
@@ -96,20 +123,50 @@ func (api *GraphQLAPIImpl) GetBlockDetails(ctx context.Context, blockNumber rpc.
 	}
 
 	result := make([]map[string]interface{}, 0, len(receipts))
-	for _, receipt := range receipts {
+	for i, receipt := range receipts {
 		txn := block.Transactions()[receipt.TransactionIndex]
 
 		transaction := ethutils.MarshalReceipt(receipt, txn, chainConfig, block.HeaderNoCopy(), txn.Hash(), true)
-		transaction["nonce"] = txn.GetNonce()
-		transaction["value"] = txn.GetValue()
-		transaction["data"] = txn.GetData()
+		if err := marshalGraphQLTransaction(transaction, txn, block.BaseFee()); err != nil {
+			return nil, err
+		}
+		transaction["gasPrice"] = transaction["effectiveGasPrice"]
 		transaction["logs"] = receipt.Logs
+		var rawReceipt bytes.Buffer
+		receipts.EncodeIndex(i, &rawReceipt)
+		transaction["rawReceipt"] = rawReceipt.Bytes()
 		result = append(result, transaction)
 	}
 
+	rawHeader, err := rlp.EncodeToBytes(block.HeaderNoCopy())
+	if err != nil {
+		return nil, err
+	}
+	getBlockRes["rawHeader"] = rawHeader
+	if getBlockRes["raw"], err = rlp.EncodeToBytes(block); err != nil {
+		return nil, err
+	}
+	if chainConfig.IsLondon(block.NumberU64() + 1) {
+		getBlockRes["nextBaseFeePerGas"] = (*hexutil.Big)(misc.CalcBaseFee(chainConfig, block.HeaderNoCopy()))
+	}
+
+	// Ommers have the total difficulty of the including block, the same as returned by eth_getUncleByBlockNumberAndIndex
+	ommers := make([]map[string]interface{}, 0, len(block.Uncles()))
+	for _, uncle := range block.Uncles() {
+		ommer, err := ethapi.RPCMarshalBlock(types.NewBlockWithHeader(uncle), false, false, map[string]interface{}{"totalDifficulty": getBlockRes["totalDifficulty"]})
+		if err != nil {
+			return nil, err
+		}
+		if ommer["rawHeader"], err = rlp.EncodeToBytes(uncle); err != nil {
+			return nil, err
+		}
+		ommers = append(ommers, ommer)
+	}
+
 	response := map[string]interface{}{}
 	response["block"] = getBlockRes
 	response["receipts"] = result
+	response["ommers"] = ommers
 
 	// Withdrawals
 	wresult := make([]map[string]interface{}, 0, len(block.Withdrawals()))
@@ -128,6 +185,162 @@ func (api *GraphQLAPIImpl) GetBlockDetails(ctx context.Context, blockNumber rpc.
 	return response, nil
 }
 
+// GetBlockNumberByHash returns the number of the block, nil if the block is not found.
+func (api *GraphQLAPIImpl) GetBlockNumberByHash(ctx context.Context, hash common.Hash) (*uint64, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return rawdb.ReadHeaderNumber(tx, hash), nil
+}
+
+// GetTransactionBlockNumber returns the number of the block including the transaction, nil if the transaction
+// is not found.
+func (api *GraphQLAPIImpl) GetTransactionBlockNumber(ctx context.Context, hash common.Hash) (*uint64, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blockNum, ok, err := api.txnLookup(ctx, tx, hash)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &blockNum, nil
+}
+
+// GetPendingTransactions returns transactions of the pending block, in the same form as GetBlockDetails
+// returns mined ones, without receipt fields.
+func (api *GraphQLAPIImpl) GetPendingTransactions(ctx context.Context) ([]map[string]interface{}, error) {
+	block := api.pendingBlock()
+	if block == nil {
+		return nil, nil
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.MakeSigner(chainConfig, block.NumberU64(), block.Time())
+
+	result := make([]map[string]interface{}, 0, len(block.Transactions()))
+	for _, txn := range block.Transactions() {
+		from, err := txn.Sender(*signer)
+		if err != nil {
+			return nil, err
+		}
+		transaction := map[string]interface{}{
+			"transactionHash": txn.Hash(),
+			"from":            from,
+			"to":              txn.GetTo(),
+			"type":            hexutil.Uint(txn.Type()),
+		}
+		if err := marshalGraphQLTransaction(transaction, txn, nil); err != nil {
+			return nil, err
+		}
+		result = append(result, transaction)
+	}
+	return result, nil
+}
+
+// marshalGraphQLTransaction adds the fields of the transaction itself to its receipt fields.
+func marshalGraphQLTransaction(fields map[string]interface{}, txn types.Transaction, baseFee *big.Int) error {
+	fields["nonce"] = txn.GetNonce()
+	fields["value"] = txn.GetValue()
+	fields["data"] = txn.GetData()
+	fields["gas"] = hexutil.Uint64(txn.GetGas())
+	// the price offered, mined transactions report effective gas price instead
+	fields["gasPrice"] = (*hexutil.Big)(txn.GetPrice().ToBig())
+	if txn.Type() >= types.DynamicFeeTxType {
+		fields["gasPrice"] = (*hexutil.Big)(txn.GetFeeCap().ToBig())
+		fields["maxFeePerGas"] = (*hexutil.Big)(txn.GetFeeCap().ToBig())
+		fields["maxPriorityFeePerGas"] = (*hexutil.Big)(txn.GetTip().ToBig())
+	}
+	if baseFee != nil {
+		fields["effectiveTip"] = (*hexutil.Big)(txn.GetEffectiveGasTip(uint256.MustFromBig(baseFee)).ToBig())
+	}
+	v, r, s := txn.RawSignatureValues()
+	fields["v"] = (*hexutil.Big)(v.ToBig())
+	fields["r"] = (*hexutil.Big)(r.ToBig())
+	fields["s"] = (*hexutil.Big)(s.ToBig())
+	if txn.Type() != types.LegacyTxType {
+		fields["accessList"] = txn.GetAccessList()
+	}
+
+	var raw bytes.Buffer
+	if err := txn.MarshalBinary(&raw); err != nil {
+		return err
+	}
+	fields["raw"] = raw.Bytes()
+	return nil
+}
+
+func (api *GraphQLAPIImpl) BlockNumber(ctx context.Context) (hexutil.Uint64, error) {
+	return api.eth.BlockNumber(ctx)
+}
+
+func (api *GraphQLAPIImpl) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	return api.eth.GetBalance(ctx, address, blockNrOrHash)
+}
+
+func (api *GraphQLAPIImpl) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	return api.eth.GetTransactionCount(ctx, address, blockNrOrHash)
+}
+
+func (api *GraphQLAPIImpl) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error) {
+	return api.eth.GetCode(ctx, address, blockNrOrHash)
+}
+
+func (api *GraphQLAPIImpl) GetStorageAt(ctx context.Context, address common.Address, index string, blockNrOrHash rpc.BlockNumberOrHash) (string, error) {
+	return api.eth.GetStorageAt(ctx, address, index, blockNrOrHash)
+}
+
+// Call executes the call like eth_call does, but returns the whole execution result: failed calls are not
+// errors, their status is reported along with the return data.
+func (api *GraphQLAPIImpl) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (*evmtypes.ExecutionResult, error) {
+	result, err := api.eth.doCall(ctx, args, blockNrOrHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("block %v not found", blockNrOrHash)
+	}
+	return result, nil
+}
+
+func (api *GraphQLAPIImpl) EstimateGas(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	return api.eth.EstimateGas(ctx, &args, &blockNrOrHash)
+}
+
+func (api *GraphQLAPIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.Logs, error) {
+	return api.eth.GetLogs(ctx, crit)
+}
+
+func (api *GraphQLAPIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	return api.eth.GasPrice(ctx)
+}
+
+func (api *GraphQLAPIImpl) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	return api.eth.MaxPriorityFeePerGas(ctx)
+}
+
+func (api *GraphQLAPIImpl) Syncing(ctx context.Context) (interface{}, error) {
+	return api.eth.Syncing(ctx)
+}
+
+func (api *GraphQLAPIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutility.Bytes) (common.Hash, error) {
+	return api.eth.SendRawTransaction(ctx, encodedTx)
+}
+
 func (api *GraphQLAPIImpl) getBlockWithSenders(ctx context.Context, number rpc.BlockNumber, tx kv.Tx) (*types.Block, []common.Address, error) {
 	if number == rpc.PendingBlockNumber {
 		return api.pendingBlock(), nil, nil
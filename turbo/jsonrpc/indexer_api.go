@@ -0,0 +1,175 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/indexer"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+)
+
+const maxIndexerPageSize = 1000
+
+// IndexerAPI serves data of user-defined indexers built by the CustomTrace stage, see package turbo/indexer.
+type IndexerAPI interface {
+	List(ctx context.Context) (map[string]hexutil.Uint64, error)
+	GetTransactions(ctx context.Context, name string, key hexutility.Bytes, fromBlock, toBlock *rpc.BlockNumber, pageSize *int) ([]*IndexedTransaction, error)
+	GetValue(ctx context.Context, name string, key hexutility.Bytes) (hexutility.Bytes, error)
+}
+
+type IndexerAPIImpl struct {
+	*BaseAPI
+	db kv.RoDB
+}
+
+func NewIndexerAPI(base *BaseAPI, db kv.RoDB) *IndexerAPIImpl {
+	return &IndexerAPIImpl{
+		BaseAPI: base,
+		db:      db,
+	}
+}
+
+type IndexedTransaction struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	TxIndex     hexutil.Uint64 `json:"transactionIndex"`
+	TxHash      common.Hash    `json:"transactionHash"`
+}
+
+// List returns indexers found in the db with the last block processed by each of them.
+func (api *IndexerAPIImpl) List(ctx context.Context) (map[string]hexutil.Uint64, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	progresses, err := indexer.Progresses(tx)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]hexutil.Uint64, len(progresses))
+	for name, progress := range progresses {
+		res[name] = hexutil.Uint64(progress)
+	}
+	return res, nil
+}
+
+// GetTransactions returns txns of blocks [fromBlock, toBlock] found in the inverted index of the indexer by the key,
+// oldest first. By default, all blocks are searched and at most maxIndexerPageSize txns are returned.
+func (api *IndexerAPIImpl) GetTransactions(ctx context.Context, name string, key hexutility.Bytes, fromBlock, toBlock *rpc.BlockNumber, pageSize *int) ([]*IndexedTransaction, error) {
+	if err := indexer.CheckName(name); err != nil {
+		return nil, err
+	}
+	limit := maxIndexerPageSize
+	if pageSize != nil {
+		if *pageSize <= 0 || *pageSize > maxIndexerPageSize {
+			return nil, fmt.Errorf("pageSize must be 1-%d", maxIndexerPageSize)
+		}
+		limit = *pageSize
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	from, to := rpc.EarliestBlockNumber, rpc.LatestBlockNumber
+	if fromBlock != nil {
+		from = *fromBlock
+	}
+	if toBlock != nil {
+		to = *toBlock
+	}
+	fromNum, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(from), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	toNum, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(to), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if fromNum > toNum {
+		return nil, fmt.Errorf("fromBlock %d is after toBlock %d", fromNum, toNum)
+	}
+	fromTxNum, err := rawdbv3.TxNums.Min(tx, fromNum)
+	if err != nil {
+		return nil, err
+	}
+	toTxNum, err := rawdbv3.TxNums.Max(tx, toNum)
+	if err != nil {
+		return nil, err
+	}
+
+	txNums, err := indexer.IndexRange(tx, name, key, int(fromTxNum), int(toTxNum+1), order.Asc, limit)
+	if err != nil {
+		return nil, err
+	}
+	it := rawdbv3.TxNums2BlockNums(tx, txNums, order.Asc)
+	defer it.Close()
+
+	res := make([]*IndexedTransaction, 0)
+	for it.HasNext() {
+		_, blockNum, txIndex, isFinalTxn, _, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if isFinalTxn || txIndex < 0 {
+			continue
+		}
+		txn, err := api._txnReader.TxnByIdxInBlock(ctx, tx, blockNum, txIndex)
+		if err != nil {
+			return nil, err
+		}
+		if txn == nil {
+			return nil, fmt.Errorf("txn %d of block %d not found", txIndex, blockNum)
+		}
+		res = append(res, &IndexedTransaction{
+			BlockNumber: hexutil.Uint64(blockNum),
+			TxIndex:     hexutil.Uint64(txIndex),
+			TxHash:      txn.Hash(),
+		})
+	}
+	return res, nil
+}
+
+// GetValue returns the latest value of the key in the domain of the indexer, null if there is no value.
+func (api *IndexerAPIImpl) GetValue(ctx context.Context, name string, key hexutility.Bytes) (hexutility.Bytes, error) {
+	if err := indexer.CheckName(name); err != nil {
+		return nil, err
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	v, err := indexer.GetValue(tx, name, key)
+	if err != nil {
+		return nil, err
+	}
+	return common.Copy(v), nil
+}
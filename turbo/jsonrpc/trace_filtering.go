@@ -359,8 +359,7 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 	engine := api.engine()
 
 	var json = jsoniter.ConfigCompatibleWithStandardLibrary
-	stream.WriteArrayStart()
-	first := true
+	as := rpc.NewArrayStream(ctx, stream)
 	// Execute all transactions in picked blocks
 
 	count := uint64(^uint(0)) // this just makes it easier to use below
@@ -388,38 +387,23 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 	for it.HasNext() {
 		txNum, blockNum, txIndex, isFnalTxn, blockNumChanged, err := it.Next()
 		if err != nil {
-			if first {
-				first = false
-			} else {
-				stream.WriteMore()
+			if wErr := as.WriteError(err); wErr != nil {
+				return as.Close(wErr)
 			}
-			stream.WriteObjectStart()
-			rpc.HandleError(err, stream)
-			stream.WriteObjectEnd()
 			continue
 		}
 
 		if blockNumChanged {
 			if lastHeader, err = api._blockReader.HeaderByNumber(ctx, dbtx, blockNum); err != nil {
-				if first {
-					first = false
-				} else {
-					stream.WriteMore()
+				if wErr := as.WriteError(err); wErr != nil {
+					return as.Close(wErr)
 				}
-				stream.WriteObjectStart()
-				rpc.HandleError(err, stream)
-				stream.WriteObjectEnd()
 				continue
 			}
 			if lastHeader == nil {
-				if first {
-					first = false
-				} else {
-					stream.WriteMore()
+				if wErr := as.WriteError(fmt.Errorf("header not found: %d", blockNum)); wErr != nil {
+					return as.Close(wErr)
 				}
-				stream.WriteObjectStart()
-				rpc.HandleError(fmt.Errorf("header not found: %d", blockNum), stream)
-				stream.WriteObjectEnd()
 				continue
 			}
 
@@ -443,14 +427,9 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 
 			body, _, err := api._blockReader.Body(ctx, dbtx, lastBlockHash, blockNum)
 			if err != nil {
-				if first {
-					first = false
-				} else {
-					stream.WriteMore()
+				if wErr := as.WriteError(err); wErr != nil {
+					return as.Close(wErr)
 				}
-				stream.WriteObjectStart()
-				rpc.HandleError(err, stream)
-				stream.WriteObjectEnd()
 				continue
 			}
 			// Block reward section, handle specially
@@ -471,24 +450,18 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 				tr.TraceAddress = []int{}
 				b, err := json.Marshal(tr)
 				if err != nil {
-					if first {
-						first = false
-					} else {
-						stream.WriteMore()
+					if wErr := as.WriteError(err); wErr != nil {
+						return as.Close(wErr)
 					}
-					stream.WriteObjectStart()
-					rpc.HandleError(err, stream)
-					stream.WriteObjectEnd()
 					continue
 				}
 				if nSeen > after && nExported < count {
-					if first {
-						first = false
-					} else {
-						stream.WriteMore()
+					el, err := as.Next()
+					if err != nil {
+						return as.Close(err)
 					}
-					if _, err := stream.Write(b); err != nil {
-						return err
+					if _, err := el.Write(b); err != nil {
+						return as.Close(err)
 					}
 					nExported++
 				}
@@ -511,24 +484,18 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 						tr.TraceAddress = []int{}
 						b, err := json.Marshal(tr)
 						if err != nil {
-							if first {
-								first = false
-							} else {
-								stream.WriteMore()
+							if wErr := as.WriteError(err); wErr != nil {
+								return as.Close(wErr)
 							}
-							stream.WriteObjectStart()
-							rpc.HandleError(err, stream)
-							stream.WriteObjectEnd()
 							continue
 						}
 						if nSeen > after && nExported < count {
-							if first {
-								first = false
-							} else {
-								stream.WriteMore()
+							el, err := as.Next()
+							if err != nil {
+								return as.Close(err)
 							}
-							if _, err := stream.Write(b); err != nil {
-								return err
+							if _, err := el.Write(b); err != nil {
+								return as.Close(err)
 							}
 							nExported++
 						}
@@ -544,14 +511,9 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 		//fmt.Printf("txNum=%d, blockNum=%d, txIndex=%d\n", txNum, blockNum, txIndex)
 		txn, err := api._txnReader.TxnByIdxInBlock(ctx, dbtx, blockNum, txIndex)
 		if err != nil {
-			if first {
-				first = false
-			} else {
-				stream.WriteMore()
+			if wErr := as.WriteError(err); wErr != nil {
+				return as.Close(wErr)
 			}
-			stream.WriteObjectStart()
-			rpc.HandleError(err, stream)
-			stream.WriteObjectEnd()
 			continue
 		}
 		if txn == nil {
@@ -560,14 +522,9 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 		txHash := txn.Hash()
 		msg, err := txn.AsMessage(*lastSigner, lastHeader.BaseFee, lastRules)
 		if err != nil {
-			if first {
-				first = false
-			} else {
-				stream.WriteMore()
+			if wErr := as.WriteError(err); wErr != nil {
+				return as.Close(wErr)
 			}
-			stream.WriteObjectStart()
-			rpc.HandleError(err, stream)
-			stream.WriteObjectEnd()
 			continue
 		}
 
@@ -583,7 +540,7 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 		var ot OeTracer
 		ot.config, err = parseOeTracerConfig(traceConfig)
 		if err != nil {
-			return err
+			return as.Close(err)
 		}
 		ot.compat = api.compatibility
 		ot.r = traceResult
@@ -602,37 +559,22 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 		var execResult *evmtypes.ExecutionResult
 		execResult, err = core.ApplyMessage(evm, msg, gp, true /* refunds */, gasBailOut)
 		if err != nil {
-			if first {
-				first = false
-			} else {
-				stream.WriteMore()
+			if wErr := as.WriteError(err); wErr != nil {
+				return as.Close(wErr)
 			}
-			stream.WriteObjectStart()
-			rpc.HandleError(err, stream)
-			stream.WriteObjectEnd()
 			continue
 		}
 		traceResult.Output = common.Copy(execResult.ReturnData)
 		if err = ibs.FinalizeTx(evm.ChainRules(), noop); err != nil {
-			if first {
-				first = false
-			} else {
-				stream.WriteMore()
+			if wErr := as.WriteError(err); wErr != nil {
+				return as.Close(wErr)
 			}
-			stream.WriteObjectStart()
-			rpc.HandleError(err, stream)
-			stream.WriteObjectEnd()
 			continue
 		}
 		if err = ibs.CommitBlock(evm.ChainRules(), cachedWriter); err != nil {
-			if first {
-				first = false
-			} else {
-				stream.WriteMore()
+			if wErr := as.WriteError(err); wErr != nil {
+				return as.Close(wErr)
 			}
-			stream.WriteObjectStart()
-			rpc.HandleError(err, stream)
-			stream.WriteObjectEnd()
 			continue
 		}
 		isIntersectionMode := req.Mode == TraceFilterModeIntersection
@@ -645,32 +587,25 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 				pt.TransactionPosition = &txIndexU64
 				b, err := json.Marshal(pt)
 				if err != nil {
-					if first {
-						first = false
-					} else {
-						stream.WriteMore()
+					if wErr := as.WriteError(err); wErr != nil {
+						return as.Close(wErr)
 					}
-					stream.WriteObjectStart()
-					rpc.HandleError(err, stream)
-					stream.WriteObjectEnd()
 					continue
 				}
 				if nSeen > after && nExported < count {
-					if first {
-						first = false
-					} else {
-						stream.WriteMore()
+					el, err := as.Next()
+					if err != nil {
+						return as.Close(err)
 					}
-					if _, err := stream.Write(b); err != nil {
-						return err
+					if _, err := el.Write(b); err != nil {
+						return as.Close(err)
 					}
 					nExported++
 				}
 			}
 		}
 	}
-	stream.WriteArrayEnd()
-	return stream.Flush()
+	return as.Close(nil)
 }
 
 func filterTrace(pt *ParityTrace, fromAddresses map[common.Address]struct{}, toAddresses map[common.Address]struct{}, isIntersectionMode bool) bool {
@@ -110,7 +110,7 @@ func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rp
 
 	signer := types.MakeSigner(chainConfig, block.NumberU64(), block.Time())
 	rules := chainConfig.Rules(block.NumberU64(), block.Time())
-	stream.WriteArrayStart()
+	as := rpc.NewArrayStream(ctx, stream)
 
 	txns := block.Transactions()
 	var borStateSyncTxn types.Transaction
@@ -118,8 +118,7 @@ func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rp
 		borStateSyncTxHash := bortypes.ComputeBorTxHash(block.NumberU64(), block.Hash())
 		_, ok, err := api._blockReader.EventLookup(ctx, tx, borStateSyncTxHash)
 		if err != nil {
-			stream.WriteArrayEnd()
-			return err
+			return as.Close(err)
 		}
 		if ok {
 			borStateSyncTxn = bortypes.NewBorTransaction()
@@ -136,19 +135,14 @@ func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rp
 			txnHash = txn.Hash()
 		}
 
+		if _, err := as.Next(); err != nil {
+			return as.Close(err)
+		}
 		stream.WriteObjectStart()
 		stream.WriteObjectField("txHash")
 		stream.WriteString(txnHash.Hex())
 		stream.WriteMore()
 		stream.WriteObjectField("result")
-		select {
-		default:
-		case <-ctx.Done():
-			stream.WriteNil()
-			stream.WriteObjectEnd()
-			stream.WriteArrayEnd()
-			return ctx.Err()
-		}
 		ibs.SetTxContext(txnHash, block.Hash(), idx)
 		msg, _ := txn.AsMessage(*signer, block.BaseFee(), rules)
 
@@ -195,21 +189,8 @@ func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rp
 		}
 
 		stream.WriteObjectEnd()
-		if idx != len(txns)-1 {
-			stream.WriteMore()
-		}
-
-		if err := stream.Flush(); err != nil {
-			return err
-		}
-	}
-
-	stream.WriteArrayEnd()
-	if err := stream.Flush(); err != nil {
-		return err
 	}
-
-	return nil
+	return as.Close(nil)
 }
 
 // TraceTransaction implements debug_traceTransaction. Returns Geth style transaction traces.
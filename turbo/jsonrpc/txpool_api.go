@@ -19,6 +19,7 @@ package jsonrpc
 import (
 	"context"
 	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/erigontech/erigon-lib/common/hexutil"
@@ -27,6 +28,7 @@ import (
 	"github.com/erigontech/erigon-lib/gointerfaces"
 	proto_txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
 	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/txpool/txpoolcfg"
 
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
@@ -36,6 +38,8 @@ import (
 type TxPoolAPI interface {
 	Content(ctx context.Context) (map[string]map[string]map[string]*RPCTransaction, error)
 	ContentFrom(ctx context.Context, addr libcommon.Address) (map[string]map[string]*RPCTransaction, error)
+	GetTransactionStatus(ctx context.Context, hash libcommon.Hash) (*TxPoolTransactionStatus, error)
+	DiscardHistory(ctx context.Context, sender *libcommon.Address, fromTimestamp *hexutil.Uint64, limit *hexutil.Uint) ([]*TxPoolDiscardEvent, error)
 }
 
 // TxPoolAPIImpl data structure to store things needed for net_ commands
@@ -218,6 +222,96 @@ func (api *TxPoolAPIImpl) Status(ctx context.Context) (map[string]hexutil.Uint,
 	}, nil
 }
 
+// maximum number of events returned by a single txpool_discardHistory call
+const discardHistoryMaxLimit = 10_000
+
+// TxPoolDiscardEvent is a transaction which was evicted from the pool or replaced by another one
+type TxPoolDiscardEvent struct {
+	Hash        libcommon.Hash    `json:"hash"`
+	Sender      libcommon.Address `json:"sender"`
+	Nonce       hexutil.Uint64    `json:"nonce"`
+	Reason      string            `json:"reason"`
+	ReplacedBy  *libcommon.Hash   `json:"replacedBy,omitempty"`
+	BlockNumber hexutil.Uint64    `json:"blockNumber"`
+	Timestamp   hexutil.Uint64    `json:"timestamp"`
+}
+
+// TxPoolTransactionStatus is the state of a transaction in the pool: "pending", "baseFee" or "queued" for
+// pooled transactions, "discarded" with the discard event for evicted ones, or "unknown"
+type TxPoolTransactionStatus struct {
+	Status  string              `json:"status"`
+	Discard *TxPoolDiscardEvent `json:"discard,omitempty"`
+}
+
+func newTxPoolDiscardEvent(e *proto_txpool.DiscardEvent) *TxPoolDiscardEvent {
+	res := &TxPoolDiscardEvent{
+		Hash:        gointerfaces.ConvertH256ToHash(e.Hash),
+		Sender:      gointerfaces.ConvertH160toAddress(e.Sender),
+		Nonce:       hexutil.Uint64(e.Nonce),
+		Reason:      discardReasonString(e.Reason),
+		BlockNumber: hexutil.Uint64(e.BlockNumber),
+		Timestamp:   hexutil.Uint64(e.Timestamp),
+	}
+	if e.ReplacedBy != nil {
+		replacedBy := libcommon.Hash(gointerfaces.ConvertH256ToHash(e.ReplacedBy))
+		res.ReplacedBy = &replacedBy
+	}
+	return res
+}
+
+// discardReasonString - reason comes from the pool over gRPC, it may be unknown to this rpcdaemon
+func discardReasonString(r uint32) string {
+	if r > math.MaxUint8 {
+		return fmt.Sprintf("unknown(%d)", r)
+	}
+	return txpoolcfg.DiscardReason(r).String()
+}
+
+// GetTransactionStatus returns the sub-pool of the transaction or, if it isn't in the pool anymore, why it was discarded.
+func (api *TxPoolAPIImpl) GetTransactionStatus(ctx context.Context, hash libcommon.Hash) (*TxPoolTransactionStatus, error) {
+	reply, err := api.pool.TransactionStatus(ctx, &proto_txpool.TransactionStatusRequest{Hash: gointerfaces.ConvertHashToH256(hash)})
+	if err != nil {
+		return nil, err
+	}
+	switch reply.Status {
+	case proto_txpool.TransactionStatusReply_PENDING:
+		return &TxPoolTransactionStatus{Status: "pending"}, nil
+	case proto_txpool.TransactionStatusReply_BASE_FEE:
+		return &TxPoolTransactionStatus{Status: "baseFee"}, nil
+	case proto_txpool.TransactionStatusReply_QUEUED:
+		return &TxPoolTransactionStatus{Status: "queued"}, nil
+	case proto_txpool.TransactionStatusReply_DISCARDED:
+		return &TxPoolTransactionStatus{Status: "discarded", Discard: newTxPoolDiscardEvent(reply.Discard)}, nil
+	default:
+		return &TxPoolTransactionStatus{Status: "unknown"}, nil
+	}
+}
+
+// DiscardHistory returns the discard and replacement events kept by the pool, oldest first.
+// Optionally filtered by sender and by the unix timestamp of the earliest event. At most discardHistoryMaxLimit
+// events are returned, a missing or zero limit means the maximum.
+func (api *TxPoolAPIImpl) DiscardHistory(ctx context.Context, sender *libcommon.Address, fromTimestamp *hexutil.Uint64, limit *hexutil.Uint) ([]*TxPoolDiscardEvent, error) {
+	req := &proto_txpool.DiscardHistoryRequest{Limit: discardHistoryMaxLimit}
+	if sender != nil {
+		req.Sender = gointerfaces.ConvertAddressToH160(*sender)
+	}
+	if fromTimestamp != nil {
+		req.FromTimestamp = uint64(*fromTimestamp)
+	}
+	if limit != nil && *limit > 0 && uint64(*limit) < discardHistoryMaxLimit {
+		req.Limit = uint32(*limit)
+	}
+	reply, err := api.pool.DiscardHistory(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*TxPoolDiscardEvent, len(reply.Events))
+	for i, e := range reply.Events {
+		events[i] = newTxPoolDiscardEvent(e)
+	}
+	return events, nil
+}
+
 /*
 
 // Inspect retrieves the content of the transaction pool and flattens it into an
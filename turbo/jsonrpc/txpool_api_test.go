@@ -28,6 +28,7 @@ import (
 	"github.com/erigontech/erigon-lib/common/hexutil"
 	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
 	"github.com/erigontech/erigon-lib/kv/kvcache"
+	"github.com/erigontech/erigon-lib/txpool/txpoolcfg"
 
 	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/erigontech/erigon/core"
@@ -79,3 +80,63 @@ func TestTxPoolContent(t *testing.T) {
 	require.Equal(status["pending"], hexutil.Uint(1))
 	require.Equal(status["queued"], hexutil.Uint(0))
 }
+
+func TestTxPoolDiscardHistory(t *testing.T) {
+	m, require := mock.MockWithTxPool(t), require.New(t)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 1, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(libcommon.Address{1})
+	})
+	require.NoError(err)
+	err = m.InsertChain(chain)
+	require.NoError(err)
+
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	txPool := txpool.NewTxpoolClient(conn)
+	ff := rpchelper.New(ctx, rpchelper.DefaultFiltersConfig, nil, txPool, txpool.NewMiningClient(conn), func() {}, m.Log)
+	api := NewTxPoolAPI(NewBaseApi(ff, kvcache.New(kvcache.DefaultCoherentConfig), m.BlockReader, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs), m.DB, txPool)
+
+	signer := types.LatestSignerForChainID(m.ChainConfig.ChainID)
+	var hashes []libcommon.Hash
+	for _, gasPrice := range []uint64{10 * params.GWei, 20 * params.GWei} {
+		txn, err := types.SignTx(types.NewTransaction(0, libcommon.Address{1}, uint256.NewInt(1234), params.TxGas, uint256.NewInt(gasPrice), nil), *signer, m.Key)
+		require.NoError(err)
+		buf := bytes.NewBuffer(nil)
+		require.NoError(txn.MarshalBinary(buf))
+		reply, err := txPool.Add(ctx, &txpool.AddRequest{RlpTxs: [][]byte{buf.Bytes()}})
+		require.NoError(err)
+		require.Equal(txpool.ImportResult_SUCCESS, reply.Imported[0], fmt.Sprintf("%s", reply.Errors))
+		hashes = append(hashes, txn.Hash())
+	}
+
+	status, err := api.GetTransactionStatus(ctx, hashes[1])
+	require.NoError(err)
+	require.Equal("pending", status.Status)
+	require.Nil(status.Discard)
+
+	status, err = api.GetTransactionStatus(ctx, hashes[0])
+	require.NoError(err)
+	require.Equal("discarded", status.Status)
+	require.Equal(txpoolcfg.ReplacedByHigherTip.String(), status.Discard.Reason)
+	require.Equal(hashes[1], *status.Discard.ReplacedBy)
+	require.Equal(m.Address, status.Discard.Sender)
+
+	status, err = api.GetTransactionStatus(ctx, libcommon.Hash{1})
+	require.NoError(err)
+	require.Equal("unknown", status.Status)
+
+	events, err := api.DiscardHistory(ctx, &m.Address, nil, nil)
+	require.NoError(err)
+	require.Len(events, 1)
+	require.Equal(hashes[0], events[0].Hash)
+	zero := hexutil.Uint(0)
+	events, err = api.DiscardHistory(ctx, &m.Address, nil, &zero)
+	require.NoError(err)
+	require.Len(events, 1)
+	events, err = api.DiscardHistory(ctx, &libcommon.Address{1}, nil, nil)
+	require.NoError(err)
+	require.Empty(events)
+
+	// reasons added to a newer pool are reported, not panicked on
+	require.Equal("unknown(200)", discardReasonString(200))
+	require.Equal("unknown(300)", discardReasonString(300))
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
@@ -103,7 +104,11 @@ func TraceTx(
 	stream *jsoniter.Stream,
 	callTimeout time.Duration,
 ) error {
-	tracer, streaming, cancel, err := AssembleTracer(ctx, config, txCtx.TxHash, stream, callTimeout)
+	tracerCtx := &tracers.Context{TxHash: txCtx.TxHash, BlockNumber: new(big.Int).SetUint64(blockCtx.BlockNumber)}
+	if txIbs, ok := ibs.(*state.IntraBlockState); ok {
+		tracerCtx.BlockHash, tracerCtx.TxIndex = txIbs.BlockHash(), txIbs.TxIndex()
+	}
+	tracer, streaming, cancel, err := AssembleTracer(ctx, config, tracerCtx, stream, callTimeout)
 	if err != nil {
 		stream.WriteNil()
 		return err
@@ -122,7 +127,7 @@ func TraceTx(
 func AssembleTracer(
 	ctx context.Context,
 	config *tracersConfig.TraceConfig,
-	txHash libcommon.Hash,
+	tracerCtx *tracers.Context,
 	stream *jsoniter.Stream,
 	callTimeout time.Duration,
 ) (vm.EVMLogger, bool, context.CancelFunc, error) {
@@ -144,7 +149,7 @@ func AssembleTracer(
 		if config != nil && config.TracerConfig != nil {
 			cfg = *config.TracerConfig
 		}
-		tracer, err := tracers.New(*config.Tracer, &tracers.Context{TxHash: txHash}, cfg)
+		tracer, err := tracers.New(*config.Tracer, tracerCtx, cfg)
 		if err != nil {
 			return nil, false, func() {}, err
 		}
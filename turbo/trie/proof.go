@@ -311,9 +311,9 @@ func VerifyAccountProofByHash(stateRoot libcommon.Hash, accountKey libcommon.Has
 			return errors.New("account is not in state, but has non-zero nonce")
 		case proof.Balance.ToInt().Sign() != 0:
 			return errors.New("account is not in state, but has balance")
-		case proof.StorageHash != libcommon.Hash{}:
+		case proof.StorageHash != libcommon.Hash{} && proof.StorageHash != EmptyRoot:
 			return errors.New("account is not in state, but has non-empty storage hash")
-		case proof.CodeHash != libcommon.Hash{}:
+		case proof.CodeHash != libcommon.Hash{} && proof.CodeHash != EmptyCodeHash:
 			return errors.New("account is not in state, but has non-empty code hash")
 		default:
 			return nil